@@ -1,6 +1,7 @@
 package seth
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -18,12 +19,21 @@ type ABIFinderResult struct {
 	Method         *abi.Method
 	DuplicateCount int
 	contractName   string
+	isInterface    bool
 }
 
 func (a *ABIFinderResult) ContractName() string {
 	return strings.TrimSuffix(a.contractName, ".abi")
 }
 
+// InterfaceName returns name of the interface ABI used to decode the call, if contract's own ABI wasn't found, otherwise it's empty
+func (a *ABIFinderResult) InterfaceName() string {
+	if !a.isInterface {
+		return ""
+	}
+	return a.ContractName()
+}
+
 func NewABIFinder(contractMap ContractMap, contractStore *ContractStore) ABIFinder {
 	return ABIFinder{
 		ContractMap:   contractMap,
@@ -35,10 +45,16 @@ func NewABIFinder(contractMap ContractMap, contractStore *ContractStore) ABIFind
 // If the contract address is known, it will use the ABI instance that is known to be at the address.
 // If the contract address is not known, it will iterate over all known ABIs and check if any of them
 // has a method with the given signature. If there are duplicates we will use the first ABI that matched.
+// If no contract ABI has the method, interface ABIs are checked as the last resort.
 func (a *ABIFinder) FindABIByMethod(address string, signature []byte) (ABIFinderResult, error) {
 	result := ABIFinderResult{}
 	stringSignature := common.Bytes2Hex(signature)
 
+	// contracts deployed from raw bytecode have no ABI, a matching method of another ABI would be a false positive
+	if a.ContractMap.IsRawBytecode(address) {
+		return ABIFinderResult{}, fmt.Errorf("%s: %s (%s)", ErrNoABIMethod, ErrRawBytecode, address)
+	}
+
 	// let's start by checking if we already know what contract is at the address being called,
 	// so that we don't have to search all known ABIs. If we have a match, let's double check
 	// that it's correct. If it's not we will stop and return an error
@@ -72,7 +88,10 @@ func (a *ABIFinder) FindABIByMethod(address string, signature []byte) (ABIFinder
 						Str("Signature", stringSignature).
 						Msgf("Updating contract mapping as previous one was based on non-unique method signature")
 
-					a.ContractMap.AddContract(address, correctedContractName)
+					// contract deployed at this address was verified by its code hash, so the mapping is correct
+					if !a.ContractMap.isVerifiedDeployment(address) {
+						a.ContractMap.AddContract(address, correctedContractName)
+					}
 
 					result.Method = correctedMethod
 					result.ABI = correctedAbi
@@ -83,6 +102,10 @@ func (a *ABIFinder) FindABIByMethod(address string, signature []byte) (ABIFinder
 				}
 			}
 
+			if interfaceResult, ok := a.ContractStore.findInterfaceMethod(signature); ok {
+				return interfaceResult, nil
+			}
+
 			L.Err(err).
 				Str("Signature", stringSignature).
 				Str("Supposed contract", contractName).
@@ -126,6 +149,9 @@ func (a *ABIFinder) FindABIByMethod(address string, signature []byte) (ABIFinder
 		}
 
 		if result.Method == nil {
+			if interfaceResult, ok := a.ContractStore.findInterfaceMethod(signature); ok {
+				return interfaceResult, nil
+			}
 			return ABIFinderResult{}, errors.New(ErrNoABIMethod)
 		}
 	}
@@ -0,0 +1,327 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrEncodePacked = "failed to encode packed data"
+	ErrDecodePacked = "failed to decode packed data"
+)
+
+// EncodePacked encodes values the same way as Solidity's abi.encodePacked: static types use as many bytes as they need
+// (e.g. 1 byte for uint8 or bool, 20 bytes for address), strings and bytes are encoded without length and elements of
+// arrays are padded to 32 bytes. Types are Solidity type names, e.g.:
+//
+//	data, err := seth.EncodePacked([]string{"address", "uint256", "string"}, receiver, big.NewInt(5), "memo")
+//
+// Integers can be passed as *big.Int or any Go integer type, fixed bytes as byte arrays or slices of the right length.
+// Structs and arrays of dynamic types aren't supported, the same as in Solidity.
+func EncodePacked(types []string, values ...interface{}) ([]byte, error) {
+	if len(types) != len(values) {
+		return nil, fmt.Errorf("%s: got %d types, but %d values", ErrEncodePacked, len(types), len(values))
+	}
+
+	var packed []byte
+	for i, typeName := range types {
+		t, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrEncodePacked)
+		}
+		encoded, err := encodePackedValue(t, reflect.ValueOf(values[i]), false)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: value %d (%s)", ErrEncodePacked, i, typeName)
+		}
+		packed = append(packed, encoded...)
+	}
+
+	return packed, nil
+}
+
+// KeccakPacked returns keccak256 hash of packed values, the same as Solidity's keccak256(abi.encodePacked(...)), which is
+// commonly used for signed messages and Merkle tree leaves
+func KeccakPacked(types []string, values ...interface{}) (common.Hash, error) {
+	packed, err := EncodePacked(types, values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return crypto.Keccak256Hash(packed), nil
+}
+
+// DecodePacked decodes data encoded with abi.encodePacked into values of Go types used by go-ethereum's ABI decoder (e.g.
+// uint8 for uint8 and *big.Int for uint256). Packed encoding has no lengths, so at most one value can have dynamic size
+// (string, bytes or dynamic array), its size is whatever is left after all static values.
+func DecodePacked(types []string, data []byte) ([]interface{}, error) {
+	parsed := make([]abi.Type, 0, len(types))
+	dynamicIdx := -1
+	staticSize := 0
+	for i, typeName := range types {
+		t, err := abi.NewType(typeName, "", nil)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrDecodePacked)
+		}
+		size, err := packedSize(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: value %d (%s)", ErrDecodePacked, i, typeName)
+		}
+		if size < 0 {
+			if dynamicIdx != -1 {
+				return nil, fmt.Errorf("%s: only one dynamic type is supported, but both %s and %s are dynamic", ErrDecodePacked, types[dynamicIdx], typeName)
+			}
+			dynamicIdx = i
+		} else {
+			staticSize += size
+		}
+		parsed = append(parsed, t)
+	}
+
+	dynamicSize := len(data) - staticSize
+	if dynamicSize < 0 || (dynamicIdx == -1 && dynamicSize != 0) {
+		return nil, fmt.Errorf("%s: expected %d bytes of static values, but got %d bytes", ErrDecodePacked, staticSize, len(data))
+	}
+
+	values := make([]interface{}, 0, len(parsed))
+	offset := 0
+	for i, t := range parsed {
+		size, _ := packedSize(t)
+		if i == dynamicIdx {
+			size = dynamicSize
+		}
+		value, err := decodePackedValue(t, data[offset:offset+size])
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: value %d (%s)", ErrDecodePacked, i, types[i])
+		}
+		values = append(values, value)
+		offset += size
+	}
+
+	return values, nil
+}
+
+// encodePackedValue encodes a single value, inArray pads it to 32 bytes as Solidity does for elements of arrays
+func encodePackedValue(t abi.Type, value reflect.Value, inArray bool) ([]byte, error) {
+	for value.IsValid() && value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	if !value.IsValid() {
+		return nil, errors.New("value is nil")
+	}
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := packedInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		size := t.Size / 8
+		if inArray {
+			size = 32
+		}
+		return encodePackedInteger(n, t, size)
+	case abi.BoolTy:
+		if value.Kind() != reflect.Bool {
+			return nil, fmt.Errorf("can't encode %s as bool", value.Type())
+		}
+		b := byte(0)
+		if value.Bool() {
+			b = 1
+		}
+		if inArray {
+			return common.LeftPadBytes([]byte{b}, 32), nil
+		}
+		return []byte{b}, nil
+	case abi.AddressTy:
+		address, ok := value.Interface().(common.Address)
+		if !ok {
+			return nil, fmt.Errorf("can't encode %s as address", value.Type())
+		}
+		if inArray {
+			return common.LeftPadBytes(address.Bytes(), 32), nil
+		}
+		return address.Bytes(), nil
+	case abi.FixedBytesTy, abi.HashTy:
+		b, err := packedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("expected %d bytes, but got %d", t.Size, len(b))
+		}
+		if inArray {
+			return common.RightPadBytes(b, 32), nil
+		}
+		return b, nil
+	case abi.StringTy, abi.BytesTy:
+		if inArray {
+			return nil, fmt.Errorf("arrays of %s are not supported by packed encoding", t.String())
+		}
+		if t.T == abi.StringTy {
+			if value.Kind() != reflect.String {
+				return nil, fmt.Errorf("can't encode %s as string", value.Type())
+			}
+			return []byte(value.String()), nil
+		}
+		return packedBytes(value)
+	case abi.SliceTy, abi.ArrayTy:
+		if inArray {
+			return nil, fmt.Errorf("nested arrays are not supported by packed encoding")
+		}
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+			return nil, fmt.Errorf("can't encode %s as %s", value.Type(), t.String())
+		}
+		if t.T == abi.ArrayTy && value.Len() != t.Size {
+			return nil, fmt.Errorf("expected %d elements, but got %d", t.Size, value.Len())
+		}
+		var packed []byte
+		for i := 0; i < value.Len(); i++ {
+			encoded, err := encodePackedValue(*t.Elem, value.Index(i), true)
+			if err != nil {
+				return nil, errors.Wrapf(err, "element %d", i)
+			}
+			packed = append(packed, encoded...)
+		}
+		return packed, nil
+	default:
+		return nil, fmt.Errorf("type %s is not supported by packed encoding", t.String())
+	}
+}
+
+func encodePackedInteger(n *big.Int, t abi.Type, size int) ([]byte, error) {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return nil, fmt.Errorf("%s doesn't fit into %s", n.String(), t.String())
+		}
+		return common.LeftPadBytes(n.Bytes(), size), nil
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return nil, fmt.Errorf("%s doesn't fit into %s", n.String(), t.String())
+	}
+	// two's complement in 32 bytes, trimmed to the size
+	word := math.U256Bytes(new(big.Int).Set(n))
+
+	return word[32-size:], nil
+}
+
+func decodePackedValue(t abi.Type, data []byte) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		word := common.LeftPadBytes(data, 32)
+		if t.T == abi.IntTy && len(data) < 32 && len(data) > 0 && data[0]&0x80 != 0 {
+			// sign-extend negative values
+			for i := 0; i < 32-len(data); i++ {
+				word[i] = 0xff
+			}
+		}
+		return abi.ReadInteger(t, word)
+	case abi.BoolTy:
+		if data[len(data)-1] > 1 {
+			return nil, fmt.Errorf("invalid bool value %d", data[len(data)-1])
+		}
+		return data[len(data)-1] == 1, nil
+	case abi.AddressTy:
+		return common.BytesToAddress(data), nil
+	case abi.FixedBytesTy:
+		return abi.ReadFixedBytes(t, data)
+	case abi.HashTy:
+		return common.BytesToHash(data), nil
+	case abi.StringTy:
+		return string(data), nil
+	case abi.BytesTy:
+		return common.CopyBytes(data), nil
+	case abi.SliceTy, abi.ArrayTy:
+		if len(data)%32 != 0 {
+			return nil, fmt.Errorf("array data length %d is not a multiple of 32", len(data))
+		}
+		elemSize, _ := packedSize(*t.Elem)
+		var result reflect.Value
+		if t.T == abi.ArrayTy {
+			result = reflect.New(t.GetType()).Elem()
+		} else {
+			result = reflect.MakeSlice(t.GetType(), len(data)/32, len(data)/32)
+		}
+		for i := 0; i < len(data)/32; i++ {
+			word := data[i*32 : (i+1)*32]
+			// elements are padded to 32 bytes, fixed bytes on the right and everything else on the left
+			if t.Elem.T == abi.FixedBytesTy {
+				word = word[:elemSize]
+			} else if t.Elem.T != abi.IntTy && t.Elem.T != abi.UintTy {
+				word = word[32-elemSize:]
+			}
+			value, err := decodePackedValue(*t.Elem, word)
+			if err != nil {
+				return nil, errors.Wrapf(err, "element %d", i)
+			}
+			result.Index(i).Set(reflect.ValueOf(value))
+		}
+		return result.Interface(), nil
+	default:
+		return nil, fmt.Errorf("type %s is not supported by packed encoding", t.String())
+	}
+}
+
+// packedSize returns number of bytes used by packed value of given type or -1 if its size is dynamic
+func packedSize(t abi.Type) (int, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		return t.Size / 8, nil
+	case abi.BoolTy:
+		return 1, nil
+	case abi.AddressTy:
+		return common.AddressLength, nil
+	case abi.FixedBytesTy, abi.HashTy:
+		return t.Size, nil
+	case abi.StringTy, abi.BytesTy:
+		return -1, nil
+	case abi.SliceTy, abi.ArrayTy:
+		if elemSize, err := packedSize(*t.Elem); err != nil || elemSize < 0 || t.Elem.T == abi.SliceTy || t.Elem.T == abi.ArrayTy {
+			return 0, fmt.Errorf("arrays of %s are not supported by packed encoding", t.Elem.String())
+		}
+		if t.T == abi.SliceTy {
+			return -1, nil
+		}
+		return t.Size * 32, nil
+	default:
+		return 0, fmt.Errorf("type %s is not supported by packed encoding", t.String())
+	}
+}
+
+func packedInteger(value reflect.Value) (*big.Int, error) {
+	switch v := value.Interface().(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, errors.New("value is nil")
+		}
+		return v, nil
+	case big.Int:
+		return &v, nil
+	}
+	if isInteger(value.Kind()) {
+		return integerToBig(value), nil
+	}
+
+	return nil, fmt.Errorf("can't encode %s as integer", value.Type())
+}
+
+func packedBytes(value reflect.Value) ([]byte, error) {
+	switch {
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		return value.Bytes(), nil
+	case value.Kind() == reflect.Array && value.Type().Elem().Kind() == reflect.Uint8:
+		b := make([]byte, value.Len())
+		reflect.Copy(reflect.ValueOf(b), value)
+		return b, nil
+	default:
+		return nil, fmt.Errorf("can't encode %s as bytes", value.Type())
+	}
+}
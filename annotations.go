@@ -0,0 +1,50 @@
+package seth
+
+import (
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AnnotatedClient is a Client that attaches annotations (key-value labels with business context, e.g. test scenario or step)
+// to every transaction it decodes. Annotations end up in DecodedTransaction, in decoded calls of the trace and in trace JSON files.
+type AnnotatedClient struct {
+	*Client
+	annotations map[string]string
+}
+
+// WithAnnotation returns a client that will attach given annotation to all transactions decoded with it.
+// Example: client.WithAnnotation("scenario", "round-3").Decode(contract.DoSomething(client.NewTXOpts()))
+func (m *Client) WithAnnotation(key, value string) *AnnotatedClient {
+	return &AnnotatedClient{
+		Client:      m,
+		annotations: map[string]string{key: value},
+	}
+}
+
+// WithAnnotation returns a new client with all existing annotations and the new one (which overrides the existing one with the same key)
+func (a *AnnotatedClient) WithAnnotation(key, value string) *AnnotatedClient {
+	annotations := make(map[string]string, len(a.annotations)+1)
+	for k, v := range a.annotations {
+		annotations[k] = v
+	}
+	annotations[key] = value
+
+	return &AnnotatedClient{
+		Client:      a.Client,
+		annotations: annotations,
+	}
+}
+
+// Annotations returns a copy of annotations that will be attached to decoded transactions
+func (a *AnnotatedClient) Annotations() map[string]string {
+	annotations := make(map[string]string, len(a.annotations))
+	for k, v := range a.annotations {
+		annotations[k] = v
+	}
+
+	return annotations
+}
+
+// Decode works exactly like Client.Decode, but also attaches annotations to the decoded transaction and its trace
+func (a *AnnotatedClient) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction, error) {
+	return a.Client.decode(tx, txErr, a.Annotations())
+}
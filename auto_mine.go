@@ -0,0 +1,61 @@
+package seth
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// evmMineMethod mines a single block on dev nodes (Anvil, Hardhat, Ganache)
+	evmMineMethod = "evm_mine"
+)
+
+// autoMiner mines a block on dev nodes when transaction Seth is waiting for stays pending for too long, e.g. because node
+// was started with long block time
+type autoMiner struct {
+	after     time.Duration
+	lastMined time.Time
+}
+
+// newAutoMiner returns nil if automatic mining is disabled or node doesn't support it
+func (m *Client) newAutoMiner(timing *InclusionTiming) *autoMiner {
+	if m.Cfg.Network.AutoMineAfter == nil || m.Cfg.Network.AutoMineAfter.Duration() <= 0 || m.autoMineUnsupported.Load() {
+		return nil
+	}
+
+	return &autoMiner{after: m.Cfg.Network.AutoMineAfter.Duration(), lastMined: timing.StartedAt}
+}
+
+// mineIfStuck mines a block, if transaction has been pending for longer than configured time since waiting started or
+// since the last block was mined
+func (m *Client) mineIfStuck(ctx context.Context, l zerolog.Logger, miner *autoMiner, timing *InclusionTiming) {
+	if miner == nil || time.Since(miner.lastMined) < miner.after || m.autoMineUnsupported.Load() {
+		return
+	}
+
+	var result interface{}
+	err := m.Client.Client().CallContext(ctx, &result, evmMineMethod)
+	miner.lastMined = time.Now()
+	if err != nil {
+		if isMethodNotFoundErr(err) {
+			// no point in trying again, e.g. Geth in dev mode doesn't support it
+			m.autoMineUnsupported.Store(true)
+			l.Warn().
+				Str("Method", evmMineMethod).
+				Msg("Node doesn't support mining on demand. Automatic mining is disabled")
+			return
+		}
+		l.Debug().
+			Err(err).
+			Msg("Failed to mine a block. Will try again later")
+		return
+	}
+
+	timing.AutoMinedBlocks++
+	l.Info().
+		Str("Pending for", time.Since(timing.StartedAt).Round(time.Millisecond).String()).
+		Int("Auto-mined blocks", timing.AutoMinedBlocks).
+		Msg("Transaction was pending for too long. Mined a block")
+}
@@ -0,0 +1,149 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrInsufficientBalance = "insufficient balance"
+	ErrFundlessKeys        = "some keys have no funds"
+
+	KeyBalanceCheck_Warn  = "WARN"
+	KeyBalanceCheck_Error = "ERROR"
+)
+
+// InsufficientBalanceError is returned by pre-flight balance check, when sender can't cover maximum cost of a transaction
+type InsufficientBalanceError struct {
+	// KeyNum is the index of sender's key or -1 if sender isn't one of client's keys
+	KeyNum   int
+	Address  common.Address
+	Balance  *big.Int
+	Required *big.Int
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("%s: key %d (%s) has %s, but transaction might cost up to %s", ErrInsufficientBalance, e.KeyNum, e.Address.Hex(), e.Balance.String(), e.Required.String())
+}
+
+// CheckBalanceForTx checks whether sender's pending balance covers maximum cost of the transaction (gas limit * gas fee cap +
+// blob fees + value). It returns *InsufficientBalanceError if it doesn't.
+func (m *Client) CheckBalanceForTx(ctx context.Context, from common.Address, tx *types.Transaction) error {
+	balance, err := m.Client.PendingBalanceAt(ctx, from)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get balance of %s", from.Hex())
+	}
+
+	required := tx.Cost()
+	if balance.Cmp(required) >= 0 {
+		return nil
+	}
+
+	keyNum := -1
+	for i, addr := range m.Addresses {
+		if addr == from {
+			keyNum = i
+			break
+		}
+	}
+
+	return &InsufficientBalanceError{
+		KeyNum:   keyNum,
+		Address:  from,
+		Balance:  balance,
+		Required: required,
+	}
+}
+
+// checkBalanceIfEnabled runs pre-flight balance check if it's enabled for current network
+func (m *Client) checkBalanceIfEnabled(from common.Address, tx *types.Transaction) error {
+	if !m.Cfg.Network.BalanceCheckEnabled {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	return m.CheckBalanceForTx(ctx, from, tx)
+}
+
+// balanceCheckingSigner wraps signer so that transactions, which sender can't afford, are never signed (and thus not sent)
+func (m *Client) balanceCheckingSigner(signer bind.SignerFn) bind.SignerFn {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if err := m.checkBalanceIfEnabled(address, tx); err != nil {
+			return nil, err
+		}
+		return signer(address, tx)
+	}
+}
+
+// FundlessKeys returns numbers of keys, whose balance at latest block is zero. Such keys usually belong to a different network
+// or were never funded.
+func (m *Client) FundlessKeys() ([]int, error) {
+	return m.fundlessKeys(len(m.Addresses))
+}
+
+// fundlessKeys returns numbers of keys with zero balance among first n keys
+func (m *Client) fundlessKeys(n int) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	fundless := make([]int, 0)
+	for keyNum, addr := range m.Addresses[:n] {
+		balance, err := m.Client.BalanceAt(ctx, addr, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get balance of %s", addr.Hex())
+		}
+		if balance.Sign() == 0 {
+			fundless = append(fundless, keyNum)
+		}
+	}
+
+	return fundless, nil
+}
+
+// checkKeyBalancesOnStart checks that all keys have non-zero balance, so that wrong keys are detected before first transaction.
+// Depending on key_balance_check it either logs a warning or returns an error listing fundless keys.
+func (m *Client) checkKeyBalancesOnStart() error {
+	if m.Cfg.KeyBalanceCheck == "" {
+		return nil
+	}
+
+	keysToCheck := len(m.Addresses)
+	// ephemeral keys are funded by the root key later
+	if m.Cfg.ephemeral && keysToCheck > 1 {
+		keysToCheck = 1
+	}
+
+	fundless, err := m.fundlessKeys(keysToCheck)
+	if err != nil {
+		return err
+	}
+	if len(fundless) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(fundless))
+	for _, keyNum := range fundless {
+		keys = append(keys, fmt.Sprintf("%d (%s)", keyNum, m.Addresses[keyNum].Hex()))
+	}
+
+	if m.Cfg.KeyBalanceCheck == KeyBalanceCheck_Error {
+		return fmt.Errorf("%s on network %s (chain ID %d): %s. Make sure that keys belong to this network and are funded",
+			ErrFundlessKeys, m.Cfg.Network.Name, m.ChainID, strings.Join(keys, ", "))
+	}
+
+	L.Warn().
+		Strs("Keys", keys).
+		Str("Network", m.Cfg.Network.Name).
+		Msg("Some keys have no funds. Make sure that keys belong to this network and are funded")
+
+	return nil
+}
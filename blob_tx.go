@@ -0,0 +1,255 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrBlobTxDisabled     = "blob transactions are disabled, set 'blob_transactions = true' in network config"
+	ErrBlobTxNoRecipient  = "blob transactions can't create contracts"
+	ErrBlobTxNoBlobs      = "blob transaction has no blob hashes"
+	ErrBlobsNotSupported  = "network doesn't support blobs (latest header has no excess blob gas)"
+	ErrEstimateBlobFeeCap = "failed to estimate blob fee cap"
+	ErrBlobTooLarge       = "data doesn't fit in a blob"
+
+	// BlobFeeCapMultiplier is applied to blob base fee of the next block, so that blob transaction stays valid even if
+	// blob base fee keeps rising for a few blocks (it can increase by ~12.5% per block)
+	BlobFeeCapMultiplier = 2
+
+	// BlobDataCapacity is the number of bytes that fit in a single blob with BlobFromData()
+	BlobDataCapacity = 4096 * 31
+)
+
+type blobParamsKey struct{}
+
+// blobParams are blob fields set with TransactOpt helpers, they are stored in the context of transaction options,
+// because bind.TransactOpts has no blob fields
+type blobParams struct {
+	sidecar *types.BlobTxSidecar
+	hashes  []common.Hash
+	feeCap  *big.Int
+}
+
+// withBlobParams returns TransactOpt that modifies a copy of blob params already set in transaction options
+func withBlobParams(modify func(p *blobParams)) TransactOpt {
+	return func(o *bind.TransactOpts) {
+		ctx := o.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		params := blobParams{}
+		if existing, ok := ctx.Value(blobParamsKey{}).(blobParams); ok {
+			params = existing
+		}
+		modify(&params)
+		o.Context = context.WithValue(ctx, blobParamsKey{}, params)
+	}
+}
+
+// WithBlobs makes transaction a blob (EIP-4844) transaction carrying given blobs, blob hashes are computed from sidecar's
+// commitments. Requires blob transactions to be enabled. Use NewBlobSidecar() to create the sidecar.
+func WithBlobs(sidecar *types.BlobTxSidecar) TransactOpt {
+	return withBlobParams(func(p *blobParams) {
+		p.sidecar = sidecar
+		p.hashes = sidecar.BlobHashes()
+	})
+}
+
+// WithBlobHashes makes transaction a blob (EIP-4844) transaction with given blob hashes, but without blobs. Nodes reject
+// such transactions in eth_sendRawTransaction, so it's only useful for signing transactions that are sent elsewhere or
+// for testing how contracts handle blob hashes (BLOBHASH opcode) on dev nodes that don't require blobs.
+func WithBlobHashes(hashes ...common.Hash) TransactOpt {
+	return withBlobParams(func(p *blobParams) {
+		p.hashes = hashes
+	})
+}
+
+// WithBlobFeeCap sets maximum fee per blob gas of blob transaction, otherwise it's estimated from the latest header
+func WithBlobFeeCap(blobFeeCap *big.Int) TransactOpt {
+	return withBlobParams(func(p *blobParams) {
+		p.feeCap = blobFeeCap
+	})
+}
+
+// blobSigner wraps signer so that transactions built by geth bindings are turned into blob transactions before they are
+// signed, if blob params were set in transaction options. It reads params from options when signing, so that TransactOpt
+// helpers applied after the signer was set still take effect. It has to wrap all other signers, so that they see the final
+// transaction (e.g. balance check includes blob fees).
+func (m *Client) blobSigner(opts *bind.TransactOpts, signer bind.SignerFn) bind.SignerFn {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if opts.Context == nil {
+			return signer(address, tx)
+		}
+		params, ok := opts.Context.Value(blobParamsKey{}).(blobParams)
+		if !ok || tx.Type() == types.BlobTxType {
+			return signer(address, tx)
+		}
+		if !m.Cfg.Network.BlobTransactions {
+			return nil, errors.New(ErrBlobTxDisabled)
+		}
+
+		blobTx, err := m.toBlobTx(opts.Context, tx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		return signer(address, blobTx)
+	}
+}
+
+// toBlobTx creates unsigned blob transaction with the same fields as given one, legacy gas price is used both as fee cap and tip cap
+func (m *Client) toBlobTx(ctx context.Context, tx *types.Transaction, params blobParams) (*types.Transaction, error) {
+	if tx.To() == nil {
+		return nil, errors.New(ErrBlobTxNoRecipient)
+	}
+	if len(params.hashes) == 0 {
+		return nil, errors.New(ErrBlobTxNoBlobs)
+	}
+	blobFeeCap := params.feeCap
+	if blobFeeCap == nil {
+		var err error
+		if blobFeeCap, err = m.blobFeeCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(uint64(m.ChainID)),
+		Nonce:      tx.Nonce(),
+		GasTipCap:  uint256.MustFromBig(tx.GasTipCap()),
+		GasFeeCap:  uint256.MustFromBig(tx.GasFeeCap()),
+		Gas:        tx.Gas(),
+		To:         *tx.To(),
+		Value:      uint256.MustFromBig(tx.Value()),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+		BlobFeeCap: uint256.MustFromBig(blobFeeCap),
+		BlobHashes: params.hashes,
+		Sidecar:    params.sidecar,
+	}), nil
+}
+
+// EstimateBlobFeeCap returns maximum fee per blob gas for a blob transaction: blob base fee of the next block (calculated
+// from excess blob gas and blob gas used in the latest header) multiplied by BlobFeeCapMultiplier
+func (m *Client) EstimateBlobFeeCap(ctx context.Context) (*big.Int, error) {
+	header, err := m.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrEstimateBlobFeeCap)
+	}
+	if header.ExcessBlobGas == nil {
+		return nil, errors.New(ErrBlobsNotSupported)
+	}
+	var blobGasUsed uint64
+	if header.BlobGasUsed != nil {
+		blobGasUsed = *header.BlobGasUsed
+	}
+	nextBlobBaseFee := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(*header.ExcessBlobGas, blobGasUsed))
+
+	return new(big.Int).Mul(nextBlobBaseFee, big.NewInt(BlobFeeCapMultiplier)), nil
+}
+
+// blobFeeCap returns estimated blob fee cap or configured fallback one, if estimations are disabled or fail
+func (m *Client) blobFeeCap(ctx context.Context) (*big.Int, error) {
+	fallback := big.NewInt(m.Cfg.Network.BlobFeeCap)
+	if m.Cfg.IsSimulatedNetwork() || !m.Cfg.Network.GasPriceEstimationEnabled {
+		if fallback.Sign() == 0 {
+			return nil, fmt.Errorf("%s: gas price estimations are disabled and 'blob_fee_cap' isn't set", ErrEstimateBlobFeeCap)
+		}
+		return fallback, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	estimated, err := m.EstimateBlobFeeCap(ctx)
+	if err == nil {
+		return estimated, nil
+	}
+	if fallback.Sign() == 0 {
+		return nil, err
+	}
+	L.Warn().
+		Err(err).
+		Int64("Fallback", m.Cfg.Network.BlobFeeCap).
+		Msg("Failed to estimate blob fee cap. Using fallback value")
+
+	return fallback, nil
+}
+
+// BlobFromData puts arbitrary data (up to BlobDataCapacity bytes) into a blob. Each field element holds 31 bytes of data,
+// its first byte is always zero, so that it's always a valid BLS scalar.
+func BlobFromData(data []byte) (kzg4844.Blob, error) {
+	var blob kzg4844.Blob
+	if len(data) > BlobDataCapacity {
+		return blob, fmt.Errorf("%s: %d bytes, capacity is %d", ErrBlobTooLarge, len(data), BlobDataCapacity)
+	}
+	for i := 0; len(data) > 0; i++ {
+		n := copy(blob[i*32+1:(i+1)*32], data)
+		data = data[n:]
+	}
+
+	return blob, nil
+}
+
+// NewBlobSidecar creates sidecar of a blob transaction with one blob per each data chunk, together with their KZG
+// commitments and proofs
+func NewBlobSidecar(data ...[]byte) (*types.BlobTxSidecar, error) {
+	sidecar := &types.BlobTxSidecar{}
+	for _, d := range data {
+		blob, err := BlobFromData(d)
+		if err != nil {
+			return nil, err
+		}
+		commitment, err := kzg4844.BlobToCommitment(blob)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compute blob commitment")
+		}
+		proof, err := kzg4844.ComputeBlobProof(blob, commitment)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compute blob proof")
+		}
+		sidecar.Blobs = append(sidecar.Blobs, blob)
+		sidecar.Commitments = append(sidecar.Commitments, commitment)
+		sidecar.Proofs = append(sidecar.Proofs, proof)
+	}
+
+	return sidecar, nil
+}
+
+// DecodedBlobData describes blobs carried by a blob transaction and fees paid for them
+type DecodedBlobData struct {
+	BlobHashes []common.Hash `json:"blob_hashes"`
+	BlobFeeCap *big.Int      `json:"blob_fee_cap"`
+	// BlobGasUsed, BlobGasPrice and BlobFee are only set if receipt has blob fields
+	BlobGasUsed  uint64   `json:"blob_gas_used,omitempty"`
+	BlobGasPrice *big.Int `json:"blob_gas_price,omitempty"`
+	// BlobFee is paid in addition to execution fee
+	BlobFee *big.Int `json:"blob_fee,omitempty"`
+}
+
+// decodeBlobData returns blob data of blob transaction and its receipt or nil, if it's not a blob transaction
+func decodeBlobData(tx *types.Transaction, receipt *types.Receipt) *DecodedBlobData {
+	if tx.Type() != types.BlobTxType {
+		return nil
+	}
+	data := &DecodedBlobData{
+		BlobHashes: tx.BlobHashes(),
+		BlobFeeCap: tx.BlobGasFeeCap(),
+	}
+	if receipt != nil && receipt.BlobGasPrice != nil {
+		data.BlobGasUsed = receipt.BlobGasUsed
+		data.BlobGasPrice = receipt.BlobGasPrice
+		data.BlobFee = new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice)
+	}
+
+	return data
+}
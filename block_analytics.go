@@ -0,0 +1,183 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/sync/errgroup"
+)
+
+// BlockAnalytics describes gas used in a single block and the share of it used by client's transactions
+type BlockAnalytics struct {
+	Number    uint64    `json:"number"`
+	Timestamp time.Time `json:"timestamp"`
+	GasUsed   uint64    `json:"gas_used"`
+	GasLimit  uint64    `json:"gas_limit"`
+	// Utilization is the percentage of block's gas limit that was used
+	Utilization     float64 `json:"utilization"`
+	Transactions    int     `json:"transactions"`
+	OurTransactions int     `json:"our_transactions"`
+	OurGasUsed      uint64  `json:"our_gas_used"`
+	// OurGasShare is the percentage of gas used in the block that was used by client's transactions
+	OurGasShare float64 `json:"our_gas_share"`
+}
+
+// BlockRangeAnalytics describes gas used in a range of blocks and the share of it used by client's transactions. High
+// utilization with low share of our gas means that our transactions are competing with other traffic for block space.
+type BlockRangeAnalytics struct {
+	StartBlock uint64           `json:"start_block"`
+	EndBlock   uint64           `json:"end_block"`
+	Blocks     []BlockAnalytics `json:"blocks"`
+	// SkippedBlocks are blocks that couldn't be unmarshalled, because of transaction types go-ethereum doesn't support
+	SkippedBlocks   []uint64 `json:"skipped_blocks,omitempty"`
+	GasUsed         uint64   `json:"gas_used"`
+	GasLimit        uint64   `json:"gas_limit"`
+	Utilization     float64  `json:"utilization"`
+	Transactions    int      `json:"transactions"`
+	OurTransactions int      `json:"our_transactions"`
+	OurGasUsed      uint64   `json:"our_gas_used"`
+	OurGasShare     float64  `json:"our_gas_share"`
+	// TimedTransactions is the number of our transactions in the range, for which inclusion time is known. Only transactions
+	// decoded by the client (and still kept in session log, see MaxSessionTransactions) have it.
+	TimedTransactions int `json:"timed_transactions"`
+	// AvgInclusionTime is the average time from sending to mining of timed transactions
+	AvgInclusionTime time.Duration `json:"avg_inclusion_time"`
+	// AvgInclusionBlocks is the average number of blocks mined from sending to mining of timed transactions
+	AvgInclusionBlocks float64 `json:"avg_inclusion_blocks"`
+}
+
+// Analytics fetches blocks from startBlock to endBlock (both inclusive) and returns gas used in each of them, number of
+// transactions sent from client's addresses, share of gas they used and average time it took to include them. Gas used by
+// our transactions is taken from session log, if they were decoded by the client, and from their receipts otherwise.
+func (cs *BlockStats) Analytics(startBlock, endBlock uint64) (*BlockRangeAnalytics, error) {
+	if startBlock > endBlock {
+		return nil, fmt.Errorf("start block %d is greater than end block %d", startBlock, endBlock)
+	}
+
+	ourAddresses := make(map[common.Address]bool, len(cs.Client.Addresses))
+	for _, address := range cs.Client.Addresses {
+		ourAddresses[address] = true
+	}
+	sessionTxs := make(map[string]SessionTransaction)
+	for _, tx := range cs.Client.SessionTransactions() {
+		sessionTxs[tx.Hash] = tx
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(cs.Client.ChainID))
+
+	L.Info().
+		Uint64("StartBlock", startBlock).
+		Uint64("EndBlock", endBlock).
+		Msg("Calculating analytics for blocks interval")
+
+	analytics := &BlockRangeAnalytics{StartBlock: startBlock, EndBlock: endBlock}
+	var (
+		mu                 sync.Mutex
+		totalInclusionTime time.Duration
+		totalBlocksElapsed uint64
+	)
+	eg := &errgroup.Group{}
+	for bn := startBlock; bn <= endBlock; bn++ {
+		bn := bn
+		eg.Go(func() error {
+			cs.Limiter.Take()
+			block, err := cs.Client.Client.BlockByNumber(context.Background(), new(big.Int).SetUint64(bn))
+			if err != nil {
+				// same as in Stats, some networks have blocks that can't be unmarshalled
+				if strings.Contains(err.Error(), "value overflows uint256") || strings.Contains(err.Error(), "transaction type not supported") {
+					L.Error().Err(err).Uint64("BlockNumber", bn).Msg("skipped block")
+					mu.Lock()
+					analytics.SkippedBlocks = append(analytics.SkippedBlocks, bn)
+					mu.Unlock()
+					return nil
+				}
+				return fmt.Errorf("failed to get block %d: %w", bn, err)
+			}
+
+			blockAnalytics := BlockAnalytics{
+				Number:       bn,
+				Timestamp:    time.Unix(int64(block.Time()), 0).UTC(),
+				GasUsed:      block.GasUsed(),
+				GasLimit:     block.GasLimit(),
+				Utilization:  calculateRatioPercentage(block.GasUsed(), block.GasLimit()),
+				Transactions: len(block.Transactions()),
+			}
+			var inclusionTime time.Duration
+			var blocksElapsed uint64
+			var timed int
+			for _, tx := range block.Transactions() {
+				from, err := types.Sender(signer, tx)
+				if err != nil || !ourAddresses[from] {
+					continue
+				}
+				blockAnalytics.OurTransactions++
+				if record, ok := sessionTxs[tx.Hash().Hex()]; ok && record.Block == bn {
+					blockAnalytics.OurGasUsed += record.GasUsed
+					if record.InclusionTime > 0 {
+						inclusionTime += record.InclusionTime
+						blocksElapsed += record.InclusionBlocks
+						timed++
+					}
+					continue
+				}
+				cs.Limiter.Take()
+				receipt, err := cs.Client.Client.TransactionReceipt(context.Background(), tx.Hash())
+				if err != nil {
+					return fmt.Errorf("failed to get receipt of transaction %s: %w", tx.Hash().Hex(), err)
+				}
+				blockAnalytics.OurGasUsed += receipt.GasUsed
+			}
+			blockAnalytics.OurGasShare = calculateRatioPercentage(blockAnalytics.OurGasUsed, blockAnalytics.GasUsed)
+
+			mu.Lock()
+			defer mu.Unlock()
+			analytics.Blocks = append(analytics.Blocks, blockAnalytics)
+			totalInclusionTime += inclusionTime
+			totalBlocksElapsed += blocksElapsed
+			analytics.TimedTransactions += timed
+
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(analytics.Blocks, func(i, j int) bool {
+		return analytics.Blocks[i].Number < analytics.Blocks[j].Number
+	})
+	sort.Slice(analytics.SkippedBlocks, func(i, j int) bool {
+		return analytics.SkippedBlocks[i] < analytics.SkippedBlocks[j]
+	})
+	for _, b := range analytics.Blocks {
+		analytics.GasUsed += b.GasUsed
+		analytics.GasLimit += b.GasLimit
+		analytics.Transactions += b.Transactions
+		analytics.OurTransactions += b.OurTransactions
+		analytics.OurGasUsed += b.OurGasUsed
+	}
+	analytics.Utilization = calculateRatioPercentage(analytics.GasUsed, analytics.GasLimit)
+	analytics.OurGasShare = calculateRatioPercentage(analytics.OurGasUsed, analytics.GasUsed)
+	if analytics.TimedTransactions > 0 {
+		analytics.AvgInclusionTime = totalInclusionTime / time.Duration(analytics.TimedTransactions)
+		analytics.AvgInclusionBlocks = float64(totalBlocksElapsed) / float64(analytics.TimedTransactions)
+	}
+
+	L.Info().
+		Int("Blocks", len(analytics.Blocks)).
+		Int("Transactions", analytics.Transactions).
+		Int("OurTransactions", analytics.OurTransactions).
+		Uint64("GasUsed", analytics.GasUsed).
+		Str("Utilization", fmt.Sprintf("%.2f%%", analytics.Utilization)).
+		Str("OurGasShare", fmt.Sprintf("%.2f%%", analytics.OurGasShare)).
+		Str("AvgInclusionTime", analytics.AvgInclusionTime.String()).
+		Msg("Block range analytics")
+
+	return analytics, nil
+}
@@ -35,7 +35,7 @@ type BlockStats struct {
 // NewBlockStats creates a new instance of BlockStats
 func NewBlockStats(c *Client) (*BlockStats, error) {
 	return &BlockStats{
-		Limiter: ratelimit.New(c.Cfg.BlockStatsConfig.RPCRateLimit, ratelimit.WithoutSlack),
+		Limiter: ratelimit.New(capRateLimit(c.Cfg.BlockStatsConfig.RPCRateLimit, c.Cfg.ProviderProfile()), ratelimit.WithoutSlack),
 		Client:  c,
 	}, nil
 }
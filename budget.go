@@ -0,0 +1,148 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	ErrBudgetExceeded = "spend budget exceeded"
+)
+
+// BudgetExceededError is returned instead of sending a transaction, once fees paid by client's keys reached configured spend budget
+type BudgetExceededError struct {
+	Budget *big.Int
+	Spent  *big.Int
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s was already paid in fees, budget is %s. Refusing to send more transactions", ErrBudgetExceeded, e.Spent.String(), e.Budget.String())
+}
+
+// spendTracker sums fees paid by transactions sent from client's keys, each transaction is counted only once
+type spendTracker struct {
+	mu      sync.Mutex
+	budget  *big.Int
+	spent   *big.Int
+	counted map[common.Hash]bool
+}
+
+func newSpendTracker(budget *big.Int) *spendTracker {
+	return &spendTracker{
+		budget:  budget,
+		spent:   big.NewInt(0),
+		counted: make(map[common.Hash]bool),
+	}
+}
+
+// check returns *BudgetExceededError if budget is exhausted, it's a no-op on nil tracker, which is used when there's no budget
+func (s *spendTracker) check() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spent.Cmp(s.budget) < 0 {
+		return nil
+	}
+
+	return &BudgetExceededError{Budget: new(big.Int).Set(s.budget), Spent: new(big.Int).Set(s.spent)}
+}
+
+// record adds fee paid by the transaction and returns true if it exhausted the budget
+func (s *spendTracker) record(receipt *types.Receipt) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counted[receipt.TxHash] {
+		return false
+	}
+	s.counted[receipt.TxHash] = true
+	wasExhausted := s.spent.Cmp(s.budget) >= 0
+	s.spent.Add(s.spent, receiptFee(receipt))
+
+	return !wasExhausted && s.spent.Cmp(s.budget) >= 0
+}
+
+// receiptFee returns fee paid by mined transaction, L1 data fees of rollups aren't included
+func receiptFee(receipt *types.Receipt) *big.Int {
+	fee := big.NewInt(0)
+	if receipt.EffectiveGasPrice != nil {
+		fee.Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	if receipt.BlobGasPrice != nil {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+	}
+
+	return fee
+}
+
+// SpendBudget returns configured spend budget in base units of native currency or nil if spending isn't limited
+func (m *Client) SpendBudget() *big.Int {
+	if m.budget == nil {
+		return nil
+	}
+
+	return new(big.Int).Set(m.budget.budget)
+}
+
+// Spent returns fees paid by transactions sent from client's keys (in base units of native currency) that were waited for. It's
+// always 0 if spending isn't limited.
+func (m *Client) Spent() *big.Int {
+	if m.budget == nil {
+		return big.NewInt(0)
+	}
+	m.budget.mu.Lock()
+	defer m.budget.mu.Unlock()
+
+	return new(big.Int).Set(m.budget.spent)
+}
+
+// recordSpend adds fee paid by the transaction to spent amount, if it was sent from one of client's keys
+func (m *Client) recordSpend(tx *types.Transaction, receipt *types.Receipt) {
+	if m.budget == nil || receipt == nil {
+		return
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil || !m.isClientAddress(from) {
+		return
+	}
+	if m.budget.record(receipt) {
+		L.Warn().
+			Str("Budget", m.FormatNativeAmount(m.budget.budget)).
+			Str("Spent", m.FormatNativeAmount(m.Spent())).
+			Msg("Spend budget exhausted. No more transactions will be sent")
+	}
+}
+
+// budgetCheckingSigner wraps signer so that no transactions are signed (and thus not sent) once spend budget is exhausted
+func (m *Client) budgetCheckingSigner(signer bind.SignerFn) bind.SignerFn {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if err := m.budget.check(); err != nil {
+			return nil, err
+		}
+		return signer(address, tx)
+	}
+}
+
+func (m *Client) isClientAddress(addr common.Address) bool {
+	for _, a := range m.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toBaseUnits converts an amount of native currency (e.g. 0.5 ETH) to its base units (e.g. wei)
+func (m *Client) toBaseUnits(amount float64) *big.Int {
+	multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(m.NativeCurrency().Decimals)), nil))
+	baseUnits, _ := new(big.Float).Mul(big.NewFloat(amount), multiplier).Int(nil)
+
+	return baseUnits
+}
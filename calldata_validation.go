@@ -0,0 +1,202 @@
+package seth
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	ErrCalldataValidation = "calldata validation failed"
+)
+
+var (
+	// addresses below this value are most probably numbers passed in place of an address (values up to 0xff are left alone,
+	// because that's where precompiles live)
+	suspiciousAddressMin = big.NewInt(0xff)
+	suspiciousAddressMax = new(big.Int).Lsh(big.NewInt(1), 64)
+	// integers in this range are most probably addresses passed in place of a number
+	addressLikeIntMin = new(big.Int).Lsh(big.NewInt(1), 128)
+	addressLikeIntMax = new(big.Int).Lsh(big.NewInt(1), 160)
+)
+
+// CalldataValidationConfig enables checking calldata of every transaction against the ABI of the target contract before
+// it is signed. Transactions with invalid calldata are not sent.
+type CalldataValidationConfig struct {
+	// AllowZeroAddress disables the check for zero address arguments
+	AllowZeroAddress bool `toml:"allow_zero_address"`
+	// ZeroAddressAllowedFor lists names of arguments that can be zero addresses (e.g. "referrer")
+	ZeroAddressAllowedFor []string `toml:"zero_address_allowed_for"`
+}
+
+// CalldataIssue describes a single problem found in calldata
+type CalldataIssue struct {
+	Method   string
+	Argument string
+	Message  string
+}
+
+func (c CalldataIssue) String() string {
+	if c.Argument == "" {
+		return fmt.Sprintf("%s: %s", c.Method, c.Message)
+	}
+	return fmt.Sprintf("%s: argument '%s' %s", c.Method, c.Argument, c.Message)
+}
+
+// ValidateCalldata checks that calldata sent to given address decodes cleanly against the ABI of the contract deployed there
+// (taken from the contract map) and flags common mistakes: zero addresses, numbers passed as addresses and arguments that
+// were most probably swapped. It returns nil if there's no ABI for the target address or all checks passed.
+func (m *Client) ValidateCalldata(to common.Address, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	contractName := m.ContractAddressToNameMap.GetContractName(to.Hex())
+	if contractName == "" {
+		L.Debug().
+			Str("Address", to.Hex()).
+			Msg("No contract name for address. Skipping calldata validation")
+		return nil
+	}
+
+	contractABI, ok := m.ContractStore.GetABI(contractName)
+	if !ok {
+		L.Debug().
+			Str("Address", to.Hex()).
+			Str("Contract", contractName).
+			Msg("No ABI for contract. Skipping calldata validation")
+		return nil
+	}
+
+	cfg := m.Cfg.CalldataValidation
+	if cfg == nil {
+		cfg = &CalldataValidationConfig{}
+	}
+
+	issues := validateCalldata(contractABI, data, cfg)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+
+	return fmt.Errorf("%s for call to %s (%s): %s", ErrCalldataValidation, contractName, to.Hex(), strings.Join(messages, "; "))
+}
+
+// validateCalldata returns all issues found in calldata
+func validateCalldata(contractABI *abi.ABI, data []byte, cfg *CalldataValidationConfig) []CalldataIssue {
+	if len(data) < 4 {
+		return []CalldataIssue{{Method: UNKNOWN, Message: fmt.Sprintf("calldata is only %d bytes long, it doesn't contain a method selector", len(data))}}
+	}
+
+	method, err := contractABI.MethodById(data[:4])
+	if err != nil {
+		return []CalldataIssue{{Method: UNKNOWN, Message: fmt.Sprintf("selector 0x%s not found in contract ABI", common.Bytes2Hex(data[:4]))}}
+	}
+
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return []CalldataIssue{{Method: method.Sig, Message: fmt.Sprintf("arguments don't decode: %s", err)}}
+	}
+
+	issues := make([]CalldataIssue, 0)
+	repacked, err := method.Inputs.Pack(args...)
+	if err == nil && !bytes.Equal(repacked, data[4:]) {
+		issues = append(issues, CalldataIssue{Method: method.Sig, Message: fmt.Sprintf("arguments are not canonically encoded (%d bytes expected, %d bytes found)", len(repacked), len(data[4:]))})
+	}
+
+	var suspiciousAddress, addressLikeInt string
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+
+		if input.Type.T == abi.UintTy || input.Type.T == abi.IntTy {
+			if v, ok := args[i].(*big.Int); ok && v.Cmp(addressLikeIntMin) >= 0 && v.Cmp(addressLikeIntMax) < 0 {
+				addressLikeInt = name
+			}
+			continue
+		}
+
+		walkAddresses(reflect.ValueOf(args[i]), func(addr common.Address) {
+			if addr == (common.Address{}) {
+				if !cfg.AllowZeroAddress && !containsString(cfg.ZeroAddressAllowedFor, input.Name) {
+					issues = append(issues, CalldataIssue{Method: method.Sig, Argument: name, Message: "is a zero address"})
+				}
+				return
+			}
+
+			v := new(big.Int).SetBytes(addr.Bytes())
+			if v.Cmp(suspiciousAddressMin) > 0 && v.Cmp(suspiciousAddressMax) < 0 {
+				suspiciousAddress = name
+				issues = append(issues, CalldataIssue{Method: method.Sig, Argument: name, Message: fmt.Sprintf("looks like a number, not an address (%s)", addr.Hex())})
+			}
+		})
+	}
+
+	if suspiciousAddress != "" && addressLikeInt != "" {
+		issues = append(issues, CalldataIssue{Method: method.Sig, Message: fmt.Sprintf("arguments '%s' and '%s' were probably swapped", suspiciousAddress, addressLikeInt)})
+	}
+
+	return issues
+}
+
+// walkAddresses calls fn for every address found in v, including addresses nested in arrays, slices and tuples
+func walkAddresses(v reflect.Value, fn func(common.Address)) {
+	if !v.IsValid() {
+		return
+	}
+
+	if v.Type() == reflect.TypeOf(common.Address{}) {
+		fn(v.Interface().(common.Address))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		walkAddresses(v.Elem(), fn)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			walkAddresses(v.Index(i), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			walkAddresses(v.Field(i), fn)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validatingSigner wraps signer so that calldata of every contract call is validated before the transaction is signed
+func (m *Client) validatingSigner(signer bind.SignerFn) bind.SignerFn {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		if tx.To() != nil {
+			if err := m.ValidateCalldata(*tx.To(), tx.Data()); err != nil {
+				return nil, err
+			}
+		}
+		return signer(address, tx)
+	}
+}
@@ -0,0 +1,152 @@
+package seth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	Capability_DebugTrace       = "debug_trace"
+	Capability_TxPool           = "txpool"
+	Capability_FeeHistory       = "fee_history"
+	Capability_TraceTransaction = "trace_transaction"
+	Capability_Anvil            = "anvil"
+
+	DefaultCapabilityProbeTimeout = 5 * time.Second
+
+	// JSON-RPC error code returned when method doesn't exist
+	methodNotFoundErrorCode = -32601
+)
+
+type capabilityProbe struct {
+	method string
+	params []interface{}
+}
+
+// capabilityProbes maps each well-known capability to a call that is used to check whether it's available. It doesn't
+// matter whether the call succeeds, only whether the node knows the method.
+var capabilityProbes = map[string]capabilityProbe{
+	Capability_DebugTrace:       {method: "debug_traceTransaction", params: []interface{}{common.Hash{}, map[string]interface{}{}}},
+	Capability_TxPool:           {method: "txpool_content"},
+	Capability_FeeHistory:       {method: "eth_feeHistory", params: []interface{}{"0x1", "latest", []float64{}}},
+	Capability_TraceTransaction: {method: "trace_transaction", params: []interface{}{common.Hash{}}},
+	Capability_Anvil:            {method: "anvil_nodeInfo"},
+}
+
+// Capabilities holds the results of probing RPC node for optional methods. Keys are either capability names
+// (e.g. "debug_trace") or RPC method names (e.g. "debug_traceTransaction" or custom methods from network config).
+type Capabilities struct {
+	Methods map[string]bool
+	mu      *sync.RWMutex
+}
+
+// Supports returns true if given capability or method is supported. Unknown capabilities are reported as not supported.
+func (c *Capabilities) Supports(capabilityOrMethod string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Methods[capabilityOrMethod]
+}
+
+// ProbeCapabilities checks which of the well-known optional methods and custom methods from network config are supported
+// by the RPC node. A method is considered unsupported only if the node says it doesn't exist, any other error
+// (e.g. invalid params or transaction not found) means that it's supported.
+func ProbeCapabilities(ctx context.Context, rpcClient *rpc.Client, customMethods []string) *Capabilities {
+	return probeCapabilities(ctx, rpcClient, customMethods, nil)
+}
+
+// probeCapabilities works like ProbeCapabilities, but methods that are known to be unsupported (e.g. from provider profile)
+// are not probed
+func probeCapabilities(ctx context.Context, rpcClient *rpc.Client, customMethods, knownUnsupported []string) *Capabilities {
+	caps := &Capabilities{
+		Methods: make(map[string]bool),
+		mu:      &sync.RWMutex{},
+	}
+
+	probe := func(method string, params []interface{}) bool {
+		for _, unsupported := range knownUnsupported {
+			if unsupported == method {
+				L.Trace().
+					Str("Method", method).
+					Msg("Method is not supported by RPC provider. Skipping probe")
+				return false
+			}
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, DefaultCapabilityProbeTimeout)
+		defer cancel()
+		var result interface{}
+		err := rpcClient.CallContext(probeCtx, &result, method, params...)
+		supported := !isMethodNotFoundErr(err)
+		L.Trace().
+			Err(err).
+			Str("Method", method).
+			Bool("Supported", supported).
+			Msg("Probed RPC method")
+		return supported
+	}
+
+	for capability, p := range capabilityProbes {
+		supported := probe(p.method, p.params)
+		caps.Methods[capability] = supported
+		caps.Methods[p.method] = supported
+	}
+
+	for _, method := range customMethods {
+		caps.Methods[method] = probe(method, nil)
+	}
+
+	L.Debug().
+		Interface("Capabilities", caps.Methods).
+		Msg("Probed RPC node capabilities")
+
+	return caps
+}
+
+func isMethodNotFoundErr(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundErrorCode {
+		return true
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"does not exist", "method not found", "not available", "unsupported method", "method not supported"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Supports returns true if the RPC node supports given capability (e.g. seth.Capability_DebugTrace) or method
+// (e.g. "debug_traceTransaction" or one of `custom_rpc_methods` from network config). If capabilities were not
+// probed it optimistically returns true.
+func (m *Client) Supports(capabilityOrMethod string) bool {
+	if m.Capabilities == nil {
+		return true
+	}
+
+	return m.Capabilities.Supports(capabilityOrMethod)
+}
+
+// degradeUnsupportedFeatures disables features that depend on methods the RPC node doesn't support
+func (m *Client) degradeUnsupportedFeatures() {
+	if m.Cfg.TracingLevel != TracingLevel_None && !m.Supports(m.Cfg.traceCapability()) && m.Cfg.TracingFailurePolicy != TracingFailurePolicy_Fail {
+		L.Warn().Str("Capability", m.Cfg.traceCapability()).Msg("Trace method is not supported by the RPC node. Disabling tracing")
+		m.Cfg.TracingLevel = TracingLevel_None
+	}
+
+	if m.Cfg.Network.GasPriceEstimationEnabled && !m.Supports(Capability_FeeHistory) {
+		L.Warn().Msg("eth_feeHistory is not supported by the RPC node. Disabling gas price estimation and using hardcoded values. Remember to update your config!")
+		m.Cfg.Network.GasPriceEstimationEnabled = false
+	}
+}
@@ -0,0 +1,223 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// DefaultChaosMaxSendDelay is the maximum delay of a send, if chaos delays sends, but max_send_delay isn't set
+	DefaultChaosMaxSendDelay = 2 * time.Second
+
+	ErrChaosConfig = "invalid chaos config"
+
+	chaosErrorBody = "seth chaos: injected transient RPC error"
+)
+
+// ChaosConfig configures opt-in fault injection, which lets frameworks built on Seth test their own retry and resilience logic.
+// Each fault happens with given probability (between 0 and 1), drawn from a random number generator seeded with Seed, so that
+// a failing run can be reproduced. If Seed is 0 a random one is used and logged.
+type ChaosConfig struct {
+	Seed int64 `toml:"seed"`
+	// RPCErrorProbability is the probability that an RPC request fails with 503 Service Unavailable without reaching the node
+	RPCErrorProbability float64 `toml:"rpc_error_probability"`
+	// DropReceiptPollProbability is the probability that eth_getTransactionReceipt returns no receipt, as if the transaction wasn't mined yet
+	DropReceiptPollProbability float64 `toml:"drop_receipt_poll_probability"`
+	// SendDelayProbability is the probability that a transaction is sent to the node after a random delay of up to MaxSendDelay
+	SendDelayProbability float64   `toml:"send_delay_probability"`
+	MaxSendDelay         *Duration `toml:"max_send_delay"`
+	// ForceGasBumpProbability is the probability that Decode stops waiting for a transaction as if it timed out, which makes it
+	// bump gas. It has effect only if gas bumping is enabled, and it's never forced for the last gas bump attempt.
+	ForceGasBumpProbability float64 `toml:"force_gas_bump_probability"`
+}
+
+// ChaosStats are counts of faults injected so far
+type ChaosStats struct {
+	RPCErrors           int
+	DroppedReceiptPolls int
+	DelayedSends        int
+	ForcedGasBumps      int
+}
+
+// Chaos injects faults configured in ChaosConfig. It is safe to use from multiple goroutines, but faults are reproducible
+// only if requests are made in the same order.
+type Chaos struct {
+	cfg ChaosConfig
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	stats ChaosStats
+}
+
+// validate checks that probabilities are between 0 and 1 and that delay isn't negative
+func (c *ChaosConfig) validate() error {
+	probabilities := map[string]float64{
+		"rpc_error_probability":         c.RPCErrorProbability,
+		"drop_receipt_poll_probability": c.DropReceiptPollProbability,
+		"send_delay_probability":        c.SendDelayProbability,
+		"force_gas_bump_probability":    c.ForceGasBumpProbability,
+	}
+	for name, p := range probabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s: %s must be between 0 and 1, but it's %v", ErrChaosConfig, name, p)
+		}
+	}
+	if c.MaxSendDelay != nil && c.MaxSendDelay.Duration() < 0 {
+		return fmt.Errorf("%s: max_send_delay can't be negative", ErrChaosConfig)
+	}
+
+	return nil
+}
+
+// NewChaos creates fault injector with given config
+func NewChaos(cfg ChaosConfig) *Chaos {
+	if cfg.Seed == 0 {
+		cfg.Seed = time.Now().UnixNano()
+	}
+	if cfg.MaxSendDelay == nil {
+		cfg.MaxSendDelay = &Duration{D: DefaultChaosMaxSendDelay}
+	}
+	L.Warn().
+		Int64("Seed", cfg.Seed).
+		Float64("RPC errors", cfg.RPCErrorProbability).
+		Float64("Dropped receipt polls", cfg.DropReceiptPollProbability).
+		Float64("Delayed sends", cfg.SendDelayProbability).
+		Float64("Forced gas bumps", cfg.ForceGasBumpProbability).
+		Msg("Chaos is enabled, faults will be injected. Set the same seed to reproduce them")
+
+	return &Chaos{cfg: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Seed returns seed of the random number generator, which can be used to reproduce injected faults
+func (c *Chaos) Seed() int64 {
+	return c.cfg.Seed
+}
+
+// Stats returns counts of faults injected so far
+func (c *Chaos) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// roll returns true with given probability and increments given counter if it does
+func (c *Chaos) roll(probability float64, counter *int) bool {
+	if probability <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rng.Float64() >= probability {
+		return false
+	}
+	*counter++
+
+	return true
+}
+
+// sendDelay returns how long sending of a transaction should be delayed, 0 means no delay
+func (c *Chaos) sendDelay() time.Duration {
+	if !c.roll(c.cfg.SendDelayProbability, &c.stats.DelayedSends) {
+		return 0
+	}
+	maxDelay := c.cfg.MaxSendDelay.Duration()
+	if maxDelay <= 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return time.Duration(c.rng.Int63n(int64(maxDelay)))
+}
+
+// forceGasBump returns true if waiting for transaction should be given up on to force gas bumping. It's nil-safe.
+func (c *Chaos) forceGasBump(l zerolog.Logger) bool {
+	if c == nil || !c.roll(c.cfg.ForceGasBumpProbability, &c.stats.ForcedGasBumps) {
+		return false
+	}
+	l.Warn().Msg("Chaos: forcing gas bump")
+
+	return true
+}
+
+// ChaosTransport injects RPC errors, dropped receipt polls and delayed sends into HTTP requests. Websocket connections
+// don't use it, so only errors of HTTP RPCs are injected. Batch requests are passed as-is.
+type ChaosTransport struct {
+	Transport http.RoundTripper
+	chaos     *Chaos
+}
+
+// RoundTrip implements the RoundTripper interface
+func (t *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if req.Body == nil {
+		return transport.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	// batch requests and anything else that isn't a single JSON-RPC call are not affected
+	if err := json.Unmarshal(body, &msg); err != nil || msg.Method == "" {
+		return transport.RoundTrip(req)
+	}
+
+	if t.chaos.roll(t.chaos.cfg.RPCErrorProbability, &t.chaos.stats.RPCErrors) {
+		L.Debug().Str("Method", msg.Method).Msg("Chaos: injecting transient RPC error")
+		return chaosResponse(req, http.StatusServiceUnavailable, chaosErrorBody), nil
+	}
+
+	switch msg.Method {
+	case "eth_getTransactionReceipt":
+		if t.chaos.roll(t.chaos.cfg.DropReceiptPollProbability, &t.chaos.stats.DroppedReceiptPolls) {
+			L.Debug().Msg("Chaos: dropping receipt poll")
+			return chaosResponse(req, http.StatusOK, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":null}`, msg.ID)), nil
+		}
+	case "eth_sendRawTransaction", "eth_sendTransaction":
+		if delay := t.chaos.sendDelay(); delay > 0 {
+			L.Debug().Str("Delay", delay.String()).Msg("Chaos: delaying send")
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(delay):
+			}
+		}
+	}
+
+	return transport.RoundTrip(req)
+}
+
+// chaosResponse returns response that is sent instead of node's one
+func chaosResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,53 @@
+package seth_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	sethcmd "github.com/smartcontractkit/seth/cmd"
+)
+
+func TestCLIPlugins(t *testing.T) {
+	var called bool
+	var url string
+	err := sethcmd.RegisterCommand(&cli.Command{
+		Name:    "plugin-test",
+		Aliases: []string{"pt"},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "dry"},
+		},
+		Action: func(cCtx *cli.Context) error {
+			called = cCtx.Bool("dry")
+			url = sethcmd.Options.URL
+			return nil
+		},
+	}, false)
+	require.NoError(t, err, "should have registered plugin command")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "gas", Action: func(*cli.Context) error { return nil }}, false)
+	require.Error(t, err, "should have rejected built-in command name")
+	require.Contains(t, err.Error(), sethcmd.ErrDuplicateCommand, "should have returned duplicate command error")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "reverted", Action: func(*cli.Context) error { return nil }}, false)
+	require.ErrorContains(t, err, sethcmd.ErrDuplicateCommand, "should have rejected built-in reverted command name")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "replay", Aliases: []string{"r"}, Action: func(*cli.Context) error { return nil }}, false)
+	require.ErrorContains(t, err, sethcmd.ErrDuplicateCommand, "should have rejected alias of built-in reverted command")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "keys", Action: func(*cli.Context) error { return nil }}, false)
+	require.ErrorContains(t, err, sethcmd.ErrDuplicateCommand, "should have rejected built-in keys command name")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "other", Aliases: []string{"pt"}, Action: func(*cli.Context) error { return nil }}, false)
+	require.Error(t, err, "should have rejected alias of registered plugin")
+
+	err = sethcmd.RegisterCommand(&cli.Command{Name: "no-action"}, false)
+	require.Error(t, err, "should have rejected command without action")
+	require.Contains(t, err.Error(), sethcmd.ErrPluginCommand, "should have returned invalid command error")
+
+	err = sethcmd.RunCLI([]string{"seth", "-u", "http://localhost:8545", "pt", "--dry"})
+	require.NoError(t, err, "should have run plugin command")
+	require.True(t, called, "plugin command should have received its flags")
+	require.Equal(t, "http://localhost:8545", url, "plugin command should have received config options")
+}
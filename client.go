@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/avast/retry-go"
@@ -20,10 +22,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"golang.org/x/sync/errgroup"
+	"go.uber.org/ratelimit"
 )
 
 const (
@@ -36,6 +39,7 @@ const (
 	ErrNoKeyLoaded              = "failed to load private key"
 	ErrRpcHealthCheckFailed     = "RPC health check failed ¯\\_(ツ)_/¯"
 	ErrContractDeploymentFailed = "contract deployment failed"
+	ErrTracingFailed            = "failed to trace transaction"
 
 	ContractMapFilePattern          = "deployed_contracts_%s_%s.toml"
 	RevertedTransactionsFilePattern = "reverted_transactions_%s_%s.json"
@@ -49,6 +53,10 @@ var (
 	TracingLevel_Reverted = "REVERTED"
 	TracingLevel_All      = "ALL"
 
+	TracingFailurePolicy_Fail                 = "FAIL"
+	TracingFailurePolicy_Warn                 = "WARN"
+	TracingFailurePolicy_DisableAfterFailures = "DISABLE_AFTER_N"
+
 	TraceOutput_Console = "console"
 	TraceOutput_JSON    = "json"
 	TraceOutput_DOT     = "dot"
@@ -71,6 +79,41 @@ type Client struct {
 	ContractAddressToNameMap ContractMap
 	ABIFinder                *ABIFinder
 	HeaderCache              *LFUHeaderCache
+	TracingQueue             *TracingQueue
+	Capabilities             *Capabilities
+	KeyRotator               *KeyRotator
+	Recorder                 *InteractionRecorder
+	TxJournal                *TxJournal
+	ExpectedEvents           *ExpectedEvents
+	Redactor                 *Redactor
+	DevNode                  *DevNode
+	// Chaos injects faults for resilience testing, it's nil unless chaos is configured
+	Chaos *Chaos
+	// Metrics collects confirmation metrics of decoded transactions, it's nil unless confirmation_metrics is enabled
+	Metrics *ConfirmationMetrics
+
+	tracingFailures atomic.Int64
+	// set once tracing was disabled because of tracing failures, it's read instead of Cfg.TracingLevel, because tracing
+	// failures are also handled by asynchronous tracing workers
+	tracingDisabled atomic.Bool
+	// set once node reports that it doesn't support evm_mine
+	autoMineUnsupported atomic.Bool
+	telemetry           *rpcTelemetry
+	// limits bulk RPC calls according to provider profile, nil if there's no limit
+	rpcLimiter ratelimit.Limiter
+	// average block time used for adaptive receipt polling, nil until it's calculated
+	blockTime   *time.Duration
+	blockTimeMu sync.Mutex
+	// guards slots of Addresses and PrivateKeys, because key rotation swaps keys while they might be in use
+	keysMu sync.RWMutex
+	// nonces of sent and mined transactions that reads are checked against, nil unless read consistency is "retry"
+	writes *writeTracker
+	// fees paid by client's keys, nil unless spend budget is set
+	budget *spendTracker
+	// keys used instead of broken ones by SendWithAnyKey
+	keySubstitutions keySubstitutions
+	// compact records of decoded transactions used by ExportSession
+	session sessionLog
 }
 
 // NewClientWithConfig creates a new seth client with all deps setup from config
@@ -89,6 +132,9 @@ func NewClientWithConfig(cfg *Config) (*Client, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, ErrCreateABIStore)
 	}
+	if err := cs.loadInterfaceABIs(cfg); err != nil {
+		return nil, err
+	}
 	if cfg.ephemeral {
 		// we don't care about any other keys, only the root key
 		// you should not use ephemeral mode with more than 1 key
@@ -118,9 +164,8 @@ func NewClientWithConfig(cfg *Config) (*Client, error) {
 	// this part is kind of duplicated in NewClientRaw, but we need to create contract map before creating Tracer
 	// so that both the tracer and client have references to the same map
 	contractAddressToNameMap := NewEmptyContractMap()
-	contractAddressToNameMap.addressMap = make(map[string]string)
 	if !cfg.IsSimulatedNetwork() {
-		contractAddressToNameMap.addressMap, err = LoadDeployedContracts(cfg.ContractMapFile)
+		contractAddressToNameMap, err = loadContractMapFromFiles(cfg)
 		if err != nil {
 			return nil, errors.Wrap(err, ErrReadContractMap)
 		}
@@ -171,6 +216,58 @@ func ValidateConfig(cfg *Config) error {
 
 	}
 
+	for name, fallbacks := range map[string]map[string]int64{
+		"fallback_gas_price":   cfg.Network.FallbackGasPrices,
+		"fallback_gas_fee_cap": cfg.Network.FallbackGasFeeCaps,
+		"fallback_gas_tip_cap": cfg.Network.FallbackGasTipCaps,
+	} {
+		for priority := range fallbacks {
+			switch priority {
+			case Priority_Degen, Priority_Fast, Priority_Standard, Priority_Slow:
+			default:
+				return fmt.Errorf("%s has unknown priority '%s'. It must be one of: degen, fast, standard, slow", name, priority)
+			}
+		}
+	}
+
+	switch cfg.Network.ReadConsistency {
+	case "", ReadConsistency_Sticky, ReadConsistency_Retry:
+	default:
+		return fmt.Errorf("read_consistency must be one of: '', %s, %s", ReadConsistency_Sticky, ReadConsistency_Retry)
+	}
+
+	switch cfg.Network.TxConfirmationStrategy {
+	case "", TxConfirmationStrategy_Polling, TxConfirmationStrategy_Subscription:
+	default:
+		return fmt.Errorf("tx_confirmation_strategy must be one of: '', %s, %s", TxConfirmationStrategy_Polling, TxConfirmationStrategy_Subscription)
+	}
+
+	if err := cfg.Network.validateExplorerURLs(); err != nil {
+		return err
+	}
+
+	if err := cfg.Network.validateExternalActors(); err != nil {
+		return err
+	}
+
+	if err := cfg.validateKeys(); err != nil {
+		return err
+	}
+
+	if cfg.Network.BlobFeeCap < 0 {
+		return fmt.Errorf("blob_fee_cap can't be negative, got %d", cfg.Network.BlobFeeCap)
+	}
+
+	if cfg.Network.SpendBudget != nil && *cfg.Network.SpendBudget <= 0 {
+		return fmt.Errorf("spend_budget must be greater than 0, got %v", *cfg.Network.SpendBudget)
+	}
+
+	if cfg.Network.AutoMineAfter != nil && cfg.Network.AutoMineAfter.Duration() > 0 && !cfg.IsSimulatedNetwork() {
+		L.Warn().
+			Str("Network", cfg.Network.Name).
+			Msg("Automatic mining is enabled, but network isn't a simulated one. It will only work if the node supports evm_mine")
+	}
+
 	if cfg.Network.GasLimit != 0 {
 		L.Warn().
 			Msg("Gas limit is set, this will override the gas limit set by the network. This option should be used **ONLY** if node is incapable of estimating gas limit itself, which happens only with very old versions")
@@ -190,6 +287,111 @@ func ValidateConfig(cfg *Config) error {
 		return errors.New("tracing level must be one of: NONE, REVERTED, ALL")
 	}
 
+	if cfg.TracingFailurePolicy == "" {
+		cfg.TracingFailurePolicy = TracingFailurePolicy_Warn
+	}
+
+	cfg.TracingFailurePolicy = strings.ToUpper(cfg.TracingFailurePolicy)
+
+	switch cfg.TracingFailurePolicy {
+	case TracingFailurePolicy_Fail:
+	case TracingFailurePolicy_Warn:
+	case TracingFailurePolicy_DisableAfterFailures:
+		if cfg.TracingFailuresBeforeDisable == 0 {
+			return errors.New("when tracing failure policy is DISABLE_AFTER_N, tracing_failures_before_disable must be greater than 0")
+		}
+	default:
+		return errors.New("tracing failure policy must be one of: FAIL, WARN, DISABLE_AFTER_N")
+	}
+
+	if cfg.TracingWorkers < 0 {
+		return errors.New("tracing workers must be greater than or equal to 0")
+	}
+
+	switch cfg.TraceBackend {
+	case "", TraceBackend_Geth, TraceBackend_Parity:
+	default:
+		return fmt.Errorf("trace_backend must be one of: '', %s, %s", TraceBackend_Geth, TraceBackend_Parity)
+	}
+
+	if cfg.StrictTracing && cfg.TracingWorkers > 0 {
+		return errors.New("strict tracing requires synchronous tracing, set tracing_workers to 0")
+	}
+
+	if cfg.KeyBalanceCheck != "" {
+		cfg.KeyBalanceCheck = strings.ToUpper(cfg.KeyBalanceCheck)
+		switch cfg.KeyBalanceCheck {
+		case KeyBalanceCheck_Warn:
+		case KeyBalanceCheck_Error:
+		default:
+			return fmt.Errorf("key balance check must be one of: %s, %s", KeyBalanceCheck_Warn, KeyBalanceCheck_Error)
+		}
+	}
+
+	if err := validateExpectedEvents(cfg.ExpectedEvents); err != nil {
+		return err
+	}
+
+	if err := validateRedaction(cfg.Redaction); err != nil {
+		return err
+	}
+
+	if err := cfg.validateKeyLabels(); err != nil {
+		return err
+	}
+
+	if (cfg.Network.ReceiptPollInterval != nil && cfg.Network.ReceiptPollInterval.Duration() < 0) ||
+		(cfg.Network.ReceiptPollJitter != nil && cfg.Network.ReceiptPollJitter.Duration() < 0) {
+		return errors.New("receipt poll interval and jitter must be greater than or equal to 0")
+	}
+
+	if cfg.TraceRetention != nil && (cfg.TraceRetention.MaxTransactions < 0 || cfg.TraceRetention.MaxBytes < 0) {
+		return errors.New("trace retention limits must be greater than or equal to 0")
+	}
+
+	if cfg.RPCFailover != nil && ((cfg.RPCFailover.RetryDelay != nil && cfg.RPCFailover.RetryDelay.Duration() < 0) ||
+		(cfg.RPCFailover.Cooldown != nil && cfg.RPCFailover.Cooldown.Duration() < 0)) {
+		return errors.New("rpc failover retry delay and cooldown must be greater than or equal to 0")
+	}
+
+	if cfg.TxJournal != nil {
+		if cfg.TxJournal.ResumePolicy == "" {
+			cfg.TxJournal.ResumePolicy = TxJournalResumePolicy_Wait
+		}
+		cfg.TxJournal.ResumePolicy = strings.ToUpper(cfg.TxJournal.ResumePolicy)
+
+		switch cfg.TxJournal.ResumePolicy {
+		case TxJournalResumePolicy_Wait:
+		case TxJournalResumePolicy_Bump:
+		default:
+			return errors.New("transaction journal resume policy must be one of: WAIT, BUMP")
+		}
+	}
+
+	if cfg.Chaos != nil {
+		if err := cfg.Chaos.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateProviderProfile(cfg.Network.ProviderProfile); err != nil {
+		return err
+	}
+
+	if err := validateConfigProfile(cfg.Profile); err != nil {
+		return err
+	}
+
+	for _, pattern := range cfg.ContractMapFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid contract map file pattern '%s': %w", pattern, err)
+		}
+	}
+
+	if cfg.KeyRotation != nil && !cfg.IsKeyRotationEnabled() {
+		return errors.New("when key rotation is configured, either max_transactions_per_key or max_nonce must be greater than 0")
+	}
+
 	for _, output := range cfg.TraceOutputs {
 		switch strings.ToLower(output) {
 		case TraceOutput_Console:
@@ -226,20 +428,43 @@ func NewClientRaw(
 	if len(cfg.Network.URLs) == 0 {
 		return nil, errors.New("no RPC URL provided")
 	}
-	if len(cfg.Network.URLs) > 1 {
-		L.Warn().Msg("Multiple RPC URLs provided, only the first one will be used")
+	if warning := cfg.rpcFailoverWarning(); warning != "" {
+		L.Warn().Msg(warning)
+	}
+	telemetry, err := newRPCTelemetry(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var transport http.RoundTripper = NewLoggingTransport()
+	var chaos *Chaos
+	if cfg.Chaos != nil {
+		chaos = NewChaos(*cfg.Chaos)
+		// injected faults should look like real ones to the layers above, e.g. failover and telemetry
+		transport = &ChaosTransport{Transport: transport, chaos: chaos}
 	}
+	authOpts, err := cfg.rpcAuthDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := append([]rpc.ClientOption{rpc.WithHeaders(cfg.RPCHeaders)}, authOpts...)
+	dialOpts = append(dialOpts, cfg.stickySessionDialOptions()...)
+	if telemetry != nil {
+		transport = &TelemetryTransport{Transport: transport, telemetry: telemetry}
+		dialOpts = append(dialOpts, rpc.WithWebsocketDialer(telemetry.websocketDialer(cfg.FirstNetworkURL())))
+	}
+	if pool := cfg.rpcFailoverPool(); pool != nil {
+		transport = &FailoverTransport{Transport: transport, pool: pool}
+	}
+	_, stickySessionJar := cfg.stickySession()
+	dialOpts = append(dialOpts, rpc.WithHTTPClient(&http.Client{
+		Transport: transport,
+		Jar:       stickySessionJar,
+	}))
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Network.DialTimeout.Duration())
 	defer cancel()
-	rpcClient, err := rpc.DialOptions(ctx,
-		cfg.FirstNetworkURL(),
-		rpc.WithHeaders(cfg.RPCHeaders),
-		rpc.WithHTTPClient(&http.Client{
-			Transport: NewLoggingTransport(),
-		}),
-	)
+	rpcClient, err := rpc.DialOptions(ctx, cfg.FirstNetworkURL(), dialOpts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect RPC client to '%s' due to: %w", cfg.FirstNetworkURL(), err)
+		return nil, ClassifyError(fmt.Errorf("failed to connect RPC client to '%s' due to: %w", cfg.FirstNetworkURL(), err))
 	}
 	client := ethclient.NewClient(rpcClient)
 
@@ -248,10 +473,14 @@ func NewClientRaw(
 		return nil, errors.Wrap(err, "failed to get chain ID")
 	}
 	cfg.Network.ChainID = chainId.String()
+	cfg.setKeyAliases(addrs)
 	cID, err := strconv.Atoi(cfg.Network.ChainID)
 	if err != nil {
 		return nil, err
 	}
+	if telemetry != nil {
+		telemetry.setChainID(cfg.Network.ChainID)
+	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	c := &Client{
 		Cfg:         cfg,
@@ -262,6 +491,15 @@ func NewClientRaw(
 		ChainID:     int64(cID),
 		Context:     ctx,
 		CancelFunc:  cancelFunc,
+		Chaos:       chaos,
+		telemetry:   telemetry,
+		rpcLimiter:  newProviderRateLimiter(cfg.ProviderProfile()),
+	}
+	if cfg.Network.ReadConsistency == ReadConsistency_Retry {
+		c.writes = newWriteTracker()
+	}
+	if cfg.Network.SpendBudget != nil {
+		c.budget = newSpendTracker(c.toBaseUnits(*cfg.Network.SpendBudget))
 	}
 	for _, o := range opts {
 		o(c)
@@ -270,7 +508,7 @@ func NewClientRaw(
 	if c.ContractAddressToNameMap.addressMap == nil {
 		c.ContractAddressToNameMap = NewEmptyContractMap()
 		if !cfg.IsSimulatedNetwork() {
-			c.ContractAddressToNameMap.addressMap, err = LoadDeployedContracts(cfg.ContractMapFile)
+			c.ContractAddressToNameMap, err = loadContractMapFromFiles(cfg)
 			if err != nil {
 				return nil, errors.Wrap(err, ErrReadContractMap)
 			}
@@ -278,6 +516,7 @@ func NewClientRaw(
 				L.Info().
 					Int("Size", len(c.ContractAddressToNameMap.addressMap)).
 					Str("File name", cfg.ContractMapFile).
+					Strs("Additional files", cfg.ContractMapFiles).
 					Msg("No contract map provided, read it from file")
 			} else {
 				L.Info().
@@ -293,9 +532,22 @@ func NewClientRaw(
 			Int("Size", len(c.ContractAddressToNameMap.addressMap)).
 			Msg("Contract map was provided")
 	}
+	if c.Capabilities == nil {
+		var knownUnsupported []string
+		if profile := cfg.ProviderProfile(); profile != nil {
+			knownUnsupported = profile.UnsupportedMethods
+		}
+		c.Capabilities = probeCapabilities(context.Background(), rpcClient, cfg.Network.CustomRPCMethods, knownUnsupported)
+	}
+	c.degradeUnsupportedFeatures()
+	c.selectTxType()
+	if err := c.checkNodeAccounts(); err != nil {
+		return nil, err
+	}
+
 	if c.NonceManager != nil {
 		c.NonceManager.Client = c
-		if len(c.Cfg.Network.PrivateKeys) > 0 {
+		if len(c.Cfg.Network.PrivateKeys) > 0 || len(c.Cfg.Network.NodeAccounts) > 0 {
 			if err := c.NonceManager.UpdateNonces(); err != nil {
 				return nil, err
 			}
@@ -312,6 +564,10 @@ func NewClientRaw(
 		}
 	}
 
+	if err := c.checkKeyBalancesOnStart(); err != nil {
+		return nil, err
+	}
+
 	cfg.setEphemeralAddrs()
 
 	L.Info().
@@ -322,29 +578,27 @@ func NewClientRaw(
 		Int64("Ephemeral keys", *cfg.EphemeralAddrs).
 		Msg("Created new client")
 
-	if cfg.ephemeral {
-		gasPrice, err := c.GetSuggestedLegacyFees(context.Background(), Priority_Standard)
-		if err != nil {
-			gasPrice = big.NewInt(c.Cfg.Network.GasPrice)
-		}
+	c.ExpectedEvents = NewExpectedEvents(cfg.ExpectedEvents)
+	if cfg.IsSimulatedNetwork() {
+		c.DevNode = &DevNode{client: c}
+	}
+	c.Redactor = NewRedactor(cfg.Redaction)
+	if cfg.ConfirmationMetrics {
+		c.Metrics = NewConfirmationMetrics()
+	}
 
-		bd, err := c.CalculateSubKeyFunding(*cfg.EphemeralAddrs, gasPrice.Int64(), *cfg.RootKeyFundsBuffer)
+	if cfg.IsTxJournalEnabled() {
+		c.TxJournal, err = NewTxJournal(cfg.TxJournal.File)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	if cfg.ephemeral {
 		L.Warn().Msg("Ephemeral mode, all funds will be lost!")
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		eg, egCtx := errgroup.WithContext(ctx)
 		// root key is element 0 in ephemeral
-		for _, addr := range c.Addresses[1:] {
-			addr := addr
-			eg.Go(func() error {
-				return c.TransferETHFromKey(egCtx, 0, addr.Hex(), bd.AddrFunding, gasPrice)
-			})
-		}
-		if err := eg.Wait(); err != nil {
+		if err := c.fundEphemeralKeys(); err != nil {
 			return nil, err
 		}
 	}
@@ -355,6 +609,9 @@ func NewClientRaw(
 			if err != nil {
 				return nil, errors.Wrap(err, ErrCreateABIStore)
 			}
+			if err := cs.loadInterfaceABIs(cfg); err != nil {
+				return nil, err
+			}
 			c.ContractStore = cs
 		}
 		if c.ABIFinder == nil {
@@ -369,6 +626,19 @@ func NewClientRaw(
 		c.Tracer = tr
 	}
 
+	if c.Tracer != nil {
+		// rules registered at runtime must apply to both decoded transactions and call traces
+		c.Tracer.Redactor = c.Redactor
+	}
+
+	if c.Tracer != nil && cfg.TracingWorkers > 0 {
+		c.TracingQueue = NewTracingQueue(c, cfg.TracingWorkers)
+	}
+
+	if cfg.IsKeyRotationEnabled() {
+		c.KeyRotator = NewKeyRotator(c, cfg.KeyRotation)
+	}
+
 	now := time.Now().Format("2006-01-02-15-04-05")
 	c.Cfg.revertedTransactionsFile = filepath.Join(c.Cfg.ArtifactsDir, fmt.Sprintf(RevertedTransactionsFilePattern, c.Cfg.Network.Name, now))
 
@@ -379,11 +649,12 @@ func NewClientRaw(
 
 		if c.Cfg.Network.EIP1559DynamicFees {
 			L.Debug().Msg("Checking if EIP-1559 is supported by the network")
+			gasPrice, gasFeeCap, gasTipCap := c.Cfg.Network.FallbackFees(Priority_Standard)
 			c.CalculateGasEstimations(GasEstimationRequest{
 				GasEstimationEnabled: true,
-				FallbackGasPrice:     c.Cfg.Network.GasPrice,
-				FallbackGasFeeCap:    c.Cfg.Network.GasFeeCap,
-				FallbackGasTipCap:    c.Cfg.Network.GasTipCap,
+				FallbackGasPrice:     gasPrice,
+				FallbackGasFeeCap:    gasFeeCap,
+				FallbackGasTipCap:    gasTipCap,
 				Priority:             Priority_Standard,
 			})
 		}
@@ -398,6 +669,10 @@ func NewClientRaw(
 		}
 	}
 
+	if c.TxJournal != nil {
+		c.resumeJournal()
+	}
+
 	return c, nil
 }
 
@@ -408,7 +683,8 @@ func (m *Client) checkRPCHealth() error {
 
 	gasPrice, err := m.GetSuggestedLegacyFees(context.Background(), Priority_Standard)
 	if err != nil {
-		gasPrice = big.NewInt(m.Cfg.Network.GasPrice)
+		fallbackGasPrice, _, _ := m.Cfg.Network.FallbackFees(Priority_Standard)
+		gasPrice = big.NewInt(fallbackGasPrice)
 	}
 
 	err = m.TransferETHFromKey(ctx, 0, m.Addresses[0].Hex(), big.NewInt(10_000), gasPrice)
@@ -427,6 +703,38 @@ func (m *Client) checkRPCHealth() error {
 // At the same time we also return decoded transaction, so contrary to go convention you might get both error and result.
 // Last, but not least, if gas bumps are enabled, we will try to bump gas on transaction timeout and resubmit it with higher gas.
 func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction, error) {
+	return m.decode(tx, txErr, nil)
+}
+
+// decode is the implementation of Decode, which additionally attaches annotations to decoded transaction and its trace
+func (m *Client) decode(tx *types.Transaction, txErr error, annotations map[string]string) (*DecodedTransaction, error) {
+	decoded, err := m.decodeAndTrace(tx, txErr, annotations)
+	// recorded once expected events are checked, so that their warnings are included
+	defer m.recordSessionTx(decoded)
+	m.Metrics.record(decoded)
+	if err != nil {
+		m.printTxSummary(decoded)
+		return decoded, ClassifyError(err)
+	}
+
+	if expectedErr := m.checkExpectedEvents(decoded); expectedErr != nil {
+		if m.ExpectedEvents.Policy() == ExpectedEventsPolicy_Fail {
+			m.printTxSummary(decoded)
+			return decoded, expectedErr
+		}
+		L.Warn().
+			Err(expectedErr).
+			Str("Transaction", decoded.Hash).
+			Msg("Transaction succeeded, but some expected events are missing. Contract might have swallowed an error")
+		decoded.addWarning(DecodeWarning_MissingExpectedEvents, expectedErr.Error())
+	}
+
+	m.printTxSummary(decoded)
+	return decoded, nil
+}
+
+// decodeAndTrace waits for transaction to be mined, decodes it and traces it according to tracing level
+func (m *Client) decodeAndTrace(tx *types.Transaction, txErr error, annotations map[string]string) (*DecodedTransaction, error) {
 	if len(m.Errors) > 0 {
 		return nil, verr.Join(m.Errors...)
 	}
@@ -456,15 +764,28 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 	// if transaction was not mined, we will retry it with gas bumping, but only if gas bumping is enabled
 	// and if the transaction was not mined in time, other errors will be returned as is
 	var receipt *types.Receipt
+	timing := m.newInclusionTiming(context.Background(), l)
+	// nonce of the transaction is reserved from the first gas bump until receipt is found (or waiting is given up on)
+	releaseNonce := m.reserveNonceForBump(nil)
+	bumped := false
+	var attempt uint
 	err := retry.Do(
 		func() error {
 			var err error
+			attempt++
+			if attempt < m.Cfg.GasBumpRetries() && m.Chaos.forceGasBump(l) {
+				return context.DeadlineExceeded
+			}
 			ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
-			receipt, err = m.WaitMined(ctx, l, m.Client, tx)
+			receipt, err = m.waitMined(ctx, l, m.Client, tx, timing)
 			cancel()
 
 			return err
 		}, retry.OnRetry(func(i uint, retryErr error) {
+			if !bumped {
+				releaseNonce = m.reserveNonceForBump(tx)
+				bumped = true
+			}
 			replacementTx, replacementErr := prepareReplacementTransaction(m, tx)
 			if replacementErr != nil {
 				L.Debug().Str("Replacement error", replacementErr.Error()).Str("Current error", retryErr.Error()).Uint("Attempt", i).Msg("Failed to prepare replacement transaction. Retrying without the original one")
@@ -473,6 +794,7 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 				L.Debug().Str("Current error", retryErr.Error()).Uint("Attempt", i).Msg("Waiting for transaction to be confirmed after gas bump")
 			}
 			tx = replacementTx
+			timing.GasBumps++
 		}),
 		retry.DelayType(retry.FixedDelay),
 		// unless attempts is at least 1 retry.Do won't execute at all
@@ -487,6 +809,7 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 			return m.Cfg.GasBumpRetries() != 0 && errors.Is(err, context.DeadlineExceeded)
 		}),
 	)
+	releaseNonce()
 
 	if err != nil {
 		L.Trace().
@@ -497,34 +820,74 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 
 	var revertErr error
 	if receipt.Status == 0 {
-		revertErr = m.callAndGetRevertReason(tx, receipt)
+		revertErr = withKind(ErrReverted, m.callAndGetRevertReason(tx, receipt))
 	}
 
-	decoded, decodeErr := m.decodeTransaction(l, tx, receipt)
+	timing.finish(receipt)
+	l.Debug().
+		Str("Duration", timing.Duration.String()).
+		Int("Polls", timing.Polls).
+		Uint64("Blocks elapsed", timing.BlocksElapsed).
+		Int("Gas bumps", timing.GasBumps).
+		Msg("Transaction inclusion timing")
 
-	if decodeErr != nil && errors.Is(decodeErr, errors.New(ErrNoABIMethod)) {
-		if m.Cfg.hasOutput(TraceOutput_JSON) {
-			L.Trace().
+	decoded, decodeErr := m.decodeTransaction(l, tx, receipt)
+	decoded.Annotations = annotations
+	decoded.Timing = timing
+	if decoded.Blob = decodeBlobData(tx, receipt); decoded.Blob != nil {
+		l.Debug().
+			Int("Blobs", len(decoded.Blob.BlobHashes)).
+			Uint64("Blob gas used", decoded.Blob.BlobGasUsed).
+			Interface("Blob gas price", decoded.Blob.BlobGasPrice).
+			Msg("Decoded blob transaction")
+	}
+	if ranOutOfGas(tx, receipt) {
+		revertErr = outOfGasErr(tx, receipt, revertErr)
+		decoded.addWarning(DecodeWarning_OutOfGas, revertErr.Error())
+	}
+	if decoded.ExplorerURL = m.TxExplorerURL(tx.Hash().Hex()); decoded.ExplorerURL != "" {
+		l.Info().
+			Str("URL", decoded.ExplorerURL).
+			Msg("Transaction mined")
+	}
+	if receipt.EffectiveGasPrice == nil {
+		decoded.addWarning(DecodeWarning_GasDataUnavailable, "receipt has no effective gas price")
+	}
+	if decodeErr != nil {
+		if strings.Contains(decodeErr.Error(), ErrNoABIMethod) {
+			decoded.addWarning(DecodeWarning_MissingABI, decodeErr.Error())
+		} else {
+			l.Debug().
 				Err(decodeErr).
-				Msg("Failed to decode transaction. Saving transaction data hash as JSON")
-
-			err = CreateOrAppendToJsonArray(m.Cfg.revertedTransactionsFile, tx.Hash().Hex())
-			if err != nil {
-				l.Warn().
-					Err(err).
-					Str("TXHash", tx.Hash().Hex()).
-					Msg("Failed to save reverted transaction hash to file")
-			} else {
-				l.Trace().
-					Str("TXHash", tx.Hash().Hex()).
-					Msg("Saved reverted transaction to file")
-			}
+				Msg("Failed to decode transaction")
+			decoded.addWarning(DecodeWarning_DecodingFailed, decodeErr.Error())
 		}
+	}
+
+	if m.Recorder != nil && receipt.Status == types.ReceiptStatusSuccessful {
+		m.Recorder.recordCall(tx, decoded)
+	}
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		m.saveRevertedTransaction(l, tx, receipt, decoded, revertErr)
+	}
+
+	// top-level call without ABI can't be decoded, so in strict mode we fail right away
+	if m.Cfg.StrictTracing && decodeErr != nil && strings.Contains(decodeErr.Error(), ErrNoABIMethod) {
 		m.printDecodedTXData(l, decoded)
-		return decoded, revertErr
+		to := UNKNOWN
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		strictErr := fmt.Errorf("%s: selector 0x%s to %s (missing ABI)", ErrUndecodedCalls, common.Bytes2Hex(tx.Data()[:4]), to)
+		if revertErr != nil {
+			return decoded, verr.Join(revertErr, strictErr)
+		}
+		return decoded, strictErr
 	}
 
-	if m.Cfg.TracingLevel == TracingLevel_None {
+	tracingLevel := m.tracingLevel()
+	if tracingLevel == TracingLevel_None {
 		L.Trace().
 			Str("Transaction Hash", tx.Hash().Hex()).
 			Msg("Tracing level is NONE, skipping decoding")
@@ -532,41 +895,46 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 		return decoded, revertErr
 	}
 
-	if m.Cfg.TracingLevel == TracingLevel_All || (m.Cfg.TracingLevel == TracingLevel_Reverted && revertErr != nil) {
-		traceErr := m.Tracer.TraceGethTX(decoded.Hash, revertErr)
-		if traceErr != nil {
-			if m.Cfg.hasOutput(TraceOutput_JSON) {
-				L.Trace().
-					Err(traceErr).
-					Msg("Failed to trace call, but decoding was successful. Saving decoded data as JSON")
-
-				path, saveErr := saveAsJson(decoded, filepath.Join(m.Cfg.ArtifactsDir, "traces"), decoded.Hash)
-				if saveErr != nil {
-					L.Warn().
-						Err(saveErr).
-						Msg("Failed to save decoded call as JSON")
-				} else {
-					L.Trace().
-						Str("Path", path).
-						Str("Tx hash", decoded.Hash).
-						Msg("Saved decoded transaction data to JSON")
-				}
-			}
+	if tracingLevel == TracingLevel_All || (tracingLevel == TracingLevel_Reverted && revertErr != nil) {
+		if m.TracingQueue != nil {
+			m.TracingQueue.Enqueue(l, decoded, revertErr)
+			return decoded, revertErr
+		}
 
-			if strings.Contains(traceErr.Error(), "debug_traceTransaction does not exist") {
-				L.Warn().
-					Err(err).
-					Msg("Debug API is either disabled or not available on the node. Disabling tracing")
+		if traceErr := m.traceDecodedTransaction(l, decoded, revertErr); traceErr != nil {
+			decoded.addWarning(DecodeWarning_TracingFailed, traceErr.Error())
+			return decoded, m.handleTracingFailure(traceErr, revertErr)
+		}
 
-				m.Cfg.TracingLevel = TracingLevel_None
+		if m.Cfg.StrictTracing {
+			if strictErr := undecodedCallsErr(m.Tracer.GetDecodedCalls(decoded.Hash)); strictErr != nil {
+				if revertErr != nil {
+					return decoded, verr.Join(revertErr, strictErr)
+				}
+				return decoded, strictErr
 			}
-
-			m.printDecodedTXData(l, decoded)
-			return decoded, revertErr
 		}
+	} else {
+		L.Trace().
+			Str("Transaction Hash", tx.Hash().Hex()).
+			Str("Tracing level", tracingLevel).
+			Bool("Was reverted?", revertErr != nil).
+			Msg("Transaction doesn't match tracing level, skipping decoding")
+	}
 
+	return decoded, revertErr
+}
+
+// traceDecodedTransaction traces decoded transaction and saves the results to configured outputs. It returns tracing error, if any.
+func (m *Client) traceDecodedTransaction(l zerolog.Logger, decoded *DecodedTransaction, revertErr error) error {
+	traceErr := m.Tracer.traceTX(decoded.Hash, decoded, revertErr)
+	if traceErr != nil {
 		if m.Cfg.hasOutput(TraceOutput_JSON) {
-			path, saveErr := saveAsJson(m.Tracer.GetDecodedCalls(decoded.Hash), filepath.Join(m.Cfg.ArtifactsDir, "traces"), decoded.Hash)
+			L.Trace().
+				Err(traceErr).
+				Msg("Failed to trace call, but decoding was successful. Saving decoded data as JSON")
+
+			path, saveErr := saveTraceAsJson(m.Cfg, decoded, filepath.Join(m.Cfg.ArtifactsDir, "traces"), decoded.Hash)
 			if saveErr != nil {
 				L.Warn().
 					Err(saveErr).
@@ -575,32 +943,127 @@ func (m *Client) Decode(tx *types.Transaction, txErr error) (*DecodedTransaction
 				L.Trace().
 					Str("Path", path).
 					Str("Tx hash", decoded.Hash).
-					Msg("Saved decoded call data to JSON")
+					Msg("Saved decoded transaction data to JSON")
 			}
 		}
-	} else {
-		L.Trace().
-			Str("Transaction Hash", tx.Hash().Hex()).
-			Str("Tracing level", m.Cfg.TracingLevel).
-			Bool("Was reverted?", revertErr != nil).
-			Msg("Transaction doesn't match tracing level, skipping decoding")
+
+		m.printDecodedTXData(l, decoded)
+
+		return traceErr
 	}
 
-	return decoded, revertErr
+	if len(decoded.Annotations) > 0 {
+		for _, call := range m.Tracer.GetDecodedCalls(decoded.Hash) {
+			call.Annotations = decoded.Annotations
+		}
+	}
+
+	if m.Cfg.hasOutput(TraceOutput_JSON) {
+		path, saveErr := saveTraceAsJson(m.Cfg, m.Tracer.GetDecodedCalls(decoded.Hash), filepath.Join(m.Cfg.ArtifactsDir, "traces"), decoded.Hash)
+		if saveErr != nil {
+			L.Warn().
+				Err(saveErr).
+				Msg("Failed to save decoded call as JSON")
+		} else {
+			L.Trace().
+				Str("Path", path).
+				Str("Tx hash", decoded.Hash).
+				Msg("Saved decoded call data to JSON")
+		}
+	}
+
+	return nil
+}
+
+// Close traces all transactions queued for asynchronous tracing, stops tracing workers and closes the RPC connection.
+// Client cannot be used after it was closed.
+func (m *Client) Close() {
+	if m.TracingQueue != nil {
+		m.TracingQueue.Close()
+	}
+	if m.Client != nil {
+		m.Client.Close()
+	}
+}
+
+// FlushTraces blocks until all transactions queued for asynchronous tracing have been traced. It returns immediately if
+// asynchronous tracing is disabled.
+func (m *Client) FlushTraces() {
+	if m.TracingQueue != nil {
+		m.TracingQueue.Flush()
+	}
+}
+
+// tracingLevel returns tracing level that is in effect, which is TracingLevel_None once tracing was disabled because of
+// tracing failures
+func (m *Client) tracingLevel() string {
+	if m.tracingDisabled.Load() {
+		return TracingLevel_None
+	}
+	return m.Cfg.TracingLevel
+}
+
+// handleTracingFailure applies configured tracing failure policy. It returns the error that Decode should return.
+func (m *Client) handleTracingFailure(traceErr, revertErr error) error {
+	traceMethodMissing := strings.Contains(traceErr.Error(), "debug_traceTransaction does not exist") || strings.Contains(traceErr.Error(), "trace_transaction does not exist")
+	if traceMethodMissing && m.Cfg.TracingFailurePolicy != TracingFailurePolicy_Fail {
+		L.Warn().
+			Err(traceErr).
+			Msg("Debug API is either disabled or not available on the node. Disabling tracing")
+
+		m.tracingDisabled.Store(true)
+
+		return revertErr
+	}
+
+	switch m.Cfg.TracingFailurePolicy {
+	case TracingFailurePolicy_Fail:
+		if revertErr != nil {
+			return verr.Join(revertErr, errors.Wrap(traceErr, ErrTracingFailed))
+		}
+		return errors.Wrap(traceErr, ErrTracingFailed)
+	case TracingFailurePolicy_DisableAfterFailures:
+		failures := m.tracingFailures.Add(1)
+		L.Warn().
+			Err(traceErr).
+			Int64("Failures", failures).
+			Uint("Max failures", m.Cfg.TracingFailuresBeforeDisable).
+			Msg("Failed to trace transaction")
+
+		if failures >= int64(m.Cfg.TracingFailuresBeforeDisable) {
+			L.Warn().
+				Msgf("Tracing failed %d times. Disabling tracing", failures)
+
+			m.tracingDisabled.Store(true)
+		}
+	default:
+		L.Warn().
+			Err(traceErr).
+			Msg("Failed to trace transaction. Continuing without tracing data")
+	}
+
+	return revertErr
 }
 
 func (m *Client) TransferETHFromKey(ctx context.Context, fromKeyNum int, to string, value *big.Int, gasPrice *big.Int) error {
 	if fromKeyNum > len(m.PrivateKeys) || fromKeyNum > len(m.Addresses) {
 		return errors.Wrap(errors.New(ErrNoKeyLoaded), fmt.Sprintf("requested key: %d", fromKeyNum))
 	}
+
+	from, privateKey := m.key(fromKeyNum)
+	return m.transferETHFromPrivateKey(ctx, from, privateKey, to, value, gasPrice)
+}
+
+// transferETHFromPrivateKey transfers ETH from given address/private key, which doesn't have to be one of client's keys
+func (m *Client) transferETHFromPrivateKey(ctx context.Context, from common.Address, privateKey *ecdsa.PrivateKey, to string, value *big.Int, gasPrice *big.Int) error {
 	toAddr := common.HexToAddress(to)
-	chainID, err := m.Client.NetworkID(context.Background())
+	chainID, err := m.Client.NetworkID(ctx)
 	if err != nil {
 		return errors.Wrap(err, "failed to get network ID")
 	}
 
 	var gasLimit int64
-	gasLimitRaw, err := m.EstimateGasLimitForFundTransfer(m.Addresses[fromKeyNum], common.HexToAddress(to), value)
+	gasLimitRaw, err := m.estimateGasLimitForFundTransfer(ctx, from, common.HexToAddress(to), value)
 	if err != nil {
 		gasLimit = m.Cfg.Network.TransferGasFee
 	} else {
@@ -612,17 +1075,24 @@ func (m *Client) TransferETHFromKey(ctx context.Context, fromKeyNum int, to stri
 	}
 
 	rawTx := &types.LegacyTx{
-		Nonce:    m.NonceManager.NextNonce(m.Addresses[fromKeyNum]).Uint64(),
+		Nonce:    m.NonceManager.NextNonce(from).Uint64(),
 		To:       &toAddr,
 		Value:    value,
 		Gas:      uint64(gasLimit),
 		GasPrice: gasPrice,
 	}
 	L.Debug().Interface("TransferTx", rawTx).Send()
-	signedTx, err := types.SignNewTx(m.PrivateKeys[fromKeyNum], types.NewEIP155Signer(chainID), rawTx)
+	if err := m.checkBalanceIfEnabled(from, types.NewTx(rawTx)); err != nil {
+		return err
+	}
+	if err := m.budget.check(); err != nil {
+		return err
+	}
+	signedTx, err := m.signNewTx(from, privateKey, types.NewEIP155Signer(chainID), rawTx)
 	if err != nil {
 		return errors.Wrap(err, "failed to sign tx")
 	}
+	m.journalTx(signedTx, fmt.Sprintf("transfer to %s", toAddr.Hex()))
 
 	ctx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
 	defer cancel()
@@ -632,7 +1102,7 @@ func (m *Client) TransferETHFromKey(ctx context.Context, fromKeyNum int, to stri
 	}
 	l := L.With().Str("Transaction", signedTx.Hash().Hex()).Logger()
 	l.Info().
-		Int("FromKeyNum", fromKeyNum).
+		Str("From", from.Hex()).
 		Str("To", to).
 		Interface("Value", value).
 		Msg("Send ETH")
@@ -645,35 +1115,8 @@ func (m *Client) TransferETHFromKey(ctx context.Context, fromKeyNum int, to stri
 
 // WaitMined the same as bind.WaitMined, awaits transaction receipt until timeout
 func (m *Client) WaitMined(ctx context.Context, l zerolog.Logger, b bind.DeployBackend, tx *types.Transaction) (*types.Receipt, error) {
-	queryTicker := time.NewTicker(time.Second)
-	defer queryTicker.Stop()
-	ctx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
-	defer cancel()
-	for {
-		receipt, err := b.TransactionReceipt(ctx, tx.Hash())
-		if err == nil {
-			l.Info().
-				Int64("BlockNumber", receipt.BlockNumber.Int64()).
-				Str("TX", tx.Hash().String()).
-				Msg("Transaction receipt found")
-			return receipt, nil
-		} else if errors.Is(err, ethereum.NotFound) {
-			l.Debug().
-				Str("TX", tx.Hash().String()).
-				Msg("Awaiting transaction")
-		} else {
-			l.Warn().
-				Err(err).
-				Str("TX", tx.Hash().String()).
-				Msg("Failed to get receipt")
-		}
-		select {
-		case <-ctx.Done():
-			l.Error().Err(err).Msg("Transaction context is done")
-			return nil, ctx.Err()
-		case <-queryTicker.C:
-		}
-	}
+	receipt, err := m.waitMined(ctx, l, b, tx, &InclusionTiming{})
+	return receipt, ClassifyError(err)
 }
 
 /* ClientOpts client functional options */
@@ -751,7 +1194,7 @@ func (m *Client) NewCallOpts(o ...CallOpt) *bind.CallOpts {
 func (m *Client) NewCallKeyOpts(keyNum int, o ...CallOpt) *bind.CallOpts {
 	co := &bind.CallOpts{
 		Pending: false,
-		From:    m.Addresses[keyNum],
+		From:    m.keyAddress(keyNum),
 	}
 	for _, f := range o {
 		f(co)
@@ -849,11 +1292,17 @@ func (m *Client) NewTXKeyOpts(keyNum int, o ...TransactOpt) *bind.TransactOpts {
 
 		return opts
 	}
+	if m.KeyRotator != nil {
+		m.KeyRotator.RotateIfNeeded(keyNum)
+	}
 	L.Debug().
 		Interface("KeyNum", keyNum).
-		Interface("Address", m.Addresses[keyNum]).
+		Interface("Address", m.keyAddress(keyNum)).
 		Msg("Estimating transaction")
 	opts, nonceStatus, estimations := m.getProposedTransactionOptions(keyNum)
+	if m.KeyRotator != nil {
+		m.KeyRotator.RecordUse(keyNum, nonceStatus.PendingNonce)
+	}
 
 	m.configureTransactionOpts(opts, nonceStatus.PendingNonce, estimations, o...)
 	L.Debug().
@@ -887,13 +1336,24 @@ type NonceStatus struct {
 func (m *Client) getNonceStatus(address common.Address) (NonceStatus, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
 	defer cancel()
-	pendingNonce, err := m.Client.PendingNonceAt(ctx, address)
+	pendingNonce, err := m.nonceOf(ctx, address, BlockTag_Pending)
 	if err != nil {
 		L.Error().Err(err).Msg("Failed to get pending nonce")
 		return NonceStatus{}, err
 	}
+	if m.NonceManager != nil {
+		// nonce of transaction that is being replaced might not be visible in the pending state, don't reuse it
+		if unreserved := m.NonceManager.unreservedNonce(address, pendingNonce); unreserved != pendingNonce {
+			L.Debug().
+				Str("Address", address.Hex()).
+				Uint64("Pending nonce", pendingNonce).
+				Uint64("Nonce", unreserved).
+				Msg("Pending nonce is reserved by gas bumping. Using next unreserved one")
+			pendingNonce = unreserved
+		}
+	}
 
-	lastNonce, err := m.Client.NonceAt(ctx, address, nil)
+	lastNonce, err := m.nonceOf(ctx, address, BlockTag_Latest)
 	if err != nil {
 		return NonceStatus{}, err
 	}
@@ -906,7 +1366,7 @@ func (m *Client) getNonceStatus(address common.Address) (NonceStatus, error) {
 
 // getProposedTransactionOptions gets all the tx info that network proposed
 func (m *Client) getProposedTransactionOptions(keyNum int) (*bind.TransactOpts, NonceStatus, GasEstimations) {
-	nonceStatus, err := m.getNonceStatus(m.Addresses[keyNum])
+	nonceStatus, err := m.getNonceStatus(m.keyAddress(keyNum))
 	if err != nil {
 		m.Errors = append(m.Errors, err)
 		// can't return nil, otherwise RPC wrapper will panic
@@ -945,7 +1405,7 @@ This issue is caused by one of two things:
 		Interface("GasEstimations", estimations).
 		Msg("Proposed transaction options")
 
-	opts, err := bind.NewKeyedTransactorWithChainID(m.PrivateKeys[keyNum], big.NewInt(m.ChainID))
+	opts, err := m.newTransactor(keyNum)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to create transactor for key %d", keyNum)
 		m.Errors = append(m.Errors, err)
@@ -961,6 +1421,25 @@ This issue is caused by one of two things:
 		opts.Context = ctx
 	}
 
+	if m.Cfg.CalldataValidation != nil {
+		opts.Signer = m.validatingSigner(opts.Signer)
+	}
+
+	if m.Cfg.Network.BalanceCheckEnabled {
+		opts.Signer = m.balanceCheckingSigner(opts.Signer)
+	}
+
+	if m.budget != nil {
+		opts.Signer = m.budgetCheckingSigner(opts.Signer)
+	}
+
+	if m.TxJournal != nil {
+		opts.Signer = m.journalingSigner(opts.Signer)
+	}
+
+	// must be the last one, so that all other signers see blob transaction
+	opts.Signer = m.blobSigner(opts, opts.Signer)
+
 	return opts, nonceStatus, estimations
 }
 
@@ -972,14 +1451,21 @@ type GasEstimationRequest struct {
 	Priority             string
 }
 
-// NewDefaultGasEstimationRequest creates a new default gas estimation request based on current network configuration
+// NewDefaultGasEstimationRequest creates a new default gas estimation request based on current network configuration.
+// Fallback values are the ones configured for network's transaction priority (see Network.FallbackFees()).
 func (m *Client) NewDefaultGasEstimationRequest() GasEstimationRequest {
+	return m.NewGasEstimationRequest(m.Cfg.Network.GasPriceEstimationTxPriority)
+}
+
+// NewGasEstimationRequest creates a new gas estimation request for given priority with fallback values configured for it
+func (m *Client) NewGasEstimationRequest(priority string) GasEstimationRequest {
+	gasPrice, gasFeeCap, gasTipCap := m.Cfg.Network.FallbackFees(priority)
 	return GasEstimationRequest{
 		GasEstimationEnabled: m.Cfg.Network.GasPriceEstimationEnabled,
-		FallbackGasPrice:     m.Cfg.Network.GasPrice,
-		FallbackGasFeeCap:    m.Cfg.Network.GasFeeCap,
-		FallbackGasTipCap:    m.Cfg.Network.GasTipCap,
-		Priority:             m.Cfg.Network.GasPriceEstimationTxPriority,
+		FallbackGasPrice:     gasPrice,
+		FallbackGasFeeCap:    gasFeeCap,
+		FallbackGasTipCap:    gasTipCap,
+		Priority:             priority,
 	}
 }
 
@@ -1047,7 +1533,12 @@ func (m *Client) CalculateGasEstimations(request GasEstimationRequest) GasEstima
 
 // EstimateGasLimitForFundTransfer estimates gas limit for fund transfer
 func (m *Client) EstimateGasLimitForFundTransfer(from, to common.Address, amount *big.Int) (uint64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	return m.estimateGasLimitForFundTransfer(context.Background(), from, to, amount)
+}
+
+// estimateGasLimitForFundTransfer works like EstimateGasLimitForFundTransfer, but stops when given context is done
+func (m *Client) estimateGasLimitForFundTransfer(ctx context.Context, from, to common.Address, amount *big.Int) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
 	defer cancel()
 	gasLimit, err := m.Client.EstimateGas(ctx, ethereum.CallMsg{
 		From:  from,
@@ -1111,6 +1602,7 @@ func (cl *ContractLoader[T]) LoadContract(name string, address common.Address, a
 // DeployContract deploys contract using ABI and bytecode passed to it, waits for transaction to be minted and contract really
 // available at the address, so that when the method returns it's safe to interact with it. It also saves the contract address and ABI name
 // to the contract map, so that we can use that, when tracing transactions. It is suggested to use name identical to the name of the contract Solidity file.
+// If deployment transaction disappears from the network and deployment_redeploy_retries is set, deployment is sent again with a fresh nonce.
 func (m *Client) DeployContract(auth *bind.TransactOpts, name string, abi abi.ABI, bytecode []byte, params ...interface{}) (DeploymentData, error) {
 	L.Info().
 		Msgf("Started deploying %s contract", name)
@@ -1121,24 +1613,164 @@ func (m *Client) DeployContract(auth *bind.TransactOpts, name string, abi abi.AB
 		}
 	}
 
-	address, tx, contract, err := bind.DeployContract(auth, abi, bytecode, m.Client, params...)
-	if err != nil {
-		return DeploymentData{}, wrapErrInMessageWithASuggestion(err)
+	if err := m.CheckDeploymentCodeSize(auth.From, abi, bytecode, params...); err != nil {
+		return DeploymentData{}, errors.Wrapf(err, "contract %s cannot be deployed", name)
 	}
 
-	L.Info().
+	if err := checkPayableConstructor(name, abi, auth.Value); err != nil {
+		return DeploymentData{}, err
+	}
+
+	var (
+		address  common.Address
+		tx       *types.Transaction
+		contract *bind.BoundContract
+		label    string
+		err      error
+	)
+	// deployment transaction can disappear from the network (e.g. evicted from the mempool of a flaky testnet), in that case
+	// it's sent again with a fresh nonce, but only a limited number of times
+	for redeploys := uint(0); ; redeploys++ {
+		address, tx, contract, err = bind.DeployContract(auth, abi, bytecode, m.Client, params...)
+		if err != nil {
+			return DeploymentData{}, ClassifyError(wrapErrInMessageWithASuggestion(err))
+		}
+
+		L.Info().
+			Str("Address", address.Hex()).
+			Str("TXHash", tx.Hash().Hex()).
+			Msgf("Waiting for %s contract deployment to finish", name)
+
+		if m.TxJournal != nil {
+			// signer has already journaled the transaction, but it didn't know which contract is being deployed
+			m.journalTx(tx, fmt.Sprintf("deployment of %s", name))
+		}
+
+		m.ContractAddressToNameMap.AddContract(address.Hex(), name)
+		label = m.ContractAddressToNameMap.labelNewInstance(address.Hex())
+		if label != "" {
+			L.Debug().
+				Str("Address", address.Hex()).
+				Str("Label", label).
+				Msgf("Labelled another instance of %s contract", name)
+		}
+
+		if _, ok := m.ContractStore.GetABI(name); !ok {
+			m.ContractStore.AddABI(name, abi)
+		}
+
+		tx, err = m.waitForDeployment(tx)
+		if err == nil {
+			break
+		}
+
+		dropped := m.isDeploymentDropped(tx, address)
+		if redeploys >= m.Cfg.Network.DeploymentRedeployRetries || !dropped {
+			// pass this specific error, so that Decode knows that it's not the actual revert reason
+			_, _ = m.Decode(tx, errors.New(ErrContractDeploymentFailed))
+
+			err = ClassifyError(wrapErrInMessageWithASuggestion(m.rewriteDeploymentError(err)))
+			if dropped {
+				err = withKind(ErrDropped, err)
+			}
+			return DeploymentData{}, err
+		}
+
+		L.Warn().
+			Str("Address", address.Hex()).
+			Str("TXHash", tx.Hash().Hex()).
+			Uint64("Nonce", tx.Nonce()).
+			Uint("Redeploy", redeploys+1).
+			Uint("Max redeploys", m.Cfg.Network.DeploymentRedeployRetries).
+			Msgf("Deployment transaction of %s contract disappeared from the network. Redeploying it", name)
+
+		// dropped transaction will never be mined, new one might be deployed to a different address
+		m.journalDone(tx)
+		m.ContractAddressToNameMap.removeContract(address.Hex())
+		auth, err = m.redeploymentOpts(auth)
+		if err != nil {
+			return DeploymentData{}, errors.Wrapf(err, "failed to redeploy %s contract", name)
+		}
+	}
+
+	m.journalDone(tx)
+
+	data := DeploymentData{Address: address, Transaction: tx, BoundContract: contract, Value: tx.Value(), ConstructorArgs: params}
+	code, codeErr := m.deployedCode(address)
+	if codeErr == nil {
+		data.CodeSize = len(code)
+		data.Pragma = pragmaOf(code)
+	}
+	data.GasUsed = m.deploymentGasUsed(tx)
+
+	deployedLog := L.Info().
 		Str("Address", address.Hex()).
 		Str("TXHash", tx.Hash().Hex()).
-		Msgf("Waiting for %s contract deployment to finish", name)
+		Str("Value", m.FormatNativeAmount(tx.Value())).
+		Int("Code size", data.CodeSize).
+		Uint64("Gas used", data.GasUsed)
+	if data.Pragma != nil {
+		deployedLog = deployedLog.Str("Pragma", data.Pragma.String())
+	}
+	if url := m.AddressExplorerURL(address); url != "" {
+		deployedLog = deployedLog.Str("URL", url)
+	}
+	deployedLog.Msgf("Deployed %s contract", name)
+
+	if err := m.verifyDeployedBalance(name, address, tx.Value()); err != nil {
+		return DeploymentData{}, err
+	}
+
+	if m.Recorder != nil {
+		m.Recorder.recordDeployment(name, abi, bytecode, auth.From, address, tx, params...)
+	}
 
-	m.ContractAddressToNameMap.AddContract(address.Hex(), name)
+	codeHash := crypto.Keccak256Hash(code)
+	if codeErr != nil {
+		L.Debug().
+			Err(codeErr).
+			Msg("Failed to get deployed code. Contract map entry won't be verified")
+	} else {
+		m.ContractAddressToNameMap.AddContractWithCodeHash(address.Hex(), name, codeHash)
+	}
 
-	if _, ok := m.ContractStore.GetABI(name); !ok {
-		m.ContractStore.AddABI(name, abi)
+	if !m.Cfg.ShouldSaveDeployedContractMap() {
+		return data, nil
 	}
 
+	if err := SaveDeployedContract(m.Cfg.ContractMapFile, name, address.Hex()); err != nil {
+		L.Warn().
+			Err(err).
+			Msg("Failed to save deployed contract address to file")
+	}
+
+	if codeErr == nil {
+		if err := SaveDeployedContractCodeHash(m.Cfg.ContractMapFile, address.Hex(), codeHash); err != nil {
+			L.Warn().
+				Err(err).
+				Msg("Failed to save deployed contract code hash to file")
+		}
+	}
+
+	if label != "" {
+		if err := SaveContractLabel(m.Cfg.ContractMapFile, address.Hex(), label); err != nil {
+			L.Warn().
+				Err(err).
+				Msg("Failed to save deployed contract label to file")
+		}
+	}
+
+	return data, nil
+}
+
+// waitForDeployment waits until contract is deployed, bumping gas of the deployment transaction if it's enabled and
+// transaction isn't mined in time. It returns the last sent transaction, which might be a replacement of the original one.
+func (m *Client) waitForDeployment(tx *types.Transaction) (*types.Transaction, error) {
+	// nonce of deployment transaction is reserved from the first gas bump until deployment is finished (or given up on)
+	releaseNonce := m.reserveNonceForBump(nil)
+	bumped := false
 	// retry is needed both for gas bumping and for waiting for deployment to finish (sometimes there's no code at address the first time we check)
-	if err := retry.Do(
+	err := retry.Do(
 		func() error {
 			ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
 			_, err := bind.WaitDeployed(ctx, m.Client, tx)
@@ -1163,6 +1795,10 @@ func (m *Client) DeployContract(auth *bind.TransactOpts, name string, abi abi.AB
 		}, retry.OnRetry(func(i uint, retryErr error) {
 			switch {
 			case errors.Is(retryErr, context.DeadlineExceeded):
+				if !bumped {
+					releaseNonce = m.reserveNonceForBump(tx)
+					bumped = true
+				}
 				replacementTx, replacementErr := prepareReplacementTransaction(m, tx)
 				if replacementErr != nil {
 					L.Debug().Str("Current error", retryErr.Error()).Str("Replacement error", replacementErr.Error()).Uint("Attempt", i+1).Msg("Failed to prepare replacement transaction for contract deployment. Retrying with the original one")
@@ -1189,29 +1825,77 @@ func (m *Client) DeployContract(auth *bind.TransactOpts, name string, abi abi.AB
 				strings.Contains(strings.ToLower(err.Error()), "no contract code after deployment") ||
 				(m.Cfg.GasBumpRetries() != 0 && errors.Is(err, context.DeadlineExceeded))
 		}),
-	); err != nil {
-		// pass this specific error, so that Decode knows that it's not the actual revert reason
-		_, _ = m.Decode(tx, errors.New(ErrContractDeploymentFailed))
+	)
+	releaseNonce()
+
+	return tx, err
+}
+
+// isDeploymentDropped returns true if deployment transaction (and all its replacements) disappeared from the network: it's
+// neither mined nor pending, its nonce wasn't used and there's no code at contract address
+func (m *Client) isDeploymentDropped(tx *types.Transaction, address common.Address) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
 
-		return DeploymentData{}, wrapErrInMessageWithASuggestion(m.rewriteDeploymentError(err))
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Debug().Err(err).Msg("Failed to get sender of deployment transaction")
+		return false
+	}
+	if _, _, err := m.Client.TransactionByHash(ctx, tx.Hash()); !errors.Is(err, ethereum.NotFound) {
+		return false
+	}
+	// if any transaction with the same nonce is pending or mined, pending nonce is higher
+	pendingNonce, err := m.nonceOf(ctx, from, BlockTag_Pending)
+	if err != nil || pendingNonce > tx.Nonce() {
+		return false
+	}
+	code, err := m.Client.CodeAt(ctx, address, nil)
+	if err != nil || len(code) > 0 {
+		return false
 	}
 
-	L.Info().
-		Str("Address", address.Hex()).
-		Str("TXHash", tx.Hash().Hex()).
-		Msgf("Deployed %s contract", name)
+	return true
+}
 
-	if !m.Cfg.ShouldSaveDeployedContractMap() {
-		return DeploymentData{Address: address, Transaction: tx, BoundContract: contract}, nil
+// redeploymentOpts returns copy of transaction options with fresh nonce (if nonce was set), used to send deployment again
+func (m *Client) redeploymentOpts(auth *bind.TransactOpts) (*bind.TransactOpts, error) {
+	opts := *auth
+	if auth.Nonce == nil {
+		return &opts, nil
+	}
+	nonceStatus, err := m.getNonceStatus(auth.From)
+	if err != nil {
+		return nil, err
 	}
+	opts.Nonce = new(big.Int).SetUint64(nonceStatus.PendingNonce)
 
-	if err := SaveDeployedContract(m.Cfg.ContractMapFile, name, address.Hex()); err != nil {
-		L.Warn().
-			Err(err).
-			Msg("Failed to save deployed contract address to file")
+	return &opts, nil
+}
+
+// SetContractLabel sets a custom label (alias) of contract instance at given address, which is shown instead of contract name
+// in traces, journal and recordings. It's useful when there are many instances of the same contract. Labels must be unique,
+// empty label removes it. If saving of contract map is enabled, label is saved too.
+func (m *Client) SetContractLabel(address common.Address, label string) error {
+	if !m.ContractAddressToNameMap.IsKnownAddress(address.Hex()) {
+		return fmt.Errorf("%s: %s", ErrUnknownContractAddress, address.Hex())
+	}
+
+	if label != "" {
+		if other := m.ContractAddressToNameMap.addressForLabel(label); other != "" && other != strings.ToLower(address.Hex()) {
+			return fmt.Errorf("%s: '%s' is used by %s", ErrContractLabelTaken, label, other)
+		}
 	}
 
-	return DeploymentData{Address: address, Transaction: tx, BoundContract: contract}, nil
+	m.ContractAddressToNameMap.SetContractLabel(address.Hex(), label)
+
+	if m.Cfg.ShouldSaveDeployedContractMap() {
+		if err := SaveContractLabel(m.Cfg.ContractMapFile, address.Hex(), label); err != nil {
+			return errors.Wrap(err, "failed to save contract label to file")
+		}
+	}
+
+	return nil
 }
 
 // rewriteDeploymentError makes some known errors more human friendly
@@ -1247,6 +1931,54 @@ type DeploymentData struct {
 	Address       common.Address
 	Transaction   *types.Transaction
 	BoundContract *bind.BoundContract
+	// Value sent to contract's constructor
+	Value *big.Int
+	// CodeSize is the size of deployed (runtime) code in bytes, 0 if it couldn't be fetched
+	CodeSize int
+	// GasUsed by deployment transaction, 0 if its receipt couldn't be fetched
+	GasUsed uint64
+	// Pragma is the compiler version read from metadata of deployed code, nil if code has no Solidity metadata
+	Pragma *Pragma
+	// ConstructorArgs are the arguments passed to contract's constructor
+	ConstructorArgs []interface{}
+}
+
+// deployedCode returns code deployed at given address
+func (m *Client) deployedCode(address common.Address) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	return m.Client.CodeAt(ctx, address, nil)
+}
+
+// deploymentGasUsed returns gas used by deployment transaction or 0 if its receipt can't be fetched
+func (m *Client) deploymentGasUsed(tx *types.Transaction) uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	receipt, err := m.Client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		L.Debug().
+			Err(err).
+			Str("TXHash", tx.Hash().Hex()).
+			Msg("Failed to get receipt of deployment transaction. Gas used is unknown")
+		return 0
+	}
+
+	return receipt.GasUsed
+}
+
+// pragmaOf returns compiler version from metadata of the code or nil if it has none (e.g. it wasn't compiled with solc)
+func pragmaOf(code []byte) *Pragma {
+	// metadata length is encoded in the last 2 bytes
+	if len(code) < 2 {
+		return nil
+	}
+	pragma, err := DecodePragmaVersion(common.Bytes2Hex(code))
+	if err != nil {
+		return nil
+	}
+
+	return &pragma
 }
 
 // DeployContractFromContractStore deploys contract from Seth's Contract Store, waits for transaction to be minted and contract really
@@ -1336,7 +2068,7 @@ func (m *Client) WaitUntilNoPendingTxFoKeyNum(keyNum int, timeout time.Duration)
 	if keyNum > len(m.Addresses)-1 || keyNum < 0 {
 		return fmt.Errorf("keyNum is out of range. Expected %d-%d. Got: %d", 0, len(m.Addresses)-1, keyNum)
 	}
-	return m.WaitUntilNoPendingTx(m.Addresses[keyNum], timeout)
+	return m.WaitUntilNoPendingTx(m.keyAddress(keyNum), timeout)
 }
 
 // WaitUntilNoPendingTx waits until there's no pending transaction for address. If after timeout there are still pending transactions, it returns error.
@@ -0,0 +1,29 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+)
+
+//go:generate mockery --name ClientAPI --output ./mocks --outpkg mocks --filename client_api.go
+
+// ClientAPI is the subset of Client methods most commonly used by test frameworks built on top of Seth. Depend on it instead
+// of *Client if you want to replace Seth with a mock in unit tests (see mocks.ClientAPI).
+type ClientAPI interface {
+	Decode(tx *types.Transaction, txErr error) (*DecodedTransaction, error)
+	NewTXOpts(o ...TransactOpt) *bind.TransactOpts
+	NewTXKeyOpts(keyNum int, o ...TransactOpt) *bind.TransactOpts
+	NewCallOpts(o ...CallOpt) *bind.CallOpts
+	NewCallKeyOpts(keyNum int, o ...CallOpt) *bind.CallOpts
+	DeployContract(auth *bind.TransactOpts, name string, abi abi.ABI, bytecode []byte, params ...interface{}) (DeploymentData, error)
+	DeployContractFromContractStore(auth *bind.TransactOpts, name string, params ...interface{}) (DeploymentData, error)
+	TransferETHFromKey(ctx context.Context, fromKeyNum int, to string, value *big.Int, gasPrice *big.Int) error
+	WaitMined(ctx context.Context, l zerolog.Logger, b bind.DeployBackend, tx *types.Transaction) (*types.Receipt, error)
+}
+
+var _ ClientAPI = (*Client)(nil)
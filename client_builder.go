@@ -57,6 +57,51 @@ func (c *ClientBuilder) WithRpcUrl(url string) *ClientBuilder {
 	return c
 }
 
+// WithRpcUrls sets RPC URLs of the network. If there's more than one and all of them are HTTP(S), requests fail over to
+// the next URL when the active one fails, see WithRPCFailover.
+// Default value is an empty slice (which is an incorrect value).
+func (c *ClientBuilder) WithRpcUrls(urls ...string) *ClientBuilder {
+	c.config.Network.URLs = urls
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].URLs = urls
+	}
+	return c
+}
+
+// WithRPCFailover sets how requests fail over between RPC URLs: maximum number of URLs a single request is sent to (0 means
+// all of them), number of consecutive failures after which active URL is replaced with the healthiest one and how long
+// a failed URL is skipped.
+// Default values are 0 (all URLs), 1 failure and 30 seconds.
+func (c *ClientBuilder) WithRPCFailover(maxAttempts, failureThreshold uint, cooldown time.Duration) *ClientBuilder {
+	c.config.RPCFailover = &RPCFailoverConfig{
+		MaxAttempts:      maxAttempts,
+		FailureThreshold: failureThreshold,
+		Cooldown:         &Duration{D: cooldown},
+	}
+	return c
+}
+
+// WithChaos enables fault injection for testing retry and resilience logic built on top of Seth: probability of transient
+// RPC errors, of receipt polls returning no receipt, of forced gas bumps (if gas bumping is enabled) and of sends delayed
+// by up to maxSendDelay (0 means 2 seconds). Faults are drawn from a random number generator seeded with seed (0 means random seed).
+// Default value is nil, which means that no faults are injected.
+func (c *ClientBuilder) WithChaos(seed int64, rpcErrorProbability, dropReceiptPollProbability, forceGasBumpProbability, sendDelayProbability float64, maxSendDelay time.Duration) *ClientBuilder {
+	c.config.Chaos = &ChaosConfig{
+		Seed:                       seed,
+		RPCErrorProbability:        rpcErrorProbability,
+		DropReceiptPollProbability: dropReceiptPollProbability,
+		ForceGasBumpProbability:    forceGasBumpProbability,
+		SendDelayProbability:       sendDelayProbability,
+	}
+	if maxSendDelay > 0 {
+		c.config.Chaos.MaxSendDelay = &Duration{D: maxSendDelay}
+	}
+	return c
+}
+
 // WithPrivateKeys sets the private keys for the config. At least one is required to build a valid config.
 // Default value is an empty slice (which is an incorrect value).
 func (c *ClientBuilder) WithPrivateKeys(pks []string) *ClientBuilder {
@@ -70,6 +115,20 @@ func (c *ClientBuilder) WithPrivateKeys(pks []string) *ClientBuilder {
 	return c
 }
 
+// WithNodeAccounts sets addresses of accounts unlocked in the node (e.g. in dev Geth), which are used as keys after
+// private keys. Their transactions are signed by the node with eth_signTransaction.
+// Default value is an empty slice.
+func (c *ClientBuilder) WithNodeAccounts(addresses ...string) *ClientBuilder {
+	c.config.Network.NodeAccounts = addresses
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].NodeAccounts = addresses
+	}
+	return c
+}
+
 // WithNetworkName sets the network name, useful mostly for debugging and logging.
 // Default value is "default".
 func (c *ClientBuilder) WithNetworkName(name string) *ClientBuilder {
@@ -103,6 +162,25 @@ func (c *ClientBuilder) WithGasPriceEstimations(enabled bool, estimationBlocks u
 	return c
 }
 
+// WithFallbackGasPrices sets per-priority fallback values, which are used instead of the ones set with `WithLegacyGasPrice()` and
+// `WithDynamicGasPrices()` when gas estimation fails. Maps are keyed by priority ("slow", "standard", "fast"), priorities
+// without a value use the static fallback. Any of the maps can be nil.
+// Default values are nil (no per-priority fallbacks).
+func (c *ClientBuilder) WithFallbackGasPrices(gasPrices, gasFeeCaps, gasTipCaps map[string]int64) *ClientBuilder {
+	c.config.Network.FallbackGasPrices = gasPrices
+	c.config.Network.FallbackGasFeeCaps = gasFeeCaps
+	c.config.Network.FallbackGasTipCaps = gasTipCaps
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].FallbackGasPrices = gasPrices
+		c.config.Networks[0].FallbackGasFeeCaps = gasFeeCaps
+		c.config.Networks[0].FallbackGasTipCaps = gasTipCaps
+	}
+	return c
+}
+
 // WithEIP1559DynamicFees enables or disables EIP-1559 dynamic fees. If enabled, you should set gas fee cap and gas tip cap with `WithDynamicGasPrices()`
 // Default value is true.
 func (c *ClientBuilder) WithEIP1559DynamicFees(enabled bool) *ClientBuilder {
@@ -116,6 +194,95 @@ func (c *ClientBuilder) WithEIP1559DynamicFees(enabled bool) *ClientBuilder {
 	return c
 }
 
+// WithTxTypeAutoDetection enables or disables automatic detection of transaction type supported by the network on start. If
+// enabled, EIP-1559 dynamic fees are enabled or disabled based on `ChainTxTypes` registry or, for unknown chains, presence
+// of base fee in latest block and support for eth_feeHistory.
+// Default value is false.
+func (c *ClientBuilder) WithTxTypeAutoDetection(enabled bool) *ClientBuilder {
+	c.config.Network.AutoDetectTxType = enabled
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].AutoDetectTxType = enabled
+	}
+	return c
+}
+
+// WithReadConsistency sets read consistency mode for load-balanced RPC providers: "sticky" pins all requests to one upstream
+// node with a session header and cookies, "retry" retries nonce and balance reads until they reflect transactions sent
+// by the client. Empty string disables it.
+// Default value is "" (disabled).
+func (c *ClientBuilder) WithReadConsistency(mode string) *ClientBuilder {
+	c.config.Network.ReadConsistency = mode
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].ReadConsistency = mode
+	}
+	return c
+}
+
+// WithTxConfirmationStrategy sets how WaitMined learns that transaction was mined: "polling" checks receipt every receipt
+// poll interval, "subscription" checks it only when a new block arrives via eth_subscribe("newHeads") and falls back to
+// polling if RPC doesn't support subscriptions (e.g. over HTTP).
+// Default value is "" (polling).
+func (c *ClientBuilder) WithTxConfirmationStrategy(strategy string) *ClientBuilder {
+	c.config.Network.TxConfirmationStrategy = strategy
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].TxConfirmationStrategy = strategy
+	}
+	return c
+}
+
+// WithExplorerURLs sets block explorer link templates used in logs and transaction summaries. Transaction template must
+// contain {hash} placeholder and address template {address} placeholder, e.g. "https://etherscan.io/tx/{hash}".
+// Default value is "" for both (links are generated only for chains known to Seth).
+func (c *ClientBuilder) WithExplorerURLs(txURL, addressURL string) *ClientBuilder {
+	c.config.Network.ExplorerTxURL = txURL
+	c.config.Network.ExplorerAddressURL = addressURL
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].ExplorerTxURL = txURL
+		c.config.Networks[0].ExplorerAddressURL = addressURL
+	}
+	return c
+}
+
+// WithExternalActors sets labels of addresses other than Seth's keys (e.g. Chainlink nodes under test), which are shown
+// in traces of their transactions instead of "unknown". Keys are hex addresses and values are labels, e.g. "node-operator-1".
+// Default value is an empty map.
+func (c *ClientBuilder) WithExternalActors(labels map[string]string) *ClientBuilder {
+	c.config.Network.ExternalActors = labels
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].ExternalActors = labels
+	}
+	return c
+}
+
+// WithDeploymentRedeployRetries sets how many times contract deployment is sent again with a fresh nonce, when deployment
+// transaction disappears from the network (e.g. is evicted from the mempool of a flaky testnet) without being mined.
+// Default value is 0 (deployment isn't redeployed).
+func (c *ClientBuilder) WithDeploymentRedeployRetries(retries uint) *ClientBuilder {
+	c.config.Network.DeploymentRedeployRetries = retries
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].DeploymentRedeployRetries = retries
+	}
+	return c
+}
+
 // WithLegacyGasPrice sets the gas price for legacy transactions that will be used only if EIP-1559 dynamic fees are disabled.
 // Default value is 1 gwei.
 func (c *ClientBuilder) WithLegacyGasPrice(gasPrice int64) *ClientBuilder {
@@ -157,6 +324,70 @@ func (c *ClientBuilder) WithTransferGasFee(gasFee int64) *ClientBuilder {
 	return c
 }
 
+// WithBalanceCheck enables or disables pre-flight balance check, which makes sure that sender can afford the transaction before it's sent.
+// It costs one additional RPC call per transaction.
+// Default value is false.
+func (c *ClientBuilder) WithBalanceCheck(enabled bool) *ClientBuilder {
+	c.config.Network.BalanceCheckEnabled = enabled
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].BalanceCheckEnabled = enabled
+	}
+	return c
+}
+
+// WithKeyBalanceCheck makes client check on start that all keys have non-zero balance. Keys without funds usually belong to
+// a different network or were never funded. Policy can be "WARN" (log fundless keys) or "ERROR" (fail client creation). In ephemeral
+// mode only root key is checked.
+// Default value is "", which disables the check.
+func (c *ClientBuilder) WithKeyBalanceCheck(policy string) *ClientBuilder {
+	c.config.KeyBalanceCheck = policy
+	return c
+}
+
+// WithProviderProfile selects a profile of RPC provider (e.g. "infura", "alchemy", "quicknode" or "public"), which caps rate, concurrency
+// and batch size of bulk RPC calls and marks methods that provider doesn't support.
+// Default value is "", which means no limits.
+func (c *ClientBuilder) WithProviderProfile(profile string) *ClientBuilder {
+	c.config.Network.ProviderProfile = profile
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].ProviderProfile = profile
+	}
+	return c
+}
+
+// WithReceiptPolling sets how often WaitMined polls for transaction receipt. Random jitter between 0 and given value is added to each
+// interval. With adaptive polling enabled interval is derived from average block time observed on the network (polling a few
+// times per block) and given interval is used only if block time can't be calculated.
+// Default value is 1s interval, no jitter and adaptive polling disabled.
+func (c *ClientBuilder) WithReceiptPolling(interval, jitter time.Duration, adaptive bool) *ClientBuilder {
+	c.config.Network.ReceiptPollInterval = &Duration{D: interval}
+	c.config.Network.ReceiptPollJitter = &Duration{D: jitter}
+	c.config.Network.AdaptiveReceiptPolling = adaptive
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].ReceiptPollInterval = &Duration{D: interval}
+		c.config.Networks[0].ReceiptPollJitter = &Duration{D: jitter}
+		c.config.Networks[0].AdaptiveReceiptPolling = adaptive
+	}
+	return c
+}
+
+// WithReceiptPollFn sets custom function that decides how long WaitMined waits between receipt polls, e.g. to implement
+// exponential backoff. It receives attempt number and interval Seth would use otherwise. Jitter isn't added to its result.
+// Default value is nil, which means that configured interval is used.
+func (c *ClientBuilder) WithReceiptPollFn(fn ReceiptPollFn) *ClientBuilder {
+	c.config.ReceiptPollFn = fn
+	return c
+}
+
 // WithGasBumping sets the number of retries for gas bumping and max gas price. You can also provide a custom bumping strategy. If the transaction is not mined within this number of retries, it will be considered failed.
 // If the gas price is bumped to a value higher than max gas price, no more gas bumping will be attempted and previous gas price will be used by all subsequent attempts. If set to 0 max price is not checked.
 // Default value is 10 retries, no max gas price and a default bumping strategy (with gas increase % based on gas_price_estimation_tx_priority)
@@ -182,6 +413,75 @@ func (c *ClientBuilder) WithTransactionTimeout(timeout time.Duration) *ClientBui
 	return c
 }
 
+// WithTxValidity sets number of blocks within which a transaction has to be mined. If it isn't, it's replaced with a cancellation
+// (0 value transfer to self with the same nonce) and waiting for it fails with *TxExpiredError.
+// Default value is 0, which means that transactions have no deadline.
+func (c *ClientBuilder) WithTxValidity(blocks uint64) *ClientBuilder {
+	c.config.Network.TxValidityBlocks = blocks
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].TxValidityBlocks = blocks
+	}
+	return c
+}
+
+// WithAutoMining enables mining a block with evm_mine (supported by Anvil and Hardhat), when transaction Seth is waiting for
+// has been pending for longer than given time. It's meant for dev nodes with long block times. If node doesn't support evm_mine,
+// automatic mining is disabled after the first attempt.
+// Default value is 0, which means that blocks are never mined by Seth.
+func (c *ClientBuilder) WithAutoMining(after time.Duration) *ClientBuilder {
+	c.config.Network.AutoMineAfter = MustMakeDuration(after)
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].AutoMineAfter = MustMakeDuration(after)
+	}
+	return c
+}
+
+// WithBlobTransactions enables sending blob (EIP-4844) transactions created with WithBlobs() or WithBlobHashes() options. Fallback
+// blob fee cap (in wei) is used when gas price estimations are disabled or blob fee estimation fails.
+// Default values are false and 0.
+func (c *ClientBuilder) WithBlobTransactions(enabled bool, fallbackBlobFeeCap int64) *ClientBuilder {
+	c.config.Network.BlobTransactions = enabled
+	c.config.Network.BlobFeeCap = fallbackBlobFeeCap
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].BlobTransactions = enabled
+		c.config.Networks[0].BlobFeeCap = fallbackBlobFeeCap
+	}
+	return c
+}
+
+// WithSpendBudget limits fees that client's keys can pay during the session to given amount of native currency (e.g. 0.5 ETH).
+// Fees are counted for every transaction that Seth waited for. Once the budget is spent, sending new transactions fails
+// with *BudgetExceededError.
+// Default value is nil, which means that spending isn't limited.
+func (c *ClientBuilder) WithSpendBudget(budget float64) *ClientBuilder {
+	c.config.Network.SpendBudget = &budget
+	// defensive programming
+	if len(c.config.Networks) == 0 {
+		c.config.Networks = append(c.config.Networks, c.config.Network)
+	} else {
+		c.config.Networks[0].SpendBudget = &budget
+	}
+	return c
+}
+
+// WithRPCAuth sets provider of Authorization header sent with each request to the RPC node (and when WS connection is established),
+// both by the client and the tracer. Use it for RPCs that require JWT (seth.NewJWTAuthProvider()) or expiring bearer tokens
+// (seth.NewRefreshingTokenProvider()), which static RPC headers can't handle.
+// Default value is nil, which means that no Authorization header is added.
+func (c *ClientBuilder) WithRPCAuth(provider RPCAuthProvider) *ClientBuilder {
+	c.config.RPCAuthProvider = provider
+	return c
+}
+
 // WithRpcDialTimeout sets the timeout for dialing the RPC server. If the connection is not established within this time, it will be considered failed.
 // Default value is 1 minute.
 func (c *ClientBuilder) WithRpcDialTimeout(timeout time.Duration) *ClientBuilder {
@@ -212,6 +512,145 @@ func (c *ClientBuilder) WithTracing(level string, outputs []string) *ClientBuild
 	return c
 }
 
+// WithTracingFailurePolicy sets what happens when tracing of a transaction fails. Policy can be one of: "fail" (Decode returns an error),
+// "warn" (failure is logged and Decode returns decoded transaction) or "disable_after_n" (same as "warn", but tracing is disabled after failuresBeforeDisable failures).
+// Default value is "warn".
+func (c *ClientBuilder) WithTracingFailurePolicy(policy string, failuresBeforeDisable uint) *ClientBuilder {
+	c.config.TracingFailurePolicy = policy
+	c.config.TracingFailuresBeforeDisable = failuresBeforeDisable
+	return c
+}
+
+// WithTraceBackend sets RPC API used to trace transactions: "geth" uses debug_traceTransaction, "parity" uses trace_transaction
+// (OpenEthereum/Parity trace API), which is the only one exposed by some nodes and RPC providers. Parity traces have no logs
+// and no opcodes, so decoded calls have no events and storage access can't be traced.
+// Default value is "geth".
+func (c *ClientBuilder) WithTraceBackend(backend string) *ClientBuilder {
+	c.config.TraceBackend = backend
+	return c
+}
+
+// WithTxSummary enables printing compact summary of each decoded transaction (method, arguments, events, gas and cost) at Info level.
+// Default value is false.
+func (c *ClientBuilder) WithTxSummary(enabled bool) *ClientBuilder {
+	c.config.PrintTxSummary = enabled
+	return c
+}
+
+// WithConfirmationMetrics enables collecting confirmation latency, gas used, effective gas price and number of gas bumps of each
+// transaction waited for by Decode. Collected metrics are available in client.Metrics and can be written to JSON or CSV file.
+// Default value is false.
+func (c *ClientBuilder) WithConfirmationMetrics(enabled bool) *ClientBuilder {
+	c.config.ConfirmationMetrics = enabled
+	return c
+}
+
+// WithStorageTracing enables attaching storage slots read and written by each call to decoded calls. Slots are named if storage layout
+// of the contract (solc's "<ContractName>_storage.json" file) is present in ABI dir. Calls also get counts of cold and warm (EIP-2929)
+// state accesses. It requires opcodes (struct logger) trace.
+// Default value is false.
+func (c *ClientBuilder) WithStorageTracing(enabled bool) *ClientBuilder {
+	c.config.TraceStorage = enabled
+	return c
+}
+
+// WithFallbackCallDecoding enables decoding of traced calls to methods without known ABI using known method signatures
+// (see KnownMethodSignatures) or, if method is unknown, heuristically. Such calls are marked with a comment.
+// Default value is false.
+func (c *ClientBuilder) WithFallbackCallDecoding(enabled bool) *ClientBuilder {
+	c.config.TraceFallbackDecoding = enabled
+	return c
+}
+
+// WithAsyncTracing sets the number of workers that trace transactions asynchronously. When greater than 0 Decode returns
+// as soon as transaction is decoded and tracing happens in the background (use `client.FlushTraces()` to wait for it to finish).
+// Default value is 0, which means that tracing is synchronous.
+func (c *ClientBuilder) WithAsyncTracing(workers int) *ClientBuilder {
+	c.config.TracingWorkers = workers
+	return c
+}
+
+// WithTraceRetention limits number and size (in bytes, as received from the node) of raw traces kept in memory, releasing the
+// oldest ones first, and optionally releases opcode traces as soon as transaction is decoded. Zero means no limit.
+// Default value is no limits, all traces are kept for the whole lifetime of the client.
+func (c *ClientBuilder) WithTraceRetention(maxTransactions int, maxBytes int64, dropOpCodeTraces bool) *ClientBuilder {
+	c.config.TraceRetention = &TraceRetentionConfig{
+		MaxTransactions:  maxTransactions,
+		MaxBytes:         maxBytes,
+		DropOpCodeTraces: dropOpCodeTraces,
+	}
+	return c
+}
+
+// WithStrictTracing makes Decode return an error listing selectors and addresses of all calls in the trace that couldn't be
+// decoded (unknown methods, missing ABIs or decoding failures). Requires synchronous tracing.
+// Default value is false.
+func (c *ClientBuilder) WithStrictTracing(enabled bool) *ClientBuilder {
+	c.config.StrictTracing = enabled
+	return c
+}
+
+// WithStrictKeys makes client creation fail if the same private key is configured more than once. Otherwise duplicates are
+// kept as aliases of the first occurrence (so that key numbers don't change), but are never used concurrently with it.
+// Default value is false.
+func (c *ClientBuilder) WithStrictKeys(enabled bool) *ClientBuilder {
+	c.config.StrictKeys = enabled
+	return c
+}
+
+// WithCanonicalTraceJSON makes JSON trace files use canonical format (sorted keys, numbers as strings), which is stable between runs.
+// Default value is false.
+func (c *ClientBuilder) WithCanonicalTraceJSON(enabled bool) *ClientBuilder {
+	c.config.TraceCanonicalJSON = enabled
+	return c
+}
+
+// WithCalldataValidation enables validation of calldata against the ABI of the target contract before each transaction is signed.
+// Zero address arguments are reported unless allowZeroAddress is true or their names are listed in zeroAddressAllowedFor.
+// Default value is nil, which means that calldata is not validated.
+func (c *ClientBuilder) WithCalldataValidation(allowZeroAddress bool, zeroAddressAllowedFor ...string) *ClientBuilder {
+	c.config.CalldataValidation = &CalldataValidationConfig{
+		AllowZeroAddress:      allowZeroAddress,
+		ZeroAddressAllowedFor: zeroAddressAllowedFor,
+	}
+	return c
+}
+
+// WithTelemetry enables OpenTelemetry instrumentation of RPC calls. Spans are exported to OTLP/HTTP collector at endpoint, if it's empty
+// global tracer provider is used instead. Standard OTEL_* env vars are respected.
+// Default value is nil, which means that RPC calls are instrumented only if OTEL_EXPORTER_OTLP_ENDPOINT env var is set.
+func (c *ClientBuilder) WithTelemetry(endpoint, serviceName string) *ClientBuilder {
+	c.config.Telemetry = &TelemetryConfig{
+		Enabled:     true,
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+	}
+	return c
+}
+
+// WithTxJournal enables persistent journal of in-flight transactions stored in file. Transactions left in the journal by a previous
+// process are resumed when client starts according to resumePolicy, which can be one of: "wait" or "bump".
+// Default value is nil, which means that transactions are not journaled.
+func (c *ClientBuilder) WithTxJournal(file, resumePolicy string) *ClientBuilder {
+	c.config.TxJournal = &TxJournalConfig{
+		File:         file,
+		ResumePolicy: resumePolicy,
+	}
+	return c
+}
+
+// WithKeyRotation enables rotation of non-root keys. Key is retired after it was used for maxTransactionsPerKey transactions
+// or when its nonce reaches maxNonce (0 disables given limit). Replacement key is generated and funded on the fly and funds
+// from the retired key are returned to the root key.
+// Default value is nil (disabled).
+func (c *ClientBuilder) WithKeyRotation(maxTransactionsPerKey, maxNonce uint64) *ClientBuilder {
+	c.config.KeyRotation = &KeyRotationConfig{
+		MaxTransactionsPerKey: maxTransactionsPerKey,
+		MaxNonce:              maxNonce,
+	}
+	return c
+}
+
 // WithProtections enables or disables nonce protection (fails, when key has a pending transaction and you try to submit another one) and node health check on startup.
 // Default values are false for nonce protection and true for node health check.
 func (c *ClientBuilder) WithProtections(pendingNonceProtectionEnabled, nodeHealthStartupCheck bool) *ClientBuilder {
@@ -230,16 +669,45 @@ func (c *ClientBuilder) WithArtifactsFolder(folder string) *ClientBuilder {
 // WithNonceManager sets the rate limit for key sync, number of retries, timeout and retry delay.
 // Default values are 10 calls per second, 3 retires, 60s timeout and 5s retry delay.
 func (c *ClientBuilder) WithNonceManager(rateLimitSec int, retries uint, timeout, retryDelay time.Duration) *ClientBuilder {
+	healNonceGaps := c.config.NonceManager != nil && c.config.NonceManager.HealNonceGaps
 	c.config.NonceManager = &NonceManagerCfg{
 		KeySyncRateLimitSec: rateLimitSec,
 		KeySyncRetries:      retries,
 		KeySyncTimeout:      MustMakeDuration(timeout),
 		KeySyncRetryDelay:   MustMakeDuration(retryDelay),
+		HealNonceGaps:       healNonceGaps,
+	}
+
+	return c
+}
+
+// WithNonceGapHealing makes nonce manager replace transactions left pending by previous runs (e.g. crashed ones) with no-op
+// transactions (0 value transfers to self) and wait until they are mined, before client is returned.
+// Default value is false.
+func (c *ClientBuilder) WithNonceGapHealing(enabled bool) *ClientBuilder {
+	if c.config.NonceManager == nil {
+		c.config.NonceManager = &NonceManagerCfg{KeySyncRateLimitSec: 10, KeySyncRetries: 3, KeySyncTimeout: MustMakeDuration(60 * time.Second), KeySyncRetryDelay: MustMakeDuration(5 * time.Second)}
 	}
+	c.config.NonceManager.HealNonceGaps = enabled
 
 	return c
 }
 
+// Config returns the config built so far. It can be modified further before creating a client with NewClientWithConfig.
+func (c *ClientBuilder) Config() *Config {
+	return c.config
+}
+
+// WithProfile applies predefined profile ("ci" or "local") on top of values set so far, values set after it take precedence.
+// Default value is "" (no profile).
+func (c *ClientBuilder) WithProfile(name string) *ClientBuilder {
+	if err := c.config.ApplyProfile(name); err != nil {
+		// unknown profile is reported by config validation
+		c.config.Profile = name
+	}
+	return c
+}
+
 // Build creates a new Client from the builder.
 func (c *ClientBuilder) Build() (*Client, error) {
 	return NewClientWithConfig(c.config)
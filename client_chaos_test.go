@@ -0,0 +1,116 @@
+package seth_test
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+// replaceableTxService returns sent transactions as pending, so that they can be replaced with gas bumping
+type replaceableTxService struct {
+	*feePayingService
+	txs sync.Map
+}
+
+func (s *replaceableTxService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.txs.Store(tx.Hash(), tx)
+	return s.feePayingService.SendRawTransaction(raw)
+}
+
+func (s *replaceableTxService) GetTransactionByHash(txHash common.Hash) *types.Transaction {
+	tx, ok := s.txs.Load(txHash)
+	if !ok {
+		return nil
+	}
+	return tx.(*types.Transaction)
+}
+
+func TestAPIChaos(t *testing.T) {
+	newChaosClient := func(t *testing.T, service *replaceableTxService, builder func(b *seth.ClientBuilder) *seth.ClientBuilder) (*seth.Client, error) {
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+		cfg := builder(test_utils.NewMockRPCClientBuilder(rpcURL).
+			WithLegacyGasPrice(1_000_000_000).
+			WithReceiptPolling(10*time.Millisecond, 0, false)).
+			Config()
+		require.NoError(t, seth.ValidateConfig(cfg), "config should be valid")
+
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		cfg.Network.PrivateKeys = []string{hex.EncodeToString(crypto.FromECDSA(pk))}
+		return seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk})
+	}
+	newService := func() *replaceableTxService {
+		return &replaceableTxService{feePayingService: &feePayingService{
+			gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+			gasPrice:         big.NewInt(1_000_000_000),
+		}}
+	}
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	worker := common.HexToAddress("0x7000000000000000000000000000000000000007")
+
+	t.Run("dropped receipt polls are reproducible with the same seed", func(t *testing.T) {
+		var stats []seth.ChaosStats
+		for i := 0; i < 2; i++ {
+			c, err := newChaosClient(t, newService(), func(b *seth.ClientBuilder) *seth.ClientBuilder {
+				return b.WithGasBumping(0, 0, nil).WithChaos(42, 0, 0.5, 0, 0, 0)
+			})
+			require.NoError(t, err, "failed to create client")
+			require.Equal(t, int64(42), c.Chaos.Seed(), "seed should be kept")
+			contract := bind.NewBoundContract(worker, contractABI, c.Client, c.Client, c.Client)
+			for j := 0; j < 5; j++ {
+				_, err = c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(60_000)), "work"))
+				require.NoError(t, err, "transaction should be mined despite dropped receipt polls")
+			}
+			stats = append(stats, c.Chaos.Stats())
+			c.Client.Close()
+		}
+		require.Greater(t, stats[0].DroppedReceiptPolls, 0, "some receipt polls should be dropped")
+		require.Equal(t, stats[0], stats[1], "faults should be the same with the same seed")
+	})
+
+	t.Run("forced gas bumps", func(t *testing.T) {
+		c, err := newChaosClient(t, newService(), func(b *seth.ClientBuilder) *seth.ClientBuilder {
+			return b.WithGasBumping(3, 0, nil).WithChaos(1, 0, 0, 1, 0, 0)
+		})
+		require.NoError(t, err, "failed to create client")
+		defer c.Client.Close()
+		contract := bind.NewBoundContract(worker, contractABI, c.Client, c.Client, c.Client)
+		decoded, err := c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(60_000)), "work"))
+		require.NoError(t, err, "last attempt shouldn't be forced to time out")
+		require.Equal(t, 2, decoded.Timing.GasBumps, "gas should be bumped before each forced attempt")
+		require.Equal(t, 2, c.Chaos.Stats().ForcedGasBumps, "forced gas bumps should be counted")
+	})
+
+	t.Run("transient RPC errors", func(t *testing.T) {
+		_, err := newChaosClient(t, newService(), func(b *seth.ClientBuilder) *seth.ClientBuilder {
+			return b.WithGasBumping(0, 0, nil).WithChaos(1, 1, 0, 0, 0, 0)
+		})
+		require.ErrorContains(t, err, "503", "injected RPC error should be returned")
+	})
+
+	t.Run("invalid probability", func(t *testing.T) {
+		cfg := seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").WithChaos(1, 1.5, 0, 0, 0, 0).Config()
+		require.ErrorContains(t, seth.ValidateConfig(cfg), seth.ErrChaosConfig, "probability above 1 should be rejected")
+	})
+}
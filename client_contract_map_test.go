@@ -7,7 +7,10 @@ import (
 	"github.com/smartcontractkit/seth"
 	"github.com/smartcontractkit/seth/test_utils"
 	"github.com/stretchr/testify/require"
+	"math/big"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -52,6 +55,40 @@ func TestContractMapErrorsWhenReadingMalformedAddress(t *testing.T) {
 	require.Contains(t, err.Error(), "hex string without 0x prefix", "expected error reading malformed address")
 }
 
+func TestContractMapMergesMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	core := filepath.Join(dir, "deployed_contracts_core.toml")
+	feature := filepath.Join(dir, "deployed_contracts_feature.toml")
+	current := filepath.Join(dir, "current.toml")
+
+	require.NoError(t, seth.SaveDeployedContract(core, "LinkToken", "0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82"))
+	require.NoError(t, seth.SaveDeployedContract(feature, "VRFCoordinator", "0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"))
+	// same entry in two files is not a conflict
+	require.NoError(t, seth.SaveDeployedContract(feature, "LinkToken", "0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82"))
+	require.NoError(t, seth.SaveDeployedContract(current, "Consumer", "0x68B1D87F95878fE05B998F19b66F4baba5De1aed"))
+
+	cfg := &seth.Config{
+		ContractMapFile:  current,
+		ContractMapFiles: []string{filepath.Join(dir, "deployed_contracts_*.toml")},
+	}
+	paths, err := cfg.ContractMapFilePaths()
+	require.NoError(t, err, "failed to get contract map file paths")
+	require.Equal(t, []string{core, feature, current}, paths, "contract map files should be sorted and followed by the main file")
+
+	contracts, _, err := seth.LoadAndMergeDeployedContracts(paths)
+	require.NoError(t, err, "failed to merge contract maps")
+	require.Equal(t, map[string]string{
+		"0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82": "LinkToken",
+		"0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE": "VRFCoordinator",
+		"0x68B1D87F95878fE05B998F19b66F4baba5De1aed": "Consumer",
+	}, contracts, "incorrect merged contract map")
+
+	require.NoError(t, seth.SaveDeployedContract(current, "OtherToken", "0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82"))
+	_, _, err = seth.LoadAndMergeDeployedContracts(paths)
+	require.Error(t, err, "expected error when the same address has different names")
+	require.Contains(t, err.Error(), seth.ErrContractMapConflict, "incorrect error")
+}
+
 func TestContractMapNonSimulatedClientSavesAndReadsContractMap(t *testing.T) {
 	file, err := os.CreateTemp("", "deployed_contracts.toml")
 	require.NoError(t, err, "failed to create temp file")
@@ -163,3 +200,62 @@ func TestContractMapNewClientIsNotCreatedWhenCorruptedContractMapFileExists_Inva
 	require.Contains(t, err.Error(), seth.ErrReadContractMap, "expected error reading invalid contract address")
 	require.Nil(t, newClient, "expected new client to be nil")
 }
+
+func TestContractMapSavesCodeHashesToFileAndReadsThem(t *testing.T) {
+	file, err := os.CreateTemp("", "deployed_contracts.toml")
+	require.NoError(t, err, "failed to create temp file")
+	t.Cleanup(func() { _ = os.Remove(seth.CodeHashesFileName(file.Name())) })
+
+	codeHash := common.HexToHash("0x1234")
+	err = seth.SaveDeployedContractCodeHash(file.Name(), "0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82", codeHash)
+	require.NoError(t, err, "failed to save code hash")
+
+	hashes, err := seth.LoadDeployedContractCodeHashes(file.Name())
+	require.NoError(t, err, "failed to load code hashes")
+	require.Equal(t, map[string]common.Hash{"0x0dcd1bf9a1b36ce34237eeafef220932846bcd82": codeHash}, hashes)
+}
+
+func TestContractMapSavesLabelsToFileAndReadsThem(t *testing.T) {
+	file, err := os.CreateTemp("", "deployed_contracts.toml")
+	require.NoError(t, err, "failed to create temp file")
+	t.Cleanup(func() { _ = os.Remove(seth.LabelsFileName(file.Name())) })
+
+	address := "0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82"
+	err = seth.SaveContractLabel(file.Name(), address, "LinkToken#2")
+	require.NoError(t, err, "failed to save label")
+	// label can be changed, so it must replace the previous one
+	err = seth.SaveContractLabel(file.Name(), address, "FeeToken")
+	require.NoError(t, err, "failed to save label")
+
+	labels, err := seth.LoadContractLabels(file.Name())
+	require.NoError(t, err, "failed to load labels")
+	require.Equal(t, map[string]string{"0x0dcd1bf9a1b36ce34237eeafef220932846bcd82": "FeeToken"}, labels)
+
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(address, "LinkToken")
+	require.Equal(t, "LinkToken", contractMap.GetContractLabel(address), "name should be used when there's no label")
+	contractMap.SetContractLabel(address, labels[strings.ToLower(address)])
+	require.Equal(t, "FeeToken", contractMap.GetContractLabel(address), "incorrect label")
+	require.Equal(t, "LinkToken", contractMap.GetContractName(address), "label must not change contract name")
+}
+
+func TestContractMapCorrectsMisassignedEntryUsingCodeHash(t *testing.T) {
+	client := newClient(t)
+
+	data, err := client.DeployContractFromContractStore(client.NewTXOpts(), "NetworkDebugSubContract")
+	require.NoError(t, err, "failed to deploy contract")
+	_, ok := client.ContractAddressToNameMap.GetCodeHash(data.Address.Hex())
+	require.True(t, ok, "code hash should be recorded on deployment")
+
+	// simulate entry that was mis-assigned, because of colliding selectors
+	client.ContractAddressToNameMap.AddContract(data.Address.Hex(), "NetworkDebugContract")
+
+	_, err = client.Decode(data.BoundContract.Transact(client.NewTXOpts(), "trace", big.NewInt(1), big.NewInt(2)))
+	require.NoError(t, err, "failed to decode transaction")
+	require.Equal(t, "NetworkDebugSubContract", client.ContractAddressToNameMap.GetContractName(data.Address.Hex()), "entry should be corrected")
+
+	stale := client.ContractAddressToNameMap.StaleEntries()
+	require.Len(t, stale, 1, "expected one stale entry")
+	require.Equal(t, "NetworkDebugContract", stale[0].Name, "incorrect stale name")
+	require.Equal(t, "NetworkDebugSubContract", stale[0].CorrectedName, "incorrect corrected name")
+}
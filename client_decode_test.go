@@ -2,9 +2,24 @@ package seth_test
 
 import (
 	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
 	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/test_utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -59,6 +74,32 @@ func TestSmokeDebugReverts(t *testing.T) {
 			require.Equal(t, expectedOutput, err.Error())
 		})
 	}
+
+	builtInTests := []struct {
+		name   string
+		method string
+		reason string
+	}{
+		{name: "decode Error(string) revert data", method: "alwaysRevertsRequire", reason: "revert: always revert error"},
+		{name: "decode Panic(uint256) revert data", method: "alwaysRevertsAssert", reason: "panic: assertion failed (code: 0x1)"},
+	}
+
+	for _, tc := range builtInTests {
+		t.Run(tc.name, func(t *testing.T) {
+			var out []interface{}
+			err := TestEnv.DebugContractRaw.Call(&bind.CallOpts{}, &out, tc.method)
+			require.Error(t, err, "call should have reverted")
+			var dataErr rpc.DataError
+			require.True(t, errors.As(err, &dataErr), "revert should carry revert data")
+			errData, ok := dataErr.ErrorData().(string)
+			require.True(t, ok, "revert data should be a hex string")
+			data, err := hexutil.Decode(errData)
+			require.NoError(t, err, "failed to decode revert data")
+			reason, ok := seth.DecodeRevertReason(data)
+			require.True(t, ok, "revert data should be a built-in error")
+			require.Equal(t, tc.reason, reason, "incorrect revert reason")
+		})
+	}
 }
 
 func TestSmokeDebugData(t *testing.T) {
@@ -233,3 +274,554 @@ func TestSmokeDebugData(t *testing.T) {
 		})
 	}
 }
+
+func TestSmokeDebugAnnotations(t *testing.T) {
+	c := newClient(t)
+
+	annotated := c.WithAnnotation("scenario", "smoke").WithAnnotation("step", "1")
+	dtx, err := annotated.Decode(TestEnv.DebugContractRaw.Transact(c.NewTXOpts(), "emitNoIndexEvent"))
+	require.NoError(t, err, "failed to decode transaction")
+	require.Equal(t, map[string]string{"scenario": "smoke", "step": "1"}, dtx.Annotations, "annotations do not match")
+
+	dtx, err = c.Decode(TestEnv.DebugContractRaw.Transact(c.NewTXOpts(), "emitNoIndexEvent"))
+	require.NoError(t, err, "failed to decode transaction")
+	require.Nil(t, dtx.Annotations, "transaction decoded without annotations should not have any")
+}
+
+func TestSmokeDebugDecodeBlock(t *testing.T) {
+	c := newClient(t)
+
+	dtx, err := c.Decode(TestEnv.DebugContractRaw.Transact(c.NewTXOpts(), "emitNoIndexEvent"))
+	require.NoError(t, err, "failed to decode transaction")
+
+	decodedTxs, err := c.DecodeBlock(dtx.Receipt.BlockNumber)
+	require.NoError(t, err, "failed to decode block")
+
+	var found *seth.DecodedTransaction
+	for _, decoded := range decodedTxs {
+		if decoded.Hash == dtx.Hash {
+			found = decoded
+		}
+	}
+	require.NotNil(t, found, "transaction sent by our key should be decoded")
+	require.Equal(t, dtx.Method, found.Method, "method does not match")
+	require.Equal(t, dtx.Events, found.Events, "events do not match")
+	require.Equal(t, dtx.Receipt.TxHash, found.Receipt.TxHash, "receipt should be prefetched")
+}
+
+func TestSmokeDebugInclusionTiming(t *testing.T) {
+	c := newClient(t)
+
+	dtx, err := c.Decode(TestEnv.DebugContractRaw.Transact(c.NewTXOpts(), "emitNoIndexEvent"))
+	require.NoError(t, err, "failed to decode transaction")
+	require.NotNil(t, dtx.Timing, "inclusion timing should be set")
+	require.GreaterOrEqual(t, dtx.Timing.Polls, 1, "receipt should be polled at least once")
+	require.Equal(t, dtx.Receipt.BlockNumber.Uint64(), dtx.Timing.InclusionBlock, "inclusion block does not match receipt")
+	require.Equal(t, 0, dtx.Timing.GasBumps, "no gas bumps expected")
+	require.True(t, dtx.Timing.MinedAt.After(dtx.Timing.StartedAt), "transaction should be mined after waiting started")
+}
+
+func TestSmokeDebugDecodeInto(t *testing.T) {
+	// mirrors values created by ABI decoder for processNestedData((string,uint256[])) and its anonymous outputs
+	decoded := map[string]interface{}{
+		"data": struct {
+			Name   string     `json:"name"`
+			Values []*big.Int `json:"values"`
+		}{
+			Name:   "my awesome name",
+			Values: []*big.Int{big.NewInt(2), big.NewInt(266810)},
+		},
+		"_amount": big.NewInt(1000),
+		"sender":  common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		"hash":    [32]byte{1, 2, 3},
+		"counter": uint8(7),
+		"0":       int64(-5),
+	}
+
+	type nestedData struct {
+		Name   string
+		Values []int64
+	}
+	var typed struct {
+		Data    nestedData
+		Amount  uint64
+		Sender  common.Address `json:"sender"`
+		Hash    common.Hash
+		Counter *big.Int
+		First   int32 `json:"0"`
+		Missing string
+	}
+	err := seth.DecodeInto(decoded, &typed)
+	require.NoError(t, err, "should have converted decoded values")
+	require.Equal(t, nestedData{Name: "my awesome name", Values: []int64{2, 266810}}, typed.Data, "nested struct should match")
+	require.Equal(t, uint64(1000), typed.Amount, "ABI name should have been matched with field name")
+	require.Equal(t, common.HexToAddress("0x1000000000000000000000000000000000000001"), typed.Sender, "address should match")
+	require.Equal(t, common.Hash{1, 2, 3}, typed.Hash, "fixed bytes should have been converted to hash")
+	require.Equal(t, big.NewInt(7), typed.Counter, "small integer should have been converted to big.Int")
+	require.Equal(t, int32(-5), typed.First, "anonymous value should have been matched by json tag")
+	require.Empty(t, typed.Missing, "field without decoded value should be left untouched")
+
+	var overflow struct {
+		Amount uint8
+	}
+	err = seth.DecodeInto(decoded, &overflow)
+	require.Error(t, err, "should have failed on overflow")
+	require.Contains(t, err.Error(), "_amount: 1000 overflows uint8", "should have returned path of the value")
+
+	var mismatched struct {
+		Data struct {
+			Name int
+		}
+	}
+	err = seth.DecodeInto(decoded, &mismatched)
+	require.Error(t, err, "should have failed on type mismatch")
+	require.Contains(t, err.Error(), "data.Name: can't convert string into int", "should have returned path of the value")
+
+	err = seth.DecodeInto(decoded, typed)
+	require.Error(t, err, "should have rejected non-pointer target")
+	require.Contains(t, err.Error(), seth.ErrDecodeIntoTarget, "should have returned invalid target error")
+}
+
+type decodeWarningsService struct{}
+
+func (s *decodeWarningsService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	return &types.Receipt{TxHash: txHash, BlockNumber: big.NewInt(1), Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}
+}
+
+func TestAPIDecodeReturnsWarnings(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &decodeWarningsService{}})
+
+	c := &seth.Client{
+		Cfg:    seth.NewClientBuilder().Config(),
+		Client: ethclient.NewClient(rpc.DialInProc(server)),
+	}
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, Data: []byte{0xa9, 0x05, 0x9c, 0xbb}})
+	decoded, err := c.Decode(tx, nil)
+	require.NoError(t, err, "warnings shouldn't make Decode fail")
+	require.True(t, decoded.HasWarning(seth.DecodeWarning_DecodingSkipped), "missing contract store should be reported")
+	require.True(t, decoded.HasWarning(seth.DecodeWarning_GasDataUnavailable), "missing effective gas price should be reported")
+	require.False(t, decoded.HasWarning(seth.DecodeWarning_TracingFailed), "transaction shouldn't be traced")
+}
+
+func TestAPIDecodedTransactionString(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	to := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(big.NewInt(1337)), &types.LegacyTx{Nonce: 1, To: &to, Gas: 60_000, GasPrice: big.NewInt(2)})
+	require.NoError(t, err, "failed to sign transaction")
+
+	decoded := &seth.DecodedTransaction{
+		CommonData: seth.CommonData{
+			Signature: "a9059cbb",
+			Method:    "transfer",
+			Input:     map[string]interface{}{"to": to, "amount": big.NewInt(5)},
+		},
+		Hash:        tx.Hash().Hex(),
+		Transaction: tx,
+		Receipt:     &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(12), GasUsed: 50_000, EffectiveGasPrice: big.NewInt(2)},
+		Events: []seth.DecodedTransactionLog{{
+			DecodedCommonLog: seth.DecodedCommonLog{Signature: "Transfer(address,uint256)", EventData: map[string]interface{}{"to": to, "value": big.NewInt(5)}},
+		}},
+		Warnings: []seth.DecodeWarning{{Code: seth.DecodeWarning_UndecodedLogs, Message: "1 log wasn't decoded"}},
+	}
+
+	require.Equal(t, "Transaction "+tx.Hash().Hex()+" succeeded in block 12\n"+
+		"  Call: transfer(amount=5, to=0x68B1D87F95878fE05B998F19b66F4baba5De1aed) [a9059cbb]\n"+
+		"  Event: Transfer(to=0x68B1D87F95878fE05B998F19b66F4baba5De1aed, value=5)\n"+
+		"  Gas: 50000 used / 60000 limit, price 2 wei\n"+
+		"  Cost: 100000 wei / 0.0000000000001 ETH\n"+
+		"  Warning: "+decoded.Warnings[0].String()+"\n", decoded.String(), "incorrect summary")
+
+	var b strings.Builder
+	require.NoError(t, decoded.PrettyPrint(&b), "failed to print summary")
+	require.Equal(t, decoded.String(), b.String(), "PrettyPrint and String should produce the same output")
+}
+
+func TestAPIExplorerURLs(t *testing.T) {
+	address := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	hash := "0x4c21294bf4c0a19de16e0fca74e1ea1687ba96c3cab64f6fca5640fb7b84df65"
+
+	c := &seth.Client{Cfg: seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").Config(), ChainID: 1337}
+	require.Empty(t, c.TxExplorerURL(hash), "unknown chain without templates should have no links")
+	require.Empty(t, c.AddressExplorerURL(address), "unknown chain without templates should have no links")
+
+	c.ChainID = 11155111
+	require.Equal(t, "https://sepolia.etherscan.io/tx/"+hash, c.TxExplorerURL(hash), "known chain should use explorer from registry")
+	require.Equal(t, "https://sepolia.etherscan.io/address/"+address.Hex(), c.AddressExplorerURL(address), "known chain should use explorer from registry")
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		WithExplorerURLs("https://explorer.local/transactions/{hash}?tab=logs", "https://explorer.local/accounts/{address}").
+		Config()
+	require.NoError(t, seth.ValidateConfig(cfg), "templates with placeholders should be valid")
+	c = &seth.Client{Cfg: cfg, ChainID: 11155111}
+	require.Equal(t, "https://explorer.local/transactions/"+hash+"?tab=logs", c.TxExplorerURL(hash), "configured template should take precedence")
+	require.Equal(t, "https://explorer.local/accounts/"+address.Hex(), c.AddressExplorerURL(address), "configured template should take precedence")
+
+	decoded := &seth.DecodedTransaction{Hash: hash, ExplorerURL: c.TxExplorerURL(hash)}
+	require.Contains(t, decoded.String(), "  Explorer: https://explorer.local/transactions/"+hash, "summary should contain explorer link")
+
+	cfg = seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		WithExplorerURLs("https://explorer.local/tx/", "").
+		Config()
+	err := seth.ValidateConfig(cfg)
+	require.Error(t, err, "template without placeholder should be invalid")
+	require.Contains(t, err.Error(), seth.ErrExplorerURLTemplate, "should have returned template error")
+}
+
+func TestAPIEncodePacked(t *testing.T) {
+	address := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	packed, err := seth.EncodePacked(
+		[]string{"int8", "uint16", "bool", "address", "bytes4", "string", "uint256[]", "int16[2]"},
+		int8(-1), 0x1234, true, address, [4]byte{0xde, 0xad, 0xbe, 0xef}, "abc", []*big.Int{big.NewInt(1), big.NewInt(2)}, [2]int16{-2, 3},
+	)
+	require.NoError(t, err, "should have encoded values")
+	expected := "ff" + "1234" + "01" + "1000000000000000000000000000000000000001" + "deadbeef" + "616263" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe" +
+		"0000000000000000000000000000000000000000000000000000000000000003"
+	require.Equal(t, expected, common.Bytes2Hex(packed), "packed data should match Solidity's encoding")
+
+	hash, err := seth.KeccakPacked([]string{"string"}, "abc")
+	require.NoError(t, err, "should have hashed value")
+	require.Equal(t, "0x4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45", hash.Hex(), "hash should match keccak256(abi.encodePacked(\"abc\"))")
+
+	packedTypes := []string{"uint8", "int32", "address", "bytes", "bytes2", "uint256", "bool[2]"}
+	packed, err = seth.EncodePacked(packedTypes, uint8(7), int32(-42), address, []byte{1, 2, 3}, []byte{0xab, 0xcd}, big.NewInt(1_000_000), [2]bool{true, false})
+	require.NoError(t, err, "should have encoded values")
+	values, err := seth.DecodePacked(packedTypes, packed)
+	require.NoError(t, err, "should have decoded values")
+	require.Equal(t, []interface{}{uint8(7), int32(-42), address, []byte{1, 2, 3}, [2]byte{0xab, 0xcd}, big.NewInt(1_000_000), [2]bool{true, false}}, values, "decoded values should match encoded ones")
+
+	_, err = seth.EncodePacked([]string{"uint8"}, 256)
+	require.Error(t, err, "should have rejected value out of range")
+	require.Contains(t, err.Error(), seth.ErrEncodePacked, "should have returned encoding error")
+
+	_, err = seth.EncodePacked([]string{"string[]"}, []string{"a"})
+	require.Error(t, err, "should have rejected array of dynamic type")
+
+	_, err = seth.DecodePacked([]string{"string", "bytes"}, []byte("ab"))
+	require.Error(t, err, "should have rejected two dynamic types")
+	require.Contains(t, err.Error(), seth.ErrDecodePacked, "should have returned decoding error")
+
+	_, err = seth.DecodePacked([]string{"uint256"}, []byte{1})
+	require.Error(t, err, "should have rejected data shorter than static values")
+}
+
+func TestAPIFallbackCallDecoding(t *testing.T) {
+	signature, ok := seth.LookupMethodSignature(common.FromHex("0xa9059cbb"))
+	require.True(t, ok, "transfer selector should be known")
+	require.Equal(t, "transfer(address,uint256)", signature, "selector should resolve to ERC-20 transfer")
+
+	_, ok = seth.LookupMethodSignature(common.FromHex("0xdeadbeef"))
+	require.False(t, ok, "random selector should be unknown")
+
+	seth.KnownMethodSignatures = append(seth.KnownMethodSignatures, "customMethod(uint8)")
+	defer func() {
+		seth.KnownMethodSignatures = seth.KnownMethodSignatures[:len(seth.KnownMethodSignatures)-1]
+	}()
+	signature, ok = seth.LookupMethodSignature(crypto.Keccak256([]byte("customMethod(uint8)"))[:4])
+	require.True(t, ok, "appended signature should be known")
+	require.Equal(t, "customMethod(uint8)", signature, "selector should resolve to appended signature")
+
+	address := common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE")
+	hash := crypto.Keccak256Hash([]byte("data"))
+	args := append(common.LeftPadBytes(address.Bytes(), 32), common.LeftPadBytes(big.NewInt(1_000_000).Bytes(), 32)...)
+	args = append(args, math.U256Bytes(big.NewInt(-5))...)
+	args = append(args, hash.Bytes()...)
+	args = append(args, 0x01, 0x02)
+
+	decoded := seth.HeuristicDecodeArgs(args)
+	require.Equal(t, map[string]interface{}{
+		"0": address,
+		"1": big.NewInt(1_000_000),
+		"2": big.NewInt(-5),
+		"3": [32]byte(hash),
+		"4": []byte{0x01, 0x02},
+	}, decoded, "words should be classified by their content")
+}
+
+func TestAPIExternalActors(t *testing.T) {
+	own := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	nodeAddr := common.HexToAddress("0x4000000000000000000000000000000000000004")
+	operatorAddr := common.HexToAddress("0x5000000000000000000000000000000000000005")
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		WithExternalActors(map[string]string{nodeAddr.Hex(): "chainlink-node-1"}).
+		Config()
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, []common.Address{own})
+	require.NoError(t, err, "failed to create tracer")
+
+	require.Equal(t, "chainlink-node-1", tracer.ExternalActorLabel(nodeAddr), "configured actor should be registered")
+	tracer.RegisterExternalActor(operatorAddr, "node-operator-1")
+	require.True(t, tracer.IsExternalActor(operatorAddr), "actor should be registered")
+	require.Equal(t, map[common.Address]string{
+		nodeAddr:     "chainlink-node-1",
+		operatorAddr: "node-operator-1",
+	}, tracer.ExternalActors(), "wrong external actors")
+
+	decodeFrom := func(from common.Address) *seth.DecodedCall {
+		trace := seth.Trace{
+			TxHash: "0x01",
+			CallTrace: &seth.TXCallTraceOutput{Call: seth.Call{
+				From:  strings.ToLower(from.Hex()),
+				To:    strings.ToLower(operatorAddr.Hex()),
+				Input: "0xa9059cbb",
+				Type:  "CALL",
+			}},
+		}
+		calls, err := tracer.DecodeTrace(seth.L, trace)
+		require.NoError(t, err, "failed to decode trace")
+		require.NotEmpty(t, calls, "trace should have decoded calls")
+		return calls[0]
+	}
+
+	call := decodeFrom(nodeAddr)
+	require.Equal(t, "chainlink-node-1", call.From, "sender should be labelled with actor label")
+	require.Equal(t, "node-operator-1", call.To, "receiver should be labelled with actor label")
+	require.Equal(t, "you", decodeFrom(own).From, "own keys should take precedence over external actors")
+
+	tracer.RegisterExternalActor(operatorAddr, "")
+	require.False(t, tracer.IsExternalActor(operatorAddr), "empty label should remove the actor")
+	require.Equal(t, "unknown", decodeFrom(nodeAddr).To, "removed actor should be unknown")
+
+	cfg = seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		WithExternalActors(map[string]string{"not-an-address": "chainlink-node-1"}).
+		Config()
+	err = seth.ValidateConfig(cfg)
+	require.Error(t, err, "should have failed for invalid actor address")
+	require.Contains(t, err.Error(), seth.ErrExternalActor, "should have returned external actor error")
+
+	c := &seth.Client{}
+	require.EqualError(t, c.RegisterExternalActor(nodeAddr, "chainlink-node-1"), seth.ErrExternalActorNoTrace, "should have failed without tracer")
+}
+
+// revertReplayService reverts calls made at historical blocks and succeeds at the latest one, as if the cause of revert
+// was fixed in the meantime
+type revertReplayService struct {
+	mu     sync.Mutex
+	blocks []string
+}
+
+func (s *revertReplayService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *revertReplayService) Call(_ map[string]interface{}, block string) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = append(s.blocks, block)
+	if block != "latest" {
+		return nil, errors.New("execution reverted: not enough balance")
+	}
+	return hexutil.Bytes{}, nil
+}
+
+// revertDataError is returned by fake services the same way as geth returns reverts: with revert data in the error
+type revertDataError struct {
+	message string
+	data    []byte
+}
+
+func (e *revertDataError) Error() string {
+	if e.message != "" {
+		return e.message
+	}
+	return "execution reverted"
+}
+
+func (e *revertDataError) ErrorCode() int {
+	return 3
+}
+
+func (e *revertDataError) ErrorData() interface{} {
+	return hexutil.Encode(e.data)
+}
+
+// builtInRevertService mines all transactions as reverted and reverts their replays with revert data set by the test
+type builtInRevertService struct {
+	*gasHungryService
+	revertMessage string
+	revertData    []byte
+}
+
+func (s *builtInRevertService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	hash, err := s.gasHungryService.SendRawTransaction(raw)
+	if err != nil {
+		return hash, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// reverted early, so that it isn't mistaken for running out of gas
+	s.receipts[hash].Status = types.ReceiptStatusFailed
+	s.receipts[hash].GasUsed = 30_000
+	return hash, nil
+}
+
+func (s *builtInRevertService) Call(_ map[string]interface{}, _ string) (hexutil.Bytes, error) {
+	return nil, &revertDataError{message: s.revertMessage, data: s.revertData}
+}
+
+func TestAPIBuiltInRevertReasons(t *testing.T) {
+	service := &builtInRevertService{gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)}}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	c := test_utils.NewMockRPCClient(t, cfg)
+
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err, "failed to create string type")
+	message, err := abi.Arguments{{Type: stringType}}.Pack("not enough balance")
+	require.NoError(t, err, "failed to pack revert message")
+	errorData := append(crypto.Keccak256([]byte("Error(string)"))[:4], message...)
+	panicData := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], common.LeftPadBytes([]byte{0x11}, 32)...)
+
+	tests := []struct {
+		name     string
+		message  string
+		data     []byte
+		expected string
+	}{
+		{name: "require with message", data: errorData, expected: "execution reverted: revert: not enough balance"},
+		{name: "panic", data: panicData, expected: "execution reverted: panic: arithmetic overflow or underflow (code: 0x11)"},
+		{name: "unknown revert data", data: []byte{0x01, 0x02, 0x03, 0x04}, expected: "execution reverted"},
+		{name: "node message with reason is kept", message: "execution reverted: not enough balance", data: errorData, expected: "execution reverted: not enough balance"},
+		{name: "node message with panic is kept", message: "execution reverted: arithmetic underflow or overflow", data: panicData, expected: "execution reverted: arithmetic underflow or overflow"},
+	}
+
+	to := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service.revertMessage = tc.message
+			service.revertData = tc.data
+			_, err := c.SendRawCall(0, to, []byte{0xde, 0xad, 0xbe, 0xef}, seth.WithGasLimit(60_000))
+			require.EqualError(t, err, tc.expected, "incorrect revert reason")
+			require.ErrorIs(t, err, seth.ErrReverted, "revert should be classified as such")
+		})
+	}
+}
+
+func TestAPIReplayRevertedTransactions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "reverted_transactions.json")
+	err := os.WriteFile(file, []byte(`[
+		"0x1111111111111111111111111111111111111111111111111111111111111111",
+		{
+			"hash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+			"from": "0x6000000000000000000000000000000000000006",
+			"to": "0x7000000000000000000000000000000000000007",
+			"value": "0",
+			"calldata": "0x12345678",
+			"block_number": 5,
+			"method": "withdraw",
+			"error": "not enough balance"
+		}
+	]`), 0600)
+	require.NoError(t, err, "failed to write reverted transactions file")
+
+	txs, err := seth.ReadRevertedTransactions(file)
+	require.NoError(t, err, "failed to read reverted transactions")
+	require.Len(t, txs, 2, "both transactions should have been read")
+	require.Equal(t, seth.RevertedTransaction{Hash: "0x1111111111111111111111111111111111111111111111111111111111111111"}, txs[0], "plain hash should have been read")
+	require.Equal(t, "withdraw", txs[1].Method, "method should have been read")
+	require.Equal(t, uint64(5), txs[1].BlockNumber, "block number should have been read")
+
+	service := &revertReplayService{}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		Config()
+	c, err := seth.NewClientRaw(cfg, nil, nil)
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	results, err := c.ReplayRevertedTransactions(txs[1:], seth.ReplayMode_Simulate, false)
+	require.NoError(t, err, "failed to replay reverted transactions")
+	require.Len(t, results, 1, "transaction should have been replayed")
+	require.NoError(t, results[0].Err, "transaction should have been simulated")
+	require.True(t, results[0].StillReverts, "transaction should still revert at its block")
+	require.Contains(t, results[0].Error, "not enough balance", "revert reason should have been returned")
+
+	results, err = c.ReplayRevertedTransactions(txs[1:], seth.ReplayMode_Simulate, true)
+	require.NoError(t, err, "failed to replay reverted transactions")
+	require.False(t, results[0].StillReverts, "transaction shouldn't revert at the latest block")
+	require.Equal(t, []string{"0x5", "latest"}, service.blocks, "transaction should have been simulated at its block and at the latest one")
+
+	_, err = c.ReplayRevertedTransactions(txs, "rerun", false)
+	require.Error(t, err, "unknown replay mode should have been rejected")
+}
+
+func TestAPIInterfaceABIs(t *testing.T) {
+	feed := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	const aggregatorABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "AggregatorV3Interface.abi"), []byte(aggregatorABI), 0600), "failed to write interface ABI")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an ABI"), 0600), "failed to write other file")
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		Config()
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	require.NoError(t, cs.LoadInterfaceABIs(dir), "failed to load interface ABIs")
+	require.Contains(t, cs.InterfaceABIs, "AggregatorV3Interface.abi", "interface ABI should be loaded")
+	require.Len(t, cs.InterfaceABIs, 1, "only ABI files should be loaded")
+	require.Empty(t, cs.ABIs, "interface ABIs shouldn't be used as contract ABIs")
+
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	decode := func(t *testing.T) *seth.DecodedCall {
+		calls, err := tracer.DecodeTrace(seth.L, seth.Trace{
+			TxHash: "0x01",
+			CallTrace: &seth.TXCallTraceOutput{Call: seth.Call{
+				From:   "0x1000000000000000000000000000000000000001",
+				To:     strings.ToLower(feed.Hex()),
+				Input:  "0x313ce567",
+				Output: "0x0000000000000000000000000000000000000000000000000000000000000008",
+				Type:   "STATICCALL",
+			}},
+		})
+		require.NoError(t, err, "failed to decode trace")
+		require.NotEmpty(t, calls, "trace should have decoded calls")
+		return calls[0]
+	}
+
+	t.Run("decodes unknown contract with interface", func(t *testing.T) {
+		call := decode(t)
+		require.Equal(t, "decimals()", call.Method, "call should be decoded with interface ABI")
+		require.Equal(t, "AggregatorV3Interface", call.Interface, "call should be labeled with interface name")
+		require.Contains(t, call.Comment, "AggregatorV3Interface", "comment should mention interface")
+		require.Equal(t, uint8(8), call.Output["0"], "output should be decoded")
+		require.False(t, contractMap.IsKnownAddress(feed.Hex()), "interface shouldn't be mapped to the address")
+
+		_, err := abiFinder.FindABIByMethod("0x7000000000000000000000000000000000000007", common.FromHex("0xfeaf968c"))
+		require.ErrorContains(t, err, seth.ErrNoABIMethod, "method missing in interfaces shouldn't be found")
+	})
+
+	t.Run("contract ABI takes precedence", func(t *testing.T) {
+		parsed, err := abi.JSON(strings.NewReader(aggregatorABI))
+		require.NoError(t, err, "failed to parse ABI")
+		cs.AddABI("PriceFeed", parsed)
+
+		call := decode(t)
+		require.Equal(t, "decimals()", call.Method, "call should be decoded with contract ABI")
+		require.Empty(t, call.Interface, "call decoded with contract ABI shouldn't be labeled with interface")
+	})
+}
@@ -0,0 +1,318 @@
+package seth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+func TestAPIDeployContractWithValue(t *testing.T) {
+	// constructor without any logic accepts value, runtime code is a single STOP
+	payableAbi, err := abi.JSON(strings.NewReader(`[{"type":"constructor","inputs":[],"stateMutability":"payable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	bytecode := common.FromHex("0x6001600c60003960016000f300")
+
+	c := newClient(t)
+	opts := c.NewTXOpts()
+	data, err := c.DeployContractWithValue(opts, "Payable", payableAbi, bytecode, big.NewInt(1_000))
+	require.NoError(t, err, "failed to deploy contract with value")
+	require.Nil(t, opts.Value, "transaction options should not be modified")
+	require.Equal(t, big.NewInt(1_000), data.Value, "deployment data should include value")
+
+	balance, err := c.Client.BalanceAt(context.Background(), data.Address, nil)
+	require.NoError(t, err, "failed to get balance")
+	require.Equal(t, big.NewInt(1_000), balance, "contract should hold the value")
+}
+
+func TestAPIDeploymentValue(t *testing.T) {
+	nonPayableAbi, err := abi.JSON(strings.NewReader(`[{"type":"constructor","inputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	cfg := seth.NewClientBuilder().Config()
+	cfg.Network.MaxCodeSize = -1
+	cfg.Network.MaxInitCodeSize = -1
+	c := &seth.Client{Cfg: cfg}
+
+	_, err = c.DeployContractWithValue(&bind.TransactOpts{}, "NonPayable", nonPayableAbi, common.FromHex("0x6080"), big.NewInt(1))
+	require.ErrorContains(t, err, seth.ErrNonPayableConstructor, "value should not be sent to non-payable constructor")
+
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &gasEstimationService{}})
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("NonPayable", nonPayableAbi)
+	cs.AddBIN("NonPayable", common.FromHex("0x6080"))
+	c = &seth.Client{
+		Cfg:           seth.NewClientBuilder().WithEIP1559DynamicFees(false).WithLegacyGasPrice(10).WithGasPriceEstimations(false, 0, "").Config(),
+		Client:        ethclient.NewClient(rpc.DialInProc(server)),
+		ContractStore: cs,
+		Addresses:     []common.Address{common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE")},
+	}
+
+	estimation, err := c.EstimateTestCost(seth.CostPlan{Deployments: []seth.PlannedDeployment{{ContractName: "NonPayable", Count: 2, Value: big.NewInt(7)}}})
+	require.NoError(t, err, "failed to estimate cost")
+	require.Equal(t, big.NewInt(2*(1_000_000*10+7)), estimation.TotalCost, "deployment value should be included in cost")
+}
+
+// flakyDeploymentService drops first few raw transactions it receives and mines the rest immediately
+type flakyDeploymentService struct {
+	mu       sync.Mutex
+	drops    int
+	sent     int
+	nonce    uint64
+	code     map[common.Address][]byte
+	receipts map[common.Hash]*types.Receipt
+	// deployedCode is code of every deployed contract, 0x01 if it's empty
+	deployedCode []byte
+}
+
+func (s *flakyDeploymentService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *flakyDeploymentService) GetTransactionCount(_ common.Address, _ string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.nonce)
+}
+
+func (s *flakyDeploymentService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent++
+	if s.sent <= s.drops {
+		return tx.Hash(), nil
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	address := crypto.CreateAddress(from, tx.Nonce())
+	s.code[address] = []byte{0x01}
+	if s.deployedCode != nil {
+		s.code[address] = s.deployedCode
+	}
+	s.receipts[tx.Hash()] = &types.Receipt{
+		TxHash:          tx.Hash(),
+		Status:          types.ReceiptStatusSuccessful,
+		BlockNumber:     big.NewInt(1),
+		ContractAddress: address,
+		GasUsed:         tx.Gas(),
+		Logs:            []*types.Log{},
+	}
+	s.nonce = tx.Nonce() + 1
+	return tx.Hash(), nil
+}
+
+func (s *flakyDeploymentService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.receipts[txHash]
+}
+
+func (s *flakyDeploymentService) EstimateGas(_ map[string]interface{}) hexutil.Uint64 {
+	return 100_000
+}
+
+func (s *flakyDeploymentService) GetTransactionByHash(_ common.Hash) *types.Transaction {
+	return nil
+}
+
+func (s *flakyDeploymentService) GetCode(address common.Address, _ string) hexutil.Bytes {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.code[address]
+}
+
+func TestAPIRedeployDroppedDeployment(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader("[]"))
+	require.NoError(t, err, "failed to parse ABI")
+
+	deploy := func(t *testing.T, drops int, redeploys uint) (*flakyDeploymentService, seth.DeploymentData, error) {
+		service := &flakyDeploymentService{
+			drops:    drops,
+			code:     make(map[common.Address][]byte),
+			receipts: make(map[common.Hash]*types.Receipt),
+		}
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+		cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+			WithGasBumping(0, 0, nil).
+			WithDeploymentRedeployRetries(redeploys).
+			Config()
+		cfg.Network.TxnTimeout = seth.MustMakeDuration(300 * time.Millisecond)
+
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		cs, err := seth.NewContractStore("", "")
+		require.NoError(t, err, "failed to create contract store")
+		c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk}, seth.WithContractStore(cs))
+		require.NoError(t, err, "failed to create client")
+		t.Cleanup(c.Client.Close)
+
+		data, err := c.DeployContract(c.NewTXOpts(), "Empty", contractABI, []byte{0x00})
+		return service, data, err
+	}
+
+	t.Run("redeployed", func(t *testing.T) {
+		service, data, err := deploy(t, 1, 2)
+		require.NoError(t, err, "deployment should have been redeployed")
+		require.Equal(t, 2, service.sent, "deployment should have been sent twice")
+		require.NotEmpty(t, service.code[data.Address], "contract should have been deployed")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service, _, err := deploy(t, 1, 0)
+		require.Error(t, err, "deployment should have failed without redeploys")
+		require.ErrorIs(t, err, seth.ErrDropped, "deployment should have been reported as dropped")
+		require.ErrorIs(t, err, seth.ErrTimeout, "deployment should have been reported as timed out")
+		require.Equal(t, 1, service.sent, "deployment should have been sent only once")
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		service, _, err := deploy(t, 5, 2)
+		require.Error(t, err, "deployment should have failed after all redeploys")
+		require.ErrorIs(t, err, seth.ErrDropped, "deployment should have been reported as dropped")
+		require.Equal(t, 3, service.sent, "deployment should have been sent once and redeployed twice")
+	})
+}
+
+func TestAPIDeploymentData(t *testing.T) {
+	// runtime code followed by INVALID marker and CBOR metadata {"solc": 0.8.20}, its length is in the last 2 bytes
+	deployedCode := common.FromHex("0x6080fea164736f6c6343000814000a")
+	service := &flakyDeploymentService{
+		code:         make(map[common.Address][]byte),
+		receipts:     make(map[common.Hash]*types.Receipt),
+		deployedCode: deployedCode,
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk}, seth.WithContractStore(cs))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"constructor","inputs":[{"name":"limit","type":"uint256"}],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	data, err := c.DeployContract(c.NewTXOpts(), "Limited", contractABI, []byte{0x00}, big.NewInt(10))
+	require.NoError(t, err, "failed to deploy contract")
+	require.Equal(t, len(deployedCode), data.CodeSize, "size of deployed code should have been set")
+	require.Equal(t, uint64(100_000), data.GasUsed, "gas used should have been taken from receipt")
+	require.NotNil(t, data.Pragma, "pragma should have been decoded from metadata")
+	require.Equal(t, "0.8.20", data.Pragma.String(), "incorrect pragma")
+	require.Equal(t, []interface{}{big.NewInt(10)}, data.ConstructorArgs, "constructor args should have been set")
+
+	service.deployedCode = []byte{0x60, 0x80}
+	data, err = c.DeployContract(c.NewTXOpts(), "Limited", contractABI, []byte{0x00}, big.NewInt(10))
+	require.NoError(t, err, "failed to deploy contract")
+	require.Nil(t, data.Pragma, "code without metadata should have no pragma")
+}
+
+// rawBytecodeService is flakyDeploymentService that remembers calldata of sent transactions and echoes calldata of calls
+type rawBytecodeService struct {
+	*flakyDeploymentService
+	sentData [][]byte
+}
+
+func (s *rawBytecodeService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.sentData = append(s.sentData, tx.Data())
+	return s.flakyDeploymentService.SendRawTransaction(raw)
+}
+
+func (s *rawBytecodeService) Call(args map[string]interface{}, _ string) hexutil.Bytes {
+	input, _ := args["input"].(string)
+	if input == "" {
+		input, _ = args["data"].(string)
+	}
+	return common.FromHex(input)
+}
+
+func TestAPIRawBytecode(t *testing.T) {
+	fromHex, err := seth.RawCalldata("0xa9059cbb", "0x01")
+	require.NoError(t, err, "failed to build calldata from hex selector")
+	fromSig, err := seth.RawCalldata("transfer(address, uint256)", "0x01")
+	require.NoError(t, err, "failed to build calldata from signature")
+	require.Equal(t, common.FromHex("0xa9059cbb01"), fromHex, "incorrect calldata")
+	require.Equal(t, fromHex, fromSig, "selector and signature should give the same calldata")
+	_, err = seth.RawCalldata("0xa9059c", "")
+	require.ErrorContains(t, err, seth.ErrInvalidSelector, "selector shorter than 4 bytes should be rejected")
+	_, err = seth.RawCalldata("transfer", "")
+	require.ErrorContains(t, err, seth.ErrInvalidSelector, "method name without arguments should be rejected")
+	_, err = seth.RawCalldata("0xa9059cbb", "0xzz")
+	require.ErrorContains(t, err, seth.ErrInvalidRawArgs, "invalid hex arguments should be rejected")
+
+	service := &rawBytecodeService{flakyDeploymentService: &flakyDeploymentService{
+		code:     make(map[common.Address][]byte),
+		receipts: make(map[common.Hash]*types.Receipt),
+	}}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	// has a method with the same selector, it mustn't be used to decode calls to raw bytecode
+	tokenAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs.AddABI("Token", tokenAbi)
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk},
+		seth.WithContractStore(cs), seth.WithContractMap(contractMap), seth.WithABIFinder(&abiFinder))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	data, err := c.DeployRawBytecode(c.NewTXOpts(), "Fuzzed", common.FromHex("0x6080"), "0x0a")
+	require.NoError(t, err, "failed to deploy raw bytecode")
+	require.Equal(t, common.FromHex("0x60800a"), service.sentData[0], "constructor arguments should have been appended to bytecode")
+	require.True(t, c.ContractAddressToNameMap.IsRawBytecode(data.Address.Hex()), "contract should have been marked as raw bytecode")
+	require.Equal(t, "Fuzzed", c.ContractAddressToNameMap.GetContractName(data.Address.Hex()), "contract should have been added to contract map")
+
+	args, err := tokenAbi.Methods["transfer"].Inputs.Pack(data.Address, big.NewInt(1))
+	require.NoError(t, err, "failed to pack arguments")
+	decoded, err := c.TransactRaw(c.NewTXOpts(), data.Address, "0xa9059cbb", hexutil.Encode(args))
+	require.NoError(t, err, "failed to send raw transaction")
+	require.Equal(t, append(common.FromHex("0xa9059cbb"), args...), service.sentData[1], "incorrect calldata")
+	require.Equal(t, uint64(1), decoded.Transaction.Nonce(), "nonce should have been managed by the client")
+	require.True(t, decoded.HasWarning(seth.DecodeWarning_MissingABI), "call to raw bytecode should have been marked as undecoded")
+	require.Empty(t, decoded.Method, "call to raw bytecode shouldn't have been decoded with another ABI")
+
+	output, err := c.CallRaw(c.Addresses[0], data.Address, "0xa9059cbb", "0x01")
+	require.NoError(t, err, "failed to call raw bytecode")
+	require.Equal(t, common.FromHex("0xa9059cbb01"), output, "incorrect output")
+}
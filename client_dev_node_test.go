@@ -0,0 +1,219 @@
+package seth_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+// slowBlocksService never mines transactions on its own, they are only mined by evm_mine
+type slowBlocksService struct {
+	mu       sync.Mutex
+	block    uint64
+	mines    int
+	pending  []common.Hash
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (s *slowBlocksService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *slowBlocksService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.block)
+}
+
+func (s *slowBlocksService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.receipts[txHash]
+}
+
+type evmMineService struct {
+	node *slowBlocksService
+}
+
+func (s *evmMineService) Mine() string {
+	s.node.mu.Lock()
+	defer s.node.mu.Unlock()
+	s.node.mines++
+	s.node.block++
+	for _, hash := range s.node.pending {
+		s.node.receipts[hash] = &types.Receipt{TxHash: hash, Status: types.ReceiptStatusSuccessful, BlockNumber: new(big.Int).SetUint64(s.node.block), Logs: []*types.Log{}}
+	}
+	s.node.pending = nil
+	return "0x0"
+}
+
+func TestAPIAutoMining(t *testing.T) {
+	wait := func(t *testing.T, supportsEvmMine bool, autoMineAfter time.Duration) (*slowBlocksService, *seth.InclusionTiming, error) {
+		service := &slowBlocksService{block: 1, receipts: make(map[common.Hash]*types.Receipt)}
+		server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+		if supportsEvmMine {
+			require.NoError(t, server.RegisterName("evm", &evmMineService{node: service}))
+		}
+		httpServer := httptest.NewServer(server)
+		t.Cleanup(httpServer.Close)
+
+		cfg := seth.NewClientBuilder().
+			WithRpcUrl(httpServer.URL).
+			WithNetworkName(seth.ANVIL).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithEIP1559DynamicFees(false).
+			WithGasPriceEstimations(false, 0, "").
+			WithReceiptPolling(10*time.Millisecond, 0, false).
+			WithAutoMining(autoMineAfter).
+			Config()
+		cfg.Network.TxnTimeout = seth.MustMakeDuration(500 * time.Millisecond)
+		c, err := seth.NewClientRaw(cfg, nil, nil)
+		require.NoError(t, err, "failed to create client")
+		t.Cleanup(c.Client.Close)
+
+		tx := types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21_000, GasPrice: big.NewInt(1)})
+		service.mu.Lock()
+		service.pending = append(service.pending, tx.Hash())
+		service.mu.Unlock()
+
+		_, timing, err := c.WaitMinedWithTiming(context.Background(), seth.L, c.Client, tx)
+		return service, timing, err
+	}
+
+	t.Run("mines stuck transaction", func(t *testing.T) {
+		service, timing, err := wait(t, true, 50*time.Millisecond)
+		require.NoError(t, err, "transaction should have been mined")
+		require.Equal(t, 1, service.mines, "exactly one block should have been mined")
+		require.Equal(t, 1, timing.AutoMinedBlocks, "auto-mined block should have been recorded")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service, _, err := wait(t, true, 0)
+		require.Error(t, err, "transaction should never have been mined")
+		require.Equal(t, 0, service.mines, "no block should have been mined")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, timing, err := wait(t, false, 50*time.Millisecond)
+		require.Error(t, err, "transaction should never have been mined")
+		require.Equal(t, 0, timing.AutoMinedBlocks, "no block should have been mined")
+	})
+}
+
+// timeTravelService is a dev node, whose blocks are mined only with evm_mine and whose time can be moved forward
+type timeTravelService struct {
+	mu            sync.Mutex
+	block         uint64
+	now           uint64
+	nextTimestamp uint64
+}
+
+func (s *timeTravelService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *timeTravelService) GetBlockByNumber(_ string, _ bool) *types.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &types.Header{Number: new(big.Int).SetUint64(s.block), Difficulty: big.NewInt(0), Time: s.now}
+}
+
+type evmTimeTravelService struct {
+	node *timeTravelService
+}
+
+func (s *evmTimeTravelService) IncreaseTime(seconds int64) string {
+	s.node.mu.Lock()
+	defer s.node.mu.Unlock()
+	s.node.now += uint64(seconds)
+	return "0x0"
+}
+
+func (s *evmTimeTravelService) SetNextBlockTimestamp(timestamp int64) (string, error) {
+	s.node.mu.Lock()
+	defer s.node.mu.Unlock()
+	if uint64(timestamp) <= s.node.now {
+		return "", fmt.Errorf("timestamp %d is lower than or equal to previous block's timestamp %d", timestamp, s.node.now)
+	}
+	s.node.nextTimestamp = uint64(timestamp)
+	return "0x0", nil
+}
+
+func (s *evmTimeTravelService) Mine() string {
+	s.node.mu.Lock()
+	defer s.node.mu.Unlock()
+	s.node.block++
+	s.node.now++
+	if s.node.nextTimestamp != 0 {
+		s.node.now = s.node.nextTimestamp
+		s.node.nextTimestamp = 0
+	}
+	return "0x0"
+}
+
+func TestAPIDevNodeTimeTravel(t *testing.T) {
+	service := &timeTravelService{block: 1, now: 1_700_000_000}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service, "evm": &evmTimeTravelService{node: service}})
+
+	newClient := func(t *testing.T, networkName string) *seth.Client {
+		cfg := seth.NewClientBuilder().
+			WithRpcUrl(rpcURL).
+			WithNetworkName(networkName).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithEIP1559DynamicFees(false).
+			WithGasPriceEstimations(false, 0, "").
+			Config()
+		c, err := seth.NewClientRaw(cfg, nil, nil)
+		require.NoError(t, err, "failed to create client")
+		t.Cleanup(c.Client.Close)
+		return c
+	}
+
+	t.Run("not available on live networks", func(t *testing.T) {
+		require.Nil(t, newClient(t, "Sepolia").DevNode, "time travel shouldn't be available on live networks")
+	})
+
+	c := newClient(t, seth.ANVIL)
+	require.NotNil(t, c.DevNode, "time travel should be available on simulated networks")
+
+	t.Run("advance time", func(t *testing.T) {
+		minedAt, err := c.DevNode.AdvanceTime(time.Hour)
+		require.NoError(t, err, "failed to advance time")
+		require.Equal(t, time.Unix(1_700_003_601, 0), minedAt, "block should have been mined an hour later")
+		require.Equal(t, uint64(2), service.block, "a block should have been mined")
+
+		_, err = c.DevNode.AdvanceTime(500 * time.Millisecond)
+		require.ErrorContains(t, err, seth.ErrTimeTravel, "time can't be advanced by less than a second")
+	})
+
+	t.Run("set next block timestamp", func(t *testing.T) {
+		next := time.Unix(1_800_000_000, 0)
+		minedAt, err := c.DevNode.SetNextBlockTimestamp(next)
+		require.NoError(t, err, "failed to set next block timestamp")
+		require.Equal(t, next, minedAt, "block should have been mined with given timestamp")
+
+		_, err = c.DevNode.SetNextBlockTimestamp(next)
+		require.ErrorContains(t, err, seth.ErrTimeTravel, "time can't go back")
+	})
+
+	t.Run("mine", func(t *testing.T) {
+		minedAt, err := c.DevNode.Mine()
+		require.NoError(t, err, "failed to mine a block")
+		require.Equal(t, time.Unix(1_800_000_001, 0), minedAt, "incorrect timestamp of mined block")
+		require.Equal(t, uint64(4), service.block, "a block should have been mined")
+	})
+}
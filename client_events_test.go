@@ -0,0 +1,154 @@
+package seth_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+type tokenTransfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log
+}
+
+type logsSubscriptionService struct {
+	logs []types.Log
+}
+
+func (s *logsSubscriptionService) Logs(ctx context.Context, _ map[string]interface{}) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, log := range s.logs {
+			_ = notifier.Notify(sub.ID, log)
+		}
+	}()
+	return sub, nil
+}
+
+// logsPollingService mines a new block every time block number is requested and has logs only in block 3
+type logsPollingService struct {
+	mu    sync.Mutex
+	block uint64
+	logs  []types.Log
+}
+
+func (s *logsPollingService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.block++
+	return hexutil.Uint64(s.block)
+}
+
+func (s *logsPollingService) GetLogs(crit map[string]interface{}) ([]types.Log, error) {
+	from, err := hexutil.DecodeUint64(crit["fromBlock"].(string))
+	if err != nil {
+		return nil, err
+	}
+	to, err := hexutil.DecodeUint64(crit["toBlock"].(string))
+	if err != nil {
+		return nil, err
+	}
+	if from > 3 || to < 3 {
+		return []types.Log{}, nil
+	}
+	return s.logs, nil
+}
+
+func TestAPISubscribeTyped(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(`[{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Token", contractAbi)
+
+	token := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	from := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	data, err := contractAbi.Events["Transfer"].Inputs.NonIndexed().Pack(big.NewInt(42))
+	require.NoError(t, err, "failed to pack event data")
+	transfer := types.Log{
+		Address:     token,
+		Topics:      []common.Hash{contractAbi.Events["Transfer"].ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        data,
+		BlockNumber: 3,
+		TxHash:      common.HexToHash("0x1"),
+	}
+	removed := transfer
+	removed.Removed = true
+	removed.TxHash = common.HexToHash("0x2")
+
+	newClient := func(c *rpc.Client) *seth.Client {
+		return &seth.Client{
+			Cfg:           seth.NewClientBuilder().WithReceiptPolling(10*time.Millisecond, 0, false).Config(),
+			Client:        ethclient.NewClient(c),
+			ContractStore: cs,
+		}
+	}
+
+	assertTransfer := func(t *testing.T, events <-chan tokenTransfer) {
+		select {
+		case event := <-events:
+			require.Equal(t, from, event.From, "indexed parameter should be decoded")
+			require.Equal(t, to, event.To, "indexed parameter should be decoded")
+			require.Equal(t, big.NewInt(42), event.Value, "non-indexed parameter should be decoded")
+			require.Equal(t, transfer.TxHash, event.Raw.TxHash, "raw log should be set")
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not delivered")
+		}
+	}
+
+	t.Run("subscription", func(t *testing.T) {
+		server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &logsSubscriptionService{logs: []types.Log{removed, transfer}}})
+
+		events, stop, err := seth.SubscribeTyped[tokenTransfer](newClient(rpc.DialInProc(server)), token, "Token", "Transfer")
+		require.NoError(t, err, "failed to subscribe")
+		assertTransfer(t, events)
+		stop()
+		stop()
+		_, open := <-events
+		require.False(t, open, "channel should be closed after subscription is stopped")
+	})
+
+	t.Run("polling", func(t *testing.T) {
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": &logsPollingService{logs: []types.Log{removed, transfer}}})
+		rpcClient, err := rpc.Dial(rpcURL)
+		require.NoError(t, err, "failed to dial fake node")
+
+		events, stop, err := seth.SubscribeTyped[tokenTransfer](newClient(rpcClient), token, "Token", "Transfer")
+		require.NoError(t, err, "failed to subscribe")
+		defer stop()
+		assertTransfer(t, events)
+		select {
+		case event := <-events:
+			t.Fatalf("only one event should have been delivered, got %v", event)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		c := newClient(rpc.DialInProc(rpc.NewServer()))
+		_, _, err := seth.SubscribeTyped[*tokenTransfer](c, token, "Token", "Transfer")
+		require.ErrorContains(t, err, seth.ErrTypedEventTarget, "pointer should be rejected")
+		_, _, err = seth.SubscribeTyped[tokenTransfer](c, token, "Missing", "Transfer")
+		require.ErrorContains(t, err, seth.ErrNoAbiFound, "missing ABI should be reported")
+		_, _, err = seth.SubscribeTyped[tokenTransfer](c, token, "Token", "Approval")
+		require.ErrorContains(t, err, seth.ErrNoABIEvent, "missing event should be reported")
+	})
+}
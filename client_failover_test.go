@@ -0,0 +1,403 @@
+package seth_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+type txPoolService struct{}
+
+func (s *txPoolService) Content() (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+type customService struct{}
+
+func (s *customService) Method() error {
+	return errors.New("invalid params")
+}
+
+func TestAPIProbeCapabilities(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"txpool": &txPoolService{}, "custom": &customService{}})
+
+	caps := seth.ProbeCapabilities(context.Background(), rpc.DialInProc(server), []string{"custom_method", "custom_missing"})
+	require.True(t, caps.Supports(seth.Capability_TxPool), "txpool should be supported")
+	require.True(t, caps.Supports("txpool_content"), "txpool_content should be supported")
+	require.False(t, caps.Supports(seth.Capability_DebugTrace), "debug_trace should not be supported")
+	require.False(t, caps.Supports(seth.Capability_Anvil), "anvil should not be supported")
+	require.True(t, caps.Supports("custom_method"), "method returning an error other than 'method not found' should be supported")
+	require.False(t, caps.Supports("custom_missing"), "missing custom method should not be supported")
+}
+
+type chainIDService struct{}
+
+func (s *chainIDService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func TestAPITelemetry(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &chainIDService{}})
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	wsServer := httptest.NewServer(server.WebsocketHandler([]string{"*"}))
+	defer wsServer.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	for _, url := range []string{httpServer.URL, "ws" + strings.TrimPrefix(wsServer.URL, "http")} {
+		cfg := seth.NewClientBuilder().
+			WithRpcUrl(url).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithGasPriceEstimations(false, 0, "").
+			Config()
+		cfg.Telemetry = &seth.TelemetryConfig{Enabled: true}
+
+		c, err := seth.NewClientRaw(cfg, nil, nil)
+		require.NoError(t, err, "failed to create client")
+		c.Client.Close()
+	}
+
+	var chainIDSpans, failedSpans int
+	for _, span := range recorder.Ended() {
+		require.Equal(t, trace.SpanKindClient, span.SpanKind(), "span should be a client span")
+		attrs := attribute.NewSet(span.Attributes()...)
+		method, ok := attrs.Value(semconv.RPCMethodKey)
+		require.True(t, ok, "span should have method attribute")
+		require.Equal(t, span.Name(), method.AsString(), "span name should be method name")
+
+		if span.Name() == "eth_chainId" {
+			chainIDSpans++
+			require.Equal(t, codes.Unset, span.Status().Code, "eth_chainId should succeed")
+		}
+		if span.Status().Code == codes.Error {
+			failedSpans++
+			_, ok := attrs.Value(semconv.RPCJsonrpcErrorCodeKey)
+			require.True(t, ok, "failed span should have error code attribute")
+		}
+	}
+	require.Equal(t, 2, chainIDSpans, "eth_chainId should be traced both over HTTP and WS")
+	require.Greater(t, failedSpans, 0, "capability probes for missing methods should be traced as errors")
+}
+
+func TestAPIRPCAuth(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"debug": &debugTraceService{}})
+
+	var authHeaders []string
+	var authMu sync.Mutex
+	newAuthServer := func(valid func(header string) bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authMu.Lock()
+			authHeaders = append(authHeaders, r.Header.Get("Authorization"))
+			authMu.Unlock()
+			if !valid(r.Header.Get("Authorization")) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			server.ServeHTTP(w, r)
+		}))
+	}
+
+	t.Run("JWT from file", func(t *testing.T) {
+		secret := common.FromHex("0x7365637265747365637265747365637265747365637265747365637265747365")
+		httpServer := newAuthServer(func(header string) bool {
+			token, err := jwt.Parse(strings.TrimPrefix(header, "Bearer "), func(_ *jwt.Token) (interface{}, error) {
+				return secret, nil
+			})
+			return err == nil && token.Valid
+		})
+		defer httpServer.Close()
+
+		secretFile := filepath.Join(t.TempDir(), "jwt.hex")
+		require.NoError(t, os.WriteFile(secretFile, []byte(common.Bytes2Hex(secret)+"\n"), 0600), "failed to write JWT secret")
+		provider, err := seth.NewJWTAuthProviderFromFile(secretFile)
+		require.NoError(t, err, "failed to create JWT provider")
+
+		cfg := seth.NewClientBuilder().WithRpcUrl(httpServer.URL).WithRPCAuth(provider).Config()
+		tracer, err := seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+		require.NoError(t, err, "failed to create tracer")
+		require.NoError(t, tracer.TraceGethTX("0x01", nil), "authenticated requests should succeed")
+
+		cfg = seth.NewClientBuilder().WithRpcUrl(httpServer.URL).Config()
+		tracer, err = seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+		require.NoError(t, err, "failed to create tracer")
+		require.Error(t, tracer.TraceGethTX("0x01", nil), "requests without token should fail")
+
+		_, err = seth.NewJWTAuthProvider([]byte("too short"))
+		require.ErrorContains(t, err, seth.ErrInvalidJWT, "short secret should be rejected")
+	})
+
+	t.Run("refreshing token", func(t *testing.T) {
+		authHeaders = nil
+		httpServer := newAuthServer(func(header string) bool {
+			return strings.HasPrefix(header, "Bearer token-")
+		})
+		defer httpServer.Close()
+
+		refreshes := 0
+		newProvider := func(expiresIn time.Duration) seth.RPCAuthProvider {
+			refreshes = 0
+			return seth.NewRefreshingTokenProvider(func(_ context.Context) (string, time.Time, error) {
+				refreshes++
+				return fmt.Sprintf("token-%d", refreshes), time.Now().Add(expiresIn), nil
+			}, time.Minute)
+		}
+
+		cfg := seth.NewClientBuilder().WithRpcUrl(httpServer.URL).WithRPCAuth(newProvider(time.Hour)).Config()
+		tracer, err := seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+		require.NoError(t, err, "failed to create tracer")
+		require.NoError(t, tracer.TraceGethTX("0x01", nil), "authenticated requests should succeed")
+		require.Equal(t, 1, refreshes, "valid token should be reused")
+		require.Greater(t, len(authHeaders), 1, "tracing should send multiple requests")
+
+		// tokens expire within refresh margin, so they are refreshed before every request
+		authHeaders = nil
+		cfg = seth.NewClientBuilder().WithRpcUrl(httpServer.URL).WithRPCAuth(newProvider(30 * time.Second)).Config()
+		tracer, err = seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+		require.NoError(t, err, "failed to create tracer")
+		require.NoError(t, tracer.TraceGethTX("0x02", nil), "authenticated requests should succeed")
+		require.Equal(t, len(authHeaders), refreshes, "token expiring within refresh margin should be refreshed before each request")
+		require.Equal(t, fmt.Sprintf("Bearer token-%d", refreshes), authHeaders[len(authHeaders)-1], "latest token should be used")
+	})
+}
+
+type laggingNodeService struct {
+	mu       sync.Mutex
+	nonceFor func(calls int) uint64
+	calls    int
+}
+
+func (s *laggingNodeService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *laggingNodeService) GetTransactionCount(_ common.Address, _ string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return hexutil.Uint64(s.nonceFor(s.calls))
+}
+
+func (s *laggingNodeService) GetBalance(_ common.Address, _ string) *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1_000))
+}
+
+func (s *laggingNodeService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	return &types.Receipt{TxHash: txHash, Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(1), Logs: []*types.Log{}}
+}
+
+func TestAPIReadConsistency(t *testing.T) {
+	newClient := func(t *testing.T, url, mode string) *seth.Client {
+		cfg := seth.NewClientBuilder().
+			WithRpcUrl(url).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithGasPriceEstimations(false, 0, "").
+			WithReadConsistency(mode).
+			Config()
+		cfg.Network.ReceiptPollInterval = seth.MustMakeDuration(10 * time.Millisecond)
+		cfg.Network.TxnTimeout = seth.MustMakeDuration(500 * time.Millisecond)
+
+		c, err := seth.NewClientRaw(cfg, nil, nil)
+		require.NoError(t, err, "failed to create client")
+		t.Cleanup(c.Client.Close)
+		return c
+	}
+
+	t.Run("sticky", func(t *testing.T) {
+		server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &chainIDService{}})
+
+		var sessions, cookies []string
+		var mu sync.Mutex
+		httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			sessions = append(sessions, r.Header.Get(seth.DefaultStickySessionHeader))
+			if cookie, err := r.Cookie("upstream"); err == nil {
+				cookies = append(cookies, cookie.Value)
+			}
+			mu.Unlock()
+			http.SetCookie(w, &http.Cookie{Name: "upstream", Value: "node-1"})
+			server.ServeHTTP(w, r)
+		}))
+		defer httpServer.Close()
+
+		c := newClient(t, httpServer.URL, seth.ReadConsistency_Sticky)
+		_, err := c.Client.ChainID(context.Background())
+		require.NoError(t, err, "failed to get chain ID")
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Greater(t, len(sessions), 1, "client should have sent requests")
+		require.NotEmpty(t, sessions[0], "session header should be sent")
+		for _, session := range sessions {
+			require.Equal(t, sessions[0], session, "all requests should use the same session")
+		}
+		require.Len(t, cookies, len(sessions)-1, "cookie set by load balancer should be sent with subsequent requests")
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		service := &laggingNodeService{nonceFor: func(calls int) uint64 {
+			// node sees the mined transaction only from the third call
+			if calls < 3 {
+				return 0
+			}
+			return 1
+		}}
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+		c := newClient(t, rpcURL, seth.ReadConsistency_Retry)
+
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		from := crypto.PubkeyToAddress(pk.PublicKey)
+		tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(big.NewInt(1337)), &types.LegacyTx{Nonce: 0, To: &from, Gas: 21_000, GasPrice: big.NewInt(1)})
+		require.NoError(t, err, "failed to sign transaction")
+		_, err = c.WaitMined(context.Background(), seth.L, c.Client, tx)
+		require.NoError(t, err, "failed to wait for transaction")
+
+		nonce, err := c.NonceOf(from, seth.BlockTag_Latest)
+		require.NoError(t, err, "failed to get nonce")
+		require.Equal(t, uint64(1), nonce, "nonce should reflect mined transaction")
+		service.mu.Lock()
+		require.Equal(t, 3, service.calls, "stale reads should be retried")
+		service.mu.Unlock()
+
+		balance, err := c.BalanceOf(from, seth.BlockTag_Latest)
+		require.NoError(t, err, "failed to get balance")
+		require.Equal(t, big.NewInt(1_000), balance, "incorrect balance")
+
+		service.mu.Lock()
+		service.nonceFor = func(_ int) uint64 { return 0 }
+		service.mu.Unlock()
+		_, err = c.BalanceOf(from, seth.BlockTag_Pending)
+		require.ErrorContains(t, err, seth.ErrInconsistentRead, "read should fail if node never reflects client's writes")
+	})
+}
+
+func TestAPIRPCFailover(t *testing.T) {
+	var overloadedHits atomic.Int32
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		overloadedHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer overloaded.Close()
+
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &chainIDService{}})
+	healthy := httptest.NewServer(server)
+	defer healthy.Close()
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrls(overloaded.URL, healthy.URL).
+		WithRPCFailover(0, 1, time.Minute).
+		WithTracing(seth.TracingLevel_None, nil).
+		WithProtections(false, false).
+		WithEIP1559DynamicFees(false).
+		WithGasPriceEstimations(false, 0, "").
+		Config()
+	require.NoError(t, seth.ValidateConfig(cfg), "failover config should be valid")
+	c, err := seth.NewClientRaw(cfg, nil, nil)
+	require.NoError(t, err, "client should have failed over to healthy endpoint")
+	defer c.Client.Close()
+	require.Equal(t, int64(1337), c.ChainID, "chain ID should have been read from healthy endpoint")
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Client.ChainID(context.Background())
+		require.NoError(t, err, "requests should be sent to healthy endpoint")
+	}
+	require.Equal(t, int32(1), overloadedHits.Load(), "failed endpoint should be skipped during cooldown")
+
+	endpoints := c.RPCEndpoints()
+	require.Len(t, endpoints, 2, "both endpoints should be reported")
+	require.False(t, endpoints[0].Active, "failed endpoint should not be active")
+	require.False(t, endpoints[0].Healthy, "failed endpoint should be unhealthy")
+	require.Equal(t, uint64(1), endpoints[0].Failures, "failure should be counted")
+	require.True(t, endpoints[1].Active, "healthy endpoint should be active")
+	require.GreaterOrEqual(t, endpoints[1].Successes, uint64(4), "successes should be counted")
+	require.Greater(t, endpoints[1].Score, endpoints[0].Score, "healthy endpoint should have higher score")
+
+	healthy.Close()
+	_, err = c.Client.ChainID(context.Background())
+	require.ErrorContains(t, err, "503", "request should fail when all endpoints fail")
+	require.Equal(t, int32(2), overloadedHits.Load(), "unhealthy endpoint should be tried when all others fail")
+
+	single := seth.NewClientBuilder().WithRpcUrl(overloaded.URL).Config()
+	require.Nil(t, (&seth.Client{Cfg: single}).RPCEndpoints(), "failover should be disabled with a single URL")
+}
+
+func TestAPIRPCFailoverPoolBuiltOnceForConcurrentTracers(t *testing.T) {
+	var overloadedHits atomic.Int32
+	overloaded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		overloadedHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer overloaded.Close()
+
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &chainIDService{}})
+	healthy := httptest.NewServer(server)
+	defer healthy.Close()
+
+	cfg := test_utils.NewMockRPCClientBuilder(overloaded.URL).
+		WithRpcUrls(overloaded.URL, healthy.URL).
+		WithRPCFailover(0, 1, time.Minute).
+		Config()
+	require.NoError(t, seth.ValidateConfig(cfg), "failover config should be valid")
+
+	// tracers are created concurrently with no client yet, so they are the ones building the pool
+	tracers := make([]*seth.Tracer, 8)
+	errs := make([]error, len(tracers))
+	var wg sync.WaitGroup
+	for i := range tracers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tracers[i], errs[i] = seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+		}(i)
+	}
+	wg.Wait()
+	for i := range tracers {
+		require.NoError(t, errs[i], "tracer should have been created")
+	}
+
+	c, err := seth.NewClientRaw(cfg, nil, nil)
+	require.NoError(t, err, "client should have failed over to healthy endpoint")
+	defer c.Client.Close()
+	require.Equal(t, int32(1), overloadedHits.Load(), "client should have failed over once")
+
+	// tracers share the pool with the client, so they go straight to the endpoint client failed over to
+	for _, tracer := range tracers {
+		_ = tracer.TraceGethTX(common.Hash{}.Hex(), nil)
+	}
+	require.Equal(t, int32(1), overloadedHits.Load(), "tracers should have skipped endpoint that failed for the client")
+}
@@ -0,0 +1,435 @@
+package seth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+func TestAPIFundingWorkflow(t *testing.T) {
+	cfg, err := seth.ReadConfig()
+	require.NoError(t, err, "failed to read config")
+
+	pks, err := seth.NewEphemeralKeys(2)
+	require.NoError(t, err, "failed to create ephemeral keys")
+	cfg.Network.PrivateKeys = append(cfg.Network.PrivateKeys, pks...)
+
+	c, err := seth.NewClientWithConfig(cfg)
+	require.NoError(t, err, "failed to initialize seth")
+
+	var progressCalls int
+	workflow := seth.NewFundingWorkflow(c, func(p seth.FundingProgress) {
+		progressCalls++
+		require.NoError(t, p.Err, "transfer should not fail")
+		require.Equal(t, 2, p.Total, "incorrect total number of transfers")
+	})
+
+	amount := big.NewInt(1_000_000_000_000_000)
+	report, err := workflow.SplitFunds(context.Background(), c.Addresses[1:], amount)
+	require.NoError(t, err, "failed to split funds")
+	require.Equal(t, 2, report.Succeeded, "incorrect number of succeeded transfers")
+	require.Equal(t, 2, progressCalls, "incorrect number of progress calls")
+	require.Equal(t, new(big.Int).Mul(amount, big.NewInt(2)), report.TotalTransferred, "incorrect total transferred amount")
+
+	// addresses are already funded, so nothing should be sent
+	report, err = workflow.SplitFunds(context.Background(), c.Addresses[1:], amount)
+	require.NoError(t, err, "failed to resume funds split")
+	require.Equal(t, 0, report.Succeeded, "no transfers should have been executed")
+	require.Equal(t, 2, report.Skipped, "all transfers should have been skipped")
+
+	progressCalls = 0
+	report, err = workflow.ReturnFunds(context.Background(), "")
+	require.NoError(t, err, "failed to return funds")
+	require.Equal(t, 2, report.Succeeded, "incorrect number of succeeded transfers")
+	require.Equal(t, 2, progressCalls, "incorrect number of progress calls")
+}
+
+// stuckBalanceService fails balance reads of one address and doesn't answer the first balance read of another one
+// until the request is cancelled
+type stuckBalanceService struct {
+	failing common.Address
+	stuck   common.Address
+	reads   atomic.Int64
+}
+
+func (s *stuckBalanceService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *stuckBalanceService) GetTransactionCount(_ common.Address, _ string) hexutil.Uint64 {
+	return 0
+}
+
+func (s *stuckBalanceService) GetBalance(ctx context.Context, addr common.Address, _ string) (*hexutil.Big, error) {
+	switch addr {
+	case s.failing:
+		return nil, errors.New("balance unavailable")
+	case s.stuck:
+		if s.reads.Add(1) == 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+	return (*hexutil.Big)(big.NewInt(0)), nil
+}
+
+func TestAPIReturnFundsWaitsForOtherKeysOnFailure(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addrs := make([]common.Address, 3)
+	for i := range keys {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		keys[i] = pk
+		addrs[i] = crypto.PubkeyToAddress(pk.PublicKey)
+	}
+
+	service := &stuckBalanceService{failing: addrs[1], stuck: addrs[2]}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		Config()
+	c, err := seth.NewClientRaw(cfg, addrs, keys)
+	require.NoError(t, err, "failed to create client")
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	report, err := seth.NewFundingWorkflow(c, nil).ReturnFunds(ctx, "")
+	require.Error(t, err, "funds return should have failed")
+	require.Contains(t, err.Error(), "balance unavailable", "should have returned the first failure")
+	require.Less(t, time.Since(start), 5*time.Second, "stuck return should have been interrupted when context was done")
+	require.Len(t, report.Transfers, 2, "report should contain both transfers")
+	require.Equal(t, addrs[2], report.Transfers[1].From, "incorrect sender of second transfer")
+	require.ErrorIs(t, report.Transfers[1].Err, context.DeadlineExceeded, "return from stuck key should have run until context was done")
+	require.Equal(t, 2, report.Failed, "both returns should have failed")
+}
+
+type balanceService struct {
+	balance *big.Int
+}
+
+func (s *balanceService) GetBalance(_ common.Address, _ string) *hexutil.Big {
+	return (*hexutil.Big)(s.balance)
+}
+
+func TestAPIBalanceCheck(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &balanceService{balance: big.NewInt(1_000_000)}})
+
+	from := common.HexToAddress("0x0DCd1Bf9A1b36cE34237eEaFef220932846BCD82")
+	c := &seth.Client{
+		Client:    ethclient.NewClient(rpc.DialInProc(server)),
+		Addresses: []common.Address{common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"), from},
+	}
+	to := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+
+	affordable := types.NewTx(&types.DynamicFeeTx{To: &to, Gas: 21_000, GasFeeCap: big.NewInt(40), Value: big.NewInt(100_000)})
+	require.NoError(t, c.CheckBalanceForTx(context.Background(), from, affordable), "transaction should be affordable")
+
+	tooExpensive := types.NewTx(&types.DynamicFeeTx{To: &to, Gas: 21_000, GasFeeCap: big.NewInt(50), Value: big.NewInt(1)})
+	err := c.CheckBalanceForTx(context.Background(), from, tooExpensive)
+	require.Error(t, err, "transaction should not be affordable")
+
+	var balanceErr *seth.InsufficientBalanceError
+	require.True(t, errors.As(err, &balanceErr), "error should be InsufficientBalanceError")
+	require.Equal(t, 1, balanceErr.KeyNum, "incorrect key number")
+	require.Equal(t, from, balanceErr.Address, "incorrect address")
+	require.Equal(t, big.NewInt(1_000_000), balanceErr.Balance, "incorrect balance")
+	require.Equal(t, big.NewInt(1_050_001), balanceErr.Required, "incorrect required amount")
+}
+
+type gasEstimationService struct{}
+
+func (s *gasEstimationService) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	if to, ok := args["to"]; ok && to != nil {
+		return 50_000
+	}
+	return 1_000_000
+}
+
+func TestAPIEstimateTestCost(t *testing.T) {
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &gasEstimationService{}})
+
+	contractAbi, err := abi.JSON(strings.NewReader(`[{"type":"constructor","inputs":[]},{"type":"function","name":"mint","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Token", contractAbi)
+	cs.AddBIN("Token", common.FromHex("0x6080"))
+
+	cfg := seth.NewClientBuilder().
+		WithEIP1559DynamicFees(false).
+		WithLegacyGasPrice(10).
+		WithGasPriceEstimations(false, 0, "").
+		Config()
+	c := &seth.Client{
+		Cfg:           cfg,
+		Client:        ethclient.NewClient(rpc.DialInProc(server)),
+		ContractStore: cs,
+		Addresses:     []common.Address{common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE")},
+	}
+
+	estimation, err := c.EstimateTestCost(seth.CostPlan{
+		Deployments: []seth.PlannedDeployment{{ContractName: "Token", Count: 3}},
+		Calls: []seth.PlannedCall{
+			{ContractName: "Token", Address: common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed"), Method: "mint", Params: []interface{}{big.NewInt(1)}, Count: 10},
+			// not deployed yet, so gas limit has to be given
+			{ContractName: "Token", Method: "mint", Count: 2, GasLimit: 60_000, Value: big.NewInt(5)},
+		},
+		Transfers: 4,
+	})
+	require.NoError(t, err, "failed to estimate cost")
+	require.Len(t, estimation.Items, 4, "incorrect number of items")
+	require.Equal(t, big.NewInt(10), estimation.GasPrice, "incorrect gas price")
+	require.Equal(t, uint64(3*1_000_000+10*50_000+2*60_000+4*21_000), estimation.TotalGas, "incorrect total gas")
+	require.Equal(t, big.NewInt(int64(estimation.TotalGas)*10+2*5), estimation.TotalCost, "incorrect total cost")
+
+	_, err = c.EstimateTestCost(seth.CostPlan{Calls: []seth.PlannedCall{{ContractName: "Token", Method: "mint", Count: 1}}})
+	require.Error(t, err, "call without address and gas limit should not be estimated")
+	require.Contains(t, err.Error(), seth.ErrCostEstimationNoTarget, "incorrect error")
+}
+
+type keyBalanceService struct {
+	balances map[common.Address]*big.Int
+}
+
+func (s *keyBalanceService) GetBalance(addr common.Address, _ string) *hexutil.Big {
+	if balance, ok := s.balances[addr]; ok {
+		return (*hexutil.Big)(balance)
+	}
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+func TestAPIFundlessKeys(t *testing.T) {
+	funded := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	fundless := common.HexToAddress("0x3Aa5ebB10DC797CAC828524e59A333d0A371443c")
+	service := &keyBalanceService{balances: map[common.Address]*big.Int{funded: big.NewInt(1)}}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	c := &seth.Client{
+		Cfg:       seth.NewClientBuilder().Config(),
+		Client:    ethclient.NewClient(rpc.DialInProc(server)),
+		Addresses: []common.Address{funded, fundless, funded},
+	}
+
+	keys, err := c.FundlessKeys()
+	require.NoError(t, err, "failed to get fundless keys")
+	require.Equal(t, []int{1}, keys, "only key 1 should have no funds")
+}
+
+// feePayingService is gasHungryService that reports fees paid by transactions in receipts
+type feePayingService struct {
+	*gasHungryService
+	gasPrice *big.Int
+}
+
+func (s *feePayingService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	receipt := s.gasHungryService.GetTransactionReceipt(txHash)
+	if receipt == nil {
+		return nil
+	}
+	withFee := *receipt
+	withFee.EffectiveGasPrice = s.gasPrice
+	return &withFee
+}
+
+func TestAPISpendBudget(t *testing.T) {
+	service := &feePayingService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		gasPrice:         big.NewInt(1_000_000_000),
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		// enough for two transactions that use 50k gas at 1 gwei
+		WithSpendBudget(0.0001).
+		Config()
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Worker", contractABI)
+
+	c := test_utils.NewMockRPCClient(t, cfg, seth.WithContractStore(cs))
+	require.Equal(t, big.NewInt(100_000_000_000_000), c.SpendBudget(), "budget should have been converted to wei")
+
+	contract := bind.NewBoundContract(common.HexToAddress("0x7000000000000000000000000000000000000007"), contractABI, c.Client, c.Client, c.Client)
+	for i := 0; i < 2; i++ {
+		decoded, err := c.Decode(contract.Transact(c.NewTXOpts(), "work"))
+		require.NoError(t, err, "transaction within budget should have been sent")
+		// waiting for the same transaction again shouldn't count its fee twice
+		_, err = c.WaitMined(context.Background(), seth.L, c.Client, decoded.Transaction)
+		require.NoError(t, err, "failed to wait for transaction")
+	}
+	require.Equal(t, big.NewInt(100_000_000_000_000), c.Spent(), "fees of both transactions should have been counted once")
+
+	_, err = c.Decode(contract.Transact(c.NewTXOpts(), "work"))
+	var budgetErr *seth.BudgetExceededError
+	require.ErrorAs(t, err, &budgetErr, "transaction over budget should have been refused")
+	require.Equal(t, c.Spent(), budgetErr.Spent, "spent amount should have been reported")
+
+	signed, _, err := c.SignTx(0, &types.LegacyTx{To: &common.Address{}, Gas: 21_000, GasPrice: big.NewInt(1)})
+	require.NoError(t, err, "signing alone doesn't spend anything")
+	require.ErrorContains(t, c.SendSignedTx(signed), seth.ErrBudgetExceeded, "signed transaction over budget should have been refused")
+	require.Len(t, service.limits, 2, "no transaction should have been sent after budget was exhausted")
+}
+
+// tokenService is an ERC-20 token with balances kept in memory
+type tokenService struct {
+	*feePayingService
+	tokenABI abi.ABI
+	balances map[common.Address]*big.Int
+}
+
+func (s *tokenService) Call(args map[string]interface{}, _ string) (hexutil.Bytes, error) {
+	input, _ := args["input"].(string)
+	if input == "" {
+		input, _ = args["data"].(string)
+	}
+	data, err := hexutil.Decode(input)
+	if err != nil || len(data) < 4 {
+		return nil, errors.New("invalid call data")
+	}
+	method, err := s.tokenABI.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch method.Name {
+	case "balanceOf":
+		balance := s.balances[values[0].(common.Address)]
+		if balance == nil {
+			balance = big.NewInt(0)
+		}
+		return method.Outputs.Pack(balance)
+	case "symbol":
+		return method.Outputs.Pack("LINK")
+	case "decimals":
+		return method.Outputs.Pack(uint8(18))
+	}
+	return nil, errors.New("unsupported method")
+}
+
+func (s *tokenService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	args, err := s.tokenABI.Methods["transfer"].Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return common.Hash{}, err
+	}
+	to, amount := args[0].(common.Address), args[1].(*big.Int)
+	s.mu.Lock()
+	if s.balances[from] == nil || s.balances[from].Cmp(amount) < 0 {
+		s.mu.Unlock()
+		return common.Hash{}, errors.New("transfer amount exceeds balance")
+	}
+	s.balances[from] = new(big.Int).Sub(s.balances[from], amount)
+	if s.balances[to] == nil {
+		s.balances[to] = big.NewInt(0)
+	}
+	s.balances[to] = new(big.Int).Add(s.balances[to], amount)
+	s.mu.Unlock()
+	return s.feePayingService.SendRawTransaction(raw)
+}
+
+func TestAPITokenFunding(t *testing.T) {
+	tokenABI, err := abi.JSON(strings.NewReader(`[
+{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
+{"type":"function","name":"decimals","inputs":[],"outputs":[{"name":"","type":"uint8"}],"stateMutability":"view"},
+{"type":"function","name":"symbol","inputs":[],"outputs":[{"name":"","type":"string"}],"stateMutability":"view"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	addresses := make([]common.Address, 3)
+	privateKeys := make([]string, 3)
+	for i := range keys {
+		keys[i], err = crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		addresses[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		privateKeys[i] = hex.EncodeToString(crypto.FromECDSA(keys[i]))
+	}
+
+	service := &tokenService{
+		feePayingService: &feePayingService{
+			gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+			gasPrice:         big.NewInt(1_000_000_000),
+		},
+		tokenABI: tokenABI,
+		balances: map[common.Address]*big.Int{addresses[0]: big.NewInt(1_001)},
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+	cfg.Network.PrivateKeys = privateKeys
+	c, err := seth.NewClientRaw(cfg, addresses, keys)
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	token := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	workflow := seth.NewFundingWorkflow(c, nil)
+	report, err := workflow.SplitTokenFunds(context.Background(), token, addresses[1:], nil)
+	require.NoError(t, err, "failed to split token funds")
+	require.Equal(t, 2, report.Succeeded, "both keys should be funded")
+	require.Equal(t, &token, report.Token, "token should be set in report")
+	require.Equal(t, "LINK", report.Currency.Symbol, "token symbol should be used in report")
+	require.Equal(t, big.NewInt(1_000), report.TotalTransferred, "balance should be split evenly")
+	require.Equal(t, big.NewInt(1), report.RootBalanceAfter, "remainder should stay on root key")
+	require.Equal(t, big.NewInt(500), report.Transfers[0].BalanceAfter, "token balance of funded key should be reconciled")
+
+	report, err = workflow.SplitTokenFunds(context.Background(), token, addresses[1:], nil)
+	require.NoError(t, err, "failed to split token funds again")
+	require.Equal(t, 2, report.Skipped, "keys with tokens should be skipped")
+
+	require.NoError(t, seth.ReturnTokenFunds(c, token.Hex(), ""), "failed to return token funds")
+	require.Equal(t, big.NewInt(1_001), service.balances[addresses[0]], "all tokens should be returned to root key")
+	require.Zero(t, service.balances[addresses[1]].Sign(), "key should be swept")
+	require.Zero(t, service.balances[addresses[2]].Sign(), "key should be swept")
+
+	report, err = workflow.ReturnTokenFunds(context.Background(), token, "")
+	require.NoError(t, err, "failed to return token funds again")
+	require.Equal(t, 2, report.Skipped, "keys without tokens should be skipped")
+
+	require.NoError(t, seth.SplitTokenFunds(c, token.Hex(), big.NewInt(100)), "failed to split fixed amount")
+	require.Equal(t, big.NewInt(100), service.balances[addresses[1]], "fixed amount should be sent")
+	require.Equal(t, big.NewInt(801), service.balances[addresses[0]], "fixed amounts should be taken from root key")
+}
@@ -1,9 +1,25 @@
 package seth
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	BlockTag_Latest    = "latest"
+	BlockTag_Pending   = "pending"
+	BlockTag_Safe      = "safe"
+	BlockTag_Finalized = "finalized"
+	BlockTag_Earliest  = "earliest"
+
+	ErrInvalidBlockTag = "invalid block tag"
 )
 
 // MustGetRootKeyAddress returns the root key address from the client configuration. If no addresses are found, it panics.
@@ -41,3 +57,84 @@ func (m *Client) GetRootPrivateKey() (*ecdsa.PrivateKey, error) {
 	}
 	return m.PrivateKeys[0], nil
 }
+
+// ParseBlockTag converts block tag ("latest", "pending", "safe", "finalized", "earliest") or block number (decimal or 0x-prefixed hex)
+// to a value accepted by go-ethereum's client methods. Empty tag means "latest", for which nil is returned.
+func ParseBlockTag(blockTag string) (*big.Int, error) {
+	blockTag = strings.TrimSpace(strings.ToLower(blockTag))
+	if blockTag == "" || blockTag == BlockTag_Latest {
+		return nil, nil
+	}
+
+	if number, ok := new(big.Int).SetString(blockTag, 10); ok {
+		if number.Sign() < 0 {
+			return nil, fmt.Errorf("%s: block number must not be negative, got %s", ErrInvalidBlockTag, blockTag)
+		}
+		return number, nil
+	}
+
+	var bn rpc.BlockNumber
+	if err := bn.UnmarshalJSON([]byte(blockTag)); err != nil {
+		return nil, fmt.Errorf("%s: '%s': %w", ErrInvalidBlockTag, blockTag, err)
+	}
+
+	return big.NewInt(bn.Int64()), nil
+}
+
+// BalanceOf returns balance of given address at given block tag ("latest", "pending", "safe", "finalized", "earliest" or block number).
+// Use "pending" to assert on balance after submitting a transaction, but before it's mined.
+func (m *Client) BalanceOf(addr common.Address, blockTag string) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	return m.balanceOf(ctx, addr, blockTag)
+}
+
+// NonceOf returns nonce of given address at given block tag ("latest", "pending", "safe", "finalized", "earliest" or block number).
+// Pending nonce includes transactions that were submitted, but not yet mined.
+func (m *Client) NonceOf(addr common.Address, blockTag string) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	return m.nonceOf(ctx, addr, blockTag)
+}
+
+func (m *Client) balanceOf(ctx context.Context, addr common.Address, blockTag string) (*big.Int, error) {
+	block, err := ParseBlockTag(blockTag)
+	if err != nil {
+		return nil, err
+	}
+	return m.consistentBalance(ctx, addr, block, blockTag)
+}
+
+func (m *Client) nonceOf(ctx context.Context, addr common.Address, blockTag string) (uint64, error) {
+	block, err := ParseBlockTag(blockTag)
+	if err != nil {
+		return 0, err
+	}
+	return m.consistentNonce(ctx, addr, block, blockTag)
+}
+
+// keyAddress returns address of given key. Key rotation swaps keys in their slots, so it should be used instead of
+// indexing Addresses wherever the key might be in use.
+func (m *Client) keyAddress(keyNum int) common.Address {
+	m.keysMu.RLock()
+	defer m.keysMu.RUnlock()
+	return m.Addresses[keyNum]
+}
+
+// key returns address and private key of given key, both read from the same slot even if key is being rotated
+func (m *Client) key(keyNum int) (common.Address, *ecdsa.PrivateKey) {
+	m.keysMu.RLock()
+	defer m.keysMu.RUnlock()
+	return m.Addresses[keyNum], m.PrivateKeys[keyNum]
+}
+
+// keys returns copies of client's addresses and private keys, so that they can be iterated over while keys are rotated
+func (m *Client) keys() ([]common.Address, []*ecdsa.PrivateKey) {
+	m.keysMu.RLock()
+	defer m.keysMu.RUnlock()
+	addrs := make([]common.Address, len(m.Addresses))
+	copy(addrs, m.Addresses)
+	privateKeys := make([]*ecdsa.PrivateKey, len(m.PrivateKeys))
+	copy(privateKeys, m.PrivateKeys)
+	return addrs, privateKeys
+}
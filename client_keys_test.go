@@ -0,0 +1,413 @@
+package seth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+	"github.com/smartcontractkit/seth/test_utils/localchain"
+)
+
+func TestAPIKeyRotation(t *testing.T) {
+	cfg, err := seth.ReadConfig()
+	require.NoError(t, err, "failed to read config")
+
+	pks, err := seth.NewEphemeralKeys(1)
+	require.NoError(t, err, "failed to create ephemeral keys")
+	cfg.Network.PrivateKeys = append(cfg.Network.PrivateKeys, pks...)
+	cfg.KeyRotation = &seth.KeyRotationConfig{MaxTransactionsPerKey: 1}
+
+	c, err := seth.NewClientWithConfig(cfg)
+	require.NoError(t, err, "failed to initialize seth")
+	require.NotNil(t, c.KeyRotator, "key rotator should be created")
+
+	_, err = seth.NewFundingWorkflow(c, nil).SplitFunds(context.Background(), c.Addresses[1:], big.NewInt(10_000_000_000_000_000))
+	require.NoError(t, err, "failed to fund key")
+
+	originalAddress := c.Addresses[1]
+	_, err = c.Decode(TestEnv.DebugContract.AddCounter(c.NewTXKeyOpts(1), big.NewInt(0), big.NewInt(1)))
+	require.NoError(t, err, "failed to send first transaction")
+	require.Equal(t, originalAddress, c.Addresses[1], "key should not be rotated before reaching the limit")
+
+	_, err = c.Decode(TestEnv.DebugContract.AddCounter(c.NewTXKeyOpts(1), big.NewInt(0), big.NewInt(1)))
+	require.NoError(t, err, "failed to send transaction with rotated key")
+	require.NotEqual(t, originalAddress, c.Addresses[1], "key should be rotated after reaching the limit")
+
+	retired := c.KeyRotator.RetiredKeys()
+	require.Len(t, retired, 1, "incorrect number of retired keys")
+	require.Equal(t, originalAddress, retired[0].Address, "incorrect retired key")
+	require.Equal(t, c.Addresses[1], retired[0].ReplacedBy, "incorrect replacement key")
+	require.NoError(t, retired[0].Err, "funds should be returned from retired key")
+}
+
+func TestAPIKeyRotationWithLowRootBalance(t *testing.T) {
+	chain := localchain.Start(t, localchain.Opts{Keys: 1})
+	c := chain.Client
+	tracer, err := seth.NewTracer(c.ContractStore, c.ABIFinder, c.Cfg, c.ContractAddressToNameMap, c.Addresses)
+	require.NoError(t, err, "failed to create tracer")
+	c.Tracer = tracer
+	c.KeyRotator = seth.NewKeyRotator(c, &seth.KeyRotationConfig{MaxTransactionsPerKey: 1})
+
+	// leave root key with less than balance of the rotated key, so that it can't fund replacement on its own
+	rootBalance, err := c.BalanceOf(c.Addresses[0], seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get root key balance")
+	keep := big.NewInt(1_000_000_000_000_000_000)
+	burn := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	require.NoError(t, c.TransferETHFromKey(context.Background(), 0, burn.Hex(), new(big.Int).Sub(rootBalance, keep), nil), "failed to drain root key")
+
+	originalAddress := c.Addresses[1]
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = c.NewCallKeyOpts(1).From
+					_ = c.IsNodeAccount(1)
+				}
+			}
+		}()
+	}
+	err = c.KeyRotator.Rotate(context.Background(), 1)
+	close(stop)
+	wg.Wait()
+	require.NoError(t, err, "failed to rotate key")
+
+	require.NotEqual(t, originalAddress, c.Addresses[1], "key should have been rotated")
+	require.Equal(t, c.Addresses[1], c.NewCallKeyOpts(1).From, "replacement key should be used")
+	require.Equal(t, c.Addresses[1], c.Tracer.Addresses[1], "tracer should recognise replacement key as client's own")
+
+	retired := c.KeyRotator.RetiredKeys()
+	require.Len(t, retired, 1, "incorrect number of retired keys")
+	require.NoError(t, retired[0].Err, "funds should be returned from retired key")
+	balance, err := c.BalanceOf(c.Addresses[1], seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance of replacement key")
+	require.Equal(t, retired[0].ReturnedFunds, balance, "replacement key should be funded with returned funds")
+	balance, err = c.BalanceOf(originalAddress, seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance of retired key")
+	require.Less(t, balance.Cmp(localchain.DefaultKeyFunding), 0, "funds should have been returned from retired key")
+}
+
+func TestAPIDuplicateKeys(t *testing.T) {
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": &chainIDService{}})
+
+	rawPks := make([]string, 0)
+	for i := 0; i < 2; i++ {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		rawPks = append(rawPks, common.Bytes2Hex(crypto.FromECDSA(pk)))
+	}
+	// root key is configured twice
+	rawPks = append(rawPks, rawPks[0])
+
+	newConfig := func(strict bool) *seth.Config {
+		return seth.NewClientBuilder().
+			WithRpcUrl(rpcURL).
+			WithPrivateKeys(rawPks).
+			WithStrictKeys(strict).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithEIP1559DynamicFees(false).
+			WithGasPriceEstimations(false, 0, "").
+			Config()
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		err := seth.ValidateConfig(newConfig(true))
+		require.ErrorContains(t, err, seth.ErrDuplicateKeys, "duplicate key should have been rejected")
+		require.ErrorContains(t, err, "key 2 is the same as key 0", "duplicate should have been described")
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		cfg := newConfig(false)
+		require.NoError(t, seth.ValidateConfig(cfg), "duplicate key should have been accepted")
+		addrs, pks, err := cfg.ParseKeys()
+		require.NoError(t, err, "failed to parse keys")
+		c, err := seth.NewClientRaw(cfg, addrs, pks)
+		require.NoError(t, err, "failed to create client")
+		defer c.Client.Close()
+
+		require.Len(t, c.Addresses, 3, "duplicate should have been kept, so that key numbers don't change")
+		require.True(t, c.Cfg.IsKeyAlias(2), "duplicate should have been an alias")
+		require.False(t, c.Cfg.IsKeyAlias(1), "distinct key shouldn't have been an alias")
+		require.Equal(t, 0, c.Cfg.CanonicalKeyNum(2), "alias should have pointed to the first occurrence")
+		require.Equal(t, 1, c.Cfg.CanonicalKeyNum(1), "distinct key should have been its own canonical key")
+		require.Equal(t, 1, c.Cfg.GetMaxConcurrency(), "root key and duplicate shouldn't have been counted")
+
+		used := make([]int, 0)
+		require.NoError(t, seth.Parallel(context.Background(), c, 1, func(keyNum int) error {
+			used = append(used, keyNum)
+			return nil
+		}), "parallel interaction should have succeeded")
+		require.Equal(t, []int{1}, used, "duplicate key shouldn't have been used")
+		require.ErrorContains(t, seth.Parallel(context.Background(), c, 2, func(_ int) error { return nil }), seth.ErrParallelConcurrency, "duplicate key shouldn't have been counted")
+	})
+}
+
+// brokenKeysService is gasHungryService that rejects transactions of some senders, as if they had no funds
+type brokenKeysService struct {
+	*gasHungryService
+	broken map[common.Address]bool
+}
+
+func (s *brokenKeysService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if s.broken[from] {
+		return common.Hash{}, errors.New("insufficient funds for gas * price + value")
+	}
+	return s.gasHungryService.SendRawTransaction(raw)
+}
+
+func TestAPISendWithAnyKey(t *testing.T) {
+	require.True(t, seth.IsKeySpecificErr(errors.New("nonce too low")), "poisoned nonce should be key-specific")
+	require.True(t, seth.IsKeySpecificErr(errors.Wrap(&seth.InsufficientBalanceError{Balance: big.NewInt(0), Required: big.NewInt(1)}, "failed")), "failed balance check should be key-specific")
+	require.False(t, seth.IsKeySpecificErr(errors.New("execution reverted")), "revert should not be key-specific")
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	newClient := func(t *testing.T, brokenKeys ...int) (*seth.Client, *brokenKeysService) {
+		pks := make([]*ecdsa.PrivateKey, 0, 4)
+		addrs := make([]common.Address, 0, 4)
+		for i := 0; i < 4; i++ {
+			pk, err := crypto.GenerateKey()
+			require.NoError(t, err, "failed to generate key")
+			pks = append(pks, pk)
+			addrs = append(addrs, crypto.PubkeyToAddress(pk.PublicKey))
+		}
+		service := &brokenKeysService{
+			gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+			broken:           make(map[common.Address]bool),
+		}
+		for _, keyNum := range brokenKeys {
+			service.broken[addrs[keyNum]] = true
+		}
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+		cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+			WithLegacyGasPrice(1_000_000_000).
+			WithGasBumping(0, 0, nil).
+			Config()
+		cs, err := seth.NewContractStore("", "")
+		require.NoError(t, err, "failed to create contract store")
+		cs.AddABI("Worker", contractABI)
+		c, err := seth.NewClientRaw(cfg, addrs, pks, seth.WithContractStore(cs))
+		require.NoError(t, err, "failed to create client")
+		t.Cleanup(c.Client.Close)
+		return c, service
+	}
+
+	work := func(c *seth.Client) seth.KeyAgnosticFn {
+		contract := bind.NewBoundContract(common.HexToAddress("0x7000000000000000000000000000000000000007"), contractABI, c.Client, c.Client, c.Client)
+		return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return contract.Transact(opts, "work")
+		}
+	}
+
+	t.Run("substituted", func(t *testing.T) {
+		c, _ := newClient(t, 1, 2)
+		decoded, err := c.SendWithAnyKey(1, work(c))
+		require.NoError(t, err, "transaction should have been sent with another key")
+		sender, err := types.Sender(types.LatestSignerForChainID(decoded.Transaction.ChainId()), decoded.Transaction)
+		require.NoError(t, err, "failed to recover sender")
+		require.Equal(t, c.Addresses[3], sender, "first working key should have been used")
+		require.True(t, decoded.HasWarning(seth.DecodeWarning_KeySubstituted), "substitution should have been added as warning")
+
+		substitutions := c.KeySubstitutions()
+		require.Len(t, substitutions, 2, "both substitutions should have been recorded")
+		require.Equal(t, 1, substitutions[0].KeyNum, "incorrect original key")
+		require.Equal(t, 2, substitutions[0].SubstituteKeyNum, "incorrect substitute key")
+		require.Equal(t, 3, substitutions[1].SubstituteKeyNum, "incorrect substitute key")
+		require.Contains(t, substitutions[0].Reason, "insufficient funds", "reason should have been recorded")
+	})
+
+	t.Run("working key", func(t *testing.T) {
+		c, _ := newClient(t, 2)
+		decoded, err := c.SendWithAnyKey(1, work(c))
+		require.NoError(t, err, "transaction should have been sent")
+		require.False(t, decoded.HasWarning(seth.DecodeWarning_KeySubstituted), "working key shouldn't have been substituted")
+		require.Empty(t, c.KeySubstitutions(), "no substitution should have been recorded")
+	})
+
+	t.Run("no alternate key", func(t *testing.T) {
+		c, service := newClient(t, 1, 2, 3)
+		_, err := c.SendWithAnyKey(1, work(c))
+		require.ErrorContains(t, err, seth.ErrNoAlternateKey, "all keys are broken")
+		require.Empty(t, service.limits, "nothing should have been sent")
+	})
+}
+
+func TestAPIKeysFromEnv(t *testing.T) {
+	keys := make([]string, 0)
+	addrs := make([]common.Address, 0)
+	for i := 0; i < 3; i++ {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		keys = append(keys, hex.EncodeToString(crypto.FromECDSA(pk)))
+		addrs = append(addrs, crypto.PubkeyToAddress(pk.PublicKey))
+	}
+
+	rpcURL := test_utils.NewMockRPC(t, nil)
+
+	cfgPath := filepath.Join(t.TempDir(), "seth.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte("[[networks]]\nname = \"Default\"\ntransaction_timeout = \"10s\"\n"), 0600), "failed to write config")
+	t.Setenv(seth.CONFIG_FILE_ENV_VAR, cfgPath)
+	t.Setenv(seth.NETWORK_ENV_VAR, "")
+	t.Setenv(seth.URL_ENV_VAR, rpcURL)
+	t.Setenv(seth.ROOT_PRIVATE_KEY_ENV_VAR, keys[0])
+	t.Setenv(seth.PRIVATE_KEYS_ENV_VAR, fmt.Sprintf(" %s, %s,", keys[1], keys[2]))
+	t.Setenv(seth.ADDRESS_LABELS_ENV_VAR, "deployer,,funder")
+
+	cfg, err := seth.ReadConfig()
+	require.NoError(t, err, "failed to read config")
+	require.Equal(t, keys, cfg.Network.PrivateKeys, "keys from env should follow the root key")
+	require.Equal(t, []string{"deployer", "", "funder"}, cfg.Network.KeyLabels, "labels should be mapped to keys by index")
+	require.Equal(t, "funder", cfg.Network.KeyLabel(2), "incorrect label")
+	require.Equal(t, "", cfg.Network.KeyLabel(1), "key without label should have empty label")
+	require.Equal(t, "", cfg.Network.KeyLabel(3), "key out of range should have empty label")
+	require.NoError(t, seth.ValidateConfig(cfg), "labels of all keys should be valid")
+
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Worker", parsed)
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, addrs)
+	require.NoError(t, err, "failed to create tracer")
+
+	work := hexutil.Encode(parsed.Methods["work"].ID)
+	worker := "0x7000000000000000000000000000000000000007"
+	calls, err := tracer.DecodeTrace(seth.L, seth.Trace{
+		TxHash: "0x01",
+		CallTrace: &seth.TXCallTraceOutput{
+			Call:  seth.Call{From: strings.ToLower(addrs[0].Hex()), To: worker, Type: "CALL", Input: work, Output: "0x"},
+			Calls: []seth.Call{{From: strings.ToLower(addrs[1].Hex()), To: worker, Type: "CALL", Input: work, Output: "0x"}},
+		},
+	})
+	require.NoError(t, err, "failed to decode trace")
+	require.Equal(t, "you (deployer)", calls[0].From, "labelled key should be shown with its label")
+	require.Equal(t, "you", calls[1].From, "key without label should be shown as own key")
+
+	cfg.Network.KeyLabels = []string{"deployer", "deployer"}
+	require.ErrorContains(t, seth.ValidateConfig(cfg), seth.ErrKeyLabels, "duplicate labels should be rejected")
+	cfg.Network.KeyLabels = []string{"a", "b", "c", "d"}
+	require.ErrorContains(t, seth.ValidateConfig(cfg), seth.ErrKeyLabels, "labels of missing keys should be rejected")
+}
+
+type nodeAccountService struct {
+	*gasHungryService
+	key    *ecdsa.PrivateKey
+	signed atomic.Int32
+}
+
+func (s *nodeAccountService) Accounts() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *nodeAccountService) SignTransaction(args map[string]interface{}) (map[string]interface{}, error) {
+	if common.HexToAddress(args["from"].(string)) != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, errors.New("unknown account")
+	}
+	to := common.HexToAddress(args["to"].(string))
+	txData := &types.LegacyTx{
+		Nonce:    hexutil.MustDecodeUint64(args["nonce"].(string)),
+		GasPrice: hexutil.MustDecodeBig(args["gasPrice"].(string)),
+		Gas:      hexutil.MustDecodeUint64(args["gas"].(string)),
+		To:       &to,
+		Value:    hexutil.MustDecodeBig(args["value"].(string)),
+		Data:     hexutil.MustDecode(args["input"].(string)),
+	}
+	tx, err := types.SignNewTx(s.key, types.LatestSignerForChainID(big.NewInt(1337)), txData)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	s.signed.Add(1)
+	return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": tx}, nil
+}
+
+func TestAPINodeAccounts(t *testing.T) {
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	nodeAccount := crypto.PubkeyToAddress(nodeKey.PublicKey)
+	service := &nodeAccountService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		key:              nodeKey,
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	newClient := func(nodeAccount common.Address) (*seth.Client, error) {
+		cfg := seth.NewClientBuilder().
+			WithRpcUrl(rpcURL).
+			WithNodeAccounts(nodeAccount.Hex()).
+			WithTracing(seth.TracingLevel_None, nil).
+			WithProtections(false, false).
+			WithEIP1559DynamicFees(false).
+			WithGasPriceEstimations(false, 0, "").
+			WithLegacyGasPrice(1_000_000_000).
+			WithGasBumping(0, 0, nil).
+			Config()
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		return seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey), nodeAccount}, []*ecdsa.PrivateKey{pk, nil})
+	}
+
+	t.Run("transactions are signed by the node", func(t *testing.T) {
+		c, err := newClient(nodeAccount)
+		require.NoError(t, err, "failed to create client")
+		defer c.Client.Close()
+		require.False(t, c.IsNodeAccount(0), "root key has a private key")
+		require.True(t, c.IsNodeAccount(1), "key without private key should be a node account")
+
+		contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+		require.NoError(t, err, "failed to parse ABI")
+		contract := bind.NewBoundContract(common.HexToAddress("0x7000000000000000000000000000000000000007"), contractABI, c.Client, c.Client, c.Client)
+		decoded, err := c.Decode(contract.Transact(c.NewTXKeyOpts(1, seth.WithGasLimit(60_000)), "work"))
+		require.NoError(t, err, "transaction from node account should have been mined")
+		sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1337)), decoded.Transaction)
+		require.NoError(t, err, "failed to recover sender")
+		require.Equal(t, nodeAccount, sender, "transaction should have been sent from node account")
+		require.Equal(t, int32(1), service.signed.Load(), "node should have signed the transaction")
+	})
+
+	t.Run("accounts not managed by the node are rejected", func(t *testing.T) {
+		_, err := newClient(common.HexToAddress("0x8000000000000000000000000000000000000008"))
+		require.ErrorContains(t, err, seth.ErrNodeAccountNotManaged, "unmanaged account should have been rejected")
+	})
+}
@@ -0,0 +1,439 @@
+package seth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+func TestAPILoadGun(t *testing.T) {
+	service := &flakyDeploymentService{
+		code:     make(map[common.Address][]byte),
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk}, seth.WithContractStore(cs))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	receiver := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	gun := seth.NewLoadGun(c, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx, err := opts.Signer(opts.From, types.NewTx(&types.LegacyTx{
+			Nonce:    opts.Nonce.Uint64(),
+			To:       &receiver,
+			Gas:      21_000,
+			GasPrice: opts.GasPrice,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		return tx, c.Client.SendTransaction(opts.Context, tx)
+	})
+
+	result := gun.Call()
+	require.NoError(t, result.Err, "call should have succeeded")
+	require.Equal(t, 0, result.KeyNum, "root key should be used, when there are no other keys")
+	require.Equal(t, uint64(21_000), result.GasUsed, "gas used should be taken from receipt")
+	require.NotNil(t, result.Decoded, "transaction should have been decoded")
+	require.Positive(t, result.Latency, "latency should be measured")
+
+	failing := seth.NewLoadGun(c, func(_ *bind.TransactOpts) (*types.Transaction, error) {
+		return nil, errors.New("call failed")
+	})
+	result = failing.Call()
+	require.Error(t, result.Err, "call should have failed")
+
+	stats := gun.Stats()
+	require.Equal(t, 1, stats.Calls, "wrong number of calls")
+	require.Equal(t, 0, stats.Failed, "wrong number of failed calls")
+	require.Equal(t, uint64(21_000), stats.GasUsed, "wrong total gas used")
+	require.Equal(t, stats.MaxLatency, stats.AvgLatency, "average of a single call should be equal to its latency")
+	require.Equal(t, 1, failing.Stats().Failed, "failed call should be counted")
+}
+
+func TestAPITxScheduler(t *testing.T) {
+	service := &flakyDeploymentService{
+		code:     make(map[common.Address][]byte),
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithGasBumping(0, 0, nil).
+		Config()
+	cfg.Network.FallbackGasPrices = map[string]int64{seth.Priority_Fast: 3_000_000_000, seth.Priority_Slow: 1_000_000_000}
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk}, seth.WithContractStore(cs))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	type dispatched struct {
+		class    string
+		nonce    uint64
+		gasPrice *big.Int
+		at       time.Time
+	}
+	var mu sync.Mutex
+	var order []dispatched
+	receiver := common.HexToAddress("0x6000000000000000000000000000000000000006")
+	send := func(class string) seth.ScheduledTxFn {
+		return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			mu.Lock()
+			order = append(order, dispatched{class: class, nonce: opts.Nonce.Uint64(), gasPrice: opts.GasPrice, at: time.Now()})
+			mu.Unlock()
+			tx, err := opts.Signer(opts.From, types.NewTx(&types.LegacyTx{
+				Nonce:    opts.Nonce.Uint64(),
+				To:       &receiver,
+				Gas:      21_000,
+				GasPrice: opts.GasPrice,
+			}))
+			if err != nil {
+				return nil, err
+			}
+			return tx, c.Client.SendTransaction(opts.Context, tx)
+		}
+	}
+
+	scheduler := seth.NewTxSchedulerWithPolicies(c, 0, map[string]seth.TxClassPolicy{
+		seth.TxClass_Critical: {Priority: seth.Priority_Fast},
+		seth.TxClass_Bulk:     {Priority: seth.Priority_Slow, Interval: 300 * time.Millisecond},
+	})
+
+	results := make([]<-chan *seth.ScheduledTxResult, 0)
+	for i := 0; i < 3; i++ {
+		result, err := scheduler.Enqueue(seth.TxClass_Bulk, send(seth.TxClass_Bulk))
+		require.NoError(t, err, "failed to enqueue bulk transaction")
+		results = append(results, result)
+	}
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 2, scheduler.Pending(seth.TxClass_Bulk), "bulk transactions should be paced")
+	decoded, err := scheduler.Send(seth.TxClass_Critical, send(seth.TxClass_Critical))
+	require.NoError(t, err, "critical transaction should have succeeded")
+	require.NotNil(t, decoded, "critical transaction should have been decoded")
+
+	_, err = scheduler.Enqueue(seth.TxClass_Background, send(seth.TxClass_Background))
+	require.Error(t, err, "class without policy should be rejected")
+	require.Contains(t, err.Error(), seth.ErrUnknownTxClass, "should have returned unknown class error")
+
+	for _, result := range results {
+		r := <-result
+		require.NoError(t, r.Err, "bulk transaction should have succeeded")
+		require.Equal(t, seth.TxClass_Bulk, r.Class, "wrong class of result")
+	}
+	scheduler.Close()
+	_, err = scheduler.Enqueue(seth.TxClass_Critical, send(seth.TxClass_Critical))
+	require.EqualError(t, err, seth.ErrTxSchedulerClosed, "closed scheduler should reject transactions")
+
+	require.Len(t, order, 4, "all transactions should have been dispatched")
+	classes := make([]string, 0, len(order))
+	for i, d := range order {
+		classes = append(classes, d.class)
+		require.Equal(t, uint64(i), d.nonce, "transactions should share the nonce sequence")
+	}
+	require.Equal(t, []string{seth.TxClass_Bulk, seth.TxClass_Critical, seth.TxClass_Bulk, seth.TxClass_Bulk}, classes, "critical transaction should jump the queue")
+	require.Equal(t, big.NewInt(3_000_000_000), order[1].gasPrice, "critical transaction should use fast fees")
+	require.Equal(t, big.NewInt(1_000_000_000), order[0].gasPrice, "bulk transaction should use slow fees")
+	require.GreaterOrEqual(t, order[3].at.Sub(order[2].at), 250*time.Millisecond, "bulk transactions should be paced")
+}
+
+func TestAPIParallel(t *testing.T) {
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": &chainIDService{}})
+
+	var addrs []common.Address
+	var pks []*ecdsa.PrivateKey
+	var rawPks []string
+	for i := 0; i < 4; i++ {
+		pk, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		addrs = append(addrs, crypto.PubkeyToAddress(pk.PublicKey))
+		pks = append(pks, pk)
+		rawPks = append(rawPks, common.Bytes2Hex(crypto.FromECDSA(pk)))
+	}
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithPrivateKeys(rawPks).
+		WithTracing(seth.TracingLevel_None, nil).
+		WithProtections(false, false).
+		WithEIP1559DynamicFees(false).
+		WithGasPriceEstimations(false, 0, "").
+		Config()
+	c, err := seth.NewClientRaw(cfg, addrs, pks)
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	t.Run("runs each interaction with its own key", func(t *testing.T) {
+		var mu sync.Mutex
+		used := make(map[int]int)
+		err := seth.Parallel(context.Background(), c, 3, func(keyNum int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			used[keyNum]++
+			return nil
+		})
+		require.NoError(t, err, "parallel interactions should have succeeded")
+		require.Equal(t, map[int]int{1: 1, 2: 1, 3: 1}, used, "each key except root should have been used once")
+	})
+
+	t.Run("attributes errors to keys", func(t *testing.T) {
+		failure := errors.New("reverted")
+		err := seth.Parallel(context.Background(), c, 3, func(keyNum int) error {
+			switch keyNum {
+			case 1:
+				return failure
+			case 3:
+				panic("boom")
+			}
+			return nil
+		})
+		require.Error(t, err, "failed interactions should have been reported")
+		require.ErrorIs(t, err, failure, "original error should have been kept")
+		var keyErr *seth.KeyError
+		require.ErrorAs(t, err, &keyErr, "error should have been attributed to a key")
+		require.Equal(t, 1, keyErr.KeyNum, "errors should have been ordered by key")
+		require.Equal(t, addrs[1], keyErr.Address, "address of the key should have been reported")
+		require.Contains(t, err.Error(), "key 3", "panic should have been attributed to its key")
+		require.Contains(t, err.Error(), seth.ErrParallelPanic, "panic should have been reported")
+	})
+
+	t.Run("rejects concurrency higher than number of keys", func(t *testing.T) {
+		err := seth.Parallel(context.Background(), c, 4, func(_ int) error {
+			t.Fatal("nothing should have been run")
+			return nil
+		})
+		require.ErrorContains(t, err, seth.ErrParallelConcurrency, "root key shouldn't have been counted")
+	})
+
+	t.Run("doesn't start interactions after context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := seth.Parallel(ctx, c, 2, func(_ int) error {
+			t.Fatal("nothing should have been run")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled, "context error should have been returned")
+	})
+}
+
+// blockRangeService serves blocks with transactions added to them by the test
+type blockRangeService struct {
+	*gasHungryService
+	blocks map[uint64][]*types.Transaction
+	gas    map[uint64]uint64
+}
+
+func (s *blockRangeService) GetBlockByNumber(number string, _ bool) (map[string]interface{}, error) {
+	n, err := hexutil.DecodeUint64(number)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	txs := s.blocks[n]
+	s.mu.Unlock()
+	header := &types.Header{
+		Number:     new(big.Int).SetUint64(n),
+		Difficulty: big.NewInt(0),
+		GasLimit:   1_000_000,
+		GasUsed:    s.gas[n],
+		Time:       1_700_000_000 + n*2,
+		TxHash:     types.EmptyTxsHash,
+		UncleHash:  types.EmptyUncleHash,
+	}
+	if len(txs) > 0 {
+		header.TxHash = types.DeriveSha(types.Transactions(txs), trie.NewStackTrie(nil))
+	}
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	block := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return nil, err
+	}
+	encodedTxs := make([]interface{}, 0, len(txs))
+	for _, tx := range txs {
+		encodedTxs = append(encodedTxs, tx)
+	}
+	block["transactions"] = encodedTxs
+	block["uncles"] = []string{}
+
+	return block, nil
+}
+
+func TestAPIBlockRangeAnalytics(t *testing.T) {
+	service := &blockRangeService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		blocks:           make(map[uint64][]*types.Transaction),
+		gas:              map[uint64]uint64{1: 200_000, 2: 100_000, 3: 0},
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cfg.Network.PrivateKeys = []string{hex.EncodeToString(crypto.FromECDSA(pk))}
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk})
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	worker := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	contract := bind.NewBoundContract(worker, contractABI, c.Client, c.Client, c.Client)
+	decoded, err := c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(60_000)), "work"))
+	require.NoError(t, err, "failed to decode transaction")
+	require.NotNil(t, decoded.Timing, "inclusion timing should be measured")
+
+	// our transaction, which wasn't decoded, its gas used is read from receipt
+	signer := types.LatestSignerForChainID(big.NewInt(c.ChainID))
+	notDecoded, err := types.SignTx(types.NewTransaction(1, worker, big.NewInt(0), 40_000, big.NewInt(1_000_000_000), nil), signer, pk)
+	require.NoError(t, err, "failed to sign transaction")
+	// transaction of someone else
+	otherPk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	foreign, err := types.SignTx(types.NewTransaction(0, worker, big.NewInt(0), 150_000, big.NewInt(1_000_000_000), nil), signer, otherPk)
+	require.NoError(t, err, "failed to sign transaction")
+
+	service.mu.Lock()
+	service.blocks[1] = []*types.Transaction{decoded.Transaction, foreign}
+	service.blocks[2] = []*types.Transaction{notDecoded}
+	service.receipts[notDecoded.Hash()] = &types.Receipt{TxHash: notDecoded.Hash(), Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(2), GasUsed: 25_000, Logs: []*types.Log{}}
+	service.mu.Unlock()
+
+	bs, err := seth.NewBlockStats(c)
+	require.NoError(t, err, "failed to create block stats")
+	analytics, err := bs.Analytics(1, 3)
+	require.NoError(t, err, "failed to calculate analytics")
+
+	require.Len(t, analytics.Blocks, 3, "all blocks should be analysed")
+	require.Equal(t, uint64(1), analytics.Blocks[0].Number, "blocks should be sorted")
+	require.Equal(t, 2, analytics.Blocks[0].Transactions, "all transactions should be counted")
+	require.Equal(t, 1, analytics.Blocks[0].OurTransactions, "only our transaction should be counted")
+	require.Equal(t, uint64(50_000), analytics.Blocks[0].OurGasUsed, "gas used should be taken from session log")
+	require.Equal(t, 25.0, analytics.Blocks[0].OurGasShare, "share of our gas should be calculated")
+	require.Equal(t, uint64(25_000), analytics.Blocks[1].OurGasUsed, "gas used should be taken from receipt")
+	require.Equal(t, 0, analytics.Blocks[2].OurTransactions, "empty block should have no transactions")
+
+	require.Equal(t, uint64(300_000), analytics.GasUsed, "total gas used should be summed")
+	require.Equal(t, 3, analytics.Transactions, "total transactions should be summed")
+	require.Equal(t, 2, analytics.OurTransactions, "our transactions should be summed")
+	require.Equal(t, 25.0, analytics.OurGasShare, "share of our gas in the range should be calculated")
+	require.InDelta(t, 10.0, analytics.Utilization, 0.001, "utilization should be calculated")
+	require.Equal(t, 1, analytics.TimedTransactions, "only decoded transaction has inclusion timing")
+	require.Equal(t, decoded.Timing.Duration, analytics.AvgInclusionTime, "inclusion time should be taken from session log")
+	require.Equal(t, float64(decoded.Timing.BlocksElapsed), analytics.AvgInclusionBlocks, "blocks elapsed should be taken from session log")
+
+	_, err = bs.Analytics(3, 1)
+	require.Error(t, err, "reversed range should be rejected")
+}
+
+func TestAPIConfirmationMetrics(t *testing.T) {
+	service := &feePayingService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		gasPrice:         big.NewInt(1_000_000_000),
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		WithConfirmationMetrics(true).
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cfg.Network.PrivateKeys = []string{hex.EncodeToString(crypto.FromECDSA(pk))}
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk})
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+	require.NotNil(t, c.Metrics, "metrics should be collected")
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	contract := bind.NewBoundContract(common.HexToAddress("0x7000000000000000000000000000000000000007"), contractABI, c.Client, c.Client, c.Client)
+	_, err = c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(60_000)), "work"))
+	require.NoError(t, err, "failed to decode transaction")
+	_, err = c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(60_000)), "work"))
+	require.NoError(t, err, "failed to decode transaction")
+	_, err = c.Decode(contract.Transact(c.NewTXOpts(seth.WithGasLimit(30_000)), "work"))
+	require.Error(t, err, "transaction should run out of gas")
+
+	txs := c.Metrics.Transactions()
+	require.Len(t, txs, 3, "all waited for transactions should be recorded")
+	require.Equal(t, c.Addresses[0].Hex(), txs[0].From, "sender should be recorded")
+	require.Equal(t, uint64(50_000), txs[0].GasUsed, "gas used should be recorded")
+	require.Equal(t, big.NewInt(1_000_000_000), txs[0].EffectiveGasPrice, "effective gas price should be recorded")
+
+	report := c.Metrics.Report()
+	require.Equal(t, 3, report.Transactions, "all transactions should be counted")
+	require.Equal(t, 1, report.Reverted, "reverted transaction should be counted")
+	require.Equal(t, uint64(130_000), report.TotalGasUsed, "gas used should be summed")
+	require.Equal(t, big.NewInt(1_000_000_000), report.AvgEffectiveGasPrice, "average gas price should be calculated")
+	require.Greater(t, report.Latency.Max, time.Duration(0), "latency should be measured")
+	require.LessOrEqual(t, report.Latency.Min, report.Latency.Perc50, "min latency shouldn't exceed median")
+	require.LessOrEqual(t, report.Latency.Perc99, report.Latency.Max, "p99 latency shouldn't exceed max")
+
+	dir := t.TempDir()
+	require.NoError(t, c.Metrics.WriteJSON(filepath.Join(dir, "metrics.json")), "failed to write JSON")
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.json"))
+	require.NoError(t, err, "failed to read JSON")
+	var dumped struct {
+		Report       seth.ConfirmationMetricsReport `json:"report"`
+		Transactions []seth.TxConfirmationMetric    `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &dumped), "failed to unmarshal JSON")
+	require.Equal(t, report, dumped.Report, "report should be written")
+	require.Len(t, dumped.Transactions, 3, "all transactions should be written")
+
+	require.NoError(t, c.Metrics.WriteCSV(filepath.Join(dir, "metrics.csv")), "failed to write CSV")
+	f, err := os.Open(filepath.Join(dir, "metrics.csv"))
+	require.NoError(t, err, "failed to open CSV")
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err, "failed to read CSV")
+	require.Len(t, rows, 4, "header and one row per transaction should be written")
+	require.Equal(t, "gas_used", rows[0][8], "header should be written")
+	require.Equal(t, "true", rows[3][11], "reverted transaction should be marked")
+
+	c.Metrics.Reset()
+	require.Empty(t, c.Metrics.Transactions(), "metrics should be reset")
+}
@@ -0,0 +1,129 @@
+package seth_test
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+type nonceGapService struct {
+	mu          sync.Mutex
+	latestNonce uint64
+	sent        []*types.Transaction
+	underpriced bool
+}
+
+func (s *nonceGapService) GetTransactionCount(_ common.Address, blockTag string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if blockTag == "pending" {
+		return 5
+	}
+	return hexutil.Uint64(s.latestNonce)
+}
+
+func (s *nonceGapService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(10))
+}
+
+func (s *nonceGapService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	if !s.underpriced {
+		s.underpriced = true
+		return common.Hash{}, errors.New("replacement transaction underpriced")
+	}
+	s.sent = append(s.sent, tx)
+	s.latestNonce = tx.Nonce() + 1
+	return tx.Hash(), nil
+}
+
+func TestAPINonceGapHealing(t *testing.T) {
+	service := &nonceGapService{latestNonce: 3}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	addr := crypto.PubkeyToAddress(pk.PublicKey)
+
+	cfg := seth.NewClientBuilder().
+		WithEIP1559DynamicFees(false).
+		WithLegacyGasPrice(1).
+		WithNonceGapHealing(true).
+		Config()
+	c := &seth.Client{
+		Cfg:         cfg,
+		Client:      ethclient.NewClient(rpc.DialInProc(server)),
+		Addresses:   []common.Address{addr},
+		PrivateKeys: []*ecdsa.PrivateKey{pk},
+		ChainID:     1337,
+	}
+	nm, err := seth.NewNonceManager(cfg, c.Addresses, c.PrivateKeys)
+	require.NoError(t, err, "failed to create nonce manager")
+	nm.Client = c
+
+	require.NoError(t, nm.UpdateNonces(), "failed to update nonces")
+	require.Equal(t, int64(5), nm.Nonces[addr], "nonce should be the one after replaced transactions")
+	require.Len(t, service.sent, 2, "stuck transactions with nonces 3 and 4 should be replaced")
+	for i, tx := range service.sent {
+		require.Equal(t, uint64(3+i), tx.Nonce(), "incorrect nonce of replacement transaction")
+		require.Equal(t, addr, *tx.To(), "replacement should be a transfer to self")
+		require.Equal(t, big.NewInt(40), tx.GasPrice(), "gas price should be doubled after underpriced replacement")
+	}
+}
+
+func TestAPIGasBumpNonceReservation(t *testing.T) {
+	service := &laggingNodeService{nonceFor: func(_ int) uint64 {
+		// replaced transaction with nonce 5 isn't visible in the pending state
+		return 5
+	}}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTracing(seth.TracingLevel_None, nil).
+		WithProtections(false, false).
+		WithGasPriceEstimations(false, 0, "").
+		Config()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	addr := crypto.PubkeyToAddress(pk.PublicKey)
+	nm, err := seth.NewNonceManager(cfg, []common.Address{addr}, []*ecdsa.PrivateKey{pk})
+	require.NoError(t, err, "failed to create nonce manager")
+
+	c, err := seth.NewClientRaw(cfg, []common.Address{addr}, []*ecdsa.PrivateKey{pk}, seth.WithNonceManager(nm))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	require.Equal(t, uint64(5), c.NewTXOpts().Nonce.Uint64(), "pending nonce should be used")
+
+	c.NonceManager.ReserveNonce(addr, 5)
+	c.NonceManager.ReserveNonce(addr, 6)
+	require.True(t, c.NonceManager.IsNonceReserved(addr, 5), "nonce should be reserved")
+	require.False(t, c.NonceManager.IsNonceReserved(common.Address{}, 5), "nonce of other address should not be reserved")
+	require.Equal(t, uint64(7), c.NewTXOpts().Nonce.Uint64(), "reserved nonces should be skipped")
+
+	c.NonceManager.ReleaseNonce(addr, 5)
+	c.NonceManager.ReleaseNonce(addr, 6)
+	require.False(t, c.NonceManager.IsNonceReserved(addr, 5), "nonce should be released")
+	require.Equal(t, uint64(5), c.NewTXOpts().Nonce.Uint64(), "released nonce should be used again")
+	require.Empty(t, c.Errors, "client should have no errors")
+}
@@ -0,0 +1,178 @@
+package seth_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+type snapshotService struct {
+	mu      sync.Mutex
+	abi     abi.ABI
+	counter int64
+	blocks  []string
+}
+
+type snapshotCallArgs struct {
+	To   *common.Address `json:"to"`
+	Data hexutil.Bytes   `json:"data"`
+}
+
+func (s *snapshotService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *snapshotService) BlockNumber() hexutil.Uint64 {
+	return 42
+}
+
+func (s *snapshotService) Call(args snapshotCallArgs, block string) (hexutil.Bytes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = append(s.blocks, block)
+	method, err := s.abi.MethodById(args.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch method.Name {
+	case "counter":
+		return method.Outputs.Pack(big.NewInt(s.counter))
+	case "owner":
+		return method.Outputs.Pack(common.HexToAddress("0x1000000000000000000000000000000000000001"))
+	case "limits":
+		return method.Outputs.Pack(uint8(1), uint8(9))
+	default:
+		return nil, errors.New("execution reverted")
+	}
+}
+
+func TestAPIContractSnapshot(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"counter","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"owner","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+		{"type":"function","name":"limits","stateMutability":"pure","inputs":[],"outputs":[{"name":"min","type":"uint8"},{"name":"max","type":"uint8"}]},
+		{"type":"function","name":"broken","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"counterOf","stateMutability":"view","inputs":[{"name":"who","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"increment","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	service := &snapshotService{abi: contractABI, counter: 1}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Counter", contractABI)
+	c := &seth.Client{
+		Cfg:           seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").Config(),
+		Client:        ethclient.NewClient(rpc.DialInProc(server)),
+		ContractStore: cs,
+	}
+	defer c.Client.Close()
+	address := common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	before, err := c.Snapshot(address, "Counter")
+	require.NoError(t, err, "failed to snapshot contract")
+	require.Equal(t, uint64(42), before.BlockNumber, "snapshot should be taken at latest block")
+	require.Equal(t, map[string]interface{}{
+		"counter": big.NewInt(1),
+		"owner":   common.HexToAddress("0x1000000000000000000000000000000000000001"),
+		"limits":  map[string]interface{}{"min": uint8(1), "max": uint8(9)},
+	}, before.Values, "only argument-less getters should be called")
+	require.Contains(t, before.Errors, "broken", "reverted getter should be listed in errors")
+	service.mu.Lock()
+	for _, block := range service.blocks {
+		require.Equal(t, "0x2a", block, "all calls should be pinned to the same block")
+	}
+	service.mu.Unlock()
+
+	service.mu.Lock()
+	service.counter = 2
+	service.mu.Unlock()
+	after, err := c.SnapshotAtBlock(address, "Counter", big.NewInt(43))
+	require.NoError(t, err, "failed to snapshot contract")
+	require.Equal(t, uint64(43), after.BlockNumber, "snapshot should be taken at given block")
+	require.Equal(t, map[string]seth.SnapshotChange{
+		"counter": {Before: big.NewInt(1), After: big.NewInt(2)},
+	}, before.Diff(after), "only changed getter should be in the diff")
+
+	_, err = c.Snapshot(address, "Unknown")
+	require.Error(t, err, "should have failed for unknown ABI")
+	require.Contains(t, err.Error(), seth.ErrSnapshot, "should have returned snapshot error")
+}
+
+func TestAPIDiffSnapshots(t *testing.T) {
+	address := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	before := &seth.ContractSnapshot{
+		Address:     address,
+		ABIName:     "Counter",
+		BlockNumber: 10,
+		Values: map[string]interface{}{
+			"counter": big.NewInt(1),
+			"owner":   common.HexToAddress("0x1000000000000000000000000000000000000001"),
+			"paused":  false,
+		},
+		Errors: map[string]string{"broken": "execution reverted"},
+	}
+	after := &seth.ContractSnapshot{
+		Address:     address,
+		ABIName:     "Counter",
+		BlockNumber: 12,
+		Values: map[string]interface{}{
+			"broken":  big.NewInt(7),
+			"counter": big.NewInt(2),
+			"owner":   common.HexToAddress("0x1000000000000000000000000000000000000001"),
+			"paused":  false,
+		},
+		Errors: map[string]string{},
+	}
+
+	diff, err := seth.DiffSnapshots(before, after)
+	require.NoError(t, err, "failed to diff snapshots")
+	require.True(t, diff.HasChanges(), "diff should have changes")
+	require.Equal(t, uint64(10), diff.BlockBefore, "wrong block before")
+	require.Equal(t, uint64(12), diff.BlockAfter, "wrong block after")
+	require.Equal(t, []seth.GetterChange{
+		{Getter: "broken", After: big.NewInt(7), BeforeError: "execution reverted"},
+		{Getter: "counter", Before: big.NewInt(1), After: big.NewInt(2)},
+	}, diff.Changes, "only changed getters should be listed, sorted by name")
+	require.Equal(t, "broken: <error: execution reverted> -> 7; counter: 1 -> 2", diff.String(), "wrong diff summary")
+
+	unchanged, err := seth.DiffSnapshots(after, after)
+	require.NoError(t, err, "failed to diff snapshots")
+	require.False(t, unchanged.HasChanges(), "identical snapshots should have no changes")
+	require.Equal(t, "no changes", unchanged.String(), "wrong diff summary")
+
+	other := *after
+	other.Address = common.HexToAddress("0x3000000000000000000000000000000000000003")
+	_, err = seth.DiffSnapshots(before, &other)
+	require.Error(t, err, "should have failed for snapshots of different contracts")
+	require.Contains(t, err.Error(), seth.ErrSnapshotDiff, "should have returned snapshot diff error")
+
+	c := &seth.Client{Cfg: seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").WithArtifactsFolder(t.TempDir()).Config()}
+	recorded, err := c.RecordSnapshotDiff("increment", before, after)
+	require.NoError(t, err, "failed to record snapshot diff")
+	require.Equal(t, "increment", recorded.Step, "diff should be labelled with step name")
+
+	data, err := os.ReadFile(filepath.Join(c.Cfg.ArtifactsDir, "snapshot_diffs", "increment.json"))
+	require.NoError(t, err, "failed to read saved diff")
+	var saved map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &saved), "failed to unmarshal saved diff")
+	require.Equal(t, "increment", saved["step"], "wrong step in saved diff")
+	require.Len(t, saved["changes"], 2, "wrong number of changes in saved diff")
+}
@@ -1,20 +1,33 @@
 package seth_test
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/smartcontractkit/seth/test_utils"
 	"io"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
 	"github.com/smartcontractkit/seth/contracts/bind/link_token_interface"
 	"github.com/stretchr/testify/require"
 
@@ -1794,3 +1807,1000 @@ func removeGasDataFromDecodedCalls(decodedCall map[string][]*seth.DecodedCall) {
 		}
 	}
 }
+
+func TestTraceContractTracingAsync(t *testing.T) {
+	c := newClientWithContractMapFromEnv(t)
+	SkipAnvil(t, c)
+
+	c.Cfg.TracingLevel = seth.TracingLevel_All
+	c.Cfg.TraceOutputs = []string{seth.TraceOutput_Console}
+	c.TracingQueue = seth.NewTracingQueue(c, 2)
+	t.Cleanup(c.TracingQueue.Close)
+
+	var x int64 = 2
+	var y int64 = 4
+	hashes := make([]string, 0)
+	for i := 0; i < 3; i++ {
+		tx, err := c.Decode(TestEnv.DebugContract.Trace(c.NewTXOpts(), big.NewInt(x), big.NewInt(y)))
+		require.NoError(t, err, FailedToDecode)
+		hashes = append(hashes, tx.Hash)
+	}
+
+	c.FlushTraces()
+
+	require.Equal(t, 3, len(c.Tracer.GetAllDecodedCalls()), "expected 3 decoded transactons")
+	for _, hash := range hashes {
+		require.Equal(t, 2, len(c.Tracer.GetDecodedCalls(hash)), "expected 2 decoded calls for each transaction")
+	}
+}
+
+func TestAPIRecordAndReplay(t *testing.T) {
+	c := newClient(t)
+
+	c.StartRecording()
+	sub, err := c.DeployContractFromContractStore(c.NewTXOpts(), "NetworkDebugSubContract")
+	require.NoError(t, err, "failed to deploy sub contract")
+	main, err := c.DeployContractFromContractStore(c.NewTXOpts(), "NetworkDebugContract", sub.Address)
+	require.NoError(t, err, "failed to deploy main contract")
+	_, err = c.Decode(main.BoundContract.Transact(c.NewTXOpts(), "addCounter", big.NewInt(0), big.NewInt(1)))
+	require.NoError(t, err, "failed to send transaction")
+
+	manifest, err := c.StopRecording()
+	require.NoError(t, err, "failed to stop recording")
+	require.Len(t, manifest.Steps, 3, "incorrect number of recorded steps")
+	require.Equal(t, seth.RecordedStep_Deploy, manifest.Steps[1].Type, "incorrect step type")
+	require.Equal(t, "NetworkDebugContract", manifest.Steps[1].ContractName, "incorrect contract name")
+	require.Equal(t, seth.RecordedStep_Call, manifest.Steps[2].Type, "incorrect step type")
+	require.Equal(t, "addCounter", manifest.Steps[2].Method, "incorrect method")
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	require.NoError(t, manifest.Save(path), "failed to save manifest")
+	loaded, err := seth.LoadInteractionManifest(path)
+	require.NoError(t, err, "failed to load manifest")
+
+	report, err := seth.NewReplayer(c).Replay(loaded)
+	require.NoError(t, err, "failed to replay manifest")
+	require.Len(t, report.Transactions, 1, "incorrect number of replayed transactions")
+	newSub := report.Addresses[sub.Address]
+	newMain := report.Addresses[main.Address]
+	require.NotEqual(t, sub.Address, newSub, "sub contract should be deployed again")
+	require.NotEqual(t, main.Address, newMain, "main contract should be deployed again")
+	require.Equal(t, newMain, *report.Transactions[0].Transaction.To(), "transaction should be sent to the new contract")
+}
+
+type debugTraceService struct{}
+
+func (s *debugTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config == nil {
+		// opcode traces are by far the largest ones
+		return map[string]interface{}{"gas": 21000, "failed": false, "structLogs": make([]map[string]interface{}, 100)}
+	}
+	if (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{"0xa9059cbb-64": 1}
+	}
+	return map[string]interface{}{"type": "CALL", "from": "0x0", "to": "0x1", "input": "0xa9059cbb", "output": "0x"}
+}
+
+func TestAPITraceRetention(t *testing.T) {
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"debug": &debugTraceService{}})
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTraceRetention(2, 0, true).
+		Config()
+	tracer, err := seth.NewTracer(nil, nil, cfg, seth.NewEmptyContractMap(), nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	for _, txHash := range []string{"0x01", "0x02", "0x03"} {
+		require.NoError(t, tracer.TraceGethTX(txHash, nil), "failed to trace transaction")
+	}
+
+	stats := tracer.TraceMemoryStats()
+	require.Equal(t, 2, stats.Transactions, "only 2 traces should be kept")
+	require.Equal(t, 1, stats.Released, "oldest trace should be released")
+	require.Greater(t, stats.Bytes, int64(0), "size of traces should be tracked")
+	require.Error(t, tracer.PrintTXTrace("0x01"), "oldest trace should be released")
+	require.NoError(t, tracer.PrintTXTrace("0x03"), "newest trace should be kept")
+
+	tracer.Release("0x03")
+	tracer.Release("0x02")
+	stats = tracer.TraceMemoryStats()
+	require.Equal(t, 0, stats.Transactions, "all traces should be released")
+	require.Equal(t, int64(0), stats.Bytes, "released traces should not use memory")
+}
+
+type storageTraceService struct {
+	vault, token, user common.Address
+	depositInput       string
+	transferInput      string
+	balanceSlot        common.Hash
+}
+
+func (s *storageTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config == nil {
+		word := func(v int64) string { return common.BigToHash(big.NewInt(v)).Hex() }
+		return map[string]interface{}{"gas": 50000, "failed": false, "structLogs": []map[string]interface{}{
+			{"op": "SLOAD", "depth": 1, "stack": []string{word(0)}, "storage": map[string]string{strings.TrimPrefix(word(0), "0x"): word(5)}},
+			{"op": "SSTORE", "depth": 1, "stack": []string{word(7), word(0)}},
+			{"op": "CALL", "depth": 1, "stack": []string{}},
+			{"op": "SSTORE", "depth": 2, "stack": []string{word(1), word(3)}},
+			{"op": "STOP", "depth": 2, "stack": []string{}},
+			{"op": "SSTORE", "depth": 1, "stack": []string{word(100), s.balanceSlot.Hex()}},
+			{"op": "STOP", "depth": 1, "stack": []string{}},
+		}}
+	}
+	if (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{}
+	}
+	return map[string]interface{}{"type": "CALL", "from": s.user.Hex(), "to": s.vault.Hex(), "input": s.depositInput, "output": "0x",
+		"calls": []map[string]interface{}{{"type": "CALL", "from": s.vault.Hex(), "to": s.token.Hex(), "input": s.transferInput, "output": "0x"}}}
+}
+
+func TestAPITraceStorageAccess(t *testing.T) {
+	vaultAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"deposit","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	tokenAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	service := &storageTraceService{
+		vault: common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed"),
+		token: common.HexToAddress("0x3Aa5ebB10DC797CAC828524e59A333d0A371443c"),
+		user:  common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"),
+	}
+	depositInput, err := vaultAbi.Pack("deposit", service.user, big.NewInt(100))
+	require.NoError(t, err, "failed to pack input")
+	transferInput, err := tokenAbi.Pack("transfer", service.vault, big.NewInt(1))
+	require.NoError(t, err, "failed to pack input")
+	service.depositInput = hexutil.Encode(depositInput)
+	service.transferInput = hexutil.Encode(transferInput)
+	// balances mapping is stored in slot 1
+	service.balanceSlot = crypto.Keccak256Hash(common.LeftPadBytes(service.user.Bytes(), 32), common.LeftPadBytes([]byte{1}, 32))
+
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"debug": service})
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Vault", vaultAbi)
+	cs.AddABI("Token", tokenAbi)
+	cs.AddStorageLayout("Vault", &seth.StorageLayout{
+		Storage: []seth.StorageLayoutEntry{
+			{Label: "totalDeposits", Slot: "0", Type: "t_uint256"},
+			{Label: "balances", Slot: "1", Type: "t_mapping(t_address,t_uint256)"},
+		},
+		Types: map[string]seth.StorageLayoutType{
+			"t_address":                      {Encoding: "inplace", Label: "address"},
+			"t_uint256":                      {Encoding: "inplace", Label: "uint256"},
+			"t_mapping(t_address,t_uint256)": {Encoding: "mapping", Label: "mapping(address => uint256)", Key: "t_address", Value: "t_uint256"},
+		},
+	})
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(service.vault.Hex(), "Vault")
+	contractMap.AddContract(service.token.Hex(), "Token")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithStorageTracing(true).
+		WithArtifactsFolder(t.TempDir()).
+		Config()
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+	require.NoError(t, tracer.TraceGethTX("0x01", nil), "failed to trace transaction")
+
+	calls := tracer.GetDecodedCalls("0x01")
+	require.Len(t, calls, 2, "incorrect number of decoded calls")
+
+	vault := strings.ToLower(service.vault.Hex())
+	require.Equal(t, []seth.StorageAccess{
+		{Address: vault, Slot: common.BigToHash(big.NewInt(0)).Hex(), Value: common.BigToHash(big.NewInt(5)).Hex(), Label: "totalDeposits"},
+	}, calls[0].StorageReads, "incorrect storage reads of main call")
+	require.Equal(t, []seth.StorageAccess{
+		{Address: vault, Slot: common.BigToHash(big.NewInt(0)).Hex(), Value: common.BigToHash(big.NewInt(7)).Hex(), Label: "totalDeposits"},
+		{Address: vault, Slot: service.balanceSlot.Hex(), Value: common.BigToHash(big.NewInt(100)).Hex(), Label: "balances[" + service.user.Hex() + "]"},
+	}, calls[0].StorageWrites, "incorrect storage writes of main call")
+
+	require.Empty(t, calls[1].StorageReads, "sub call should have no storage reads")
+	require.Equal(t, []seth.StorageAccess{
+		{Address: strings.ToLower(service.token.Hex()), Slot: common.BigToHash(big.NewInt(3)).Hex(), Value: common.BigToHash(big.NewInt(1)).Hex()},
+	}, calls[1].StorageWrites, "storage writes of sub call should not be attributed to main call and should not be labeled without storage layout")
+}
+
+func TestAPITracerQueries(t *testing.T) {
+	contractMap := seth.NewEmptyContractMap()
+	main := "0x68b1d87f95878fe05b998f19b66f4baba5de1aed"
+	sub := "0x3aa5ebb10dc797cac828524e59a333d0a371443c"
+	contractMap.AddContract(main, "NetworkDebugContract")
+	contractMap.AddContract(sub, "NetworkDebugSubContract")
+
+	cfg := seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").Config()
+	tracer, err := seth.NewTracer(nil, nil, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	newCall := func(to, method, selector string, events ...string) *seth.DecodedCall {
+		call := &seth.DecodedCall{CommonData: seth.CommonData{Method: method, Signature: selector}, ToAddress: to}
+		for _, event := range events {
+			call.Events = append(call.Events, seth.DecodedCommonLog{Signature: event})
+		}
+		return call
+	}
+	tracer.AddDecodedCalls("0x01", []*seth.DecodedCall{
+		newCall(main, "traceSubWithCallback(int256,int256)", "3e41f135"),
+		newCall(sub, "traceOneInt(int256)", "fa8fca7a", "OneIndexEvent(uint256)"),
+		newCall(sub, "traceOneInt(int256)", "fa8fca7a", "OneIndexEvent(uint256)"),
+	})
+	tracer.AddDecodedCalls("0x02", []*seth.DecodedCall{
+		newCall(main, "traceOneInt(int256)", "fa8fca7a", "OneIndexEvent(uint256)", "TwoIndexEvent(uint256,address)"),
+		newCall(sub, "traceOneInt(int256)", "fa8fca7a"),
+	})
+
+	require.Len(t, tracer.DecodedCalls(), 5, "all calls should be returned")
+	require.Len(t, tracer.CallsTo("NetworkDebugSubContract"), 3, "incorrect number of calls to sub contract")
+	require.Len(t, tracer.CallsTo("NetworkDebugContract"), 2, "incorrect number of calls to main contract")
+	require.Empty(t, tracer.CallsTo("Unknown"), "unknown contract should have no calls")
+
+	require.Len(t, tracer.CallsByMethod("traceOneInt"), 4, "calls should be found by name")
+	require.Len(t, tracer.CallsByMethod("traceOneInt(int256)"), 4, "calls should be found by signature")
+	require.Len(t, tracer.CallsByMethod("0xfa8fca7a"), 4, "calls should be found by selector")
+	require.Len(t, tracer.CallsToMethod("NetworkDebugSubContract", "traceOneInt"), 3, "calls should be found by contract and method")
+
+	require.Len(t, tracer.EventsByName("OneIndexEvent"), 3, "events should be found by name")
+	require.Len(t, tracer.EventsByName("TwoIndexEvent(uint256,address)"), 1, "events should be found by signature")
+
+	tracer.Release("0x01")
+	require.Len(t, tracer.CallsToMethod("NetworkDebugSubContract", "traceOneInt"), 1, "released transactions should not be queried")
+}
+
+func TestAPITenderlyExport(t *testing.T) {
+	token := "0x68b1d87f95878fe05b998f19b66f4baba5de1aed"
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(token, "Token")
+	tokenAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Token", tokenAbi)
+
+	cfg := seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").Config()
+	cfg.Network.ChainID = "1337"
+	tracer, err := seth.NewTracer(cs, nil, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	to := common.HexToAddress("0x3aa5ebb10dc797cac828524e59a333d0a371443c")
+	tracer.AddDecodedCalls("0x01", []*seth.DecodedCall{
+		{CommonData: seth.CommonData{CallType: "CALL", Method: "transfer(address,uint256)", Signature: "a9059cbb", Input: map[string]interface{}{"amount": big.NewInt(5), "to": to}}, ToAddress: token, GasUsed: 100,
+			Events: []seth.DecodedCommonLog{{Signature: "Transfer(address,address,uint256)", ABIName: "Token.abi", EventData: map[string]interface{}{"value": big.NewInt(5), "to": to, "from": to}}}},
+		{CommonData: seth.CommonData{CallType: "STATICCALL", Method: seth.UNKNOWN, Signature: seth.UNKNOWN, NestingLevel: 1}, ToAddress: to.Hex()},
+		{CommonData: seth.CommonData{CallType: "CALL", Method: seth.UNKNOWN, Signature: "12345678", NestingLevel: 2}, ToAddress: to.Hex()},
+		{CommonData: seth.CommonData{CallType: "DELEGATECALL", Method: seth.UNKNOWN, Signature: seth.UNKNOWN, NestingLevel: 1}, ToAddress: to.Hex()},
+	})
+
+	trace, err := tracer.ExportTenderlyTrace("0x01")
+	require.NoError(t, err, "failed to export trace")
+	require.Equal(t, "1337", trace.NetworkID, "incorrect network id")
+	root := trace.CallTrace
+	require.Equal(t, "Token", root.ContractName, "incorrect contract name")
+	require.Equal(t, "0xa9059cbb", root.FunctionSelector, "incorrect selector")
+	require.Equal(t, []seth.TenderlyArg{
+		{Soltype: seth.TenderlySoltype{Name: "to", Type: "address"}, Value: to},
+		{Soltype: seth.TenderlySoltype{Name: "amount", Type: "uint256"}, Value: big.NewInt(5)},
+	}, root.DecodedInput, "inputs should be in ABI order with types")
+	require.Len(t, root.Logs, 1, "incorrect number of logs")
+	require.Equal(t, "Transfer", root.Logs[0].Name, "incorrect log name")
+	require.Equal(t, "from", root.Logs[0].Inputs[0].Soltype.Name, "log inputs should be in ABI order")
+	require.Len(t, root.Calls, 2, "root should have 2 direct sub calls")
+	require.Equal(t, "STATICCALL", root.Calls[0].CallType, "incorrect order of sub calls")
+	require.Len(t, root.Calls[0].Calls, 1, "nested call should be a child of the first sub call")
+	require.Equal(t, "DELEGATECALL", root.Calls[1].CallType, "incorrect order of sub calls")
+
+	_, err = tracer.ExportTenderlyTrace("0x02")
+	require.ErrorContains(t, err, seth.ErrNoDecodedCalls, "unknown transaction should not be exported")
+
+	var requests []string
+	tenderly := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("X-Access-Key"), "access key should be sent")
+		requests = append(requests, r.URL.Path)
+		if strings.HasSuffix(r.URL.Path, "/simulate") {
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body), "failed to decode simulation request")
+			require.Equal(t, float64(12), body["block_number"], "simulation should run at the block of the transaction")
+			_, _ = w.Write([]byte(`{"simulation":{"id":"sim-1"}}`))
+		}
+	}))
+	defer tenderly.Close()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(big.NewInt(1337)), &types.LegacyTx{To: &to, Gas: 21_000, GasPrice: big.NewInt(1)})
+	require.NoError(t, err, "failed to sign transaction")
+	decoded := &seth.DecodedTransaction{Hash: tx.Hash().Hex(), Transaction: tx, Receipt: &types.Receipt{BlockNumber: big.NewInt(12)}}
+
+	c := &seth.Client{Cfg: cfg}
+	_, err = c.ShareTenderlySimulation(context.Background(), seth.TenderlyConfig{}, decoded)
+	require.ErrorContains(t, err, seth.ErrTenderlyConfig, "credentials should be required")
+
+	url, err := c.ShareTenderlySimulation(context.Background(), seth.TenderlyConfig{Account: "acc", Project: "proj", AccessKey: "secret", APIURL: tenderly.URL}, decoded)
+	require.NoError(t, err, "failed to share simulation")
+	require.Equal(t, seth.DefaultTenderlyDashboardURL+"/shared/simulation/sim-1", url, "incorrect shared simulation URL")
+	require.Equal(t, []string{"/api/v1/account/acc/project/proj/simulate", "/api/v1/account/acc/project/proj/simulations/sim-1/share"}, requests, "incorrect API calls")
+}
+
+// failingTraceService exposes debug_traceTransaction, but fails to trace any transaction
+type failingTraceService struct {
+	calls atomic.Int64
+}
+
+func (s *failingTraceService) TraceTransaction(_ common.Hash, _ map[string]interface{}) (interface{}, error) {
+	s.calls.Add(1)
+	return nil, errors.New("tracer crashed")
+}
+
+func TestAPIAsyncTracingFailures(t *testing.T) {
+	service := &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)}
+	debug := &failingTraceService{}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service, "debug": debug})
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTracing(seth.TracingLevel_All, []string{seth.TraceOutput_Console}).
+		WithTracingFailurePolicy(seth.TracingFailurePolicy_DisableAfterFailures, 1).
+		WithProtections(false, false).
+		WithEIP1559DynamicFees(false).
+		WithGasPriceEstimations(false, 0, "").
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+	cfg.TracingWorkers = 2
+
+	c := test_utils.NewMockRPCClient(t, cfg)
+	require.NotNil(t, c.TracingQueue, "tracing queue should have been started")
+
+	to := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.SendRawCall(0, to, []byte{0xde, 0xad, 0xbe, 0xef}, seth.WithGasLimit(60_000))
+			require.NoError(t, err, "tracing failure shouldn't fail the transaction")
+		}()
+	}
+	wg.Wait()
+	c.FlushTraces()
+
+	traced := debug.calls.Load()
+	_, err := c.SendRawCall(0, to, []byte{0xde, 0xad, 0xbe, 0xef}, seth.WithGasLimit(60_000))
+	require.NoError(t, err, "failed to send transaction")
+	c.FlushTraces()
+	require.Equal(t, traced, debug.calls.Load(), "tracing should have been disabled after the first failure")
+
+	c.Close()
+	c.Close()
+}
+
+type stateAccessTraceService struct {
+	user, vault, token, other common.Address
+	runInput, pokeInput       string
+}
+
+func (s *stateAccessTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config == nil {
+		word := func(v int64) string { return common.BigToHash(big.NewInt(v)).Hex() }
+		return map[string]interface{}{"gas": 50000, "failed": false, "structLogs": []map[string]interface{}{
+			{"op": "SLOAD", "depth": 1, "stack": []string{word(0)}},
+			{"op": "SLOAD", "depth": 1, "stack": []string{word(0)}},
+			{"op": "BALANCE", "depth": 1, "stack": []string{s.user.Hex()}},
+			{"op": "EXTCODESIZE", "depth": 1, "stack": []string{s.token.Hex()}},
+			{"op": "CALL", "depth": 1, "stack": []string{word(0), s.token.Hex(), word(30000)}},
+			{"op": "SLOAD", "depth": 2, "stack": []string{word(1)}},
+			{"op": "BALANCE", "depth": 2, "stack": []string{s.other.Hex()}},
+			{"op": "REVERT", "depth": 2, "stack": []string{word(0), word(0)}},
+			// sub call reverted, so accounts and slots it accessed are cold again
+			{"op": "BALANCE", "depth": 1, "stack": []string{s.other.Hex()}},
+			{"op": "STOP", "depth": 1, "stack": []string{}},
+		}}
+	}
+	if (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{}
+	}
+	return map[string]interface{}{"type": "CALL", "from": s.user.Hex(), "to": s.vault.Hex(), "input": s.runInput, "output": "0x",
+		"calls": []map[string]interface{}{{"type": "CALL", "from": s.vault.Hex(), "to": s.token.Hex(), "input": s.pokeInput, "output": "0x", "error": "execution reverted"}}}
+}
+
+func TestAPIStateAccessCounts(t *testing.T) {
+	vaultAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"run","inputs":[],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	tokenAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"poke","inputs":[],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	service := &stateAccessTraceService{
+		user:      common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"),
+		vault:     common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed"),
+		token:     common.HexToAddress("0x3Aa5ebB10DC797CAC828524e59A333d0A371443c"),
+		other:     common.HexToAddress("0x6000000000000000000000000000000000000006"),
+		runInput:  hexutil.Encode(vaultAbi.Methods["run"].ID),
+		pokeInput: hexutil.Encode(tokenAbi.Methods["poke"].ID),
+	}
+
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"debug": service})
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Vault", vaultAbi)
+	cs.AddABI("Token", tokenAbi)
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(service.vault.Hex(), "Vault")
+	contractMap.AddContract(service.token.Hex(), "Token")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithStorageTracing(true).
+		WithArtifactsFolder(t.TempDir()).
+		Config()
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+	require.NoError(t, tracer.TraceGethTX("0x01", nil), "failed to trace transaction")
+
+	calls := tracer.GetDecodedCalls("0x01")
+	require.Len(t, calls, 2, "incorrect number of decoded calls")
+	require.Equal(t, &seth.StateAccessCounts{ColdSlots: 1, WarmSlots: 1, ColdAccounts: 2, WarmAccounts: 2}, calls[0].StateAccess, "incorrect state access of main call")
+	require.Equal(t, &seth.StateAccessCounts{ColdSlots: 1, ColdAccounts: 1}, calls[1].StateAccess, "incorrect state access of sub call")
+
+	total, err := tracer.StateAccessCounts("0x01")
+	require.NoError(t, err, "failed to get state access counts")
+	require.Equal(t, seth.StateAccessCounts{ColdSlots: 2, WarmSlots: 1, ColdAccounts: 3, WarmAccounts: 2}, total, "incorrect state access of transaction")
+	require.Equal(t, uint64(2*2100+3*2600+3*100), total.AccessGas(), "incorrect access gas")
+
+	_, err = tracer.StateAccessCounts("0x02")
+	require.ErrorContains(t, err, seth.ErrNoStateAccess, "untraced transaction should have no state access counts")
+}
+
+// cloneTraceService returns trace of a call to EIP-1167 clone, which delegates it to its implementation
+type cloneTraceService struct {
+	user, clone, implementation common.Address
+	input                       string
+	codeRequests                int
+}
+
+func (s *cloneTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config != nil && (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{s.input[:10] + "-64": 2}
+	}
+	return map[string]interface{}{
+		"type": "CALL", "from": s.user.Hex(), "to": s.clone.Hex(), "input": s.input, "output": "0x", "gas": "0x10000", "gasUsed": "0x5000",
+		"calls": []map[string]interface{}{
+			{"type": "DELEGATECALL", "from": s.clone.Hex(), "to": s.implementation.Hex(), "input": s.input, "output": "0x", "gas": "0xf000", "gasUsed": "0x4000"},
+		},
+	}
+}
+
+func (s *cloneTraceService) GetCode(address common.Address, _ string) hexutil.Bytes {
+	s.codeRequests++
+	if address == s.clone {
+		return append(append(common.FromHex("0x363d3d373d3d3d363d73"), s.implementation.Bytes()...), common.FromHex("0x5af43d82803e903d91602b57fd5bf3")...)
+	}
+	return hexutil.Bytes{0x60, 0x80}
+}
+
+func TestAPIMinimalProxyClones(t *testing.T) {
+	implementation := common.HexToAddress("0x3Aa5ebB10DC797CAC828524e59A333d0A371443c")
+	code := append(append(common.FromHex("0x363d3d373d3d3d363d73"), implementation.Bytes()...), common.FromHex("0x5af43d82803e903d91602b57fd5bf3")...)
+	resolved, ok := seth.EIP1167Implementation(code)
+	require.True(t, ok, "minimal proxy should have been detected")
+	require.Equal(t, implementation, resolved, "incorrect implementation")
+	_, ok = seth.EIP1167Implementation(code[:len(code)-1])
+	require.False(t, ok, "truncated code isn't a minimal proxy")
+
+	transferAbi := `[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`
+	tokenAbi, err := abi.JSON(strings.NewReader(transferAbi))
+	require.NoError(t, err, "failed to parse ABI")
+	walletAbi, err := abi.JSON(strings.NewReader(transferAbi))
+	require.NoError(t, err, "failed to parse ABI")
+
+	service := &cloneTraceService{
+		user:           common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"),
+		clone:          common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed"),
+		implementation: implementation,
+	}
+	input, err := tokenAbi.Pack("transfer", service.user, big.NewInt(1))
+	require.NoError(t, err, "failed to pack input")
+	service.input = hexutil.Encode(input)
+
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"debug": service, "eth": service})
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Token", tokenAbi)
+	// has the same method, so without clone detection ABI of the clone would be a guess
+	cs.AddABI("Wallet", walletAbi)
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(implementation.Hex(), "Token")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithArtifactsFolder(t.TempDir()).
+		Config()
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+	require.NoError(t, tracer.TraceGethTX("0x01", nil), "failed to trace transaction")
+
+	calls := tracer.GetDecodedCalls("0x01")
+	require.Len(t, calls, 2, "both calls should have been decoded")
+	require.Equal(t, "CloneOf(Token)", calls[0].To, "clone should have been labeled with its implementation")
+	require.Equal(t, "transfer(address,uint256)", calls[0].Method, "call to clone should have been decoded with implementation's ABI")
+	require.Equal(t, big.NewInt(1), calls[0].Input["amount"], "input should have been decoded")
+	require.Equal(t, "CloneOf(Token)", calls[1].From, "delegatecall should have been made by the clone")
+	require.Equal(t, "Token", calls[1].To, "implementation should keep its name")
+
+	impl, ok := contractMap.GetCloneImplementation(service.clone.Hex())
+	require.True(t, ok, "clone should have been recorded")
+	require.True(t, strings.EqualFold(implementation.Hex(), impl), "incorrect implementation")
+	require.Equal(t, "Token", contractMap.GetContractName(service.clone.Hex()), "clone should have been mapped to implementation's ABI")
+
+	requests := service.codeRequests
+	require.NoError(t, tracer.TraceGethTX("0x02", nil), "failed to trace transaction")
+	require.Equal(t, requests, service.codeRequests, "clone should have been resolved only once")
+}
+
+// redactionTraceService returns trace of an oracle request, which passes a secret in calldata and emits it in an event
+type redactionTraceService struct {
+	user, oracle      common.Address
+	input, output     string
+	eventID, eventLog string
+}
+
+func (s *redactionTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config != nil && (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{s.input[:10] + "-128": 1}
+	}
+	return map[string]interface{}{
+		"type": "CALL", "from": s.user.Hex(), "to": s.oracle.Hex(), "input": s.input, "output": s.output, "gas": "0x10000", "gasUsed": "0x5000",
+		"logs": []map[string]interface{}{
+			{"address": s.oracle.Hex(), "topics": []string{s.eventID}, "data": s.eventLog},
+		},
+	}
+}
+
+func TestAPITraceRedaction(t *testing.T) {
+	oracleAbi, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"requestData","inputs":[{"name":"url","type":"string"},{"name":"apiKey","type":"string"}],"outputs":[{"name":"requestId","type":"uint256"}]},
+		{"type":"event","name":"Requested","anonymous":false,"inputs":[{"name":"apiKey","type":"string","indexed":false}]}
+	]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	secret := "super-secret-key"
+	service := &redactionTraceService{
+		user:    common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE"),
+		oracle:  common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed"),
+		eventID: oracleAbi.Events["Requested"].ID.Hex(),
+	}
+	input, err := oracleAbi.Pack("requestData", "https://example.com", secret)
+	require.NoError(t, err, "failed to pack input")
+	output, err := oracleAbi.Methods["requestData"].Outputs.Pack(big.NewInt(7))
+	require.NoError(t, err, "failed to pack output")
+	eventLog, err := oracleAbi.Events["Requested"].Inputs.Pack(secret)
+	require.NoError(t, err, "failed to pack event")
+	service.input = hexutil.Encode(input)
+	service.output = hexutil.Encode(output)
+	service.eventLog = hexutil.Encode(eventLog)
+
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"debug": service})
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Oracle", oracleAbi)
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(service.oracle.Hex(), "Oracle")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithArtifactsFolder(t.TempDir()).
+		Config()
+	cfg.Redaction = &seth.RedactionConfig{Arguments: []string{"apiKey"}}
+	require.NoError(t, seth.ValidateConfig(cfg), "config with redaction should be valid")
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	t.Run("argument rule masks inputs and event parameters", func(t *testing.T) {
+		require.NoError(t, tracer.TraceGethTX("0x01", nil), "failed to trace transaction")
+		calls := tracer.GetDecodedCalls("0x01")
+		require.Len(t, calls, 1, "incorrect number of decoded calls")
+		require.Equal(t, seth.RedactedValue(secret), calls[0].Input["apiKey"], "secret input should be masked")
+		require.Equal(t, "https://example.com", calls[0].Input["url"], "other inputs shouldn't be masked")
+		require.Equal(t, big.NewInt(7), calls[0].Output["requestId"], "outputs shouldn't be masked")
+		require.Len(t, calls[0].Events, 1, "incorrect number of decoded events")
+		require.Equal(t, seth.RedactedValue(secret), calls[0].Events[0].EventData["apiKey"], "secret event parameter should be masked")
+
+		asJson, err := json.Marshal(calls)
+		require.NoError(t, err, "failed to marshal decoded calls")
+		require.NotContains(t, string(asJson), secret, "secret leaked to JSON trace")
+	})
+
+	t.Run("method rule masks all inputs and outputs", func(t *testing.T) {
+		tracer.Redactor.RedactMethod("Oracle.requestData")
+		require.NoError(t, tracer.TraceGethTX("0x02", nil), "failed to trace transaction")
+		calls := tracer.GetDecodedCalls("0x02")
+		require.Len(t, calls, 1, "incorrect number of decoded calls")
+		require.Equal(t, seth.RedactedValue(secret), calls[0].Input["apiKey"], "secret input should be masked")
+		require.Equal(t, seth.RedactedValue("https://example.com"), calls[0].Input["url"], "all inputs should be masked")
+		require.Equal(t, seth.RedactedValue(big.NewInt(7)), calls[0].Output["requestId"], "all outputs should be masked")
+		require.True(t, strings.HasPrefix(seth.RedactedValue(secret), seth.RedactedPrefix+"(0x"), "masked value should contain checksum")
+		require.NotEqual(t, seth.RedactedValue(secret), seth.RedactedValue("another-key"), "different secrets should have different checksums")
+	})
+
+	t.Run("empty rules are rejected", func(t *testing.T) {
+		cfg.Redaction = &seth.RedactionConfig{Methods: []string{" "}}
+		require.Error(t, seth.ValidateConfig(cfg), "empty redaction rule should be rejected")
+		cfg.Redaction = nil
+	})
+}
+
+// parityTraceService exposes only trace_transaction, like Erigon or Nethermind without debug API
+type parityTraceService struct {
+	traces json.RawMessage
+}
+
+func (s *parityTraceService) Transaction(_ string) json.RawMessage {
+	return s.traces
+}
+
+func TestAPIParityTraceBackend(t *testing.T) {
+	const feedABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+	const decimals = "0x313ce567"
+	const eight = "0x0000000000000000000000000000000000000000000000000000000000000008"
+	user := "0x1000000000000000000000000000000000000001"
+	proxy := "0x2000000000000000000000000000000000000002"
+	feed := "0x3000000000000000000000000000000000000003"
+	created := "0x4000000000000000000000000000000000000004"
+
+	// flat trace: proxy -> feed (staticcall) -> feed (delegatecall), then proxy creates a contract
+	traces := fmt.Sprintf(`[
+		{"type":"call","action":{"callType":"call","from":"%[1]s","to":"%[2]s","gas":"0x10000","input":"%[5]s","value":"0x0"},"result":{"gasUsed":"0x100","output":"%[6]s"},"subtraces":2,"traceAddress":[]},
+		{"type":"call","action":{"callType":"staticcall","from":"%[2]s","to":"%[3]s","gas":"0x8000","input":"%[5]s","value":"0x0"},"result":{"gasUsed":"0x50","output":"%[6]s"},"subtraces":1,"traceAddress":[0]},
+		{"type":"call","action":{"callType":"delegatecall","from":"%[3]s","to":"%[3]s","gas":"0x4000","input":"%[5]s","value":"0x0"},"result":null,"error":"Reverted","subtraces":0,"traceAddress":[0,0]},
+		{"type":"create","action":{"creationMethod":"create2","from":"%[2]s","gas":"0x4000","init":"0x60806040","value":"0x0"},"result":{"gasUsed":"0x20","address":"%[4]s","code":"0x"},"subtraces":0,"traceAddress":[1]}
+	]`, user, proxy, feed, created, decimals, eight)
+
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"trace": &parityTraceService{traces: json.RawMessage(traces)}})
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTracing(seth.TracingLevel_All, []string{}).
+		WithTraceBackend(seth.TraceBackend_Parity).
+		Config()
+	require.NoError(t, seth.ValidateConfig(cfg), "parity backend should be valid")
+
+	parsed, err := abi.JSON(strings.NewReader(feedABI))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Feed", parsed)
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	txHash := "0x0000000000000000000000000000000000000000000000000000000000000001"
+	require.NoError(t, tracer.TraceGethTX(txHash, nil), "failed to trace transaction")
+
+	calls := tracer.GetDecodedCalls(txHash)
+	require.Len(t, calls, 4, "all calls should be decoded")
+	require.Equal(t, "decimals()", calls[0].Method, "main call should be decoded")
+	require.Equal(t, "CALL", calls[0].CallType, "incorrect call type")
+	require.Equal(t, uint8(8), calls[0].Output["0"], "output of main call should be decoded")
+
+	require.Equal(t, "STATICCALL", calls[1].CallType, "incorrect call type")
+	require.Equal(t, 1, calls[1].NestingLevel, "incorrect nesting level")
+	require.Equal(t, "DELEGATECALL", calls[2].CallType, "incorrect call type")
+	require.Equal(t, 2, calls[2].NestingLevel, "incorrect nesting level")
+	require.Equal(t, "Reverted", calls[2].Error, "error should be kept")
+
+	require.Equal(t, "CREATE2", calls[3].CallType, "incorrect call type")
+	require.Equal(t, created, calls[3].ToAddress, "created contract should be the receiver")
+	require.Equal(t, 1, calls[3].NestingLevel, "incorrect nesting level")
+
+	_, err = seth.ParityToCallTrace([]seth.ParityTrace{{Type: "call", TraceAddress: []int{0, 1}}})
+	require.ErrorContains(t, err, seth.ErrParityTrace, "orphaned call should be rejected")
+
+	cfg.TraceBackend = "erigon"
+	require.ErrorContains(t, seth.ValidateConfig(cfg), "trace_backend must be one of", "unknown backend should be rejected")
+}
+
+func TestAPIExportSession(t *testing.T) {
+	service := &feePayingService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		gasPrice:         big.NewInt(1_000_000_000),
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL+"/v3/very-secret-api-key").
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Worker", contractABI)
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cfg.Network.PrivateKeys = []string{hex.EncodeToString(crypto.FromECDSA(pk))}
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk},
+		seth.WithContractStore(cs), seth.WithContractMap(contractMap), seth.WithABIFinder(&abiFinder))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	worker := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	contract := bind.NewBoundContract(worker, contractABI, c.Client, c.Client, c.Client)
+	for i := 0; i < 2; i++ {
+		_, err := c.Decode(contract.Transact(c.NewTXOpts(), "work"))
+		require.NoError(t, err, "failed to send transaction")
+	}
+
+	report := c.SessionReport()
+	require.Equal(t, 2, report.Transactions, "both transactions should be reported")
+	require.Equal(t, big.NewInt(100_000_000_000_000), report.TotalFees, "fees of both transactions should be summed")
+	require.Len(t, report.GasByMethod, 1, "both transactions call the same method")
+	require.Equal(t, "Worker.work()", report.GasByMethod[0].Method, "method should be prefixed with contract name")
+	require.Equal(t, uint64(50_000), report.GasByMethod[0].AvgGasUsed, "incorrect average gas used")
+
+	path := filepath.Join(t.TempDir(), "artifacts", "session.tar.gz")
+	require.NoError(t, c.ExportSession(path), "failed to export session")
+
+	f, err := os.Open(path)
+	require.NoError(t, err, "failed to open session archive")
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err, "session archive should be gzipped")
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err, "failed to read session archive")
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err, "failed to read session file")
+		files[header.Name] = data
+	}
+
+	for _, name := range []string{seth.SessionFile_Config, seth.SessionFile_ContractMap, seth.SessionFile_Transactions, seth.SessionFile_Report} {
+		require.Contains(t, files, name, "session archive should contain %s", name)
+	}
+	config := string(files[seth.SessionFile_Config])
+	require.NotContains(t, config, "very-secret-api-key", "RPC URL path should be redacted")
+	require.NotContains(t, config, cfg.Network.PrivateKeys[0], "private key should be redacted")
+	require.Contains(t, config, seth.RedactedSecret, "redacted secrets should be marked")
+	require.Equal(t, []string{cfg.Network.PrivateKeys[0]}, c.Cfg.Network.PrivateKeys, "client config shouldn't be modified")
+
+	var txs []seth.SessionTransaction
+	require.NoError(t, json.Unmarshal(files[seth.SessionFile_Transactions], &txs), "failed to parse transactions")
+	require.Len(t, txs, 2, "all decoded transactions should be exported")
+	require.Equal(t, worker.Hex(), txs[0].To, "incorrect receiver")
+	require.Equal(t, c.Addresses[0].Hex(), txs[0].From, "incorrect sender")
+
+	var exportedMap map[string]string
+	require.NoError(t, json.Unmarshal(files[seth.SessionFile_ContractMap], &exportedMap), "failed to parse contract map")
+	require.Equal(t, contractMap.GetContractMap(), exportedMap, "contract map should be exported")
+}
+
+func TestAPIDecodeNestedCalls(t *testing.T) {
+	const feedABI = `[{"inputs":[],"name":"decimals","outputs":[{"internalType":"uint8","name":"","type":"uint8"}],"stateMutability":"view","type":"function"}]`
+	const decimals = "0x313ce567"
+	const eight = "0x0000000000000000000000000000000000000000000000000000000000000008"
+	proxy := "0x2000000000000000000000000000000000000002"
+	feed := "0x3000000000000000000000000000000000000003"
+
+	subCall := func(input string, calls ...seth.Call) seth.Call {
+		return seth.Call{From: proxy, To: feed, Type: "STATICCALL", Input: input, Output: eight, Gas: "0x1000", GasUsed: "0x100", Calls: calls}
+	}
+
+	// output of this call is malformed, so it fails to decode
+	broken := subCall(decimals, subCall(decimals, subCall(decimals)))
+	broken.Output = "0xzz"
+
+	// proxy -> feed -> broken call -> feed -> feed, and a second call of proxy at the top level
+	trace := seth.Trace{
+		TxHash: "0x0000000000000000000000000000000000000000000000000000000000000001",
+		CallTrace: &seth.TXCallTraceOutput{
+			Call: seth.Call{From: proxy, To: proxy, Type: "CALL", Input: decimals, Output: eight, Gas: "0x10000", GasUsed: "0x1000"},
+			Calls: []seth.Call{
+				subCall(decimals, broken),
+				subCall(decimals),
+			},
+		},
+	}
+
+	rpcURL := test_utils.NewMockRPC(t, nil)
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTracing(seth.TracingLevel_All, []string{}).
+		Config()
+
+	parsed, err := abi.JSON(strings.NewReader(feedABI))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Feed", parsed)
+	contractMap := seth.NewEmptyContractMap()
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	tracer, err := seth.NewTracer(cs, &abiFinder, cfg, contractMap, nil)
+	require.NoError(t, err, "failed to create tracer")
+
+	calls, err := tracer.DecodeTrace(seth.L, trace)
+	require.NoError(t, err, "failed to decode trace")
+	require.Len(t, calls, 6, "all calls should be kept")
+
+	expected := []struct {
+		method       string
+		nestingLevel int
+		parentIndex  int
+	}{
+		{"decimals()", 0, -1},
+		{"decimals()", 1, 0},
+		{seth.FAILED_TO_DECODE, 2, 1},
+		{"decimals()", 3, 2},
+		{"decimals()", 4, 3},
+		{"decimals()", 1, 0},
+	}
+	for i, e := range expected {
+		require.Equal(t, e.method, calls[i].Method, "incorrect method of call %d", i)
+		require.Equal(t, e.nestingLevel, calls[i].NestingLevel, "incorrect nesting level of call %d", i)
+		if e.parentIndex < 0 {
+			require.Nil(t, calls[i].ParentIndex, "main call should have no parent")
+			continue
+		}
+		require.NotNil(t, calls[i].ParentIndex, "call %d should have a parent", i)
+		require.Equal(t, e.parentIndex, *calls[i].ParentIndex, "incorrect parent index of call %d", i)
+	}
+	require.Equal(t, uint8(8), calls[4].Output["0"], "calls below a call that failed to decode should be decoded")
+
+	marshalled, err := json.Marshal(calls[3])
+	require.NoError(t, err, "failed to marshal decoded call")
+	require.Contains(t, string(marshalled), `"parent_index":2`, "parent index should be saved")
+	marshalled, err = json.Marshal(calls[0])
+	require.NoError(t, err, "failed to marshal decoded call")
+	require.NotContains(t, string(marshalled), `"parent_index"`, "main call should have no parent index")
+}
+
+type loggingWorkerService struct {
+	*feePayingService
+	worker common.Address
+	event  common.Hash
+	data   []byte
+}
+
+func (s *loggingWorkerService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	receipt := s.feePayingService.GetTransactionReceipt(txHash)
+	if receipt == nil {
+		return nil
+	}
+	receipt.Logs = []*types.Log{{Address: s.worker, Topics: []common.Hash{s.event}, Data: s.data, TxHash: txHash}}
+	return receipt
+}
+
+type receiptLogsTraceService struct {
+	mu             sync.Mutex
+	service        *loggingWorkerService
+	opCodesTraces  int
+	callTraceInput string
+}
+
+func (s *receiptLogsTraceService) TraceTransaction(_ string, config *map[string]interface{}) interface{} {
+	if config == nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.opCodesTraces++
+		return map[string]interface{}{"gas": 50000, "failed": false, "structLogs": []map[string]interface{}{}}
+	}
+	if (*config)["tracer"] == "4byteTracer" {
+		return map[string]int{}
+	}
+	log := map[string]interface{}{"address": s.service.worker.Hex(), "topics": []string{s.service.event.Hex()}, "data": hexutil.Encode(s.service.data)}
+	return map[string]interface{}{"type": "CALL", "from": "0x0", "to": s.service.worker.Hex(), "input": s.callTraceInput, "output": "0x",
+		"gas": "0xc350", "gasUsed": "0xc350", "logs": []interface{}{log}}
+}
+
+func TestAPITraceReusesReceiptLogs(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"},` +
+		`{"type":"event","name":"Worked","inputs":[{"name":"secret","type":"uint256","indexed":false}],"anonymous":false}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	data, err := contractABI.Events["Worked"].Inputs.Pack(big.NewInt(42))
+	require.NoError(t, err, "failed to pack event data")
+
+	service := &loggingWorkerService{
+		feePayingService: &feePayingService{
+			gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+			gasPrice:         big.NewInt(1_000_000_000),
+		},
+		worker: common.HexToAddress("0x7000000000000000000000000000000000000007"),
+		event:  contractABI.Events["Worked"].ID,
+		data:   data,
+	}
+	traceService := &receiptLogsTraceService{service: service, callTraceInput: hexutil.Encode(contractABI.Methods["work"].ID)}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service, "debug": traceService})
+
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl(rpcURL).
+		WithTracing(seth.TracingLevel_All, []string{}).
+		WithProtections(false, false).
+		WithEIP1559DynamicFees(false).
+		WithGasPriceEstimations(false, 0, "").
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+	cfg.Redaction = &seth.RedactionConfig{Arguments: []string{"secret"}}
+
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Worker", contractABI)
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	cfg.Network.PrivateKeys = []string{hex.EncodeToString(crypto.FromECDSA(pk))}
+	contractMap := seth.NewEmptyContractMap()
+	contractMap.AddContract(service.worker.Hex(), "Worker")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk},
+		seth.WithContractStore(cs), seth.WithContractMap(contractMap), seth.WithABIFinder(&abiFinder))
+	require.NoError(t, err, "failed to create client")
+	defer c.Client.Close()
+
+	contract := bind.NewBoundContract(service.worker, contractABI, c.Client, c.Client, c.Client)
+	decoded, err := c.Decode(contract.Transact(c.NewTXOpts(), "work"))
+	require.NoError(t, err, "failed to decode transaction")
+	require.Len(t, decoded.Events, 1, "event should be decoded from receipt")
+	require.Equal(t, seth.RedactedValue(big.NewInt(42)), decoded.Events[0].EventData["secret"], "event data should be redacted")
+
+	calls := c.Tracer.GetDecodedCalls(decoded.Hash)
+	require.Len(t, calls, 1, "main call should be decoded")
+	require.Len(t, calls[0].Events, 1, "event of main call should be decoded")
+	require.Equal(t, decoded.Events[0].EventData, calls[0].Events[0].EventData, "event decoded from receipt should be reused without redacting it again")
+	require.Equal(t, "Worker", calls[0].Events[0].ABIName, "ABI name should be set")
+
+	calls[0].Events[0].EventData["secret"] = "changed"
+	require.Equal(t, seth.RedactedValue(big.NewInt(42)), decoded.Events[0].EventData["secret"], "trace events should not share data with transaction events")
+
+	traceService.mu.Lock()
+	defer traceService.mu.Unlock()
+	require.Equal(t, 0, traceService.opCodesTraces, "opcodes trace should only be fetched for storage tracing")
+}
+
+func TestAPIStrictTracingMissingABI(t *testing.T) {
+	service := &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	to := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	calldata := []byte{0xde, 0xad, 0xbe, 0xef}
+	for _, strict := range []bool{true, false} {
+		t.Run(fmt.Sprintf("strict %t", strict), func(t *testing.T) {
+			cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+				WithLegacyGasPrice(1_000_000_000).
+				WithGasBumping(0, 0, nil).
+				WithStrictTracing(strict).
+				Config()
+			cs, err := seth.NewContractStore("", "")
+			require.NoError(t, err, "failed to create contract store")
+			abiFinder := seth.NewABIFinder(seth.NewEmptyContractMap(), cs)
+			c := test_utils.NewMockRPCClient(t, cfg, seth.WithContractStore(cs), seth.WithABIFinder(&abiFinder))
+
+			decoded, err := c.SendRawCall(0, to, calldata)
+			require.NotNil(t, decoded, "decoded transaction should be returned")
+			require.Equal(t, calldata, decoded.Transaction.Data(), "incorrect calldata")
+			if !strict {
+				require.NoError(t, err, "call without ABI shouldn't fail when strict tracing is disabled")
+				return
+			}
+			require.ErrorContains(t, err, seth.ErrUndecodedCalls, "call without ABI should fail strict tracing")
+			require.ErrorContains(t, err, fmt.Sprintf("selector 0xdeadbeef to %s (missing ABI)", to.Hex()), "error should name selector and address")
+		})
+	}
+}
@@ -0,0 +1,840 @@
+package seth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+type rawTxService struct {
+	mu   sync.Mutex
+	sent []hexutil.Bytes
+}
+
+func (s *rawTxService) GetTransactionCount(_ common.Address, _ string) hexutil.Uint64 {
+	return 7
+}
+
+func (s *rawTxService) EstimateGas(_ map[string]interface{}) hexutil.Uint64 {
+	return 30_000
+}
+
+func (s *rawTxService) SendRawTransaction(raw hexutil.Bytes) common.Hash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, raw)
+	tx := new(types.Transaction)
+	_ = tx.UnmarshalBinary(raw)
+	return tx.Hash()
+}
+
+func TestAPISignAndSendRawTx(t *testing.T) {
+	service := &rawTxService{}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	c := &seth.Client{
+		Cfg: seth.NewClientBuilder().
+			WithEIP1559DynamicFees(true).
+			WithDynamicGasPrices(200, 100).
+			WithGasPriceEstimations(false, 0, "").
+			Config(),
+		Client:      ethclient.NewClient(rpc.DialInProc(server)),
+		Addresses:   []common.Address{crypto.PubkeyToAddress(pk.PublicKey)},
+		PrivateKeys: []*ecdsa.PrivateKey{pk},
+		ChainID:     1337,
+	}
+	to := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+
+	signed, raw, err := c.SignTx(0, &types.DynamicFeeTx{To: &to, Value: big.NewInt(1), GasTipCap: big.NewInt(5)})
+	require.NoError(t, err, "failed to sign transaction")
+	require.Equal(t, uint64(7), signed.Nonce(), "nonce should be the pending nonce")
+	require.Equal(t, uint64(30_000), signed.Gas(), "gas limit should be estimated")
+	require.Equal(t, big.NewInt(200), signed.GasFeeCap(), "gas fee cap should come from config")
+	require.Equal(t, big.NewInt(5), signed.GasTipCap(), "gas tip cap set by caller should be kept")
+	require.Equal(t, big.NewInt(1337), signed.ChainId(), "incorrect chain ID")
+	sender, err := types.Sender(types.LatestSignerForChainID(signed.ChainId()), signed)
+	require.NoError(t, err, "failed to recover sender")
+	require.Equal(t, c.Addresses[0], sender, "incorrect sender")
+
+	// unsigned transaction built by external tool
+	unsigned, err := types.NewTx(&types.LegacyTx{To: &to, Gas: 21_000, GasPrice: big.NewInt(3)}).MarshalBinary()
+	require.NoError(t, err, "failed to encode unsigned transaction")
+	signedLegacy, _, err := c.SignRawTx(0, hexutil.Encode(unsigned))
+	require.NoError(t, err, "failed to sign raw transaction")
+	require.Equal(t, uint64(21_000), signedLegacy.Gas(), "gas limit set by caller should be kept")
+	require.Equal(t, big.NewInt(3), signedLegacy.GasPrice(), "gas price set by caller should be kept")
+
+	sent, err := c.SendRawTx(raw)
+	require.NoError(t, err, "failed to send raw transaction")
+	require.Equal(t, signed.Hash(), sent.Hash(), "sent transaction should be the signed one")
+	require.Len(t, service.sent, 1, "transaction should be sent")
+
+	_, _, err = c.SignTx(0, &types.BlobTx{})
+	require.Error(t, err, "blob transactions should be rejected when they are disabled")
+	require.Contains(t, err.Error(), seth.ErrUnsupportedTxType, "incorrect error")
+
+	c.Cfg.Network.BlobTransactions = true
+	c.Cfg.Network.BlobFeeCap = 300
+	signedBlob, _, err := c.SignTx(0, &types.BlobTx{To: to, BlobHashes: []common.Hash{{0x01}}})
+	require.NoError(t, err, "blob transaction should be signed when blob transactions are enabled")
+	require.Equal(t, uint8(types.BlobTxType), signedBlob.Type(), "incorrect transaction type")
+	require.Equal(t, big.NewInt(300), signedBlob.BlobGasFeeCap(), "blob fee cap should come from config")
+	require.Equal(t, uint64(30_000), signedBlob.Gas(), "gas limit should be estimated")
+}
+
+func TestAPISendRawCall(t *testing.T) {
+	service := &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	c := test_utils.NewMockRPCClient(t, cfg)
+
+	to := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	calldata := []byte{0xde, 0xad, 0xbe, 0xef}
+	decoded, err := c.SendRawCall(0, to, calldata, seth.WithValue(big.NewInt(1)))
+	require.NoError(t, err, "raw call should have been mined")
+	require.Equal(t, calldata, decoded.Transaction.Data(), "calldata should be sent as-is")
+	require.Equal(t, to, *decoded.Transaction.To(), "incorrect recipient")
+	require.Equal(t, big.NewInt(1), decoded.Transaction.Value(), "value should be set with transaction options")
+	require.Equal(t, uint64(0), decoded.Transaction.Nonce(), "nonce should come from nonce manager")
+	require.Equal(t, service.estimate, decoded.Transaction.Gas(), "gas limit should be estimated")
+
+	decoded, err = c.SendRawCall(0, to, calldata, seth.WithGasLimit(49_000))
+	require.Error(t, err, "raw call with too little gas should have reverted")
+	require.Equal(t, uint64(1), decoded.Transaction.Nonce(), "nonce should be incremented")
+	require.Equal(t, uint64(49_000), decoded.Transaction.Gas(), "gas limit set with options should be used")
+
+	_, err = c.SendRawCall(-1, to, calldata)
+	require.ErrorContains(t, err, "keyNum is out of range", "invalid key should have been rejected")
+}
+
+type receiptPollingService struct {
+	mu    sync.Mutex
+	polls int
+}
+
+func (s *receiptPollingService) GetBlockByNumber(number string, _ bool) *types.Header {
+	latest := int64(100)
+	if number != "latest" {
+		n, _ := hexutil.DecodeBig(number)
+		latest = n.Int64()
+	}
+	return &types.Header{Number: big.NewInt(latest), Difficulty: big.NewInt(0), Time: uint64(latest) * 12}
+}
+
+func (s *receiptPollingService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.polls++
+	if s.polls < 3 {
+		return nil
+	}
+	return &types.Receipt{TxHash: txHash, BlockNumber: big.NewInt(100), Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}
+}
+
+func TestAPIReceiptPolling(t *testing.T) {
+	service := &receiptPollingService{}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	var attempts []int
+	c := &seth.Client{
+		Cfg: seth.NewClientBuilder().
+			WithReceiptPolling(5*time.Second, 0, true).
+			WithReceiptPollFn(func(attempt int, interval time.Duration) time.Duration {
+				attempts = append(attempts, attempt)
+				require.Equal(t, 3*time.Second, interval, "interval should be a quarter of 12s block time")
+				return time.Millisecond
+			}).
+			Config(),
+		Client: ethclient.NewClient(rpc.DialInProc(server)),
+	}
+	require.Equal(t, 3*time.Second, c.ReceiptPollInterval(), "interval should be derived from block time")
+
+	c.Cfg.Network.AdaptiveReceiptPolling = false
+	require.Equal(t, 5*time.Second, c.ReceiptPollInterval(), "configured interval should be used without adaptive polling")
+	c.Cfg.Network.AdaptiveReceiptPolling = true
+
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+	receipt, err := c.WaitMined(context.Background(), seth.L, c.Client, tx)
+	require.NoError(t, err, "failed to wait for transaction")
+	require.Equal(t, tx.Hash(), receipt.TxHash, "incorrect receipt")
+	require.Equal(t, []int{1, 2}, attempts, "custom poll function should be called before each retry")
+}
+
+type txValidityService struct {
+	mu          sync.Mutex
+	blockNumber uint64
+	cancelled   *types.Transaction
+}
+
+func (s *txValidityService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blockNumber++
+	return hexutil.Uint64(s.blockNumber)
+}
+
+func (s *txValidityService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(10))
+}
+
+func (s *txValidityService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.cancelled = tx
+	return tx.Hash(), nil
+}
+
+func (s *txValidityService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// original transaction is never mined
+	if s.cancelled == nil || s.cancelled.Hash() != txHash {
+		return nil
+	}
+	return &types.Receipt{TxHash: txHash, BlockNumber: big.NewInt(int64(s.blockNumber)), Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}
+}
+
+func TestAPITxValidity(t *testing.T) {
+	service := &txValidityService{blockNumber: 10}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	addr := crypto.PubkeyToAddress(pk.PublicKey)
+
+	c := &seth.Client{
+		Cfg: seth.NewClientBuilder().
+			WithEIP1559DynamicFees(false).
+			WithLegacyGasPrice(1).
+			WithTxValidity(3).
+			WithReceiptPollFn(func(_ int, _ time.Duration) time.Duration {
+				return time.Millisecond
+			}).
+			Config(),
+		Client:      ethclient.NewClient(rpc.DialInProc(server)),
+		Addresses:   []common.Address{addr},
+		PrivateKeys: []*ecdsa.PrivateKey{pk},
+		ChainID:     1337,
+	}
+
+	to := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(big.NewInt(1337)), &types.LegacyTx{Nonce: 4, To: &to, Value: big.NewInt(1), Gas: 21_000, GasPrice: big.NewInt(50)})
+	require.NoError(t, err, "failed to sign transaction")
+
+	_, timing, err := c.WaitMinedWithTiming(context.Background(), seth.L, c.Client, tx)
+	require.Error(t, err, "expired transaction should not be mined")
+	var expiredErr *seth.TxExpiredError
+	require.True(t, errors.As(err, &expiredErr), "error should be TxExpiredError")
+	require.Equal(t, uint64(11), expiredErr.StartBlock, "incorrect start block")
+	require.Equal(t, uint64(3), expiredErr.ValidityBlocks, "incorrect validity blocks")
+	require.Equal(t, tx.Hash(), expiredErr.Tx.Hash(), "incorrect expired transaction")
+
+	cancellation := expiredErr.Cancellation
+	require.Equal(t, service.cancelled.Hash(), cancellation.Hash(), "cancellation should be the sent transaction")
+	require.Equal(t, tx.Nonce(), cancellation.Nonce(), "cancellation should use the same nonce")
+	require.Equal(t, addr, *cancellation.To(), "cancellation should be a transfer to self")
+	require.Equal(t, big.NewInt(0), cancellation.Value(), "cancellation should not transfer any value")
+	require.Equal(t, big.NewInt(100), cancellation.GasPrice(), "cancellation should pay twice the original gas price")
+	require.Greater(t, timing.Polls, 1, "receipt should be polled until deadline")
+}
+
+type pendingTxService struct {
+	txs []*types.Transaction
+}
+
+func (s *pendingTxService) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for _, tx := range s.txs {
+			_ = notifier.Notify(sub.ID, tx.Hash())
+		}
+	}()
+	return sub, nil
+}
+
+func (s *pendingTxService) GetTransactionByHash(hash common.Hash) *types.Transaction {
+	for _, tx := range s.txs {
+		if tx.Hash() == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
+type txPoolContentService struct {
+	content map[string]map[string]map[string]*types.Transaction
+}
+
+func (s *txPoolContentService) Content() map[string]map[string]map[string]*types.Transaction {
+	return s.content
+}
+
+func TestAPIPendingWatcher(t *testing.T) {
+	contractAbi, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"mint","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Token", contractAbi)
+	contractMap := seth.NewEmptyContractMap()
+	token := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	contractMap.AddContract(token.Hex(), "Token")
+	abiFinder := seth.NewABIFinder(contractMap, cs)
+
+	ours, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	theirs, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	calldata, err := contractAbi.Pack("mint", big.NewInt(5))
+	require.NoError(t, err, "failed to pack calldata")
+
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+	ourTx, err := types.SignNewTx(ours, signer, &types.LegacyTx{Nonce: 1, To: &token, Gas: 50_000, GasPrice: big.NewInt(1), Data: calldata})
+	require.NoError(t, err, "failed to sign transaction")
+	theirTx, err := types.SignNewTx(theirs, signer, &types.LegacyTx{Nonce: 1, To: &token, Gas: 50_000, GasPrice: big.NewInt(1), Data: calldata})
+	require.NoError(t, err, "failed to sign transaction")
+	ourAddr := crypto.PubkeyToAddress(ours.PublicKey)
+
+	newClient := func(server *rpc.Server) *seth.Client {
+		return &seth.Client{
+			Cfg:                      seth.NewClientBuilder().WithReceiptPolling(10*time.Millisecond, 0, false).Config(),
+			Client:                   ethclient.NewClient(rpc.DialInProc(server)),
+			Addresses:                []common.Address{ourAddr},
+			ContractStore:            cs,
+			ContractAddressToNameMap: contractMap,
+			ABIFinder:                &abiFinder,
+		}
+	}
+
+	assertPending := func(t *testing.T, w *seth.PendingWatcher) {
+		select {
+		case pending := <-w.Pending():
+			require.Equal(t, ourTx.Hash(), pending.Hash, "only transaction from watched address should be reported")
+			require.Equal(t, ourAddr, pending.From, "incorrect sender")
+			require.Equal(t, "mint", pending.Method, "calldata should be decoded")
+			require.Equal(t, big.NewInt(5), pending.Input["amount"], "calldata should be decoded")
+		case <-time.After(5 * time.Second):
+			t.Fatal("pending transaction was not reported")
+		}
+		_, seen := w.Seen(ourTx.Hash())
+		require.True(t, seen, "transaction should be seen")
+		_, seen = w.Seen(theirTx.Hash())
+		require.False(t, seen, "transaction from other address should not be seen")
+	}
+
+	t.Run("subscription", func(t *testing.T) {
+		server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": &pendingTxService{txs: []*types.Transaction{theirTx, ourTx}}})
+
+		w, err := newClient(server).NewPendingWatcher()
+		require.NoError(t, err, "failed to create pending watcher")
+		require.False(t, w.Polling, "subscription should be used")
+		assertPending(t, w)
+		w.Stop()
+		_, open := <-w.Pending()
+		require.False(t, open, "channel should be closed after watcher is stopped")
+	})
+
+	t.Run("txpool polling", func(t *testing.T) {
+		server := test_utils.NewMockRPCServer(t, nil)
+		require.NoError(t, server.RegisterName("txpool", &txPoolContentService{content: map[string]map[string]map[string]*types.Transaction{
+			"pending": {
+				ourAddr.Hex(): {"1": ourTx},
+				crypto.PubkeyToAddress(theirs.PublicKey).Hex(): {"1": theirTx},
+			},
+		}}))
+
+		w, err := newClient(server).NewPendingWatcher()
+		require.NoError(t, err, "failed to create pending watcher")
+		defer w.Stop()
+		require.True(t, w.Polling, "txpool_content should be polled when subscription is not supported")
+		assertPending(t, w)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		server := test_utils.NewMockRPCServer(t, nil)
+
+		_, err := newClient(server).NewPendingWatcher()
+		require.ErrorContains(t, err, seth.ErrPendingWatcher, "watcher should not be created")
+	})
+}
+
+func TestAPIFallbackGasPrices(t *testing.T) {
+	cfg := seth.NewClientBuilder().
+		WithLegacyGasPrice(1_000).
+		WithDynamicGasPrices(2_000, 500).
+		WithFallbackGasPrices(
+			map[string]int64{seth.Priority_Fast: 3_000, seth.Priority_Slow: 100},
+			map[string]int64{seth.Priority_Fast: 5_000},
+			nil,
+		).
+		Config()
+
+	gasPrice, gasFeeCap, gasTipCap := cfg.Network.FallbackFees(seth.Priority_Fast)
+	require.Equal(t, []int64{3_000, 5_000, 500}, []int64{gasPrice, gasFeeCap, gasTipCap}, "fast priority should use its fallbacks")
+	gasPrice, gasFeeCap, gasTipCap = cfg.Network.FallbackFees(seth.Priority_Standard)
+	require.Equal(t, []int64{1_000, 2_000, 500}, []int64{gasPrice, gasFeeCap, gasTipCap}, "standard priority should use static fallbacks")
+
+	c := &seth.Client{Cfg: cfg}
+	c.Cfg.Network.GasPriceEstimationEnabled = false
+	estimations := c.CalculateGasEstimations(c.NewGasEstimationRequest(seth.Priority_Slow))
+	require.Equal(t, int64(100), estimations.GasPrice.Int64(), "slow priority fallback gas price should be used")
+	require.Equal(t, int64(2_000), estimations.GasFeeCap.Int64(), "static gas fee cap should be used")
+
+	cfg.Network.FallbackGasTipCaps = map[string]int64{"urgent": 1}
+	require.ErrorContains(t, seth.ValidateConfig(cfg), "fallback_gas_tip_cap has unknown priority 'urgent'", "unknown priority should be rejected")
+}
+
+type txTypeService struct {
+	baseFee *big.Int
+}
+
+func (s *txTypeService) GetBlockByNumber(_ string, _ bool) *types.Header {
+	return &types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(0), BaseFee: s.baseFee}
+}
+
+func (s *txTypeService) FeeHistory(_ string, _ string, _ []float64) (interface{}, error) {
+	return map[string]interface{}{"oldestBlock": "0x1"}, nil
+}
+
+func TestAPIDetectTxType(t *testing.T) {
+	service := &txTypeService{baseFee: big.NewInt(1_000)}
+	server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+	rpcClient := rpc.DialInProc(server)
+
+	c := &seth.Client{
+		Client:       ethclient.NewClient(rpcClient),
+		ChainID:      1337,
+		Capabilities: seth.ProbeCapabilities(context.Background(), rpcClient, nil),
+	}
+
+	txType, err := c.DetectTxType(context.Background())
+	require.NoError(t, err, "failed to detect transaction type")
+	require.Equal(t, seth.TxType_DynamicFee, txType, "network with base fee should use dynamic fee transactions")
+
+	service.baseFee = nil
+	txType, err = c.DetectTxType(context.Background())
+	require.NoError(t, err, "failed to detect transaction type")
+	require.Equal(t, seth.TxType_Legacy, txType, "network without base fee should use legacy transactions")
+
+	service.baseFee = big.NewInt(1_000)
+	c.ChainID = 56
+	txType, err = c.DetectTxType(context.Background())
+	require.NoError(t, err, "failed to detect transaction type")
+	require.Equal(t, seth.TxType_Legacy, txType, "registry entry should take precedence over probing")
+}
+
+func TestAPIWaitForCondition(t *testing.T) {
+	cfg := seth.NewClientBuilder().Config()
+	c := &seth.Client{Cfg: cfg, Addresses: []common.Address{common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE")}}
+
+	checks := 0
+	err := c.WaitForCondition(context.Background(), time.Millisecond, func() (bool, error) {
+		checks++
+		return checks == 3, nil
+	})
+	require.NoError(t, err, "condition should be met")
+	require.Equal(t, 3, checks, "condition should be checked until it's met")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = c.WaitForCondition(ctx, time.Millisecond, func() (bool, error) { return false, nil })
+	require.ErrorContains(t, err, seth.ErrConditionNotMet, "condition should not be met")
+
+	err = c.WaitForCondition(context.Background(), time.Millisecond, func() (bool, error) { return false, errors.New("boom") })
+	require.ErrorContains(t, err, "boom", "getter error should stop waiting")
+
+	c.Cfg.Network.ReceiptPollInterval = seth.MustMakeDuration(time.Millisecond)
+	round := int64(0)
+	err = seth.WaitForValue(c, func(opts *bind.CallOpts) (*big.Int, error) {
+		require.Equal(t, c.Addresses[0], opts.From, "call options should be passed to getter")
+		round++
+		return big.NewInt(round), nil
+	}, big.NewInt(5), time.Second)
+	require.NoError(t, err, "value should be reached")
+
+	err = seth.WaitForValue(c, func(_ *bind.CallOpts) (string, error) { return "pending", nil }, "done", 20*time.Millisecond)
+	require.ErrorContains(t, err, "last value was pending, but done was expected", "timeout error should include last value")
+}
+
+// gasHungryService mines every transaction immediately, transactions with gas limit lower than the estimation run out of gas
+type gasHungryService struct {
+	mu       sync.Mutex
+	estimate uint64
+	limits   []uint64
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (s *gasHungryService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1337))
+}
+
+func (s *gasHungryService) GetTransactionCount(_ common.Address, _ string) hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(len(s.limits))
+}
+
+func (s *gasHungryService) GetCode(_ common.Address, _ string) hexutil.Bytes {
+	return hexutil.Bytes{0x01}
+}
+
+func (s *gasHungryService) EstimateGas(_ map[string]interface{}) hexutil.Uint64 {
+	return hexutil.Uint64(s.estimate)
+}
+
+func (s *gasHungryService) Call(_ map[string]interface{}, _ string) (hexutil.Bytes, error) {
+	return nil, errors.New("out of gas")
+}
+
+func (s *gasHungryService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = append(s.limits, tx.Gas())
+	receipt := &types.Receipt{
+		TxHash:      tx.Hash(),
+		Status:      types.ReceiptStatusSuccessful,
+		BlockNumber: big.NewInt(1),
+		GasUsed:     s.estimate,
+		Logs:        []*types.Log{},
+	}
+	if tx.Gas() < s.estimate {
+		receipt.Status = types.ReceiptStatusFailed
+		receipt.GasUsed = tx.Gas()
+	}
+	s.receipts[tx.Hash()] = receipt
+	return tx.Hash(), nil
+}
+
+func (s *gasHungryService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.receipts[txHash]
+}
+
+func TestAPIOutOfGas(t *testing.T) {
+	service := &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		Config()
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Worker", contractABI)
+
+	c := test_utils.NewMockRPCClient(t, cfg, seth.WithContractStore(cs))
+
+	contractAddress := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	contract := bind.NewBoundContract(contractAddress, contractABI, c.Client, c.Client, c.Client)
+	work := func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return contract.Transact(opts, "work")
+	}
+
+	estimated, err := c.EstimateCallGas(0, work)
+	require.NoError(t, err, "failed to estimate gas")
+	require.Equal(t, service.estimate, estimated, "node's estimation should have been returned")
+	require.Empty(t, service.limits, "estimation shouldn't have sent anything")
+
+	decoded, err := c.SendWithExactGas(0, work)
+	require.NoError(t, err, "transaction with exact gas should have succeeded")
+	require.False(t, decoded.HasWarning(seth.DecodeWarning_OutOfGas), "successful transaction shouldn't have been classified as out of gas")
+
+	decoded, err = c.SendWithGasDelta(0, 1_000, work)
+	require.Error(t, err, "transaction with too little gas should have reverted")
+	require.True(t, seth.IsOutOfGas(err), "revert should have been classified as out of gas")
+	require.True(t, decoded.HasWarning(seth.DecodeWarning_OutOfGas), "decoded transaction should have out of gas warning")
+
+	_, err = c.SendExpectingOutOfGas(0, 0, work)
+	require.NoError(t, err, "transaction should have run out of gas with default delta")
+
+	_, err = c.SendWithGasDelta(0, service.estimate, work)
+	require.ErrorContains(t, err, seth.ErrGasDeltaTooLarge, "delta larger than estimation should have been rejected")
+
+	require.Equal(t, []uint64{50_000, 49_000, 49_999}, service.limits, "transactions should have been sent with exact gas limits")
+}
+
+func TestAPIErrorTaxonomy(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected error
+	}{
+		{err: errors.New("insufficient funds for gas * price + value"), expected: seth.ErrInsufficientFunds},
+		{err: &seth.InsufficientBalanceError{}, expected: seth.ErrInsufficientFunds},
+		{err: errors.New("nonce too low: next nonce 5, tx nonce 4"), expected: seth.ErrNonceConflict},
+		{err: errors.New("replacement transaction underpriced"), expected: seth.ErrNonceConflict},
+		{err: errors.New("execution reverted: Ownable: caller is not the owner"), expected: seth.ErrReverted},
+		{err: fmt.Errorf("failed to connect: %w", &net.OpError{Op: "dial", Err: errors.New("refused")}), expected: seth.ErrRPCUnavailable},
+		{err: errors.New("Post \"http://localhost:8545\": dial tcp 127.0.0.1:8545: connect: connection refused"), expected: seth.ErrRPCUnavailable},
+		{err: fmt.Errorf("waiting for receipt: %w", context.DeadlineExceeded), expected: seth.ErrTimeout},
+		{err: errors.New("deployment transaction was not mined within 1m0s"), expected: seth.ErrTimeout},
+	}
+	for _, tt := range tests {
+		classified := seth.ClassifyError(tt.err)
+		require.ErrorIs(t, classified, tt.expected, "incorrect classification of: %s", tt.err.Error())
+		require.Equal(t, tt.err.Error(), classified.Error(), "classification shouldn't change error message")
+		require.Equal(t, classified, seth.ClassifyError(classified), "classified error shouldn't be wrapped again")
+	}
+
+	unknown := errors.New("something else")
+	require.Equal(t, unknown, seth.ClassifyError(unknown), "unknown errors should be returned as they are")
+	require.NoError(t, seth.ClassifyError(nil), "nil should stay nil")
+
+	c := &seth.Client{Cfg: &seth.Config{}}
+	_, err := c.Decode(nil, errors.New("insufficient funds for gas * price + value"))
+	require.ErrorIs(t, err, seth.ErrInsufficientFunds, "Decode should classify errors")
+	require.True(t, seth.IsKeySpecificErr(err), "insufficient funds should be key-specific")
+}
+
+// blobService is gasHungryService that remembers sent transactions, reports blob gas in receipts and has blobs in headers
+type blobService struct {
+	*gasHungryService
+	sent          []*types.Transaction
+	excessBlobGas uint64
+	blobGasUsed   uint64
+}
+
+func (s *blobService) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	s.sent = append(s.sent, tx)
+	return s.gasHungryService.SendRawTransaction(raw)
+}
+
+func (s *blobService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	receipt := s.gasHungryService.GetTransactionReceipt(txHash)
+	if receipt == nil {
+		return nil
+	}
+	withBlobs := *receipt
+	withBlobs.BlobGasUsed = params.BlobTxBlobGasPerBlob
+	withBlobs.BlobGasPrice = big.NewInt(3)
+	return &withBlobs
+}
+
+func (s *blobService) GetBlockByNumber(_ string, _ bool) *types.Header {
+	return &types.Header{
+		Number:        big.NewInt(1),
+		Difficulty:    big.NewInt(0),
+		BaseFee:       big.NewInt(1),
+		ExcessBlobGas: &s.excessBlobGas,
+		BlobGasUsed:   &s.blobGasUsed,
+	}
+}
+
+func TestAPIBlobTransactions(t *testing.T) {
+	service := &blobService{
+		gasHungryService: &gasHungryService{estimate: 50_000, receipts: make(map[common.Hash]*types.Receipt)},
+		excessBlobGas:    10 * params.BlobTxBlobGasPerBlob,
+		blobGasUsed:      6 * params.BlobTxBlobGasPerBlob,
+	}
+	rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": service})
+
+	cfg := test_utils.NewMockRPCClientBuilder(rpcURL).
+		WithLegacyGasPrice(1_000_000_000).
+		WithGasBumping(0, 0, nil).
+		WithBlobTransactions(false, 5).
+		Config()
+
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"work","inputs":[],"outputs":[],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	c := test_utils.NewMockRPCClient(t, cfg)
+	contract := bind.NewBoundContract(common.HexToAddress("0x7000000000000000000000000000000000000007"), contractABI, c.Client, c.Client, c.Client)
+
+	sidecar, err := seth.NewBlobSidecar([]byte("first blob"), []byte("second blob"))
+	require.NoError(t, err, "failed to create sidecar")
+	require.Len(t, sidecar.BlobHashes(), 2, "each data chunk should have its own blob")
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := c.Decode(contract.Transact(c.NewTXOpts(seth.WithBlobs(sidecar)), "work"))
+		require.ErrorContains(t, err, seth.ErrBlobTxDisabled, "blob transaction shouldn't be sent unless enabled")
+		require.Empty(t, service.sent, "nothing should have been sent")
+	})
+
+	c.Cfg.Network.BlobTransactions = true
+
+	t.Run("with blobs", func(t *testing.T) {
+		decoded, err := c.Decode(contract.Transact(c.NewTXOpts(seth.WithBlobs(sidecar)), "work"))
+		require.NoError(t, err, "failed to send blob transaction")
+		require.Equal(t, uint8(types.BlobTxType), decoded.Transaction.Type(), "transaction should be a blob transaction")
+		require.NotNil(t, service.sent[len(service.sent)-1].BlobTxSidecar(), "blobs should have been sent to the node")
+		require.NotNil(t, decoded.Blob, "blob data should have been decoded")
+		require.Equal(t, sidecar.BlobHashes(), decoded.Blob.BlobHashes, "incorrect blob hashes")
+		require.Equal(t, big.NewInt(5), decoded.Blob.BlobFeeCap, "fallback blob fee cap should be used without estimations")
+		require.Equal(t, uint64(params.BlobTxBlobGasPerBlob), decoded.Blob.BlobGasUsed, "blob gas used should have been taken from receipt")
+		require.Equal(t, big.NewInt(3*params.BlobTxBlobGasPerBlob), decoded.Blob.BlobFee, "incorrect blob fee")
+		require.Equal(t, big.NewInt(1_000_000_000), decoded.Transaction.GasFeeCap(), "legacy gas price should be used as fee cap")
+	})
+
+	t.Run("with blob hashes and fee cap", func(t *testing.T) {
+		hash := common.HexToHash("0x01" + strings.Repeat("00", 31))
+		decoded, err := c.Decode(contract.Transact(c.NewTXOpts(seth.WithBlobHashes(hash), seth.WithBlobFeeCap(big.NewInt(7))), "work"))
+		require.NoError(t, err, "failed to send blob transaction")
+		require.Equal(t, []common.Hash{hash}, decoded.Transaction.BlobHashes(), "incorrect blob hashes")
+		require.Equal(t, big.NewInt(7), decoded.Transaction.BlobGasFeeCap(), "blob fee cap from options should be used")
+	})
+
+	t.Run("regular transactions are unaffected", func(t *testing.T) {
+		decoded, err := c.Decode(contract.Transact(c.NewTXOpts(), "work"))
+		require.NoError(t, err, "failed to send transaction")
+		require.Equal(t, uint8(types.LegacyTxType), decoded.Transaction.Type(), "transaction without blobs should stay legacy")
+		require.Nil(t, decoded.Blob, "transaction without blobs shouldn't have blob data")
+	})
+
+	t.Run("fee cap estimation", func(t *testing.T) {
+		estimated, err := c.EstimateBlobFeeCap(context.Background())
+		require.NoError(t, err, "failed to estimate blob fee cap")
+		nextFee := eip4844.CalcBlobFee(eip4844.CalcExcessBlobGas(service.excessBlobGas, service.blobGasUsed))
+		require.Equal(t, new(big.Int).Mul(nextFee, big.NewInt(seth.BlobFeeCapMultiplier)), estimated, "incorrect blob fee cap")
+	})
+
+	t.Run("contract creation", func(t *testing.T) {
+		_, _, _, err := bind.DeployContract(c.NewTXOpts(seth.WithBlobs(sidecar)), abi.ABI{}, []byte{0x00}, c.Client)
+		require.ErrorContains(t, err, seth.ErrBlobTxNoRecipient, "blob transaction can't create contracts")
+	})
+
+	_, err = seth.BlobFromData(make([]byte, seth.BlobDataCapacity+1))
+	require.ErrorContains(t, err, seth.ErrBlobTooLarge, "data larger than blob should be rejected")
+}
+
+// newHeadsService mines a block every 20ms, announces it with newHeads subscription and includes transaction in block 3
+type newHeadsService struct {
+	mu    sync.Mutex
+	block uint64
+}
+
+func (s *newHeadsService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, _ := rpc.NotifierFromContext(ctx)
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(20 * time.Millisecond)
+			s.mu.Lock()
+			s.block++
+			header := &types.Header{Number: new(big.Int).SetUint64(s.block), Difficulty: big.NewInt(0)}
+			s.mu.Unlock()
+			_ = notifier.Notify(sub.ID, header)
+		}
+	}()
+	return sub, nil
+}
+
+func (s *newHeadsService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.block < 3 {
+		return nil
+	}
+	return &types.Receipt{TxHash: txHash, BlockNumber: big.NewInt(3), Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{}}
+}
+
+func (s *newHeadsService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.block)
+}
+
+func TestAPITxConfirmationStrategy(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	to := common.HexToAddress("0x68B1D87F95878fE05B998F19b66F4baba5De1aed")
+	tx, err := types.SignNewTx(pk, types.LatestSignerForChainID(big.NewInt(1337)), &types.LegacyTx{To: &to, Value: big.NewInt(1), Gas: 21_000, GasPrice: big.NewInt(1)})
+	require.NoError(t, err, "failed to sign transaction")
+
+	newClient := func(rpcClient *rpc.Client, pollDelay time.Duration) *seth.Client {
+		return &seth.Client{
+			Cfg: seth.NewClientBuilder().
+				WithTxConfirmationStrategy(seth.TxConfirmationStrategy_Subscription).
+				WithTransactionTimeout(5 * time.Second).
+				WithReceiptPollFn(func(_ int, _ time.Duration) time.Duration {
+					return pollDelay
+				}).
+				Config(),
+			Client:  ethclient.NewClient(rpcClient),
+			ChainID: 1337,
+		}
+	}
+
+	t.Run("subscription", func(t *testing.T) {
+		service := &newHeadsService{}
+		server := test_utils.NewMockRPCServer(t, map[string]interface{}{"eth": service})
+
+		// receipt would be polled only once, if WaitMined didn't wait for new blocks
+		c := newClient(rpc.DialInProc(server), time.Hour)
+		receipt, timing, err := c.WaitMinedWithTiming(context.Background(), seth.L, c.Client, tx)
+		require.NoError(t, err, "transaction should be mined once new blocks arrive")
+		require.Equal(t, big.NewInt(3), receipt.BlockNumber, "incorrect inclusion block")
+		require.LessOrEqual(t, timing.Polls, 4, "receipt should be checked only once per block")
+	})
+
+	t.Run("falls back to polling over HTTP", func(t *testing.T) {
+		service := &newHeadsService{}
+		rpcURL := test_utils.NewMockRPC(t, map[string]interface{}{"eth": &httpHeadsService{service}})
+		rpcClient, err := rpc.Dial(rpcURL)
+		require.NoError(t, err, "failed to dial fake node")
+
+		c := newClient(rpcClient, time.Millisecond)
+		receipt, err := c.WaitMined(context.Background(), seth.L, c.Client, tx)
+		require.NoError(t, err, "transaction should be mined when polling")
+		require.Equal(t, big.NewInt(3), receipt.BlockNumber, "incorrect inclusion block")
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		cfg := seth.NewClientBuilder().WithTxConfirmationStrategy("push").Config()
+		require.ErrorContains(t, seth.ValidateConfig(cfg), "tx_confirmation_strategy must be one of", "unknown strategy should be rejected")
+	})
+}
+
+// httpHeadsService is newHeadsService, whose blocks are mined each time receipt is polled, since there are no subscriptions over HTTP
+type httpHeadsService struct {
+	*newHeadsService
+}
+
+func (s *httpHeadsService) GetTransactionReceipt(txHash common.Hash) *types.Receipt {
+	s.mu.Lock()
+	s.block++
+	s.mu.Unlock()
+	return s.newHeadsService.GetTransactionReceipt(txHash)
+}
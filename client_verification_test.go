@@ -0,0 +1,193 @@
+package seth_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/smartcontractkit/seth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIInclusionProofs(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err, "should have generated key")
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+
+	txs := make(types.Transactions, 0)
+	receipts := make(types.Receipts, 0)
+	for i := 0; i < 20; i++ {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1337),
+			Nonce:     uint64(i),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(10),
+			Gas:       21_000,
+			To:        &common.Address{},
+			Value:     big.NewInt(int64(i)),
+		})
+		require.NoError(t, err, "should have signed transaction")
+		txs = append(txs, tx)
+		receipts = append(receipts, &types.Receipt{Type: types.DynamicFeeTxType, Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(21_000 * (i + 1)), Logs: []*types.Log{}})
+	}
+
+	t.Run("transactions proof", func(t *testing.T) {
+		proof, err := seth.NewTransactionsProof(txs, 7)
+		require.NoError(t, err, "should have built proof")
+		require.Equal(t, types.DeriveSha(txs, trie.NewStackTrie(nil)), proof.Root, "proof root should match transactions root")
+		require.NoError(t, proof.Verify(), "proof should be valid")
+
+		encoded, err := txs[7].MarshalBinary()
+		require.NoError(t, err, "should have encoded transaction")
+		require.Equal(t, encoded, proof.Value, "proven value should be encoded transaction")
+
+		proof.Value = []byte{0x01}
+		require.Error(t, proof.Verify(), "proof of different value should be invalid")
+	})
+
+	t.Run("receipts proof", func(t *testing.T) {
+		proof, err := seth.NewReceiptsProof(receipts, 0)
+		require.NoError(t, err, "should have built proof")
+		require.Equal(t, types.DeriveSha(receipts, trie.NewStackTrie(nil)), proof.Root, "proof root should match receipts root")
+		require.NoError(t, proof.Verify(), "proof should be valid")
+
+		proof.Root = common.HexToHash("0x01")
+		require.Error(t, proof.Verify(), "proof under different root should be invalid")
+
+		_, err = seth.NewReceiptsProof(receipts, len(receipts))
+		require.Error(t, err, "should have rejected out of range index")
+	})
+
+	t.Run("account proof", func(t *testing.T) {
+		address := common.HexToAddress("0x1000000000000000000000000000000000000001")
+		missing := common.HexToAddress("0x2000000000000000000000000000000000000002")
+		slot := common.HexToHash("0x01")
+
+		storageTrie := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+		slotValue, err := rlp.EncodeToBytes(big.NewInt(42).Bytes())
+		require.NoError(t, err, "should have encoded storage value")
+		require.NoError(t, storageTrie.Update(crypto.Keccak256(slot.Bytes()), slotValue), "should have updated storage trie")
+
+		account := types.StateAccount{Nonce: 3, Balance: big.NewInt(1000), Root: storageTrie.Hash(), CodeHash: types.EmptyCodeHash.Bytes()}
+		accountValue, err := rlp.EncodeToBytes(&account)
+		require.NoError(t, err, "should have encoded account")
+		stateTrie := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+		require.NoError(t, stateTrie.Update(crypto.Keccak256(address.Bytes()), accountValue), "should have updated state trie")
+
+		prove := func(tr *trie.Trie, key []byte) []string {
+			db := memorydb.New()
+			require.NoError(t, tr.Prove(key, db), "should have proven key")
+			nodes := make([]string, 0)
+			it := db.NewIterator(nil, nil)
+			defer it.Release()
+			for it.Next() {
+				nodes = append(nodes, hexutil.Encode(it.Value()))
+			}
+			return nodes
+		}
+
+		result := &gethclient.AccountResult{
+			Address:      address,
+			AccountProof: prove(stateTrie, crypto.Keccak256(address.Bytes())),
+			Balance:      big.NewInt(1000),
+			CodeHash:     types.EmptyCodeHash,
+			Nonce:        3,
+			StorageHash:  storageTrie.Hash(),
+			StorageProof: []gethclient.StorageResult{
+				{Key: slot.Hex(), Value: big.NewInt(42), Proof: prove(storageTrie, crypto.Keccak256(slot.Bytes()))},
+				{Key: common.HexToHash("0x02").Hex(), Value: big.NewInt(0), Proof: prove(storageTrie, crypto.Keccak256(common.HexToHash("0x02").Bytes()))},
+			},
+		}
+		require.NoError(t, seth.VerifyAccountProof(stateTrie.Hash(), result), "account proof should be valid")
+
+		result.Balance = big.NewInt(1001)
+		err = seth.VerifyAccountProof(stateTrie.Hash(), result)
+		require.Error(t, err, "proof of different balance should be invalid")
+		require.Contains(t, err.Error(), seth.ErrInvalidProof, "should have returned invalid proof error")
+
+		result.Balance = big.NewInt(1000)
+		result.StorageProof[0].Value = big.NewInt(43)
+		require.Error(t, seth.VerifyAccountProof(stateTrie.Hash(), result), "proof of different storage value should be invalid")
+
+		absent := &gethclient.AccountResult{
+			Address:      missing,
+			AccountProof: prove(stateTrie, crypto.Keccak256(missing.Bytes())),
+			Balance:      big.NewInt(0),
+		}
+		require.NoError(t, seth.VerifyAccountProof(stateTrie.Hash(), absent), "absence proof should be valid")
+	})
+}
+
+func TestAPIVerifyContract(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[{"type":"constructor","inputs":[{"name":"initial","type":"uint256"}],"stateMutability":"nonpayable"}]`))
+	require.NoError(t, err, "failed to parse ABI")
+	cs, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	cs.AddABI("Counter", contractABI)
+
+	var mu sync.Mutex
+	var submitted url.Values
+	statusChecks := 0
+	explorer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm(), "failed to parse form")
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Form.Get("action") {
+		case "verifysourcecode":
+			submitted = r.PostForm
+			_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":"guid-1"}`))
+		case "checkverifystatus":
+			statusChecks++
+			if statusChecks == 1 {
+				_, _ = w.Write([]byte(`{"status":"0","message":"NOTOK","result":"Pending in queue"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"status":"1","message":"OK","result":"Pass - Verified"}`))
+		}
+	}))
+	defer explorer.Close()
+
+	cfg := seth.NewClientBuilder().WithRpcUrl("http://localhost:8545").Config()
+	cfg.Network.ExplorerAPIURL = explorer.URL
+	cfg.Network.ExplorerAPIKey = "secret"
+	c := &seth.Client{Cfg: cfg, ChainID: 11155111, ContractStore: cs}
+
+	address := common.HexToAddress("0x7000000000000000000000000000000000000007")
+	input := seth.ContractVerificationInput{
+		StandardJSONInput: json.RawMessage(`{"language":"Solidity","sources":{"contracts/Counter.sol":{"content":"contract Counter {}"}}}`),
+		CompilerVersion:   "v0.8.19+commit.7dd6d404",
+		ConstructorArgs:   []interface{}{big.NewInt(1)},
+		PollInterval:      time.Millisecond,
+	}
+	require.NoError(t, c.VerifyContract(context.Background(), address, "Counter", input), "contract should be verified")
+
+	require.Equal(t, "contracts/Counter.sol:Counter", submitted.Get("contractname"), "source file should be found in compiler input")
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000000000001", submitted.Get("constructorArguements"), "constructor arguments should be ABI-encoded")
+	require.Equal(t, "secret", submitted.Get("apikey"), "API key should be sent")
+	require.Equal(t, "11155111", submitted.Get("chainid"), "chain ID should be sent")
+	require.Equal(t, 2, statusChecks, "status should be polled until verification passes")
+
+	err = c.VerifyContract(context.Background(), address, "Missing", input)
+	require.ErrorContains(t, err, seth.ErrContractSourceNotFound, "missing source should be reported")
+
+	c.Cfg.Network.ExplorerAPIURL = ""
+	err = c.VerifyContract(context.Background(), address, "Counter", input)
+	require.ErrorContains(t, err, seth.ErrExplorerAPIConfig, "explorer API URL should be required")
+}
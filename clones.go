@@ -0,0 +1,93 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// eip1167Prefix and eip1167Suffix surround implementation address in runtime code of EIP-1167 minimal proxy
+	eip1167Prefix = common.FromHex("0x363d3d373d3d3d363d73")
+	eip1167Suffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// EIP1167Implementation returns implementation address embedded in runtime code of EIP-1167 minimal proxy (clone), false
+// if code isn't a minimal proxy
+func EIP1167Implementation(code []byte) (common.Address, bool) {
+	if len(code) != len(eip1167Prefix)+common.AddressLength+len(eip1167Suffix) ||
+		!bytes.HasPrefix(code, eip1167Prefix) || !bytes.HasSuffix(code, eip1167Suffix) {
+		return common.Address{}, false
+	}
+
+	return common.BytesToAddress(code[len(eip1167Prefix) : len(eip1167Prefix)+common.AddressLength]), true
+}
+
+// GetCloneImplementation returns address of implementation, if contract at given address is a known EIP-1167 clone
+func (c ContractMap) GetCloneImplementation(addr string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	implementation := c.clones[strings.ToLower(addr)]
+	return implementation, implementation != ""
+}
+
+// cloneChecked returns true if it was already checked whether contract at given address is a clone
+func (c ContractMap) cloneChecked(addr string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.clones[strings.ToLower(addr)]
+	return ok
+}
+
+// setCloneImplementation records implementation of the clone at given address, empty implementation means it's not a clone
+func (c ContractMap) setCloneImplementation(addr, implementation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clones[strings.ToLower(addr)] = strings.ToLower(implementation)
+}
+
+// resolveClone checks if contract at an address missing from the contract map is an EIP-1167 clone. If it is and its
+// implementation is known, clone is mapped to implementation's name, so that calls to it are decoded with implementation's
+// ABI. Clone is labeled "CloneOf(<implementation>)". Each address is checked only once.
+func resolveClone(rpcClient *rpc.Client, timeout time.Duration, contractMap ContractMap, address string) {
+	if rpcClient == nil || contractMap.clones == nil || address == UNKNOWN || contractMap.IsKnownAddress(address) || contractMap.cloneChecked(address) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var code hexutil.Bytes
+	if err := rpcClient.CallContext(ctx, &code, "eth_getCode", common.HexToAddress(address), "latest"); err != nil {
+		L.Debug().
+			Err(err).
+			Str("Address", address).
+			Msg("Failed to get contract code. Won't check if it's a minimal proxy")
+		return
+	}
+
+	implementation, ok := EIP1167Implementation(code)
+	if !ok {
+		contractMap.setCloneImplementation(address, "")
+		return
+	}
+	contractMap.setCloneImplementation(address, implementation.Hex())
+
+	implementationName := implementation.Hex()
+	if contractMap.IsKnownAddress(implementation.Hex()) {
+		contractMap.AddContract(address, contractMap.GetContractName(implementation.Hex()))
+		implementationName = contractMap.GetContractLabel(implementation.Hex())
+	}
+	contractMap.SetContractLabel(address, fmt.Sprintf("CloneOf(%s)", implementationName))
+
+	L.Debug().
+		Str("Address", address).
+		Str("Implementation", implementation.Hex()).
+		Str("Name", implementationName).
+		Msg("Found EIP-1167 minimal proxy")
+}
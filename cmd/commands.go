@@ -0,0 +1,348 @@
+package seth
+
+import (
+	"context"
+	verr "errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/seth"
+)
+
+const (
+	ErrNoKeyFile        = "no keyfile specified, use --keyfile flag. Ex.: 'seth --keyfile keyfile.toml -n Geth keys fund -a 5'"
+	ErrNoRootPrivateKey = "no root private key specified, use -k flag. Ex.: 'seth -k ac09...ff80 -n Geth keys fund -a 5'"
+)
+
+// GasOptions are options of the gas command
+type GasOptions struct {
+	// Blocks is the number of most recent blocks used for estimation
+	Blocks        uint64
+	TipPercentile float64
+	// Priorities to probe, if set suggested [[networks]] TOML fragment is printed instead of fee history stats
+	Priorities []string
+}
+
+// NewClient creates a client for commands that only read data from the network (stats and gas). Nothing is read from env
+// vars. If root private key isn't set, a random one is used, since no transactions are sent.
+func NewClient(opts seth.ConfigOptions) (*seth.Client, error) {
+	opts, err := withRootPrivateKey(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := seth.ReadConfigWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return seth.NewClientWithConfig(cfg)
+}
+
+// InitConfig generates seth.toml for network available under given RPC URL. Existing file is overwritten only if force is true.
+func InitConfig(url, networkName, outputPath string, force bool) error {
+	if url == "" {
+		return fmt.Errorf("no RPC URL specified, use --url flag. Ex.: 'seth init --url http://localhost:8545'")
+	}
+	return initConfig(url, networkName, outputPath, force)
+}
+
+// Stats prints block stats. Negative start means last N blocks (then end is ignored), otherwise both start and end are required.
+func Stats(c *seth.Client, start, end int64) error {
+	if start == 0 {
+		return fmt.Errorf("at least start block should be defined, ex.: -s -10")
+	}
+	if start > 0 && end == 0 {
+		return fmt.Errorf("invalid block params. Last N blocks example: -s -10, interval example: -s 10 -e 20")
+	}
+	cs, err := seth.NewBlockStats(c)
+	if err != nil {
+		return err
+	}
+	return cs.Stats(big.NewInt(start), big.NewInt(end))
+}
+
+// Gas prints fee history stats and fallback prices for TOML config or, if priorities are set, suggested [[networks]] TOML fragment
+func Gas(c *seth.Client, opts GasOptions) error {
+	if len(opts.Priorities) > 0 {
+		fragment, err := suggestNetworkConfig(c, opts.Blocks, opts.Priorities)
+		if err != nil {
+			return err
+		}
+		fmt.Print(fragment)
+		return nil
+	}
+
+	ge := seth.NewGasEstimator(c)
+	stats, err := ge.Stats(opts.Blocks, opts.TipPercentile)
+	if err != nil {
+		return err
+	}
+	seth.L.Info().
+		Interface("Max", stats.GasPrice.Max).
+		Interface("99", stats.GasPrice.Perc99).
+		Interface("75", stats.GasPrice.Perc75).
+		Interface("50", stats.GasPrice.Perc50).
+		Interface("25", stats.GasPrice.Perc25).
+		Msg("Base fee (Wei)")
+	seth.L.Info().
+		Interface("Max", stats.TipCap.Max).
+		Interface("99", stats.TipCap.Perc99).
+		Interface("75", stats.TipCap.Perc75).
+		Interface("50", stats.TipCap.Perc50).
+		Interface("25", stats.TipCap.Perc25).
+		Msg("Priority fee (Wei)")
+	seth.L.Info().
+		Interface("GasPrice", stats.SuggestedGasPrice).
+		Msg("Suggested gas price now")
+	seth.L.Info().
+		Interface("GasTipCap", stats.SuggestedGasTipCap).
+		Msg("Suggested gas tip cap now")
+
+	type asTomlCfg struct {
+		GasPrice int64 `toml:"gas_price"`
+		GasTip   int64 `toml:"gas_tip_cap"`
+		GasFee   int64 `toml:"gas_fee_cap"`
+	}
+
+	tomlCfg := asTomlCfg{
+		GasPrice: stats.SuggestedGasPrice.Int64(),
+		GasTip:   stats.SuggestedGasTipCap.Int64(),
+		GasFee:   stats.SuggestedGasPrice.Int64() + stats.SuggestedGasTipCap.Int64(),
+	}
+
+	marshalled, err := toml.Marshal(tomlCfg)
+	if err != nil {
+		return err
+	}
+
+	seth.L.Info().Msgf("Fallback prices for TOML config:\n%s", string(marshalled))
+
+	return nil
+}
+
+// Trace decodes and traces given transactions with all calls, printing possible revert reasons. Nothing is read from env vars.
+// If root private key isn't set, a random one is used, since no transactions are sent.
+func Trace(opts seth.ConfigOptions, txHashes []string) error {
+	client, err := newTracingClient(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, txHash := range txHashes {
+		seth.L.Info().Msgf("Tracing transaction %s", txHash)
+		ctx, cancel := context.WithTimeout(context.Background(), client.Cfg.Network.TxnTimeout.Duration())
+		tx, _, err := client.Client.TransactionByHash(ctx, common.HexToHash(txHash))
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "failed to get transaction %s", txHash)
+		}
+
+		_, err = client.Decode(tx, nil)
+		if err != nil {
+			seth.L.Info().Msgf("Possible revert reason: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ReplayReverted re-simulates or re-traces all transactions from reverted transactions file and prints which of them
+// still revert
+func ReplayReverted(opts seth.ConfigOptions, file, mode string, atLatest bool) error {
+	client, err := newTracingClient(opts)
+	if err != nil {
+		return err
+	}
+
+	results, err := client.ReplayRevertedTransactionsFile(file, mode, atLatest)
+	if err != nil {
+		return err
+	}
+
+	var stillReverting, failed int
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Printf("%s\tfailed to replay: %s\n", r.Transaction.Hash, r.Err)
+		case r.StillReverts:
+			stillReverting++
+			fmt.Printf("%s\treverts: %s\n", r.Transaction.Hash, r.Error)
+		default:
+			fmt.Printf("%s\tdoesn't revert anymore (originally: %s)\n", r.Transaction.Hash, r.Transaction.Error)
+		}
+	}
+	fmt.Printf("Replayed %d transactions: %d still revert, %d don't, %d couldn't be replayed\n", len(results), stillReverting, len(results)-stillReverting-failed, failed)
+
+	return nil
+}
+
+// Fund adds given number of new keys to the keyfile (it's created if it doesn't exist yet) and funds all keyfile keys that
+// don't have any funds yet from the root key. Each key gets amount wei or, if amount is nil, root key's balance is split
+// evenly. New keys are saved before they are funded, so that funds are never sent to a key that could be lost, and keyfile
+// balances are updated even if some transfers failed. Nothing is read from env vars.
+func Fund(opts seth.ConfigOptions, keys int, amount *big.Int) (*seth.FundingReport, error) {
+	if err := validateKeyFileOptions(opts); err != nil {
+		return nil, err
+	}
+
+	kf := &seth.KeyFile{}
+	if _, err := os.Stat(opts.KeyFilePath); err == nil {
+		kf, err = seth.ReadKeyFile(opts.KeyFilePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < keys; i++ {
+		addr, pk, err := seth.NewAddress()
+		if err != nil {
+			return nil, err
+		}
+		kf.Keys = append(kf.Keys, &seth.KeyData{PrivateKey: pk, Address: addr})
+	}
+	if err := kf.Write(opts.KeyFilePath); err != nil {
+		return nil, err
+	}
+
+	client, err := newKeyFileClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	addresses := make([]common.Address, 0, len(kf.Keys))
+	for _, key := range kf.Keys {
+		addresses = append(addresses, common.HexToAddress(key.Address))
+	}
+	report, fundErr := seth.NewFundingWorkflow(client, nil).SplitFunds(context.Background(), addresses, amount)
+	if report != nil {
+		report.Log()
+	}
+
+	return report, verr.Join(fundErr, updateKeyFile(client, kf, opts.KeyFilePath))
+}
+
+// ReturnFunds returns funds from all keyfile keys to toAddr (or to the root key if it's empty) and updates keyfile balances.
+// Keys stay in the keyfile, so that they can be funded again. Nothing is read from env vars.
+func ReturnFunds(opts seth.ConfigOptions, toAddr string) (*seth.FundingReport, error) {
+	if err := validateKeyFileOptions(opts); err != nil {
+		return nil, err
+	}
+	kf, err := seth.ReadKeyFile(opts.KeyFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newKeyFileClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	report, returnErr := seth.NewFundingWorkflow(client, nil).ReturnFunds(context.Background(), toAddr)
+	if report != nil {
+		report.Log()
+	}
+
+	return report, verr.Join(returnErr, updateKeyFile(client, kf, opts.KeyFilePath))
+}
+
+// UpdateKeyfile updates balances of all keyfile keys with their current balances. Nothing is read from env vars.
+func UpdateKeyfile(opts seth.ConfigOptions) error {
+	if err := validateKeyFileOptions(opts); err != nil {
+		return err
+	}
+	kf, err := seth.ReadKeyFile(opts.KeyFilePath)
+	if err != nil {
+		return err
+	}
+
+	client, err := newKeyFileClient(opts)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	return updateKeyFile(client, kf, opts.KeyFilePath)
+}
+
+// updateKeyFile reads current balances of keyfile keys and writes them to the keyfile
+func updateKeyFile(client *seth.Client, kf *seth.KeyFile, path string) error {
+	for _, key := range kf.Keys {
+		balance, err := client.BalanceOf(common.HexToAddress(key.Address), seth.BlockTag_Latest)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get balance of %s", key.Address)
+		}
+		key.Funds = balance.String()
+	}
+	return kf.Write(path)
+}
+
+// validateKeyFileOptions checks that keyfile and root key, which funds are sent from and to, are set
+func validateKeyFileOptions(opts seth.ConfigOptions) error {
+	if opts.KeyFilePath == "" {
+		return errors.New(ErrNoKeyFile)
+	}
+	if opts.RootPrivateKey == "" {
+		return errors.New(ErrNoRootPrivateKey)
+	}
+	return nil
+}
+
+// newKeyFileClient creates a client that uses the root key and keyfile keys only, since funds are sent from and to them
+func newKeyFileClient(opts seth.ConfigOptions) (*seth.Client, error) {
+	opts.PrivateKeys = nil
+
+	cfg, err := seth.ReadConfigWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	zero := int64(0)
+	cfg.EphemeralAddrs = &zero
+
+	return seth.NewClientWithConfig(cfg)
+}
+
+// newTracingClient creates a client that traces all transactions and doesn't send any of its own
+func newTracingClient(opts seth.ConfigOptions) (*seth.Client, error) {
+	_ = os.Setenv(seth.LogLevelEnvVar, "debug")
+
+	opts, err := withRootPrivateKey(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := seth.ReadConfigWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	zero := int64(0)
+	cfg.EphemeralAddrs = &zero
+	cfg.TracingLevel = seth.TracingLevel_All
+	// health check sends a transaction, which is not what we want when tracing
+	cfg.CheckRpcHealthOnStart = false
+
+	return seth.NewClientWithConfig(cfg)
+}
+
+// withRootPrivateKey sets random root private key, if none is set
+func withRootPrivateKey(opts seth.ConfigOptions) (seth.ConfigOptions, error) {
+	if opts.RootPrivateKey != "" {
+		return opts, nil
+	}
+	_, pk, err := seth.NewAddress()
+	if err != nil {
+		return opts, err
+	}
+	opts.RootPrivateKey = pk
+
+	return opts, nil
+}
@@ -0,0 +1,182 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/seth"
+)
+
+const (
+	// number of most recent blocks used to calculate average block gas limit and usage
+	gasLimitSampleBlocks = 20
+	// suggested gas limit is rounded down to a multiple of this value
+	gasLimitRounding = 100_000
+)
+
+var allPriorities = []string{seth.Priority_Slow, seth.Priority_Standard, seth.Priority_Fast, seth.Priority_Degen}
+
+var networkFragmentTemplate = template.Must(template.New("network").Parse(`# suggested by 'seth gas' on {{ .GeneratedAt }}, gas prices estimated from last {{ .EstimationBlocks }} blocks
+# last {{ .SampledBlocks }} blocks: average gas limit {{ .AvgBlockGasLimit }}, average gas used {{ .AvgBlockGasUsed }} ({{ printf "%.1f" .AvgBlockFullness }}% full)
+{{- range .Suggestions }}
+# {{ printf "%-8s" .Priority }} gas_price = {{ .GasPrice }}{{ if $.EIP1559 }}, gas_fee_cap = {{ .GasFeeCap }}, gas_tip_cap = {{ .GasTipCap }}{{ end }}
+{{- end }}
+[[networks]]
+name = "{{ .Name }}"
+eip_1559_dynamic_fees = {{ .EIP1559 }}
+
+gas_price_estimation_enabled = true
+gas_price_estimation_blocks = {{ .EstimationBlocks }}
+gas_price_estimation_tx_priority = "{{ .Selected.Priority }}"
+
+# half of average block gas limit, so that transactions can still be included in busy blocks
+gas_limit = {{ .GasLimit }}
+transfer_gas_fee = {{ .TransferGasFee }}
+
+# fallback values used when gas price estimation fails
+gas_price = {{ .Selected.GasPrice }}
+{{- if .EIP1559 }}
+gas_fee_cap = {{ .Selected.GasFeeCap }}
+gas_tip_cap = {{ .Selected.GasTipCap }}
+{{- end }}
+`))
+
+type prioritySuggestion struct {
+	Priority  string
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+type networkFragmentValues struct {
+	GeneratedAt      string
+	Name             string
+	EIP1559          bool
+	EstimationBlocks uint64
+	TransferGasFee   int64
+	SampledBlocks    int
+	AvgBlockGasLimit uint64
+	AvgBlockGasUsed  uint64
+	AvgBlockFullness float64
+	GasLimit         uint64
+	Suggestions      []prioritySuggestion
+	Selected         prioritySuggestion
+}
+
+// parsePriorities parses comma-separated list of priorities, "all" means all of them
+func parsePriorities(raw string) ([]string, error) {
+	if strings.TrimSpace(strings.ToLower(raw)) == "all" {
+		return allPriorities, nil
+	}
+
+	priorities := make([]string, 0)
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(strings.ToLower(p))
+		if p == "" {
+			continue
+		}
+		switch p {
+		case seth.Priority_Slow, seth.Priority_Standard, seth.Priority_Fast, seth.Priority_Degen:
+			priorities = append(priorities, p)
+		default:
+			return nil, fmt.Errorf("priority must be one of: %s or all", strings.Join(allPriorities, ", "))
+		}
+	}
+	if len(priorities) == 0 {
+		return nil, errors.New("no priorities given")
+	}
+
+	return priorities, nil
+}
+
+// suggestNetworkConfig probes gas prices for each priority and returns a [[networks]] TOML fragment with fallback prices for
+// the first priority and gas limit derived from recent blocks
+func suggestNetworkConfig(c *seth.Client, blocks uint64, priorities []string) (string, error) {
+	if blocks > 0 {
+		c.Cfg.Network.GasPriceEstimationBlocks = blocks
+	}
+
+	values := networkFragmentValues{
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+		Name:             c.Cfg.Network.Name,
+		EIP1559:          c.Cfg.Network.EIP1559DynamicFees,
+		EstimationBlocks: c.Cfg.Network.GasPriceEstimationBlocks,
+		TransferGasFee:   c.Cfg.Network.TransferGasFee,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	for _, priority := range priorities {
+		suggestion := prioritySuggestion{Priority: priority}
+		var err error
+		suggestion.GasPrice, err = c.GetSuggestedLegacyFees(ctx, priority)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get suggested gas price for %s priority", priority)
+		}
+		if values.EIP1559 {
+			suggestion.GasFeeCap, suggestion.GasTipCap, err = c.GetSuggestedEIP1559Fees(ctx, priority)
+			if err != nil {
+				return "", errors.Wrapf(err, "failed to get suggested EIP-1559 fees for %s priority", priority)
+			}
+		}
+		values.Suggestions = append(values.Suggestions, suggestion)
+	}
+	values.Selected = values.Suggestions[0]
+
+	if err := fillBlockGasAverages(ctx, c, &values); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := networkFragmentTemplate.Execute(&buf, values); err != nil {
+		return "", errors.Wrap(err, "failed to render network config")
+	}
+
+	return buf.String(), nil
+}
+
+// fillBlockGasAverages calculates average gas limit and usage of most recent blocks and suggests gas limit for transactions
+func fillBlockGasAverages(ctx context.Context, c *seth.Client, values *networkFragmentValues) error {
+	latest, err := c.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get latest block header")
+	}
+
+	gasLimitSum, gasUsedSum := new(big.Int), new(big.Int)
+	header := latest
+	for i := 0; i < gasLimitSampleBlocks; i++ {
+		gasLimitSum.Add(gasLimitSum, new(big.Int).SetUint64(header.GasLimit))
+		gasUsedSum.Add(gasUsedSum, new(big.Int).SetUint64(header.GasUsed))
+		values.SampledBlocks++
+
+		if header.Number.Sign() == 0 || i == gasLimitSampleBlocks-1 {
+			break
+		}
+		header, err = c.Client.HeaderByNumber(ctx, new(big.Int).Sub(header.Number, big.NewInt(1)))
+		if err != nil {
+			return errors.Wrap(err, "failed to get block header")
+		}
+	}
+
+	samples := big.NewInt(int64(values.SampledBlocks))
+	values.AvgBlockGasLimit = new(big.Int).Div(gasLimitSum, samples).Uint64()
+	values.AvgBlockGasUsed = new(big.Int).Div(gasUsedSum, samples).Uint64()
+	if values.AvgBlockGasLimit > 0 {
+		values.AvgBlockFullness = float64(values.AvgBlockGasUsed) / float64(values.AvgBlockGasLimit) * 100
+	}
+
+	values.GasLimit = values.AvgBlockGasLimit / 2
+	if values.GasLimit > gasLimitRounding {
+		values.GasLimit -= values.GasLimit % gasLimitRounding
+	}
+
+	return nil
+}
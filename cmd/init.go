@@ -0,0 +1,154 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/pkg/errors"
+
+	"github.com/smartcontractkit/seth"
+)
+
+const (
+	ErrConfigFileExists = "config file already exists, use --force to overwrite it"
+
+	// number of blocks used to suggest fallback gas prices, same as in example seth.toml
+	initGasEstimationBlocks = 100
+	// tip percentile used to suggest fallback gas tip cap
+	initTipPercentile = 50
+)
+
+var initConfigTemplate = template.Must(template.New("seth.toml").Parse(`# generated by 'seth init' for chain {{ .ChainID }} on {{ .GeneratedAt }}
+# private key is read from {{ .RootKeyEnvVar }} env var, don't store it here
+
+abi_dir = "contracts/abi"
+bin_dir = "contracts/bin"
+
+# possible values: none, reverted, all
+tracing_level = "reverted"
+trace_outputs = ["console"]
+
+artifacts_dir = "artifacts"
+
+ephemeral_addresses_number = 0
+root_key_funds_buffer = 10
+
+[nonce_manager]
+key_sync_rate_limit_per_sec = 10
+key_sync_timeout = "20s"
+key_sync_retry_delay = "1s"
+key_sync_retries = 10
+
+[[networks]]
+name = "{{ .Name }}"
+urls_secret = ["{{ .URL }}"]
+dial_timeout = "1m"
+transaction_timeout = "5m"
+eip_1559_dynamic_fees = {{ .EIP1559 }}
+
+gas_price_estimation_enabled = true
+gas_price_estimation_blocks = {{ .EstimationBlocks }}
+gas_price_estimation_tx_priority = "standard"
+
+transfer_gas_fee = 21_000
+
+# fallback values used when gas price estimation fails, suggested by the node when config was generated
+gas_price = {{ .GasPrice }}
+{{- if .EIP1559 }}
+gas_fee_cap = {{ .GasFeeCap }}
+gas_tip_cap = {{ .GasTipCap }}
+{{- end }}
+`))
+
+type initConfigValues struct {
+	GeneratedAt      string
+	RootKeyEnvVar    string
+	Name             string
+	URL              string
+	ChainID          string
+	EIP1559          bool
+	EstimationBlocks uint64
+	GasPrice         *big.Int
+	GasFeeCap        *big.Int
+	GasTipCap        *big.Int
+}
+
+// initConfig dials the node, detects network capabilities and writes a seth.toml with a network section for it
+func initConfig(url, networkName, outputPath string, force bool) error {
+	if !force {
+		if _, err := os.Stat(outputPath); err == nil {
+			return fmt.Errorf("%s: %s", ErrConfigFileExists, outputPath)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), seth.DefaultDialTimeout)
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to '%s' due to: %w", url, err)
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain ID")
+	}
+
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get latest block header")
+	}
+
+	values := initConfigValues{
+		GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+		RootKeyEnvVar:    seth.ROOT_PRIVATE_KEY_ENV_VAR,
+		Name:             networkName,
+		URL:              url,
+		ChainID:          chainID.String(),
+		EIP1559:          header.BaseFee != nil,
+		EstimationBlocks: initGasEstimationBlocks,
+	}
+
+	if values.EIP1559 {
+		// estimator only needs the RPC client
+		stats, err := seth.NewGasEstimator(&seth.Client{Client: client}).Stats(initGasEstimationBlocks, initTipPercentile)
+		if err != nil {
+			seth.L.Warn().Err(err).Msg("Failed to get fee history. Assuming network doesn't support EIP-1559")
+			values.EIP1559 = false
+		} else {
+			values.GasPrice = stats.SuggestedGasPrice
+			values.GasTipCap = stats.SuggestedGasTipCap
+			values.GasFeeCap = new(big.Int).Add(stats.SuggestedGasPrice, stats.SuggestedGasTipCap)
+		}
+	}
+
+	if !values.EIP1559 {
+		values.GasPrice, err = client.SuggestGasPrice(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to get suggested gas price")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := initConfigTemplate.Execute(&buf, values); err != nil {
+		return errors.Wrap(err, "failed to render config")
+	}
+
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0600); err != nil {
+		return errors.Wrapf(err, "failed to write config to %s", outputPath)
+	}
+
+	seth.L.Info().
+		Str("Chain ID", values.ChainID).
+		Bool("EIP-1559", values.EIP1559).
+		Str("File", outputPath).
+		Msg("Config generated")
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package seth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+
+	"github.com/smartcontractkit/seth"
+)
+
+const (
+	ErrPluginCommand    = "plugin command must have a name and an action"
+	ErrDuplicateCommand = "command with this name or alias is already registered"
+)
+
+// Plugin is an extra subcommand registered by downstream repos, so that they can ship one CLI reusing config, network and
+// key bootstrap of the seth binary
+type Plugin struct {
+	Command *cli.Command
+	// NeedsClient makes the CLI create a client (available as C) before the command is run. Client uses root private key
+	// if it's set, otherwise a random one.
+	NeedsClient bool
+}
+
+var (
+	pluginsMu sync.Mutex
+	plugins   []Plugin
+
+	// Options are config options built from global flags (or env vars) before any command other than init is run
+	Options seth.ConfigOptions
+)
+
+// builtinCommands are names and aliases of commands defined in RunCLI, plugins can't override them
+var builtinCommands = []string{"init", "stats", "s", "gas", "g", "trace", "t", "reverted", "r", "keys", "k", "help", "h"}
+
+// RegisterCommand registers extra CLI subcommand. It has to be called before RunCLI, usually from main or init function
+// of downstream binary:
+//
+//	func main() {
+//		sethcmd.MustRegisterCommand(&cli.Command{Name: "fund", Action: fundAction}, true)
+//		if err := sethcmd.RunCLI(os.Args); err != nil {
+//			panic(err)
+//		}
+//	}
+//
+// Command's name and aliases can't collide with built-in or already registered commands.
+func RegisterCommand(command *cli.Command, needsClient bool) error {
+	if command == nil || command.Name == "" || (command.Action == nil && len(command.Subcommands) == 0) {
+		return errors.New(ErrPluginCommand)
+	}
+
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	taken := make(map[string]bool)
+	for _, name := range builtinCommands {
+		taken[name] = true
+	}
+	for _, plugin := range plugins {
+		for _, name := range plugin.Command.Names() {
+			taken[name] = true
+		}
+	}
+	for _, name := range command.Names() {
+		if taken[name] {
+			return fmt.Errorf("%s: %s", ErrDuplicateCommand, name)
+		}
+	}
+
+	plugins = append(plugins, Plugin{Command: command, NeedsClient: needsClient})
+
+	return nil
+}
+
+// MustRegisterCommand works like RegisterCommand, but panics on error
+func MustRegisterCommand(command *cli.Command, needsClient bool) {
+	if err := RegisterCommand(command, needsClient); err != nil {
+		panic(err)
+	}
+}
+
+// RegisteredPlugins returns all registered plugins in registration order
+func RegisteredPlugins() []Plugin {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	return append([]Plugin{}, plugins...)
+}
+
+// pluginNeedsClient returns true if given command name or alias belongs to a plugin that needs a client
+func pluginNeedsClient(name string) bool {
+	for _, plugin := range RegisteredPlugins() {
+		if !plugin.NeedsClient {
+			continue
+		}
+		for _, pluginName := range plugin.Command.Names() {
+			if pluginName == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func pluginCommands() []*cli.Command {
+	registered := RegisteredPlugins()
+	commands := make([]*cli.Command, 0, len(registered))
+	for _, plugin := range registered {
+		commands = append(commands, plugin.Command)
+	}
+
+	return commands
+}
@@ -1,16 +1,9 @@
 package seth
 
 import (
-	"context"
 	"fmt"
-	"github.com/ethereum/go-ethereum/rpc"
 	"math/big"
-	"os"
-	"path/filepath"
 
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/pelletier/go-toml/v2"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
@@ -18,67 +11,74 @@ import (
 )
 
 const (
-	ErrNoNetwork = "no network specified, use -n flag. Ex.: 'seth -n Geth stats' or -u and -c flags. Ex.: 'seth -u http://localhost:8545 -c 1337 stats'"
+	ErrNoNetwork = "no network specified, use -n flag. Ex.: 'seth -n Geth stats' or -u flag. Ex.: 'seth -u http://localhost:8545 stats'"
 )
 
 var C *seth.Client
 
 func RunCLI(args []string) error {
+	var opts seth.ConfigOptions
 	app := &cli.App{
 		Name:      "seth",
 		Version:   "v1.0.0",
 		Usage:     "seth CLI",
 		UsageText: `utility to create and control Ethereum keys and give you more debug info about chains`,
 		Flags: []cli.Flag{
-			&cli.StringFlag{Name: "networkName", Aliases: []string{"n"}},
-			&cli.StringFlag{Name: "url", Aliases: []string{"u"}},
+			&cli.StringFlag{Name: "networkName", Aliases: []string{"n"}, EnvVars: []string{seth.NETWORK_ENV_VAR}, Usage: "name of the network from TOML config"},
+			&cli.StringFlag{Name: "url", Aliases: []string{"u"}, EnvVars: []string{seth.URL_ENV_VAR}, Usage: "RPC URL, used with default network from TOML config"},
+			&cli.StringFlag{Name: "config", Aliases: []string{"c"}, EnvVars: []string{seth.CONFIG_FILE_ENV_VAR}, Usage: "path to TOML config"},
+			&cli.StringFlag{Name: "rootPrivateKey", Aliases: []string{"k"}, EnvVars: []string{seth.ROOT_PRIVATE_KEY_ENV_VAR}, Usage: "root private key, a random one is used if not set"},
+			&cli.StringFlag{Name: "keyfile", Aliases: []string{"kf"}, EnvVars: []string{seth.KEYFILE_PATH_ENV_VAR}, Usage: "path to TOML keyfile, its keys are used after the root key"},
 		},
 		Before: func(cCtx *cli.Context) error {
-			networkName := cCtx.String("networkName")
-			url := cCtx.String("url")
-			if networkName == "" && url == "" {
-				return errors.New(ErrNoNetwork)
+			// init creates the config, so there's no network to select yet
+			if cCtx.Args().First() == "init" {
+				return nil
 			}
-			if networkName != "" {
-				_ = os.Setenv(seth.NETWORK_ENV_VAR, networkName)
+			opts = seth.ConfigOptions{
+				Path:           cCtx.String("config"),
+				NetworkName:    cCtx.String("networkName"),
+				URL:            cCtx.String("url"),
+				RootPrivateKey: cCtx.String("rootPrivateKey"),
+				KeyFilePath:    cCtx.String("keyfile"),
 			}
-			if url != "" {
-				_ = os.Setenv(seth.URL_ENV_VAR, url)
+			Options = opts
+			if opts.NetworkName == "" && opts.URL == "" {
+				return errors.New(ErrNoNetwork)
 			}
-			if cCtx.Args().Len() > 0 && cCtx.Args().First() != "trace" {
+			switch cCtx.Args().First() {
+			case "gas", "g", "stats", "s":
 				var err error
-				switch cCtx.Args().First() {
-				case "gas", "stats":
-					var cfg *seth.Config
-					var pk string
-					_, pk, err = seth.NewAddress()
-					if err != nil {
-						return err
-					}
-
-					err = os.Setenv(seth.ROOT_PRIVATE_KEY_ENV_VAR, pk)
-					if err != nil {
-						return err
-					}
-
-					cfg, err = seth.ReadConfig()
-					if err != nil {
-						return err
-					}
-					C, err = seth.NewClientWithConfig(cfg)
+				C, err = NewClient(opts)
+				if err != nil {
+					return err
+				}
+			default:
+				if pluginNeedsClient(cCtx.Args().First()) {
+					var err error
+					C, err = NewClient(opts)
 					if err != nil {
 						return err
 					}
-				case "trace":
-					return nil
-				}
-				if err != nil {
-					return err
 				}
 			}
 			return nil
 		},
-		Commands: []*cli.Command{
+		Commands: append([]*cli.Command{
+			{
+				Name:        "init",
+				HelpName:    "init",
+				Description: "generate seth.toml for network available under given RPC URL",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "url", Aliases: []string{"u"}, Usage: "RPC URL of the network"},
+					&cli.StringFlag{Name: "name", Value: seth.DefaultNetworkName, Usage: "name of the network in generated config"},
+					&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Value: "seth.toml", Usage: "path of generated config"},
+					&cli.BoolFlag{Name: "force", Aliases: []string{"f"}, Usage: "overwrite existing config"},
+				},
+				Action: func(cCtx *cli.Context) error {
+					return InitConfig(cCtx.String("url"), cCtx.String("name"), cCtx.String("output"), cCtx.Bool("force"))
+				},
+			},
 			{
 				Name:        "stats",
 				HelpName:    "stats",
@@ -89,19 +89,7 @@ func RunCLI(args []string) error {
 					&cli.Int64Flag{Name: "end_block", Aliases: []string{"e"}},
 				},
 				Action: func(cCtx *cli.Context) error {
-					start := cCtx.Int64("start_block")
-					end := cCtx.Int64("end_block")
-					if start == 0 {
-						return fmt.Errorf("at least start block should be defined, ex.: -s -10")
-					}
-					if start > 0 && end == 0 {
-						return fmt.Errorf("invalid block params. Last N blocks example: -s -10, interval example: -s 10 -e 20")
-					}
-					cs, err := seth.NewBlockStats(C)
-					if err != nil {
-						return err
-					}
-					return cs.Stats(big.NewInt(start), big.NewInt(end))
+					return Stats(C, cCtx.Int64("start_block"), cCtx.Int64("end_block"))
 				},
 			},
 			{
@@ -112,56 +100,21 @@ func RunCLI(args []string) error {
 				Flags: []cli.Flag{
 					&cli.Int64Flag{Name: "blocks", Aliases: []string{"b"}},
 					&cli.Float64Flag{Name: "tipPercentile", Aliases: []string{"tp"}},
+					&cli.StringFlag{Name: "priorities", Aliases: []string{"p"}, Usage: "comma-separated priorities (slow, standard, fast, degen or all) to probe; prints suggested [[networks]] TOML fragment using the first one"},
 				},
 				Action: func(cCtx *cli.Context) error {
-					ge := seth.NewGasEstimator(C)
-					blocks := cCtx.Uint64("blocks")
-					tipPerc := cCtx.Float64("tipPercentile")
-					stats, err := ge.Stats(blocks, tipPerc)
-					if err != nil {
-						return err
+					gasOpts := GasOptions{
+						Blocks:        cCtx.Uint64("blocks"),
+						TipPercentile: cCtx.Float64("tipPercentile"),
 					}
-					seth.L.Info().
-						Interface("Max", stats.GasPrice.Max).
-						Interface("99", stats.GasPrice.Perc99).
-						Interface("75", stats.GasPrice.Perc75).
-						Interface("50", stats.GasPrice.Perc50).
-						Interface("25", stats.GasPrice.Perc25).
-						Msg("Base fee (Wei)")
-					seth.L.Info().
-						Interface("Max", stats.TipCap.Max).
-						Interface("99", stats.TipCap.Perc99).
-						Interface("75", stats.TipCap.Perc75).
-						Interface("50", stats.TipCap.Perc50).
-						Interface("25", stats.TipCap.Perc25).
-						Msg("Priority fee (Wei)")
-					seth.L.Info().
-						Interface("GasPrice", stats.SuggestedGasPrice).
-						Msg("Suggested gas price now")
-					seth.L.Info().
-						Interface("GasTipCap", stats.SuggestedGasTipCap).
-						Msg("Suggested gas tip cap now")
-
-					type asTomlCfg struct {
-						GasPrice int64 `toml:"gas_price"`
-						GasTip   int64 `toml:"gas_tip_cap"`
-						GasFee   int64 `toml:"gas_fee_cap"`
-					}
-
-					tomlCfg := asTomlCfg{
-						GasPrice: stats.SuggestedGasPrice.Int64(),
-						GasTip:   stats.SuggestedGasTipCap.Int64(),
-						GasFee:   stats.SuggestedGasPrice.Int64() + stats.SuggestedGasTipCap.Int64(),
-					}
-
-					marshalled, err := toml.Marshal(tomlCfg)
-					if err != nil {
-						return err
+					if rawPriorities := cCtx.String("priorities"); rawPriorities != "" {
+						priorities, err := parsePriorities(rawPriorities)
+						if err != nil {
+							return err
+						}
+						gasOpts.Priorities = priorities
 					}
-
-					seth.L.Info().Msgf("Fallback prices for TOML config:\n%s", string(marshalled))
-
-					return err
+					return Gas(C, gasOpts)
 				},
 			},
 			{
@@ -187,120 +140,89 @@ func RunCLI(args []string) error {
 
 					var transactions []string
 					if file != "" {
-						err := seth.OpenJsonFileAsStruct(file, &transactions)
+						reverted, err := seth.ReadRevertedTransactions(file)
 						if err != nil {
 							return err
 						}
-					} else {
-						transactions = append(transactions, txHash)
-					}
-
-					_ = os.Setenv(seth.LogLevelEnvVar, "debug")
-
-					cfgPath := os.Getenv(seth.CONFIG_FILE_ENV_VAR)
-					if cfgPath == "" {
-						return errors.New(seth.ErrEmptyConfigPath)
-					}
-					var cfg *seth.Config
-					d, err := os.ReadFile(cfgPath)
-					if err != nil {
-						return errors.Wrap(err, seth.ErrReadSethConfig)
-					}
-					err = toml.Unmarshal(d, &cfg)
-					if err != nil {
-						return errors.Wrap(err, seth.ErrUnmarshalSethConfig)
-					}
-					absPath, err := filepath.Abs(cfgPath)
-					if err != nil {
-						return err
-					}
-					cfg.ConfigDir = filepath.Dir(absPath)
-
-					snet := os.Getenv(seth.NETWORK_ENV_VAR)
-					if snet != "" {
-						for _, n := range cfg.Networks {
-							if n.Name == snet {
-								cfg.Network = n
-								break
-							}
-						}
-						if cfg.Network == nil {
-							return fmt.Errorf("network %s not defined in the TOML file", snet)
+						for _, tx := range reverted {
+							transactions = append(transactions, tx.Hash)
 						}
 					} else {
-						url := os.Getenv(seth.URL_ENV_VAR)
-
-						if url == "" {
-							return fmt.Errorf("network not selected, set %s=... or %s=..., check TOML config for available networks", seth.NETWORK_ENV_VAR, seth.URL_ENV_VAR)
-						}
-
-						//look for default network
-						for _, n := range cfg.Networks {
-							if n.Name == seth.DefaultNetworkName {
-								cfg.Network = n
-								cfg.Network.Name = snet
-								cfg.Network.URLs = []string{url}
-								break
-							}
-						}
-
-						if cfg.Network == nil {
-							return fmt.Errorf("default network not defined in the TOML file")
-						}
-
-						if cfg.Network.DialTimeout == nil {
-							cfg.Network.DialTimeout = &seth.Duration{D: seth.DefaultDialTimeout}
-						}
-						ctx, cancel := context.WithTimeout(context.Background(), cfg.Network.DialTimeout.Duration())
-						defer cancel()
-						rpcClient, err := rpc.DialOptions(ctx, cfg.FirstNetworkURL(), rpc.WithHeaders(cfg.RPCHeaders))
-						if err != nil {
-							return fmt.Errorf("failed to connect RPC client to '%s' due to: %w", cfg.FirstNetworkURL(), err)
-						}
-						client := ethclient.NewClient(rpcClient)
-						defer client.Close()
-
-						if cfg.Network.Name == seth.DefaultNetworkName {
-							chainId, err := client.ChainID(context.Background())
-							if err != nil {
-								return errors.Wrap(err, "failed to get chain ID")
-							}
-							cfg.Network.ChainID = chainId.String()
-						}
-					}
-
-					zero := int64(0)
-					cfg.EphemeralAddrs = &zero
-					cfg.TracingLevel = seth.TracingLevel_All
-					if cfg.Network.DialTimeout == nil {
-						cfg.Network.DialTimeout = &seth.Duration{D: seth.DefaultDialTimeout}
-					}
-
-					client, err := seth.NewClientWithConfig(cfg)
-					if err != nil {
-						return err
+						transactions = append(transactions, txHash)
 					}
 
 					seth.L.Info().Msgf("Tracing transactions from %s file", file)
 
-					for _, txHash := range transactions {
-						seth.L.Info().Msgf("Tracing transaction %s", txHash)
-						ctx, cancel := context.WithTimeout(context.Background(), cfg.Network.TxnTimeout.Duration())
-						tx, _, err := client.Client.TransactionByHash(ctx, common.HexToHash(txHash))
-						cancel()
-						if err != nil {
-							return errors.Wrapf(err, "failed to get transaction %s", txHash)
-						}
-
-						_, err = client.Decode(tx, nil)
-						if err != nil {
-							seth.L.Info().Msgf("Possible revert reason: %s", err.Error())
-						}
-					}
-					return err
+					return Trace(opts, transactions)
 				},
 			},
-		},
+			{
+				Name:        "reverted",
+				HelpName:    "reverted",
+				Aliases:     []string{"r"},
+				Description: "re-simulate or re-trace transactions from reverted transactions file",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "file", Aliases: []string{"f"}, Required: true, Usage: "reverted transactions file"},
+					&cli.StringFlag{Name: "mode", Aliases: []string{"m"}, Value: seth.ReplayMode_Simulate, Usage: "simulate (eth_call) or trace (decode and trace again)"},
+					&cli.BoolFlag{Name: "latest", Aliases: []string{"l"}, Usage: "simulate at the latest block instead of the block transaction was mined in"},
+				},
+				Action: func(cCtx *cli.Context) error {
+					return ReplayReverted(opts, cCtx.String("file"), cCtx.String("mode"), cCtx.Bool("latest"))
+				},
+			},
+			{
+				Name:        "keys",
+				HelpName:    "keys",
+				Aliases:     []string{"k"},
+				Description: "create and fund keys stored in keyfile and return their funds",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "fund",
+						HelpName:    "fund",
+						Aliases:     []string{"f"},
+						Description: "add new keys to keyfile and fund all keyfile keys that don't have any funds from the root key",
+						Flags: []cli.Flag{
+							&cli.IntFlag{Name: "addresses", Aliases: []string{"a"}, Usage: "number of new keys to add to keyfile"},
+							&cli.StringFlag{Name: "amount", Aliases: []string{"v"}, Usage: "amount of wei sent to each key, root key's balance is split evenly if not set"},
+						},
+						Action: func(cCtx *cli.Context) error {
+							var amount *big.Int
+							if rawAmount := cCtx.String("amount"); rawAmount != "" {
+								var ok bool
+								amount, ok = new(big.Int).SetString(rawAmount, 10)
+								if !ok {
+									return fmt.Errorf("invalid amount: %s", rawAmount)
+								}
+							}
+							_, err := Fund(opts, cCtx.Int("addresses"), amount)
+							return err
+						},
+					},
+					{
+						Name:        "return",
+						HelpName:    "return",
+						Aliases:     []string{"r"},
+						Description: "return funds from all keyfile keys",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "address", Aliases: []string{"a"}, Usage: "address funds are returned to, root key by default"},
+						},
+						Action: func(cCtx *cli.Context) error {
+							_, err := ReturnFunds(opts, cCtx.String("address"))
+							return err
+						},
+					},
+					{
+						Name:        "update",
+						HelpName:    "update",
+						Aliases:     []string{"u"},
+						Description: "update balances of keyfile keys",
+						Action: func(cCtx *cli.Context) error {
+							return UpdateKeyfile(opts)
+						},
+					},
+				},
+			},
+		}, pluginCommands()...),
 	}
 	return app.Run(args)
 }
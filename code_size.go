@@ -0,0 +1,129 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MaxContractCodeSize is the maximum size of deployed contract code as defined in EIP-170
+	MaxContractCodeSize = 24_576
+	// MaxInitCodeSize is the maximum size of contract creation code as defined in EIP-3860
+	MaxInitCodeSize = 2 * MaxContractCodeSize
+
+	ErrInitCodeSizeExceeded     = "init code size exceeds the limit"
+	ErrContractCodeSizeExceeded = "contract code size exceeds the limit"
+	ErrPackConstructorArgs      = "failed to pack constructor arguments"
+)
+
+// CodeSizeEstimation contains sizes (in bytes) of contract creation code (bytecode with constructor arguments) and deployed code.
+// RuntimeCodeSize is -1 if it couldn't be estimated.
+type CodeSizeEstimation struct {
+	InitCodeSize        int
+	RuntimeCodeSize     int
+	MaxInitCodeSize     int64
+	MaxContractCodeSize int64
+}
+
+// maxCodeSizes returns code size limits for current network. Negative value means that the check is disabled.
+func (c *Config) maxCodeSizes() (maxCodeSize int64, maxInitCodeSize int64) {
+	maxCodeSize = c.Network.MaxCodeSize
+	if maxCodeSize == 0 {
+		maxCodeSize = MaxContractCodeSize
+	}
+	maxInitCodeSize = c.Network.MaxInitCodeSize
+	if maxInitCodeSize == 0 {
+		maxInitCodeSize = MaxInitCodeSize
+	}
+
+	return
+}
+
+// CheckInitCodeSize returns a descriptive error if init code is larger than the limit (EIP-3860). Negative limit disables the check.
+func CheckInitCodeSize(initCode []byte, limit int64) error {
+	if limit < 0 || int64(len(initCode)) <= limit {
+		return nil
+	}
+
+	return fmt.Errorf("%s: init code is %d bytes, which is %d bytes over the limit of %d bytes (EIP-3860)", ErrInitCodeSizeExceeded, len(initCode), int64(len(initCode))-limit, limit)
+}
+
+// CheckContractCodeSize returns a descriptive error if deployed code is larger than the limit (EIP-170). Negative limit disables the check.
+func CheckContractCodeSize(code []byte, limit int64) error {
+	if limit < 0 || int64(len(code)) <= limit {
+		return nil
+	}
+
+	return fmt.Errorf("%s: contract code is %d bytes, which is %d bytes over the limit of %d bytes (EIP-170)", ErrContractCodeSizeExceeded, len(code), int64(len(code))-limit, limit)
+}
+
+// EstimateDeploymentCodeSize calculates the size of contract creation code and estimates the size of deployed code by simulating
+// the deployment with eth_call. If the simulation fails runtime code size is set to -1.
+func (m *Client) EstimateDeploymentCodeSize(from common.Address, contractAbi abi.ABI, bytecode []byte, params ...interface{}) (CodeSizeEstimation, error) {
+	maxCodeSize, maxInitCodeSize := m.Cfg.maxCodeSizes()
+	estimation := CodeSizeEstimation{
+		RuntimeCodeSize:     -1,
+		MaxContractCodeSize: maxCodeSize,
+		MaxInitCodeSize:     maxInitCodeSize,
+	}
+
+	packedArgs, err := contractAbi.Pack("", params...)
+	if err != nil {
+		return estimation, errors.Wrap(err, ErrPackConstructorArgs)
+	}
+	initCode := append(append([]byte{}, bytecode...), packedArgs...)
+	estimation.InitCodeSize = len(initCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	code, err := m.Client.CallContract(ctx, ethereum.CallMsg{From: from, Data: initCode}, nil)
+	if err != nil {
+		L.Debug().Err(err).Msg("Failed to simulate contract deployment. Runtime code size is unknown")
+		return estimation, nil
+	}
+	estimation.RuntimeCodeSize = len(code)
+
+	return estimation, nil
+}
+
+// CheckDeploymentCodeSize verifies that contract creation code and deployed code do not exceed limits defined in EIP-3860 and EIP-170
+// (or limits set in network config). It returns a descriptive error with the number of bytes over the limit.
+func (m *Client) CheckDeploymentCodeSize(from common.Address, contractAbi abi.ABI, bytecode []byte, params ...interface{}) error {
+	maxCodeSize, maxInitCodeSize := m.Cfg.maxCodeSizes()
+	if maxCodeSize < 0 && maxInitCodeSize < 0 {
+		return nil
+	}
+
+	packedArgs, err := contractAbi.Pack("", params...)
+	if err != nil {
+		return errors.Wrap(err, ErrPackConstructorArgs)
+	}
+	initCode := append(append([]byte{}, bytecode...), packedArgs...)
+	if err := CheckInitCodeSize(initCode, maxInitCodeSize); err != nil {
+		return err
+	}
+
+	if maxCodeSize < 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	code, err := m.Client.CallContract(ctx, ethereum.CallMsg{From: from, Data: initCode}, nil)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "max code size exceeded") {
+			// deployed code is part of init code, so it can't be bigger than that
+			return fmt.Errorf("%s: contract code exceeds the limit of %d bytes (EIP-170) by at most %d bytes (init code is %d bytes)", ErrContractCodeSizeExceeded, maxCodeSize, int64(len(initCode))-maxCodeSize, len(initCode))
+		}
+		L.Debug().Err(err).Msg("Failed to simulate contract deployment. Skipping contract code size check")
+		return nil
+	}
+
+	return CheckContractCodeSize(code, maxCodeSize)
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -28,6 +30,14 @@ const (
 	ROOT_PRIVATE_KEY_ENV_VAR = "SETH_ROOT_PRIVATE_KEY"
 	NETWORK_ENV_VAR          = "SETH_NETWORK"
 	URL_ENV_VAR              = "SETH_URL"
+	// KEYFILE_PATH_ENV_VAR is a path to the keyfile, its keys are added after the root key
+	KEYFILE_PATH_ENV_VAR = "SETH_KEYFILE_PATH"
+	// PRIVATE_KEYS_ENV_VAR is a comma-separated list of private keys added after the root key and keyfile keys
+	PRIVATE_KEYS_ENV_VAR = "SETH_PRIVATE_KEYS"
+	// ADDRESS_LABELS_ENV_VAR is a comma-separated list of labels of keys, in key number order (root key first)
+	ADDRESS_LABELS_ENV_VAR = "SETH_ADDRESS_LABELS"
+	// PROFILE_ENV_VAR selects predefined config profile ("ci" or "local"), it takes precedence over profile from TOML
+	PROFILE_ENV_VAR = "SETH_PROFILE"
 
 	DefaultNetworkName = "Default"
 	DefaultDialTimeout = 1 * time.Minute
@@ -42,28 +52,63 @@ type Config struct {
 	// internal fields
 	revertedTransactionsFile string
 	ephemeral                bool
-	RPCHeaders               http.Header
+	stickySessionID          string
+	stickySessionJar         http.CookieJar
+	rpcPoolOnce              sync.Once
+	rpcPool                  *rpcPool
+	// keyAliases maps numbers of duplicated keys to numbers of their first occurrence
+	keyAliases map[int]int
+	RPCHeaders http.Header
 
 	// external fields
 	// ArtifactDir is the directory where all artifacts generated by seth are stored (e.g. transaction traces)
-	ArtifactsDir                  string            `toml:"artifacts_dir"`
-	EphemeralAddrs                *int64            `toml:"ephemeral_addresses_number"`
-	RootKeyFundsBuffer            *int64            `toml:"root_key_funds_buffer"`
-	ABIDir                        string            `toml:"abi_dir"`
-	BINDir                        string            `toml:"bin_dir"`
-	ContractMapFile               string            `toml:"contract_map_file"`
-	SaveDeployedContractsMap      bool              `toml:"save_deployed_contracts_map"`
-	Network                       *Network          `toml:"network"`
-	Networks                      []*Network        `toml:"networks"`
-	NonceManager                  *NonceManagerCfg  `toml:"nonce_manager"`
-	TracingLevel                  string            `toml:"tracing_level"`
-	TraceOutputs                  []string          `toml:"trace_outputs"`
-	PendingNonceProtectionEnabled bool              `toml:"pending_nonce_protection_enabled"`
-	ConfigDir                     string            `toml:"abs_path"`
-	ExperimentsEnabled            []string          `toml:"experiments_enabled"`
-	CheckRpcHealthOnStart         bool              `toml:"check_rpc_health_on_start"`
-	BlockStatsConfig              *BlockStatsConfig `toml:"block_stats"`
-	GasBump                       *GasBumpConfig    `toml:"gas_bump"`
+	ArtifactsDir                  string                    `toml:"artifacts_dir"`
+	EphemeralAddrs                *int64                    `toml:"ephemeral_addresses_number"`
+	RootKeyFundsBuffer            *int64                    `toml:"root_key_funds_buffer"`
+	ABIDir                        string                    `toml:"abi_dir"`
+	BINDir                        string                    `toml:"bin_dir"`
+	InterfaceABIDir               string                    `toml:"interface_abi_dir"`
+	ContractMapFile               string                    `toml:"contract_map_file"`
+	ContractMapFiles              []string                  `toml:"contract_map_files"`
+	SaveDeployedContractsMap      bool                      `toml:"save_deployed_contracts_map"`
+	Network                       *Network                  `toml:"network"`
+	Networks                      []*Network                `toml:"networks"`
+	NonceManager                  *NonceManagerCfg          `toml:"nonce_manager"`
+	TracingLevel                  string                    `toml:"tracing_level"`
+	TraceOutputs                  []string                  `toml:"trace_outputs"`
+	TracingFailurePolicy          string                    `toml:"tracing_failure_policy"`
+	TracingFailuresBeforeDisable  uint                      `toml:"tracing_failures_before_disable"`
+	TracingWorkers                int                       `toml:"tracing_workers"`
+	StrictTracing                 bool                      `toml:"strict_tracing"`
+	StrictKeys                    bool                      `toml:"strict_keys"`
+	TraceCanonicalJSON            bool                      `toml:"trace_canonical_json"`
+	TraceRetention                *TraceRetentionConfig     `toml:"trace_retention"`
+	TraceStorage                  bool                      `toml:"trace_storage"`
+	TraceFallbackDecoding         bool                      `toml:"trace_fallback_decoding"`
+	TraceBackend                  string                    `toml:"trace_backend"`
+	PendingNonceProtectionEnabled bool                      `toml:"pending_nonce_protection_enabled"`
+	ConfigDir                     string                    `toml:"abs_path"`
+	ExperimentsEnabled            []string                  `toml:"experiments_enabled"`
+	CheckRpcHealthOnStart         bool                      `toml:"check_rpc_health_on_start"`
+	BlockStatsConfig              *BlockStatsConfig         `toml:"block_stats"`
+	GasBump                       *GasBumpConfig            `toml:"gas_bump"`
+	KeyRotation                   *KeyRotationConfig        `toml:"key_rotation"`
+	CalldataValidation            *CalldataValidationConfig `toml:"calldata_validation"`
+	Telemetry                     *TelemetryConfig          `toml:"telemetry"`
+	TxJournal                     *TxJournalConfig          `toml:"tx_journal"`
+	ExpectedEvents                *ExpectedEventsConfig     `toml:"expected_events"`
+	Redaction                     *RedactionConfig          `toml:"redaction"`
+	RPCFailover                   *RPCFailoverConfig        `toml:"rpc_failover"`
+	Chaos                         *ChaosConfig              `toml:"chaos"`
+	KeyBalanceCheck               string                    `toml:"key_balance_check"`
+	PrintTxSummary                bool                      `toml:"print_tx_summary"`
+	ConfirmationMetrics           bool                      `toml:"confirmation_metrics"`
+	// Profile is predefined set of values ("ci" or "local") applied on top of the config
+	Profile string `toml:"profile"`
+	// ReceiptPollFn overrides how long WaitMined waits between receipt polls
+	ReceiptPollFn ReceiptPollFn `toml:"-"`
+	// RPCAuthProvider sets Authorization header of each request sent to the RPC node, it takes precedence over network's jwt_secret_file
+	RPCAuthProvider RPCAuthProvider `toml:"-"`
 }
 
 type GasBumpConfig struct {
@@ -81,6 +126,11 @@ func (c *Config) GasBumpRetries() uint {
 	return c.GasBump.Retries
 }
 
+// IsTxJournalEnabled returns true if in-flight transactions should be persisted to a journal file
+func (c *Config) IsTxJournalEnabled() bool {
+	return c.TxJournal != nil && c.TxJournal.File != ""
+}
+
 // HasMaxBumpGasPrice returns true if the max gas price for gas bumping is set
 func (c *Config) HasMaxBumpGasPrice() bool {
 	return c.GasBump != nil && c.GasBump.MaxGasPrice > 0
@@ -91,6 +141,8 @@ type NonceManagerCfg struct {
 	KeySyncTimeout      *Duration `toml:"key_sync_timeout"`
 	KeySyncRetries      uint      `toml:"key_sync_retries"`
 	KeySyncRetryDelay   *Duration `toml:"key_sync_retry_delay"`
+	// HealNonceGaps replaces transactions left pending by previous runs with no-op transactions on start
+	HealNonceGaps bool `toml:"heal_nonce_gaps"`
 }
 
 type Network struct {
@@ -108,20 +160,127 @@ type Network struct {
 	GasPriceEstimationEnabled    bool      `toml:"gas_price_estimation_enabled"`
 	GasPriceEstimationBlocks     uint64    `toml:"gas_price_estimation_blocks"`
 	GasPriceEstimationTxPriority string    `toml:"gas_price_estimation_tx_priority"`
+	MaxCodeSize                  int64     `toml:"max_code_size"`
+	MaxInitCodeSize              int64     `toml:"max_init_code_size"`
+	NativeCurrencySymbol         string    `toml:"native_currency_symbol"`
+	NativeCurrencyDecimals       *uint8    `toml:"native_currency_decimals"`
+	CustomRPCMethods             []string  `toml:"custom_rpc_methods"`
+	BalanceCheckEnabled          bool      `toml:"balance_check_enabled"`
+	ProviderProfile              string    `toml:"provider_profile"`
+	ReceiptPollInterval          *Duration `toml:"receipt_poll_interval"`
+	ReceiptPollJitter            *Duration `toml:"receipt_poll_jitter"`
+	AdaptiveReceiptPolling       bool      `toml:"adaptive_receipt_polling"`
+	TxValidityBlocks             uint64    `toml:"tx_validity_blocks"`
+	JWTSecretFile                string    `toml:"jwt_secret_file"`
+	AutoDetectTxType             bool      `toml:"auto_detect_tx_type"`
+	// ReadConsistency is either empty (no guarantees), "sticky" or "retry", see ReadConsistency_* constants
+	ReadConsistency     string `toml:"read_consistency"`
+	StickySessionHeader string `toml:"sticky_session_header"`
+	// FallbackGasPrices, FallbackGasFeeCaps and FallbackGasTipCaps are per-priority fallback values used instead of
+	// GasPrice, GasFeeCap and GasTipCap when gas estimation fails
+	FallbackGasPrices  map[string]int64 `toml:"fallback_gas_price"`
+	FallbackGasFeeCaps map[string]int64 `toml:"fallback_gas_fee_cap"`
+	FallbackGasTipCaps map[string]int64 `toml:"fallback_gas_tip_cap"`
+	// ExplorerTxURL and ExplorerAddressURL are block explorer link templates with {hash} and {address} placeholders,
+	// if they are empty links are generated only for chains from ChainExplorerURLs
+	ExplorerTxURL      string `toml:"explorer_tx_url"`
+	ExplorerAddressURL string `toml:"explorer_address_url"`
+	// ExplorerAPIURL is the Etherscan-compatible API (e.g. "https://api.etherscan.io/v2/api" or Blockscout's "/api") contracts
+	// are verified with, ExplorerAPIKey can be also set with SETH_EXPLORER_API_KEY env var
+	ExplorerAPIURL string `toml:"explorer_api_url"`
+	ExplorerAPIKey string `toml:"explorer_api_key"`
+	// ExternalActors maps addresses of actors other than Seth's keys (e.g. Chainlink nodes under test) to labels shown in traces
+	ExternalActors map[string]string `toml:"external_actors"`
+	// DeploymentRedeployRetries is how many times contract deployment is sent again (with a fresh nonce), when deployment
+	// transaction disappears from the network without being mined, 0 disables redeploying
+	DeploymentRedeployRetries uint `toml:"deployment_redeploy_retries"`
+	// AutoMineAfter enables mining a block with evm_mine (Anvil, Hardhat), when transaction Seth is waiting for has been
+	// pending for this long, so that tests don't hang on dev nodes with long block times. Nil or 0 disables it.
+	AutoMineAfter *Duration `toml:"auto_mine_after"`
+	// SpendBudget is the amount of native currency (e.g. 0.5 ETH) that client's keys can pay in fees during the session. Once
+	// it's spent, no more transactions are sent and *BudgetExceededError is returned instead. Nil means no limit.
+	SpendBudget *float64 `toml:"spend_budget"`
+	// BlobTransactions enables sending blob (EIP-4844) transactions, created with WithBlobs() or WithBlobHashes() options
+	BlobTransactions bool `toml:"blob_transactions"`
+	// BlobFeeCap is maximum fee per blob gas (in wei) used when gas price estimations are disabled or blob fee estimation fails
+	BlobFeeCap int64 `toml:"blob_fee_cap"`
+	// TxConfirmationStrategy is either empty or "polling" (receipt is polled every receipt poll interval) or "subscription"
+	// (receipt is checked when a new block arrives), see TxConfirmationStrategy_* constants
+	TxConfirmationStrategy string `toml:"tx_confirmation_strategy"`
+	// KeyLabels are human-readable labels of keys in key number order (root key first), shown in traces and reports. Empty
+	// label means that key has no label.
+	KeyLabels []string `toml:"key_labels"`
+	// NodeAccounts are addresses of accounts unlocked in the node (e.g. dev Geth's account). They are added as keys after
+	// private keys (and ephemeral keys) and their transactions are signed by the node.
+	NodeAccounts []string `toml:"node_accounts"`
 
 	// derivative vars
 	ChainID string
 }
 
+// FallbackFees returns gas price, gas fee cap and gas tip cap that should be used for given priority when gas estimation
+// fails. Values that aren't configured for that priority default to GasPrice, GasFeeCap and GasTipCap.
+func (n *Network) FallbackFees(priority string) (gasPrice, gasFeeCap, gasTipCap int64) {
+	gasPrice, gasFeeCap, gasTipCap = n.GasPrice, n.GasFeeCap, n.GasTipCap
+	priority = strings.ToLower(priority)
+	if v, ok := n.FallbackGasPrices[priority]; ok {
+		gasPrice = v
+	}
+	if v, ok := n.FallbackGasFeeCaps[priority]; ok {
+		gasFeeCap = v
+	}
+	if v, ok := n.FallbackGasTipCaps[priority]; ok {
+		gasTipCap = v
+	}
+
+	return
+}
+
 // DefaultClient returns a Client with reasonable default config with the specified RPC URL and private keys. You should pass at least 1 private key.
 // It assumes that network is EIP-1559 compatible (if it's not, the client will later automatically update its configuration to reflect it).
 func DefaultClient(rpcUrl string, privateKeys []string) (*Client, error) {
 	return NewClientBuilder().WithRpcUrl(rpcUrl).WithPrivateKeys(privateKeys).Build()
 }
 
+// ConfigOptions select config file, network, keys and profile. They have the same meaning as "SETH_CONFIG_PATH", "SETH_NETWORK",
+// "SETH_URL", "SETH_ROOT_PRIVATE_KEY", "SETH_PRIVATE_KEYS", "SETH_ADDRESS_LABELS" and "SETH_PROFILE" env vars.
+type ConfigOptions struct {
+	Path           string
+	NetworkName    string
+	URL            string
+	RootPrivateKey string
+	// KeyFilePath is a path to the keyfile, its keys are added to network's keys after the root key
+	KeyFilePath string
+	// PrivateKeys are added to network's keys after the root key and keyfile keys
+	PrivateKeys []string
+	// AddressLabels are labels of network's keys in key number order, they replace key_labels from TOML
+	AddressLabels []string
+	// Profile is applied on top of the config, it replaces profile from TOML
+	Profile string
+}
+
+// ConfigOptionsFromEnv returns config options read from env vars
+func ConfigOptionsFromEnv() ConfigOptions {
+	return ConfigOptions{
+		Path:           os.Getenv(CONFIG_FILE_ENV_VAR),
+		NetworkName:    os.Getenv(NETWORK_ENV_VAR),
+		URL:            os.Getenv(URL_ENV_VAR),
+		RootPrivateKey: os.Getenv(ROOT_PRIVATE_KEY_ENV_VAR),
+		KeyFilePath:    os.Getenv(KEYFILE_PATH_ENV_VAR),
+		PrivateKeys:    nonEmpty(splitEnvList(os.Getenv(PRIVATE_KEYS_ENV_VAR))),
+		AddressLabels:  splitEnvList(os.Getenv(ADDRESS_LABELS_ENV_VAR)),
+		Profile:        os.Getenv(PROFILE_ENV_VAR),
+	}
+}
+
 // ReadConfig reads the TOML config file from location specified by env var "SETH_CONFIG_PATH" and returns a Config struct
 func ReadConfig() (*Config, error) {
-	cfgPath := os.Getenv(CONFIG_FILE_ENV_VAR)
+	return ReadConfigWithOptions(ConfigOptionsFromEnv())
+}
+
+// ReadConfigWithOptions reads the TOML config file and selects network in the same way as ReadConfig, but doesn't read any env vars
+func ReadConfigWithOptions(opts ConfigOptions) (*Config, error) {
+	cfgPath := opts.Path
 	if cfgPath == "" {
 		return nil, errors.New(ErrEmptyConfigPath)
 	}
@@ -139,7 +298,7 @@ func ReadConfig() (*Config, error) {
 		return nil, err
 	}
 	cfg.ConfigDir = filepath.Dir(absPath)
-	snet := os.Getenv(NETWORK_ENV_VAR)
+	snet := opts.NetworkName
 	if snet != "" {
 		for _, n := range cfg.Networks {
 			if n.Name == snet {
@@ -151,7 +310,7 @@ func ReadConfig() (*Config, error) {
 
 	if cfg.Network == nil {
 		L.Debug().Msgf("Network %s not found in TOML, trying to use URL", snet)
-		url := os.Getenv(URL_ENV_VAR)
+		url := opts.URL
 
 		if url == "" {
 			return nil, fmt.Errorf("network not selected, set %s=... or %s=..., check TOML config for available networks", NETWORK_ENV_VAR, URL_ENV_VAR)
@@ -178,15 +337,34 @@ func ReadConfig() (*Config, error) {
 		}
 	}
 
-	rootPrivateKey := os.Getenv(ROOT_PRIVATE_KEY_ENV_VAR)
+	rootPrivateKey := opts.RootPrivateKey
 	if rootPrivateKey == "" {
 		return nil, errors.Errorf(ErrEmptyRootPrivateKey, ROOT_PRIVATE_KEY_ENV_VAR)
 	} else {
 		cfg.Network.PrivateKeys = append(cfg.Network.PrivateKeys, rootPrivateKey)
 	}
+	if opts.KeyFilePath != "" {
+		kf, err := ReadKeyFile(opts.KeyFilePath)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Network.PrivateKeys = append(cfg.Network.PrivateKeys, kf.PrivateKeys()...)
+	}
+	cfg.Network.PrivateKeys = append(cfg.Network.PrivateKeys, opts.PrivateKeys...)
+	if len(opts.AddressLabels) > 0 {
+		cfg.Network.KeyLabels = opts.AddressLabels
+	}
 	if cfg.Network.DialTimeout == nil {
 		cfg.Network.DialTimeout = &Duration{D: DefaultDialTimeout}
 	}
+	if opts.Profile != "" {
+		cfg.Profile = opts.Profile
+	}
+	if cfg.Profile != "" {
+		if err := cfg.ApplyProfile(cfg.Profile); err != nil {
+			return nil, err
+		}
+	}
 	L.Trace().Interface("Config", cfg).Msg("Parsed seth config")
 	return cfg, nil
 }
@@ -196,7 +374,7 @@ func (c *Config) FirstNetworkURL() string {
 	return c.Network.URLs[0]
 }
 
-// ParseKeys parses private keys from the config
+// ParseKeys parses private keys from the config, node accounts are returned after them with nil private keys
 func (c *Config) ParseKeys() ([]common.Address, []*ecdsa.PrivateKey, error) {
 	addresses := make([]common.Address, 0)
 	privKeys := make([]*ecdsa.PrivateKey, 0)
@@ -214,6 +392,15 @@ func (c *Config) ParseKeys() ([]common.Address, []*ecdsa.PrivateKey, error) {
 		addresses = append(addresses, pubKeyAddress)
 		privKeys = append(privKeys, privateKey)
 	}
+	nodeAccounts, err := c.parseNodeAccounts()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, address := range nodeAccounts {
+		// node accounts have no private key, node signs their transactions
+		addresses = append(addresses, address)
+		privKeys = append(privKeys, nil)
+	}
 	return addresses, privKeys, nil
 }
 
@@ -230,6 +417,36 @@ func (c *Config) GenerateContractMapFileName() string {
 	return fmt.Sprintf(ContractMapFilePattern, networkName, now)
 }
 
+// ContractMapFilePaths returns paths of all contract map files that should be loaded: files matching patterns from
+// `contract_map_files` (in the order of patterns) followed by `contract_map_file`, to which new deployments are saved
+func (c *Config) ContractMapFilePaths() ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	for _, pattern := range c.ContractMapFiles {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid contract map file pattern '%s'", pattern)
+		}
+		if len(matches) == 0 {
+			L.Warn().Str("Pattern", pattern).Msg("No contract map files matched the pattern")
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			add(match)
+		}
+	}
+	add(c.ContractMapFile)
+
+	return paths, nil
+}
+
 // ShouldSaveDeployedContractMap returns true if the contract map should be saved (i.e. not a simulated network and functionality is enabled)
 func (c *Config) ShouldSaveDeployedContractMap() bool {
 	return !c.IsSimulatedNetwork() && c.SaveDeployedContractsMap
@@ -284,13 +501,13 @@ func (c *Config) AppendPksToNetwork(pks []string, name string) bool {
 	return false
 }
 
-// GetMaxConcurrency returns the maximum number of concurrent transactions. Root key is excluded from the count.
+// GetMaxConcurrency returns the maximum number of concurrent transactions. Root key and duplicated keys are excluded from the count.
 func (c *Config) GetMaxConcurrency() int {
 	if c.ephemeral {
 		return int(*c.EphemeralAddrs)
 	}
 
-	return len(c.Network.PrivateKeys) - 1
+	return len(c.Network.PrivateKeys) + len(c.Network.NodeAccounts) - 1 - len(c.keyAliases)
 }
 
 func (c *Config) hasOutput(output string) bool {
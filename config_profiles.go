@@ -0,0 +1,76 @@
+package seth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ConfigProfile_CI    = "ci"
+	ConfigProfile_Local = "local"
+)
+
+// configProfiles overlay config values tuned for given environment, so that the same seth.toml can be used both in CI and locally
+var configProfiles = map[string]func(c *Config){
+	// fail fast and keep logs short: short timeouts, only reverted transactions are traced and ephemeral keys are not
+	// generated, so client creation doesn't wait for their funding
+	ConfigProfile_CI: func(c *Config) {
+		c.TracingLevel = TracingLevel_Reverted
+		c.EphemeralAddrs = &ZeroInt64
+		c.Network.TxnTimeout = MustMakeDuration(1 * time.Minute)
+		c.Network.DialTimeout = MustMakeDuration(15 * time.Second)
+		if c.NonceManager != nil {
+			c.NonceManager.KeySyncTimeout = MustMakeDuration(20 * time.Second)
+		}
+	},
+	// debug comfortably: all transactions are traced to console, JSON and DOT files and timeouts are long enough to
+	// step through a test or wait for a slow local node
+	ConfigProfile_Local: func(c *Config) {
+		c.TracingLevel = TracingLevel_All
+		c.TraceOutputs = []string{TraceOutput_Console, TraceOutput_JSON, TraceOutput_DOT}
+		c.Network.TxnTimeout = MustMakeDuration(15 * time.Minute)
+		c.Network.DialTimeout = MustMakeDuration(5 * time.Minute)
+		if c.NonceManager != nil {
+			c.NonceManager.KeySyncTimeout = MustMakeDuration(5 * time.Minute)
+		}
+	},
+}
+
+// ConfigProfileNames returns sorted names of all config profiles
+func ConfigProfileNames() []string {
+	names := make([]string, 0, len(configProfiles))
+	for name := range configProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyProfile overlays values of config profile ("ci" or "local") on top of the config. Profile names are case-insensitive.
+// Network has to be already selected, because profiles change its timeouts.
+func (c *Config) ApplyProfile(name string) error {
+	apply, ok := configProfiles[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("config profile must be one of: %s", strings.Join(ConfigProfileNames(), ", "))
+	}
+	if c.Network == nil {
+		return fmt.Errorf("network must be selected before applying '%s' config profile", name)
+	}
+	apply(c)
+	c.Profile = strings.ToLower(name)
+	L.Info().Str("Profile", c.Profile).Msg("Applied config profile")
+
+	return nil
+}
+
+func validateConfigProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := configProfiles[strings.ToLower(name)]; !ok {
+		return fmt.Errorf("config profile must be one of: %s", strings.Join(ConfigProfileNames(), ", "))
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package seth_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -214,3 +216,124 @@ func TestConfigAppendPkToInactiveNetwork(t *testing.T) {
 	require.Equal(t, 0, len(cfg.Networks[0].PrivateKeys), "network should have 0 pks")
 	require.Equal(t, []string{"pk"}, cfg.Networks[1].PrivateKeys, "network should have 1 pk")
 }
+
+func TestConfig_TracingFailurePolicy(t *testing.T) {
+	type test struct {
+		name                  string
+		policy                string
+		failuresBeforeDisable uint
+		expected              string
+		err                   string
+	}
+
+	tests := []test{
+		{name: "defaults to warn", policy: "", expected: seth.TracingFailurePolicy_Warn},
+		{name: "is case insensitive", policy: "fail", expected: seth.TracingFailurePolicy_Fail},
+		{name: "disable after N failures", policy: "disable_after_n", failuresBeforeDisable: 3, expected: seth.TracingFailurePolicy_DisableAfterFailures},
+		{name: "disable after 0 failures is invalid", policy: "disable_after_n", err: "when tracing failure policy is DISABLE_AFTER_N, tracing_failures_before_disable must be greater than 0"},
+		{name: "unknown policy", policy: "ignore", err: "tracing failure policy must be one of: FAIL, WARN, DISABLE_AFTER_N"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &seth.Config{
+				Network:                      &seth.Network{},
+				TracingFailurePolicy:         tc.policy,
+				TracingFailuresBeforeDisable: tc.failuresBeforeDisable,
+			}
+			err := seth.ValidateConfig(cfg)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err, "incorrect validation error")
+				return
+			}
+			require.NoError(t, err, "config should be valid")
+			require.Equal(t, tc.expected, cfg.TracingFailurePolicy, "incorrect tracing failure policy")
+		})
+	}
+}
+
+func TestConfig_StrictTracingRequiresSyncTracing(t *testing.T) {
+	cfg := &seth.Config{
+		Network:        &seth.Network{},
+		StrictTracing:  true,
+		TracingWorkers: 2,
+	}
+	err := seth.ValidateConfig(cfg)
+	require.EqualError(t, err, "strict tracing requires synchronous tracing, set tracing_workers to 0", "incorrect validation error")
+
+	cfg.TracingWorkers = 0
+	require.NoError(t, seth.ValidateConfig(cfg), "config should be valid")
+}
+
+func TestConfig_KeyRotation(t *testing.T) {
+	cfg := &seth.Config{
+		Network:     &seth.Network{},
+		KeyRotation: &seth.KeyRotationConfig{},
+	}
+	err := seth.ValidateConfig(cfg)
+	require.EqualError(t, err, "when key rotation is configured, either max_transactions_per_key or max_nonce must be greater than 0", "incorrect validation error")
+
+	cfg.KeyRotation.MaxNonce = 1000
+	require.NoError(t, seth.ValidateConfig(cfg), "config should be valid")
+	require.True(t, cfg.IsKeyRotationEnabled(), "key rotation should be enabled")
+}
+
+func TestConfig_ProviderProfile(t *testing.T) {
+	cfg := &seth.Config{
+		Network: &seth.Network{ProviderProfile: "unknown"},
+	}
+	err := seth.ValidateConfig(cfg)
+	require.EqualError(t, err, "provider profile must be one of: alchemy, infura, public, quicknode", "incorrect validation error")
+	require.Nil(t, cfg.ProviderProfile(), "unknown profile should not be returned")
+
+	cfg.Network.ProviderProfile = "Alchemy"
+	require.NoError(t, seth.ValidateConfig(cfg), "config should be valid")
+	require.NotNil(t, cfg.ProviderProfile(), "profile should be found regardless of case")
+	require.Equal(t, seth.ProviderProfile_Alchemy, cfg.ProviderProfile().Name, "incorrect profile")
+
+	seth.RegisterProviderProfile(seth.ProviderProfile{Name: "MyNode", RequestsPerSecond: 100, BatchSize: 500})
+	cfg.Network.ProviderProfile = "mynode"
+	require.NoError(t, seth.ValidateConfig(cfg), "custom profile should be valid")
+	require.Equal(t, 500, cfg.ProviderProfile().BatchSize, "incorrect custom profile")
+}
+
+func TestConfig_ConfigProfile(t *testing.T) {
+	cfg := seth.NewClientBuilder().
+		WithRpcUrl("http://localhost:8545").
+		WithTracing(seth.TracingLevel_None, nil).
+		WithProfile("CI").
+		WithTracing(seth.TracingLevel_All, []string{seth.TraceOutput_Console}).
+		Config()
+	require.NoError(t, seth.ValidateConfig(cfg), "config should be valid")
+	require.Equal(t, seth.ConfigProfile_CI, cfg.Profile, "profile should be found regardless of case")
+	require.Equal(t, time.Minute, cfg.Network.TxnTimeout.Duration(), "profile should overlay transaction timeout")
+	require.Equal(t, int64(0), *cfg.EphemeralAddrs, "ci profile should disable ephemeral keys")
+	require.Equal(t, seth.TracingLevel_All, cfg.TracingLevel, "values set after profile should take precedence")
+
+	cfg = seth.NewClientBuilder().WithProfile("staging").Config()
+	require.EqualError(t, seth.ValidateConfig(cfg), "config profile must be one of: ci, local", "incorrect validation error")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "seth.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+profile = "ci"
+tracing_level = "NONE"
+
+[[networks]]
+name = "Default"
+urls_secret = ["http://localhost:8545"]
+transaction_timeout = "5m"
+`), 0o600), "failed to write config")
+	opts := seth.ConfigOptions{Path: configPath, NetworkName: "Default", RootPrivateKey: "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"}
+	cfg, err := seth.ReadConfigWithOptions(opts)
+	require.NoError(t, err, "failed to read config")
+	require.Equal(t, seth.TracingLevel_Reverted, cfg.TracingLevel, "profile from TOML should overlay base values")
+	require.Equal(t, time.Minute, cfg.Network.TxnTimeout.Duration(), "profile from TOML should overlay network values")
+
+	opts.Profile = seth.ConfigProfile_Local
+	cfg, err = seth.ReadConfigWithOptions(opts)
+	require.NoError(t, err, "failed to read config")
+	require.Equal(t, seth.ConfigProfile_Local, cfg.Profile, "profile from options should replace the one from TOML")
+	require.Equal(t, seth.TracingLevel_All, cfg.TracingLevel, "local profile should trace all transactions")
+	require.Equal(t, 15*time.Minute, cfg.Network.TxnTimeout.Duration(), "local profile should use long timeouts")
+}
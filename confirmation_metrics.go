@@ -0,0 +1,240 @@
+package seth
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math/big"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/montanaflynn/stats"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrWriteConfirmationMetrics = "failed to write confirmation metrics"
+)
+
+// TxConfirmationMetric describes confirmation of a single transaction waited for by Decode
+type TxConfirmationMetric struct {
+	Hash   string `json:"hash"`
+	From   string `json:"from,omitempty"`
+	Method string `json:"method,omitempty"`
+	// SentAt is when Seth started waiting for the transaction, which for `client.Decode(contract.Method(opts))` is right after it was sent
+	SentAt            time.Time     `json:"sent_at"`
+	MinedAt           time.Time     `json:"mined_at"`
+	Latency           time.Duration `json:"latency"`
+	Block             uint64        `json:"block"`
+	BlocksElapsed     uint64        `json:"blocks_elapsed"`
+	GasUsed           uint64        `json:"gas_used"`
+	EffectiveGasPrice *big.Int      `json:"effective_gas_price,omitempty"`
+	GasBumps          int           `json:"gas_bumps"`
+	Reverted          bool          `json:"reverted"`
+}
+
+// LatencyPercentiles are percentiles of transaction confirmation latency
+type LatencyPercentiles struct {
+	Min    time.Duration `json:"min"`
+	Avg    time.Duration `json:"avg"`
+	Perc50 time.Duration `json:"p50"`
+	Perc90 time.Duration `json:"p90"`
+	Perc95 time.Duration `json:"p95"`
+	Perc99 time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// ConfirmationMetricsReport summarises confirmations of all transactions recorded so far
+type ConfirmationMetricsReport struct {
+	Transactions int                `json:"transactions"`
+	Reverted     int                `json:"reverted"`
+	Latency      LatencyPercentiles `json:"latency"`
+	// AvgBlocksElapsed is the average number of blocks mined from sending to mining of a transaction
+	AvgBlocksElapsed     float64  `json:"avg_blocks_elapsed"`
+	TotalGasUsed         uint64   `json:"total_gas_used"`
+	AvgGasUsed           uint64   `json:"avg_gas_used"`
+	AvgEffectiveGasPrice *big.Int `json:"avg_effective_gas_price"`
+	MaxEffectiveGasPrice *big.Int `json:"max_effective_gas_price"`
+	TotalGasBumps        int      `json:"total_gas_bumps"`
+	// BumpedTransactions is the number of transactions that were mined only after gas bumping
+	BumpedTransactions int `json:"bumped_transactions"`
+}
+
+// ConfirmationMetrics collects confirmation latency, gas used, effective gas price and number of gas bumps of every transaction
+// waited for by Decode. Unlike session log, it keeps all transactions, so that report of a load test covers all of them.
+// It is safe to use from multiple goroutines.
+type ConfirmationMetrics struct {
+	mu  sync.Mutex
+	txs []TxConfirmationMetric
+}
+
+// NewConfirmationMetrics creates an empty metrics collector
+func NewConfirmationMetrics() *ConfirmationMetrics {
+	return &ConfirmationMetrics{}
+}
+
+// record adds decoded transaction to metrics, transactions that Seth didn't wait for (without timing or receipt) are skipped
+func (c *ConfirmationMetrics) record(decoded *DecodedTransaction) {
+	if c == nil || decoded == nil || decoded.Transaction == nil || decoded.Receipt == nil || decoded.Timing == nil {
+		return
+	}
+	timing := decoded.Timing
+	metric := TxConfirmationMetric{
+		Hash:          decoded.Hash,
+		Method:        decoded.Method,
+		SentAt:        timing.StartedAt,
+		MinedAt:       timing.MinedAt,
+		Latency:       timing.Duration,
+		Block:         timing.InclusionBlock,
+		BlocksElapsed: timing.BlocksElapsed,
+		GasUsed:       decoded.Receipt.GasUsed,
+		GasBumps:      timing.GasBumps,
+		Reverted:      decoded.Receipt.Status != types.ReceiptStatusSuccessful,
+	}
+	if decoded.Receipt.EffectiveGasPrice != nil {
+		metric.EffectiveGasPrice = new(big.Int).Set(decoded.Receipt.EffectiveGasPrice)
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(decoded.Transaction.ChainId()), decoded.Transaction); err == nil {
+		metric.From = from.Hex()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.txs = append(c.txs, metric)
+}
+
+// Transactions returns metrics of all transactions recorded so far
+func (c *ConfirmationMetrics) Transactions() []TxConfirmationMetric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]TxConfirmationMetric{}, c.txs...)
+}
+
+// Reset removes all recorded transactions, e.g. between load test phases
+func (c *ConfirmationMetrics) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.txs = nil
+}
+
+// Report returns summary of all transactions recorded so far
+func (c *ConfirmationMetrics) Report() ConfirmationMetricsReport {
+	txs := c.Transactions()
+	report := ConfirmationMetricsReport{
+		Transactions:         len(txs),
+		AvgEffectiveGasPrice: big.NewInt(0),
+		MaxEffectiveGasPrice: big.NewInt(0),
+	}
+	if len(txs) == 0 {
+		return report
+	}
+
+	latencies := make([]float64, 0, len(txs))
+	totalGasPrice := big.NewInt(0)
+	pricedTxs := 0
+	var blocksElapsed uint64
+	for _, tx := range txs {
+		latencies = append(latencies, float64(tx.Latency))
+		blocksElapsed += tx.BlocksElapsed
+		report.TotalGasUsed += tx.GasUsed
+		report.TotalGasBumps += tx.GasBumps
+		if tx.GasBumps > 0 {
+			report.BumpedTransactions++
+		}
+		if tx.Reverted {
+			report.Reverted++
+		}
+		if tx.EffectiveGasPrice != nil {
+			totalGasPrice.Add(totalGasPrice, tx.EffectiveGasPrice)
+			pricedTxs++
+			if tx.EffectiveGasPrice.Cmp(report.MaxEffectiveGasPrice) > 0 {
+				report.MaxEffectiveGasPrice = new(big.Int).Set(tx.EffectiveGasPrice)
+			}
+		}
+	}
+	report.AvgGasUsed = report.TotalGasUsed / uint64(len(txs))
+	report.AvgBlocksElapsed = float64(blocksElapsed) / float64(len(txs))
+	if pricedTxs > 0 {
+		report.AvgEffectiveGasPrice = totalGasPrice.Div(totalGasPrice, big.NewInt(int64(pricedTxs)))
+	}
+
+	// errors are returned only for empty input
+	percentile := func(p float64) time.Duration {
+		v, _ := stats.Percentile(latencies, p)
+		return time.Duration(v)
+	}
+	minLatency, _ := stats.Min(latencies)
+	maxLatency, _ := stats.Max(latencies)
+	avgLatency, _ := stats.Mean(latencies)
+	report.Latency = LatencyPercentiles{
+		Min:    time.Duration(minLatency),
+		Avg:    time.Duration(avgLatency),
+		Perc50: percentile(50),
+		Perc90: percentile(90),
+		Perc95: percentile(95),
+		Perc99: percentile(99),
+		Max:    time.Duration(maxLatency),
+	}
+
+	return report
+}
+
+// WriteJSON writes report together with metrics of all transactions to JSON file
+func (c *ConfirmationMetrics) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(struct {
+		Report       ConfirmationMetricsReport `json:"report"`
+		Transactions []TxConfirmationMetric    `json:"transactions"`
+	}{
+		Report:       c.Report(),
+		Transactions: c.Transactions(),
+	}, "", "   ")
+	if err != nil {
+		return errors.Wrap(err, ErrWriteConfirmationMetrics)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return errors.Wrap(err, ErrWriteConfirmationMetrics)
+	}
+
+	return nil
+}
+
+// WriteCSV writes metrics of all transactions to CSV file, one row per transaction, with latency in milliseconds and
+// effective gas price in wei
+func (c *ConfirmationMetrics) WriteCSV(path string) error {
+	rows := [][]string{{"hash", "from", "method", "sent_at", "mined_at", "latency_ms", "block", "blocks_elapsed", "gas_used", "effective_gas_price", "gas_bumps", "reverted"}}
+	for _, tx := range c.Transactions() {
+		gasPrice := ""
+		if tx.EffectiveGasPrice != nil {
+			gasPrice = tx.EffectiveGasPrice.String()
+		}
+		rows = append(rows, []string{
+			tx.Hash,
+			tx.From,
+			tx.Method,
+			tx.SentAt.UTC().Format(time.RFC3339Nano),
+			tx.MinedAt.UTC().Format(time.RFC3339Nano),
+			strconv.FormatInt(tx.Latency.Milliseconds(), 10),
+			strconv.FormatUint(tx.Block, 10),
+			strconv.FormatUint(tx.BlocksElapsed, 10),
+			strconv.FormatUint(tx.GasUsed, 10),
+			gasPrice,
+			strconv.Itoa(tx.GasBumps),
+			strconv.FormatBool(tx.Reverted),
+		})
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, ErrWriteConfirmationMetrics)
+	}
+	defer func() { _ = f.Close() }()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		return errors.Wrap(err, ErrWriteConfirmationMetrics)
+	}
+
+	return nil
+}
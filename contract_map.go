@@ -1,31 +1,71 @@
 package seth
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrContractMapConflict    = "conflicting contract map entries"
+	ErrUnknownContractAddress = "address is not in the contract map"
+	ErrContractLabelTaken     = "contract label is already used by another address"
 )
 
 type ContractMap struct {
 	mu         *sync.RWMutex
 	addressMap map[string]string
+	// code hashes and names of contracts deployed via Seth, used to detect stale or wrong entries
+	codeHashes    map[string]common.Hash
+	deployedNames map[string]string
+	verified      map[string]bool
+	stale         map[string]StaleContractMapEntry
+	// labels distinguish instances of the same contract, they are used only for display and never for ABI lookup
+	labels map[string]string
+	// clones map addresses of EIP-1167 minimal proxies to their implementations, empty implementation means it's not a clone
+	clones map[string]string
+	// addresses of contracts deployed from raw bytecode, calls to them are never decoded
+	rawBytecode map[string]bool
+}
+
+// StaleContractMapEntry is a contract map entry, which had a different name than the contract deployed at its address
+// (e.g. because it was mis-assigned when method selectors collided) or whose on-chain code no longer matches the code hash
+// recorded at deployment
+type StaleContractMapEntry struct {
+	Address          string
+	Name             string
+	ExpectedCodeHash common.Hash
+	ActualCodeHash   common.Hash
+	// CorrectedName is the name of the contract whose code hash matched the on-chain code, empty if none did
+	CorrectedName string
 }
 
 func NewEmptyContractMap() ContractMap {
-	return ContractMap{
-		mu:         &sync.RWMutex{},
-		addressMap: map[string]string{},
-	}
+	return NewContractMap(map[string]string{})
 }
 
 func NewContractMap(contracts map[string]string) ContractMap {
 	return ContractMap{
-		mu:         &sync.RWMutex{},
-		addressMap: contracts,
+		mu:            &sync.RWMutex{},
+		addressMap:    contracts,
+		codeHashes:    map[string]common.Hash{},
+		deployedNames: map[string]string{},
+		verified:      map[string]bool{},
+		stale:         map[string]StaleContractMapEntry{},
+		labels:        map[string]string{},
+		clones:        map[string]string{},
+		rawBytecode:   map[string]bool{},
 	}
 }
 
@@ -71,6 +111,294 @@ func (c ContractMap) AddContract(addr, name string) {
 	c.addressMap[strings.ToLower(addr)] = name
 }
 
+// SetContractLabel sets label of the contract instance at given address (e.g. "LinkToken#3" or "FeeToken"). Label is shown
+// instead of contract name in traces, journal and recordings. Empty label removes it.
+func (c ContractMap) SetContractLabel(addr, label string) {
+	if c.labels == nil || addr == UNKNOWN {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if label == "" {
+		delete(c.labels, strings.ToLower(addr))
+		return
+	}
+	c.labels[strings.ToLower(addr)] = label
+}
+
+// removeContract removes contract at given address together with its label and code hash
+func (c ContractMap) removeContract(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr = strings.ToLower(addr)
+	delete(c.addressMap, addr)
+	delete(c.labels, addr)
+	delete(c.codeHashes, addr)
+	delete(c.deployedNames, addr)
+	delete(c.verified, addr)
+	delete(c.stale, addr)
+	delete(c.clones, addr)
+	delete(c.rawBytecode, addr)
+}
+
+// IsRawBytecode returns true if contract at given address was deployed from raw bytecode, without ABI
+func (c ContractMap) IsRawBytecode(addr string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rawBytecode[strings.ToLower(addr)]
+}
+
+func (c ContractMap) markRawBytecode(addr string) {
+	if c.rawBytecode == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rawBytecode[strings.ToLower(addr)] = true
+}
+
+// GetContractLabel returns label of the contract instance at given address or, if it has none, its name
+func (c ContractMap) GetContractLabel(addr string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addr = strings.ToLower(addr)
+	if label := c.labels[addr]; label != "" {
+		return label
+	}
+	return c.addressMap[addr]
+}
+
+// GetLabels returns a copy of all instance labels
+func (c ContractMap) GetLabels() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	labels := make(map[string]string, len(c.labels))
+	for k, v := range c.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// addressForLabel returns address of the contract instance with given label or empty string if there's none
+func (c ContractMap) addressForLabel(label string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for addr, l := range c.labels {
+		if l == label {
+			return addr
+		}
+	}
+	return ""
+}
+
+// labelNewInstance labels contract at given address with "<name>#<n>", if there are already other instances of the same
+// contract in the map and it has no label yet. First instance keeps its plain name. It returns the label or empty string.
+func (c ContractMap) labelNewInstance(addr string) string {
+	if c.labels == nil || addr == UNKNOWN {
+		return ""
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr = strings.ToLower(addr)
+	name := c.addressMap[addr]
+	if name == "" || c.labels[addr] != "" {
+		return ""
+	}
+
+	instances := 0
+	for otherAddr, otherName := range c.addressMap {
+		if otherName == name && otherAddr != addr {
+			instances++
+		}
+	}
+	if instances == 0 {
+		return ""
+	}
+
+	taken := make(map[string]bool, len(c.labels))
+	for _, l := range c.labels {
+		taken[l] = true
+	}
+	n := instances + 1
+	label := fmt.Sprintf("%s#%d", name, n)
+	for taken[label] {
+		n++
+		label = fmt.Sprintf("%s#%d", name, n)
+	}
+	c.labels[addr] = label
+
+	return label
+}
+
+// AddContractWithCodeHash adds contract to the map together with the hash of its on-chain code, which is later used
+// to verify that the entry is still correct
+func (c ContractMap) AddContractWithCodeHash(addr, name string, codeHash common.Hash) {
+	c.AddContract(addr, name)
+	c.setCodeHash(addr, codeHash)
+}
+
+func (c ContractMap) setCodeHash(addr string, codeHash common.Hash) {
+	if c.codeHashes == nil || addr == UNKNOWN {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr = strings.ToLower(addr)
+	c.codeHashes[addr] = codeHash
+	c.deployedNames[addr] = c.addressMap[addr]
+	delete(c.verified, addr)
+}
+
+// setCodeHashes sets code hashes loaded from file, names of contracts in the map are assumed to be the deployed ones
+func (c *ContractMap) setCodeHashes(codeHashes map[string]common.Hash) {
+	if c.codeHashes == nil {
+		*c = NewContractMap(c.addressMap)
+	}
+
+	for addr, hash := range codeHashes {
+		addr = strings.ToLower(addr)
+		if name := c.addressMap[addr]; name != "" {
+			c.codeHashes[addr] = hash
+			c.deployedNames[addr] = name
+		}
+	}
+}
+
+// GetCodeHash returns code hash recorded for given address and whether it was found
+func (c ContractMap) GetCodeHash(addr string) (common.Hash, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hash, ok := c.codeHashes[strings.ToLower(addr)]
+	return hash, ok
+}
+
+// needsVerification returns true if entry for given address has a code hash and wasn't verified yet
+func (c ContractMap) needsVerification(addr string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addr = strings.ToLower(addr)
+	if _, ok := c.codeHashes[addr]; !ok {
+		return false
+	}
+	return !c.verified[addr]
+}
+
+// isVerifiedDeployment returns true if on-chain code at given address was verified to be the contract deployed via Seth
+func (c ContractMap) isVerifiedDeployment(addr string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addr = strings.ToLower(addr)
+	_, ok := c.codeHashes[addr]
+	return ok && c.verified[addr]
+}
+
+// verifyCodeHash compares on-chain code hash with the one recorded at deployment. If they match, but the entry has a different
+// name than the deployed contract, it's restored to the deployed name. If they differ, the entry is corrected to the name of
+// another deployed contract with matching code hash or, if there's none, removed from the map, so that ABI will be looked up
+// by method signature. It returns true if entry was stale.
+func (c ContractMap) verifyCodeHash(addr string, actual common.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr = strings.ToLower(addr)
+	c.verified[addr] = true
+
+	expected, ok := c.codeHashes[addr]
+	if !ok {
+		return false
+	}
+
+	entry := StaleContractMapEntry{
+		Address:          addr,
+		Name:             c.addressMap[addr],
+		ExpectedCodeHash: expected,
+		ActualCodeHash:   actual,
+	}
+
+	if expected == actual {
+		deployedName := c.deployedNames[addr]
+		if deployedName == "" || deployedName == entry.Name {
+			return false
+		}
+		entry.CorrectedName = deployedName
+		c.addressMap[addr] = deployedName
+		c.stale[addr] = entry
+
+		return true
+	}
+
+	for otherAddr, otherHash := range c.codeHashes {
+		if otherHash == actual && otherAddr != addr && c.deployedNames[otherAddr] != "" {
+			entry.CorrectedName = c.deployedNames[otherAddr]
+			break
+		}
+	}
+
+	if entry.CorrectedName != "" {
+		c.addressMap[addr] = entry.CorrectedName
+		c.codeHashes[addr] = actual
+		c.deployedNames[addr] = entry.CorrectedName
+	} else {
+		delete(c.addressMap, addr)
+		delete(c.codeHashes, addr)
+		delete(c.deployedNames, addr)
+	}
+	c.stale[addr] = entry
+
+	return true
+}
+
+// verifyContractMapEntry lazily checks whether on-chain code at given address matches code hash recorded at deployment.
+// Each address is verified only once. Failures to fetch the code are only logged, since verification is best-effort.
+func verifyContractMapEntry(rpcClient *rpc.Client, timeout time.Duration, contractMap ContractMap, address string) {
+	if rpcClient == nil || address == UNKNOWN || !contractMap.needsVerification(address) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var code hexutil.Bytes
+	if err := rpcClient.CallContext(ctx, &code, "eth_getCode", common.HexToAddress(address), "latest"); err != nil {
+		L.Debug().
+			Err(err).
+			Str("Address", address).
+			Msg("Failed to get contract code. Contract map entry won't be verified")
+		return
+	}
+
+	name := contractMap.GetContractName(address)
+	if !contractMap.verifyCodeHash(address, crypto.Keccak256Hash(code)) {
+		return
+	}
+
+	if corrected := contractMap.GetContractName(address); corrected != "" {
+		L.Warn().
+			Str("Address", address).
+			Str("Old name", name).
+			Str("New name", corrected).
+			Msg("Contract map entry didn't match contract deployed at the address. Corrected it using recorded code hashes")
+		return
+	}
+
+	L.Warn().
+		Str("Address", address).
+		Str("Name", name).
+		Msg("On-chain code didn't match contract map entry. Removed it from contract map, ABI will be looked up by method signature")
+}
+
+// StaleEntries returns all entries that were found to be stale during verification
+func (c ContractMap) StaleEntries() []StaleContractMapEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]StaleContractMapEntry, 0, len(c.stale))
+	for _, entry := range c.stale {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
 func (c ContractMap) Size() int {
 	return len(c.addressMap)
 }
@@ -117,3 +445,194 @@ func LoadDeployedContracts(filename string) (map[string]string, error) {
 
 	return contracts, nil
 }
+
+// CodeHashesFileName returns name of the file in which code hashes of contracts from given contract map file are stored
+func CodeHashesFileName(contractMapFile string) string {
+	return strings.TrimSuffix(contractMapFile, ".toml") + "_code_hashes.toml"
+}
+
+// SaveDeployedContractCodeHash saves code hash of deployed contract next to the contract map file
+func SaveDeployedContractCodeHash(contractMapFile, address string, codeHash common.Hash) error {
+	file, err := os.OpenFile(CodeHashesFileName(contractMapFile), os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	marshalled, err := toml.Marshal(map[string]string{address: codeHash.Hex()})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(string(marshalled))
+	return err
+}
+
+// LoadDeployedContractCodeHashes loads code hashes saved next to the contract map file. If there's no such file, empty map is returned
+func LoadDeployedContractCodeHashes(contractMapFile string) (map[string]common.Hash, error) {
+	tomlFile, err := os.Open(CodeHashesFileName(contractMapFile))
+	if err != nil {
+		return map[string]common.Hash{}, nil
+	}
+	defer tomlFile.Close()
+
+	b, _ := io.ReadAll(tomlFile)
+	raw := map[common.Address]common.Hash{}
+	if err := toml.Unmarshal(b, &raw); err != nil {
+		return map[string]common.Hash{}, err
+	}
+
+	hashes := map[string]common.Hash{}
+	for k, v := range raw {
+		hashes[strings.ToLower(k.Hex())] = v
+	}
+
+	return hashes, nil
+}
+
+// LabelsFileName returns name of the file in which instance labels of contracts from given contract map file are stored
+func LabelsFileName(contractMapFile string) string {
+	return strings.TrimSuffix(contractMapFile, ".toml") + "_labels.toml"
+}
+
+// SaveContractLabel saves label of contract instance next to the contract map file, replacing previous label of that address
+func SaveContractLabel(contractMapFile, address, label string) error {
+	labels, err := LoadContractLabels(contractMapFile)
+	if err != nil {
+		return err
+	}
+
+	address = strings.ToLower(address)
+	if label == "" {
+		delete(labels, address)
+	} else {
+		labels[address] = label
+	}
+
+	raw := make(map[string]string, len(labels))
+	for addr, l := range labels {
+		raw[common.HexToAddress(addr).Hex()] = l
+	}
+
+	marshalled, err := toml.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(LabelsFileName(contractMapFile), marshalled, 0600)
+}
+
+// LoadContractLabels loads instance labels saved next to the contract map file. If there's no such file, empty map is returned
+func LoadContractLabels(contractMapFile string) (map[string]string, error) {
+	tomlFile, err := os.Open(LabelsFileName(contractMapFile))
+	if err != nil {
+		return map[string]string{}, nil
+	}
+	defer tomlFile.Close()
+
+	b, _ := io.ReadAll(tomlFile)
+	raw := map[common.Address]string{}
+	if err := toml.Unmarshal(b, &raw); err != nil {
+		return map[string]string{}, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range raw {
+		labels[strings.ToLower(k.Hex())] = v
+	}
+
+	return labels, nil
+}
+
+// loadAndMergeContractLabels loads instance labels of all given contract map files. It returns an error if the same address
+// has different labels in different files.
+func loadAndMergeContractLabels(filenames []string) (map[string]string, error) {
+	labels := map[string]string{}
+	sources := map[string]string{}
+
+	for _, filename := range filenames {
+		fileLabels, err := LoadContractLabels(filename)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load contract labels for %s", filename)
+		}
+		for addr, label := range fileLabels {
+			if existing, ok := labels[addr]; ok && existing != label {
+				return nil, fmt.Errorf("%s: address %s is labelled '%s' in %s, but '%s' in %s", ErrContractMapConflict, addr, existing, sources[addr], label, filename)
+			}
+			labels[addr] = label
+			sources[addr] = filename
+		}
+	}
+
+	return labels, nil
+}
+
+// LoadAndMergeDeployedContracts loads contract maps and code hashes from all given files and merges them. It returns an error
+// if the same address is mapped to different contracts or code hashes in different files.
+func LoadAndMergeDeployedContracts(filenames []string) (map[string]string, map[string]common.Hash, error) {
+	contracts := map[string]string{}
+	codeHashes := map[string]common.Hash{}
+	contractSources := map[string]string{}
+	codeHashSources := map[string]string{}
+
+	for _, filename := range filenames {
+		fileContracts, err := LoadDeployedContracts(filename)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to load contract map from %s", filename)
+		}
+		for addr, name := range fileContracts {
+			if existing, ok := contracts[addr]; ok && existing != name {
+				return nil, nil, fmt.Errorf("%s: address %s is '%s' in %s, but '%s' in %s", ErrContractMapConflict, addr, existing, contractSources[addr], name, filename)
+			}
+			contracts[addr] = name
+			contractSources[addr] = filename
+		}
+
+		fileCodeHashes, err := LoadDeployedContractCodeHashes(filename)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to load contract code hashes for %s", filename)
+		}
+		for addr, hash := range fileCodeHashes {
+			if existing, ok := codeHashes[addr]; ok && existing != hash {
+				return nil, nil, fmt.Errorf("%s: address %s has code hash %s in %s, but %s in %s", ErrContractMapConflict, addr, existing.Hex(), codeHashSources[addr], hash.Hex(), filename)
+			}
+			codeHashes[addr] = hash
+			codeHashSources[addr] = filename
+		}
+	}
+
+	return contracts, codeHashes, nil
+}
+
+// loadContractMapFromFiles creates contract map from all configured contract map files
+func loadContractMapFromFiles(cfg *Config) (ContractMap, error) {
+	paths, err := cfg.ContractMapFilePaths()
+	if err != nil {
+		return ContractMap{}, err
+	}
+
+	contracts, codeHashes, err := LoadAndMergeDeployedContracts(paths)
+	if err != nil {
+		return ContractMap{}, err
+	}
+
+	labels, err := loadAndMergeContractLabels(paths)
+	if err != nil {
+		return ContractMap{}, err
+	}
+
+	contractMap := NewContractMap(contracts)
+	contractMap.setCodeHashes(codeHashes)
+	for addr, label := range labels {
+		contractMap.SetContractLabel(addr, label)
+	}
+
+	if len(paths) > 1 {
+		L.Debug().
+			Strs("Files", paths).
+			Int("Size", len(contracts)).
+			Msg("Merged contract maps")
+	}
+
+	return contractMap, nil
+}
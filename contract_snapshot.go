@@ -0,0 +1,306 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrSnapshot     = "failed to snapshot contract state"
+	ErrSnapshotDiff = "failed to diff contract snapshots"
+)
+
+// ContractSnapshot holds values returned by all argument-less view and pure functions of a contract at one block
+type ContractSnapshot struct {
+	Address     common.Address `json:"address"`
+	ABIName     string         `json:"abi_name"`
+	BlockNumber uint64         `json:"block_number"`
+	// Values are keyed by getter name. Getters with a single output have its value, the ones with more outputs have
+	// a map of output name (or index, if output is unnamed) to value
+	Values map[string]interface{} `json:"values"`
+	// Errors are keyed by name of getters that reverted or couldn't be decoded
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// SnapshotChange is a value of a getter that differs between two snapshots, nil means that getter had no value
+type SnapshotChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Snapshot calls every argument-less view and pure function of the contract at the latest block and returns decoded
+// values. ABI has to be present in the Contract Store. All calls are sent in a single batch request, so that they see
+// the same state. Getters that revert don't fail the snapshot, they are listed in Errors instead.
+func (m *Client) Snapshot(contractAddr common.Address, abiName string) (*ContractSnapshot, error) {
+	return m.SnapshotAtBlock(contractAddr, abiName, nil)
+}
+
+// SnapshotAtBlock works like Snapshot, but reads state at given block (or latest, if it's nil)
+func (m *Client) SnapshotAtBlock(contractAddr common.Address, abiName string, block *big.Int) (*ContractSnapshot, error) {
+	if m.ContractStore == nil {
+		return nil, errors.Wrap(errors.New("contract store is nil"), ErrSnapshot)
+	}
+	contractABI, ok := m.ContractStore.GetABI(abiName)
+	if !ok {
+		return nil, fmt.Errorf("%s: ABI %s not found in contract store", ErrSnapshot, abiName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	// pin all calls to the same block, even if a new one is mined in the meantime
+	if block == nil {
+		latest, err := m.Client.BlockNumber(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrSnapshot)
+		}
+		block = new(big.Int).SetUint64(latest)
+	}
+
+	getters := snapshotGetters(contractABI)
+	results := make([]hexutil.Bytes, len(getters))
+	batch := make([]rpc.BatchElem, len(getters))
+	for i, getter := range getters {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args: []interface{}{
+				map[string]interface{}{"to": contractAddr, "data": hexutil.Bytes(getter.ID)},
+				toBlockNumArg(block),
+			},
+			Result: &results[i],
+		}
+	}
+	if len(batch) > 0 {
+		if err := m.Client.Client().BatchCallContext(ctx, batch); err != nil {
+			return nil, errors.Wrap(err, ErrSnapshot)
+		}
+	}
+
+	snapshot := &ContractSnapshot{
+		Address:     contractAddr,
+		ABIName:     abiName,
+		BlockNumber: block.Uint64(),
+		Values:      make(map[string]interface{}, len(getters)),
+		Errors:      make(map[string]string),
+	}
+	for i, getter := range getters {
+		if batch[i].Error != nil {
+			snapshot.Errors[getter.Name] = batch[i].Error.Error()
+			continue
+		}
+		value, err := snapshotValue(getter, results[i])
+		if err != nil {
+			snapshot.Errors[getter.Name] = err.Error()
+			continue
+		}
+		snapshot.Values[getter.Name] = value
+	}
+
+	L.Debug().
+		Str("Address", contractAddr.Hex()).
+		Str("ABI", abiName).
+		Uint64("Block", snapshot.BlockNumber).
+		Int("Getters", len(getters)).
+		Int("Errors", len(snapshot.Errors)).
+		Msg("Snapshotted contract state")
+
+	return snapshot, nil
+}
+
+// Diff returns getters, whose values differ between this and other (later) snapshot. Getters that are present only in
+// one of snapshots are included with nil value on the other side.
+func (s *ContractSnapshot) Diff(other *ContractSnapshot) map[string]SnapshotChange {
+	changes := make(map[string]SnapshotChange)
+	for name, before := range s.Values {
+		after, ok := other.Values[name]
+		if !ok || !valuesEqual(before, after) {
+			changes[name] = SnapshotChange{Before: before, After: after}
+		}
+	}
+	for name, after := range other.Values {
+		if _, ok := s.Values[name]; !ok {
+			changes[name] = SnapshotChange{After: after}
+		}
+	}
+
+	return changes
+}
+
+// GetterChange is a getter whose value (or error) differs between two snapshots
+type GetterChange struct {
+	Getter      string      `json:"getter"`
+	Before      interface{} `json:"before"`
+	After       interface{} `json:"after"`
+	BeforeError string      `json:"before_error,omitempty"`
+	AfterError  string      `json:"after_error,omitempty"`
+}
+
+// SnapshotDiff is the on-chain effect of a test step on a contract: getters that changed between two snapshots sorted by name
+type SnapshotDiff struct {
+	Step        string         `json:"step,omitempty"`
+	Address     common.Address `json:"address"`
+	ABIName     string         `json:"abi_name"`
+	BlockBefore uint64         `json:"block_before"`
+	BlockAfter  uint64         `json:"block_after"`
+	Changes     []GetterChange `json:"changes"`
+}
+
+// DiffSnapshots compares two snapshots of the same contract and returns getters, whose values changed, including the ones
+// that started or stopped reverting. Both snapshots must be taken for the same address.
+func DiffSnapshots(before, after *ContractSnapshot) (*SnapshotDiff, error) {
+	if before == nil || after == nil {
+		return nil, errors.Wrap(errors.New("snapshot is nil"), ErrSnapshotDiff)
+	}
+	if before.Address != after.Address {
+		return nil, fmt.Errorf("%s: snapshots are of different contracts %s and %s", ErrSnapshotDiff, before.Address.Hex(), after.Address.Hex())
+	}
+
+	diff := &SnapshotDiff{
+		Address:     after.Address,
+		ABIName:     after.ABIName,
+		BlockBefore: before.BlockNumber,
+		BlockAfter:  after.BlockNumber,
+		Changes:     make([]GetterChange, 0),
+	}
+	getters := make(map[string]struct{})
+	for _, m := range []map[string]interface{}{before.Values, after.Values} {
+		for name := range m {
+			getters[name] = struct{}{}
+		}
+	}
+	for _, m := range []map[string]string{before.Errors, after.Errors} {
+		for name := range m {
+			getters[name] = struct{}{}
+		}
+	}
+	for name := range getters {
+		change := GetterChange{
+			Getter:      name,
+			Before:      before.Values[name],
+			After:       after.Values[name],
+			BeforeError: before.Errors[name],
+			AfterError:  after.Errors[name],
+		}
+		if change.BeforeError == change.AfterError && valuesEqual(change.Before, change.After) {
+			continue
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool {
+		return diff.Changes[i].Getter < diff.Changes[j].Getter
+	})
+
+	return diff, nil
+}
+
+// HasChanges returns true if any getter changed
+func (d *SnapshotDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// String returns a one-line summary of changes, e.g. "counter: 1 -> 2; owner: 0x... -> 0x..."
+func (d *SnapshotDiff) String() string {
+	if !d.HasChanges() {
+		return "no changes"
+	}
+	changes := make([]string, 0, len(d.Changes))
+	for _, c := range d.Changes {
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", c.Getter, formatGetterValue(c.Before, c.BeforeError), formatGetterValue(c.After, c.AfterError)))
+	}
+
+	return strings.Join(changes, "; ")
+}
+
+// Log prints every changed getter
+func (d *SnapshotDiff) Log() {
+	for _, c := range d.Changes {
+		L.Info().
+			Str("Step", d.Step).
+			Str("Address", d.Address.Hex()).
+			Str("ABI", d.ABIName).
+			Str("Getter", c.Getter).
+			Str("Before", formatGetterValue(c.Before, c.BeforeError)).
+			Str("After", formatGetterValue(c.After, c.AfterError)).
+			Msg("Contract state changed")
+	}
+	L.Info().
+		Str("Step", d.Step).
+		Str("Address", d.Address.Hex()).
+		Str("ABI", d.ABIName).
+		Uint64("BlockBefore", d.BlockBefore).
+		Uint64("BlockAfter", d.BlockAfter).
+		Int("Changes", len(d.Changes)).
+		Msg("Contract state diff")
+}
+
+// RecordSnapshotDiff diffs two snapshots, labels the diff with test step name, logs it and saves it as JSON file in
+// artifacts directory (snapshot_diffs/<step>.json), so that each test step has a record of its on-chain effect
+func (m *Client) RecordSnapshotDiff(step string, before, after *ContractSnapshot) (*SnapshotDiff, error) {
+	diff, err := DiffSnapshots(before, after)
+	if err != nil {
+		return nil, err
+	}
+	diff.Step = step
+	diff.Log()
+
+	path, err := saveTraceAsJson(m.Cfg, diff, filepath.Join(m.Cfg.ArtifactsDir, "snapshot_diffs"), step)
+	if err != nil {
+		return diff, errors.Wrap(err, ErrSnapshotDiff)
+	}
+	L.Debug().Str("Path", path).Msg("Saved contract state diff")
+
+	return diff, nil
+}
+
+func formatGetterValue(value interface{}, errMsg string) string {
+	if errMsg != "" {
+		return fmt.Sprintf("<error: %s>", errMsg)
+	}
+
+	return formatSummaryValue(value)
+}
+
+// snapshotGetters returns argument-less view and pure functions sorted by name
+func snapshotGetters(contractABI *abi.ABI) []abi.Method {
+	getters := make([]abi.Method, 0)
+	for _, method := range contractABI.Methods {
+		if len(method.Inputs) == 0 && len(method.Outputs) > 0 && method.IsConstant() {
+			getters = append(getters, method)
+		}
+	}
+	sort.Slice(getters, func(i, j int) bool {
+		return getters[i].Name < getters[j].Name
+	})
+
+	return getters
+}
+
+func snapshotValue(getter abi.Method, data []byte) (interface{}, error) {
+	values, err := getter.Outputs.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 1 {
+		return values[0], nil
+	}
+
+	outputs := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := getter.Outputs[i].Name
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		outputs[name] = value
+	}
+
+	return outputs, nil
+}
@@ -1,11 +1,14 @@
 package seth
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/pkg/errors"
@@ -15,13 +18,18 @@ const (
 	ErrOpenABIFile = "failed to open ABI file"
 	ErrParseABI    = "failed to parse ABI file"
 	ErrOpenBINFile = "failed to open BIN file"
+	ErrNoABIEvent  = "no ABI event found"
 )
 
 // ContractStore contains all ABIs that are used in decoding. It might also contain contract bytecode for deployment
 type ContractStore struct {
 	ABIs ABIStore
 	BINs map[string][]byte
-	mu   *sync.RWMutex
+	// StorageLayouts are used to name storage slots accessed by traced calls, keys are contract names
+	StorageLayouts map[string]*StorageLayout
+	// InterfaceABIs are used to decode calls to contracts, whose own ABI is unknown, see AddInterfaceABI
+	InterfaceABIs ABIStore
+	mu            *sync.RWMutex
 }
 
 type ABIStore map[string]abi.ABI
@@ -72,9 +80,120 @@ func (c *ContractStore) AddBIN(name string, bin []byte) {
 	c.BINs[name] = bin
 }
 
+// GetStorageLayout returns storage layout of contract with given name
+func (c *ContractStore) GetStorageLayout(name string) (*StorageLayout, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	layout, ok := c.StorageLayouts[strings.TrimSuffix(name, ".abi")]
+	return layout, ok
+}
+
+// AddStorageLayout adds storage layout of contract with given name
+func (c *ContractStore) AddStorageLayout(name string, layout *StorageLayout) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.StorageLayouts == nil {
+		c.StorageLayouts = make(map[string]*StorageLayout)
+	}
+	c.StorageLayouts[strings.TrimSuffix(name, ".abi")] = layout
+}
+
+// EncodeCall returns calldata (method selector followed by ABI-encoded arguments) of a call to given method of the contract
+func (c *ContractStore) EncodeCall(contractName, method string, args ...interface{}) ([]byte, error) {
+	contractAbi, ok := c.GetABI(contractName)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s", ErrNoAbiFound, contractName)
+	}
+	if _, ok := contractAbi.Methods[method]; !ok {
+		return nil, fmt.Errorf("%s: %s.%s", ErrNoABIMethod, contractName, method)
+	}
+
+	data, err := contractAbi.Pack(method, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode call to %s.%s", contractName, method)
+	}
+
+	return data, nil
+}
+
+// EventFilter returns filter query matching given event of the contract. Indexed values are matched against event's indexed
+// parameters in the order in which they are declared, nil matches any value and missing trailing values match anything as well.
+// Filter has no addresses and block range, set them if needed.
+func (c *ContractStore) EventFilter(contractName, eventName string, indexed ...interface{}) (ethereum.FilterQuery, error) {
+	contractAbi, ok := c.GetABI(contractName)
+	if !ok {
+		return ethereum.FilterQuery{}, fmt.Errorf("%s: %s", ErrNoAbiFound, contractName)
+	}
+	event, ok := contractAbi.Events[eventName]
+	if !ok {
+		return ethereum.FilterQuery{}, fmt.Errorf("%s: %s.%s", ErrNoABIEvent, contractName, eventName)
+	}
+
+	indexedInputs := make(abi.Arguments, 0)
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexedInputs = append(indexedInputs, input)
+		}
+	}
+	if len(indexed) > len(indexedInputs) {
+		return ethereum.FilterQuery{}, fmt.Errorf("event %s.%s has %d indexed parameters, but %d values were given", contractName, eventName, len(indexedInputs), len(indexed))
+	}
+
+	topics := [][]common.Hash{{event.ID}}
+	for i, value := range indexed {
+		if value == nil {
+			topics = append(topics, nil)
+			continue
+		}
+		topic, err := abi.MakeTopics([]interface{}{value})
+		if err != nil {
+			return ethereum.FilterQuery{}, errors.Wrapf(err, "failed to encode indexed parameter '%s' of event %s.%s", indexedInputs[i].Name, contractName, eventName)
+		}
+		topics = append(topics, topic[0])
+	}
+
+	// trailing wildcards are not needed
+	for len(topics) > 1 && topics[len(topics)-1] == nil {
+		topics = topics[:len(topics)-1]
+	}
+
+	return ethereum.FilterQuery{Topics: topics}, nil
+}
+
+// EventMatch is an event definition found in one of the ABIs from ContractStore
+type EventMatch struct {
+	ABIName string
+	ABI     abi.ABI
+	Event   abi.Event
+}
+
+// FindEventsByTopic returns events with given ID (topic0) from all ABIs in the store. Results are sorted by ABI name, so
+// that in case of collisions the outcome doesn't depend on map iteration order.
+func (c *ContractStore) FindEventsByTopic(topic common.Hash) []EventMatch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []EventMatch
+	for name, a := range c.ABIs {
+		for _, event := range a.Events {
+			if event.ID == topic {
+				matches = append(matches, EventMatch{ABIName: strings.TrimSuffix(name, ".abi"), ABI: a, Event: event})
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ABIName < matches[j].ABIName
+	})
+
+	return matches
+}
+
 // NewContractStore creates a new Contract store
 func NewContractStore(abiPath, binPath string) (*ContractStore, error) {
-	cs := &ContractStore{ABIs: make(ABIStore), BINs: make(map[string][]byte), mu: &sync.RWMutex{}}
+	cs := &ContractStore{ABIs: make(ABIStore), BINs: make(map[string][]byte), StorageLayouts: make(map[string]*StorageLayout), InterfaceABIs: make(ABIStore), mu: &sync.RWMutex{}}
 
 	if abiPath != "" {
 		files, err := os.ReadDir(abiPath)
@@ -96,6 +215,14 @@ func NewContractStore(abiPath, binPath string) (*ContractStore, error) {
 				cs.ABIs[f.Name()] = a
 				foundABI = true
 			}
+			if strings.HasSuffix(f.Name(), StorageLayoutFileSuffix) {
+				layout, err := LoadStorageLayout(filepath.Join(abiPath, f.Name()))
+				if err != nil {
+					return nil, err
+				}
+				cs.StorageLayouts[strings.TrimSuffix(f.Name(), StorageLayoutFileSuffix)] = layout
+				L.Debug().Str("File", f.Name()).Msg("Storage layout file loaded")
+			}
 		}
 		if !foundABI {
 			L.Warn().Msg("No ABI files found")
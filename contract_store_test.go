@@ -1,8 +1,11 @@
 package seth_test
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/pkg/errors"
 	"github.com/smartcontractkit/seth"
 	"github.com/stretchr/testify/require"
@@ -101,3 +104,56 @@ func TestSmokeContractBINStore(t *testing.T) {
 		})
 	}
 }
+
+func TestSmokeContractStoreFindEventsByTopic(t *testing.T) {
+	cs, err := seth.NewContractStore("./contracts/abi", "")
+	require.NoError(t, err, "failed to create contract store")
+
+	topic := crypto.Keccak256Hash([]byte("OneIndexEvent(uint256)"))
+	matches := cs.FindEventsByTopic(topic)
+	require.Len(t, matches, 2, "event should be found in two ABIs")
+	require.Equal(t, "NetworkDebugContract", matches[0].ABIName, "matches should be sorted by ABI name")
+	require.Equal(t, "NetworkDebugSubContract", matches[1].ABIName, "matches should be sorted by ABI name")
+	require.Equal(t, topic, matches[1].Event.ID, "incorrect event")
+
+	require.Empty(t, cs.FindEventsByTopic(crypto.Keccak256Hash([]byte("Missing()"))), "unknown event should not be found")
+}
+
+func TestSmokeContractStoreEncodeCall(t *testing.T) {
+	cs, err := seth.NewContractStore("./contracts/abi", "")
+	require.NoError(t, err, "failed to create contract store")
+
+	data, err := cs.EncodeCall("NetworkDebugContract", "addCounter", big.NewInt(1), big.NewInt(2))
+	require.NoError(t, err, "failed to encode call")
+	require.Equal(t, crypto.Keccak256([]byte("addCounter(int256,int256)"))[:4], data[:4], "incorrect method selector")
+	require.Equal(t, common.LeftPadBytes([]byte{1}, 32), data[4:36], "incorrect first argument")
+	require.Equal(t, common.LeftPadBytes([]byte{2}, 32), data[36:68], "incorrect second argument")
+
+	_, err = cs.EncodeCall("NetworkDebugContract", "missing")
+	require.ErrorContains(t, err, seth.ErrNoABIMethod, "unknown method should not be encoded")
+	_, err = cs.EncodeCall("Missing", "addCounter")
+	require.ErrorContains(t, err, seth.ErrNoAbiFound, "unknown contract should not be encoded")
+	_, err = cs.EncodeCall("NetworkDebugContract", "addCounter", big.NewInt(1))
+	require.Error(t, err, "call with missing arguments should not be encoded")
+}
+
+func TestSmokeContractStoreEventFilter(t *testing.T) {
+	cs, err := seth.NewContractStore("./contracts/abi", "")
+	require.NoError(t, err, "failed to create contract store")
+
+	eventID := crypto.Keccak256Hash([]byte("ThreeIndexEvent(uint256,address,uint256)"))
+	startedBy := common.HexToAddress("0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE")
+
+	filter, err := cs.EventFilter("NetworkDebugContract", "ThreeIndexEvent")
+	require.NoError(t, err, "failed to create event filter")
+	require.Equal(t, [][]common.Hash{{eventID}}, filter.Topics, "filter without indexed values should only match event ID")
+
+	filter, err = cs.EventFilter("NetworkDebugContract", "ThreeIndexEvent", nil, startedBy, nil)
+	require.NoError(t, err, "failed to create event filter")
+	require.Equal(t, [][]common.Hash{{eventID}, nil, {common.BytesToHash(startedBy.Bytes())}}, filter.Topics, "incorrect topics")
+
+	_, err = cs.EventFilter("NetworkDebugContract", "ThreeIndexEvent", big.NewInt(1), startedBy, big.NewInt(2), big.NewInt(3))
+	require.Error(t, err, "filter with too many indexed values should not be created")
+	_, err = cs.EventFilter("NetworkDebugContract", "Missing")
+	require.ErrorContains(t, err, seth.ErrNoABIEvent, "unknown event should not be found")
+}
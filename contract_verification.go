@@ -0,0 +1,235 @@
+package seth
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrExplorerAPIConfig      = "explorer_api_url is required to verify contracts"
+	ErrContractVerification   = "contract verification failed"
+	ErrContractSourceNotFound = "contract source not found in compiler input"
+
+	// DefaultVerificationPollInterval is how often verification status is checked
+	DefaultVerificationPollInterval = 5 * time.Second
+
+	EXPLORER_API_KEY_ENV_VAR = "SETH_EXPLORER_API_KEY"
+)
+
+// ContractVerificationInput describes how a deployed contract was compiled
+type ContractVerificationInput struct {
+	// StandardJSONInput is solc standard JSON input the contract was compiled from
+	StandardJSONInput json.RawMessage
+	// CompilerVersion is the full solc version, e.g. "v0.8.19+commit.7dd6d404"
+	CompilerVersion string
+	// ConstructorArgs are the arguments passed to contract's constructor (e.g. DeploymentData.ConstructorArgs), they are
+	// encoded with contract's ABI from the contract store
+	ConstructorArgs []interface{}
+	// PollInterval is how often verification status is checked, default is DefaultVerificationPollInterval
+	PollInterval time.Duration
+}
+
+// explorerAPIResponse is the response of Etherscan-compatible API, Blockscout uses the same format
+type explorerAPIResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Result  string `json:"result"`
+}
+
+// VerifyContract submits source of deployed contract to Etherscan-compatible block explorer API (Etherscan, Blockscout)
+// configured with network's explorer_api_url and explorer_api_key (or SETH_EXPLORER_API_KEY env var) and waits until
+// it's verified or context is done. Name is either a fully qualified name ("contracts/Counter.sol:Counter") or a contract
+// name, in which case the source file is looked up in compiler input by file name ("Counter.sol"). Contracts that are
+// already verified are not submitted again.
+func (m *Client) VerifyContract(ctx context.Context, address common.Address, name string, input ContractVerificationInput) error {
+	apiURL := m.Cfg.Network.ExplorerAPIURL
+	if apiURL == "" {
+		return errors.New(ErrExplorerAPIConfig)
+	}
+	apiKey := m.Cfg.Network.ExplorerAPIKey
+	if apiKey == "" {
+		apiKey = os.Getenv(EXPLORER_API_KEY_ENV_VAR)
+	}
+	if input.CompilerVersion == "" {
+		return fmt.Errorf("%s: compiler version is required", ErrContractVerification)
+	}
+
+	qualifiedName, err := qualifiedContractName(input.StandardJSONInput, name)
+	if err != nil {
+		return err
+	}
+	constructorArgs, err := m.encodeConstructorArgs(name, input.ConstructorArgs)
+	if err != nil {
+		return err
+	}
+
+	l := L.With().Str("Contract", qualifiedName).Str("Address", address.Hex()).Logger()
+	form := url.Values{
+		"apikey":                {apiKey},
+		"chainid":               {strconv.FormatInt(m.ChainID, 10)},
+		"module":                {"contract"},
+		"action":                {"verifysourcecode"},
+		"contractaddress":       {address.Hex()},
+		"sourceCode":            {string(input.StandardJSONInput)},
+		"codeformat":            {"solidity-standard-json-input"},
+		"contractname":          {qualifiedName},
+		"compilerversion":       {input.CompilerVersion},
+		"constructorArguements": {constructorArgs},
+	}
+	submitted, err := explorerAPIRequest(ctx, http.MethodPost, apiURL, form)
+	if err != nil {
+		return errors.Wrap(err, ErrContractVerification)
+	}
+	if submitted.Status != "1" {
+		if isAlreadyVerified(submitted.Result) {
+			l.Info().Msg("Contract is already verified")
+			return nil
+		}
+		return fmt.Errorf("%s: %s", ErrContractVerification, submitted.Result)
+	}
+	guid := submitted.Result
+	l.Info().Str("GUID", guid).Msg("Submitted contract verification")
+
+	pollInterval := input.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultVerificationPollInterval
+	}
+	status := url.Values{
+		"apikey":  {apiKey},
+		"chainid": {strconv.FormatInt(m.ChainID, 10)},
+		"module":  {"contract"},
+		"action":  {"checkverifystatus"},
+		"guid":    {guid},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s: verification %s is still pending", ErrContractVerification, guid)
+		case <-time.After(pollInterval):
+		}
+
+		checked, err := explorerAPIRequest(ctx, http.MethodGet, apiURL, status)
+		if err != nil {
+			l.Debug().Err(err).Msg("Failed to check verification status, will retry")
+			continue
+		}
+		switch {
+		case checked.Status == "1" || isAlreadyVerified(checked.Result):
+			l.Info().Str("URL", m.AddressExplorerURL(address)).Msg("Contract verified")
+			return nil
+		case strings.Contains(strings.ToLower(checked.Result), "pending"):
+			l.Debug().Str("Status", checked.Result).Msg("Verification is pending")
+		default:
+			return fmt.Errorf("%s: %s", ErrContractVerification, checked.Result)
+		}
+	}
+}
+
+func isAlreadyVerified(result string) bool {
+	return strings.Contains(strings.ToLower(result), "already verified")
+}
+
+// qualifiedContractName returns "path:Name" of the contract. If name isn't qualified, source file named after the contract
+// is looked up in compiler input.
+func qualifiedContractName(standardJSONInput json.RawMessage, name string) (string, error) {
+	var compilerInput struct {
+		Sources map[string]json.RawMessage `json:"sources"`
+	}
+	if err := json.Unmarshal(standardJSONInput, &compilerInput); err != nil {
+		return "", errors.Wrapf(err, "%s: invalid standard JSON input", ErrContractVerification)
+	}
+	if strings.Contains(name, ":") {
+		return name, nil
+	}
+
+	paths := make([]string, 0, len(compilerInput.Sources))
+	for sourcePath := range compilerInput.Sources {
+		if path.Base(sourcePath) == name+".sol" {
+			paths = append(paths, sourcePath)
+		}
+	}
+	switch len(paths) {
+	case 0:
+		return "", fmt.Errorf("%s: no %s.sol, use fully qualified name (path:Name)", ErrContractSourceNotFound, name)
+	case 1:
+		return paths[0] + ":" + name, nil
+	default:
+		sort.Strings(paths)
+		return "", fmt.Errorf("%s: %s.sol is ambiguous (%s), use fully qualified name (path:Name)", ErrContractSourceNotFound, name, strings.Join(paths, ", "))
+	}
+}
+
+// encodeConstructorArgs returns hex-encoded (without 0x prefix) constructor arguments, packed with ABI of the contract
+func (m *Client) encodeConstructorArgs(name string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	if m.ContractStore == nil {
+		return "", fmt.Errorf("%s: contract store is needed to encode constructor arguments", ErrContractVerification)
+	}
+	// ABI is stored under contract name, not under fully qualified one
+	contractABI, ok := m.ContractStore.GetABI(name[strings.LastIndex(name, ":")+1:])
+	if !ok {
+		return "", fmt.Errorf("%s: no ABI of %s to encode constructor arguments", ErrContractVerification, name)
+	}
+	packed, err := contractABI.Pack("", args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s: failed to encode constructor arguments", ErrContractVerification)
+	}
+
+	return hex.EncodeToString(packed), nil
+}
+
+func explorerAPIRequest(ctx context.Context, method, apiURL string, params url.Values) (*explorerAPIResponse, error) {
+	var req *http.Request
+	var err error
+	if method == http.MethodPost {
+		req, err = http.NewRequestWithContext(ctx, method, apiURL, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		separator := "?"
+		if strings.Contains(apiURL, "?") {
+			separator = "&"
+		}
+		req, err = http.NewRequestWithContext(ctx, method, apiURL+separator+params.Encode(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("explorer API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	response := &explorerAPIResponse{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, errors.Wrapf(err, "invalid explorer API response: %s", strings.TrimSpace(string(data)))
+	}
+
+	return response, nil
+}
@@ -0,0 +1,206 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrCostEstimationUnknownContract = "contract not found in contract store"
+	ErrCostEstimationNoBytecode      = "contract bytecode not found in contract store"
+	ErrCostEstimationNoTarget        = "call has neither address nor gas limit, so its gas usage can't be estimated"
+	ErrCostEstimationGas             = "failed to estimate gas"
+)
+
+// PlannedDeployment describes Count deployments of a contract from the Contract Store. If GasLimit is 0, gas usage
+// is estimated by simulating the deployment with Params as constructor arguments.
+type PlannedDeployment struct {
+	ContractName string
+	Params       []interface{}
+	// Value sent to payable constructor
+	Value    *big.Int
+	Count    int
+	GasLimit uint64
+}
+
+// PlannedCall describes Count calls of a method of a contract from the Contract Store. If GasLimit is 0, gas usage is
+// estimated by simulating the call to Address, which means that contract has to be already deployed. For contracts that
+// will only be deployed during the test set GasLimit instead.
+type PlannedCall struct {
+	ContractName string
+	Address      common.Address
+	Method       string
+	Params       []interface{}
+	Value        *big.Int
+	Count        int
+	GasLimit     uint64
+}
+
+// CostPlan is a planned set of operations whose cost should be estimated. Transfers is the number of native token
+// transfers (e.g. for funding ephemeral keys), each of which uses gas configured as transfer_gas_fee.
+type CostPlan struct {
+	Deployments []PlannedDeployment
+	Calls       []PlannedCall
+	Transfers   int
+}
+
+// CostEstimationItem is estimated cost of a single planned operation
+type CostEstimationItem struct {
+	Description string
+	Count       int
+	GasPerTx    uint64
+	Value       *big.Int
+	Cost        *big.Int
+}
+
+// CostEstimation is the estimated cost of a CostPlan. GasPrice is the maximum price per unit of gas (gas fee cap for
+// EIP-1559 networks) at the time of estimation, so TotalCost is an upper bound as long as prices don't rise.
+type CostEstimation struct {
+	GasPrice  *big.Int
+	TotalGas  uint64
+	TotalCost *big.Int
+	Items     []CostEstimationItem
+}
+
+// TotalCostInEther returns total cost in ether (or other native token)
+func (c CostEstimation) TotalCostInEther() *big.Float {
+	return WeiToEther(c.TotalCost)
+}
+
+// EstimateTestCost estimates total native token cost of planned deployments, calls and transfers at current gas prices,
+// so that you can check whether your keys have enough funds before starting a large test run. Gas usage is simulated
+// from the root key, unless gas limit is given explicitly. Value sent with calls and deployments is included in the cost.
+func (m *Client) EstimateTestCost(plan CostPlan) (CostEstimation, error) {
+	estimations := m.CalculateGasEstimations(m.NewDefaultGasEstimationRequest())
+	gasPrice := estimations.GasPrice
+	if m.Cfg.Network.EIP1559DynamicFees {
+		gasPrice = estimations.GasFeeCap
+	}
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
+	}
+
+	estimation := CostEstimation{
+		GasPrice:  gasPrice,
+		TotalCost: big.NewInt(0),
+		Items:     make([]CostEstimationItem, 0, len(plan.Deployments)+len(plan.Calls)+1),
+	}
+
+	addItem := func(description string, count int, gasPerTx uint64, value *big.Int) {
+		if value == nil {
+			value = big.NewInt(0)
+		}
+		cost := new(big.Int).Mul(new(big.Int).SetUint64(gasPerTx), gasPrice)
+		cost.Add(cost, value)
+		cost.Mul(cost, big.NewInt(int64(count)))
+
+		estimation.TotalGas += gasPerTx * uint64(count)
+		estimation.TotalCost.Add(estimation.TotalCost, cost)
+		estimation.Items = append(estimation.Items, CostEstimationItem{
+			Description: description,
+			Count:       count,
+			GasPerTx:    gasPerTx,
+			Value:       value,
+			Cost:        cost,
+		})
+	}
+
+	for _, deployment := range plan.Deployments {
+		gasLimit, err := m.estimateDeploymentGas(deployment)
+		if err != nil {
+			return CostEstimation{}, errors.Wrapf(err, "failed to estimate cost of %s deployment", deployment.ContractName)
+		}
+		addItem(fmt.Sprintf("deployment of %s", deployment.ContractName), deployment.Count, gasLimit, deployment.Value)
+	}
+
+	for _, call := range plan.Calls {
+		gasLimit, err := m.estimateCallGas(call)
+		if err != nil {
+			return CostEstimation{}, errors.Wrapf(err, "failed to estimate cost of %s.%s call", call.ContractName, call.Method)
+		}
+		addItem(fmt.Sprintf("call of %s.%s", call.ContractName, call.Method), call.Count, gasLimit, call.Value)
+	}
+
+	if plan.Transfers > 0 {
+		addItem("transfer", plan.Transfers, uint64(m.Cfg.Network.TransferGasFee), nil)
+	}
+
+	L.Debug().
+		Str("Gas price", estimation.GasPrice.String()).
+		Uint64("Total gas", estimation.TotalGas).
+		Str("Total cost (ETH)", estimation.TotalCostInEther().Text('f', -1)).
+		Msg("Estimated test cost")
+
+	return estimation, nil
+}
+
+func (m *Client) estimateDeploymentGas(deployment PlannedDeployment) (uint64, error) {
+	if deployment.GasLimit > 0 {
+		return deployment.GasLimit, nil
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(deployment.ContractName, ".abi"), ".bin")
+	contractAbi, ok := m.ContractStore.GetABI(name)
+	if !ok {
+		return 0, fmt.Errorf("%s: %s", ErrCostEstimationUnknownContract, name)
+	}
+	bytecode, ok := m.ContractStore.GetBIN(name)
+	if !ok {
+		return 0, fmt.Errorf("%s: %s", ErrCostEstimationNoBytecode, name)
+	}
+
+	packedArgs, err := contractAbi.Pack("", deployment.Params...)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrPackConstructorArgs)
+	}
+
+	return m.estimateGas(ethereum.CallMsg{
+		From:  m.MustGetRootKeyAddress(),
+		Data:  append(append([]byte{}, bytecode...), packedArgs...),
+		Value: deployment.Value,
+	})
+}
+
+func (m *Client) estimateCallGas(call PlannedCall) (uint64, error) {
+	if call.GasLimit > 0 {
+		return call.GasLimit, nil
+	}
+	if call.Address == (common.Address{}) {
+		return 0, errors.New(ErrCostEstimationNoTarget)
+	}
+
+	contractAbi, ok := m.ContractStore.GetABI(strings.TrimSuffix(call.ContractName, ".abi"))
+	if !ok {
+		return 0, fmt.Errorf("%s: %s", ErrCostEstimationUnknownContract, call.ContractName)
+	}
+
+	data, err := contractAbi.Pack(call.Method, call.Params...)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to pack arguments of %s", call.Method)
+	}
+
+	return m.estimateGas(ethereum.CallMsg{
+		From:  m.MustGetRootKeyAddress(),
+		To:    &call.Address,
+		Value: call.Value,
+		Data:  data,
+	})
+}
+
+func (m *Client) estimateGas(msg ethereum.CallMsg) (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	gasLimit, err := m.Client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, errors.Wrap(err, ErrCostEstimationGas)
+	}
+
+	return gasLimit, nil
+}
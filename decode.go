@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -29,6 +30,37 @@ const (
 	WarnNoContractStore = "ContractStore is nil, use seth.NewContractStore(...) to decode transactions"
 )
 
+const (
+	// DecodeWarning_DecodingSkipped means that transaction wasn't decoded, because Contract Store or ABI Finder is missing
+	DecodeWarning_DecodingSkipped = "DECODING_SKIPPED"
+	// DecodeWarning_MissingABI means that no ABI contains called method
+	DecodeWarning_MissingABI = "MISSING_ABI"
+	// DecodeWarning_DecodingFailed means that ABI was found, but transaction input or logs couldn't be decoded with it
+	DecodeWarning_DecodingFailed = "DECODING_FAILED"
+	// DecodeWarning_UndecodedLogs means that some logs were emitted by events that no ABI knows
+	DecodeWarning_UndecodedLogs = "UNDECODED_LOGS"
+	// DecodeWarning_TracingFailed means that transaction should have been traced, but tracing failed
+	DecodeWarning_TracingFailed = "TRACING_FAILED"
+	// DecodeWarning_GasDataUnavailable means that receipt has no effective gas price, so cost of the transaction is unknown
+	DecodeWarning_GasDataUnavailable = "GAS_DATA_UNAVAILABLE"
+	// DecodeWarning_MissingExpectedEvents means that transaction didn't emit expected events (with WARN policy)
+	DecodeWarning_MissingExpectedEvents = "MISSING_EXPECTED_EVENTS"
+	// DecodeWarning_OutOfGas means that transaction reverted, because it used all of its gas limit
+	DecodeWarning_OutOfGas = "OUT_OF_GAS"
+	// DecodeWarning_KeySubstituted means that transaction was sent with a different key than requested, because requested one failed
+	DecodeWarning_KeySubstituted = "KEY_SUBSTITUTED"
+)
+
+// DecodeWarning is a non-fatal issue found while decoding a transaction, which means that decoded data is incomplete
+type DecodeWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (w DecodeWarning) String() string {
+	return fmt.Sprintf("%s: %s", w.Code, w.Message)
+}
+
 // DecodedTransaction decoded transaction
 type DecodedTransaction struct {
 	CommonData
@@ -38,6 +70,27 @@ type DecodedTransaction struct {
 	Transaction *types.Transaction      `json:"transaction,omitempty"`
 	Receipt     *types.Receipt          `json:"receipt,omitempty"`
 	Events      []DecodedTransactionLog `json:"events,omitempty"`
+	Timing      *InclusionTiming        `json:"timing,omitempty"`
+	// Blob is only set for blob (EIP-4844) transactions
+	Blob *DecodedBlobData `json:"blob,omitempty"`
+	// ExplorerURL is block explorer link to the transaction, empty if network has no explorer
+	ExplorerURL string `json:"explorer_url,omitempty"`
+	// Warnings lists non-fatal issues found while decoding and tracing the transaction
+	Warnings []DecodeWarning `json:"warnings,omitempty"`
+}
+
+// HasWarning returns true if decoding produced a warning with given code
+func (d *DecodedTransaction) HasWarning(code string) bool {
+	for _, w := range d.Warnings {
+		if w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DecodedTransaction) addWarning(code, message string) {
+	d.Warnings = append(d.Warnings, DecodeWarning{Code: code, Message: message})
 }
 
 type CommonData struct {
@@ -49,6 +102,9 @@ type CommonData struct {
 	NestingLevel    int                    `json:"nesting_level,omitempty"`
 	ParentSignature string                 `json:"parent_signature,omitempty"`
 	Error           string                 `json:"error,omitempty"`
+	Annotations     map[string]string      `json:"annotations,omitempty"`
+	// Interface is the name of the interface ABI used for decoding, when contract's own ABI is unknown
+	Interface string `json:"interface,omitempty"`
 }
 
 // DecodedCall decoded call
@@ -63,6 +119,14 @@ type DecodedCall struct {
 	Value       int64              `json:"value,omitempty"`
 	GasLimit    uint64             `json:"gas_limit,omitempty"`
 	GasUsed     uint64             `json:"gas_used,omitempty"`
+	// ParentIndex is the index of the parent call in decoded calls of the transaction, nil for the main call and calls whose
+	// parent is unknown. Together with NestingLevel (depth in the call tree) it allows to rebuild the call tree.
+	ParentIndex *int `json:"parent_index,omitempty"`
+	// StorageReads and StorageWrites are only set if storage tracing is enabled, they don't include accesses made by sub calls
+	StorageReads  []StorageAccess `json:"storage_reads,omitempty"`
+	StorageWrites []StorageAccess `json:"storage_writes,omitempty"`
+	// StateAccess are counts of cold and warm accesses made by the call (without sub calls), only set if storage tracing is enabled
+	StateAccess *StateAccessCounts `json:"state_access,omitempty"`
 }
 
 type DecodedCommonLog struct {
@@ -70,6 +134,8 @@ type DecodedCommonLog struct {
 	Address   common.Address         `json:"address"`
 	EventData map[string]interface{} `json:"event_data"`
 	Topics    []string               `json:"topics,omitempty"`
+	// ABIName is the name of the ABI used to decode the log, only set for logs in call traces
+	ABIName string `json:"abi_name,omitempty"`
 }
 
 func getDefaultDecodedCall() *DecodedCall {
@@ -137,12 +203,14 @@ func (m *Client) decodeTransaction(l zerolog.Logger, tx *types.Transaction, rece
 	}
 	if m.ContractStore == nil {
 		L.Warn().Msg(WarnNoContractStore)
+		defaultTxn.addWarning(DecodeWarning_DecodingSkipped, WarnNoContractStore)
 		return defaultTxn, nil
 	}
 
 	sig := txData[:4]
 	if m.ABIFinder == nil {
 		L.Err(errors.New("ABIFInder is nil")).Msg("ABIFinder is required for transaction decoding")
+		defaultTxn.addWarning(DecodeWarning_DecodingSkipped, "ABIFinder is nil, transaction wasn't decoded")
 		return defaultTxn, nil
 	}
 
@@ -153,6 +221,8 @@ func (m *Client) decodeTransaction(l zerolog.Logger, tx *types.Transaction, rece
 		address = UNKNOWN
 	}
 
+	verifyContractMapEntry(m.Client.Client(), m.Cfg.Network.TxnTimeout.Duration(), m.ContractAddressToNameMap, address)
+	resolveClone(m.Client.Client(), m.Cfg.Network.TxnTimeout.Duration(), m.ContractAddressToNameMap, address)
 	abiResult, err := m.ABIFinder.FindABIByMethod(address, sig)
 	if err != nil {
 		return defaultTxn, err
@@ -182,6 +252,7 @@ func (m *Client) decodeTransaction(l zerolog.Logger, tx *types.Transaction, rece
 			Signature: common.Bytes2Hex(abiResult.Method.ID),
 			Method:    abiResult.Method.Sig,
 			Input:     txInput,
+			Interface: abiResult.InterfaceName(),
 		},
 		Index:       txIndex,
 		Receipt:     receipt,
@@ -190,12 +261,35 @@ func (m *Client) decodeTransaction(l zerolog.Logger, tx *types.Transaction, rece
 		Hash:        tx.Hash().String(),
 		Events:      txEvents,
 	}
+	m.Redactor.redactCommonData(abiResult.ContractName(), &ptx.CommonData)
+	for i := range ptx.Events {
+		m.Redactor.redactEvent(&ptx.Events[i].DecodedCommonLog)
+	}
+	if receipt != nil {
+		if undecoded := m.undecodedLogsCount(receipt.Logs); undecoded > 0 {
+			ptx.addWarning(DecodeWarning_UndecodedLogs, fmt.Sprintf("%d of %d logs were emitted by events missing from all ABIs", undecoded, len(receipt.Logs)))
+		}
+	}
 
 	return ptx, nil
 }
 
+// undecodedLogsCount returns the number of logs emitted by events that aren't present in any ABI from the Contract Store
+func (m *Client) undecodedLogsCount(logs []*types.Log) int {
+	undecoded := 0
+	for _, lo := range logs {
+		if len(lo.Topics) == 0 || len(m.ContractStore.FindEventsByTopic(lo.Topics[0])) == 0 {
+			undecoded++
+		}
+	}
+	return undecoded
+}
+
 // printDecodedTXData prints decoded txn data
 func (m *Client) printDecodedTXData(l zerolog.Logger, ptx *DecodedTransaction) {
+	if len(ptx.Annotations) > 0 {
+		l.Debug().Interface("Annotations", ptx.Annotations).Send()
+	}
 	l.Debug().Str("Method signature", ptx.Signature).Send()
 	l.Debug().Str("Method name", ptx.Method).Send()
 	if ptx.Input != nil {
@@ -211,12 +305,19 @@ func (m *Client) printDecodedTXData(l zerolog.Logger, ptx *DecodedTransaction) {
 	}
 }
 
-// DecodeCustomABIErr decodes typed Solidity errors
+// DecodeCustomABIErr decodes typed Solidity errors and built-in Error(string) and Panic(uint256) errors
 func (m *Client) DecodeCustomABIErr(txErr error) (string, error) {
 	cerr, ok := txErr.(rpc.DataError)
 	if !ok {
 		return "", errors.New(ErrRPCJSONCastError)
 	}
+	// built-in errors can be decoded even without any ABIs
+	if data, ok := revertData(txErr); ok {
+		if reason, isBuiltIn := DecodeRevertReason(data); isBuiltIn {
+			L.Trace().Str("Reason", reason).Msg("Revert Reason")
+			return reason, nil
+		}
+	}
 	if m.ContractStore == nil {
 		L.Warn().Msg(WarnNoContractStore)
 		return "", nil
@@ -308,6 +409,17 @@ func (m *Client) callAndGetRevertReason(tx *types.Transaction, rc *types.Receipt
 	}
 	_, plainStringErr := m.Client.CallContract(context.Background(), msg, rc.BlockNumber)
 
+	// if node already included the reason in the message we return it as it is, because callers match on it; otherwise
+	// we decode built-in Error(string) and Panic(uint256) errors from revert data on our own
+	if plainStringErr != nil && strings.HasPrefix(plainStringErr.Error(), "execution reverted: ") {
+		return plainStringErr
+	}
+	if data, ok := revertData(plainStringErr); ok {
+		if reason, isBuiltIn := DecodeRevertReason(data); isBuiltIn {
+			return fmt.Errorf("execution reverted: %s", reason)
+		}
+	}
+
 	decodedABIErrString, err := m.DecodeCustomABIErr(plainStringErr)
 	if err != nil {
 		return err
@@ -0,0 +1,148 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultReceiptsBatchSize is the maximum number of receipts fetched in a single batch RPC call
+	DefaultReceiptsBatchSize = 100
+
+	ErrFetchBlock    = "failed to fetch block"
+	ErrFetchReceipts = "failed to fetch transaction receipts"
+)
+
+type decodeBlockOptions struct {
+	allTransactions bool
+	batchSize       int
+}
+
+// DecodeBlockOpt is an option for DecodeBlock
+type DecodeBlockOpt func(o *decodeBlockOptions)
+
+// WithAllTransactions makes DecodeBlock decode all transactions in the block, not only the ones sent by client's keys
+func WithAllTransactions() DecodeBlockOpt {
+	return func(o *decodeBlockOptions) {
+		o.allTransactions = true
+	}
+}
+
+// WithReceiptsBatchSize sets the maximum number of receipts fetched in a single batch RPC call
+func WithReceiptsBatchSize(size int) DecodeBlockOpt {
+	return func(o *decodeBlockOptions) {
+		if size > 0 {
+			o.batchSize = size
+		}
+	}
+}
+
+// DecodeBlock fetches block with transactions, prefetches all their receipts using batch RPC calls and decodes every transaction
+// sent by one of client's keys (or all transactions, if WithAllTransactions() option is used). It doesn't wait for anything, retry
+// or trace, so it's suitable for post-hoc analysis of historical blocks (e.g. load test windows). Transactions that couldn't be
+// decoded (e.g. because there's no ABI for them) are still returned with receipt and transaction data.
+func (m *Client) DecodeBlock(blockNumber *big.Int, opts ...DecodeBlockOpt) ([]*DecodedTransaction, error) {
+	o := &decodeBlockOptions{batchSize: DefaultReceiptsBatchSize}
+	if profile := m.Cfg.ProviderProfile(); profile != nil && profile.BatchSize > 0 {
+		o.batchSize = profile.BatchSize
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	block, err := m.Client.BlockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrFetchBlock)
+	}
+
+	addrs, _ := m.keys()
+	ourKeys := make(map[common.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		ourKeys[addr] = struct{}{}
+	}
+
+	signer := types.LatestSignerForChainID(big.NewInt(m.ChainID))
+	txs := make([]*types.Transaction, 0)
+	for _, tx := range block.Transactions() {
+		if !o.allTransactions {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				L.Debug().
+					Err(err).
+					Str("Transaction", tx.Hash().Hex()).
+					Msg("Failed to recover transaction sender. Skipping it")
+				continue
+			}
+			if _, ok := ourKeys[from]; !ok {
+				continue
+			}
+		}
+		txs = append(txs, tx)
+	}
+
+	receipts, err := m.fetchReceipts(ctx, txs, o.batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedTxs := make([]*DecodedTransaction, 0, len(txs))
+	for i, tx := range txs {
+		l := L.With().Str("Transaction", tx.Hash().Hex()).Logger()
+		decoded, decodeErr := m.decodeTransaction(l, tx, receipts[i])
+		if decodeErr != nil {
+			l.Debug().
+				Err(decodeErr).
+				Msg("Failed to decode transaction from block")
+		}
+		decodedTxs = append(decodedTxs, decoded)
+	}
+
+	L.Debug().
+		Str("Block", block.Number().String()).
+		Int("Transactions in block", len(block.Transactions())).
+		Int("Decoded transactions", len(decodedTxs)).
+		Msg("Decoded block")
+
+	return decodedTxs, nil
+}
+
+// fetchReceipts fetches receipts of given transactions using batch RPC calls of at most batchSize elements
+func (m *Client) fetchReceipts(ctx context.Context, txs []*types.Transaction, batchSize int) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(txs))
+	for start := 0; start < len(txs); start += batchSize {
+		end := start + batchSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		batch := make([]rpc.BatchElem, 0, end-start)
+		for i := start; i < end; i++ {
+			receipts[i] = new(types.Receipt)
+			batch = append(batch, rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{txs[i].Hash()},
+				Result: receipts[i],
+			})
+		}
+
+		if err := m.Client.Client().BatchCallContext(ctx, batch); err != nil {
+			return nil, errors.Wrap(err, ErrFetchReceipts)
+		}
+
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, errors.Wrapf(elem.Error, "%s: %v", ErrFetchReceipts, elem.Args[0])
+			}
+		}
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,235 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrDecodeInto       = "failed to convert decoded values"
+	ErrDecodeIntoTarget = "target must be a non-nil pointer to a struct"
+)
+
+var bigIntType = reflect.TypeOf(big.NewInt(0))
+
+// DecodeInto copies decoded values (e.g. decoded.Input, decoded.Output or event's EventData) into fields of target struct,
+// so that they can be asserted against typed values instead of anonymous structs created by ABI decoder:
+//
+//	var input struct {
+//		Data struct {
+//			Name   string
+//			Values []*big.Int
+//		}
+//	}
+//	err := seth.DecodeInto(decoded.Input, &input)
+//
+// Values are matched with fields by json tag, field name or ABI name converted to Go field name (e.g. "_amount" matches
+// "Amount"). Nested structs, slices, arrays and pointers are converted recursively. Numbers can be converted between all
+// integer types and *big.Int as long as they don't overflow. Values without matching fields are ignored and fields without
+// matching values are left untouched.
+func DecodeInto(values map[string]interface{}, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return errors.Wrap(errors.New(ErrDecodeIntoTarget), ErrDecodeInto)
+	}
+
+	source := make(map[string]reflect.Value, len(values))
+	for key, value := range values {
+		source[key] = reflect.ValueOf(value)
+	}
+	if err := convertFields(source, targetValue.Elem(), ""); err != nil {
+		return errors.Wrap(err, ErrDecodeInto)
+	}
+
+	return nil
+}
+
+func convertFields(source map[string]reflect.Value, target reflect.Value, path string) error {
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value, key, ok := lookupField(source, field)
+		if !ok {
+			continue
+		}
+		if err := convertValue(value, target.Field(i), joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupField returns source value matching the field by json tag, exact field name, case-insensitive field name
+// or ABI name converted to Go field name (in that order)
+func lookupField(source map[string]reflect.Value, field reflect.StructField) (reflect.Value, string, bool) {
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+		if tag == "-" {
+			return reflect.Value{}, "", false
+		}
+		value, ok := source[tag]
+		return value, tag, ok
+	}
+	if value, ok := source[field.Name]; ok {
+		return value, field.Name, true
+	}
+	for key, value := range source {
+		if strings.EqualFold(key, field.Name) || abi.ToCamelCase(key) == field.Name {
+			return value, key, true
+		}
+	}
+
+	return reflect.Value{}, "", false
+}
+
+func convertValue(source, target reflect.Value, path string) error {
+	// unwrap interfaces and pointers (other than *big.Int) of the source
+	for source.IsValid() && (source.Kind() == reflect.Interface || (source.Kind() == reflect.Ptr && source.Type() != bigIntType)) {
+		if source.IsNil() {
+			return nil
+		}
+		source = source.Elem()
+	}
+	if !source.IsValid() {
+		return nil
+	}
+
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+
+	switch {
+	case target.Kind() == reflect.Interface && target.NumMethod() == 0:
+		target.Set(source)
+		return nil
+	case target.Type() == bigIntType:
+		return convertToBigInt(source, target, path)
+	case source.Type() == bigIntType:
+		return convertFromBigInt(source.Interface().(*big.Int), target, path)
+	case target.Kind() == reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return convertValue(source, target.Elem(), path)
+	case isInteger(source.Kind()) && isInteger(target.Kind()):
+		return convertFromBigInt(integerToBig(source), target, path)
+	case target.Kind() == reflect.Struct && source.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Value, source.NumField())
+		for i := 0; i < source.NumField(); i++ {
+			field := source.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fields[field.Name] = source.Field(i)
+			if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+				fields[tag] = source.Field(i)
+			}
+		}
+		return convertFields(fields, target, path)
+	case target.Kind() == reflect.Struct && source.Kind() == reflect.Map && source.Type().Key().Kind() == reflect.String:
+		fields := make(map[string]reflect.Value, source.Len())
+		iter := source.MapRange()
+		for iter.Next() {
+			fields[iter.Key().String()] = iter.Value()
+		}
+		return convertFields(fields, target, path)
+	case target.Kind() == reflect.Slice && (source.Kind() == reflect.Slice || source.Kind() == reflect.Array):
+		target.Set(reflect.MakeSlice(target.Type(), source.Len(), source.Len()))
+		return convertElements(source, target, path)
+	case target.Kind() == reflect.Array && (source.Kind() == reflect.Slice || source.Kind() == reflect.Array):
+		if source.Len() != target.Len() {
+			return fmt.Errorf("%s: can't convert %d elements into array of length %d", pathOrRoot(path), source.Len(), target.Len())
+		}
+		return convertElements(source, target, path)
+	case source.Kind() == target.Kind() && source.Type().ConvertibleTo(target.Type()):
+		// e.g. named string or bool types
+		target.Set(source.Convert(target.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("%s: can't convert %s into %s", pathOrRoot(path), source.Type(), target.Type())
+}
+
+func convertElements(source, target reflect.Value, path string) error {
+	for i := 0; i < source.Len(); i++ {
+		if err := convertValue(source.Index(i), target.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func convertToBigInt(source, target reflect.Value, path string) error {
+	if !isInteger(source.Kind()) {
+		return fmt.Errorf("%s: can't convert %s into %s", pathOrRoot(path), source.Type(), target.Type())
+	}
+	target.Set(reflect.ValueOf(integerToBig(source)))
+
+	return nil
+}
+
+func convertFromBigInt(value *big.Int, target reflect.Value, path string) error {
+	if value == nil {
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !value.IsInt64() || target.OverflowInt(value.Int64()) {
+			return fmt.Errorf("%s: %s overflows %s", pathOrRoot(path), value.String(), target.Type())
+		}
+		target.SetInt(value.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !value.IsUint64() || target.OverflowUint(value.Uint64()) {
+			return fmt.Errorf("%s: %s overflows %s", pathOrRoot(path), value.String(), target.Type())
+		}
+		target.SetUint(value.Uint64())
+	default:
+		return fmt.Errorf("%s: can't convert %s into %s", pathOrRoot(path), bigIntType, target.Type())
+	}
+
+	return nil
+}
+
+func integerToBig(value reflect.Value) *big.Int {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(value.Int())
+	default:
+		return new(big.Int).SetUint64(value.Uint())
+	}
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "value"
+	}
+
+	return path
+}
@@ -0,0 +1,168 @@
+package seth
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// String returns compact, human-readable summary of decoded transaction, see PrettyPrint()
+func (d *DecodedTransaction) String() string {
+	var b strings.Builder
+	_ = d.PrettyPrint(&b)
+	return b.String()
+}
+
+// PrettyPrint writes compact, human-readable summary of decoded transaction: status, called method with arguments, emitted
+// events, gas and transaction fee (in native currency of the chain), e.g.
+//
+//	Transaction 0x5e4f...9a1c succeeded in block 1234
+//	  Call: transfer(amount=5, to=0x9A9f2CCfdE556A7E9Ff0848998Aa4a0CFD8863AE) [a9059cbb]
+//	  Event: Transfer(from=0x..., to=0x..., value=5)
+//	  Gas: 51234 used / 60000 limit, price 1500000000 wei
+//	  Cost: 76851000000000 wei / 0.000076851 ETH
+func (d *DecodedTransaction) PrettyPrint(w io.Writer) error {
+	lines := []string{d.summaryHeader()}
+
+	if len(d.Annotations) > 0 {
+		lines = append(lines, "  Annotations: "+formatSummaryArgs(stringMapToInterfaceMap(d.Annotations)))
+	}
+	if d.Method != "" && d.Method != UNKNOWN {
+		lines = append(lines, fmt.Sprintf("  Call: %s(%s) [%s]", d.Method, formatSummaryArgs(d.Input), d.Signature))
+	} else if d.Signature != "" && d.Signature != UNKNOWN {
+		lines = append(lines, fmt.Sprintf("  Call: unknown method [%s]", d.Signature))
+	}
+	if len(d.Output) > 0 {
+		lines = append(lines, "  Output: "+formatSummaryArgs(d.Output))
+	}
+	for _, e := range d.Events {
+		name := e.Signature
+		if i := strings.Index(name, "("); i != -1 {
+			name = name[:i]
+		}
+		lines = append(lines, fmt.Sprintf("  Event: %s(%s)", name, formatSummaryArgs(e.EventData)))
+	}
+	if gas := d.summaryGas(); gas != "" {
+		lines = append(lines, "  Gas: "+gas)
+	}
+	if cost := d.summaryCost(); cost != "" {
+		lines = append(lines, "  Cost: "+cost)
+	}
+	if d.ExplorerURL != "" {
+		lines = append(lines, "  Explorer: "+d.ExplorerURL)
+	}
+	if d.Error != "" {
+		lines = append(lines, "  Error: "+d.Error)
+	}
+	for _, warning := range d.Warnings {
+		lines = append(lines, "  Warning: "+warning.String())
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func (d *DecodedTransaction) summaryHeader() string {
+	header := "Transaction " + d.Hash
+	if d.Receipt == nil {
+		return header
+	}
+
+	status := "succeeded"
+	if d.Receipt.Status != types.ReceiptStatusSuccessful {
+		status = "reverted"
+	}
+	if d.Receipt.BlockNumber != nil {
+		return fmt.Sprintf("%s %s in block %s", header, status, d.Receipt.BlockNumber.String())
+	}
+
+	return header + " " + status
+}
+
+func (d *DecodedTransaction) summaryGas() string {
+	var parts []string
+	if d.Receipt != nil {
+		parts = append(parts, fmt.Sprintf("%d used", d.Receipt.GasUsed))
+	}
+	if d.Transaction != nil {
+		parts = append(parts, fmt.Sprintf("%d limit", d.Transaction.Gas()))
+	}
+	gas := strings.Join(parts, " / ")
+	if d.Receipt != nil && d.Receipt.EffectiveGasPrice != nil {
+		gas += fmt.Sprintf(", price %s wei", d.Receipt.EffectiveGasPrice.String())
+	}
+
+	return gas
+}
+
+func (d *DecodedTransaction) summaryCost() string {
+	if d.Receipt == nil || d.Receipt.EffectiveGasPrice == nil {
+		return ""
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(d.Receipt.GasUsed), d.Receipt.EffectiveGasPrice)
+	currency := DefaultNativeCurrency
+	if d.Transaction != nil && d.Transaction.ChainId() != nil {
+		currency = NativeCurrencyForChain(d.Transaction.ChainId().Int64())
+	}
+
+	return currency.Format(cost)
+}
+
+// formatSummaryArgs formats arguments as "name=value" pairs sorted by name
+func formatSummaryArgs(args map[string]interface{}) string {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, formatSummaryValue(args[name])))
+	}
+
+	return strings.Join(pairs, ", ")
+}
+
+func formatSummaryValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "<nil>"
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case [32]byte:
+		return hexutil.Encode(value[:])
+	case []byte:
+		return hexutil.Encode(value)
+	case string:
+		return fmt.Sprintf("%q", value)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func stringMapToInterfaceMap(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+// printTxSummary prints summary of decoded transaction at Info level, if it's enabled in config
+func (m *Client) printTxSummary(decoded *DecodedTransaction) {
+	if decoded == nil || !m.Cfg.PrintTxSummary {
+		return
+	}
+	L.Info().Msg(strings.TrimSuffix(decoded.String(), "\n"))
+}
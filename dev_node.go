@@ -0,0 +1,83 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrTimeTravel = "failed to move time on dev node"
+
+	// evmIncreaseTimeMethod moves time of the next block forward by given number of seconds (Anvil, Hardhat, Ganache)
+	evmIncreaseTimeMethod = "evm_increaseTime"
+	// evmSetNextBlockTimestampMethod sets exact timestamp of the next block (Anvil, Hardhat, Ganache)
+	evmSetNextBlockTimestampMethod = "evm_setNextBlockTimestamp"
+)
+
+// DevNode has helpers for dev nodes (Anvil, Hardhat), which make it possible to test time-dependent contracts (vesting,
+// auctions, timeouts) without waiting or calling node's RPC methods directly. It's only available on simulated networks
+// (Client.DevNode is nil otherwise). Geth in dev mode doesn't support these methods.
+type DevNode struct {
+	client *Client
+}
+
+// AdvanceTime moves time forward by given duration (rounded down to seconds) and mines a block, so that the next call or
+// transaction sees the new time. It returns timestamp of the mined block.
+func (d *DevNode) AdvanceTime(duration time.Duration) (time.Time, error) {
+	seconds := int64(duration / time.Second)
+	if seconds < 1 {
+		return time.Time{}, fmt.Errorf("%s: time can only be advanced by at least 1 second, got %s", ErrTimeTravel, duration)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	var result interface{}
+	if err := d.client.Client.Client().CallContext(ctx, &result, evmIncreaseTimeMethod, seconds); err != nil {
+		return time.Time{}, errors.Wrapf(err, "%s: %s failed", ErrTimeTravel, evmIncreaseTimeMethod)
+	}
+
+	return d.mine(ctx)
+}
+
+// SetNextBlockTimestamp sets timestamp of the next block and mines it, so that the next call or transaction sees the new
+// time. Timestamp has to be later than the one of the latest block. It returns timestamp of the mined block.
+func (d *DevNode) SetNextBlockTimestamp(timestamp time.Time) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	var result interface{}
+	if err := d.client.Client.Client().CallContext(ctx, &result, evmSetNextBlockTimestampMethod, timestamp.Unix()); err != nil {
+		return time.Time{}, errors.Wrapf(err, "%s: %s failed", ErrTimeTravel, evmSetNextBlockTimestampMethod)
+	}
+
+	return d.mine(ctx)
+}
+
+// Mine mines a single block and returns its timestamp
+func (d *DevNode) Mine() (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	return d.mine(ctx)
+}
+
+func (d *DevNode) mine(ctx context.Context) (time.Time, error) {
+	var result interface{}
+	if err := d.client.Client.Client().CallContext(ctx, &result, evmMineMethod); err != nil {
+		return time.Time{}, errors.Wrapf(err, "%s: %s failed", ErrTimeTravel, evmMineMethod)
+	}
+	header, err := d.client.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to get mined block")
+	}
+	minedAt := time.Unix(int64(header.Time), 0)
+
+	L.Debug().
+		Uint64("Block", header.Number.Uint64()).
+		Str("Timestamp", minedAt.UTC().String()).
+		Msg("Mined a block on dev node")
+
+	return minedAt, nil
+}
@@ -0,0 +1,100 @@
+package seth
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Taxonomy of failures. Errors returned by Decode, DeployContract, WaitMined and other methods that send or wait for
+// transactions wrap one of these (when the failure falls into one of the categories), so that callers can branch with
+// errors.Is instead of checking error messages. Error messages stay the same.
+var (
+	// ErrTimeout means that transaction wasn't mined (or RPC didn't respond) in time
+	ErrTimeout = errors.New("timeout")
+	// ErrDropped means that transaction disappeared from the network without being mined
+	ErrDropped = errors.New("transaction dropped")
+	// ErrReverted means that transaction (or its gas estimation) was reverted
+	ErrReverted = errors.New("transaction reverted")
+	// ErrNonceConflict means that nonce of the transaction was already used or is too far ahead
+	ErrNonceConflict = errors.New("nonce conflict")
+	// ErrRPCUnavailable means that RPC node couldn't be reached
+	ErrRPCUnavailable = errors.New("rpc unavailable")
+	// ErrInsufficientFunds means that sender can't pay for the transaction
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// taxonomy lists all taxonomy errors, in the order in which they are matched
+var taxonomy = []error{ErrInsufficientFunds, ErrNonceConflict, ErrReverted, ErrRPCUnavailable, ErrDropped, ErrTimeout}
+
+// taxonomyPatterns are lowercase fragments of error messages (returned by nodes, geth client or Seth itself) that identify
+// taxonomy errors, they are used only for errors that don't wrap a known error type
+var taxonomyPatterns = map[error][]string{
+	ErrInsufficientFunds: {"insufficient funds", "insufficient balance"},
+	ErrNonceConflict:     {"nonce too low", "nonce too high", "replacement transaction underpriced", "invalid nonce"},
+	ErrReverted:          {"execution reverted", "transaction was reverted", "reverted"},
+	ErrRPCUnavailable:    {ErrRPCConnectionRefused, "no such host", "connection reset by peer", "503 service unavailable", "502 bad gateway"},
+	ErrTimeout:           {"context deadline exceeded", "not mined within", "timed out", "timeout"},
+}
+
+// kindError attaches taxonomy error to an error without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.err, e.kind}
+}
+
+// withKind makes err match given taxonomy error with errors.Is, nil stays nil
+func withKind(kind, err error) error {
+	if err == nil || errors.Is(err, kind) {
+		return err
+	}
+
+	return &kindError{kind: kind, err: err}
+}
+
+// ClassifyError makes err match the taxonomy error it falls into (e.g. ErrInsufficientFunds) with errors.Is. Errors that
+// already match one of taxonomy errors or don't fall into any category are returned as they are. Use it for errors returned
+// by calls made outside of Seth, e.g. directly by geth bindings, Seth already classifies errors it returns.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, kind := range taxonomy {
+		if errors.Is(err, kind) {
+			return err
+		}
+	}
+
+	var balanceErr *InsufficientBalanceError
+	var expiredErr *TxExpiredError
+	var netErr *net.OpError
+	switch {
+	case errors.As(err, &balanceErr):
+		return withKind(ErrInsufficientFunds, err)
+	case errors.As(err, &expiredErr), errors.Is(err, context.DeadlineExceeded):
+		return withKind(ErrTimeout, err)
+	case errors.As(err, &netErr):
+		return withKind(ErrRPCUnavailable, err)
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, kind := range taxonomy {
+		for _, pattern := range taxonomyPatterns[kind] {
+			if strings.Contains(msg, pattern) {
+				return withKind(kind, err)
+			}
+		}
+	}
+
+	return err
+}
@@ -1,7 +1,8 @@
 package examples_wasp
 
 import (
-	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/smartcontractkit/seth"
 	"github.com/smartcontractkit/wasp"
 	"github.com/stretchr/testify/require"
@@ -10,28 +11,6 @@ import (
 	"time"
 )
 
-type ExampleGun struct {
-	client *seth.Client
-	Data   []string
-}
-
-func NewExampleHTTPGun(client *seth.Client) *ExampleGun {
-	return &ExampleGun{
-		client: client,
-		Data:   make([]string, 0),
-	}
-}
-
-func (m *ExampleGun) Call(l *wasp.Generator) *wasp.Response {
-	_, err := m.client.Decode(
-		TestEnv.DebugContract.AddCounter(m.client.NewTXKeyOpts(m.client.AnySyncedKey()), big.NewInt(0), big.NewInt(1)),
-	)
-	if err != nil {
-		return &wasp.Response{Error: errors.Join(err).Error()}
-	}
-	return &wasp.Response{}
-}
-
 func TestWithWasp(t *testing.T) {
 	t.Setenv(seth.ROOT_PRIVATE_KEY_ENV_VAR, "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80")
 	t.Setenv(seth.CONFIG_FILE_ENV_VAR, "seth.toml")
@@ -53,7 +32,9 @@ func TestWithWasp(t *testing.T) {
 			wasp.Plain(10, 30*time.Second),
 			wasp.Plain(2, 30*time.Second),
 		),
-		Gun:        NewExampleHTTPGun(c),
+		Gun: NewSethGun(c, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return TestEnv.DebugContract.AddCounter(opts, big.NewInt(0), big.NewInt(1))
+		}),
 		Labels:     labels,
 		LokiConfig: wasp.NewEnvLokiConfig(),
 	})
@@ -0,0 +1,102 @@
+package examples_wasp
+
+import (
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/wasp"
+)
+
+// CallMetrics are metrics of a single transaction sent to WASP in Response.Data
+type CallMetrics struct {
+	KeyNum            int    `json:"key_num"`
+	TxHash            string `json:"tx_hash,omitempty"`
+	GasUsed           uint64 `json:"gas_used"`
+	EffectiveGasPrice string `json:"effective_gas_price,omitempty"`
+}
+
+// SethGun is a WASP gun that sends one transaction per call using seth.LoadGun, which selects the key, waits for
+// the transaction with Decode and measures latency and gas used
+type SethGun struct {
+	*seth.LoadGun
+}
+
+// NewSethGun creates a gun that sends transactions created by callFn
+func NewSethGun(client *seth.Client, callFn seth.LoadCallFn) *SethGun {
+	return &SethGun{LoadGun: seth.NewLoadGun(client, callFn)}
+}
+
+// Call implements wasp.Gun
+func (g *SethGun) Call(_ *wasp.Generator) *wasp.Response {
+	return toWaspResponse(g.LoadGun.Call())
+}
+
+// SethVU is a WASP virtual user that sends one transaction per iteration using seth.LoadGun. All clones share the same
+// LoadGun, so its Stats() cover the whole test.
+type SethVU struct {
+	*wasp.VUControl
+	gun *seth.LoadGun
+}
+
+// NewSethVU creates a virtual user that sends transactions created by callFn
+func NewSethVU(client *seth.Client, callFn seth.LoadCallFn) *SethVU {
+	return &SethVU{
+		VUControl: wasp.NewVUControl(),
+		gun:       seth.NewLoadGun(client, callFn),
+	}
+}
+
+// Clone implements wasp.VirtualUser
+func (v *SethVU) Clone(_ *wasp.Generator) wasp.VirtualUser {
+	return &SethVU{
+		VUControl: wasp.NewVUControl(),
+		gun:       v.gun,
+	}
+}
+
+// Setup implements wasp.VirtualUser
+func (v *SethVU) Setup(_ *wasp.Generator) error {
+	return nil
+}
+
+// Teardown implements wasp.VirtualUser
+func (v *SethVU) Teardown(_ *wasp.Generator) error {
+	return nil
+}
+
+// Call implements wasp.VirtualUser
+func (v *SethVU) Call(l *wasp.Generator) {
+	l.ResponsesChan <- toWaspResponse(v.gun.Call())
+}
+
+// Stats returns metrics aggregated over calls of this virtual user and all its clones
+func (v *SethVU) Stats() seth.LoadStats {
+	return v.gun.Stats()
+}
+
+func toWaspResponse(result *seth.LoadCallResult) *wasp.Response {
+	startedAt, finishedAt := result.StartedAt, result.FinishedAt
+	metrics := CallMetrics{
+		KeyNum:  result.KeyNum,
+		GasUsed: result.GasUsed,
+	}
+	if result.Decoded != nil {
+		metrics.TxHash = result.Decoded.Hash
+	}
+	if result.EffectiveGasPrice != nil {
+		metrics.EffectiveGasPrice = result.EffectiveGasPrice.String()
+	}
+
+	response := &wasp.Response{
+		Duration:   result.Latency,
+		StartedAt:  &startedAt,
+		FinishedAt: &finishedAt,
+		Data:       metrics,
+	}
+	if result.Err != nil {
+		response.Failed = true
+		response.Error = result.Err.Error()
+		// no key got synced in time
+		response.Timeout = result.KeyNum == seth.TimeoutKeyNum
+	}
+
+	return response
+}
@@ -0,0 +1,169 @@
+package seth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	ExpectedEventsPolicy_Warn = "WARN"
+	ExpectedEventsPolicy_Fail = "FAIL"
+
+	ErrMissingExpectedEvents = "transaction didn't emit expected events"
+)
+
+// ExpectedEventsConfig maps methods to events they are expected to emit. Keys can be method names ("transfer"), method
+// signatures ("transfer(address,uint256)") or either of them prefixed with contract name ("LinkToken.transfer"). Events
+// can be given as names ("Transfer") or signatures ("Transfer(address,address,uint256)").
+type ExpectedEventsConfig struct {
+	// Policy decides what happens when expected events are missing: WARN only logs them, FAIL makes Decode return an error
+	Policy  string              `toml:"policy"`
+	Methods map[string][]string `toml:"methods"`
+}
+
+// ExpectedEvents is a registry of events that successful transactions are expected to emit. It's used to catch silent failures
+// of contracts that swallow errors instead of reverting.
+type ExpectedEvents struct {
+	mu      *sync.RWMutex
+	policy  string
+	methods map[string][]string
+}
+
+// NewExpectedEvents creates a registry of expected events from config, which can be nil
+func NewExpectedEvents(cfg *ExpectedEventsConfig) *ExpectedEvents {
+	e := &ExpectedEvents{
+		mu:      &sync.RWMutex{},
+		policy:  ExpectedEventsPolicy_Warn,
+		methods: map[string][]string{},
+	}
+	if cfg == nil {
+		return e
+	}
+
+	if cfg.Policy != "" {
+		e.policy = strings.ToUpper(cfg.Policy)
+	}
+	for method, events := range cfg.Methods {
+		e.Expect(method, events...)
+	}
+
+	return e
+}
+
+// Expect registers events that given method is expected to emit. See ExpectedEventsConfig for supported formats.
+func (e *ExpectedEvents) Expect(method string, events ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.methods[method] = append(e.methods[method], events...)
+}
+
+// Policy returns what happens when expected events are missing
+func (e *ExpectedEvents) Policy() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policy
+}
+
+// Missing returns expected events of given method called on given contract, that are not present among emitted events.
+// Emitted events should contain both names and signatures of events.
+func (e *ExpectedEvents) Missing(contractName, methodSig string, emitted map[string]bool) []string {
+	methodName := methodSig
+	if i := strings.Index(methodSig, "("); i >= 0 {
+		methodName = methodSig[:i]
+	}
+
+	keys := []string{methodName, methodSig}
+	if contractName != "" {
+		keys = append(keys, contractName+"."+methodName, contractName+"."+methodSig)
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	seen := map[string]bool{}
+	missing := make([]string, 0)
+	for _, key := range keys {
+		for _, event := range e.methods[key] {
+			if seen[event] || emitted[event] {
+				continue
+			}
+			seen[event] = true
+			missing = append(missing, event)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
+
+func validateExpectedEvents(cfg *ExpectedEventsConfig) error {
+	if cfg == nil || cfg.Policy == "" {
+		return nil
+	}
+
+	switch strings.ToUpper(cfg.Policy) {
+	case ExpectedEventsPolicy_Warn:
+	case ExpectedEventsPolicy_Fail:
+	default:
+		return fmt.Errorf("expected events policy must be one of: %s, %s", ExpectedEventsPolicy_Warn, ExpectedEventsPolicy_Fail)
+	}
+
+	return nil
+}
+
+// ExpectEvents registers events that given method is expected to emit. When a successful transaction calling that method
+// doesn't emit them, Decode logs a warning or, with FAIL policy, returns an error. See ExpectedEventsConfig for supported formats.
+func (m *Client) ExpectEvents(method string, events ...string) {
+	if m.ExpectedEvents == nil {
+		m.ExpectedEvents = NewExpectedEvents(m.Cfg.ExpectedEvents)
+	}
+	m.ExpectedEvents.Expect(method, events...)
+}
+
+// emittedEventNames returns names and signatures of all events emitted in the receipt, that are known to any ABI
+func (m *Client) emittedEventNames(decoded *DecodedTransaction) map[string]bool {
+	emitted := map[string]bool{}
+	for _, event := range decoded.Events {
+		emitted[event.Signature] = true
+		if i := strings.Index(event.Signature, "("); i >= 0 {
+			emitted[event.Signature[:i]] = true
+		}
+	}
+
+	if m.ContractStore == nil || decoded.Receipt == nil {
+		return emitted
+	}
+
+	// events might be emitted by other contracts, whose ABIs weren't used to decode the transaction
+	for _, lo := range decoded.Receipt.Logs {
+		if len(lo.Topics) == 0 {
+			continue
+		}
+		for _, match := range m.ContractStore.FindEventsByTopic(lo.Topics[0]) {
+			emitted[match.Event.Name] = true
+			emitted[match.Event.Sig] = true
+		}
+	}
+
+	return emitted
+}
+
+// checkExpectedEvents returns an error listing expected events missing from a successful transaction
+func (m *Client) checkExpectedEvents(decoded *DecodedTransaction) error {
+	if m.ExpectedEvents == nil || decoded == nil || decoded.Method == "" || decoded.Receipt == nil ||
+		decoded.Receipt.Status != types.ReceiptStatusSuccessful || decoded.Transaction == nil || decoded.Transaction.To() == nil {
+		return nil
+	}
+
+	contractName := m.ContractAddressToNameMap.GetContractName(decoded.Transaction.To().Hex())
+	missing := m.ExpectedEvents.Missing(contractName, decoded.Method, m.emittedEventNames(decoded))
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s didn't emit %s", ErrMissingExpectedEvents, decoded.Method, strings.Join(missing, ", "))
+}
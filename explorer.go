@@ -0,0 +1,80 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ExplorerPlaceholder_Hash    = "{hash}"
+	ExplorerPlaceholder_Address = "{address}"
+
+	ErrExplorerURLTemplate = "block explorer URL template is missing placeholder"
+)
+
+// ChainExplorerURLs is a registry of base URLs of Etherscan-compatible block explorers of known chains (by chain ID).
+// They are used to generate links, if network has no explorer URL templates configured. You can add or override entries
+// before creating the client.
+var ChainExplorerURLs = map[int64]string{
+	1:        "https://etherscan.io",
+	10:       "https://optimistic.etherscan.io",
+	56:       "https://bscscan.com",
+	97:       "https://testnet.bscscan.com",
+	137:      "https://polygonscan.com",
+	8453:     "https://basescan.org",
+	42161:    "https://arbiscan.io",
+	43113:    "https://testnet.snowtrace.io",
+	43114:    "https://snowtrace.io",
+	80002:    "https://amoy.polygonscan.com",
+	84532:    "https://sepolia.basescan.org",
+	11155111: "https://sepolia.etherscan.io",
+}
+
+// TxExplorerURL returns block explorer link to the transaction or empty string if network has no explorer
+func (m *Client) TxExplorerURL(txHash string) string {
+	if m.Cfg == nil || m.Cfg.Network == nil {
+		return ""
+	}
+	template := m.Cfg.Network.ExplorerTxURL
+	if template == "" {
+		base, ok := ChainExplorerURLs[m.ChainID]
+		if !ok {
+			return ""
+		}
+		template = strings.TrimSuffix(base, "/") + "/tx/" + ExplorerPlaceholder_Hash
+	}
+
+	return strings.ReplaceAll(template, ExplorerPlaceholder_Hash, txHash)
+}
+
+// AddressExplorerURL returns block explorer link to the address (e.g. deployed contract) or empty string if network has
+// no explorer
+func (m *Client) AddressExplorerURL(address common.Address) string {
+	if m.Cfg == nil || m.Cfg.Network == nil {
+		return ""
+	}
+	template := m.Cfg.Network.ExplorerAddressURL
+	if template == "" {
+		base, ok := ChainExplorerURLs[m.ChainID]
+		if !ok {
+			return ""
+		}
+		template = strings.TrimSuffix(base, "/") + "/address/" + ExplorerPlaceholder_Address
+	}
+
+	return strings.ReplaceAll(template, ExplorerPlaceholder_Address, address.Hex())
+}
+
+// validateExplorerURLs checks that configured explorer URL templates contain their placeholders
+func (n *Network) validateExplorerURLs() error {
+	if n.ExplorerTxURL != "" && !strings.Contains(n.ExplorerTxURL, ExplorerPlaceholder_Hash) {
+		return fmt.Errorf("%s %s: %s", ErrExplorerURLTemplate, ExplorerPlaceholder_Hash, n.ExplorerTxURL)
+	}
+	if n.ExplorerAddressURL != "" && !strings.Contains(n.ExplorerAddressURL, ExplorerPlaceholder_Address) {
+		return fmt.Errorf("%s %s: %s", ErrExplorerURLTemplate, ExplorerPlaceholder_Address, n.ExplorerAddressURL)
+	}
+
+	return nil
+}
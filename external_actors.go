@@ -0,0 +1,113 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrExternalActor        = "invalid external actor"
+	ErrExternalActorNoTrace = "tracing is disabled, external actors can't be registered"
+)
+
+// externalActors holds labels of addresses that send transactions, but aren't Seth's keys (e.g. Chainlink nodes under test)
+type externalActors struct {
+	mu     sync.RWMutex
+	labels map[string]string
+}
+
+func (e *externalActors) set(address common.Address, label string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.labels == nil {
+		e.labels = make(map[string]string)
+	}
+	if label == "" {
+		delete(e.labels, strings.ToLower(address.Hex()))
+		return
+	}
+	e.labels[strings.ToLower(address.Hex())] = label
+}
+
+func (e *externalActors) label(address string) string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.labels[strings.ToLower(address)]
+}
+
+func (e *externalActors) all() map[common.Address]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	actors := make(map[common.Address]string, len(e.labels))
+	for addr, label := range e.labels {
+		actors[common.HexToAddress(addr)] = label
+	}
+	return actors
+}
+
+// RegisterExternalActor labels an address that isn't one of Seth's keys (e.g. "node-operator-1"), so that calls it makes
+// or receives are rendered with that label in traces instead of "unknown". Empty label removes the actor. Own keys and
+// known contracts take precedence over external actors.
+func (t *Tracer) RegisterExternalActor(address common.Address, label string) {
+	t.externalActors.set(address, label)
+}
+
+// ExternalActors returns a copy of all registered external actors and their labels
+func (t *Tracer) ExternalActors() map[common.Address]string {
+	return t.externalActors.all()
+}
+
+// ExternalActorLabel returns label of the external actor at given address or empty string if it's not registered
+func (t *Tracer) ExternalActorLabel(address common.Address) string {
+	return t.externalActors.label(address.Hex())
+}
+
+// IsExternalActor returns true if address is registered as an external actor
+func (t *Tracer) IsExternalActor(address common.Address) bool {
+	return t.ExternalActorLabel(address) != ""
+}
+
+// RegisterExternalActor labels an address that sends transactions traced by Seth, but isn't one of its keys. See
+// Tracer.RegisterExternalActor for details.
+func (m *Client) RegisterExternalActor(address common.Address, label string) error {
+	if m.Tracer == nil {
+		return errors.New(ErrExternalActorNoTrace)
+	}
+	m.Tracer.RegisterExternalActor(address, label)
+	L.Debug().
+		Str("Address", address.Hex()).
+		Str("Label", label).
+		Msg("Registered external actor")
+
+	return nil
+}
+
+// registerConfiguredExternalActors registers external actors from network config
+func (t *Tracer) registerConfiguredExternalActors(n *Network) {
+	for addr, label := range n.ExternalActors {
+		t.RegisterExternalActor(common.HexToAddress(addr), label)
+	}
+}
+
+// validateExternalActors checks that external actors have valid addresses, non-empty labels and that labels are unique
+func (n *Network) validateExternalActors() error {
+	owners := make(map[string]string, len(n.ExternalActors))
+	for addr, label := range n.ExternalActors {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("%s: %s is not a valid address", ErrExternalActor, addr)
+		}
+		if label == "" {
+			return fmt.Errorf("%s: %s has empty label", ErrExternalActor, addr)
+		}
+		if other, ok := owners[label]; ok {
+			return fmt.Errorf("%s: label %s is used by both %s and %s", ErrExternalActor, label, other, addr)
+		}
+		owners[label] = addr
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package seth
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	CommentKnownSignature    = "Decoded using known method signature, argument names are missing"
+	CommentHeuristicDecoding = "Arguments decoded heuristically, types are guesses"
+)
+
+// KnownMethodSignatures are signatures of widely used methods (ERC-20, ERC-721, ERC-1155 and others) used to decode calls
+// to contracts without known ABI, when fallback decoding is enabled. You can append your own signatures, e.g. of
+// third-party contracts you interact with.
+var KnownMethodSignatures = []string{
+	"transfer(address,uint256)",
+	"transferFrom(address,address,uint256)",
+	"approve(address,uint256)",
+	"balanceOf(address)",
+	"allowance(address,address)",
+	"totalSupply()",
+	"decimals()",
+	"symbol()",
+	"name()",
+	"mint(address,uint256)",
+	"burn(uint256)",
+	"burnFrom(address,uint256)",
+	"increaseAllowance(address,uint256)",
+	"decreaseAllowance(address,uint256)",
+	"permit(address,address,uint256,uint256,uint8,bytes32,bytes32)",
+	"ownerOf(uint256)",
+	"safeTransferFrom(address,address,uint256)",
+	"safeTransferFrom(address,address,uint256,bytes)",
+	"setApprovalForAll(address,bool)",
+	"isApprovedForAll(address,address)",
+	"getApproved(uint256)",
+	"safeTransferFrom(address,address,uint256,uint256,bytes)",
+	"safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)",
+	"deposit()",
+	"withdraw(uint256)",
+	"owner()",
+	"transferOwnership(address)",
+	"acceptOwnership()",
+	"multicall(bytes[])",
+	"transferAndCall(address,uint256,bytes)",
+}
+
+// LookupMethodSignature returns known method signature (see KnownMethodSignatures) with given 4-byte selector
+func LookupMethodSignature(selector []byte) (string, bool) {
+	for _, signature := range KnownMethodSignatures {
+		if bytes.Equal(crypto.Keccak256([]byte(signature))[:4], selector) {
+			return signature, true
+		}
+	}
+
+	return "", false
+}
+
+// HeuristicDecodeArgs splits ABI-encoded arguments (calldata without selector) into 32-byte words and guesses type of
+// each of them: common.Address for words that look like addresses, *big.Int for small positive and negative numbers and
+// [32]byte for everything else. Offsets and lengths of dynamic arguments are indistinguishable from numbers, so they
+// are returned as numbers too. Trailing bytes that don't form a whole word are returned as []byte. Values are keyed by
+// their index.
+func HeuristicDecodeArgs(data []byte) map[string]interface{} {
+	args := make(map[string]interface{})
+	i := 0
+	for ; (i+1)*32 <= len(data); i++ {
+		args[fmt.Sprint(i)] = guessWordType(data[i*32 : (i+1)*32])
+	}
+	if rest := data[i*32:]; len(rest) > 0 {
+		args[fmt.Sprint(i)] = common.CopyBytes(rest)
+	}
+
+	return args
+}
+
+func guessWordType(word []byte) interface{} {
+	zeroes := bytes.Repeat([]byte{0x00}, 16)
+	ones := bytes.Repeat([]byte{0xff}, 16)
+	switch {
+	case bytes.Equal(word[:12], zeroes[:12]) && !bytes.Equal(word[12:16], zeroes[:4]):
+		// numbers rarely exceed 2^128, so a value with 12 leading zero bytes that doesn't fit in 128 bits is most probably an address
+		return common.BytesToAddress(word)
+	case bytes.Equal(word[:16], zeroes):
+		return new(big.Int).SetBytes(word)
+	case bytes.Equal(word[:16], ones):
+		n := new(big.Int).SetBytes(word)
+		return n.Sub(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	default:
+		var b [32]byte
+		copy(b[:], word)
+		return b
+	}
+}
+
+// methodFromSignature creates ABI method with unnamed inputs from its signature, e.g. "transfer(address,uint256)".
+// Tuples aren't supported.
+func methodFromSignature(signature string) (*abi.Method, error) {
+	open := strings.Index(signature, "(")
+	if open < 1 || !strings.HasSuffix(signature, ")") {
+		return nil, fmt.Errorf("invalid method signature: %s", signature)
+	}
+	name := signature[:open]
+	rawTypes := signature[open+1 : len(signature)-1]
+
+	inputs := abi.Arguments{}
+	if rawTypes != "" {
+		for i, rawType := range strings.Split(rawTypes, ",") {
+			if strings.Contains(rawType, "(") {
+				return nil, fmt.Errorf("tuples are not supported in method signature: %s", signature)
+			}
+			t, err := abi.NewType(rawType, "", nil)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, abi.Argument{Name: fmt.Sprint(i), Type: t})
+		}
+	}
+	method := abi.NewMethod(name, name, abi.Function, "", false, false, inputs, nil)
+
+	return &method, nil
+}
+
+// fallbackDecodeCall decodes inputs of a call to a method without known ABI using known method signatures or, if method
+// is unknown or decoding fails, heuristically. Call is marked with a comment saying how it was decoded.
+func fallbackDecodeCall(call *DecodedCall, input []byte) {
+	if len(input) < 4 {
+		return
+	}
+
+	if signature, ok := LookupMethodSignature(input[:4]); ok {
+		method, err := methodFromSignature(signature)
+		if err == nil {
+			var decoded map[string]interface{}
+			if decoded, err = decodeTxInputs(L, input, method); err == nil {
+				call.Method = signature
+				call.Input = decoded
+				call.Comment = appendComment(call.Comment, CommentKnownSignature)
+				return
+			}
+		}
+		L.Debug().
+			Err(err).
+			Str("Signature", signature).
+			Msg("Failed to decode call using known method signature. Decoding it heuristically")
+	}
+
+	if len(input) == 4 {
+		return
+	}
+	call.Input = HeuristicDecodeArgs(input[4:])
+	call.Comment = appendComment(call.Comment, CommentHeuristicDecoding)
+}
+
+func appendComment(comment, addition string) string {
+	if comment == "" {
+		return addition
+	}
+
+	return comment + "; " + addition
+}
@@ -0,0 +1,539 @@
+package seth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	ErrNoAddressesToFund       = "no addresses to fund"
+	ErrNoAddressesToReturnFrom = "No addresses to return funds from. Have you passed correct key file?"
+	ErrFundingRollbackFailed   = "failed to roll back funding"
+)
+
+// FundingProgress describes the state of a funding workflow after a single transfer was attempted
+type FundingProgress struct {
+	Done    int
+	Total   int
+	Address common.Address
+	Amount  *big.Int
+	Err     error
+}
+
+// FundingProgressFn is called each time a funding workflow finishes (or fails) a single transfer
+type FundingProgressFn func(progress FundingProgress)
+
+// FundingTransfer describes a single transfer executed (or skipped) by a funding workflow
+type FundingTransfer struct {
+	From          common.Address
+	To            common.Address
+	Amount        *big.Int
+	BalanceBefore *big.Int
+	BalanceAfter  *big.Int
+	Skipped       bool
+	Err           error
+}
+
+// FundingReport is a reconciliation report generated after a funding workflow finishes
+type FundingReport struct {
+	RootAddress       common.Address
+	RootBalanceBefore *big.Int
+	RootBalanceAfter  *big.Int
+	TotalTransferred  *big.Int
+	Transfers         []FundingTransfer
+	Succeeded         int
+	Skipped           int
+	Failed            int
+	Currency          NativeCurrency
+	// Token is the address of ERC-20 token transferred by the workflow, balances and amounts are then token amounts. It's nil for native funds.
+	Token *common.Address
+}
+
+// FeesSpent returns the amount of native tokens root key spent on top of transferred value (only meaningful for native funds splitting)
+func (r *FundingReport) FeesSpent() *big.Int {
+	if r.RootBalanceBefore == nil || r.RootBalanceAfter == nil {
+		return big.NewInt(0)
+	}
+	spent := new(big.Int).Sub(r.RootBalanceBefore, r.RootBalanceAfter)
+	return spent.Sub(spent, r.TotalTransferred)
+}
+
+// String returns a short summary of the report
+func (r *FundingReport) String() string {
+	return fmt.Sprintf("transfers: %d succeeded, %d skipped, %d failed; total transferred: %s", r.Succeeded, r.Skipped, r.Failed, r.currency().Format(r.TotalTransferred))
+}
+
+// FailedTransfers returns all transfers that failed
+func (r *FundingReport) FailedTransfers() []FundingTransfer {
+	var failed []FundingTransfer
+	for _, t := range r.Transfers {
+		if t.Err != nil {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+func (r *FundingReport) currency() NativeCurrency {
+	if r.Currency.Symbol == "" {
+		return DefaultNativeCurrency
+	}
+	return r.Currency
+}
+
+// Log prints the reconciliation report
+func (r *FundingReport) Log() {
+	for _, t := range r.Transfers {
+		e := L.Info()
+		if t.Err != nil {
+			e = L.Error().Err(t.Err)
+		}
+		e.
+			Str("From", t.From.Hex()).
+			Str("To", t.To.Hex()).
+			Str("Amount", r.currency().Format(t.Amount)).
+			Str("BalanceBefore", r.currency().Format(t.BalanceBefore)).
+			Str("BalanceAfter", r.currency().Format(t.BalanceAfter)).
+			Bool("Skipped", t.Skipped).
+			Msg("Funding transfer")
+	}
+	L.Info().
+		Str("Root address", r.RootAddress.Hex()).
+		Str("RootBalanceBefore", r.currency().Format(r.RootBalanceBefore)).
+		Str("RootBalanceAfter", r.currency().Format(r.RootBalanceAfter)).
+		Str("TotalTransferred", r.currency().Format(r.TotalTransferred)).
+		Int("Succeeded", r.Succeeded).
+		Int("Skipped", r.Skipped).
+		Int("Failed", r.Failed).
+		Msg("Funding reconciliation report")
+}
+
+// FundingError is returned when some of the funding transfers failed. It lists every failed transfer and, if funding was
+// rolled back, contains the report of returning funds from addresses that were funded successfully.
+type FundingError struct {
+	Report         *FundingReport
+	RollbackReport *FundingReport
+	RollbackErr    error
+}
+
+func (e *FundingError) Error() string {
+	failed := e.Report.FailedTransfers()
+	msgs := make([]string, 0, len(failed))
+	for _, t := range failed {
+		msgs = append(msgs, fmt.Sprintf("%s -> %s: %s", t.From.Hex(), t.To.Hex(), t.Err.Error()))
+	}
+	msg := fmt.Sprintf("%d of %d funding transfers failed: %s", len(failed), len(e.Report.Transfers), strings.Join(msgs, "; "))
+
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("%s; %s: %s", msg, ErrFundingRollbackFailed, e.RollbackErr.Error())
+	}
+	if e.RollbackReport != nil {
+		return fmt.Sprintf("%s; funding was rolled back (%s)", msg, e.RollbackReport.String())
+	}
+	return msg
+}
+
+// FundingWorkflow orchestrates splitting funds from the root key to other addresses and returning them back.
+// It reports progress after each transfer and can be safely restarted after interruption, because it reads
+// on-chain balances before sending anything and skips addresses that were already handled.
+type FundingWorkflow struct {
+	Client     *Client
+	ProgressFn FundingProgressFn
+
+	mu   *sync.Mutex
+	done int
+}
+
+// NewFundingWorkflow creates a new funding workflow. Progress function is optional.
+func NewFundingWorkflow(c *Client, progressFn FundingProgressFn) *FundingWorkflow {
+	return &FundingWorkflow{
+		Client:     c,
+		ProgressFn: progressFn,
+		mu:         &sync.Mutex{},
+	}
+}
+
+// resetProgress resets the number of finished transfers before a new batch of them is started
+func (f *FundingWorkflow) resetProgress() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.done = 0
+}
+
+func (f *FundingWorkflow) reportProgress(total int, address common.Address, amount *big.Int, err error) {
+	f.mu.Lock()
+	f.done++
+	progress := FundingProgress{Done: f.done, Total: total, Address: address, Amount: amount, Err: err}
+	f.mu.Unlock()
+
+	L.Debug().
+		Int("Done", progress.Done).
+		Int("Total", progress.Total).
+		Str("Address", address.Hex()).
+		Msg("Funding progress")
+
+	if f.ProgressFn != nil {
+		f.ProgressFn(progress)
+	}
+}
+
+func (f *FundingWorkflow) suggestedGasPrice() *big.Int {
+	gasPrice, err := f.Client.GetSuggestedLegacyFees(context.Background(), Priority_Standard)
+	if err != nil {
+		fallbackGasPrice, _, _ := f.Client.Cfg.Network.FallbackFees(Priority_Standard)
+		gasPrice = big.NewInt(fallbackGasPrice)
+	}
+	return gasPrice
+}
+
+// SplitFunds sends funds from the root key to each of the addresses. If amount is nil it will be calculated by
+// dividing root key's free balance (minus root key funds buffer) by the number of addresses that still need funding.
+// Addresses that already have a balance (or at least amount, if it was passed) are skipped, which makes it safe
+// to run it again after an interrupted run without paying transfer fees twice. Like all funding workflow methods it
+// doesn't cancel remaining transfers when one of them fails, so that the report says exactly which transfers failed.
+func (f *FundingWorkflow) SplitFunds(ctx context.Context, addresses []common.Address, amount *big.Int) (*FundingReport, error) {
+	if len(addresses) == 0 {
+		return nil, errors.New(ErrNoAddressesToFund)
+	}
+
+	c := f.Client
+	rootAddr := c.MustGetRootKeyAddress()
+	rootBalanceBefore, err := c.balanceOf(ctx, rootAddr, BlockTag_Latest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get root key balance")
+	}
+
+	report := &FundingReport{
+		RootAddress:       rootAddr,
+		RootBalanceBefore: rootBalanceBefore,
+		TotalTransferred:  big.NewInt(0),
+		Currency:          c.NativeCurrency(),
+	}
+
+	balances := make([]*big.Int, len(addresses))
+	pending := make([]int, 0)
+	for i, addr := range addresses {
+		balance, err := c.balanceOf(ctx, addr, BlockTag_Latest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get balance of %s", addr.Hex())
+		}
+		balances[i] = balance
+		if (amount == nil && balance.Sign() == 0) || (amount != nil && balance.Cmp(amount) < 0) {
+			pending = append(pending, i)
+		}
+	}
+
+	if len(pending) < len(addresses) {
+		L.Info().
+			Int("Already funded", len(addresses)-len(pending)).
+			Int("To fund", len(pending)).
+			Msg("Resuming funds split, some addresses were already funded")
+	}
+
+	gasPrice := f.suggestedGasPrice()
+	if amount == nil && len(pending) > 0 {
+		bd, err := c.CalculateSubKeyFunding(int64(len(pending)), gasPrice.Int64(), *c.Cfg.RootKeyFundsBuffer)
+		if err != nil {
+			return nil, err
+		}
+		amount = bd.AddrFunding
+	}
+
+	transfers := make([]FundingTransfer, len(addresses))
+	for i, addr := range addresses {
+		transfers[i] = FundingTransfer{
+			From:          rootAddr,
+			To:            addr,
+			Amount:        big.NewInt(0),
+			BalanceBefore: balances[i],
+			Skipped:       true,
+		}
+	}
+
+	// we don't cancel remaining transfers when one of them fails, so that report says exactly which transfers failed
+	f.resetProgress()
+	var eg errgroup.Group
+	for _, idx := range pending {
+		idx := idx
+		eg.Go(func() error {
+			err := c.TransferETHFromKey(ctx, 0, addresses[idx].Hex(), amount, gasPrice)
+			transfers[idx].Skipped = false
+			transfers[idx].Err = err
+			if err == nil {
+				transfers[idx].Amount = amount
+			}
+			f.reportProgress(len(pending), addresses[idx], amount, err)
+			return err
+		})
+	}
+	splitErr := eg.Wait()
+
+	f.reconcile(ctx, report, transfers)
+
+	if splitErr != nil {
+		return report, &FundingError{Report: report}
+	}
+
+	return report, nil
+}
+
+// RollbackFunds returns funds to the root key from all addresses that were funded by the funding workflow described by
+// the report. Addresses whose transfer failed are also checked, because transaction might have been mined even though we
+// failed to wait for it. It's used to make sure that funds are not lost if only some of the transfers succeeded.
+func (f *FundingWorkflow) RollbackFunds(ctx context.Context, report *FundingReport) (*FundingReport, error) {
+	c := f.Client
+	rootAddr := c.MustGetRootKeyAddress()
+	rootBalanceBefore, err := c.balanceOf(ctx, rootAddr, BlockTag_Latest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get root key balance")
+	}
+
+	rollback := &FundingReport{
+		RootAddress:       rootAddr,
+		RootBalanceBefore: rootBalanceBefore,
+		TotalTransferred:  big.NewInt(0),
+		Currency:          c.NativeCurrency(),
+	}
+
+	addrs, privateKeys := c.keys()
+	keys := make(map[common.Address]*ecdsa.PrivateKey, len(addrs))
+	for i, addr := range addrs {
+		keys[addr] = privateKeys[i]
+	}
+
+	toRollback := make([]common.Address, 0)
+	for _, t := range report.Transfers {
+		if t.Skipped {
+			continue
+		}
+		if _, ok := keys[t.To]; !ok {
+			L.Warn().Str("Address", t.To.Hex()).Msg("No private key for funded address, funds won't be rolled back")
+			continue
+		}
+		toRollback = append(toRollback, t.To)
+	}
+
+	gasPrice := f.suggestedGasPrice()
+	transfers := make([]FundingTransfer, len(toRollback))
+
+	f.resetProgress()
+	var eg errgroup.Group
+	for i, addr := range toRollback {
+		i, addr := i, addr
+		eg.Go(func() error {
+			transfer, err := f.returnFundsFromKey(ctx, addr, keys[addr], rootAddr.Hex(), gasPrice)
+			transfers[i] = transfer
+			f.reportProgress(len(toRollback), addr, transfer.Amount, err)
+			return err
+		})
+	}
+	rollbackErr := eg.Wait()
+
+	f.reconcile(ctx, rollback, transfers)
+
+	return rollback, rollbackErr
+}
+
+// ReturnFunds returns funds from all non-root keys to toAddr (or to root key if it's empty). Keys that do not have
+// enough funds to cover the transfer fee are skipped, so it's safe to run it again after an interrupted run. When a return
+// fails, returns from other keys still run to completion (or until ctx is done) and the first error is returned.
+func (f *FundingWorkflow) ReturnFunds(ctx context.Context, toAddr string) (*FundingReport, error) {
+	c := f.Client
+	if toAddr == "" {
+		toAddr = c.Addresses[0].Hex()
+	}
+
+	if len(c.Addresses) == 1 {
+		return nil, errors.New(ErrNoAddressesToReturnFrom)
+	}
+
+	to := common.HexToAddress(toAddr)
+	toBalanceBefore, err := c.balanceOf(ctx, to, BlockTag_Latest)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get balance of %s", toAddr)
+	}
+
+	report := &FundingReport{
+		RootAddress:       to,
+		RootBalanceBefore: toBalanceBefore,
+		TotalTransferred:  big.NewInt(0),
+		Currency:          c.NativeCurrency(),
+	}
+
+	gasPrice := f.suggestedGasPrice()
+	total := len(c.Addresses) - 1
+	transfers := make([]FundingTransfer, total)
+
+	addrs, privateKeys := c.keys()
+	f.resetProgress()
+	var eg errgroup.Group
+	for i := 1; i < len(addrs); i++ {
+		idx := i
+		// funds were already returned from the first occurrence of the key and node accounts' funds belong to the node operator
+		if c.Cfg.IsKeyAlias(idx) || privateKeys[idx] == nil {
+			transfers[idx-1] = FundingTransfer{From: addrs[idx], To: to, Skipped: true}
+			continue
+		}
+		eg.Go(func() error {
+			transfer, err := f.returnFundsFromKey(ctx, addrs[idx], privateKeys[idx], toAddr, gasPrice)
+			transfers[idx-1] = transfer
+			f.reportProgress(total, addrs[idx], transfer.Amount, err)
+			return err
+		})
+	}
+	returnErr := eg.Wait()
+
+	f.reconcile(ctx, report, transfers)
+
+	return report, returnErr
+}
+
+// returnFundsFromKey returns all funds (minus transfer fee) from given key to toAddr. If key doesn't have enough funds
+// to cover the fee, transfer is skipped.
+func (f *FundingWorkflow) returnFundsFromKey(ctx context.Context, from common.Address, privateKey *ecdsa.PrivateKey, toAddr string, gasPrice *big.Int) (FundingTransfer, error) {
+	c := f.Client
+	transfer := FundingTransfer{
+		From:   from,
+		To:     common.HexToAddress(toAddr),
+		Amount: big.NewInt(0),
+	}
+
+	balance, err := c.balanceOf(ctx, from, BlockTag_Latest)
+	if err != nil {
+		L.Error().Err(err).Msg("Error getting balance")
+		transfer.Err = err
+		return transfer, err
+	}
+	transfer.BalanceBefore = balance
+
+	var gasLimit int64
+	gasLimitRaw, err := c.estimateGasLimitForFundTransfer(ctx, from, transfer.To, balance)
+	if err != nil {
+		gasLimit = c.Cfg.Network.TransferGasFee
+	} else {
+		gasLimit = int64(gasLimitRaw)
+	}
+
+	networkTransferFee := gasPrice.Int64() * gasLimit
+	fundsToReturn := new(big.Int).Sub(balance, big.NewInt(networkTransferFee))
+
+	if fundsToReturn.Cmp(big.NewInt(0)) == -1 {
+		L.Warn().
+			Str("Key", from.Hex()).
+			Interface("Balance", balance).
+			Interface("NetworkFee", networkTransferFee).
+			Interface("FundsToReturn", fundsToReturn).
+			Msg("Insufficient funds to return. Skipping.")
+		transfer.Skipped = true
+		return transfer, nil
+	}
+
+	L.Info().
+		Str("Key", from.Hex()).
+		Interface("Balance", balance).
+		Interface("NetworkFee", networkTransferFee).
+		Interface("GasLimit", gasLimit).
+		Interface("GasPrice", gasPrice).
+		Interface("FundsToReturn", fundsToReturn).
+		Msg("Returning funds from address")
+
+	err = c.transferETHFromPrivateKey(
+		ctx,
+		from,
+		privateKey,
+		toAddr,
+		fundsToReturn,
+		gasPrice,
+	)
+	transfer.Err = err
+	if err == nil {
+		transfer.Amount = fundsToReturn
+	}
+
+	return transfer, err
+}
+
+// reconcile reads balances after the workflow has finished and fills in the summary of the report
+func (f *FundingWorkflow) reconcile(ctx context.Context, report *FundingReport, transfers []FundingTransfer) {
+	for i := range transfers {
+		t := &transfers[i]
+		switch {
+		case t.Err != nil:
+			report.Failed++
+		case t.Skipped:
+			report.Skipped++
+		default:
+			report.Succeeded++
+			report.TotalTransferred.Add(report.TotalTransferred, t.Amount)
+		}
+
+		// for funds return we care about the balance of the sender, for funds split about the balance of the receiver
+		balanceOf := t.To
+		if t.To == report.RootAddress {
+			balanceOf = t.From
+		}
+		balance, err := f.reportBalanceOf(ctx, report, balanceOf)
+		if err != nil {
+			L.Debug().Err(err).Str("Address", balanceOf.Hex()).Msg("Failed to get balance for reconciliation report")
+			continue
+		}
+		t.BalanceAfter = balance
+	}
+
+	rootBalanceAfter, err := f.reportBalanceOf(ctx, report, report.RootAddress)
+	if err != nil {
+		L.Debug().Err(err).Str("Address", report.RootAddress.Hex()).Msg("Failed to get balance for reconciliation report")
+	} else {
+		report.RootBalanceAfter = rootBalanceAfter
+	}
+	report.Transfers = transfers
+}
+
+// reportBalanceOf returns balance of address in the currency of the report: ERC-20 token or native funds
+func (f *FundingWorkflow) reportBalanceOf(ctx context.Context, report *FundingReport, addr common.Address) (*big.Int, error) {
+	if report.Token != nil {
+		return f.tokenBalanceOf(ctx, *report.Token, addr)
+	}
+
+	return f.Client.balanceOf(ctx, addr, BlockTag_Latest)
+}
+
+// fundEphemeralKeys splits root key's funds between ephemeral keys. Ephemeral keys are generated anew each time a client is
+// created, so if only some transfers succeed we return funds from keys that were funded, otherwise retrying client creation
+// would leave them stranded on keys nobody has access to.
+func (m *Client) fundEphemeralKeys() error {
+	workflow := NewFundingWorkflow(m, nil)
+	// node accounts are funded by the node operator, so only ephemeral keys are funded
+	ephemeralAddrs := make([]common.Address, 0, len(m.Addresses)-1)
+	for i := 1; i < len(m.Addresses); i++ {
+		if !m.IsNodeAccount(i) {
+			ephemeralAddrs = append(ephemeralAddrs, m.Addresses[i])
+		}
+	}
+	report, err := workflow.SplitFunds(context.Background(), ephemeralAddrs, nil)
+	if err == nil {
+		return nil
+	}
+
+	var fundingErr *FundingError
+	if !errors.As(err, &fundingErr) {
+		return err
+	}
+
+	report.Log()
+	L.Warn().Int("Failed", report.Failed).Msg("Failed to fund some ephemeral keys, returning funds from the ones that were funded")
+	fundingErr.RollbackReport, fundingErr.RollbackErr = workflow.RollbackFunds(context.Background(), report)
+	if fundingErr.RollbackReport != nil {
+		fundingErr.RollbackReport.Log()
+	}
+
+	return fundingErr
+}
@@ -60,6 +60,10 @@ func (m *Client) CalculateNetworkCongestionMetric(blocksNumber uint64, strategy
 			timeout = 6
 		}
 
+		if m.rpcLimiter != nil {
+			m.rpcLimiter.Take()
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
 		defer cancel()
 		header, err := m.Client.HeaderByNumber(ctx, bn)
@@ -99,6 +103,12 @@ func (m *Client) CalculateNetworkCongestionMetric(blocksNumber uint64, strategy
 		}
 	}()
 
+	// provider profile might limit number of concurrent requests
+	var concurrencyLimit chan struct{}
+	if profile := m.Cfg.ProviderProfile(); profile != nil && profile.MaxConcurrency > 0 {
+		concurrencyLimit = make(chan struct{}, profile.MaxConcurrency)
+	}
+
 	startTime := time.Now()
 	for i := lastBlockNumber; i > lastBlockNumber-blocksNumber; i-- {
 		// better safe than sorry (might happen for brand-new chains)
@@ -108,6 +118,10 @@ func (m *Client) CalculateNetworkCongestionMetric(blocksNumber uint64, strategy
 
 		wg.Add(1)
 		go func(bn *big.Int) {
+			if concurrencyLimit != nil {
+				concurrencyLimit <- struct{}{}
+				defer func() { <-concurrencyLimit }()
+			}
 			header, err := getHeaderData(bn)
 			if err != nil {
 				L.Error().Err(err).Msgf("Failed to get block %d header", bn.Int64())
@@ -172,6 +186,7 @@ func calculateNewestFirstNetworkCongestionMetric(headers []*types.Header) float6
 }
 
 // GetSuggestedEIP1559Fees returns suggested tip/fee cap calculated based on historical data, current congestion, and priority.
+// If it fails, fallback values configured for the priority should be used instead, see Network.FallbackFees().
 func (m *Client) GetSuggestedEIP1559Fees(ctx context.Context, priority string) (maxFeeCap *big.Int, adjustedTipCap *big.Int, err error) {
 	L.Info().Msg("Calculating suggested EIP-1559 fees")
 	var suggestedGasTip *big.Int
@@ -181,7 +196,7 @@ func (m *Client) GetSuggestedEIP1559Fees(ctx context.Context, priority string) (
 	}
 
 	L.Debug().
-		Str("CurrentGasTip", fmt.Sprintf("%s wei / %s ether", suggestedGasTip.String(), WeiToEther(suggestedGasTip).Text('f', -1))).
+		Str("CurrentGasTip", m.FormatNativeAmount(suggestedGasTip)).
 		Msg("Current suggested gas tip")
 
 	// Fetch the baseline historical base fee and tip for the selected priority
@@ -192,8 +207,8 @@ func (m *Client) GetSuggestedEIP1559Fees(ctx context.Context, priority string) (
 	}
 
 	L.Debug().
-		Str("HistoricalBaseFee", fmt.Sprintf("%.0f wei / %s ether", baseFee64, WeiToEther(big.NewInt(int64(baseFee64))).Text('f', -1))).
-		Str("HistoricalSuggestedTip", fmt.Sprintf("%.0f wei / %s ether", historicalSuggestedTip64, WeiToEther(big.NewInt(int64(historicalSuggestedTip64))).Text('f', -1))).
+		Str("HistoricalBaseFee", m.FormatNativeAmount(big.NewInt(int64(baseFee64)))).
+		Str("HistoricalSuggestedTip", m.FormatNativeAmount(big.NewInt(int64(historicalSuggestedTip64)))).
 		Str("Priority", priority).
 		Msg("Historical fee data")
 
@@ -310,32 +325,33 @@ func (m *Client) GetSuggestedEIP1559Fees(ctx context.Context, priority string) (
 	gasCapDiff := big.NewInt(0).Sub(maxFeeCap, initialFeeCap)
 
 	L.Debug().
-		Str("Diff (Wei/Ether)", fmt.Sprintf("%s wei / %s ether", gasTipDiff.String(), WeiToEther(gasTipDiff).Text('f', -1))).
-		Str("Initial Tip", fmt.Sprintf("%s wei / %s ether", currentGasTip.String(), WeiToEther(currentGasTip).Text('f', -1))).
-		Str("Final Tip", fmt.Sprintf("%s wei / %s ether", adjustedTipCap.String(), WeiToEther(adjustedTipCap).Text('f', -1))).
+		Str("Diff", m.FormatNativeAmount(gasTipDiff)).
+		Str("Initial Tip", m.FormatNativeAmount(currentGasTip)).
+		Str("Final Tip", m.FormatNativeAmount(adjustedTipCap)).
 		Msg("Tip adjustment")
 
 	L.Debug().
-		Str("Diff (Wei/Ether)", fmt.Sprintf("%s wei / %s ether", baseFeeDiff.String(), WeiToEther(baseFeeDiff).Text('f', -1))).
-		Str("Initial Base Fee", fmt.Sprintf("%s wei / %s ether", big.NewInt(int64(baseFee64)).String(), WeiToEther(big.NewInt(int64(baseFee64))).Text('f', -1))).
-		Str("Final Base Fee", fmt.Sprintf("%s wei / %s ether", adjustedBaseFee.String(), WeiToEther(adjustedBaseFee).Text('f', -1))).
+		Str("Diff", m.FormatNativeAmount(baseFeeDiff)).
+		Str("Initial Base Fee", m.FormatNativeAmount(big.NewInt(int64(baseFee64)))).
+		Str("Final Base Fee", m.FormatNativeAmount(adjustedBaseFee)).
 		Msg("Base Fee adjustment")
 
 	L.Debug().
-		Str("Diff (Wei/Ether)", fmt.Sprintf("%s wei / %s ether", gasCapDiff.String(), WeiToEther(gasCapDiff).Text('f', -1))).
-		Str("Initial Fee Cap", fmt.Sprintf("%s wei / %s ether", initialFeeCap.String(), WeiToEther(initialFeeCap).Text('f', -1))).
-		Str("Final Fee Cap", fmt.Sprintf("%s wei / %s ether", maxFeeCap.String(), WeiToEther(maxFeeCap).Text('f', -1))).
+		Str("Diff", m.FormatNativeAmount(gasCapDiff)).
+		Str("Initial Fee Cap", m.FormatNativeAmount(initialFeeCap)).
+		Str("Final Fee Cap", m.FormatNativeAmount(maxFeeCap)).
 		Msg("Fee Cap adjustment")
 
 	L.Info().
-		Str("GasTipCap", fmt.Sprintf("%s wei / %s ether", adjustedTipCap.String(), WeiToEther(adjustedTipCap).Text('f', -1))).
-		Str("GasFeeCap", fmt.Sprintf("%s wei / %s ether", maxFeeCap.String(), WeiToEther(maxFeeCap).Text('f', -1))).
+		Str("GasTipCap", m.FormatNativeAmount(adjustedTipCap)).
+		Str("GasFeeCap", m.FormatNativeAmount(maxFeeCap)).
 		Msg("Calculated suggested EIP-1559 fees")
 
 	return
 }
 
 // GetSuggestedLegacyFees calculates the suggested gas price based on historical data, current congestion, and priority.
+// If it fails, fallback gas price configured for the priority should be used instead, see Network.FallbackFees().
 func (m *Client) GetSuggestedLegacyFees(ctx context.Context, priority string) (adjustedGasPrice *big.Int, err error) {
 	L.Info().
 		Msg("Calculating suggested Legacy fees")
@@ -400,13 +416,13 @@ func (m *Client) GetSuggestedLegacyFees(ctx context.Context, priority string) (a
 	}
 
 	L.Debug().
-		Str("Diff (Wei/Ether)", fmt.Sprintf("%s/%s", big.NewInt(0).Sub(adjustedGasPrice, suggestedGasPrice).String(), WeiToEther(big.NewInt(0).Sub(adjustedGasPrice, suggestedGasPrice)).Text('f', -1))).
-		Str("Initial GasPrice (Wei/Ether)", fmt.Sprintf("%s/%s", suggestedGasPrice.String(), WeiToEther(suggestedGasPrice).Text('f', -1))).
-		Str("Final GasPrice (Wei/Ether)", fmt.Sprintf("%s/%s", adjustedGasPrice.String(), WeiToEther(adjustedGasPrice).Text('f', -1))).
+		Str("Diff", m.FormatNativeAmount(big.NewInt(0).Sub(adjustedGasPrice, suggestedGasPrice))).
+		Str("Initial GasPrice", m.FormatNativeAmount(suggestedGasPrice)).
+		Str("Final GasPrice", m.FormatNativeAmount(adjustedGasPrice)).
 		Msg("Suggested Legacy fees")
 
 	L.Info().
-		Str("GasPrice", fmt.Sprintf("%s wei / %s ether", adjustedGasPrice.String(), WeiToEther(adjustedGasPrice).Text('f', -1))).
+		Str("GasPrice", m.FormatNativeAmount(adjustedGasPrice)).
 		Msg("Calculated suggested Legacy fees")
 
 	return
@@ -1,7 +1,10 @@
 package seth_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	link_token "github.com/smartcontractkit/seth/contracts/bind/link"
 	"github.com/smartcontractkit/seth/contracts/bind/link_token_interface"
 	"github.com/smartcontractkit/seth/test_utils"
@@ -622,3 +625,67 @@ func TestGasBumping_Contract_Interaction_Legacy_FailedBumping(t *testing.T) {
 	require.Error(t, err, "did not fail to transfer tokens, even though gas bumping is disabled")
 	require.Equal(t, 3, gasBumps, "expected 2 gas bumps")
 }
+
+func TestGasBumping_Contract_Interaction_AccessList_SufficientBumping(t *testing.T) {
+	spammer := test_utils.NewClientWithAddresses(t, 5, oneEth)
+
+	configCopy, err := test_utils.CopyConfig(spammer.Cfg)
+	require.NoError(t, err, "failed to copy config")
+
+	newPk := test_utils.NewPrivateKeyWithFunds(t, spammer, oneEth)
+	configCopy.Network.PrivateKeys = []string{newPk}
+	configCopy.EphemeralAddrs = &zero
+
+	client, err := seth.NewClientWithConfig(configCopy)
+	require.NoError(t, err, "failed to create client")
+
+	contractAbi, err := link_token_interface.LinkTokenMetaData.GetAbi()
+	require.NoError(t, err, "failed to get ABI")
+
+	data, err := client.DeployContract(client.NewTXOpts(), "LinkToken", *contractAbi, common.FromHex(link_token_interface.LinkTokenMetaData.Bin))
+	require.NoError(t, err, "contract wasn't deployed")
+
+	var gasPrices []*big.Int
+
+	client.Cfg.Network.TxnTimeout = seth.MustMakeDuration(10 * time.Second)
+	client.Cfg.GasBump = &seth.GasBumpConfig{
+		Retries: 10,
+		StrategyFn: func(gasPrice *big.Int) *big.Int {
+			gasPrices = append(gasPrices, gasPrice)
+			return new(big.Int).Mul(gasPrice, big.NewInt(10))
+		},
+	}
+
+	// introduce some traffic, so that bumping is necessary to mine the transaction
+	go func() {
+		for i := 0; i < 5; i++ {
+			_, _ = spammer.DeployContract(spammer.NewTXKeyOpts(spammer.AnySyncedKey()), "LinkToken", *contractAbi, common.FromHex(link_token.LinkTokenMetaData.Bin))
+		}
+	}()
+
+	callData, err := contractAbi.Pack("transfer", client.Addresses[0], big.NewInt(1))
+	require.NoError(t, err, "failed to pack call data")
+
+	accessList := types.AccessList{{Address: data.Address, StorageKeys: []common.Hash{{}}}}
+	nonce, err := client.Client.PendingNonceAt(context.Background(), client.Addresses[0])
+	require.NoError(t, err, "failed to get nonce")
+
+	tx, err := types.SignNewTx(client.PrivateKeys[0], types.LatestSignerForChainID(big.NewInt(client.ChainID)), &types.AccessListTx{
+		Nonce:      nonce,
+		To:         &data.Address,
+		Gas:        200_000,
+		GasPrice:   big.NewInt(1),
+		Data:       callData,
+		AccessList: accessList,
+	})
+	require.NoError(t, err, "failed to sign transaction")
+	err = client.Client.SendTransaction(context.Background(), tx)
+	require.NoError(t, err, "failed to send transaction")
+
+	decoded, err := client.Decode(tx, nil)
+	require.NoError(t, err, "transaction should be mined after gas bumping")
+	require.GreaterOrEqual(t, len(gasPrices), 1, "expected at least 1 gas bump")
+	require.Equal(t, uint8(types.AccessListTxType), decoded.Transaction.Type(), "replacement should have the same type")
+	require.Equal(t, accessList, decoded.Transaction.AccessList(), "replacement should preserve access list")
+	require.Equal(t, 1, decoded.Transaction.GasPrice().Cmp(big.NewInt(1)), "replacement should have higher gas price")
+}
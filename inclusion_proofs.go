@@ -0,0 +1,283 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrInvalidProof = "merkle proof verification failed"
+	ErrFetchProof   = "failed to fetch proof"
+	ErrProofRoot    = "root of rebuilt trie doesn't match block header"
+)
+
+// MerkleProof is a proof of inclusion of a single key in a Merkle-Patricia trie
+type MerkleProof struct {
+	Root  common.Hash
+	Key   []byte
+	Value []byte
+	// Nodes are RLP-encoded trie nodes on the path from the root to the value, in the order expected by on-chain verifiers
+	Nodes [][]byte
+}
+
+// Verify checks that proof's nodes lead from its root to its value under its key
+func (p *MerkleProof) Verify() error {
+	value, err := trie.VerifyProof(p.Root, p.Key, proofNodesDb(p.Nodes))
+	if err != nil {
+		return errors.Wrap(err, ErrInvalidProof)
+	}
+	if !bytes.Equal(value, p.Value) {
+		return fmt.Errorf("%s: proven value 0x%x doesn't match expected 0x%x", ErrInvalidProof, value, p.Value)
+	}
+
+	return nil
+}
+
+// NewTransactionsProof returns proof of inclusion of transaction with given index in transactions trie built from the list
+func NewTransactionsProof(txs types.Transactions, index int) (*MerkleProof, error) {
+	return newListProof(txs, index)
+}
+
+// NewReceiptsProof returns proof of inclusion of receipt with given index in receipts trie built from the list
+func NewReceiptsProof(receipts types.Receipts, index int) (*MerkleProof, error) {
+	return newListProof(receipts, index)
+}
+
+// GetTransactionProof returns proof of inclusion of mined transaction in transactions trie of its block. Root of the proof
+// is verified to match block header's transactions root.
+func (m *Client) GetTransactionProof(ctx context.Context, txHash common.Hash) (*MerkleProof, *types.Header, error) {
+	receipt, block, err := m.blockOfTransaction(ctx, txHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proof, err := NewTransactionsProof(block.Transactions(), int(receipt.TransactionIndex))
+	if err != nil {
+		return nil, nil, err
+	}
+	if proof.Root != block.TxHash() {
+		return nil, nil, fmt.Errorf("%s: transactions root is %s, but %s was rebuilt", ErrProofRoot, block.TxHash().Hex(), proof.Root.Hex())
+	}
+
+	return proof, block.Header(), nil
+}
+
+// GetReceiptProof returns proof of inclusion of transaction's receipt in receipts trie of its block. All receipts of the
+// block are fetched in a single batch request. Root of the proof is verified to match block header's receipts root.
+func (m *Client) GetReceiptProof(ctx context.Context, txHash common.Hash) (*MerkleProof, *types.Header, error) {
+	receipt, block, err := m.blockOfTransaction(ctx, txHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	receipts := make(types.Receipts, len(block.Transactions()))
+	batch := make([]rpc.BatchElem, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		receipts[i] = new(types.Receipt)
+		batch[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{tx.Hash()}, Result: receipts[i]}
+	}
+	if err := m.Client.Client().BatchCallContext(ctx, batch); err != nil {
+		return nil, nil, errors.Wrap(err, ErrFetchProof)
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return nil, nil, errors.Wrap(elem.Error, ErrFetchProof)
+		}
+	}
+
+	proof, err := NewReceiptsProof(receipts, int(receipt.TransactionIndex))
+	if err != nil {
+		return nil, nil, err
+	}
+	if proof.Root != block.ReceiptHash() {
+		return nil, nil, fmt.Errorf("%s: receipts root is %s, but %s was rebuilt", ErrProofRoot, block.ReceiptHash().Hex(), proof.Root.Hex())
+	}
+
+	return proof, block.Header(), nil
+}
+
+// GetAccountProof fetches account and storage proofs (eth_getProof) for given block (or latest, if it's nil) and verifies
+// them against state root of block's header
+func (m *Client) GetAccountProof(ctx context.Context, address common.Address, storageKeys []common.Hash, block *big.Int) (*gethclient.AccountResult, *types.Header, error) {
+	header, err := m.Client.HeaderByNumber(ctx, block)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ErrFetchProof)
+	}
+
+	keys := make([]string, 0, len(storageKeys))
+	for _, key := range storageKeys {
+		keys = append(keys, key.Hex())
+	}
+	// header's number is used instead of the requested block, so that proof matches the header even if new block was mined
+	result, err := gethclient.New(m.Client.Client()).GetProof(ctx, address, keys, header.Number)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ErrFetchProof)
+	}
+	if err := VerifyAccountProof(header.Root, result); err != nil {
+		return nil, nil, err
+	}
+
+	return result, header, nil
+}
+
+// VerifyAccountProof checks that account proof returned by eth_getProof proves account's nonce, balance, code hash and
+// storage root under given state root and that each storage proof proves its value under account's storage root.
+// Proofs of non-existent accounts and storage slots (absence proofs) are verified as well.
+func VerifyAccountProof(stateRoot common.Hash, result *gethclient.AccountResult) error {
+	if result == nil {
+		return errors.New(ErrInvalidProof)
+	}
+
+	accountNodes, err := decodeProofNodes(result.AccountProof)
+	if err != nil {
+		return err
+	}
+	value, err := trie.VerifyProof(stateRoot, crypto.Keccak256(result.Address.Bytes()), proofNodesDb(accountNodes))
+	if err != nil {
+		return errors.Wrapf(err, "%s: account %s", ErrInvalidProof, result.Address.Hex())
+	}
+
+	account := types.StateAccount{Balance: big.NewInt(0), Root: types.EmptyRootHash, CodeHash: types.EmptyCodeHash.Bytes()}
+	if value != nil {
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return errors.Wrapf(err, "%s: account %s", ErrInvalidProof, result.Address.Hex())
+		}
+	}
+	balance := result.Balance
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+	// nodes return zero code hash and storage root for non-existent accounts
+	codeHash, storageRoot := result.CodeHash, result.StorageHash
+	if value == nil && codeHash == (common.Hash{}) {
+		codeHash = types.EmptyCodeHash
+	}
+	if value == nil && storageRoot == (common.Hash{}) {
+		storageRoot = types.EmptyRootHash
+	}
+	if account.Nonce != result.Nonce || account.Balance.Cmp(balance) != 0 || common.BytesToHash(account.CodeHash) != codeHash || account.Root != storageRoot {
+		return fmt.Errorf("%s: proven state of account %s doesn't match returned one", ErrInvalidProof, result.Address.Hex())
+	}
+
+	for _, storage := range result.StorageProof {
+		storageNodes, err := decodeProofNodes(storage.Proof)
+		if err != nil {
+			return err
+		}
+		key := common.HexToHash(storage.Key)
+		value, err := trie.VerifyProof(account.Root, crypto.Keccak256(key.Bytes()), proofNodesDb(storageNodes))
+		if err != nil {
+			return errors.Wrapf(err, "%s: storage slot %s of account %s", ErrInvalidProof, key.Hex(), result.Address.Hex())
+		}
+		var slot []byte
+		if value != nil {
+			if err := rlp.DecodeBytes(value, &slot); err != nil {
+				return errors.Wrapf(err, "%s: storage slot %s of account %s", ErrInvalidProof, key.Hex(), result.Address.Hex())
+			}
+		}
+		expected := storage.Value
+		if expected == nil {
+			expected = big.NewInt(0)
+		}
+		if new(big.Int).SetBytes(slot).Cmp(expected) != 0 {
+			return fmt.Errorf("%s: proven value of storage slot %s of account %s doesn't match returned one", ErrInvalidProof, key.Hex(), result.Address.Hex())
+		}
+	}
+
+	return nil
+}
+
+func (m *Client) blockOfTransaction(ctx context.Context, txHash common.Hash) (*types.Receipt, *types.Block, error) {
+	receipt, err := m.Client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "%s: failed to get receipt of transaction %s", ErrFetchProof, txHash.Hex())
+	}
+	block, err := m.Client.BlockByHash(ctx, receipt.BlockHash)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "%s: failed to get block %s", ErrFetchProof, receipt.BlockHash.Hex())
+	}
+
+	return receipt, block, nil
+}
+
+// newListProof builds a trie keyed by RLP-encoded indexes (as transactions and receipts tries are) and proves item at index
+func newListProof(list types.DerivableList, index int) (*MerkleProof, error) {
+	if index < 0 || index >= list.Len() {
+		return nil, fmt.Errorf("index %d is out of range, list has %d items", index, list.Len())
+	}
+
+	tr := trie.NewEmpty(trie.NewDatabase(rawdb.NewMemoryDatabase(), nil))
+	var buf bytes.Buffer
+	var proof *MerkleProof
+	for i := 0; i < list.Len(); i++ {
+		buf.Reset()
+		list.EncodeIndex(i, &buf)
+		key := rlp.AppendUint64(nil, uint64(i))
+		value := common.CopyBytes(buf.Bytes())
+		if err := tr.Update(key, value); err != nil {
+			return nil, err
+		}
+		if i == index {
+			proof = &MerkleProof{Key: key, Value: value}
+		}
+	}
+
+	nodes := &proofNodes{}
+	if err := tr.Prove(proof.Key, nodes); err != nil {
+		return nil, err
+	}
+	proof.Root = tr.Hash()
+	proof.Nodes = nodes.nodes
+
+	return proof, nil
+}
+
+// proofNodes collects trie nodes written by Trie.Prove in path order
+type proofNodes struct {
+	nodes [][]byte
+}
+
+func (p *proofNodes) Put(_ []byte, value []byte) error {
+	p.nodes = append(p.nodes, common.CopyBytes(value))
+	return nil
+}
+
+func (p *proofNodes) Delete(_ []byte) error {
+	return errors.New("proof nodes can't be deleted")
+}
+
+func proofNodesDb(nodes [][]byte) *memorydb.Database {
+	db := memorydb.New()
+	for _, node := range nodes {
+		_ = db.Put(crypto.Keccak256(node), node)
+	}
+
+	return db
+}
+
+func decodeProofNodes(nodes []string) ([][]byte, error) {
+	decoded := make([][]byte, 0, len(nodes))
+	for _, node := range nodes {
+		data, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrInvalidProof)
+		}
+		decoded = append(decoded, data)
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,141 @@
+package seth
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// InclusionTiming describes how long it took for a transaction to be included in a block. Time is measured from the moment
+// Seth started waiting for the transaction, which for the usual `client.Decode(contract.Method(opts))` pattern is right after
+// it was sent.
+type InclusionTiming struct {
+	StartedAt time.Time     `json:"started_at"`
+	MinedAt   time.Time     `json:"mined_at"`
+	Duration  time.Duration `json:"duration"`
+	// Polls is the number of eth_getTransactionReceipt calls made before receipt was found (including calls for replaced transactions)
+	Polls int `json:"polls"`
+	// StartBlock is the latest block number when waiting started, it's 0 if it couldn't be fetched
+	StartBlock     uint64 `json:"start_block"`
+	InclusionBlock uint64 `json:"inclusion_block"`
+	BlocksElapsed  uint64 `json:"blocks_elapsed"`
+	GasBumps       int    `json:"gas_bumps"`
+	// AutoMinedBlocks is the number of blocks mined with evm_mine while waiting, see Network.AutoMineAfter
+	AutoMinedBlocks int `json:"auto_mined_blocks,omitempty"`
+}
+
+// newInclusionTiming starts measuring inclusion time
+func (m *Client) newInclusionTiming(ctx context.Context, l zerolog.Logger) *InclusionTiming {
+	timing := &InclusionTiming{StartedAt: time.Now()}
+	startBlock, err := m.Client.BlockNumber(ctx)
+	if err != nil {
+		l.Debug().
+			Err(err).
+			Msg("Failed to get latest block number. Number of blocks elapsed until inclusion won't be known")
+		return timing
+	}
+	timing.StartBlock = startBlock
+
+	return timing
+}
+
+// finish records receipt in the timing
+func (t *InclusionTiming) finish(receipt *types.Receipt) {
+	t.MinedAt = time.Now()
+	t.Duration = t.MinedAt.Sub(t.StartedAt)
+	if receipt.BlockNumber == nil {
+		return
+	}
+	t.InclusionBlock = receipt.BlockNumber.Uint64()
+	if t.StartBlock != 0 && t.InclusionBlock >= t.StartBlock {
+		t.BlocksElapsed = t.InclusionBlock - t.StartBlock
+	}
+}
+
+// WaitMinedWithTiming waits for the transaction to be mined in the same way as WaitMined, but also returns how long it took, how many
+// times receipt was polled and how many blocks have elapsed until it was included
+func (m *Client) WaitMinedWithTiming(ctx context.Context, l zerolog.Logger, b bind.DeployBackend, tx *types.Transaction) (*types.Receipt, *InclusionTiming, error) {
+	timing := m.newInclusionTiming(ctx, l)
+	receipt, err := m.waitMined(ctx, l, b, tx, timing)
+	if err != nil {
+		return nil, timing, ClassifyError(err)
+	}
+	timing.finish(receipt)
+
+	return receipt, timing, nil
+}
+
+// waitMined polls for transaction receipt until it's found or context is done. Each poll is counted in timing. If transaction
+// validity is limited and transaction isn't mined in time, it's cancelled and TxExpiredError is returned once cancellation is mined.
+// If automatic mining is enabled, a block is mined each time transaction stays pending for too long. With subscription
+// confirmation strategy receipt is checked only when a new block arrives, instead of every receipt poll interval.
+func (m *Client) waitMined(ctx context.Context, l zerolog.Logger, b bind.DeployBackend, tx *types.Transaction, timing *InclusionTiming) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	validity := m.newTxValidity(ctx, l, timing)
+	miner := m.newAutoMiner(timing)
+	heads := m.newHeadWaiter(ctx, l)
+	defer heads.close()
+	m.writes.recordSent(tx)
+	for {
+		timing.Polls++
+		receipt, err := b.TransactionReceipt(ctx, tx.Hash())
+		if err == nil {
+			l.Info().
+				Int64("BlockNumber", receipt.BlockNumber.Int64()).
+				Str("TX", tx.Hash().String()).
+				Msg("Transaction receipt found")
+			m.journalDone(tx)
+			m.writes.recordMined(tx)
+			m.recordSpend(tx, receipt)
+			return receipt, nil
+		}
+		if validity != nil && validity.cancellation != nil {
+			if _, cancellationErr := b.TransactionReceipt(ctx, validity.cancellation.Hash()); cancellationErr == nil {
+				m.journalDone(tx)
+				return nil, &TxExpiredError{
+					Tx:             tx,
+					Cancellation:   validity.cancellation,
+					StartBlock:     validity.startBlock,
+					ValidityBlocks: m.Cfg.Network.TxValidityBlocks,
+				}
+			}
+		}
+		if errors.Is(err, ethereum.NotFound) {
+			l.Debug().
+				Str("TX", tx.Hash().String()).
+				Msg("Awaiting transaction")
+		} else {
+			l.Warn().
+				Err(err).
+				Str("TX", tx.Hash().String()).
+				Msg("Failed to get receipt")
+		}
+		m.cancelIfExpired(ctx, l, tx, validity)
+		m.mineIfStuck(ctx, l, miner, timing)
+		if heads != nil {
+			if !heads.wait(ctx, l) {
+				heads.close()
+				heads = nil
+			}
+			if ctx.Err() != nil {
+				l.Error().Err(err).Msg("Transaction context is done")
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		pollTimer := time.NewTimer(m.receiptPollDelay(timing.Polls))
+		select {
+		case <-ctx.Done():
+			pollTimer.Stop()
+			l.Error().Err(err).Msg("Transaction context is done")
+			return nil, ctx.Err()
+		case <-pollTimer.C:
+		}
+	}
+}
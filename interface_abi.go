@@ -0,0 +1,96 @@
+package seth
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrLoadInterfaceABIs = "failed to load interface ABIs"
+)
+
+// AddInterfaceABI registers ABI of an interface (e.g. AggregatorV3Interface or IERC20), which is used to decode calls to
+// contracts whose own ABI is unknown. Interface ABIs are never used to identify contracts, so they don't change the
+// contract map. Name can be given with or without ".abi" suffix.
+func (c *ContractStore) AddInterfaceABI(name string, abi abi.ABI) {
+	if !strings.HasSuffix(name, ".abi") {
+		name = name + ".abi"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.InterfaceABIs == nil {
+		c.InterfaceABIs = make(ABIStore)
+	}
+	c.InterfaceABIs[name] = abi
+}
+
+// LoadInterfaceABIs registers all ".abi" files in given directory as interface ABIs, see AddInterfaceABI
+func (c *ContractStore) LoadInterfaceABIs(dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, ErrLoadInterfaceABIs)
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".abi") {
+			continue
+		}
+		ff, err := os.Open(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return errors.Wrap(err, ErrOpenABIFile)
+		}
+		a, err := abi.JSON(ff)
+		_ = ff.Close()
+		if err != nil {
+			return errors.Wrapf(err, "%s %s", ErrParseABI, f.Name())
+		}
+		c.AddInterfaceABI(f.Name(), a)
+		L.Debug().Str("File", f.Name()).Msg("Interface ABI file loaded")
+	}
+
+	return nil
+}
+
+// findInterfaceMethod returns the first interface ABI (in alphabetical order) that has a method with given signature
+func (c *ContractStore) findInterfaceMethod(signature []byte) (ABIFinderResult, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make([]string, 0, len(c.InterfaceABIs))
+	for name := range c.InterfaceABIs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		interfaceABI := c.InterfaceABIs[name]
+		method, err := interfaceABI.MethodById(signature)
+		if err != nil {
+			continue
+		}
+		return ABIFinderResult{ABI: interfaceABI, Method: method, contractName: name, isInterface: true}, true
+	}
+
+	return ABIFinderResult{}, false
+}
+
+// loadInterfaceABIs registers interface ABIs from directory set in config, if any
+func (c *ContractStore) loadInterfaceABIs(cfg *Config) error {
+	if cfg.InterfaceABIDir == "" {
+		return nil
+	}
+
+	return c.LoadInterfaceABIs(filepath.Join(cfg.ConfigDir, cfg.InterfaceABIDir))
+}
+
+// RegisterInterfaceABI registers ABI of an interface used to decode calls to contracts whose own ABI is unknown, see
+// ContractStore.AddInterfaceABI
+func (m *Client) RegisterInterfaceABI(name string, abi abi.ABI) {
+	m.ContractStore.AddInterfaceABI(name, abi)
+}
@@ -0,0 +1,416 @@
+package seth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrOpenTxJournal  = "failed to open transaction journal"
+	ErrWriteTxJournal = "failed to write to transaction journal"
+
+	TxJournalResumePolicy_Wait = "WAIT"
+	TxJournalResumePolicy_Bump = "BUMP"
+
+	journalOpAdd  = "add"
+	journalOpDone = "done"
+)
+
+// TxJournalConfig controls persistent journal of in-flight transactions. If file is set, every transaction signed or sent by Seth
+// is written to it until its receipt is found. On start entries left by a previous process are resumed according to resume policy:
+// "wait" (default) waits for their receipts (re-broadcasting transactions node doesn't know about) and "bump" also bumps their gas.
+type TxJournalConfig struct {
+	File         string `toml:"file"`
+	ResumePolicy string `toml:"resume_policy"`
+}
+
+// TxJournalEntry describes a single in-flight transaction
+type TxJournalEntry struct {
+	Hash      common.Hash    `json:"hash"`
+	From      common.Address `json:"from"`
+	KeyNum    int            `json:"key_num"`
+	Nonce     uint64         `json:"nonce"`
+	Purpose   string         `json:"purpose"`
+	RawTx     hexutil.Bytes  `json:"raw_tx"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// Transaction decodes signed transaction stored in the entry
+func (e *TxJournalEntry) Transaction() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(e.RawTx); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode journaled transaction %s", e.Hash.Hex())
+	}
+	return tx, nil
+}
+
+type journalRecord struct {
+	Op    string          `json:"op"`
+	Entry *TxJournalEntry `json:"entry,omitempty"`
+	From  common.Address  `json:"from,omitempty"`
+	Nonce uint64          `json:"nonce,omitempty"`
+}
+
+// TxJournal is an append-only file of in-flight transactions that survives process restarts. Transaction is removed from
+// the journal once a receipt for its sender and nonce is found (which also covers gas-bumped replacements).
+type TxJournal struct {
+	path    string
+	mu      *sync.Mutex
+	file    *os.File
+	entries map[common.Hash]*TxJournalEntry
+	// entries left by a previous process
+	orphaned []TxJournalEntry
+}
+
+// NewTxJournal opens (or creates) transaction journal at given path. Entries that were not completed by the previous process
+// are available with Orphaned(). Journal file is compacted, so that it contains only those entries.
+func NewTxJournal(path string) (*TxJournal, error) {
+	j := &TxJournal{
+		path:    path,
+		mu:      &sync.Mutex{},
+		entries: make(map[common.Hash]*TxJournalEntry),
+	}
+
+	if err := j.load(); err != nil {
+		return nil, errors.Wrap(err, ErrOpenTxJournal)
+	}
+
+	for _, e := range j.entries {
+		j.orphaned = append(j.orphaned, *e)
+	}
+	sort.Slice(j.orphaned, func(a, b int) bool {
+		if j.orphaned[a].From != j.orphaned[b].From {
+			return j.orphaned[a].From.Hex() < j.orphaned[b].From.Hex()
+		}
+		return j.orphaned[a].Nonce < j.orphaned[b].Nonce
+	})
+
+	if err := j.compact(); err != nil {
+		return nil, errors.Wrap(err, ErrOpenTxJournal)
+	}
+
+	return j, nil
+}
+
+func (j *TxJournal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	// raw transactions with large calldata might not fit into default buffer
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// last line might be incomplete if process crashed while writing it
+			L.Warn().Err(err).Int("Line", line).Str("File", j.path).Msg("Skipping malformed transaction journal record")
+			continue
+		}
+		j.apply(record)
+	}
+
+	return scanner.Err()
+}
+
+func (j *TxJournal) apply(record journalRecord) {
+	switch record.Op {
+	case journalOpAdd:
+		if record.Entry != nil {
+			j.entries[record.Entry.Hash] = record.Entry
+		}
+	case journalOpDone:
+		for hash, e := range j.entries {
+			if e.From == record.From && e.Nonce <= record.Nonce {
+				delete(j.entries, hash)
+			}
+		}
+	}
+}
+
+// compact rewrites the journal file, so that it contains only pending entries
+func (j *TxJournal) compact() error {
+	if dir := filepath.Dir(j.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(tmp)
+	for _, e := range j.entries {
+		if err := encoder.Encode(journalRecord{Op: journalOpAdd, Entry: e}); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return err
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+func (j *TxJournal) write(record journalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := j.file.Write(data); err != nil {
+		return errors.Wrap(err, ErrWriteTxJournal)
+	}
+	// make sure that entry is on disk before the transaction is sent
+	return j.file.Sync()
+}
+
+// Add records in-flight transaction
+func (j *TxJournal) Add(entry TxJournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries[entry.Hash] = &entry
+	return j.write(journalRecord{Op: journalOpAdd, Entry: &entry})
+}
+
+// Done removes all transactions of given sender with nonce lower or equal to the given one, because they can't be mined anymore
+func (j *TxJournal) Done(from common.Address, nonce uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	found := false
+	for _, e := range j.entries {
+		if e.From == from && e.Nonce <= nonce {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	record := journalRecord{Op: journalOpDone, From: from, Nonce: nonce}
+	j.apply(record)
+	return j.write(record)
+}
+
+// Pending returns all transactions that are still waiting for a receipt
+func (j *TxJournal) Pending() []TxJournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	pending := make([]TxJournalEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		pending = append(pending, *e)
+	}
+	return pending
+}
+
+// Orphaned returns transactions left in the journal by a previous process, sorted by sender and nonce
+func (j *TxJournal) Orphaned() []TxJournalEntry {
+	return j.orphaned
+}
+
+// Close closes journal file
+func (j *TxJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
+
+// journalTx adds signed transaction to the journal, if it's enabled. Failure to write to the journal is only logged,
+// because it shouldn't prevent the test from running.
+func (m *Client) journalTx(tx *types.Transaction, purpose string) {
+	if m.TxJournal == nil {
+		return
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to get transaction sender, transaction won't be journaled")
+		return
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to encode transaction, transaction won't be journaled")
+		return
+	}
+
+	keyNum := -1
+	for i, addr := range m.Addresses {
+		if addr == from {
+			keyNum = i
+			break
+		}
+	}
+
+	if purpose == "" {
+		purpose = m.transactionPurpose(tx)
+	}
+
+	if err := m.TxJournal.Add(TxJournalEntry{
+		Hash:      tx.Hash(),
+		From:      from,
+		KeyNum:    keyNum,
+		Nonce:     tx.Nonce(),
+		Purpose:   purpose,
+		RawTx:     raw,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to journal transaction")
+	}
+}
+
+// journalDone removes mined transaction (and all other transactions with the same sender and nonce) from the journal
+func (m *Client) journalDone(tx *types.Transaction) {
+	if m.TxJournal == nil {
+		return
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to get transaction sender, transaction won't be removed from journal")
+		return
+	}
+	if err := m.TxJournal.Done(from, tx.Nonce()); err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to mark transaction as done in journal")
+	}
+}
+
+// transactionPurpose returns a short description of what the transaction does
+func (m *Client) transactionPurpose(tx *types.Transaction) string {
+	switch {
+	case tx.To() == nil:
+		return "contract deployment"
+	case len(tx.Data()) == 0:
+		return fmt.Sprintf("transfer to %s", tx.To().Hex())
+	case len(tx.Data()) >= 4:
+		target := tx.To().Hex()
+		if name := m.ContractAddressToNameMap.GetContractLabel(target); name != "" {
+			target = name
+		}
+		return fmt.Sprintf("call 0x%x on %s", tx.Data()[:4], target)
+	default:
+		return fmt.Sprintf("call to %s", tx.To().Hex())
+	}
+}
+
+// journalingSigner wraps signer so that every signed transaction is added to the journal
+func (m *Client) journalingSigner(signer func(common.Address, *types.Transaction) (*types.Transaction, error)) func(common.Address, *types.Transaction) (*types.Transaction, error) {
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		signed, err := signer(address, tx)
+		if err != nil {
+			return nil, err
+		}
+		m.journalTx(signed, "")
+		return signed, nil
+	}
+}
+
+// resumeJournal resolves transactions left in the journal by a previous process. Transactions that were mined or replaced
+// are removed from the journal, transactions that node doesn't know about are re-broadcast and then all remaining ones are
+// waited for (and bumped, if resume policy is "bump"). Transactions that still can't be resolved stay in the journal.
+func (m *Client) resumeJournal() {
+	orphaned := m.TxJournal.Orphaned()
+	if len(orphaned) == 0 {
+		return
+	}
+
+	L.Warn().Int("Transactions", len(orphaned)).Str("File", m.Cfg.TxJournal.File).Msg("Found transactions left in the journal by a previous run, resuming them")
+
+	for _, entry := range orphaned {
+		l := L.With().
+			Str("Transaction", entry.Hash.Hex()).
+			Str("From", entry.From.Hex()).
+			Uint64("Nonce", entry.Nonce).
+			Str("Purpose", entry.Purpose).
+			Logger()
+
+		tx, err := entry.Transaction()
+		if err != nil {
+			l.Warn().Err(err).Msg("Failed to resume journaled transaction")
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+		receipt, err := m.Client.TransactionReceipt(ctx, entry.Hash)
+		if err == nil {
+			cancel()
+			l.Info().Uint64("Block", receipt.BlockNumber.Uint64()).Msg("Journaled transaction was mined")
+			m.journalDone(tx)
+			continue
+		}
+
+		latestNonce, err := m.Client.NonceAt(ctx, entry.From, nil)
+		if err == nil && latestNonce > entry.Nonce {
+			cancel()
+			l.Info().Msg("Journaled transaction was replaced or dropped, nonce was already used")
+			m.journalDone(tx)
+			continue
+		}
+
+		_, _, err = m.Client.TransactionByHash(ctx, entry.Hash)
+		if errors.Is(err, ethereum.NotFound) {
+			l.Warn().Msg("Journaled transaction is unknown to the node, re-broadcasting it")
+			if sendErr := m.Client.SendTransaction(ctx, tx); sendErr != nil {
+				l.Warn().Err(sendErr).Msg("Failed to re-broadcast journaled transaction")
+			}
+		}
+		cancel()
+
+		releaseNonce := m.reserveNonceForBump(nil)
+		if m.Cfg.TxJournal.ResumePolicy == TxJournalResumePolicy_Bump {
+			if entry.KeyNum < 0 {
+				l.Warn().Msg("Private key of journaled transaction's sender is not loaded, it can't be bumped")
+			} else if m.Cfg.GasBump == nil || m.Cfg.GasBump.StrategyFn == nil {
+				l.Warn().Msg("Gas bumping is not configured, journaled transaction won't be bumped")
+			} else {
+				releaseNonce = m.reserveNonceForBump(tx)
+				replacement, bumpErr := prepareReplacementTransaction(m, tx)
+				if bumpErr != nil {
+					l.Warn().Err(bumpErr).Msg("Failed to bump journaled transaction")
+				} else {
+					tx = replacement
+				}
+			}
+		}
+
+		if _, err := m.WaitMined(context.Background(), l, m.Client, tx); err != nil {
+			l.Warn().Err(err).Msg("Journaled transaction wasn't mined, it stays in the journal")
+		}
+		releaseNonce()
+	}
+}
@@ -0,0 +1,104 @@
+package seth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	ErrDuplicateKeys = "duplicate private keys in config"
+)
+
+// findKeyAliases returns key numbers of duplicated keys mapped to the key number of their first occurrence
+func findKeyAliases(addrs []common.Address) map[int]int {
+	aliases := make(map[int]int)
+	first := make(map[common.Address]int, len(addrs))
+	for keyNum, addr := range addrs {
+		if canonical, ok := first[addr]; ok {
+			aliases[keyNum] = canonical
+			continue
+		}
+		first[addr] = keyNum
+	}
+
+	return aliases
+}
+
+// describeKeyAliases returns human-readable list of aliases, e.g. "key 2 is the same as key 0 (0x...)"
+func describeKeyAliases(aliases map[int]int, addrs []common.Address) string {
+	keyNums := make([]int, 0, len(aliases))
+	for keyNum := range aliases {
+		keyNums = append(keyNums, keyNum)
+	}
+	sort.Ints(keyNums)
+
+	descriptions := make([]string, 0, len(keyNums))
+	for _, keyNum := range keyNums {
+		descriptions = append(descriptions, fmt.Sprintf("key %d is the same as key %d (%s)", keyNum, aliases[keyNum], addrs[keyNum].Hex()))
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// validateKeys fails if the same private key is configured more than once and strict keys are enabled. Invalid keys are
+// reported when client is created.
+func (c *Config) validateKeys() error {
+	if !c.StrictKeys {
+		return nil
+	}
+	addrs := make([]common.Address, 0, len(c.Network.PrivateKeys))
+	for _, k := range c.Network.PrivateKeys {
+		pk, err := crypto.HexToECDSA(k)
+		if err != nil {
+			return nil
+		}
+		addrs = append(addrs, crypto.PubkeyToAddress(pk.PublicKey))
+	}
+	if aliases := findKeyAliases(addrs); len(aliases) > 0 {
+		return fmt.Errorf("%s: %s", ErrDuplicateKeys, describeKeyAliases(aliases, addrs))
+	}
+
+	return nil
+}
+
+// setKeyAliases records which keys are duplicates of other keys, so that they are never used concurrently with them.
+// Duplicates are not removed, so that key numbers match positions of keys in config.
+func (c *Config) setKeyAliases(addrs []common.Address) {
+	c.keyAliases = findKeyAliases(addrs)
+	if len(c.keyAliases) == 0 {
+		return
+	}
+	L.Warn().
+		Str("Duplicates", describeKeyAliases(c.keyAliases, addrs)).
+		Msg("Same private key is configured more than once. Duplicates are treated as aliases of the first key and won't be used concurrently with it. Set 'strict_keys = true' to fail instead")
+}
+
+// IsKeyAlias returns true if key with given number is a duplicate of a key with lower number
+func (c *Config) IsKeyAlias(keyNum int) bool {
+	_, ok := c.keyAliases[keyNum]
+	return ok
+}
+
+// CanonicalKeyNum returns number of the first occurrence of the key with given number, which is the key number itself
+// unless it's a duplicate
+func (c *Config) CanonicalKeyNum(keyNum int) int {
+	if canonical, ok := c.keyAliases[keyNum]; ok {
+		return canonical
+	}
+	return keyNum
+}
+
+// distinctKeyNums returns numbers of all keys, but the root one, that aren't duplicates of other keys
+func (m *Client) distinctKeyNums() []int {
+	keyNums := make([]int, 0, len(m.Addresses))
+	for keyNum := 1; keyNum < len(m.Addresses); keyNum++ {
+		if !m.Cfg.IsKeyAlias(keyNum) {
+			keyNums = append(keyNums, keyNum)
+		}
+	}
+	return keyNums
+}
@@ -0,0 +1,115 @@
+package seth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrNoAlternateKey = "no alternate key to retry with"
+
+	// MaxKeySubstitutions is the maximum number of times a key-agnostic transaction is retried with another key, so that
+	// errors that affect all keys (e.g. none of them is funded) don't make Seth try every single key
+	MaxKeySubstitutions = 3
+)
+
+// KeyAgnosticFn sends a transaction using given transaction options, e.g. a call to contract wrapper. It must not depend on
+// the sender, since it might be called again with options of a different key.
+type KeyAgnosticFn func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// KeySubstitution records that a transaction was sent with a different key, because the requested one failed
+type KeySubstitution struct {
+	KeyNum           int    `json:"key_num"`
+	SubstituteKeyNum int    `json:"substitute_key_num"`
+	Reason           string `json:"reason"`
+}
+
+// keySubstitutions is a log of all key substitutions made by the client
+type keySubstitutions struct {
+	mu   sync.Mutex
+	list []KeySubstitution
+}
+
+// IsKeySpecificErr returns true if error is caused by the state of sender's key (insufficient funds, poisoned nonce), so
+// that the same transaction sent with another key would likely succeed
+func IsKeySpecificErr(err error) bool {
+	err = ClassifyError(err)
+
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrNonceConflict)
+}
+
+// SendWithAnyKey sends transaction created by fn with given key, waits for it and decodes it. Calling it marks the operation
+// as key-agnostic: if sending fails with a key-specific error (see IsKeySpecificErr), transaction is created again with
+// another synced key (one without pending transactions), up to MaxKeySubstitutions times. Root key and duplicated keys are
+// never used as substitutes. Each substitution is logged, added to decoded transaction as a warning and recorded in
+// KeySubstitutions(). Other errors, including reverts, are returned as they are.
+func (m *Client) SendWithAnyKey(keyNum int, fn KeyAgnosticFn) (*DecodedTransaction, error) {
+	tried := map[int]bool{}
+	var substitutions []KeySubstitution
+	for {
+		tried[m.Cfg.CanonicalKeyNum(keyNum)] = true
+		decoded, err := m.Decode(fn(m.NewTXKeyOpts(keyNum)))
+		if err == nil || !IsKeySpecificErr(err) {
+			if decoded != nil {
+				for _, s := range substitutions {
+					decoded.addWarning(DecodeWarning_KeySubstituted, fmt.Sprintf("key %d was used instead of key %d: %s", s.SubstituteKeyNum, s.KeyNum, s.Reason))
+				}
+			}
+			return decoded, err
+		}
+		if len(substitutions) >= MaxKeySubstitutions {
+			return decoded, errors.Wrapf(err, "%s, already tried %d keys", ErrNoAlternateKey, len(tried))
+		}
+
+		substitute, ok := m.alternateKey(tried)
+		if !ok {
+			return decoded, errors.Wrap(err, ErrNoAlternateKey)
+		}
+
+		substitution := KeySubstitution{KeyNum: keyNum, SubstituteKeyNum: substitute, Reason: err.Error()}
+		L.Warn().
+			Err(err).
+			Int("KeyNum", keyNum).
+			Str("Address", m.keyAddress(keyNum).Hex()).
+			Int("Substitute KeyNum", substitute).
+			Str("Substitute address", m.keyAddress(substitute).Hex()).
+			Msg("Sending transaction failed because of key-specific error. Retrying with another key")
+		substitutions = append(substitutions, substitution)
+		m.keySubstitutions.mu.Lock()
+		m.keySubstitutions.list = append(m.keySubstitutions.list, substitution)
+		m.keySubstitutions.mu.Unlock()
+		keyNum = substitute
+	}
+}
+
+// KeySubstitutions returns all key substitutions made by SendWithAnyKey
+func (m *Client) KeySubstitutions() []KeySubstitution {
+	m.keySubstitutions.mu.Lock()
+	defer m.keySubstitutions.mu.Unlock()
+
+	return append([]KeySubstitution{}, m.keySubstitutions.list...)
+}
+
+// alternateKey returns the first key that wasn't tried yet and has no pending transactions
+func (m *Client) alternateKey(tried map[int]bool) (int, bool) {
+	for _, keyNum := range m.distinctKeyNums() {
+		if tried[keyNum] {
+			continue
+		}
+		status, err := m.getNonceStatus(m.keyAddress(keyNum))
+		if err != nil || status.PendingNonce != status.LastNonce {
+			L.Debug().
+				Int("KeyNum", keyNum).
+				Msg("Key isn't synced. It won't be used as a substitute")
+			continue
+		}
+
+		return keyNum, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ErrKeyLabels = "invalid key labels"
+)
+
+// splitEnvList splits comma-separated value of an env var and trims spaces around items. Empty items are kept, so that
+// positions of the other ones don't change (e.g. "deployer,,funder" has no label for key 1). Empty value returns nil.
+func splitEnvList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	items := strings.Split(value, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
+}
+
+// nonEmpty returns items that aren't empty
+func nonEmpty(items []string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+// KeyLabel returns label of key with given number (0 is the root key) or empty string if it has none
+func (n *Network) KeyLabel(keyNum int) string {
+	if keyNum < 0 || keyNum >= len(n.KeyLabels) {
+		return ""
+	}
+
+	return n.KeyLabels[keyNum]
+}
+
+// validateKeyLabels checks that labels are unique and that there are no more labels than keys. Ephemeral keys are
+// generated when client is created, so labels of ephemeral networks aren't counted.
+func (c *Config) validateKeyLabels() error {
+	seen := make(map[string]int, len(c.Network.KeyLabels))
+	for keyNum, label := range c.Network.KeyLabels {
+		if label == "" {
+			continue
+		}
+		if other, ok := seen[label]; ok {
+			return fmt.Errorf("%s: key %d and key %d have the same label '%s'", ErrKeyLabels, other, keyNum, label)
+		}
+		seen[label] = keyNum
+	}
+	keys := len(c.Network.PrivateKeys) + len(c.Network.NodeAccounts)
+	if (c.EphemeralAddrs == nil || *c.EphemeralAddrs == 0) && len(c.Network.KeyLabels) > keys {
+		return fmt.Errorf("%s: there are %d labels, but only %d keys, check %s and key_labels", ErrKeyLabels, len(c.Network.KeyLabels), keys, ADDRESS_LABELS_ENV_VAR)
+	}
+
+	return nil
+}
+
+// AddressLabel returns label of client's key with given address or empty string if it's not client's key or it has no label
+func (m *Client) AddressLabel(address common.Address) string {
+	for keyNum, a := range m.Addresses {
+		if a == address {
+			return m.Cfg.Network.KeyLabel(keyNum)
+		}
+	}
+
+	return ""
+}
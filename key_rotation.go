@@ -0,0 +1,254 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrKeyRotationNoNonceManager = "key rotation requires nonce manager"
+	ErrKeyRotationRootKey        = "root key cannot be rotated"
+	ErrKeyRotationNodeAccount    = "node account cannot be rotated"
+)
+
+// KeyRotationConfig configures retiring keys after they were used for given number of transactions or when their nonce
+// reaches given threshold. At least one of the limits must be set.
+type KeyRotationConfig struct {
+	MaxTransactionsPerKey uint64 `toml:"max_transactions_per_key"`
+	MaxNonce              uint64 `toml:"max_nonce"`
+}
+
+// IsKeyRotationEnabled returns true if key rotation is configured
+func (c *Config) IsKeyRotationEnabled() bool {
+	return c.KeyRotation != nil && (c.KeyRotation.MaxTransactionsPerKey > 0 || c.KeyRotation.MaxNonce > 0)
+}
+
+// RetiredKey describes a key that was replaced by key rotation. If funds couldn't be returned from it, its private key
+// can be used to recover them.
+type RetiredKey struct {
+	KeyNum        int
+	Address       common.Address
+	PrivateKey    string
+	ReplacedBy    common.Address
+	Transactions  uint64
+	LastNonce     uint64
+	ReturnedFunds *big.Int
+	Err           error
+}
+
+type keyUsage struct {
+	transactions uint64
+	nonce        uint64
+}
+
+// KeyRotator retires non-root keys that exceeded configured limits. Each retired key is replaced by a newly generated one
+// in the same slot (so keyNum used by the caller stays valid). Funds from the retired key are first returned to the root
+// key and then the replacement is funded from the root key with the returned amount, so that root key doesn't need to
+// hold balance of the retired key on top of its own. Root key is never rotated.
+type KeyRotator struct {
+	client  *Client
+	cfg     *KeyRotationConfig
+	mu      *sync.Mutex
+	usage   map[int]*keyUsage
+	retired []RetiredKey
+}
+
+// NewKeyRotator creates a new key rotator
+func NewKeyRotator(client *Client, cfg *KeyRotationConfig) *KeyRotator {
+	return &KeyRotator{
+		client: client,
+		cfg:    cfg,
+		mu:     &sync.Mutex{},
+		usage:  make(map[int]*keyUsage),
+	}
+}
+
+// RecordUse records that key was used to prepare a transaction with given nonce
+func (k *KeyRotator) RecordUse(keyNum int, nonce uint64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	u, ok := k.usage[keyNum]
+	if !ok {
+		u = &keyUsage{}
+		k.usage[keyNum] = u
+	}
+	u.transactions++
+	u.nonce = nonce
+}
+
+// ShouldRotate returns true if key exceeded any of the configured limits
+func (k *KeyRotator) ShouldRotate(keyNum int) bool {
+	// node accounts can't be replaced with generated keys
+	if keyNum == 0 || k.client.IsNodeAccount(keyNum) {
+		return false
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	u, ok := k.usage[keyNum]
+	if !ok {
+		return false
+	}
+
+	return (k.cfg.MaxTransactionsPerKey > 0 && u.transactions >= k.cfg.MaxTransactionsPerKey) ||
+		(k.cfg.MaxNonce > 0 && u.nonce+1 >= k.cfg.MaxNonce)
+}
+
+// RotateIfNeeded rotates the key if it exceeded any of the configured limits. If rotation fails the key stays in use
+// and the error is logged, so that a long-running test is not interrupted.
+func (k *KeyRotator) RotateIfNeeded(keyNum int) {
+	if !k.ShouldRotate(keyNum) {
+		return
+	}
+
+	if err := k.Rotate(context.Background(), keyNum); err != nil {
+		L.Error().
+			Err(err).
+			Int("KeyNum", keyNum).
+			Msg("Failed to rotate key. Will continue using the old one")
+	}
+}
+
+// Rotate returns funds from the key in given slot back to the root key, funds a newly generated key with them and replaces
+// the old key with it. If funds couldn't be returned, replacement is funded with the balance of the old key instead.
+func (k *KeyRotator) Rotate(ctx context.Context, keyNum int) error {
+	if keyNum == 0 {
+		return errors.New(ErrKeyRotationRootKey)
+	}
+
+	c := k.client
+	if c.IsNodeAccount(keyNum) {
+		return errors.New(ErrKeyRotationNodeAccount)
+	}
+	if c.NonceManager == nil {
+		return errors.New(ErrKeyRotationNoNonceManager)
+	}
+
+	// rotations are rare, so we serialize them to avoid competing for root key funds
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	oldAddr := c.Addresses[keyNum]
+	oldKey := c.PrivateKeys[keyNum]
+
+	newAddrHex, newKeyHex, err := NewAddress()
+	if err != nil {
+		return err
+	}
+	newAddr := common.HexToAddress(newAddrHex)
+	newKey, err := crypto.HexToECDSA(newKeyHex)
+	if err != nil {
+		return err
+	}
+
+	workflow := NewFundingWorkflow(c, nil)
+	gasPrice := workflow.suggestedGasPrice()
+	// send funds back using the old key, before it's swapped out of the slot
+	transfer, returnErr := workflow.returnFundsFromKey(ctx, oldAddr, oldKey, c.Addresses[0].Hex(), gasPrice)
+
+	funding := transfer.Amount
+	if returnErr != nil {
+		funding = transfer.BalanceBefore
+	}
+	if funding == nil {
+		balance, err := c.balanceOf(ctx, oldAddr, BlockTag_Latest)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get balance of %s", oldAddr.Hex())
+		}
+		funding = balance
+	}
+	if err := c.TransferETHFromKey(ctx, 0, newAddrHex, funding, gasPrice); err != nil {
+		return errors.Wrap(err, "failed to fund replacement key")
+	}
+
+	// nonce manager's lock is taken first, the same way as when nonce gaps are healed
+	c.NonceManager.Lock()
+	c.keysMu.Lock()
+	c.Addresses[keyNum] = newAddr
+	c.PrivateKeys[keyNum] = newKey
+	c.keysMu.Unlock()
+	if _, ok := c.NonceManager.Nonces[newAddr]; !ok {
+		c.NonceManager.Nonces[newAddr] = 0
+	}
+	c.NonceManager.Unlock()
+	if c.Tracer != nil {
+		c.Tracer.replaceAddress(keyNum, newAddr)
+	}
+
+	u := k.usage[keyNum]
+	delete(k.usage, keyNum)
+	retired := RetiredKey{
+		KeyNum:        keyNum,
+		Address:       oldAddr,
+		PrivateKey:    common.Bytes2Hex(crypto.FromECDSA(oldKey)),
+		ReplacedBy:    newAddr,
+		ReturnedFunds: transfer.Amount,
+		Err:           returnErr,
+	}
+	if u != nil {
+		retired.Transactions = u.transactions
+		retired.LastNonce = u.nonce
+	}
+	k.retired = append(k.retired, retired)
+
+	l := L.Info()
+	if returnErr != nil {
+		l = L.Warn().Err(returnErr)
+	}
+	l.Int("KeyNum", keyNum).
+		Str("Retired key", oldAddr.Hex()).
+		Str("New key", newAddr.Hex()).
+		Uint64("Transactions", retired.Transactions).
+		Str("Funding", c.FormatNativeAmount(funding)).
+		Str("Returned funds", c.FormatNativeAmount(transfer.Amount)).
+		Msg("Rotated key")
+
+	return nil
+}
+
+// RetiredKeys returns all keys retired so far
+func (k *KeyRotator) RetiredKeys() []RetiredKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	retired := make([]RetiredKey, len(k.retired))
+	copy(retired, k.retired)
+	return retired
+}
+
+// RetryFailedReturns tries again to return funds from retired keys, for which it failed before. It returns the number of keys
+// for which it still failed.
+func (k *KeyRotator) RetryFailedReturns(ctx context.Context) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	c := k.client
+	workflow := NewFundingWorkflow(c, nil)
+	gasPrice := workflow.suggestedGasPrice()
+	failed := 0
+	for i := range k.retired {
+		retired := &k.retired[i]
+		if retired.Err == nil {
+			continue
+		}
+		key, err := crypto.HexToECDSA(retired.PrivateKey)
+		if err != nil {
+			retired.Err = err
+			failed++
+			continue
+		}
+		transfer, err := workflow.returnFundsFromKey(ctx, retired.Address, key, c.Addresses[0].Hex(), gasPrice)
+		retired.Err = err
+		if err != nil {
+			failed++
+			continue
+		}
+		retired.ReturnedFunds = transfer.Amount
+	}
+
+	return failed
+}
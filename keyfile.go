@@ -3,14 +3,66 @@ package seth
 import (
 	"context"
 	"crypto/ecdsa"
+	"math/big"
+	"os"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/pkg/errors"
-	"golang.org/x/sync/errgroup"
-	"math/big"
 )
 
+const (
+	ErrReadKeyFile      = "failed to read keyfile"
+	ErrUnmarshalKeyFile = "failed to unmarshal keyfile"
+	ErrWriteKeyFile     = "failed to write keyfile"
+)
+
+// KeyFile is a TOML file with keys that are used after the root key (e.g. keys funded once and reused between test runs)
+type KeyFile struct {
+	Keys []*KeyData `toml:"keys"`
+}
+
+// KeyData is a single key stored in the keyfile
+type KeyData struct {
+	PrivateKey string `toml:"private_key"`
+	Address    string `toml:"address"`
+	// Funds is the balance of the key in wei, as of the last keyfile update
+	Funds string `toml:"funds"`
+}
+
+// ReadKeyFile reads keyfile from given path
+func ReadKeyFile(path string) (*KeyFile, error) {
+	d, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrReadKeyFile)
+	}
+	kf := &KeyFile{}
+	if err := toml.Unmarshal(d, kf); err != nil {
+		return nil, errors.Wrap(err, ErrUnmarshalKeyFile)
+	}
+	return kf, nil
+}
+
+// Write writes keyfile to given path, it's readable only by the owner, because it contains private keys
+func (k *KeyFile) Write(path string) error {
+	marshalled, err := toml.Marshal(k)
+	if err != nil {
+		return errors.Wrap(err, ErrWriteKeyFile)
+	}
+	return errors.Wrap(os.WriteFile(path, marshalled, 0600), ErrWriteKeyFile)
+}
+
+// PrivateKeys returns private keys from the keyfile in the order they are stored in
+func (k *KeyFile) PrivateKeys() []string {
+	pks := make([]string, 0, len(k.Keys))
+	for _, key := range k.Keys {
+		pks = append(pks, key.PrivateKey)
+	}
+	return pks
+}
+
 // NewAddress creates a new address
 func NewAddress() (string, string, error) {
 	privateKey, err := crypto.GenerateKey()
@@ -32,74 +84,20 @@ func NewAddress() (string, string, error) {
 
 // ReturnFunds returns funds to the root key from all other keys
 func ReturnFunds(c *Client, toAddr string) error {
-	if toAddr == "" {
-		toAddr = c.Addresses[0].Hex()
-	}
-
-	gasPrice, err := c.GetSuggestedLegacyFees(context.Background(), Priority_Standard)
-	if err != nil {
-		gasPrice = big.NewInt(c.Cfg.Network.GasPrice)
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	eg, egCtx := errgroup.WithContext(ctx)
-
-	if len(c.Addresses) == 1 {
-		return errors.New("No addresses to return funds from. Have you passed correct key file?")
-	}
-
-	for i := 1; i < len(c.Addresses); i++ {
-		idx := i
-		eg.Go(func() error {
-			balance, err := c.Client.BalanceAt(context.Background(), c.Addresses[idx], nil)
-			if err != nil {
-				L.Error().Err(err).Msg("Error getting balance")
-				return err
-			}
-
-			var gasLimit int64
-			gasLimitRaw, err := c.EstimateGasLimitForFundTransfer(c.Addresses[idx], common.HexToAddress(toAddr), balance)
-			if err != nil {
-				gasLimit = c.Cfg.Network.TransferGasFee
-			} else {
-				gasLimit = int64(gasLimitRaw)
-			}
-
-			networkTransferFee := gasPrice.Int64() * gasLimit
-			fundsToReturn := new(big.Int).Sub(balance, big.NewInt(networkTransferFee))
-
-			if fundsToReturn.Cmp(big.NewInt(0)) == -1 {
-				L.Warn().
-					Str("Key", c.Addresses[idx].Hex()).
-					Interface("Balance", balance).
-					Interface("NetworkFee", networkTransferFee).
-					Interface("FundsToReturn", fundsToReturn).
-					Msg("Insufficient funds to return. Skipping.")
-				return nil
-			}
-
-			L.Info().
-				Str("Key", c.Addresses[idx].Hex()).
-				Interface("Balance", balance).
-				Interface("NetworkFee", c.Cfg.Network.GasPrice*gasLimit).
-				Interface("GasLimit", gasLimit).
-				Interface("GasPrice", gasPrice).
-				Interface("FundsToReturn", fundsToReturn).
-				Msg("Returning funds from address")
+	_, err := NewFundingWorkflow(c, nil).ReturnFunds(context.Background(), toAddr)
+	return err
+}
 
-			return c.TransferETHFromKey(
-				egCtx,
-				idx,
-				toAddr,
-				fundsToReturn,
-				gasPrice,
-			)
-		})
-	}
-	if err := eg.Wait(); err != nil {
-		return err
-	}
+// ReturnTokenFunds returns ERC-20 token balances to toAddr (or to the root key if it's empty) from all other keys. It has to be
+// called before ReturnFunds, because keys pay for token transfers with native funds.
+func ReturnTokenFunds(c *Client, tokenAddress, toAddr string) error {
+	_, err := NewFundingWorkflow(c, nil).ReturnTokenFunds(context.Background(), common.HexToAddress(tokenAddress), toAddr)
+	return err
+}
 
-	return nil
+// SplitTokenFunds splits root key's ERC-20 token balance between all other keys (e.g. ephemeral ones). If amount is nil, whole
+// balance is split evenly, otherwise each key gets amount.
+func SplitTokenFunds(c *Client, tokenAddress string, amount *big.Int) error {
+	_, err := NewFundingWorkflow(c, nil).SplitTokenFunds(context.Background(), common.HexToAddress(tokenAddress), c.Addresses[1:], amount)
+	return err
 }
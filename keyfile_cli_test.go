@@ -0,0 +1,122 @@
+package seth_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+	sethcmd "github.com/smartcontractkit/seth/cmd"
+	"github.com/smartcontractkit/seth/test_utils/localchain"
+)
+
+// newKeyFileCLIOptions starts local chain and returns options pointing at it, with keyfile in a temporary directory
+func newKeyFileCLIOptions(t *testing.T) (seth.ConfigOptions, *localchain.Chain) {
+	chain := localchain.Start(t, localchain.Opts{})
+
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "seth.toml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`
+[nonce_manager]
+key_sync_rate_limit_per_sec = 10
+key_sync_timeout = "20s"
+key_sync_retry_delay = "1s"
+key_sync_retries = 10
+
+[[networks]]
+name = "Default"
+transaction_timeout = "30s"
+transfer_gas_fee = 21_000
+gas_price = 1_000_000_000
+`), 0600), "failed to write config")
+
+	return seth.ConfigOptions{
+		Path:           cfgPath,
+		URL:            chain.URL,
+		RootPrivateKey: chain.PrivateKeys[0],
+		KeyFilePath:    filepath.Join(dir, "keyfile.toml"),
+	}, chain
+}
+
+func TestCLIKeyFileFundUpdateAndReturn(t *testing.T) {
+	opts, chain := newKeyFileCLIOptions(t)
+	amount := big.NewInt(1_000_000_000_000_000_000)
+
+	report, err := sethcmd.Fund(opts, 2, amount)
+	require.NoError(t, err, "failed to fund keys")
+	require.Equal(t, 2, report.Succeeded, "both new keys should have been funded")
+
+	kf, err := seth.ReadKeyFile(opts.KeyFilePath)
+	require.NoError(t, err, "failed to read keyfile")
+	require.Len(t, kf.Keys, 2, "new keys should have been saved to keyfile")
+	for _, key := range kf.Keys {
+		require.Equal(t, amount.String(), key.Funds, "keyfile should contain funded balance")
+	}
+
+	// keys that already have funds are skipped, only the new one is funded
+	report, err = sethcmd.Fund(opts, 1, amount)
+	require.NoError(t, err, "failed to fund keys")
+	require.Equal(t, 1, report.Succeeded, "only the new key should have been funded")
+	require.Equal(t, 2, report.Skipped, "funded keys should have been skipped")
+
+	// keys are loaded by the config as well, so that they can be used by commands and clients
+	cfg, err := seth.ReadConfigWithOptions(opts)
+	require.NoError(t, err, "failed to read config")
+	require.Len(t, cfg.Network.PrivateKeys, 4, "root key and keyfile keys should have been loaded")
+
+	recipient := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	report, err = sethcmd.ReturnFunds(opts, recipient.Hex())
+	require.NoError(t, err, "failed to return funds")
+	require.Equal(t, 3, report.Succeeded, "funds should have been returned from all keyfile keys")
+
+	balance, err := chain.Client.BalanceOf(recipient, seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance")
+	require.Equal(t, 1, balance.Sign(), "recipient should have received funds")
+
+	kf, err = seth.ReadKeyFile(opts.KeyFilePath)
+	require.NoError(t, err, "failed to read keyfile")
+	require.Len(t, kf.Keys, 3, "keys should stay in keyfile after returning funds")
+	for _, key := range kf.Keys {
+		require.Equal(t, "0", key.Funds, "keyfile should contain balance after returning funds")
+	}
+
+	// balances changed by other clients are picked up by update
+	c, err := seth.NewClientWithConfig(cfg)
+	require.NoError(t, err, "failed to create client")
+	t.Cleanup(c.Close)
+	require.NoError(t, c.TransferETHFromKey(context.Background(), 0, kf.Keys[0].Address, amount, nil), "failed to transfer funds")
+	require.NoError(t, sethcmd.UpdateKeyfile(opts), "failed to update keyfile")
+	kf, err = seth.ReadKeyFile(opts.KeyFilePath)
+	require.NoError(t, err, "failed to read keyfile")
+	require.Equal(t, amount.String(), kf.Keys[0].Funds, "keyfile should contain updated balance")
+
+	// CLI passes keyfile from flag to the same functions
+	err = sethcmd.RunCLI([]string{"seth", "-c", opts.Path, "-u", opts.URL, "-k", opts.RootPrivateKey, "--keyfile", opts.KeyFilePath, "keys", "return"})
+	require.NoError(t, err, "failed to return funds with CLI")
+	kf, err = seth.ReadKeyFile(opts.KeyFilePath)
+	require.NoError(t, err, "failed to read keyfile")
+	require.Equal(t, "0", kf.Keys[0].Funds, "funds should have been returned with CLI")
+}
+
+func TestCLIKeyFileRequiresKeyFileAndRootKey(t *testing.T) {
+	opts, _ := newKeyFileCLIOptions(t)
+
+	noKeyFile := opts
+	noKeyFile.KeyFilePath = ""
+	_, err := sethcmd.Fund(noKeyFile, 1, nil)
+	require.EqualError(t, err, sethcmd.ErrNoKeyFile, "keyfile should be required")
+
+	noRootKey := opts
+	noRootKey.RootPrivateKey = ""
+	_, err = sethcmd.Fund(noRootKey, 1, nil)
+	require.EqualError(t, err, sethcmd.ErrNoRootPrivateKey, "root key should be required")
+	require.NoFileExists(t, opts.KeyFilePath, "keyfile shouldn't be created when options are invalid")
+
+	err = sethcmd.UpdateKeyfile(opts)
+	require.ErrorContains(t, err, seth.ErrReadKeyFile, "keyfile should exist when updating it")
+}
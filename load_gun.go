@@ -0,0 +1,125 @@
+package seth
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrLoadCallKeySync = "no synced key available for load test call"
+)
+
+// LoadCallFn sends a single transaction of a load test using transaction options of the key selected for the call, e.g.:
+//
+//	func(opts *bind.TransactOpts) (*types.Transaction, error) {
+//		return contract.AddCounter(opts, big.NewInt(0), big.NewInt(1))
+//	}
+type LoadCallFn func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// LoadCallResult is the outcome of a single load test call
+type LoadCallResult struct {
+	KeyNum     int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Latency is time from requesting transaction options until transaction was mined and decoded
+	Latency           time.Duration
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	Decoded           *DecodedTransaction
+	Err               error
+}
+
+// LoadStats are aggregated metrics of all calls made by a LoadGun
+type LoadStats struct {
+	Calls      int
+	Failed     int
+	GasUsed    uint64
+	AvgLatency time.Duration
+	MaxLatency time.Duration
+}
+
+// LoadGun wraps Seth client for load testing frameworks (e.g. WASP): for each call it selects a key, sends the transaction
+// created by call function, waits for it with Decode and measures latency and gas used. Load test only needs to supply the
+// contract call. It is safe to use from multiple goroutines.
+type LoadGun struct {
+	client *Client
+	callFn LoadCallFn
+
+	mu           sync.Mutex
+	stats        LoadStats
+	totalLatency time.Duration
+}
+
+// NewLoadGun creates a LoadGun that sends transactions created by callFn
+func NewLoadGun(client *Client, callFn LoadCallFn) *LoadGun {
+	return &LoadGun{
+		client: client,
+		callFn: callFn,
+	}
+}
+
+// Call sends a single transaction and waits until it's mined and decoded. If client has more than one key, the first
+// synced one (i.e. one without pending transactions) is used, so that concurrent calls never share a key, otherwise the
+// root key is used.
+func (g *LoadGun) Call() *LoadCallResult {
+	result := &LoadCallResult{
+		KeyNum:    g.selectKey(),
+		StartedAt: time.Now(),
+	}
+	if result.KeyNum == TimeoutKeyNum {
+		result.Err = errors.New(ErrLoadCallKeySync)
+		result.FinishedAt = result.StartedAt
+		g.record(result)
+		return result
+	}
+
+	result.Decoded, result.Err = g.client.Decode(g.callFn(g.client.NewTXKeyOpts(result.KeyNum)))
+	result.FinishedAt = time.Now()
+	result.Latency = result.FinishedAt.Sub(result.StartedAt)
+	if result.Decoded != nil && result.Decoded.Receipt != nil {
+		result.GasUsed = result.Decoded.Receipt.GasUsed
+		result.EffectiveGasPrice = result.Decoded.Receipt.EffectiveGasPrice
+	}
+	g.record(result)
+
+	return result
+}
+
+// Stats returns metrics aggregated over all calls made so far
+func (g *LoadGun) Stats() LoadStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stats := g.stats
+	if stats.Calls > 0 {
+		stats.AvgLatency = g.totalLatency / time.Duration(stats.Calls)
+	}
+
+	return stats
+}
+
+func (g *LoadGun) selectKey() int {
+	if g.client.NonceManager == nil || len(g.client.Addresses) < 2 {
+		return 0
+	}
+
+	return g.client.AnySyncedKey()
+}
+
+func (g *LoadGun) record(result *LoadCallResult) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stats.Calls++
+	if result.Err != nil {
+		g.stats.Failed++
+	}
+	g.stats.GasUsed += result.GasUsed
+	g.totalLatency += result.Latency
+	if result.Latency > g.stats.MaxLatency {
+		g.stats.MaxLatency = result.Latency
+	}
+}
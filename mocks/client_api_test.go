@@ -0,0 +1,41 @@
+package mocks_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/mocks"
+)
+
+func deployWithRetry(c seth.ClientAPI, name string) (seth.DeploymentData, error) {
+	data, err := c.DeployContractFromContractStore(c.NewTXOpts(), name)
+	if err != nil {
+		return c.DeployContractFromContractStore(c.NewTXOpts(), name)
+	}
+	return data, nil
+}
+
+func TestClientAPIMock(t *testing.T) {
+	c := mocks.NewClientAPI(t)
+	opts := &bind.TransactOpts{}
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1})
+
+	c.On("NewTXOpts").Return(opts).Twice()
+	c.On("DeployContractFromContractStore", opts, "LinkToken").Return(seth.DeploymentData{}, errors.New("nonce too low")).Once()
+	c.On("DeployContractFromContractStore", opts, "LinkToken").Return(seth.DeploymentData{Transaction: tx}, nil).Once()
+
+	data, err := deployWithRetry(c, "LinkToken")
+	require.NoError(t, err, "deployment should succeed on retry")
+	require.Equal(t, tx, data.Transaction, "incorrect transaction")
+
+	c.On("Decode", tx, mock.Anything).Return(&seth.DecodedTransaction{Hash: tx.Hash().Hex()}, nil)
+	decoded, err := c.Decode(tx, nil)
+	require.NoError(t, err, "failed to decode transaction")
+	require.Equal(t, tx.Hash().Hex(), decoded.Hash, "incorrect hash")
+}
@@ -0,0 +1,102 @@
+package seth
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// NativeCurrency describes native currency of a chain, which is used to pay transaction fees
+type NativeCurrency struct {
+	Symbol   string
+	Decimals uint8
+	// BaseUnit is the name of the smallest unit of the currency (e.g. wei)
+	BaseUnit string
+}
+
+var (
+	DefaultNativeCurrency = NativeCurrency{Symbol: "ETH", Decimals: 18, BaseUnit: "wei"}
+
+	// ChainNativeCurrencies is a registry of native currencies of known chains (by chain ID). Chains that are not
+	// present in the registry are assumed to use DefaultNativeCurrency. Values can be overridden per network in the config.
+	ChainNativeCurrencies = map[int64]NativeCurrency{
+		1:          DefaultNativeCurrency,                              // Ethereum Mainnet
+		10:         DefaultNativeCurrency,                              // Optimism
+		25:         {Symbol: "CRO", Decimals: 18, BaseUnit: "wei"},     // Cronos
+		56:         {Symbol: "BNB", Decimals: 18, BaseUnit: "wei"},     // BNB Smart Chain
+		97:         {Symbol: "tBNB", Decimals: 18, BaseUnit: "wei"},    // BNB Smart Chain Testnet
+		100:        {Symbol: "xDAI", Decimals: 18, BaseUnit: "wei"},    // Gnosis
+		137:        {Symbol: "POL", Decimals: 18, BaseUnit: "wei"},     // Polygon
+		250:        {Symbol: "FTM", Decimals: 18, BaseUnit: "wei"},     // Fantom
+		295:        {Symbol: "HBAR", Decimals: 18, BaseUnit: "weibar"}, // Hedera Mainnet (JSON-RPC relay uses 18 decimals)
+		296:        {Symbol: "HBAR", Decimals: 18, BaseUnit: "weibar"}, // Hedera Testnet (JSON-RPC relay uses 18 decimals)
+		1284:       {Symbol: "GLMR", Decimals: 18, BaseUnit: "wei"},    // Moonbeam
+		1285:       {Symbol: "MOVR", Decimals: 18, BaseUnit: "wei"},    // Moonriver
+		1337:       DefaultNativeCurrency,                              // Geth
+		2021:       {Symbol: "RON", Decimals: 18, BaseUnit: "wei"},     // Ronin
+		8453:       DefaultNativeCurrency,                              // Base
+		31337:      DefaultNativeCurrency,                              // Anvil
+		42161:      DefaultNativeCurrency,                              // Arbitrum One
+		43113:      {Symbol: "AVAX", Decimals: 18, BaseUnit: "wei"},    // Avalanche Fuji
+		43114:      {Symbol: "AVAX", Decimals: 18, BaseUnit: "wei"},    // Avalanche C-Chain
+		80002:      {Symbol: "POL", Decimals: 18, BaseUnit: "wei"},     // Polygon Amoy
+		11155111:   DefaultNativeCurrency,                              // Sepolia
+		1313161554: {Symbol: "ETH", Decimals: 18, BaseUnit: "wei"},     // Aurora
+	}
+)
+
+// NativeCurrencyForChain returns native currency of the chain with given ID or DefaultNativeCurrency if the chain is unknown
+func NativeCurrencyForChain(chainID int64) NativeCurrency {
+	if c, ok := ChainNativeCurrencies[chainID]; ok {
+		return c
+	}
+
+	return DefaultNativeCurrency
+}
+
+// ToDecimal converts an amount expressed in base units to a decimal amount of the currency
+func (c NativeCurrency) ToDecimal(amount *big.Int) *big.Float {
+	f := new(big.Float)
+	f.SetPrec(236) //  IEEE 754 octuple-precision binary floating-point format: binary256
+	f.SetMode(big.ToNearestEven)
+	fAmount := new(big.Float)
+	fAmount.SetPrec(236)
+	fAmount.SetMode(big.ToNearestEven)
+	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(c.Decimals)), nil))
+
+	return f.Quo(fAmount.SetInt(amount), divisor)
+}
+
+// Format returns an amount expressed in base units both in base units and in the currency, e.g. "1500000000000000000 wei / 1.5 ETH"
+func (c NativeCurrency) Format(amount *big.Int) string {
+	if amount == nil {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("%s %s / %s %s", amount.String(), c.BaseUnit, c.ToDecimal(amount).Text('f', -1), c.Symbol)
+}
+
+// NativeCurrency returns native currency of the network the client is connected to. Values from the registry can be overridden
+// with `native_currency_symbol` and `native_currency_decimals` network settings.
+func (m *Client) NativeCurrency() NativeCurrency {
+	currency := NativeCurrencyForChain(m.ChainID)
+	if m.Cfg == nil || m.Cfg.Network == nil {
+		return currency
+	}
+
+	if m.Cfg.Network.NativeCurrencySymbol != "" {
+		currency.Symbol = m.Cfg.Network.NativeCurrencySymbol
+	}
+	if m.Cfg.Network.NativeCurrencyDecimals != nil {
+		currency.Decimals = *m.Cfg.Network.NativeCurrencyDecimals
+		if currency.Decimals != DefaultNativeCurrency.Decimals {
+			currency.BaseUnit = "base units"
+		}
+	}
+
+	return currency
+}
+
+// FormatNativeAmount formats an amount expressed in base units using native currency of the network the client is connected to
+func (m *Client) FormatNativeAmount(amount *big.Int) string {
+	return m.NativeCurrency().Format(amount)
+}
@@ -0,0 +1,154 @@
+package seth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrNodeAccountNotManaged = "node account is not managed by the node"
+	ErrNodeAccountSigning    = "failed to sign transaction with node account"
+)
+
+// parseNodeAccounts returns addresses of network's node accounts
+func (c *Config) parseNodeAccounts() ([]common.Address, error) {
+	addresses := make([]common.Address, 0, len(c.Network.NodeAccounts))
+	for _, account := range c.Network.NodeAccounts {
+		if !common.IsHexAddress(account) {
+			return nil, fmt.Errorf("invalid node account address '%s'", account)
+		}
+		addresses = append(addresses, common.HexToAddress(account))
+	}
+
+	return addresses, nil
+}
+
+// IsNodeAccount returns true if key with given number is an account managed (and unlocked) by the node, which has no private
+// key loaded in Seth
+func (m *Client) IsNodeAccount(keyNum int) bool {
+	if keyNum < 0 || keyNum >= len(m.PrivateKeys) || keyNum >= len(m.Addresses) {
+		return false
+	}
+	_, privateKey := m.key(keyNum)
+	return privateKey == nil
+}
+
+// checkNodeAccounts checks that the node manages all node accounts, otherwise the first transaction sent from them would fail
+func (m *Client) checkNodeAccounts() error {
+	var nodeAccounts []common.Address
+	for keyNum, address := range m.Addresses {
+		if m.IsNodeAccount(keyNum) {
+			nodeAccounts = append(nodeAccounts, address)
+		}
+	}
+	if len(nodeAccounts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	var managed []common.Address
+	if err := m.Client.Client().CallContext(ctx, &managed, "eth_accounts"); err != nil {
+		return errors.Wrap(err, "failed to get accounts managed by the node")
+	}
+	isManaged := make(map[common.Address]bool, len(managed))
+	for _, address := range managed {
+		isManaged[address] = true
+	}
+	for _, address := range nodeAccounts {
+		if !isManaged[address] {
+			return fmt.Errorf("%s: %s", ErrNodeAccountNotManaged, address.Hex())
+		}
+	}
+	L.Info().
+		Interface("Accounts", nodeAccounts).
+		Msg("Using accounts managed by the node")
+
+	return nil
+}
+
+// newTransactor returns transaction options signing with key's private key or, for node accounts, with the node
+func (m *Client) newTransactor(keyNum int) (*bind.TransactOpts, error) {
+	from, privateKey := m.key(keyNum)
+	if privateKey != nil {
+		return bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(m.ChainID))
+	}
+
+	signer := types.LatestSignerForChainID(big.NewInt(m.ChainID))
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return m.signWithNode(from, signer, tx)
+		},
+		Context: context.Background(),
+	}, nil
+}
+
+// signNewTx signs transaction with private key or, if it's nil, with node account
+func (m *Client) signNewTx(from common.Address, privateKey *ecdsa.PrivateKey, signer types.Signer, txData types.TxData) (*types.Transaction, error) {
+	if privateKey != nil {
+		return types.SignNewTx(privateKey, signer, txData)
+	}
+
+	return m.signWithNode(from, signer, types.NewTx(txData))
+}
+
+// signWithNode signs transaction with eth_signTransaction, which works for accounts unlocked in the node. Transaction is
+// then sent by Seth as a raw one, so that nonces, gas bumping, journaling and decoding work the same as for other keys.
+func (m *Client) signWithNode(from common.Address, signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	args := map[string]interface{}{
+		"from":    from,
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"input":   hexutil.Bytes(tx.Data()),
+		"chainId": (*hexutil.Big)(signer.ChainID()),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	case types.AccessListTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+		args["accessList"] = tx.AccessList()
+	case types.DynamicFeeTxType:
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+		args["accessList"] = tx.AccessList()
+	default:
+		// node can't attach blob sidecar to the transaction
+		return nil, fmt.Errorf("%s: unsupported transaction type %d", ErrNodeAccountSigning, tx.Type())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := m.Client.Client().CallContext(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, errors.Wrap(err, ErrNodeAccountSigning)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, errors.Wrap(err, ErrNodeAccountSigning)
+	}
+	// node fills in missing fields on its own, so make sure it signed exactly what we asked for
+	if sender, err := types.Sender(signer, signed); err != nil || sender != from || signed.Nonce() != tx.Nonce() || signed.Type() != tx.Type() {
+		return nil, fmt.Errorf("%s: node returned transaction that doesn't match the requested one", ErrNodeAccountSigning)
+	}
+
+	return signed, nil
+}
@@ -32,6 +32,8 @@ type NonceManager struct {
 	Addresses   []common.Address
 	PrivateKeys []*ecdsa.PrivateKey
 	Nonces      map[common.Address]int64
+	// reserved are nonces of transactions that are being replaced (gas bumped), they are not handed out until released
+	reserved map[common.Address]map[uint64]struct{}
 }
 
 type KeyNonce struct {
@@ -61,8 +63,13 @@ func (m *NonceManager) UpdateNonces() error {
 	L.Debug().Interface("Addrs", m.Addresses).Msg("Updating nonces for addresses")
 	m.Lock()
 	defer m.Unlock()
+	if m.cfg.HealNonceGaps {
+		if err := m.healNonceGaps(); err != nil {
+			return err
+		}
+	}
 	for addr := range m.Nonces {
-		nonce, err := m.Client.Client.NonceAt(context.Background(), addr, nil)
+		nonce, err := m.Client.nonceOf(context.Background(), addr, BlockTag_Latest)
 		if err != nil {
 			return err
 		}
@@ -71,6 +78,10 @@ func (m *NonceManager) UpdateNonces() error {
 	L.Debug().Interface("Nonces", m.Nonces).Msg("Updated nonces for addresses")
 	m.SyncedKeys = make(chan *KeyNonce, len(m.Addresses))
 	for keyNum, addr := range m.Addresses[1:] {
+		// duplicated keys share nonce with the first occurrence, so they can't be used concurrently with it
+		if m.Client != nil && m.Client.Cfg.IsKeyAlias(keyNum+1) {
+			continue
+		}
 		m.SyncedKeys <- &KeyNonce{
 			KeyNum: keyNum + 1,
 			Nonce:  uint64(m.Nonces[addr]),
@@ -85,11 +96,76 @@ func (m *NonceManager) UpdateNonces() error {
 func (m *NonceManager) NextNonce(addr common.Address) *big.Int {
 	m.Lock()
 	defer m.Unlock()
+	for m.isReserved(addr, uint64(m.Nonces[addr])) {
+		m.Nonces[addr]++
+	}
 	nextNonce := big.NewInt(m.Nonces[addr])
 	m.Nonces[addr]++
 	return nextNonce
 }
 
+// ReserveNonce reserves nonce of addr, so that it's not used by new transactions while transaction with that nonce is
+// being replaced. It should be released with ReleaseNonce once a receipt for the nonce was observed (or replacing was
+// given up). Reserving the same nonce more than once has no effect.
+func (m *NonceManager) ReserveNonce(addr common.Address, nonce uint64) {
+	m.Lock()
+	defer m.Unlock()
+	if m.reserved == nil {
+		m.reserved = make(map[common.Address]map[uint64]struct{})
+	}
+	if m.reserved[addr] == nil {
+		m.reserved[addr] = make(map[uint64]struct{})
+	}
+	m.reserved[addr][nonce] = struct{}{}
+	L.Debug().
+		Str("Address", addr.Hex()).
+		Uint64("Nonce", nonce).
+		Msg("Reserved nonce")
+}
+
+// ReleaseNonce releases nonce reserved with ReserveNonce
+func (m *NonceManager) ReleaseNonce(addr common.Address, nonce uint64) {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.reserved[addr], nonce)
+	if len(m.reserved[addr]) == 0 {
+		delete(m.reserved, addr)
+	}
+	L.Debug().
+		Str("Address", addr.Hex()).
+		Uint64("Nonce", nonce).
+		Msg("Released nonce")
+}
+
+// IsNonceReserved returns true if nonce of addr is reserved
+func (m *NonceManager) IsNonceReserved(addr common.Address, nonce uint64) bool {
+	m.Lock()
+	defer m.Unlock()
+	return m.isReserved(addr, nonce)
+}
+
+// unreservedNonce returns the lowest nonce of addr, that is equal or higher than given one and isn't reserved
+func (m *NonceManager) unreservedNonce(addr common.Address, nonce uint64) uint64 {
+	m.Lock()
+	defer m.Unlock()
+	for m.isReserved(addr, nonce) {
+		nonce++
+	}
+	return nonce
+}
+
+func (m *NonceManager) isReserved(addr common.Address, nonce uint64) bool {
+	_, ok := m.reserved[addr][nonce]
+	return ok
+}
+
+// address returns address of given key, it's read under the lock, because key rotation swaps keys while holding it
+func (m *NonceManager) address(keyNum int) common.Address {
+	m.Lock()
+	defer m.Unlock()
+	return m.Addresses[keyNum]
+}
+
 func (m *NonceManager) anySyncedKey() int {
 	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.KeySyncTimeout.Duration())
 	defer cancel()
@@ -101,10 +177,11 @@ func (m *NonceManager) anySyncedKey() int {
 		m.Client.Errors = append(m.Client.Errors, errors.New(ErrKeySync))
 		return TimeoutKeyNum //so that it's pretty uniqe number of invalid key
 	case keyData := <-m.SyncedKeys:
+		addr := m.address(keyData.KeyNum)
 		L.Trace().
 			Interface("KeyNum", keyData.KeyNum).
 			Uint64("Nonce", keyData.Nonce).
-			Interface("Address", m.Addresses[keyData.KeyNum]).
+			Interface("Address", addr).
 			Msg("Key selected")
 		go func() {
 			err := retry.Do(
@@ -112,9 +189,9 @@ func (m *NonceManager) anySyncedKey() int {
 					m.rl.Take()
 					L.Trace().
 						Interface("KeyNum", keyData.KeyNum).
-						Interface("Address", m.Addresses[keyData.KeyNum]).
+						Interface("Address", addr).
 						Msg("Key is syncing")
-					nonce, err := m.Client.Client.NonceAt(context.Background(), m.Addresses[keyData.KeyNum], nil)
+					nonce, err := m.Client.nonceOf(context.Background(), addr, BlockTag_Latest)
 					if err != nil {
 						return errors.New(ErrNonce)
 					}
@@ -122,7 +199,7 @@ func (m *NonceManager) anySyncedKey() int {
 						L.Trace().
 							Interface("KeyNum", keyData.KeyNum).
 							Uint64("Nonce", nonce).
-							Interface("Address", m.Addresses[keyData.KeyNum]).
+							Interface("Address", addr).
 							Msg("Key synced")
 						m.SyncedKeys <- &KeyNonce{
 							KeyNum: keyData.KeyNum,
@@ -134,7 +211,7 @@ func (m *NonceManager) anySyncedKey() int {
 							Interface("KeyNum", keyData.KeyNum).
 							Uint64("Nonce", nonce).
 							Int("Expected nonce", int(keyData.Nonce+1)).
-							Interface("Address", m.Addresses[keyData.KeyNum]).
+							Interface("Address", addr).
 							Msg("Key NOT synced")
 					}
 					return errors.New(ErrKeySync)
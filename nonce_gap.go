@@ -0,0 +1,217 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrNonceGapHealing = "failed to heal nonce gap"
+
+	// how many times fees are doubled, when replacement transaction is underpriced
+	nonceGapHealingFeeBumps = 5
+)
+
+// NonceGap describes a key with transactions stuck in the mempool, e.g. left there by a crashed run. Until they are mined
+// or replaced, any new transaction sent with latest nonce will fail or be queued.
+type NonceGap struct {
+	KeyNum       int
+	Address      common.Address
+	LatestNonce  uint64
+	PendingNonce uint64
+}
+
+// FindNonceGaps returns all keys whose pending nonce is higher than latest nonce
+func (m *NonceManager) FindNonceGaps() ([]NonceGap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	gaps := make([]NonceGap, 0)
+	for keyNum, addr := range m.Addresses {
+		latest, err := m.Client.nonceOf(ctx, addr, BlockTag_Latest)
+		if err != nil {
+			return nil, err
+		}
+		pending, err := m.Client.nonceOf(ctx, addr, BlockTag_Pending)
+		if err != nil {
+			return nil, err
+		}
+		if pending > latest {
+			gaps = append(gaps, NonceGap{
+				KeyNum:       keyNum,
+				Address:      addr,
+				LatestNonce:  latest,
+				PendingNonce: pending,
+			})
+		}
+	}
+
+	return gaps, nil
+}
+
+// healNonceGaps finds keys with stuck transactions and replaces these transactions with no-op transactions (0 value transfers
+// to self), waiting until all of them are mined
+func (m *NonceManager) healNonceGaps() error {
+	gaps, err := m.FindNonceGaps()
+	if err != nil {
+		return err
+	}
+
+	for _, gap := range gaps {
+		if err := m.healNonceGap(gap); err != nil {
+			return errors.Wrapf(err, "%s of key %d (%s)", ErrNonceGapHealing, gap.KeyNum, gap.Address.Hex())
+		}
+	}
+
+	return nil
+}
+
+// healNonceGap replaces transactions with nonces from latest to pending with no-op transactions and waits until latest nonce catches up
+func (m *NonceManager) healNonceGap(gap NonceGap) error {
+	L.Info().
+		Int("KeyNum", gap.KeyNum).
+		Str("Address", gap.Address.Hex()).
+		Uint64("Latest nonce", gap.LatestNonce).
+		Uint64("Pending nonce", gap.PendingNonce).
+		Msg("Replacing stuck transactions with no-op transactions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	gasPrice, gasTipCap, err := m.Client.noOpTxFees(ctx)
+	if err != nil {
+		return err
+	}
+
+	for nonce := gap.LatestNonce; nonce < gap.PendingNonce; nonce++ {
+		_, gasPrice, gasTipCap, err = m.Client.sendNoOpTx(ctx, gap.KeyNum, nonce, gasPrice, gasTipCap)
+		if err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(m.Client.ReceiptPollInterval())
+	defer ticker.Stop()
+	for {
+		latest, err := m.Client.nonceOf(ctx, gap.Address, BlockTag_Latest)
+		if err != nil {
+			return err
+		}
+		if latest >= gap.PendingNonce {
+			L.Info().
+				Int("KeyNum", gap.KeyNum).
+				Uint64("Nonce", latest).
+				Msg("Nonce gap healed")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "latest nonce is still %d, expected %d", latest, gap.PendingNonce)
+		case <-ticker.C:
+		}
+	}
+}
+
+// noOpTxFees returns twice the fees suggested by the node (or configured ones, if they are higher), since pending
+// transactions can only be replaced by ones with higher fees
+func (m *Client) noOpTxFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	gasPrice, err := m.Client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to get suggested gas price")
+	}
+	gasPrice = maxBig(gasPrice, big.NewInt(m.Cfg.Network.GasPrice))
+
+	var gasTipCap *big.Int
+	if m.Cfg.Network.EIP1559DynamicFees {
+		gasTipCap, err = m.Client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get suggested gas tip cap")
+		}
+		gasTipCap = new(big.Int).Mul(maxBig(gasTipCap, big.NewInt(m.Cfg.Network.GasTipCap)), big.NewInt(2))
+		gasPrice = maxBig(gasPrice, big.NewInt(m.Cfg.Network.GasFeeCap))
+	}
+
+	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(2))
+	if gasTipCap != nil {
+		// fee cap can't be lower than tip cap
+		gasPrice = maxBig(gasPrice, gasTipCap)
+	}
+
+	return gasPrice, gasTipCap, nil
+}
+
+// sendNoOpTx sends 0 value transfer to self with given nonce. If it's underpriced fees are doubled and it's sent again.
+// It returns sent transaction and fees that were accepted, so that they can be used for following nonces. Transaction is nil
+// if nonce was already used, e.g. because original transaction was mined in the meantime.
+func (m *Client) sendNoOpTx(ctx context.Context, keyNum int, nonce uint64, gasPrice, gasTipCap *big.Int) (*types.Transaction, *big.Int, *big.Int, error) {
+	chainID := big.NewInt(m.ChainID)
+	signer := types.LatestSignerForChainID(chainID)
+	to, privateKey := m.key(keyNum)
+
+	for bump := 0; ; bump++ {
+		var txData types.TxData
+		if gasTipCap != nil {
+			txData = &types.DynamicFeeTx{
+				ChainID:   chainID,
+				Nonce:     nonce,
+				GasTipCap: gasTipCap,
+				GasFeeCap: gasPrice,
+				Gas:       uint64(m.Cfg.Network.TransferGasFee),
+				To:        &to,
+				Value:     big.NewInt(0),
+			}
+		} else {
+			txData = &types.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: gasPrice,
+				Gas:      uint64(m.Cfg.Network.TransferGasFee),
+				To:       &to,
+				Value:    big.NewInt(0),
+			}
+		}
+
+		tx, err := m.signNewTx(to, privateKey, signer, txData)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to sign no-op transaction")
+		}
+
+		err = m.Client.SendTransaction(ctx, tx)
+		switch {
+		case err == nil:
+			L.Debug().
+				Int("KeyNum", keyNum).
+				Uint64("Nonce", nonce).
+				Str("Transaction", tx.Hash().Hex()).
+				Str("Gas price", gasPrice.String()).
+				Msg("Sent no-op replacement transaction")
+			return tx, gasPrice, gasTipCap, nil
+		case strings.Contains(err.Error(), "nonce too low"):
+			// stuck transaction was mined in the meantime
+			return nil, gasPrice, gasTipCap, nil
+		case strings.Contains(err.Error(), "underpriced") && bump < nonceGapHealingFeeBumps:
+			gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(2))
+			if gasTipCap != nil {
+				gasTipCap = new(big.Int).Mul(gasTipCap, big.NewInt(2))
+			}
+			if m.Cfg.HasMaxBumpGasPrice() && gasPrice.Cmp(big.NewInt(m.Cfg.GasBump.MaxGasPrice)) > 0 {
+				return nil, nil, nil, fmt.Errorf("replacement for nonce %d would cost more than max gas price %d", nonce, m.Cfg.GasBump.MaxGasPrice)
+			}
+		default:
+			return nil, nil, nil, errors.Wrapf(err, "failed to send no-op transaction with nonce %d", nonce)
+		}
+	}
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrOutOfGas         = "transaction ran out of gas"
+	ErrNoOutOfGas       = "transaction was expected to run out of gas, but it didn't"
+	ErrEstimateCallGas  = "failed to estimate gas of the call"
+	ErrGasDeltaTooLarge = "gas delta is larger than estimated gas"
+)
+
+// GasLimitCallFn sends a transaction using given transaction options, e.g. a call to contract wrapper. It's called twice
+// by gas limit helpers: first without sending to estimate gas, then to send the transaction with exact gas limit.
+type GasLimitCallFn func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// EstimateCallGas returns gas limit estimated by the node for transaction created by fn for given key. Transaction isn't sent.
+func (m *Client) EstimateCallGas(keyNum int, fn GasLimitCallFn) (uint64, error) {
+	// gas limit has to be 0, so that bind estimates it instead of using the one from config
+	tx, err := fn(m.NewTXKeyOpts(keyNum, WithNoSend(true), WithGasLimit(0)))
+	if err != nil {
+		return 0, errors.Wrap(err, ErrEstimateCallGas)
+	}
+
+	return tx.Gas(), nil
+}
+
+// SendWithExactGas sends transaction created by fn with gas limit equal to the node's estimation, without any buffer,
+// waits for it and decodes it
+func (m *Client) SendWithExactGas(keyNum int, fn GasLimitCallFn) (*DecodedTransaction, error) {
+	return m.SendWithGasDelta(keyNum, 0, fn)
+}
+
+// SendWithGasDelta sends transaction created by fn with gas limit lower than the node's estimation by delta, waits for it
+// and decodes it. It's meant to test how contracts behave when they are close to running out of gas (e.g. gas griefing).
+// If transaction runs out of gas returned error is classified as ErrOutOfGas (see IsOutOfGas).
+func (m *Client) SendWithGasDelta(keyNum int, delta uint64, fn GasLimitCallFn) (*DecodedTransaction, error) {
+	estimated, err := m.EstimateCallGas(keyNum, fn)
+	if err != nil {
+		return nil, err
+	}
+	if delta >= estimated {
+		return nil, fmt.Errorf("%s: delta %d, estimated gas %d", ErrGasDeltaTooLarge, delta, estimated)
+	}
+
+	L.Debug().
+		Int("KeyNum", keyNum).
+		Uint64("Estimated gas", estimated).
+		Uint64("Delta", delta).
+		Uint64("Gas limit", estimated-delta).
+		Msg("Sending transaction with exact gas limit")
+
+	return m.Decode(fn(m.NewTXKeyOpts(keyNum, WithGasLimit(estimated-delta))))
+}
+
+// SendExpectingOutOfGas sends transaction created by fn with gas limit lower than the node's estimation by delta (at
+// least 1) and checks that it ran out of gas. It returns ErrNoOutOfGas if transaction succeeded or reverted for other
+// reason.
+func (m *Client) SendExpectingOutOfGas(keyNum int, delta uint64, fn GasLimitCallFn) (*DecodedTransaction, error) {
+	if delta == 0 {
+		delta = 1
+	}
+	decoded, err := m.SendWithGasDelta(keyNum, delta, fn)
+	if IsOutOfGas(err) {
+		return decoded, nil
+	}
+	if err != nil {
+		return decoded, errors.Wrap(err, ErrNoOutOfGas)
+	}
+
+	return decoded, errors.New(ErrNoOutOfGas)
+}
+
+// IsOutOfGas returns true if error returned by Decode means that transaction ran out of gas
+func IsOutOfGas(err error) bool {
+	return err != nil && strings.Contains(err.Error(), ErrOutOfGas)
+}
+
+// ranOutOfGas returns true if reverted transaction used all of its gas limit
+func ranOutOfGas(tx *types.Transaction, receipt *types.Receipt) bool {
+	return receipt.Status == types.ReceiptStatusFailed && receipt.GasUsed >= tx.Gas()
+}
+
+// outOfGasErr classifies revert error as out of gas, so that it's easy to tell from other reverts in logs and traces
+func outOfGasErr(tx *types.Transaction, receipt *types.Receipt, revertErr error) error {
+	msg := fmt.Sprintf("%s (used %d of %d gas limit)", ErrOutOfGas, receipt.GasUsed, tx.Gas())
+	if revertErr == nil {
+		return errors.New(msg)
+	}
+
+	return errors.Wrap(revertErr, msg)
+}
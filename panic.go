@@ -0,0 +1,43 @@
+package seth
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// panicSelector is the selector of Solidity's built-in Panic(uint256) error, which is used by compiler-inserted checks
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+// panicCodes maps Solidity panic codes to human-readable explanations
+// https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var panicCodes = map[uint64]string{
+	0x00: "generic compiler inserted panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion to invalid enum value",
+	0x22: "access to incorrectly encoded storage byte array",
+	0x31: "pop() on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory, too much memory allocated",
+	0x51: "call to zero-initialized variable of internal function type",
+}
+
+// DecodePanic decodes revert data of Panic(uint256) error and returns it as a human-readable string, e.g.
+// "panic: arithmetic overflow or underflow (code: 0x11)". It returns false if data is not a panic.
+func DecodePanic(data []byte) (string, bool) {
+	if len(data) != 4+32 || !bytes.Equal(data[:4], panicSelector) {
+		return "", false
+	}
+
+	code := new(big.Int).SetBytes(data[4:])
+	explanation, ok := panicCodes[code.Uint64()]
+	if !ok || !code.IsUint64() {
+		explanation = "unknown panic code"
+	}
+
+	return fmt.Sprintf("panic: %s (code: %#x)", explanation, code), true
+}
@@ -0,0 +1,98 @@
+package seth
+
+import (
+	"context"
+	verr "errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	ErrParallelConcurrency = "invalid concurrency of parallel interactions"
+	ErrParallelPanic       = "parallel interaction panicked"
+)
+
+// KeyError is an error returned by an interaction run with given key
+type KeyError struct {
+	KeyNum  int
+	Address common.Address
+	Err     error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("key %d (%s): %s", e.KeyNum, e.Address.Hex(), e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// Parallel runs fn concurrently n times, each time with a different key, so that interactions never share a nonce. Root key
+// and duplicated keys are never used, fn should create transaction options with client.NewTXKeyOpts(keyNum). Client must have at least n keys
+// besides the root one (ephemeral ones or configured private keys, see Config.GetMaxConcurrency), otherwise an error is
+// returned before anything is run.
+//
+// Parallel waits for all interactions to finish. Interactions that didn't start before ctx was done aren't run. Errors of all
+// failed interactions (including panics) are returned joined, each one as *KeyError, so that it's clear which key failed.
+func Parallel(ctx context.Context, client *Client, n int, fn func(keyNum int) error) error {
+	if err := assertParallelConcurrency(client, n); err != nil {
+		return err
+	}
+
+	L.Debug().
+		Int("Concurrency", n).
+		Msg("Running parallel interactions")
+
+	keyNums := client.distinctKeyNums()
+	errs := make([]error, n)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		keyNum := keyNums[i]
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := runWithKey(ctx, keyNum, fn); err != nil {
+				errs[i] = &KeyError{KeyNum: keyNum, Address: client.keyAddress(keyNum), Err: err}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// errors are ordered by key, nil ones are skipped by Join
+	return verr.Join(errs...)
+}
+
+func runWithKey(ctx context.Context, keyNum int, fn func(keyNum int) error) (err error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", ErrParallelPanic, r)
+		}
+	}()
+
+	return fn(keyNum)
+}
+
+// assertParallelConcurrency checks that there are enough keys to run n interactions in parallel
+func assertParallelConcurrency(client *Client, n int) error {
+	if n < 1 {
+		return fmt.Errorf("%s: at least one interaction is required, got %d", ErrParallelConcurrency, n)
+	}
+	maxConcurrency := client.Cfg.GetMaxConcurrency()
+	if distinct := len(client.distinctKeyNums()); distinct < maxConcurrency {
+		maxConcurrency = distinct
+	}
+	if maxConcurrency < 0 {
+		maxConcurrency = 0
+	}
+	if n > maxConcurrency {
+		return fmt.Errorf("%s: %d interactions need %d keys besides the root one, but only %d are available. Set 'ephemeral_addresses_number' or add more private keys",
+			ErrParallelConcurrency, n, n, maxConcurrency)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrNonPayableConstructor = "constructor is not payable, but value was set in transaction options"
+	ErrDeployedBalance       = "deployed contract's balance is lower than value sent to its constructor"
+)
+
+// DeployContractWithValue works like DeployContract, but sends given value to contract's payable constructor. Transaction
+// options passed to it are not modified. Once contract is deployed its balance is checked to be at least equal to the value.
+func (m *Client) DeployContractWithValue(auth *bind.TransactOpts, name string, abi abi.ABI, bytecode []byte, value *big.Int, params ...interface{}) (DeploymentData, error) {
+	opts := *auth
+	opts.Value = value
+
+	return m.DeployContract(&opts, name, abi, bytecode, params...)
+}
+
+// DeployContractFromContractStoreWithValue works like DeployContractFromContractStore, but sends given value to contract's
+// payable constructor (see DeployContractWithValue)
+func (m *Client) DeployContractFromContractStoreWithValue(auth *bind.TransactOpts, name string, value *big.Int, params ...interface{}) (DeploymentData, error) {
+	opts := *auth
+	opts.Value = value
+
+	return m.DeployContractFromContractStore(&opts, name, params...)
+}
+
+// checkPayableConstructor returns an error if value is sent to a constructor that isn't payable, which would revert
+// the deployment anyway
+func checkPayableConstructor(name string, contractABI abi.ABI, value *big.Int) error {
+	if !hasValue(value) || contractABI.Constructor.Payable || contractABI.Constructor.StateMutability == "payable" {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s (value: %s)", ErrNonPayableConstructor, name, value.String())
+}
+
+// verifyDeployedBalance checks that contract deployed with a value holds at least that value. It can hold more, if funds
+// were sent to the address before deployment.
+func (m *Client) verifyDeployedBalance(name string, address common.Address, value *big.Int) error {
+	if !hasValue(value) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	balance, err := m.Client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get balance of deployed %s contract", name)
+	}
+	if balance.Cmp(value) < 0 {
+		return fmt.Errorf("%s: %s at %s has %s, but %s was sent", ErrDeployedBalance, name, address.Hex(), m.FormatNativeAmount(balance), m.FormatNativeAmount(value))
+	}
+
+	return nil
+}
+
+func hasValue(value *big.Int) bool {
+	return value != nil && value.Sign() > 0
+}
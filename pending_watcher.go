@@ -0,0 +1,249 @@
+package seth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrPendingWatcher = "failed to watch pending transactions, node supports neither newPendingTransactions subscription nor txpool_content"
+
+	// PendingWatcherBufferSize is the number of pending transactions that can wait in the channel before new ones are dropped
+	PendingWatcherBufferSize = 1000
+)
+
+// PendingTx is a transaction sent from one of watched addresses that was seen in the mempool
+type PendingTx struct {
+	Hash        common.Hash
+	Transaction *types.Transaction
+	From        common.Address
+	SeenAt      time.Time
+	// Method and Input are only set if calldata was decoded using ABIs from Contract Store
+	Method string
+	Input  map[string]interface{}
+}
+
+// PendingWatcher watches mempool for transactions sent from given addresses. It uses newPendingTransactions subscription
+// if the node supports it (requires WS connection) and otherwise polls txpool_content.
+type PendingWatcher struct {
+	client    *Client
+	addresses map[common.Address]bool
+	pending   chan *PendingTx
+	seen      map[common.Hash]*PendingTx
+	seenMu    *sync.RWMutex
+	cancel    context.CancelFunc
+	done      chan struct{}
+	// Polling is true if txpool_content is polled, because subscription isn't supported
+	Polling bool
+}
+
+// NewPendingWatcher starts watching mempool for transactions sent from given addresses or, if none are given, from all
+// client's addresses. Call Stop() once it's no longer needed.
+func (m *Client) NewPendingWatcher(addresses ...common.Address) (*PendingWatcher, error) {
+	if len(addresses) == 0 {
+		addresses = m.Addresses
+	}
+
+	w := &PendingWatcher{
+		client:    m,
+		addresses: make(map[common.Address]bool),
+		pending:   make(chan *PendingTx, PendingWatcherBufferSize),
+		seen:      make(map[common.Hash]*PendingTx),
+		seenMu:    &sync.RWMutex{},
+		done:      make(chan struct{}),
+	}
+	for _, addr := range addresses {
+		w.addresses[addr] = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	hashes := make(chan common.Hash, PendingWatcherBufferSize)
+	sub, subErr := m.Client.Client().EthSubscribe(ctx, hashes, "newPendingTransactions")
+	if subErr == nil {
+		L.Debug().Msg("Watching pending transactions using newPendingTransactions subscription")
+		go w.watchSubscription(ctx, sub.Err(), hashes, sub.Unsubscribe)
+		return w, nil
+	}
+
+	if pollErr := w.pollTxPool(ctx); pollErr != nil {
+		cancel()
+		return nil, errors.Wrapf(pollErr, "%s (subscription error: %s)", ErrPendingWatcher, subErr.Error())
+	}
+
+	L.Debug().
+		Err(subErr).
+		Msg("Subscription to pending transactions failed. Watching pending transactions by polling txpool_content")
+	w.Polling = true
+	go w.watchTxPool(ctx)
+
+	return w, nil
+}
+
+// Pending returns channel with pending transactions. Each transaction is sent only once. If channel is full, new transactions
+// are dropped (but are still reported by Seen()).
+func (w *PendingWatcher) Pending() <-chan *PendingTx {
+	return w.pending
+}
+
+// Seen returns pending transaction with given hash if it was seen in the mempool
+func (w *PendingWatcher) Seen(hash common.Hash) (*PendingTx, bool) {
+	w.seenMu.RLock()
+	defer w.seenMu.RUnlock()
+	tx, ok := w.seen[hash]
+	return tx, ok
+}
+
+// Stop stops watching mempool and closes the channel returned by Pending()
+func (w *PendingWatcher) Stop() {
+	w.cancel()
+	<-w.done
+}
+
+func (w *PendingWatcher) watchSubscription(ctx context.Context, subErr <-chan error, hashes <-chan common.Hash, unsubscribe func()) {
+	defer close(w.done)
+	defer close(w.pending)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-subErr:
+			if err != nil {
+				L.Warn().Err(err).Msg("Pending transactions subscription failed. Pending transactions are no longer watched")
+			}
+			return
+		case hash := <-hashes:
+			if _, ok := w.Seen(hash); ok {
+				continue
+			}
+			w.fetchAndAdd(ctx, hash)
+		}
+	}
+}
+
+func (w *PendingWatcher) fetchAndAdd(ctx context.Context, hash common.Hash) {
+	fetchCtx, cancel := context.WithTimeout(ctx, w.client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	tx, _, err := w.client.Client.TransactionByHash(fetchCtx, hash)
+	if err != nil {
+		// transaction might have been already mined or dropped
+		L.Trace().
+			Err(err).
+			Str("Transaction", hash.Hex()).
+			Msg("Failed to get pending transaction")
+		return
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Trace().
+			Err(err).
+			Str("Transaction", hash.Hex()).
+			Msg("Failed to get sender of pending transaction")
+		return
+	}
+	w.add(tx, from)
+}
+
+func (w *PendingWatcher) watchTxPool(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.pending)
+
+	ticker := time.NewTicker(w.client.ReceiptPollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.pollTxPool(ctx); err != nil && ctx.Err() == nil {
+				L.Debug().Err(err).Msg("Failed to poll txpool_content")
+			}
+		}
+	}
+}
+
+// pollTxPool reads pending and queued transactions of watched addresses from txpool_content
+func (w *PendingWatcher) pollTxPool(ctx context.Context) error {
+	pollCtx, cancel := context.WithTimeout(ctx, w.client.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	var content map[string]map[string]map[string]*types.Transaction
+	if err := w.client.Client.Client().CallContext(pollCtx, &content, "txpool_content"); err != nil {
+		return err
+	}
+
+	for _, pool := range []string{"pending", "queued"} {
+		for sender, txs := range content[pool] {
+			from := common.HexToAddress(sender)
+			if !w.addresses[from] {
+				continue
+			}
+			for _, tx := range txs {
+				if tx != nil {
+					w.add(tx, from)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// add records transaction sent from one of watched addresses and sends it to the channel, unless it was already seen
+func (w *PendingWatcher) add(tx *types.Transaction, from common.Address) {
+	if !w.addresses[from] {
+		return
+	}
+
+	w.seenMu.Lock()
+	if _, ok := w.seen[tx.Hash()]; ok {
+		w.seenMu.Unlock()
+		return
+	}
+	pendingTx := &PendingTx{
+		Hash:        tx.Hash(),
+		Transaction: tx,
+		From:        from,
+		SeenAt:      time.Now(),
+	}
+	pendingTx.Method, pendingTx.Input = w.client.decodeCalldata(tx)
+	w.seen[tx.Hash()] = pendingTx
+	w.seenMu.Unlock()
+
+	select {
+	case w.pending <- pendingTx:
+	default:
+		L.Warn().
+			Str("Transaction", tx.Hash().Hex()).
+			Msg("Pending transactions channel is full. Dropping transaction")
+	}
+}
+
+// decodeCalldata returns method name and decoded inputs, if ABI of called method is known
+func (m *Client) decodeCalldata(tx *types.Transaction) (string, map[string]interface{}) {
+	if m.ABIFinder == nil || len(tx.Data()) < 4 || tx.To() == nil {
+		return "", nil
+	}
+
+	abiResult, err := m.ABIFinder.FindABIByMethod(tx.To().String(), tx.Data()[:4])
+	if err != nil {
+		return "", nil
+	}
+	input, err := decodeTxInputs(L, tx.Data(), abiResult.Method)
+	if err != nil {
+		L.Debug().
+			Err(err).
+			Str("Transaction", tx.Hash().Hex()).
+			Msg("Failed to decode calldata of pending transaction")
+	}
+
+	return abiResult.Method.Name, input
+}
@@ -0,0 +1,135 @@
+package seth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/ratelimit"
+)
+
+const (
+	ProviderProfile_Infura    = "infura"
+	ProviderProfile_Alchemy   = "alchemy"
+	ProviderProfile_QuickNode = "quicknode"
+	ProviderProfile_Public    = "public"
+)
+
+// ProviderProfile describes limits of an RPC provider. Limits are caps: Seth never exceeds them, but values explicitly
+// configured elsewhere (e.g. block stats rate limit) that are lower are respected. Zero means no limit.
+type ProviderProfile struct {
+	Name string
+	// RequestsPerSecond limits RPC calls made by bulk operations (congestion calculation, block stats)
+	RequestsPerSecond int
+	// MaxConcurrency limits number of concurrent RPC calls made by bulk operations
+	MaxConcurrency int
+	// BatchSize is the maximum number of calls sent in a single batch RPC request
+	BatchSize int
+	// UnsupportedMethods are known to be unavailable, so they are not probed on start and features that need them are disabled
+	UnsupportedMethods []string
+}
+
+var (
+	providerProfilesMu = &sync.RWMutex{}
+	// limits are based on free tiers of each provider, so that they work for everyone out of the box
+	providerProfiles = map[string]ProviderProfile{
+		ProviderProfile_Infura: {
+			Name:               ProviderProfile_Infura,
+			RequestsPerSecond:  10,
+			MaxConcurrency:     10,
+			BatchSize:          100,
+			UnsupportedMethods: []string{"debug_traceTransaction", "trace_transaction", "txpool_content"},
+		},
+		ProviderProfile_Alchemy: {
+			Name:               ProviderProfile_Alchemy,
+			RequestsPerSecond:  25,
+			MaxConcurrency:     10,
+			BatchSize:          50,
+			UnsupportedMethods: []string{"txpool_content"},
+		},
+		ProviderProfile_QuickNode: {
+			Name:               ProviderProfile_QuickNode,
+			RequestsPerSecond:  15,
+			MaxConcurrency:     5,
+			BatchSize:          100,
+			UnsupportedMethods: []string{"txpool_content"},
+		},
+		ProviderProfile_Public: {
+			Name:               ProviderProfile_Public,
+			RequestsPerSecond:  5,
+			MaxConcurrency:     2,
+			BatchSize:          10,
+			UnsupportedMethods: []string{"debug_traceTransaction", "trace_transaction", "txpool_content"},
+		},
+	}
+)
+
+// RegisterProviderProfile adds a custom provider profile or replaces a built-in one. Profile names are case-insensitive.
+func RegisterProviderProfile(profile ProviderProfile) {
+	providerProfilesMu.Lock()
+	defer providerProfilesMu.Unlock()
+	profile.Name = strings.ToLower(profile.Name)
+	providerProfiles[profile.Name] = profile
+}
+
+// GetProviderProfile returns provider profile with given name and whether it was found
+func GetProviderProfile(name string) (ProviderProfile, bool) {
+	providerProfilesMu.RLock()
+	defer providerProfilesMu.RUnlock()
+	profile, ok := providerProfiles[strings.ToLower(name)]
+	return profile, ok
+}
+
+// ProviderProfileNames returns sorted names of all known provider profiles
+func ProviderProfileNames() []string {
+	providerProfilesMu.RLock()
+	defer providerProfilesMu.RUnlock()
+	names := make([]string, 0, len(providerProfiles))
+	for name := range providerProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderProfile returns provider profile selected for current network or nil, if there's none
+func (c *Config) ProviderProfile() *ProviderProfile {
+	if c.Network == nil || c.Network.ProviderProfile == "" {
+		return nil
+	}
+	profile, ok := GetProviderProfile(c.Network.ProviderProfile)
+	if !ok {
+		return nil
+	}
+	return &profile
+}
+
+func validateProviderProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := GetProviderProfile(name); !ok {
+		return fmt.Errorf("provider profile must be one of: %s", strings.Join(ProviderProfileNames(), ", "))
+	}
+	return nil
+}
+
+// capRateLimit returns the lower of configured limit and provider profile limit (0 means no limit)
+func capRateLimit(configured int, profile *ProviderProfile) int {
+	if profile == nil || profile.RequestsPerSecond <= 0 {
+		return configured
+	}
+	if configured <= 0 || configured > profile.RequestsPerSecond {
+		return profile.RequestsPerSecond
+	}
+	return configured
+}
+
+// newProviderRateLimiter returns a limiter for bulk RPC calls or nil if provider profile doesn't limit them
+func newProviderRateLimiter(profile *ProviderProfile) ratelimit.Limiter {
+	if profile == nil || profile.RequestsPerSecond <= 0 {
+		return nil
+	}
+	return ratelimit.New(profile.RequestsPerSecond, ratelimit.WithoutSlack)
+}
@@ -0,0 +1,107 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrInvalidSelector = "invalid selector"
+	ErrInvalidRawArgs  = "invalid raw arguments"
+	ErrRawBytecode     = "contract was deployed from raw bytecode and has no ABI"
+)
+
+// rawBytecodeABI has only a payable constructor without inputs, so that value can be sent with raw bytecode deployment
+var rawBytecodeABI = abi.ABI{Constructor: abi.NewMethod("", "", abi.Constructor, "payable", false, true, nil, nil)}
+
+// DeployRawBytecode deploys contract from bytecode alone, without ABI. Constructor arguments, if any, must already be ABI-encoded
+// and are given as hex string, which is appended to the bytecode. Deployment works like DeployContract (nonce management, gas
+// bumping, redeploys, contract map), but calls to the contract are never decoded: they are marked as missing ABI in decoded
+// transactions and traces. Use TransactRaw and CallRaw to interact with it.
+func (m *Client) DeployRawBytecode(auth *bind.TransactOpts, name string, bytecode []byte, rawArgs string) (DeploymentData, error) {
+	args, err := decodeRawArgs(rawArgs)
+	if err != nil {
+		return DeploymentData{}, err
+	}
+
+	data, err := m.DeployContract(auth, name, rawBytecodeABI, append(append([]byte{}, bytecode...), args...))
+	if err != nil {
+		return DeploymentData{}, err
+	}
+	m.ContractAddressToNameMap.markRawBytecode(data.Address.Hex())
+
+	return data, nil
+}
+
+// TransactRaw sends a transaction calling given selector of a contract with raw ABI-encoded arguments, waits for it and decodes
+// it. Selector can be given either as 4 bytes hex ("0xa9059cbb") or as method signature ("transfer(address,uint256)"),
+// arguments as hex string (can be empty). Use it for contracts without ABI, e.g. deployed with DeployRawBytecode, or to send
+// malformed calldata in fuzz-style tests.
+func (m *Client) TransactRaw(opts *bind.TransactOpts, to common.Address, selector, rawArgs string) (*DecodedTransaction, error) {
+	calldata, err := RawCalldata(selector, rawArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Decode(bind.NewBoundContract(to, abi.ABI{}, m.Client, m.Client, m.Client).RawTransact(opts, calldata))
+}
+
+// CallRaw executes a read-only call of given selector of a contract with raw ABI-encoded arguments at the latest block and returns
+// raw output. See TransactRaw for supported formats of selector and arguments.
+func (m *Client) CallRaw(from, to common.Address, selector, rawArgs string) ([]byte, error) {
+	calldata, err := RawCalldata(selector, rawArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	return m.Client.CallContract(ctx, ethereum.CallMsg{From: from, To: &to, Data: calldata}, nil)
+}
+
+// RawCalldata builds calldata from selector and raw ABI-encoded arguments. Selector can be given either as 4 bytes hex
+// ("0xa9059cbb") or as method signature ("transfer(address,uint256)"), arguments as hex string (can be empty).
+func RawCalldata(selector, rawArgs string) ([]byte, error) {
+	var sig []byte
+	if strings.HasPrefix(selector, "0x") {
+		decoded, err := hexutil.Decode(selector)
+		if err != nil || len(decoded) != 4 {
+			return nil, fmt.Errorf("%s: %s, expected 4 bytes hex or method signature", ErrInvalidSelector, selector)
+		}
+		sig = decoded
+	} else {
+		if !strings.Contains(selector, "(") || !strings.HasSuffix(selector, ")") {
+			return nil, fmt.Errorf("%s: %s, expected 4 bytes hex or method signature", ErrInvalidSelector, selector)
+		}
+		sig = crypto.Keccak256([]byte(strings.ReplaceAll(selector, " ", "")))[:4]
+	}
+
+	args, err := decodeRawArgs(rawArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sig, args...), nil
+}
+
+func decodeRawArgs(rawArgs string) ([]byte, error) {
+	if rawArgs == "" || rawArgs == "0x" {
+		return nil, nil
+	}
+	args, err := hexutil.Decode(rawArgs)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrInvalidRawArgs)
+	}
+
+	return args, nil
+}
@@ -0,0 +1,264 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrUnsupportedTxType = "unsupported transaction type"
+	ErrDecodeRawTx       = "failed to decode raw transaction"
+)
+
+// SignTx signs transaction data constructed outside of Seth (legacy, access list, dynamic fee or, if blob transactions are
+// enabled, blob transaction) with key at
+// index keyNum and returns signed transaction together with its RLP encoding as hex string (ready for eth_sendRawTransaction).
+// Nonce is always set to the pending nonce of the key, the same way as in NewTXKeyOpts(), so that it doesn't collide with
+// transactions sent by the client. Chain ID is set to the one of current network. Gas limit and fees are filled in only if
+// they are not set (nil or 0): gas limit is estimated and fees come from the same gas estimator that's used for other transactions.
+func (m *Client) SignTx(keyNum int, txData types.TxData) (*types.Transaction, string, error) {
+	if keyNum > len(m.PrivateKeys)-1 || keyNum < 0 {
+		return nil, "", fmt.Errorf("keyNum is out of range. Expected %d-%d. Got: %d", 0, len(m.PrivateKeys)-1, keyNum)
+	}
+	from, privateKey := m.key(keyNum)
+
+	nonceStatus, err := m.getNonceStatus(from)
+	if err != nil {
+		return nil, "", errors.Wrap(err, ErrNonce)
+	}
+
+	var estimations *GasEstimations
+	estimate := func() GasEstimations {
+		if estimations == nil {
+			e := m.CalculateGasEstimations(m.NewDefaultGasEstimationRequest())
+			estimations = &e
+		}
+		return *estimations
+	}
+	chainID := big.NewInt(m.ChainID)
+
+	switch tx := txData.(type) {
+	case *types.LegacyTx:
+		tx.Nonce = nonceStatus.PendingNonce
+		if isUnset(tx.GasPrice) {
+			tx.GasPrice = estimate().GasPrice
+		}
+		if tx.Gas == 0 {
+			if tx.Gas, err = m.estimateGas(ethereum.CallMsg{From: from, To: tx.To, Value: tx.Value, Data: tx.Data}); err != nil {
+				return nil, "", err
+			}
+		}
+	case *types.AccessListTx:
+		tx.Nonce = nonceStatus.PendingNonce
+		tx.ChainID = chainID
+		if isUnset(tx.GasPrice) {
+			tx.GasPrice = estimate().GasPrice
+		}
+		if tx.Gas == 0 {
+			if tx.Gas, err = m.estimateGas(ethereum.CallMsg{From: from, To: tx.To, Value: tx.Value, Data: tx.Data, AccessList: tx.AccessList}); err != nil {
+				return nil, "", err
+			}
+		}
+	case *types.DynamicFeeTx:
+		tx.Nonce = nonceStatus.PendingNonce
+		tx.ChainID = chainID
+		if isUnset(tx.GasFeeCap) {
+			tx.GasFeeCap = estimate().GasFeeCap
+		}
+		if isUnset(tx.GasTipCap) {
+			tx.GasTipCap = estimate().GasTipCap
+		}
+		if tx.Gas == 0 {
+			if tx.Gas, err = m.estimateGas(ethereum.CallMsg{From: from, To: tx.To, Value: tx.Value, Data: tx.Data, AccessList: tx.AccessList}); err != nil {
+				return nil, "", err
+			}
+		}
+	case *types.BlobTx:
+		if !m.Cfg.Network.BlobTransactions {
+			return nil, "", fmt.Errorf("%s: %T, blob transactions are disabled, enable them with 'blob_transactions'", ErrUnsupportedTxType, txData)
+		}
+		tx.Nonce = nonceStatus.PendingNonce
+		tx.ChainID = uint256.MustFromBig(chainID)
+		if tx.GasFeeCap == nil || tx.GasFeeCap.IsZero() {
+			tx.GasFeeCap = uint256.MustFromBig(estimate().GasFeeCap)
+		}
+		if tx.GasTipCap == nil || tx.GasTipCap.IsZero() {
+			tx.GasTipCap = uint256.MustFromBig(estimate().GasTipCap)
+		}
+		if tx.BlobFeeCap == nil || tx.BlobFeeCap.IsZero() {
+			blobFeeCap, err := m.blobFeeCap(context.Background())
+			if err != nil {
+				return nil, "", err
+			}
+			tx.BlobFeeCap = uint256.MustFromBig(blobFeeCap)
+		}
+		if tx.Gas == 0 {
+			if tx.Gas, err = m.estimateGas(ethereum.CallMsg{From: from, To: &tx.To, Value: tx.Value.ToBig(), Data: tx.Data, AccessList: tx.AccessList}); err != nil {
+				return nil, "", err
+			}
+		}
+	default:
+		return nil, "", fmt.Errorf("%s: %T", ErrUnsupportedTxType, txData)
+	}
+
+	signedTx, err := m.signNewTx(from, privateKey, types.LatestSignerForChainID(chainID), txData)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to sign transaction")
+	}
+
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to encode signed transaction")
+	}
+
+	L.Debug().
+		Int("KeyNum", keyNum).
+		Str("Transaction", signedTx.Hash().Hex()).
+		Uint64("Nonce", signedTx.Nonce()).
+		Uint8("Type", signedTx.Type()).
+		Msg("Signed transaction")
+
+	return signedTx, hexutil.Encode(raw), nil
+}
+
+// SignRawTx decodes unsigned EIP-2718 typed (or legacy RLP) transaction from hex and signs it in the same way as SignTx
+func (m *Client) SignRawTx(keyNum int, rawTx string) (*types.Transaction, string, error) {
+	tx, err := decodeRawTx(rawTx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	txData, err := txDataOf(tx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return m.SignTx(keyNum, txData)
+}
+
+// SendRawTx decodes signed transaction from hex and sends it. It can be used with Decode(), e.g.
+// client.Decode(client.SendRawTx(raw)).
+func (m *Client) SendRawTx(rawTx string) (*types.Transaction, error) {
+	tx, err := decodeRawTx(rawTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, m.SendSignedTx(tx)
+}
+
+// SendSignedTx sends transaction that was signed outside of Seth (or with SignTx) and, if enabled, adds it to the transaction journal
+func (m *Client) SendSignedTx(tx *types.Transaction) error {
+	if err := m.budget.check(); err != nil {
+		return err
+	}
+	m.journalTx(tx, "raw transaction")
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	if err := m.Client.SendTransaction(ctx, tx); err != nil {
+		return errors.Wrap(err, "failed to send transaction")
+	}
+
+	return nil
+}
+
+// SendRawCall sends calldata to given address from key with given number without a contract binding, waits for the transaction
+// to be mined and decodes it. Nonce and fees are set in the same way as in NewTXKeyOpts() and gas limit is estimated, unless
+// it's set with WithGasLimit() or network's gas_limit. If transaction isn't mined in time, its gas is bumped as for any other
+// transaction passed to Decode(). Estimation fails for non-empty calldata sent to address without code.
+func (m *Client) SendRawCall(keyNum int, to common.Address, calldata []byte, opts ...TransactOpt) (*DecodedTransaction, error) {
+	txOpts := m.NewTXKeyOpts(keyNum, opts...)
+	if txOpts.Context != nil {
+		if err, ok := txOpts.Context.Value(ContextErrorKey{}).(error); ok {
+			return nil, err
+		}
+	}
+
+	contract := bind.NewBoundContract(to, abi.ABI{}, m.Client, m.Client, m.Client)
+	return m.Decode(contract.RawTransact(txOpts, calldata))
+}
+
+// isUnset returns true if fee wasn't set, transactions decoded from RLP have zero instead of nil
+func isUnset(fee *big.Int) bool {
+	return fee == nil || fee.Sign() == 0
+}
+
+func decodeRawTx(rawTx string) (*types.Transaction, error) {
+	raw, err := hexutil.Decode(rawTx)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrDecodeRawTx)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, errors.Wrap(err, ErrDecodeRawTx)
+	}
+
+	return tx, nil
+}
+
+// txDataOf copies transaction fields that are set by the sender (everything but signature) to TxData of its type
+func txDataOf(tx *types.Transaction) (types.TxData, error) {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return &types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: tx.GasPrice(),
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}, nil
+	case types.AccessListTxType:
+		return &types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   tx.GasPrice(),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	case types.DynamicFeeTxType:
+		return &types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tx.GasTipCap(),
+			GasFeeCap:  tx.GasFeeCap(),
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}, nil
+	case types.BlobTxType:
+		return &types.BlobTx{
+			ChainID:    uint256.MustFromBig(tx.ChainId()),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  uint256.MustFromBig(tx.GasTipCap()),
+			GasFeeCap:  uint256.MustFromBig(tx.GasFeeCap()),
+			Gas:        tx.Gas(),
+			To:         *tx.To(),
+			Value:      uint256.MustFromBig(tx.Value()),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			BlobFeeCap: uint256.MustFromBig(tx.BlobGasFeeCap()),
+			BlobHashes: tx.BlobHashes(),
+			Sidecar:    tx.BlobTxSidecar(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("%s: %d", ErrUnsupportedTxType, tx.Type())
+	}
+}
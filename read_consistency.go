@@ -0,0 +1,226 @@
+package seth
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ReadConsistency_Sticky pins all requests to one upstream node of a load-balanced RPC provider, by sending the same
+	// session header with each request and keeping cookies set by the load balancer
+	ReadConsistency_Sticky = "sticky"
+	// ReadConsistency_Retry retries nonce and balance reads until node reflects all transactions sent and mined by the client
+	ReadConsistency_Retry = "retry"
+
+	DefaultStickySessionHeader = "X-Session-Id"
+
+	ErrInconsistentRead = "RPC node doesn't reflect transactions sent by the client"
+)
+
+// stickySession returns session header and cookie jar used to pin requests to one upstream node, or nil if sticky
+// read consistency is disabled. All clients created from the same config share the session.
+func (c *Config) stickySession() (http.Header, http.CookieJar) {
+	if c.Network == nil || c.Network.ReadConsistency != ReadConsistency_Sticky {
+		return nil, nil
+	}
+
+	if c.stickySessionID == "" {
+		id := make([]byte, 16)
+		_, _ = rand.Read(id)
+		c.stickySessionID = common.Bytes2Hex(id)
+		// cookiejar.New never returns an error
+		c.stickySessionJar, _ = cookiejar.New(nil)
+	}
+
+	headerName := c.Network.StickySessionHeader
+	if headerName == "" {
+		headerName = DefaultStickySessionHeader
+	}
+	header := http.Header{}
+	header.Set(headerName, c.stickySessionID)
+
+	return header, c.stickySessionJar
+}
+
+// stickySessionDialOptions returns dial options that send session header with each request, if sticky read consistency
+// is enabled. Cookie jar has to be set on HTTP client separately.
+func (c *Config) stickySessionDialOptions() []rpc.ClientOption {
+	header, _ := c.stickySession()
+	if header == nil {
+		return nil
+	}
+
+	return []rpc.ClientOption{rpc.WithHeaders(header)}
+}
+
+// writeTracker tracks nonces of transactions sent and mined by the client, so that reads can be checked against them
+type writeTracker struct {
+	mu    sync.Mutex
+	sent  map[common.Address]uint64
+	mined map[common.Address]uint64
+}
+
+func newWriteTracker() *writeTracker {
+	return &writeTracker{
+		sent:  make(map[common.Address]uint64),
+		mined: make(map[common.Address]uint64),
+	}
+}
+
+// recordSent and recordMined are no-ops on nil tracker, which is used when read consistency isn't "retry"
+func (w *writeTracker) recordSent(tx *types.Transaction) {
+	if w == nil {
+		return
+	}
+	w.record(w.sent, tx)
+}
+
+func (w *writeTracker) recordMined(tx *types.Transaction) {
+	if w == nil {
+		return
+	}
+	w.record(w.mined, tx)
+}
+
+func (w *writeTracker) record(nonces map[common.Address]uint64, tx *types.Transaction) {
+	if tx == nil {
+		return
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Debug().
+			Err(err).
+			Str("Transaction", tx.Hash().Hex()).
+			Msg("Failed to get transaction sender. Reads won't be checked against it")
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if tx.Nonce()+1 > nonces[from] {
+		nonces[from] = tx.Nonce() + 1
+	}
+}
+
+// expectedNonce returns the lowest nonce node has to return for the address at given block tag to be consistent with
+// client's writes. Pending state has to include sent transactions, latest state only mined ones. Reads of other blocks
+// aren't checked.
+func (w *writeTracker) expectedNonce(addr common.Address, blockTag string) uint64 {
+	if w == nil {
+		return 0
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	switch strings.TrimSpace(strings.ToLower(blockTag)) {
+	case BlockTag_Latest, "":
+		return w.mined[addr]
+	case BlockTag_Pending:
+		if w.sent[addr] > w.mined[addr] {
+			return w.sent[addr]
+		}
+		return w.mined[addr]
+	default:
+		return 0
+	}
+}
+
+// consistentNonce reads nonce until it reflects client's writes or context is done
+func (m *Client) consistentNonce(ctx context.Context, addr common.Address, block *big.Int, blockTag string) (uint64, error) {
+	expected := m.writes.expectedNonce(addr, blockTag)
+	var lastNonce uint64
+	for attempt := 1; ; attempt++ {
+		nonce, err := m.Client.NonceAt(ctx, addr, block)
+		if err != nil {
+			return 0, inconsistentReadErr(ctx, err, attempt, addr, lastNonce, expected)
+		}
+		if nonce >= expected {
+			return nonce, nil
+		}
+		lastNonce = nonce
+		if waitErr := m.waitForConsistentRead(ctx, addr, attempt, nonce, expected); waitErr != nil {
+			return 0, waitErr
+		}
+	}
+}
+
+// consistentBalance reads balance together with nonce in a single batch request (so that both are answered by the same
+// node) until nonce reflects client's writes or context is done
+func (m *Client) consistentBalance(ctx context.Context, addr common.Address, block *big.Int, blockTag string) (*big.Int, error) {
+	expected := m.writes.expectedNonce(addr, blockTag)
+	if expected == 0 {
+		return m.Client.BalanceAt(ctx, addr, block)
+	}
+
+	blockArg := toBlockNumArg(block)
+	var lastNonce uint64
+	for attempt := 1; ; attempt++ {
+		var balance hexutil.Big
+		var nonce hexutil.Uint64
+		batch := []rpc.BatchElem{
+			{Method: "eth_getBalance", Args: []interface{}{addr, blockArg}, Result: &balance},
+			{Method: "eth_getTransactionCount", Args: []interface{}{addr, blockArg}, Result: &nonce},
+		}
+		if err := m.Client.Client().BatchCallContext(ctx, batch); err != nil {
+			return nil, inconsistentReadErr(ctx, err, attempt, addr, lastNonce, expected)
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+		}
+		if uint64(nonce) >= expected {
+			return balance.ToInt(), nil
+		}
+		lastNonce = uint64(nonce)
+		if waitErr := m.waitForConsistentRead(ctx, addr, attempt, uint64(nonce), expected); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+func (m *Client) waitForConsistentRead(ctx context.Context, addr common.Address, attempt int, nonce, expected uint64) error {
+	L.Debug().
+		Str("Address", addr.Hex()).
+		Uint64("Nonce", nonce).
+		Uint64("Expected nonce", expected).
+		Int("Attempt", attempt).
+		Msg("RPC node doesn't reflect transactions sent by the client yet. Retrying read")
+
+	timer := time.NewTimer(m.ReceiptPollInterval())
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "%s: nonce of %s is %d, but %d was expected", ErrInconsistentRead, addr.Hex(), nonce, expected)
+	case <-timer.C:
+		return nil
+	}
+}
+
+// inconsistentReadErr returns inconsistent read error instead of the RPC one, if context was done while a read was being
+// retried, because it's the reason why read couldn't finish in time
+func inconsistentReadErr(ctx context.Context, err error, attempt int, addr common.Address, nonce, expected uint64) error {
+	if attempt == 1 || ctx.Err() == nil {
+		return err
+	}
+	return errors.Wrapf(ctx.Err(), "%s: nonce of %s is %d, but %d was expected", ErrInconsistentRead, addr.Hex(), nonce, expected)
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return BlockTag_Latest
+	}
+	return rpc.BlockNumber(number.Int64()).String()
+}
@@ -0,0 +1,113 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultReceiptPollInterval = time.Second
+
+	// number of most recent blocks used to calculate average block time for adaptive receipt polling
+	blockTimeSampleBlocks = 10
+	// with adaptive polling receipt is polled this many times per block
+	adaptivePollsPerBlock          = 4
+	minAdaptiveReceiptPollInterval = 100 * time.Millisecond
+	maxAdaptiveReceiptPollInterval = 10 * time.Second
+)
+
+// ReceiptPollFn returns how long to wait before next eth_getTransactionReceipt call made while waiting for transaction to be
+// mined. Attempt starts at 1, interval is the one Seth would use (configured, adaptive or default one), without jitter.
+type ReceiptPollFn func(attempt int, interval time.Duration) time.Duration
+
+// ReceiptPollInterval returns interval between receipt polls used by WaitMined. With adaptive polling enabled it's a fraction
+// of average block time observed on the network (calculated once per client), otherwise it's receipt_poll_interval or 1s if
+// it's not set.
+func (m *Client) ReceiptPollInterval() time.Duration {
+	interval := DefaultReceiptPollInterval
+	if m.Cfg.Network.ReceiptPollInterval != nil && m.Cfg.Network.ReceiptPollInterval.Duration() > 0 {
+		interval = m.Cfg.Network.ReceiptPollInterval.Duration()
+	}
+
+	if !m.Cfg.Network.AdaptiveReceiptPolling {
+		return interval
+	}
+
+	blockTime, ok := m.observedBlockTime()
+	if !ok {
+		return interval
+	}
+
+	adaptive := blockTime / adaptivePollsPerBlock
+	if adaptive < minAdaptiveReceiptPollInterval {
+		adaptive = minAdaptiveReceiptPollInterval
+	}
+	if adaptive > maxAdaptiveReceiptPollInterval {
+		adaptive = maxAdaptiveReceiptPollInterval
+	}
+
+	return adaptive
+}
+
+// receiptPollDelay returns how long to wait before next receipt poll, either using custom ReceiptPollFn or interval with
+// random jitter added
+func (m *Client) receiptPollDelay(attempt int) time.Duration {
+	interval := m.ReceiptPollInterval()
+	if m.Cfg.ReceiptPollFn != nil {
+		return m.Cfg.ReceiptPollFn(attempt, interval)
+	}
+
+	if m.Cfg.Network.ReceiptPollJitter != nil && m.Cfg.Network.ReceiptPollJitter.Duration() > 0 {
+		interval += time.Duration(rand.Int63n(int64(m.Cfg.Network.ReceiptPollJitter.Duration())))
+	}
+
+	return interval
+}
+
+// observedBlockTime returns average block time of most recent blocks. It's calculated only once, unless it failed
+func (m *Client) observedBlockTime() (time.Duration, bool) {
+	m.blockTimeMu.Lock()
+	defer m.blockTimeMu.Unlock()
+
+	if m.blockTime != nil {
+		return *m.blockTime, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	latest, err := m.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		L.Debug().Err(err).Msg("Failed to get latest block header. Falling back to configured receipt poll interval")
+		return 0, false
+	}
+	if latest.Number.Uint64() == 0 {
+		return 0, false
+	}
+
+	blocks := uint64(blockTimeSampleBlocks)
+	if latest.Number.Uint64() < blocks {
+		blocks = latest.Number.Uint64()
+	}
+
+	oldest, err := m.Client.HeaderByNumber(ctx, new(big.Int).Sub(latest.Number, new(big.Int).SetUint64(blocks)))
+	if err != nil {
+		L.Debug().Err(err).Msg("Failed to get block header. Falling back to configured receipt poll interval")
+		return 0, false
+	}
+
+	var blockTime time.Duration
+	if latest.Time > oldest.Time {
+		blockTime = time.Duration(latest.Time-oldest.Time) * time.Second / time.Duration(blocks)
+	}
+	m.blockTime = &blockTime
+
+	L.Debug().
+		Str("Block time", blockTime.String()).
+		Uint64("Blocks", blocks).
+		Msg("Calculated average block time for adaptive receipt polling")
+
+	return blockTime, true
+}
@@ -0,0 +1,386 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	InteractionManifestVersion = 1
+
+	RecordedStep_Deploy = "deploy"
+	RecordedStep_Call   = "call"
+
+	ErrNotRecording          = "client is not recording interactions, call StartRecording() first"
+	ErrReplayUnknownContract = "contract ABI not found in contract store"
+	ErrReplayNoBytecode      = "contract bytecode not found neither in contract store nor in the manifest"
+	ErrReplayKeyNotAvailable = "key used in the manifest is not available"
+	ErrReplayStepFailed      = "failed to replay step"
+)
+
+// InteractionManifest is a portable record of deployments and transactions performed through the client. It can be
+// saved to a file and replayed on another network with Replayer.
+type InteractionManifest struct {
+	Version int    `json:"version"`
+	Network string `json:"network"`
+	ChainID int64  `json:"chain_id"`
+	// Keys are addresses of client's keys at the time recording started, indexed by key number
+	Keys  []string       `json:"keys"`
+	Steps []RecordedStep `json:"steps"`
+}
+
+// RecordedStep is a single deployment or transaction. Calldata (or packed constructor arguments for deployments) is what's
+// replayed, decoded method and arguments are there only to make the manifest human-readable.
+type RecordedStep struct {
+	Type         string `json:"type"`
+	ContractName string `json:"contract_name,omitempty"`
+	// Label of contract instance, if it differs from contract name
+	Label    string                 `json:"label,omitempty"`
+	Address  string                 `json:"address,omitempty"`
+	KeyNum   int                    `json:"key_num"`
+	From     string                 `json:"from"`
+	Method   string                 `json:"method,omitempty"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	Calldata string                 `json:"calldata,omitempty"`
+	Bytecode string                 `json:"bytecode,omitempty"`
+	Value    string                 `json:"value,omitempty"`
+	TxHash   string                 `json:"tx_hash"`
+}
+
+// InteractionRecorder records contract deployments done with DeployContract() and successful transactions passed to Decode().
+// Steps are recorded in the order in which they were confirmed.
+type InteractionRecorder struct {
+	client   *Client
+	mu       *sync.Mutex
+	manifest *InteractionManifest
+}
+
+// NewInteractionRecorder creates a new recorder for given client
+func NewInteractionRecorder(client *Client) *InteractionRecorder {
+	keys := make([]string, 0, len(client.Addresses))
+	for _, addr := range client.Addresses {
+		keys = append(keys, addr.Hex())
+	}
+
+	return &InteractionRecorder{
+		client: client,
+		mu:     &sync.Mutex{},
+		manifest: &InteractionManifest{
+			Version: InteractionManifestVersion,
+			Network: client.Cfg.Network.Name,
+			ChainID: client.ChainID,
+			Keys:    keys,
+			Steps:   make([]RecordedStep, 0),
+		},
+	}
+}
+
+// StartRecording starts recording all deployments and transactions into a new manifest
+func (m *Client) StartRecording() *InteractionRecorder {
+	m.Recorder = NewInteractionRecorder(m)
+	L.Info().Msg("Started recording contract interactions")
+	return m.Recorder
+}
+
+// StopRecording stops recording and returns the recorded manifest
+func (m *Client) StopRecording() (*InteractionManifest, error) {
+	if m.Recorder == nil {
+		return nil, errors.New(ErrNotRecording)
+	}
+	manifest := m.Recorder.Manifest()
+	m.Recorder = nil
+	L.Info().Int("Steps", len(manifest.Steps)).Msg("Stopped recording contract interactions")
+
+	return manifest, nil
+}
+
+// SaveRecording saves manifest recorded so far to artifacts directory and returns its path
+func (m *Client) SaveRecording() (string, error) {
+	if m.Recorder == nil {
+		return "", errors.New(ErrNotRecording)
+	}
+
+	name := fmt.Sprintf("%s_%s", m.Cfg.Network.Name, time.Now().Format("2006-01-02-15-04-05"))
+	return saveAsJson(m.Recorder.Manifest(), filepath.Join(m.Cfg.ArtifactsDir, "recordings"), name)
+}
+
+// Manifest returns a copy of the manifest recorded so far
+func (r *InteractionRecorder) Manifest() *InteractionManifest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	manifest := *r.manifest
+	manifest.Keys = append([]string{}, r.manifest.Keys...)
+	manifest.Steps = append([]RecordedStep{}, r.manifest.Steps...)
+
+	return &manifest
+}
+
+func (r *InteractionRecorder) keyNum(addr common.Address) int {
+	for i, key := range r.client.Addresses {
+		if key == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+// label returns label of contract instance at given address or empty string if it's the same as contract name
+func (r *InteractionRecorder) label(address, name string) string {
+	if label := r.client.ContractAddressToNameMap.GetContractLabel(address); label != name {
+		return label
+	}
+	return ""
+}
+
+func (r *InteractionRecorder) recordDeployment(name string, contractABI abi.ABI, bytecode []byte, from, address common.Address, tx *types.Transaction, params ...interface{}) {
+	packedArgs, err := contractABI.Pack("", params...)
+	if err != nil {
+		L.Warn().Err(err).Str("Contract", name).Msg("Failed to pack constructor arguments. Deployment won't be recorded")
+		return
+	}
+
+	args := make(map[string]interface{})
+	for i, input := range contractABI.Constructor.Inputs {
+		if i < len(params) {
+			args[input.Name] = params[i]
+		}
+	}
+
+	r.append(RecordedStep{
+		Type:         RecordedStep_Deploy,
+		ContractName: name,
+		Label:        r.label(address.Hex(), name),
+		Address:      address.Hex(),
+		KeyNum:       r.keyNum(from),
+		From:         from.Hex(),
+		Args:         args,
+		Calldata:     common.Bytes2Hex(packedArgs),
+		Bytecode:     common.Bytes2Hex(bytecode),
+		Value:        tx.Value().String(),
+		TxHash:       tx.Hash().Hex(),
+	})
+}
+
+func (r *InteractionRecorder) recordCall(tx *types.Transaction, decoded *DecodedTransaction) {
+	if tx.To() == nil {
+		L.Debug().Str("Transaction", tx.Hash().Hex()).Msg("Not recording contract creation transaction, only deployments done with DeployContract() are recorded")
+		return
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Warn().Err(err).Str("Transaction", tx.Hash().Hex()).Msg("Failed to recover transaction sender. Transaction won't be recorded")
+		return
+	}
+
+	step := RecordedStep{
+		Type:         RecordedStep_Call,
+		ContractName: r.client.ContractAddressToNameMap.GetContractName(tx.To().Hex()),
+		Label:        r.label(tx.To().Hex(), r.client.ContractAddressToNameMap.GetContractName(tx.To().Hex())),
+		Address:      tx.To().Hex(),
+		KeyNum:       r.keyNum(from),
+		From:         from.Hex(),
+		Calldata:     common.Bytes2Hex(tx.Data()),
+		Value:        tx.Value().String(),
+		TxHash:       tx.Hash().Hex(),
+	}
+	if decoded != nil {
+		step.Method = decoded.Method
+		step.Args = decoded.Input
+	}
+
+	r.append(step)
+}
+
+func (r *InteractionRecorder) append(step RecordedStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manifest.Steps = append(r.manifest.Steps, step)
+	L.Debug().
+		Str("Type", step.Type).
+		Str("Contract", step.ContractName).
+		Str("Method", step.Method).
+		Str("TXHash", step.TxHash).
+		Msg("Recorded contract interaction")
+}
+
+// Save saves the manifest as JSON to given path
+func (im *InteractionManifest) Save(path string) error {
+	b, err := json.MarshalIndent(im, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0600)
+}
+
+// LoadInteractionManifest loads manifest from a JSON file
+func LoadInteractionManifest(path string) (*InteractionManifest, error) {
+	manifest := &InteractionManifest{}
+	if err := OpenJsonFileAsStruct(path, manifest); err != nil {
+		return nil, errors.Wrapf(err, "failed to load interaction manifest from %s", path)
+	}
+	if manifest.Version != InteractionManifestVersion {
+		return nil, fmt.Errorf("unsupported interaction manifest version %d, expected %d", manifest.Version, InteractionManifestVersion)
+	}
+
+	return manifest, nil
+}
+
+// ReplayReport contains results of replaying a manifest
+type ReplayReport struct {
+	// Addresses maps addresses from the manifest (both deployed contracts and keys) to their counterparts on the new network
+	Addresses    map[common.Address]common.Address
+	Deployments  map[string]DeploymentData
+	Transactions []*DecodedTransaction
+}
+
+// Replayer re-executes a recorded manifest using given client. Contracts are deployed again and all their addresses (as
+// well as addresses of keys) found in constructor arguments and calldata are replaced with new ones, so that scenarios
+// that wire contracts together can be migrated between networks (e.g. from devnet to testnet). Keys are matched by key number.
+type Replayer struct {
+	client *Client
+}
+
+// NewReplayer creates a new replayer
+func NewReplayer(client *Client) *Replayer {
+	return &Replayer{client: client}
+}
+
+// Replay executes all steps from the manifest in order and stops at first failure
+func (r *Replayer) Replay(manifest *InteractionManifest) (*ReplayReport, error) {
+	report := &ReplayReport{
+		Addresses:    make(map[common.Address]common.Address),
+		Deployments:  make(map[string]DeploymentData),
+		Transactions: make([]*DecodedTransaction, 0),
+	}
+
+	for i, key := range manifest.Keys {
+		if i < len(r.client.Addresses) {
+			report.Addresses[common.HexToAddress(key)] = r.client.Addresses[i]
+		}
+	}
+
+	for i, step := range manifest.Steps {
+		if step.KeyNum < 0 || step.KeyNum >= len(r.client.Addresses) {
+			return report, errors.Wrapf(errors.New(ErrReplayKeyNotAvailable), "%s %d: key number %d (%s)", ErrReplayStepFailed, i, step.KeyNum, step.From)
+		}
+
+		var err error
+		switch step.Type {
+		case RecordedStep_Deploy:
+			err = r.replayDeployment(step, report)
+		case RecordedStep_Call:
+			err = r.replayCall(step, report)
+		default:
+			err = fmt.Errorf("unknown step type '%s'", step.Type)
+		}
+		if err != nil {
+			return report, errors.Wrapf(err, "%s %d (%s %s)", ErrReplayStepFailed, i, step.ContractName, step.Method)
+		}
+
+		L.Info().
+			Int("Step", i+1).
+			Int("Total", len(manifest.Steps)).
+			Str("Type", step.Type).
+			Str("Contract", step.ContractName).
+			Str("Method", step.Method).
+			Msg("Replayed contract interaction")
+	}
+
+	return report, nil
+}
+
+func (r *Replayer) replayDeployment(step RecordedStep, report *ReplayReport) error {
+	m := r.client
+	contractABI, ok := m.ContractStore.GetABI(step.ContractName)
+	if !ok {
+		return errors.Wrapf(errors.New(ErrReplayUnknownContract), "contract %s", step.ContractName)
+	}
+
+	bytecode, ok := m.ContractStore.GetBIN(step.ContractName)
+	if !ok {
+		if step.Bytecode == "" {
+			return errors.Wrapf(errors.New(ErrReplayNoBytecode), "contract %s", step.ContractName)
+		}
+		bytecode = common.FromHex(step.Bytecode)
+	}
+
+	params, err := contractABI.Constructor.Inputs.Unpack(remapAddresses(common.FromHex(step.Calldata), report.Addresses))
+	if err != nil {
+		return errors.Wrap(err, "failed to unpack constructor arguments")
+	}
+
+	opts := m.NewTXKeyOpts(step.KeyNum)
+	opts.Value = parseStepValue(step.Value)
+	data, err := m.DeployContract(opts, step.ContractName, *contractABI, bytecode, params...)
+	if err != nil {
+		return err
+	}
+
+	report.Addresses[common.HexToAddress(step.Address)] = data.Address
+	report.Deployments[step.Address] = data
+
+	return nil
+}
+
+func (r *Replayer) replayCall(step RecordedStep, report *ReplayReport) error {
+	m := r.client
+	to := common.HexToAddress(step.Address)
+	if mapped, ok := report.Addresses[to]; ok {
+		to = mapped
+	}
+
+	calldata := common.FromHex(step.Calldata)
+	if len(calldata) > 4 {
+		calldata = append(calldata[:4:4], remapAddresses(calldata[4:], report.Addresses)...)
+	}
+
+	opts := m.NewTXKeyOpts(step.KeyNum)
+	opts.Value = parseStepValue(step.Value)
+	tx, err := bind.NewBoundContract(to, abi.ABI{}, m.Client, m.Client, m.Client).RawTransact(opts, calldata)
+	decoded, err := m.Decode(tx, err)
+	if err != nil {
+		return err
+	}
+	report.Transactions = append(report.Transactions, decoded)
+
+	return nil
+}
+
+// remapAddresses replaces all ABI-encoded (left-padded to 32 bytes) occurrences of old addresses with new ones
+func remapAddresses(data []byte, addresses map[common.Address]common.Address) []byte {
+	for oldAddr, newAddr := range addresses {
+		if oldAddr == newAddr {
+			continue
+		}
+		data = bytes.ReplaceAll(data, common.LeftPadBytes(oldAddr.Bytes(), 32), common.LeftPadBytes(newAddr.Bytes(), 32))
+	}
+
+	return data
+}
+
+func parseStepValue(value string) *big.Int {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+
+	return v
+}
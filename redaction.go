@@ -0,0 +1,182 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// RedactedPrefix starts every value masked by Redactor
+	RedactedPrefix = "REDACTED"
+
+	ErrEmptyRedactionRule = "redaction rule can't be empty"
+)
+
+// RedactionConfig lists sensitive calldata, which is masked in decoded transactions and calls. Methods can be given as method
+// names ("requestData"), method signatures ("requestData(string,bytes)") or either of them prefixed with contract name
+// ("Oracle.requestData"), all inputs and outputs of matching methods are masked. Arguments are names of inputs, outputs and
+// event parameters masked in all methods and events (e.g. "apiKey").
+type RedactionConfig struct {
+	Methods   []string `toml:"methods"`
+	Arguments []string `toml:"arguments"`
+}
+
+// Redactor is a registry of redaction rules. Values matching them are replaced with "REDACTED(<checksum>)", where checksum
+// is the first 4 bytes of keccak256 hash of the value, so that the same secret can still be correlated across traces
+// without being revealed. See RedactedValue.
+type Redactor struct {
+	mu        *sync.RWMutex
+	methods   map[string]bool
+	arguments map[string]bool
+}
+
+// NewRedactor creates a registry of redaction rules from config, which can be nil
+func NewRedactor(cfg *RedactionConfig) *Redactor {
+	r := &Redactor{
+		mu:        &sync.RWMutex{},
+		methods:   map[string]bool{},
+		arguments: map[string]bool{},
+	}
+	if cfg == nil {
+		return r
+	}
+
+	for _, method := range cfg.Methods {
+		r.RedactMethod(method)
+	}
+	for _, argument := range cfg.Arguments {
+		r.RedactArgument(argument)
+	}
+
+	return r
+}
+
+// RedactMethod masks all inputs and outputs of given method. See RedactionConfig for supported formats.
+func (r *Redactor) RedactMethod(method string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.methods[method] = true
+}
+
+// RedactArgument masks inputs, outputs and event parameters with given name in all methods and events
+func (r *Redactor) RedactArgument(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.arguments[name] = true
+}
+
+// IsEmpty returns true if there are no redaction rules
+func (r *Redactor) IsEmpty() bool {
+	if r == nil {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.methods) == 0 && len(r.arguments) == 0
+}
+
+// isMethodRedacted returns true if all values of given method called on given contract should be masked
+func (r *Redactor) isMethodRedacted(contractName, methodSig string) bool {
+	methodName := methodSig
+	if i := strings.Index(methodSig, "("); i >= 0 {
+		methodName = methodSig[:i]
+	}
+
+	keys := []string{methodName, methodSig}
+	if contractName != "" {
+		keys = append(keys, contractName+"."+methodName, contractName+"."+methodSig)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range keys {
+		if r.methods[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactValues masks values in place, all of them if the method is redacted, otherwise only those of redacted arguments
+func (r *Redactor) redactValues(contractName, methodSig string, values map[string]interface{}) {
+	if r.IsEmpty() || len(values) == 0 {
+		return
+	}
+	all := methodSig != "" && r.isMethodRedacted(contractName, methodSig)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for name, value := range values {
+		if all || r.arguments[name] {
+			values[name] = RedactedValue(value)
+		}
+	}
+}
+
+// redactCommonData masks inputs and outputs of decoded call or transaction
+func (r *Redactor) redactCommonData(contractName string, data *CommonData) {
+	r.redactValues(contractName, data.Method, data.Input)
+	r.redactValues(contractName, data.Method, data.Output)
+}
+
+// redactEvent masks parameters of decoded event, only argument rules apply to events
+func (r *Redactor) redactEvent(event *DecodedCommonLog) {
+	r.redactValues("", "", event.EventData)
+}
+
+// RedactedValue returns masked representation of a value, which can be used to find where a known secret was used in traces
+func RedactedValue(value interface{}) string {
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		raw = []byte(fmt.Sprint(v))
+	}
+
+	return fmt.Sprintf("%s(%s)", RedactedPrefix, hexutil.Encode(crypto.Keccak256(raw)[:4]))
+}
+
+func validateRedaction(cfg *RedactionConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	for _, rule := range append(append([]string{}, cfg.Methods...), cfg.Arguments...) {
+		if strings.TrimSpace(rule) == "" {
+			return fmt.Errorf("%s, check redaction.methods and redaction.arguments", ErrEmptyRedactionRule)
+		}
+	}
+
+	return nil
+}
+
+// RedactMethod masks all inputs and outputs of given method in decoded transactions, call traces and reports created from now on.
+// See RedactionConfig for supported formats. Raw calldata of transactions isn't masked, since it's needed to replay them.
+func (m *Client) RedactMethod(method string) {
+	m.redactor().RedactMethod(method)
+}
+
+// RedactArgument masks inputs, outputs and event parameters with given name in decoded transactions, call traces and
+// reports created from now on
+func (m *Client) RedactArgument(name string) {
+	m.redactor().RedactArgument(name)
+}
+
+// redactor returns client's redactor, creating it if needed, and makes sure that tracer shares it
+func (m *Client) redactor() *Redactor {
+	if m.Redactor == nil {
+		m.Redactor = NewRedactor(m.Cfg.Redaction)
+	}
+	if m.Tracer != nil {
+		m.Tracer.Redactor = m.Redactor
+	}
+
+	return m.Redactor
+}
@@ -100,6 +100,28 @@ var PriorityBasedGasBumpingStrategyFn = func(priority string) GasBumpStrategyFn
 	}
 }
 
+// reserveNonceForBump reserves nonce of transaction that is about to be replaced in nonce manager, so that new transactions
+// sent from the same key don't reuse it while the node might not see any pending transaction with that nonce. Returned
+// function releases the nonce and should be called once receipt for the nonce was observed or bumping was given up.
+func (m *Client) reserveNonceForBump(tx *types.Transaction) func() {
+	if m.NonceManager == nil || tx == nil {
+		return func() {}
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		L.Debug().
+			Err(err).
+			Str("Transaction", tx.Hash().Hex()).
+			Msg("Failed to get transaction sender. Its nonce won't be reserved during gas bumping")
+		return func() {}
+	}
+	m.NonceManager.ReserveNonce(sender, tx.Nonce())
+
+	return func() {
+		m.NonceManager.ReleaseNonce(sender, tx.Nonce())
+	}
+}
+
 // prepareReplacementTransaction bumps gas price of the transaction if it wasn't confirmed in time. It returns a signed replacement transaction.
 // Errors might be returned, because transaction was no longer pending, max gas price was reached or there was an error sending the transaction (e.g. nonce too low, meaning that original transaction was mined).
 var prepareReplacementTransaction = func(client *Client, tx *types.Transaction) (*types.Transaction, error) {
@@ -123,8 +145,9 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 		return nil, err
 	}
 
+	addrs, privateKeys := client.keys()
 	senderPkIdx := -1
-	for j, maybeSender := range client.Addresses {
+	for j, maybeSender := range addrs {
 		if maybeSender == sender {
 			senderPkIdx = j
 			break
@@ -136,7 +159,7 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 	}
 
 	maxGasPrice := big.NewInt(client.Cfg.GasBump.MaxGasPrice)
-	privateKey := client.PrivateKeys[senderPkIdx]
+	privateKey := privateKeys[senderPkIdx]
 	var replacementTx *types.Transaction
 
 	var checkMaxPrice = func(gasPrice, maxGasPrice *big.Int) error {
@@ -167,7 +190,7 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 			GasPrice: gasPrice,
 			Data:     tx.Data(),
 		}
-		replacementTx, err = types.SignNewTx(privateKey, signer, txData)
+		replacementTx, err = client.signNewTx(sender, privateKey, signer, txData)
 	case types.DynamicFeeTxType:
 		gasFeeCap := client.Cfg.GasBump.StrategyFn(tx.GasFeeCap())
 		gasTipCap := client.Cfg.GasBump.StrategyFn(tx.GasTipCap())
@@ -176,20 +199,25 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 		}
 		L.Warn().Interface("Old gas fee cap", tx.GasFeeCap()).Interface("New gas fee cap", gasFeeCap).Interface("Old gas tip cap", tx.GasTipCap()).Interface("New gas tip cap", gasTipCap).Msg("Bumping gas fee cap and tip cap for EIP-1559 transaction")
 		txData := &types.DynamicFeeTx{
-			Nonce:     tx.Nonce(),
-			To:        tx.To(),
-			Value:     tx.Value(),
-			Gas:       tx.Gas(),
-			GasFeeCap: gasFeeCap,
-			GasTipCap: gasTipCap,
-			Data:      tx.Data(),
+			Nonce:      tx.Nonce(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Gas:        tx.Gas(),
+			GasFeeCap:  gasFeeCap,
+			GasTipCap:  gasTipCap,
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
 		}
 
-		replacementTx, err = types.SignNewTx(privateKey, signer, txData)
+		replacementTx, err = client.signNewTx(sender, privateKey, signer, txData)
 	case types.BlobTxType:
 		if tx.To() == nil {
 			return nil, fmt.Errorf("blob tx with nil recipient is not supported")
 		}
+		// replacement has to carry the same blobs, otherwise node will reject it
+		if tx.BlobTxSidecar() == nil {
+			return nil, fmt.Errorf("blob tx without sidecar cannot be replaced")
+		}
 		gasFeeCap := client.Cfg.GasBump.StrategyFn(tx.GasFeeCap())
 		gasTipCap := client.Cfg.GasBump.StrategyFn(tx.GasTipCap())
 		blobFeeCap := client.Cfg.GasBump.StrategyFn(tx.BlobGasFeeCap())
@@ -199,18 +227,21 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 
 		L.Warn().Interface("Old gas fee cap", tx.GasFeeCap()).Interface("Old max fee per blob", tx.BlobGasFeeCap()).Interface("New max fee per blob", blobFeeCap).Interface("New gas fee cap", gasFeeCap).Interface("Old gas tip cap", tx.GasTipCap()).Interface("New gas tip cap", gasTipCap).Msg("Bumping gas fee cap and tip cap for Blob transaction")
 		txData := &types.BlobTx{
+			ChainID:    uint256.MustFromBig(tx.ChainId()),
 			Nonce:      tx.Nonce(),
 			To:         *tx.To(),
-			Value:      uint256.NewInt(tx.Value().Uint64()),
+			Value:      uint256.MustFromBig(tx.Value()),
 			Gas:        tx.Gas(),
-			GasFeeCap:  uint256.NewInt(gasFeeCap.Uint64()),
-			GasTipCap:  uint256.NewInt(gasTipCap.Uint64()),
-			BlobFeeCap: uint256.NewInt(blobFeeCap.Uint64()),
+			GasFeeCap:  uint256.MustFromBig(gasFeeCap),
+			GasTipCap:  uint256.MustFromBig(gasTipCap),
+			BlobFeeCap: uint256.MustFromBig(blobFeeCap),
 			BlobHashes: tx.BlobHashes(),
 			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			Sidecar:    tx.BlobTxSidecar(),
 		}
 
-		replacementTx, err = types.SignNewTx(privateKey, signer, txData)
+		replacementTx, err = client.signNewTx(sender, privateKey, signer, txData)
 	case types.AccessListTxType:
 		gasPrice := client.Cfg.GasBump.StrategyFn(tx.GasPrice())
 		if err := checkMaxPrice(gasPrice, maxGasPrice); err != nil {
@@ -223,11 +254,12 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 			To:         tx.To(),
 			Value:      tx.Value(),
 			Gas:        tx.Gas(),
+			GasPrice:   gasPrice,
 			Data:       tx.Data(),
 			AccessList: tx.AccessList(),
 		}
 
-		replacementTx, err = types.SignNewTx(privateKey, signer, txData)
+		replacementTx, err = client.signNewTx(sender, privateKey, signer, txData)
 
 	default:
 		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
@@ -237,6 +269,8 @@ var prepareReplacementTransaction = func(client *Client, tx *types.Transaction)
 		return nil, err
 	}
 
+	client.journalTx(replacementTx, fmt.Sprintf("gas bump of %s", tx.Hash().Hex()))
+
 	ctx, cancel := context.WithTimeout(context.Background(), client.Cfg.Network.TxnTimeout.Duration())
 	defer cancel()
 	err = client.Client.SendTransaction(ctx, replacementTx)
@@ -0,0 +1,56 @@
+package seth
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errorSelector is the selector of Solidity's built-in Error(string) error, which is used by require(condition, "message")
+// and revert("message")
+var errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+// DecodeRevertString decodes revert data of Error(string) error and returns it as "revert: message". It returns false
+// if data is not an Error(string) error.
+func DecodeRevertString(data []byte) (string, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], errorSelector) {
+		return "", false
+	}
+	message, err := abi.UnpackRevert(data)
+	if err != nil {
+		return "", false
+	}
+
+	return "revert: " + message, true
+}
+
+// DecodeRevertReason decodes revert data of Solidity's built-in errors, Error(string) and Panic(uint256), which don't need
+// any ABI to be decoded. It returns false if data is neither of them.
+func DecodeRevertReason(data []byte) (string, bool) {
+	if reason, isPanic := DecodePanic(data); isPanic {
+		return reason, true
+	}
+
+	return DecodeRevertString(data)
+}
+
+// revertData returns revert data attached to RPC error, if there is any
+func revertData(err error) ([]byte, bool) {
+	dataErr, ok := err.(rpc.DataError)
+	if !ok {
+		return nil, false
+	}
+	errData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, err := hexutil.Decode(errData)
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
@@ -0,0 +1,288 @@
+package seth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	ReplayMode_Simulate = "simulate"
+	ReplayMode_Trace    = "trace"
+
+	ErrReadRevertedTransactions = "failed to read reverted transactions"
+	ErrUnknownReplayMode        = "unknown replay mode"
+	ErrReplayRevertedTx         = "failed to replay reverted transaction"
+)
+
+// RevertedTransaction is a reverted transaction saved to reverted transactions file, it has everything that's needed
+// to re-simulate it without fetching it from the node
+type RevertedTransaction struct {
+	Hash        string `json:"hash"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Calldata    string `json:"calldata,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	Method      string `json:"method,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// UnmarshalJSON also accepts a plain transaction hash, which is how older versions of Seth saved reverted transactions
+func (r *RevertedTransaction) UnmarshalJSON(data []byte) error {
+	var hash string
+	if err := json.Unmarshal(data, &hash); err == nil {
+		*r = RevertedTransaction{Hash: hash}
+		return nil
+	}
+
+	type plain RevertedTransaction
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*r = RevertedTransaction(p)
+
+	return nil
+}
+
+// RevertedTransactionReplay is the outcome of re-simulating or re-tracing a reverted transaction
+type RevertedTransactionReplay struct {
+	Transaction RevertedTransaction
+	// StillReverts is true if transaction reverted again when it was replayed
+	StillReverts bool
+	// Error is the revert reason returned by the replay, empty if transaction didn't revert
+	Error string
+	// Decoded is set only in trace mode
+	Decoded *DecodedTransaction
+	// Err is set if transaction couldn't be replayed at all (e.g. it wasn't found)
+	Err error
+}
+
+// ReadRevertedTransactions reads reverted transactions file created by Seth. Files that contain only transaction hashes
+// are supported as well.
+func ReadRevertedTransactions(path string) ([]RevertedTransaction, error) {
+	var txs []RevertedTransaction
+	if err := OpenJsonFileAsStruct(path, &txs); err != nil {
+		return nil, errors.Wrapf(err, "%s from %s", ErrReadRevertedTransactions, path)
+	}
+
+	return txs, nil
+}
+
+// ReplayRevertedTransactionsFile reads reverted transactions file and replays all transactions from it. See
+// ReplayRevertedTransactions for details.
+func (m *Client) ReplayRevertedTransactionsFile(path, mode string, atLatest bool) ([]*RevertedTransactionReplay, error) {
+	txs, err := ReadRevertedTransactions(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.ReplayRevertedTransactions(txs, mode, atLatest)
+}
+
+// ReplayRevertedTransactions replays reverted transactions one by one for failure triage. In ReplayMode_Simulate each
+// transaction is executed with eth_call at the block it was mined in, or at the latest block if atLatest is true (e.g.
+// to check if a fix deployed in the meantime works). In ReplayMode_Trace each transaction is fetched from the node and
+// decoded and traced again according to tracing level. Failure to replay one transaction doesn't stop the others, it's
+// reported in its result.
+func (m *Client) ReplayRevertedTransactions(txs []RevertedTransaction, mode string, atLatest bool) ([]*RevertedTransactionReplay, error) {
+	if mode != ReplayMode_Simulate && mode != ReplayMode_Trace {
+		return nil, fmt.Errorf("%s: %s", ErrUnknownReplayMode, mode)
+	}
+
+	results := make([]*RevertedTransactionReplay, 0, len(txs))
+	for _, tx := range txs {
+		var result *RevertedTransactionReplay
+		if mode == ReplayMode_Simulate {
+			result = m.simulateRevertedTransaction(tx, atLatest)
+		} else {
+			result = m.traceRevertedTransaction(tx)
+		}
+		if result.Err != nil {
+			L.Warn().
+				Err(result.Err).
+				Str("Transaction", tx.Hash).
+				Msg(ErrReplayRevertedTx)
+		} else {
+			L.Info().
+				Str("Transaction", tx.Hash).
+				Str("Mode", mode).
+				Bool("Still reverts", result.StillReverts).
+				Str("Error", result.Error).
+				Msg("Replayed reverted transaction")
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (m *Client) simulateRevertedTransaction(rt RevertedTransaction, atLatest bool) *RevertedTransactionReplay {
+	result := &RevertedTransactionReplay{Transaction: rt}
+
+	// transactions saved as plain hashes have to be fetched from the node
+	if rt.From == "" || rt.BlockNumber == 0 {
+		fetched, err := m.fetchRevertedTransaction(rt.Hash)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		fetched.Error = rt.Error
+		rt = fetched
+		result.Transaction = rt
+	}
+
+	msg, err := rt.callMsg()
+	if err != nil {
+		result.Err = errors.Wrap(err, ErrReplayRevertedTx)
+		return result
+	}
+
+	var block *big.Int
+	if !atLatest {
+		block = new(big.Int).SetUint64(rt.BlockNumber)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	_, callErr := m.Client.CallContract(ctx, msg, block)
+	if callErr == nil {
+		return result
+	}
+
+	result.StillReverts = true
+	result.Error = callErr.Error()
+	if reason, decodeErr := m.DecodeCustomABIErr(callErr); decodeErr == nil && reason != "" {
+		result.Error = reason
+	}
+
+	return result
+}
+
+func (m *Client) traceRevertedTransaction(rt RevertedTransaction) *RevertedTransactionReplay {
+	result := &RevertedTransactionReplay{Transaction: rt}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	tx, _, err := m.Client.TransactionByHash(ctx, common.HexToHash(rt.Hash))
+	cancel()
+	if err != nil {
+		result.Err = errors.Wrapf(err, "%s: failed to get transaction %s", ErrReplayRevertedTx, rt.Hash)
+		return result
+	}
+
+	result.Decoded, err = m.Decode(tx, nil)
+	if err != nil {
+		result.StillReverts = true
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// fetchRevertedTransaction gets all data needed to re-simulate transaction from the node
+func (m *Client) fetchRevertedTransaction(hash string) (RevertedTransaction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	tx, _, err := m.Client.TransactionByHash(ctx, common.HexToHash(hash))
+	if err != nil {
+		return RevertedTransaction{}, errors.Wrapf(err, "%s: failed to get transaction %s", ErrReplayRevertedTx, hash)
+	}
+	receipt, err := m.Client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return RevertedTransaction{}, errors.Wrapf(err, "%s: failed to get receipt of transaction %s", ErrReplayRevertedTx, hash)
+	}
+
+	return newRevertedTransaction(tx, receipt, "", nil)
+}
+
+func (r RevertedTransaction) callMsg() (ethereum.CallMsg, error) {
+	msg := ethereum.CallMsg{From: common.HexToAddress(r.From)}
+	if r.To != "" {
+		to := common.HexToAddress(r.To)
+		msg.To = &to
+	}
+	if r.Calldata != "" {
+		data, err := hexutil.Decode(r.Calldata)
+		if err != nil {
+			return msg, errors.Wrap(err, "invalid calldata")
+		}
+		msg.Data = data
+	}
+	if r.Value != "" {
+		value, ok := new(big.Int).SetString(r.Value, 10)
+		if !ok {
+			return msg, fmt.Errorf("invalid value: %s", r.Value)
+		}
+		msg.Value = value
+	}
+
+	return msg, nil
+}
+
+func newRevertedTransaction(tx *types.Transaction, receipt *types.Receipt, method string, revertErr error) (RevertedTransaction, error) {
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return RevertedTransaction{}, errors.Wrap(err, "failed to get sender of transaction")
+	}
+
+	rt := RevertedTransaction{
+		Hash:     tx.Hash().Hex(),
+		From:     sender.Hex(),
+		Value:    tx.Value().String(),
+		Calldata: hexutil.Encode(tx.Data()),
+		Method:   method,
+	}
+	if tx.To() != nil {
+		rt.To = tx.To().Hex()
+	}
+	if receipt != nil && receipt.BlockNumber != nil {
+		rt.BlockNumber = receipt.BlockNumber.Uint64()
+	}
+	if revertErr != nil {
+		rt.Error = revertErr.Error()
+	}
+
+	return rt, nil
+}
+
+// saveRevertedTransaction appends reverted transaction to reverted transactions file, if tracing to JSON is enabled
+func (m *Client) saveRevertedTransaction(l zerolog.Logger, tx *types.Transaction, receipt *types.Receipt, decoded *DecodedTransaction, revertErr error) {
+	if !m.Cfg.hasOutput(TraceOutput_JSON) || m.Cfg.revertedTransactionsFile == "" {
+		return
+	}
+
+	var method string
+	if decoded != nil {
+		method = decoded.Method
+	}
+	rt, err := newRevertedTransaction(tx, receipt, method, revertErr)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(m.Cfg.revertedTransactionsFile), os.ModePerm)
+	}
+	if err == nil {
+		err = CreateOrAppendToJsonArray(m.Cfg.revertedTransactionsFile, rt)
+	}
+	if err != nil {
+		l.Warn().
+			Err(err).
+			Str("TXHash", tx.Hash().Hex()).
+			Msg("Failed to save reverted transaction to file")
+		return
+	}
+
+	l.Trace().
+		Str("TXHash", tx.Hash().Hex()).
+		Str("File", m.Cfg.revertedTransactionsFile).
+		Msg("Saved reverted transaction to file")
+}
@@ -0,0 +1,158 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrReadJWTSecret  = "failed to read JWT secret file"
+	ErrInvalidJWT     = "JWT secret must be 32 bytes long"
+	ErrRPCAuthRefresh = "failed to refresh RPC auth token"
+
+	// DefaultTokenRefreshMargin is how long before expiry tokens are refreshed by providers created with NewRefreshingTokenProvider
+	DefaultTokenRefreshMargin = 30 * time.Second
+)
+
+// RPCAuthProvider returns value of Authorization header sent with each HTTP request to the RPC node (and when WS connection
+// is established). It's called before every request, so it should cache tokens and refresh them only when needed. It must
+// be safe for concurrent use.
+type RPCAuthProvider interface {
+	AuthHeader() (string, error)
+}
+
+// RPCAuthProviderFn is a function that implements RPCAuthProvider
+type RPCAuthProviderFn func() (string, error)
+
+func (f RPCAuthProviderFn) AuthHeader() (string, error) {
+	return f()
+}
+
+// NewJWTAuthProvider returns provider that signs a new token for each request, as required by Engine API authentication:
+// HS256 with "iat" claim set to current time. Secret has to be 32 bytes long.
+func NewJWTAuthProvider(secret []byte) (RPCAuthProvider, error) {
+	if len(secret) != 32 {
+		return nil, errors.New(ErrInvalidJWT)
+	}
+	key := make([]byte, len(secret))
+	copy(key, secret)
+
+	return RPCAuthProviderFn(func() (string, error) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iat": &jwt.NumericDate{Time: time.Now()},
+		})
+		signed, err := token.SignedString(key)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to sign JWT token")
+		}
+		return "Bearer " + signed, nil
+	}), nil
+}
+
+// NewJWTAuthProviderFromFile works like NewJWTAuthProvider, but reads hex-encoded secret from file (the same format as
+// jwtsecret file used by execution clients)
+func NewJWTAuthProviderFromFile(path string) (RPCAuthProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrReadJWTSecret)
+	}
+	secret := common.FromHex(strings.TrimSpace(string(data)))
+
+	return NewJWTAuthProvider(secret)
+}
+
+// TokenRefreshFn returns new bearer token and the time when it expires. Zero expiry time means that token never expires.
+type TokenRefreshFn func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+type refreshingTokenProvider struct {
+	refresh       TokenRefreshFn
+	refreshMargin time.Duration
+	timeout       time.Duration
+	mu            sync.Mutex
+	token         string
+	expiresAt     time.Time
+}
+
+// NewRefreshingTokenProvider returns provider that caches bearer token and calls refresh function when there's no token yet
+// or it expires within refreshMargin (DefaultTokenRefreshMargin if it's 0)
+func NewRefreshingTokenProvider(refresh TokenRefreshFn, refreshMargin time.Duration) RPCAuthProvider {
+	if refreshMargin == 0 {
+		refreshMargin = DefaultTokenRefreshMargin
+	}
+
+	return &refreshingTokenProvider{
+		refresh:       refresh,
+		refreshMargin: refreshMargin,
+		timeout:       DefaultDialTimeout,
+	}
+}
+
+func (p *refreshingTokenProvider) AuthHeader() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token == "" || (!p.expiresAt.IsZero() && time.Now().Add(p.refreshMargin).After(p.expiresAt)) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		token, expiresAt, err := p.refresh(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, ErrRPCAuthRefresh)
+		}
+		p.token = token
+		p.expiresAt = expiresAt
+		L.Debug().
+			Time("Expires at", expiresAt).
+			Msg("Refreshed RPC auth token")
+	}
+
+	return "Bearer " + p.token, nil
+}
+
+// rpcAuthProvider returns auth provider set in config or, if JWT secret file is configured for the network, JWT auth provider.
+// It returns nil if RPC doesn't require authentication.
+func (c *Config) rpcAuthProvider() (RPCAuthProvider, error) {
+	if c.RPCAuthProvider != nil {
+		return c.RPCAuthProvider, nil
+	}
+	if c.Network == nil || c.Network.JWTSecretFile == "" {
+		return nil, nil
+	}
+
+	path := c.Network.JWTSecretFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.ConfigDir, path)
+	}
+
+	return NewJWTAuthProviderFromFile(path)
+}
+
+// rpcAuthDialOptions returns dial options that add Authorization header to each request, if RPC requires authentication
+func (c *Config) rpcAuthDialOptions() ([]rpc.ClientOption, error) {
+	provider, err := c.rpcAuthProvider()
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, nil
+	}
+
+	return []rpc.ClientOption{rpc.WithHTTPAuth(func(h http.Header) error {
+		value, err := provider.AuthHeader()
+		if err != nil {
+			return fmt.Errorf("failed to get RPC auth header: %w", err)
+		}
+		h.Set("Authorization", value)
+		return nil
+	})}, nil
+}
@@ -0,0 +1,345 @@
+package seth
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRPCFailoverCooldown is how long an endpoint that reached failure threshold is skipped
+	DefaultRPCFailoverCooldown = 30 * time.Second
+	// DefaultRPCFailoverFailureThreshold is the number of consecutive failures after which active endpoint is replaced
+	DefaultRPCFailoverFailureThreshold = 1
+
+	// rpcHealthScoreWeight is the weight of the latest request in endpoint's health score
+	rpcHealthScoreWeight = 0.2
+
+	ErrAllRPCEndpointsFailed = "all RPC endpoints failed"
+)
+
+// RPCFailoverConfig configures failover between RPC URLs of the network. Failover is enabled when the network has more than
+// one URL and all of them are HTTP(S), since websocket connections can't be moved to another endpoint per request.
+type RPCFailoverConfig struct {
+	// MaxAttempts is the maximum number of endpoints a single request is sent to, 0 means all of them
+	MaxAttempts uint `toml:"max_attempts"`
+	// RetryDelay is how long to wait before sending failed request to the next endpoint, nil means no delay
+	RetryDelay *Duration `toml:"retry_delay"`
+	// FailureThreshold is the number of consecutive failures after which an endpoint is considered unhealthy and, if it was
+	// the active one, replaced with the healthiest endpoint. Default is 1.
+	FailureThreshold uint `toml:"failure_threshold"`
+	// Cooldown is how long an unhealthy endpoint is skipped, unless all endpoints are unhealthy. Default is 30s.
+	Cooldown *Duration `toml:"cooldown"`
+}
+
+// RPCEndpointStats describes health of a single RPC endpoint
+type RPCEndpointStats struct {
+	// URL of the endpoint without path, query and user info, which often contain API keys
+	URL string
+	// Active is true for the endpoint requests are currently sent to
+	Active bool
+	// Healthy is false while the endpoint is skipped after reaching failure threshold
+	Healthy             bool
+	Successes           uint64
+	Failures            uint64
+	ConsecutiveFailures uint
+	// Score is exponentially weighted success rate of recent requests, between 0 and 1
+	Score float64
+}
+
+type rpcEndpoint struct {
+	url                 *url.URL
+	successes           uint64
+	failures            uint64
+	consecutiveFailures uint
+	score               float64
+	unhealthyUntil      time.Time
+}
+
+// rpcPool tracks health of network's RPC endpoints and selects the one requests are sent to. Requests stick to the active
+// endpoint, so that reads stay consistent with writes, until it fails.
+type rpcPool struct {
+	mu               sync.Mutex
+	endpoints        []*rpcEndpoint
+	active           int
+	maxAttempts      int
+	retryDelay       time.Duration
+	failureThreshold uint
+	cooldown         time.Duration
+}
+
+// rpcFailoverPool returns RPC endpoints pool, or nil if failover isn't enabled for the network. All clients and tracers
+// created from the same config share the pool, and with it endpoints' health. Pool is built only once, even if clients
+// are created concurrently.
+func (c *Config) rpcFailoverPool() *rpcPool {
+	c.rpcPoolOnce.Do(func() {
+		c.rpcPool = c.newRPCFailoverPool()
+	})
+
+	return c.rpcPool
+}
+
+// newRPCFailoverPool builds RPC endpoints pool from network's URLs and failover settings
+func (c *Config) newRPCFailoverPool() *rpcPool {
+	if c.Network == nil || len(c.Network.URLs) < 2 {
+		return nil
+	}
+
+	endpoints := make([]*rpcEndpoint, 0, len(c.Network.URLs))
+	for _, rawURL := range c.Network.URLs {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil
+		}
+		endpoints = append(endpoints, &rpcEndpoint{url: u, score: 1})
+	}
+
+	pool := &rpcPool{
+		endpoints:        endpoints,
+		maxAttempts:      len(endpoints),
+		failureThreshold: DefaultRPCFailoverFailureThreshold,
+		cooldown:         DefaultRPCFailoverCooldown,
+	}
+	if failover := c.RPCFailover; failover != nil {
+		if failover.MaxAttempts > 0 && int(failover.MaxAttempts) < len(endpoints) {
+			pool.maxAttempts = int(failover.MaxAttempts)
+		}
+		if failover.RetryDelay != nil {
+			pool.retryDelay = failover.RetryDelay.Duration()
+		}
+		if failover.FailureThreshold > 0 {
+			pool.failureThreshold = failover.FailureThreshold
+		}
+		if failover.Cooldown != nil {
+			pool.cooldown = failover.Cooldown.Duration()
+		}
+	}
+
+	return pool
+}
+
+// next returns index of the endpoint the next attempt of a request should be sent to: the active one first, then the
+// healthiest endpoint that wasn't tried yet. It returns -1 if all endpoints were tried.
+func (p *rpcPool) next(tried map[int]bool) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !tried[p.active] {
+		return p.active
+	}
+
+	return p.healthiestLocked(tried)
+}
+
+// healthiestLocked returns index of healthy endpoint with the highest score (first one in config order on ties), or of
+// the one that becomes healthy first, if all of them are unhealthy. Excluded endpoints are skipped.
+func (p *rpcPool) healthiestLocked(excluded map[int]bool) int {
+	now := time.Now()
+	best, bestUnhealthy := -1, -1
+	for i, e := range p.endpoints {
+		if excluded[i] {
+			continue
+		}
+		if now.Before(e.unhealthyUntil) {
+			if bestUnhealthy == -1 || e.unhealthyUntil.Before(p.endpoints[bestUnhealthy].unhealthyUntil) {
+				bestUnhealthy = i
+			}
+			continue
+		}
+		if best == -1 || e.score > p.endpoints[best].score {
+			best = i
+		}
+	}
+	if best == -1 {
+		return bestUnhealthy
+	}
+
+	return best
+}
+
+func (p *rpcPool) recordSuccess(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.endpoints[i]
+	e.successes++
+	e.consecutiveFailures = 0
+	e.unhealthyUntil = time.Time{}
+	e.score = e.score*(1-rpcHealthScoreWeight) + rpcHealthScoreWeight
+}
+
+// recordFailure marks endpoint as unhealthy once it reaches failure threshold and, if it was the active one, switches
+// to the healthiest endpoint
+func (p *rpcPool) recordFailure(i int, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.endpoints[i]
+	e.failures++
+	e.consecutiveFailures++
+	e.score = e.score * (1 - rpcHealthScoreWeight)
+	if e.consecutiveFailures < p.failureThreshold {
+		return
+	}
+	e.unhealthyUntil = time.Now().Add(p.cooldown)
+
+	if i != p.active {
+		return
+	}
+	if next := p.healthiestLocked(map[int]bool{i: true}); next != -1 {
+		p.active = next
+		L.Warn().
+			Str("Failed endpoint", redactURL(e.url.String())).
+			Str("New endpoint", redactURL(p.endpoints[next].url.String())).
+			Str("Reason", reason).
+			Uint("Consecutive failures", e.consecutiveFailures).
+			Msg("RPC endpoint failed. Switching to another one")
+	}
+}
+
+func (p *rpcPool) stats() []RPCEndpointStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]RPCEndpointStats, 0, len(p.endpoints))
+	for i, e := range p.endpoints {
+		stats = append(stats, RPCEndpointStats{
+			URL:                 redactURL(e.url.String()),
+			Active:              i == p.active,
+			Healthy:             !now.Before(e.unhealthyUntil),
+			Successes:           e.successes,
+			Failures:            e.failures,
+			ConsecutiveFailures: e.consecutiveFailures,
+			Score:               e.score,
+		})
+	}
+
+	return stats
+}
+
+// isFailoverStatus returns true for HTTP statuses returned by overloaded or unreachable nodes, which another endpoint
+// might not return
+func isFailoverStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// FailoverTransport sends requests to the active RPC endpoint of the pool and, on connection errors and responses of
+// overloaded or unreachable nodes (429, 502, 503, 504), sends them again to other endpoints. Since the same request can
+// reach more than one node, a transaction might be sent twice, which nodes reject as already known.
+type FailoverTransport struct {
+	Transport http.RoundTripper
+	pool      *rpcPool
+}
+
+// RoundTrip implements the RoundTripper interface
+func (t *FailoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tried := map[int]bool{}
+	for attempt := 1; ; attempt++ {
+		i := t.pool.next(tried)
+		tried[i] = true
+
+		attemptReq := req.Clone(req.Context())
+		endpointURL := *t.pool.endpoints[i].url
+		attemptReq.URL = &endpointURL
+		attemptReq.Host = endpointURL.Host
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.ContentLength = int64(len(body))
+		}
+
+		resp, err := transport.RoundTrip(attemptReq)
+		// request was cancelled by the caller, it's not endpoint's fault
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !isFailoverStatus(resp.StatusCode) {
+			t.pool.recordSuccess(i)
+			return resp, nil
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+		}
+		t.pool.recordFailure(i, reason)
+
+		if attempt >= t.pool.maxAttempts || len(tried) == len(t.pool.endpoints) {
+			if err != nil {
+				return nil, fmt.Errorf("%s (%d attempts): %w", ErrAllRPCEndpointsFailed, attempt, err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		L.Debug().
+			Str("Endpoint", redactURL(endpointURL.String())).
+			Str("Reason", reason).
+			Int("Attempt", attempt).
+			Msg("RPC request failed. Sending it to another endpoint")
+
+		if t.pool.retryDelay > 0 {
+			select {
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			case <-time.After(t.pool.retryDelay):
+			}
+		}
+	}
+}
+
+// rpcFailoverWarning returns a warning about ignored RPC URLs, if network has more than one URL, but failover can't be used
+func (c *Config) rpcFailoverWarning() string {
+	if len(c.Network.URLs) < 2 || c.rpcFailoverPool() != nil {
+		return ""
+	}
+	schemes := make([]string, 0, len(c.Network.URLs))
+	for _, rawURL := range c.Network.URLs {
+		if u, err := url.Parse(rawURL); err == nil {
+			schemes = append(schemes, u.Scheme)
+		}
+	}
+
+	return fmt.Sprintf("Multiple RPC URLs provided, but failover only supports HTTP(S) URLs (got: %s). Only the first one will be used", strings.Join(schemes, ", "))
+}
+
+// RPCEndpoints returns health of all RPC endpoints of the network, in config order. It returns nil if failover between
+// endpoints isn't enabled.
+func (m *Client) RPCEndpoints() []RPCEndpointStats {
+	pool := m.Cfg.rpcFailoverPool()
+	if pool == nil {
+		return nil
+	}
+
+	return pool.stats()
+}
@@ -0,0 +1,361 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	SafeContractName = "GnosisSafe"
+
+	SafeOperation_Call         uint8 = 0
+	SafeOperation_DelegateCall uint8 = 1
+
+	ErrSafeNotOwner            = "key is not an owner of the Safe"
+	ErrSafeNotEnoughSignatures = "not enough signatures to execute Safe transaction"
+	ErrSafeExecutionFailed     = "Safe transaction execution failed"
+	ErrSafeNoExecutionEvent    = "neither ExecutionSuccess nor ExecutionFailure event was emitted by the Safe"
+)
+
+// safeABIJson is a subset of Safe (v1.3.0+) ABI that's needed to execute transactions
+const safeABIJson = `[
+{"type":"function","name":"getOwners","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address[]"}]},
+{"type":"function","name":"getThreshold","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"nonce","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+{"type":"function","name":"getTransactionHash","stateMutability":"view","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"_nonce","type":"uint256"}],"outputs":[{"name":"","type":"bytes32"}]},
+{"type":"function","name":"execTransaction","stateMutability":"payable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]},
+{"type":"event","name":"ExecutionSuccess","anonymous":false,"inputs":[{"name":"txHash","type":"bytes32","indexed":false},{"name":"payment","type":"uint256","indexed":false}]},
+{"type":"event","name":"ExecutionFailure","anonymous":false,"inputs":[{"name":"txHash","type":"bytes32","indexed":false},{"name":"payment","type":"uint256","indexed":false}]}
+]`
+
+// SafeABI returns parsed ABI of the Safe contract (only methods and events used by Seth)
+func SafeABI() (abi.ABI, error) {
+	return abi.JSON(strings.NewReader(safeABIJson))
+}
+
+// SafeTransaction is a transaction that is executed by a Safe on behalf of its owners. Refund related fields
+// (SafeTxGas, BaseGas, GasPrice, GasToken, RefundReceiver) are zero by default, which means that executor pays for the gas.
+type SafeTransaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+	// Hash is the EIP-712 hash of the transaction that owners sign
+	Hash       common.Hash
+	signatures map[common.Address][]byte
+	mu         *sync.Mutex
+}
+
+// NewSafeTransaction creates a new Safe transaction without refunds. Its hash needs to be set before it can be signed, use
+// Safe.Propose() to create a transaction with hash calculated by the Safe.
+func NewSafeTransaction(to common.Address, value *big.Int, data []byte, operation uint8, nonce *big.Int) *SafeTransaction {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	return &SafeTransaction{
+		To:         to,
+		Value:      value,
+		Data:       data,
+		Operation:  operation,
+		SafeTxGas:  big.NewInt(0),
+		BaseGas:    big.NewInt(0),
+		GasPrice:   big.NewInt(0),
+		Nonce:      nonce,
+		signatures: make(map[common.Address][]byte),
+		mu:         &sync.Mutex{},
+	}
+}
+
+// Sign signs transaction hash with given private key and stores the signature
+func (stx *SafeTransaction) Sign(privateKey *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(stx.Hash.Bytes(), privateKey)
+	if err != nil {
+		return err
+	}
+	// Safe expects v to be 27 or 28 for signatures of the transaction hash
+	sig[crypto.RecoveryIDOffset] += 27
+
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	stx.signatures[crypto.PubkeyToAddress(privateKey.PublicKey)] = sig
+
+	return nil
+}
+
+// Signers returns addresses of owners that signed the transaction sorted in ascending order
+func (stx *SafeTransaction) Signers() []common.Address {
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	signers := make([]common.Address, 0, len(stx.signatures))
+	for signer := range stx.signatures {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0
+	})
+
+	return signers
+}
+
+// EncodedSignatures returns signatures concatenated in the order required by the Safe (ascending by signer address)
+func (stx *SafeTransaction) EncodedSignatures() []byte {
+	signers := stx.Signers()
+	stx.mu.Lock()
+	defer stx.mu.Unlock()
+	encoded := make([]byte, 0, len(signers)*crypto.SignatureLength)
+	for _, signer := range signers {
+		encoded = append(encoded, stx.signatures[signer]...)
+	}
+
+	return encoded
+}
+
+// SafeExecutionResult is the result of executing Safe transaction
+type SafeExecutionResult struct {
+	Success    bool
+	SafeTxHash common.Hash
+	// Payment is the refund paid by the Safe to the executor (0 if refunds are not used)
+	Payment     *big.Int
+	Transaction *DecodedTransaction
+}
+
+// Safe helps with proposing, signing and executing transactions of a Safe (formerly Gnosis Safe) multi-signature wallet
+// using keys loaded in the client
+type Safe struct {
+	Address  common.Address
+	client   *Client
+	abi      abi.ABI
+	contract *bind.BoundContract
+}
+
+// NewSafe creates a new helper for Safe deployed at given address. Safe ABI is added to the contract store and the address
+// to the contract map, so that its transactions and events are decoded.
+func NewSafe(client *Client, address common.Address) (*Safe, error) {
+	safeABI, err := SafeABI()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse Safe ABI")
+	}
+
+	if client.ContractStore != nil {
+		if _, ok := client.ContractStore.GetABI(SafeContractName); !ok {
+			client.ContractStore.AddABI(SafeContractName, safeABI)
+		}
+	}
+	if !client.ContractAddressToNameMap.IsKnownAddress(address.Hex()) {
+		client.ContractAddressToNameMap.AddContract(address.Hex(), SafeContractName)
+	}
+
+	return &Safe{
+		Address:  address,
+		client:   client,
+		abi:      safeABI,
+		contract: bind.NewBoundContract(address, safeABI, client.Client, client.Client, client.Client),
+	}, nil
+}
+
+func (s *Safe) call(method string, params ...interface{}) ([]interface{}, error) {
+	var out []interface{}
+	if err := s.contract.Call(&bind.CallOpts{Context: context.Background()}, &out, method, params...); err != nil {
+		return nil, errors.Wrapf(err, "failed to call Safe's %s", method)
+	}
+
+	return out, nil
+}
+
+// Owners returns owners of the Safe
+func (s *Safe) Owners() ([]common.Address, error) {
+	out, err := s.call("getOwners")
+	if err != nil {
+		return nil, err
+	}
+
+	return *abi.ConvertType(out[0], new([]common.Address)).(*[]common.Address), nil
+}
+
+// Threshold returns the number of signatures required to execute a transaction
+func (s *Safe) Threshold() (*big.Int, error) {
+	out, err := s.call("getThreshold")
+	if err != nil {
+		return nil, err
+	}
+
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// Nonce returns the nonce of the next Safe transaction
+func (s *Safe) Nonce() (*big.Int, error) {
+	out, err := s.call("nonce")
+	if err != nil {
+		return nil, err
+	}
+
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// Propose creates a new Safe transaction with the current Safe nonce and calculates its hash, which owners need to sign
+func (s *Safe) Propose(to common.Address, value *big.Int, data []byte, operation uint8) (*SafeTransaction, error) {
+	nonce, err := s.Nonce()
+	if err != nil {
+		return nil, err
+	}
+
+	stx := NewSafeTransaction(to, value, data, operation, nonce)
+
+	out, err := s.call("getTransactionHash", stx.To, stx.Value, stx.Data, stx.Operation, stx.SafeTxGas, stx.BaseGas, stx.GasPrice, stx.GasToken, stx.RefundReceiver, stx.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	stx.Hash = *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	L.Debug().
+		Str("Safe", s.Address.Hex()).
+		Str("To", to.Hex()).
+		Str("Nonce", nonce.String()).
+		Str("SafeTxHash", stx.Hash.Hex()).
+		Msg("Proposed Safe transaction")
+
+	return stx, nil
+}
+
+// Sign signs Safe transaction with keys with given numbers. All keys have to be owners of the Safe.
+func (s *Safe) Sign(stx *SafeTransaction, keyNums ...int) error {
+	owners, err := s.Owners()
+	if err != nil {
+		return err
+	}
+
+	for _, keyNum := range keyNums {
+		if keyNum < 0 || keyNum >= len(s.client.PrivateKeys) {
+			return errors.Wrap(errors.New(ErrNoKeyLoaded), fmt.Sprintf("requested key: %d", keyNum))
+		}
+		addr, privateKey := s.client.key(keyNum)
+		if !containsAddress(owners, addr) {
+			return errors.Wrapf(errors.New(ErrSafeNotOwner), "key %d (%s)", keyNum, addr.Hex())
+		}
+		if privateKey == nil {
+			return fmt.Errorf("%s: key %d can't sign Safe transactions", ErrNodeAccountSigning, keyNum)
+		}
+		if err := stx.Sign(privateKey); err != nil {
+			return errors.Wrapf(err, "failed to sign Safe transaction with key %d", keyNum)
+		}
+	}
+
+	return nil
+}
+
+// SignWithOwnerKeys signs Safe transaction with loaded keys that are owners of the Safe, until threshold is reached.
+// It returns the number of signatures the transaction has.
+func (s *Safe) SignWithOwnerKeys(stx *SafeTransaction) (int, error) {
+	owners, err := s.Owners()
+	if err != nil {
+		return 0, err
+	}
+	threshold, err := s.Threshold()
+	if err != nil {
+		return 0, err
+	}
+
+	addrs, privateKeys := s.client.keys()
+	for keyNum, addr := range addrs {
+		if int64(len(stx.Signers())) >= threshold.Int64() {
+			break
+		}
+		// node accounts can't sign Safe transactions, because they have no private key loaded
+		if !containsAddress(owners, addr) || keyNum >= len(privateKeys) || privateKeys[keyNum] == nil {
+			continue
+		}
+		if err := stx.Sign(privateKeys[keyNum]); err != nil {
+			return 0, errors.Wrapf(err, "failed to sign Safe transaction with key %d", keyNum)
+		}
+	}
+
+	return len(stx.Signers()), nil
+}
+
+// Execute sends Safe transaction signed by enough owners using key with given number (it doesn't need to be an owner) and
+// decodes ExecutionSuccess/ExecutionFailure event emitted by the Safe. If inner transaction failed, ErrSafeExecutionFailed is returned
+// together with the result.
+func (s *Safe) Execute(stx *SafeTransaction, keyNum int) (*SafeExecutionResult, error) {
+	threshold, err := s.Threshold()
+	if err != nil {
+		return nil, err
+	}
+	if signatures := len(stx.Signers()); int64(signatures) < threshold.Int64() {
+		return nil, fmt.Errorf("%s: has %d, but threshold is %s", ErrSafeNotEnoughSignatures, signatures, threshold.String())
+	}
+
+	decoded, err := s.client.Decode(s.contract.Transact(s.client.NewTXKeyOpts(keyNum), "execTransaction",
+		stx.To, stx.Value, stx.Data, stx.Operation, stx.SafeTxGas, stx.BaseGas, stx.GasPrice, stx.GasToken, stx.RefundReceiver, stx.EncodedSignatures()))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SafeExecutionResult{SafeTxHash: stx.Hash, Transaction: decoded}
+	found := false
+	for _, log := range decoded.Receipt.Logs {
+		if log.Address != s.Address || len(log.Topics) == 0 {
+			continue
+		}
+		for _, eventName := range []string{"ExecutionSuccess", "ExecutionFailure"} {
+			event := s.abi.Events[eventName]
+			if log.Topics[0] != event.ID {
+				continue
+			}
+			out, unpackErr := event.Inputs.Unpack(log.Data)
+			if unpackErr != nil {
+				return nil, errors.Wrapf(unpackErr, "failed to unpack %s event", eventName)
+			}
+			if common.Hash(*abi.ConvertType(out[0], new([32]byte)).(*[32]byte)) != stx.Hash {
+				continue
+			}
+			result.Success = eventName == "ExecutionSuccess"
+			result.Payment = *abi.ConvertType(out[1], new(*big.Int)).(**big.Int)
+			found = true
+		}
+	}
+
+	if !found {
+		return result, errors.New(ErrSafeNoExecutionEvent)
+	}
+
+	L.Info().
+		Str("Safe", s.Address.Hex()).
+		Str("SafeTxHash", stx.Hash.Hex()).
+		Bool("Success", result.Success).
+		Msg("Executed Safe transaction")
+
+	if !result.Success {
+		return result, errors.New(ErrSafeExecutionFailed)
+	}
+
+	return result, nil
+}
+
+func containsAddress(addresses []common.Address, addr common.Address) bool {
+	for _, a := range addresses {
+		if a == addr {
+			return true
+		}
+	}
+
+	return false
+}
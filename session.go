@@ -0,0 +1,394 @@
+package seth
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrExportSession = "failed to export session"
+
+	// RedactedSecret replaces secrets (RPC URLs paths, private keys) in exported config
+	RedactedSecret = "REDACTED"
+	// MaxSessionTransactions is the number of most recent decoded transactions kept for session export, older ones are dropped
+	MaxSessionTransactions = 10_000
+
+	SessionFile_Config          = "config.toml"
+	SessionFile_ContractMap     = "contract_map.json"
+	SessionFile_Transactions    = "transactions.json"
+	SessionFile_DecodedCalls    = "decoded_calls.json"
+	SessionFile_Report          = "report.json"
+	SessionFile_TracesDirectory = "traces"
+)
+
+// SessionTransaction is a compact record of a transaction decoded during the session
+type SessionTransaction struct {
+	Hash      string   `json:"hash"`
+	Method    string   `json:"method,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+	Contract  string   `json:"contract,omitempty"`
+	From      string   `json:"from,omitempty"`
+	FromLabel string   `json:"from_label,omitempty"`
+	To        string   `json:"to,omitempty"`
+	Reverted  bool     `json:"reverted"`
+	Block     uint64   `json:"block,omitempty"`
+	GasLimit  uint64   `json:"gas_limit,omitempty"`
+	GasUsed   uint64   `json:"gas_used,omitempty"`
+	GasPrice  *big.Int `json:"gas_price,omitempty"`
+	Fee       *big.Int `json:"fee,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+	DecodedAt string   `json:"decoded_at"`
+	// InclusionTime is how long it took to include the transaction, it's 0 if Seth didn't wait for it to be mined
+	InclusionTime   time.Duration `json:"inclusion_time,omitempty"`
+	InclusionBlocks uint64        `json:"inclusion_blocks,omitempty"`
+}
+
+// SessionMethodGas is gas used by all transactions calling the same method
+type SessionMethodGas struct {
+	Method       string `json:"method"`
+	Transactions int    `json:"transactions"`
+	MinGasUsed   uint64 `json:"min_gas_used"`
+	MaxGasUsed   uint64 `json:"max_gas_used"`
+	AvgGasUsed   uint64 `json:"avg_gas_used"`
+	TotalGasUsed uint64 `json:"total_gas_used"`
+}
+
+// SessionReport summarises transactions decoded during the session
+type SessionReport struct {
+	Network    string   `json:"network"`
+	ChainID    int64    `json:"chain_id"`
+	Keys       []string `json:"keys"`
+	ExportedAt string   `json:"exported_at"`
+	// Transactions is the number of decoded transactions, including those dropped from the export because of MaxSessionTransactions
+	Transactions int      `json:"transactions"`
+	Dropped      int      `json:"dropped_transactions,omitempty"`
+	Reverted     int      `json:"reverted"`
+	TotalGasUsed uint64   `json:"total_gas_used"`
+	TotalFees    *big.Int `json:"total_fees"`
+	// Spent is the amount tracked by spend budget, it's 0 if spending isn't limited
+	Spent       *big.Int           `json:"spent"`
+	GasByMethod []SessionMethodGas `json:"gas_by_method"`
+}
+
+// sessionLog keeps compact records of transactions decoded during the session
+type sessionLog struct {
+	mu      sync.Mutex
+	txs     []SessionTransaction
+	total   int
+	dropped int
+}
+
+// recordSessionTx adds decoded transaction to session log, it's a no-op for nil transaction
+func (m *Client) recordSessionTx(decoded *DecodedTransaction) {
+	if decoded == nil || decoded.Transaction == nil {
+		return
+	}
+	record := SessionTransaction{
+		Hash:      decoded.Hash,
+		Method:    decoded.Method,
+		Signature: decoded.Signature,
+		GasLimit:  decoded.Transaction.Gas(),
+		Error:     decoded.Error,
+		DecodedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if to := decoded.Transaction.To(); to != nil {
+		record.To = to.Hex()
+		record.Contract = strings.TrimSuffix(m.ContractAddressToNameMap.GetContractName(to.Hex()), ".abi")
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(decoded.Transaction.ChainId()), decoded.Transaction); err == nil {
+		record.From = from.Hex()
+		record.FromLabel = m.AddressLabel(from)
+	}
+	if receipt := decoded.Receipt; receipt != nil {
+		record.Reverted = receipt.Status != types.ReceiptStatusSuccessful
+		record.GasUsed = receipt.GasUsed
+		if receipt.BlockNumber != nil {
+			record.Block = receipt.BlockNumber.Uint64()
+		}
+		if receipt.EffectiveGasPrice != nil {
+			record.GasPrice = receipt.EffectiveGasPrice
+			record.Fee = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		}
+	}
+	if timing := decoded.Timing; timing != nil {
+		record.InclusionTime = timing.Duration
+		record.InclusionBlocks = timing.BlocksElapsed
+	}
+	for _, w := range decoded.Warnings {
+		record.Warnings = append(record.Warnings, w.Code)
+	}
+
+	m.session.mu.Lock()
+	defer m.session.mu.Unlock()
+	m.session.total++
+	m.session.txs = append(m.session.txs, record)
+	if len(m.session.txs) > MaxSessionTransactions {
+		m.session.txs = m.session.txs[1:]
+		m.session.dropped++
+	}
+}
+
+// SessionTransactions returns compact records of transactions decoded so far (at most MaxSessionTransactions most recent ones)
+func (m *Client) SessionTransactions() []SessionTransaction {
+	m.session.mu.Lock()
+	defer m.session.mu.Unlock()
+
+	return append([]SessionTransaction{}, m.session.txs...)
+}
+
+// SessionReport returns summary of transactions decoded so far: counts, fees and gas used by each method
+func (m *Client) SessionReport() SessionReport {
+	m.session.mu.Lock()
+	txs := append([]SessionTransaction{}, m.session.txs...)
+	total, dropped := m.session.total, m.session.dropped
+	m.session.mu.Unlock()
+
+	report := SessionReport{
+		Network:      m.Cfg.Network.Name,
+		ChainID:      m.ChainID,
+		Keys:         make([]string, 0, len(m.Addresses)),
+		ExportedAt:   time.Now().UTC().Format(time.RFC3339),
+		Transactions: total,
+		Dropped:      dropped,
+		TotalFees:    big.NewInt(0),
+		Spent:        m.Spent(),
+		GasByMethod:  []SessionMethodGas{},
+	}
+	for _, addr := range m.Addresses {
+		report.Keys = append(report.Keys, addr.Hex())
+	}
+
+	byMethod := map[string]*SessionMethodGas{}
+	for _, tx := range txs {
+		if tx.Reverted {
+			report.Reverted++
+		}
+		report.TotalGasUsed += tx.GasUsed
+		if tx.Fee != nil {
+			report.TotalFees.Add(report.TotalFees, tx.Fee)
+		}
+
+		method := tx.Method
+		if method == "" {
+			method = UNKNOWN
+		}
+		if tx.Contract != "" {
+			method = tx.Contract + "." + method
+		}
+		stats, ok := byMethod[method]
+		if !ok {
+			stats = &SessionMethodGas{Method: method, MinGasUsed: tx.GasUsed}
+			byMethod[method] = stats
+		}
+		stats.Transactions++
+		stats.TotalGasUsed += tx.GasUsed
+		if tx.GasUsed < stats.MinGasUsed {
+			stats.MinGasUsed = tx.GasUsed
+		}
+		if tx.GasUsed > stats.MaxGasUsed {
+			stats.MaxGasUsed = tx.GasUsed
+		}
+	}
+	for _, stats := range byMethod {
+		stats.AvgGasUsed = stats.TotalGasUsed / uint64(stats.Transactions)
+		report.GasByMethod = append(report.GasByMethod, *stats)
+	}
+	sort.Slice(report.GasByMethod, func(i, j int) bool {
+		return report.GasByMethod[i].Method < report.GasByMethod[j].Method
+	})
+
+	return report
+}
+
+// ExportSession saves the whole client session as a single gzipped tar archive, which can be attached to bug reports and CI
+// artifacts. It contains effective config with secrets redacted (config.toml), contract map (contract_map.json), decoded
+// transactions (transactions.json), decoded calls (decoded_calls.json) and raw traces (traces/<hash>.json) kept by the tracer,
+// and session report with fees and gas used by each method (report.json). Parent directories are created if needed.
+func (m *Client) ExportSession(path string) error {
+	redacted, err := redactedConfig(m.Cfg)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to redact config", ErrExportSession)
+	}
+	cfg, err := toml.Marshal(redacted)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to marshal config", ErrExportSession)
+	}
+
+	files := []sessionFile{{name: SessionFile_Config, data: cfg}}
+	contents := map[string]interface{}{
+		SessionFile_ContractMap:  m.ContractAddressToNameMap.snapshot(),
+		SessionFile_Transactions: m.SessionTransactions(),
+		SessionFile_Report:       m.SessionReport(),
+	}
+	if m.Tracer != nil {
+		contents[SessionFile_DecodedCalls] = m.Tracer.decodedCallsSnapshot()
+		for txHash, trace := range m.Tracer.tracesSnapshot() {
+			contents[SessionFile_TracesDirectory+"/"+txHash+".json"] = trace
+		}
+	}
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := json.MarshalIndent(contents[name], "", "   ")
+		if err != nil {
+			return errors.Wrapf(err, "%s: failed to marshal %s", ErrExportSession, name)
+		}
+		files = append(files, sessionFile{name: name, data: data})
+	}
+
+	if err := writeSessionArchive(path, files); err != nil {
+		return errors.Wrap(err, ErrExportSession)
+	}
+	L.Info().
+		Str("Path", path).
+		Int("Files", len(files)).
+		Msg("Exported session")
+
+	return nil
+}
+
+type sessionFile struct {
+	name string
+	data []byte
+}
+
+func writeSessionArchive(path string, files []sessionFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, file := range files {
+		header := &tar.Header{Name: file.name, Mode: 0600, Size: int64(len(file.data)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// redactedConfig returns a copy of config without secrets: private keys are replaced with RedactedSecret, RPC URLs keep
+// only scheme and host (API keys are often part of the path or query) and RPC headers are dropped. Config is copied through
+// TOML, because it holds internal state (e.g. RPC endpoints pool) that must not be copied.
+func redactedConfig(cfg *Config) (*Config, error) {
+	redactNetwork := func(n *Network) *Network {
+		if n == nil {
+			return nil
+		}
+		redacted := *n
+		redacted.URLs = make([]string, 0, len(n.URLs))
+		for _, u := range n.URLs {
+			redacted.URLs = append(redacted.URLs, redactURL(u))
+		}
+		redacted.PrivateKeys = make([]string, 0, len(n.PrivateKeys))
+		for range n.PrivateKeys {
+			redacted.PrivateKeys = append(redacted.PrivateKeys, RedactedSecret)
+		}
+		if n.ExplorerAPIKey != "" {
+			redacted.ExplorerAPIKey = RedactedSecret
+		}
+		return &redacted
+	}
+
+	marshalled, err := toml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	redacted := &Config{}
+	if err := toml.Unmarshal(marshalled, redacted); err != nil {
+		return nil, err
+	}
+	redacted.RPCHeaders = nil
+	redacted.Network = redactNetwork(cfg.Network)
+	redacted.Networks = make([]*Network, 0, len(cfg.Networks))
+	for _, n := range cfg.Networks {
+		redacted.Networks = append(redacted.Networks, redactNetwork(n))
+	}
+
+	return redacted, nil
+}
+
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return RedactedSecret
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return u.Scheme + "://" + u.Host
+	}
+
+	return u.Scheme + "://" + u.Host + "/" + RedactedSecret
+}
+
+// snapshot returns copy of address to contract name mapping, it's nil-safe
+func (c ContractMap) snapshot() map[string]string {
+	out := map[string]string{}
+	if c.mu == nil {
+		return out
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for addr, name := range c.addressMap {
+		out[addr] = name
+	}
+
+	return out
+}
+
+// decodedCallsSnapshot returns copy of decoded calls of all traced transactions
+func (t *Tracer) decodedCallsSnapshot() map[string][]*DecodedCall {
+	t.decodedMutex.Lock()
+	defer t.decodedMutex.Unlock()
+	out := make(map[string][]*DecodedCall, len(t.decodedCalls))
+	for txHash, calls := range t.decodedCalls {
+		out[txHash] = calls
+	}
+
+	return out
+}
+
+// tracesSnapshot returns copy of raw traces kept by the tracer
+func (t *Tracer) tracesSnapshot() map[string]*Trace {
+	t.tracesMutex.Lock()
+	defer t.tracesMutex.Unlock()
+	out := make(map[string]*Trace, len(t.traces))
+	for txHash, trace := range t.traces {
+		out[txHash] = trace
+	}
+
+	return out
+}
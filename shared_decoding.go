@@ -0,0 +1,62 @@
+package seth
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// decodedLogs are logs already decoded from transaction receipt, keyed by address, topics and data of the log. Tracer
+// reuses them for the main call, which is decoded with the same ABI, instead of decoding the same logs again.
+type decodedLogs map[string]DecodedCommonLog
+
+// logKey returns key identifying a log by its content, it's the same for a receipt log and the same log in a call trace
+func logKey(address string, topics []string, data string) string {
+	return strings.ToLower(address + "|" + strings.Join(topics, ",") + "|" + data)
+}
+
+// receiptDecodedLogs returns logs decoded from receipt of decoded transaction, it returns nil if there are none
+func receiptDecodedLogs(decoded *DecodedTransaction) decodedLogs {
+	if decoded == nil || decoded.Receipt == nil || len(decoded.Events) == 0 {
+		return nil
+	}
+
+	events := make(map[uint]DecodedTransactionLog, len(decoded.Events))
+	for _, event := range decoded.Events {
+		events[event.Index] = event
+	}
+
+	shared := make(decodedLogs, len(decoded.Events))
+	for _, lo := range decoded.Receipt.Logs {
+		event, ok := events[lo.Index]
+		if !ok {
+			continue
+		}
+		topics := make([]string, 0, len(lo.Topics))
+		for _, topic := range lo.Topics {
+			topics = append(topics, topic.Hex())
+		}
+		shared[logKey(lo.Address.Hex(), topics, hexutil.Encode(lo.Data))] = event.DecodedCommonLog
+	}
+
+	return shared
+}
+
+// get returns a copy of already decoded log matching given trace log, so that changes made to it don't affect decoded transaction
+func (d decodedLogs) get(lo TraceLog, abiName string) (*DecodedCommonLog, bool) {
+	event, ok := d[logKey(lo.Address, lo.Topics, lo.Data)]
+	if !ok {
+		return nil, false
+	}
+
+	eventData := make(map[string]interface{}, len(event.EventData))
+	for k, v := range event.EventData {
+		eventData[k] = v
+	}
+	event.EventData = eventData
+	event.ABIName = abiName
+	// trace log is used as-is, like for logs decoded from the trace
+	event.Topics = lo.Topics
+
+	return &event, true
+}
@@ -0,0 +1,119 @@
+package seth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	ErrNoStateAccess = "no state access counts for transaction, is storage tracing enabled?"
+)
+
+// StateAccessCounts are numbers of cold and warm state accesses (EIP-2929). First access to an account or a storage slot
+// in a transaction is cold and expensive, all following ones are warm and cheap. Sender, recipient and precompiles are
+// warm from the start. Access list of the transaction and block's coinbase aren't known to Seth, so accesses to them are
+// counted as cold.
+type StateAccessCounts struct {
+	// ColdSlots and WarmSlots count SLOAD and SSTORE operations
+	ColdSlots int `json:"cold_slots"`
+	WarmSlots int `json:"warm_slots"`
+	// ColdAccounts and WarmAccounts count BALANCE, EXTCODESIZE, EXTCODECOPY, EXTCODEHASH, SELFDESTRUCT and call operations
+	ColdAccounts int `json:"cold_accounts"`
+	WarmAccounts int `json:"warm_accounts"`
+}
+
+// AccessGas returns gas spent on accessing state according to EIP-2929. It doesn't include the cost of changing storage
+// value by SSTORE, only the cost of accessing the slot.
+func (a StateAccessCounts) AccessGas() uint64 {
+	return uint64(a.ColdSlots)*params.ColdSloadCostEIP2929 +
+		uint64(a.ColdAccounts)*params.ColdAccountAccessCostEIP2929 +
+		uint64(a.WarmSlots+a.WarmAccounts)*params.WarmStorageReadCostEIP2929
+}
+
+// Add returns sum of both counts
+func (a StateAccessCounts) Add(other StateAccessCounts) StateAccessCounts {
+	return StateAccessCounts{
+		ColdSlots:    a.ColdSlots + other.ColdSlots,
+		WarmSlots:    a.WarmSlots + other.WarmSlots,
+		ColdAccounts: a.ColdAccounts + other.ColdAccounts,
+		WarmAccounts: a.WarmAccounts + other.WarmAccounts,
+	}
+}
+
+func (a StateAccessCounts) String() string {
+	return fmt.Sprintf("slots: %d cold, %d warm; accounts: %d cold, %d warm; access gas: %d",
+		a.ColdSlots, a.WarmSlots, a.ColdAccounts, a.WarmAccounts, a.AccessGas())
+}
+
+func (a *StateAccessCounts) addSlot(cold bool) {
+	if cold {
+		a.ColdSlots++
+	} else {
+		a.WarmSlots++
+	}
+}
+
+func (a *StateAccessCounts) addAccount(cold bool) {
+	if cold {
+		a.ColdAccounts++
+	} else {
+		a.WarmAccounts++
+	}
+}
+
+// StateAccessCounts returns cold and warm state accesses of the whole transaction (all its calls). It requires storage
+// tracing to be enabled and transaction to be traced.
+func (t *Tracer) StateAccessCounts(txHash string) (StateAccessCounts, error) {
+	var total StateAccessCounts
+	found := false
+	for _, call := range t.GetDecodedCalls(txHash) {
+		if call.StateAccess == nil {
+			continue
+		}
+		found = true
+		total = total.Add(*call.StateAccess)
+	}
+	if !found {
+		return total, fmt.Errorf("%s: %s", ErrNoStateAccess, txHash)
+	}
+
+	return total, nil
+}
+
+// warmSet tracks accounts and storage slots that are warm in a transaction
+type warmSet map[string]bool
+
+// newWarmSet returns accounts that are warm at the start of a transaction: sender, recipient and precompiles
+func newWarmSet(mainCall Call) warmSet {
+	w := warmSet{
+		strings.ToLower(common.HexToAddress(mainCall.From).Hex()): true,
+		strings.ToLower(common.HexToAddress(mainCall.To).Hex()):   true,
+	}
+	for i := byte(1); i <= 0x0a; i++ {
+		w[strings.ToLower(common.BytesToAddress([]byte{i}).Hex())] = true
+	}
+
+	return w
+}
+
+// access marks key as warm and returns true if it was cold, the key is recorded in the frame, so that it can be reverted
+func (w warmSet) access(frame *storageFrame, key string) bool {
+	key = strings.ToLower(key)
+	if w[key] {
+		return false
+	}
+	w[key] = true
+	frame.warmed = append(frame.warmed, key)
+
+	return true
+}
+
+// revert makes keys warmed by a reverted call cold again
+func (w warmSet) revert(keys []string) {
+	for _, key := range keys {
+		delete(w, key)
+	}
+}
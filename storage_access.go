@@ -0,0 +1,371 @@
+package seth
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StorageLayoutFileSuffix is the suffix of storage layout files generated by solc with --storage-layout flag
+	StorageLayoutFileSuffix = "_storage.json"
+
+	ErrParseStorageLayout = "failed to parse storage layout file"
+)
+
+// StorageAccess is a storage slot read or written by a call. Address is the contract whose storage was accessed, which for
+// delegate calls is the caller, not the called contract.
+type StorageAccess struct {
+	Address string `json:"address"`
+	Slot    string `json:"slot"`
+	Value   string `json:"value"`
+	// Label is the name of the variable (with mapping key, if it could be found) stored in the slot, it's only set if storage
+	// layout of the contract is known
+	Label string `json:"label,omitempty"`
+}
+
+// StorageLayout is the storage layout of a contract as generated by solc (--storage-layout flag or "storageLayout" output)
+type StorageLayout struct {
+	Storage []StorageLayoutEntry         `json:"storage"`
+	Types   map[string]StorageLayoutType `json:"types"`
+}
+
+// StorageLayoutEntry is a single state variable
+type StorageLayoutEntry struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+// StorageLayoutType describes type of a state variable. Key and Value are only set for mappings.
+type StorageLayoutType struct {
+	Encoding      string `json:"encoding"`
+	Label         string `json:"label"`
+	NumberOfBytes string `json:"numberOfBytes"`
+	Key           string `json:"key,omitempty"`
+	Value         string `json:"value,omitempty"`
+}
+
+// LoadStorageLayout reads storage layout generated by solc from file
+func LoadStorageLayout(path string) (*StorageLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var layout StorageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, errors.Wrap(err, ErrParseStorageLayout)
+	}
+	return &layout, nil
+}
+
+// callStorage contains storage accesses of a single call
+type callStorage struct {
+	reads  []StorageAccess
+	writes []StorageAccess
+	access StateAccessCounts
+}
+
+// storageFrame is a call that is currently executing
+type storageFrame struct {
+	callIndex      int
+	storageAddress string
+	// warmed are accounts and slots that became warm during the call, they become cold again if the call reverts
+	warmed []string
+}
+
+// flattenCalls returns all calls in the order in which they were made (main call first)
+func flattenCalls(trace *TXCallTraceOutput) []Call {
+	calls := []Call{trace.AsCall()}
+	var flatten func([]Call)
+	flatten = func(subCalls []Call) {
+		for _, call := range subCalls {
+			calls = append(calls, call)
+			flatten(call.Calls)
+		}
+	}
+	flatten(trace.Calls)
+	return calls
+}
+
+// storageAccessesByCall reads SLOAD and SSTORE operations from opcodes trace and assigns them to calls from the call trace.
+// Results are indexed in the same order as calls returned by flattenCalls(). Each call opcode (including calls to precompiles
+// and accounts without code) corresponds to one call in the call trace and if depth increases after it, following opcodes
+// belong to that call.
+func storageAccessesByCall(trace Trace) ([]callStorage, error) {
+	if trace.CallTrace == nil || trace.OpCodesTrace == nil {
+		return nil, errors.New("both call and opcodes traces are required to trace storage access")
+	}
+	rawLogs, ok := trace.OpCodesTrace["structLogs"].([]interface{})
+	if !ok {
+		return nil, errors.New("opcodes trace has no struct logs")
+	}
+
+	calls := flattenCalls(trace.CallTrace)
+	storage := make([]callStorage, len(calls))
+	frames := []*storageFrame{{callIndex: 0, storageAddress: strings.ToLower(calls[0].To)}}
+	nextCall := 1
+	pendingCallDepth := 0
+	warm := newWarmSet(calls[0])
+
+	for _, rawLog := range rawLogs {
+		step, ok := rawLog.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		depthFloat, _ := step["depth"].(float64)
+		depth := int(depthFloat)
+
+		for len(frames) > 1 && len(frames) > depth {
+			finished := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			if calls[finished.callIndex].Error != "" {
+				warm.revert(finished.warmed)
+			} else {
+				frames[len(frames)-1].warmed = append(frames[len(frames)-1].warmed, finished.warmed...)
+			}
+		}
+
+		if pendingCallDepth > 0 {
+			if depth == pendingCallDepth+1 && nextCall < len(calls) {
+				storageAddress := strings.ToLower(calls[nextCall].To)
+				switch strings.ToUpper(calls[nextCall].Type) {
+				case "DELEGATECALL", "CALLCODE":
+					storageAddress = frames[len(frames)-1].storageAddress
+				case "CREATE", "CREATE2":
+					// created contract is warm from the start
+					warm.access(frames[len(frames)-1], storageAddress)
+				}
+				frames = append(frames, &storageFrame{callIndex: nextCall, storageAddress: storageAddress})
+			}
+			nextCall++
+			pendingCallDepth = 0
+		}
+
+		frame := frames[len(frames)-1]
+		op, _ := step["op"].(string)
+		stack, _ := step["stack"].([]interface{})
+		access := &storage[frame.callIndex].access
+
+		switch op {
+		case "SLOAD":
+			if len(stack) < 1 {
+				continue
+			}
+			slot := stackWord(stack[len(stack)-1])
+			access.addSlot(warm.access(frame, frame.storageAddress+slot.Hex()))
+			value := common.Hash{}
+			if stepStorage, ok := step["storage"].(map[string]interface{}); ok {
+				if v, ok := stepStorage[strings.TrimPrefix(slot.Hex(), "0x")].(string); ok {
+					value = common.HexToHash(v)
+				}
+			}
+			storage[frame.callIndex].reads = appendStorageAccess(storage[frame.callIndex].reads, frame.storageAddress, slot, value)
+		case "SSTORE":
+			if len(stack) < 2 {
+				continue
+			}
+			slot := stackWord(stack[len(stack)-1])
+			value := stackWord(stack[len(stack)-2])
+			storage[frame.callIndex].writes = appendStorageAccess(storage[frame.callIndex].writes, frame.storageAddress, slot, value)
+			access.addSlot(warm.access(frame, frame.storageAddress+slot.Hex()))
+		case "BALANCE", "EXTCODESIZE", "EXTCODECOPY", "EXTCODEHASH", "SELFDESTRUCT":
+			if len(stack) >= 1 {
+				access.addAccount(warm.access(frame, stackAddress(stack[len(stack)-1])))
+			}
+		case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL", "CREATE", "CREATE2":
+			pendingCallDepth = depth
+			// address is the second item of the stack for all call opcodes, gas being the first one
+			if !strings.HasPrefix(op, "CREATE") && len(stack) >= 2 {
+				access.addAccount(warm.access(frame, stackAddress(stack[len(stack)-2])))
+			}
+		}
+	}
+
+	return storage, nil
+}
+
+// appendStorageAccess adds storage access, if given slot was already accessed its value is updated instead, so that for
+// reads the first value is kept and for writes the last one
+func appendStorageAccess(accesses []StorageAccess, address string, slot, value common.Hash) []StorageAccess {
+	for i := range accesses {
+		if accesses[i].Address == address && accesses[i].Slot == slot.Hex() {
+			accesses[i].Value = value.Hex()
+			return accesses
+		}
+	}
+	return append(accesses, StorageAccess{Address: address, Slot: slot.Hex(), Value: value.Hex()})
+}
+
+// stackAddress parses stack item holding an address
+func stackAddress(item interface{}) string {
+	return strings.ToLower(common.BytesToAddress(stackWord(item).Bytes()).Hex())
+}
+
+// stackWord parses stack item, which depending on node version is either "0x"-prefixed compact hex or 64 characters long hex
+func stackWord(item interface{}) common.Hash {
+	s, _ := item.(string)
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return common.BytesToHash(common.Hex2Bytes(s))
+}
+
+// attachStorageAccesses adds storage reads and writes to decoded calls, decodedByIndex maps index from flattenCalls() to decoded call
+func (t *Tracer) attachStorageAccesses(trace Trace, decodedByIndex map[int]*DecodedCall) error {
+	storage, err := storageAccessesByCall(trace)
+	if err != nil {
+		return err
+	}
+
+	candidates := t.mappingKeyCandidates(trace, decodedByIndex)
+	for i, callStorage := range storage {
+		decoded, ok := decodedByIndex[i]
+		if !ok {
+			continue
+		}
+		decoded.StorageReads = t.labelStorageAccesses(callStorage.reads, candidates)
+		decoded.StorageWrites = t.labelStorageAccesses(callStorage.writes, candidates)
+		access := callStorage.access
+		decoded.StateAccess = &access
+	}
+
+	return nil
+}
+
+// labelStorageAccesses sets labels of accessed slots for contracts with known storage layout
+func (t *Tracer) labelStorageAccesses(accesses []StorageAccess, candidates []common.Hash) []StorageAccess {
+	if t.ContractStore == nil {
+		return accesses
+	}
+	for i := range accesses {
+		name := t.ContractAddressToNameMap.GetContractName(accesses[i].Address)
+		if name == "" {
+			continue
+		}
+		layout, ok := t.ContractStore.GetStorageLayout(name)
+		if !ok {
+			continue
+		}
+		accesses[i].Label = layout.slotLabel(common.HexToHash(accesses[i].Slot), candidates)
+	}
+	return accesses
+}
+
+// slotLabel returns name of the variable stored in the slot. For mappings key is looked up among candidates, since only
+// the hash of the key is stored on chain.
+func (l *StorageLayout) slotLabel(slot common.Hash, candidates []common.Hash) string {
+	slotInt := slot.Big()
+	labels := make([]string, 0)
+	for _, entry := range l.Storage {
+		base, ok := new(big.Int).SetString(entry.Slot, 10)
+		if !ok {
+			continue
+		}
+		if base.Cmp(slotInt) == 0 {
+			labels = append(labels, entry.Label)
+			continue
+		}
+		if l.Types[entry.Type].Encoding != "mapping" {
+			continue
+		}
+		baseWord := common.BigToHash(base)
+		for _, key := range candidates {
+			if crypto.Keccak256Hash(key.Bytes(), baseWord.Bytes()) == slot {
+				labels = append(labels, fmt.Sprintf("%s[%s]", entry.Label, formatMappingKey(key, l.Types[l.Types[entry.Type].Key].Label)))
+				break
+			}
+		}
+	}
+	return strings.Join(labels, ", ")
+}
+
+func formatMappingKey(key common.Hash, keyType string) string {
+	switch {
+	case keyType == "address" || strings.HasPrefix(keyType, "contract "):
+		return common.BytesToAddress(key.Bytes()).Hex()
+	case strings.HasPrefix(keyType, "uint"), strings.HasPrefix(keyType, "int"), keyType == "bool":
+		return key.Big().String()
+	default:
+		return key.Hex()
+	}
+}
+
+// mappingKeyCandidates returns values that might be used as mapping keys: client's addresses, addresses of known contracts,
+// addresses taking part in calls and decoded call inputs
+func (t *Tracer) mappingKeyCandidates(trace Trace, decodedByIndex map[int]*DecodedCall) []common.Hash {
+	seen := map[common.Hash]bool{}
+	candidates := make([]common.Hash, 0)
+	add := func(h common.Hash) {
+		if !seen[h] {
+			seen[h] = true
+			candidates = append(candidates, h)
+		}
+	}
+
+	t.addressesMutex.RLock()
+	for _, addr := range t.Addresses {
+		add(common.BytesToHash(addr.Bytes()))
+	}
+	t.addressesMutex.RUnlock()
+	for addr := range t.ContractAddressToNameMap.GetContractMap() {
+		add(common.BytesToHash(common.HexToAddress(addr).Bytes()))
+	}
+	for _, call := range flattenCalls(trace.CallTrace) {
+		add(common.BytesToHash(common.HexToAddress(call.From).Bytes()))
+		add(common.BytesToHash(common.HexToAddress(call.To).Bytes()))
+	}
+
+	for _, decoded := range decodedByIndex {
+		for _, input := range decoded.Input {
+			switch v := input.(type) {
+			case common.Address:
+				add(common.BytesToHash(v.Bytes()))
+			case *big.Int:
+				if v.Sign() >= 0 {
+					add(common.BigToHash(v))
+				}
+			case [32]byte:
+				add(common.Hash(v))
+			case uint8, uint16, uint32, uint64, int8, int16, int32, int64, bool:
+				add(common.BigToHash(smallIntToBig(v)))
+			}
+		}
+	}
+
+	return candidates
+}
+
+func smallIntToBig(v interface{}) *big.Int {
+	switch n := v.(type) {
+	case uint8:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint16:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint32:
+		return new(big.Int).SetUint64(uint64(n))
+	case uint64:
+		return new(big.Int).SetUint64(n)
+	case int8:
+		return big.NewInt(int64(n))
+	case int16:
+		return big.NewInt(int64(n))
+	case int32:
+		return big.NewInt(int64(n))
+	case int64:
+		return big.NewInt(n)
+	case bool:
+		if n {
+			return big.NewInt(1)
+		}
+	}
+	return big.NewInt(0)
+}
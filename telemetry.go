@@ -0,0 +1,357 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ErrCreateTelemetryExporter = "failed to create OpenTelemetry exporter"
+
+	DefaultTelemetryServiceName = "seth"
+
+	OTEL_SDK_DISABLED_ENV_VAR                  = "OTEL_SDK_DISABLED"
+	OTEL_EXPORTER_OTLP_ENDPOINT_ENV_VAR        = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT_ENV_VAR = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+
+	telemetryInstrumentationName = "github.com/smartcontractkit/seth"
+	telemetryShutdownTimeout     = 10 * time.Second
+
+	chainIDAttributeKey     = attribute.Key("seth.chain_id")
+	networkNameAttributeKey = attribute.Key("seth.network")
+	batchSizeAttributeKey   = attribute.Key("seth.rpc.batch_size")
+)
+
+// TelemetryConfig controls OpenTelemetry instrumentation of RPC calls. Each outgoing JSON-RPC call (both over HTTP and WS) is
+// recorded as a client span with method, chain, duration and error.
+type TelemetryConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Endpoint of OTLP/HTTP collector (e.g. "localhost:4318"). If empty and no OTEL_EXPORTER_OTLP_* env var is set,
+	// global tracer provider is used, so that spans end up in the same trace as the code that uses Seth.
+	Endpoint    string `toml:"endpoint"`
+	Insecure    bool   `toml:"insecure"`
+	ServiceName string `toml:"service_name"`
+}
+
+// IsTelemetryEnabled returns true if RPC calls should be instrumented with OpenTelemetry. Instrumentation is enabled either in TOML
+// or by setting one of standard OTEL_EXPORTER_OTLP_* endpoint env vars. OTEL_SDK_DISABLED=true always disables it.
+func (c *Config) IsTelemetryEnabled() bool {
+	if strings.EqualFold(os.Getenv(OTEL_SDK_DISABLED_ENV_VAR), "true") {
+		return false
+	}
+	if c.Telemetry != nil && c.Telemetry.Enabled {
+		return true
+	}
+
+	return hasOtlpEndpointEnvVar()
+}
+
+func hasOtlpEndpointEnvVar() bool {
+	return os.Getenv(OTEL_EXPORTER_OTLP_ENDPOINT_ENV_VAR) != "" || os.Getenv(OTEL_EXPORTER_OTLP_TRACES_ENDPOINT_ENV_VAR) != ""
+}
+
+// rpcTelemetry creates spans for JSON-RPC calls. It's shared by HTTP transport and WS connections of a single client.
+type rpcTelemetry struct {
+	tracer      trace.Tracer
+	provider    *sdktrace.TracerProvider
+	networkName string
+	serverAddr  string
+	chainID     atomic.Value
+}
+
+// newRPCTelemetry returns nil if telemetry is disabled. If OTLP endpoint is configured (in TOML or with env vars) Seth creates its own
+// tracer provider with OTLP/HTTP exporter (which respects all standard OTEL_* env vars), otherwise global tracer provider is used.
+func newRPCTelemetry(cfg *Config) (*rpcTelemetry, error) {
+	if !cfg.IsTelemetryEnabled() {
+		return nil, nil
+	}
+
+	t := &rpcTelemetry{
+		networkName: cfg.Network.Name,
+	}
+	if u, err := url.Parse(cfg.FirstNetworkURL()); err == nil {
+		t.serverAddr = u.Host
+	}
+
+	endpoint := ""
+	if cfg.Telemetry != nil {
+		endpoint = cfg.Telemetry.Endpoint
+	}
+
+	if endpoint == "" && !hasOtlpEndpointEnvVar() {
+		t.tracer = otel.GetTracerProvider().Tracer(telemetryInstrumentationName)
+		L.Debug().Msg("OpenTelemetry instrumentation enabled, using global tracer provider")
+		return t, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if cfg.Telemetry != nil && cfg.Telemetry.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrCreateTelemetryExporter)
+	}
+
+	serviceName := DefaultTelemetryServiceName
+	if cfg.Telemetry != nil && cfg.Telemetry.ServiceName != "" {
+		serviceName = cfg.Telemetry.ServiceName
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence over service name from TOML
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName(serviceName)),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrCreateTelemetryExporter)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	t.tracer = t.provider.Tracer(telemetryInstrumentationName)
+	L.Debug().Str("Endpoint", endpoint).Msg("OpenTelemetry instrumentation enabled, using OTLP exporter")
+
+	return t, nil
+}
+
+func (t *rpcTelemetry) setChainID(chainID string) {
+	t.chainID.Store(chainID)
+}
+
+// shutdown flushes all pending spans, it's a no-op if global tracer provider is used.
+func (t *rpcTelemetry) shutdown(ctx context.Context) error {
+	if t == nil || t.provider == nil {
+		return nil
+	}
+
+	return t.provider.Shutdown(ctx)
+}
+
+// jsonrpcMessage contains only the fields of JSON-RPC request or response that are needed to create and finish a span
+type jsonrpcMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// parseJSONRPCMessages parses single JSON-RPC message or a batch of them
+func parseJSONRPCMessages(data []byte) ([]jsonrpcMessage, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, false
+	}
+
+	if data[0] == '[' {
+		var msgs []jsonrpcMessage
+		if err := json.Unmarshal(data, &msgs); err != nil {
+			return nil, false
+		}
+		return msgs, true
+	}
+
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, false
+	}
+
+	return []jsonrpcMessage{msg}, false
+}
+
+// startSpans starts a client span for each request and returns them keyed by request ID. Notifications (requests without ID)
+// are ignored, because they don't get a response.
+func (t *rpcTelemetry) startSpans(ctx context.Context, transport string, msgs []jsonrpcMessage, batch bool) map[string]trace.Span {
+	spans := make(map[string]trace.Span, len(msgs))
+	for _, msg := range msgs {
+		if msg.Method == "" || len(msg.ID) == 0 {
+			continue
+		}
+
+		attrs := []attribute.KeyValue{
+			semconv.RPCSystemKey.String("jsonrpc"),
+			semconv.RPCMethod(msg.Method),
+			semconv.RPCJsonrpcRequestID(string(msg.ID)),
+			semconv.NetworkTransportTCP,
+			semconv.NetworkProtocolName(transport),
+			networkNameAttributeKey.String(t.networkName),
+		}
+		if t.serverAddr != "" {
+			attrs = append(attrs, semconv.ServerAddress(t.serverAddr))
+		}
+		if chainID, ok := t.chainID.Load().(string); ok && chainID != "" {
+			attrs = append(attrs, chainIDAttributeKey.String(chainID))
+		}
+		if batch {
+			attrs = append(attrs, batchSizeAttributeKey.Int(len(msgs)))
+		}
+
+		_, span := t.tracer.Start(ctx, msg.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+		spans[string(msg.ID)] = span
+	}
+
+	return spans
+}
+
+// endSpan ends the span and marks it as failed if response contains JSON-RPC error
+func endSpan(span trace.Span, response *jsonrpcMessage) {
+	if response != nil && response.Error != nil {
+		span.SetAttributes(
+			semconv.RPCJsonrpcErrorCode(response.Error.Code),
+			semconv.RPCJsonrpcErrorMessage(response.Error.Message),
+		)
+		span.SetStatus(codes.Error, response.Error.Message)
+	}
+	span.End()
+}
+
+// failSpans ends all spans with given error
+func failSpans(spans map[string]trace.Span, err error) {
+	for _, span := range spans {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	}
+}
+
+// TelemetryTransport is a RoundTripper that records each JSON-RPC call sent over HTTP as OpenTelemetry client span.
+// Trace context is propagated to the RPC node in request headers.
+type TelemetryTransport struct {
+	Transport http.RoundTripper
+	telemetry *rpcTelemetry
+}
+
+// RoundTrip implements the RoundTripper interface
+func (t *TelemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	if req.Body == nil {
+		return transport.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	msgs, batch := parseJSONRPCMessages(body)
+	spans := t.telemetry.startSpans(req.Context(), "http", msgs, batch)
+	if len(spans) == 0 {
+		return transport.RoundTrip(req)
+	}
+
+	// with a single span we can make it the parent of the RPC node's spans, for batches we just pass the caller's context
+	propagationCtx := req.Context()
+	if len(spans) == 1 {
+		for _, span := range spans {
+			propagationCtx = trace.ContextWithSpan(propagationCtx, span)
+		}
+	}
+	req = req.Clone(propagationCtx)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	otel.GetTextMapPropagator().Inject(propagationCtx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		failSpans(spans, err)
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		failSpans(spans, fmt.Errorf("unexpected HTTP status: %s", resp.Status))
+		return resp, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		failSpans(spans, err)
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	responses, _ := parseJSONRPCMessages(respBody)
+	for i := range responses {
+		if span, ok := spans[string(responses[i].ID)]; ok {
+			endSpan(span, &responses[i])
+			delete(spans, string(responses[i].ID))
+		}
+	}
+	failSpans(spans, errors.New("no response received"))
+
+	return resp, nil
+}
+
+// pendingSpans keeps track of spans for requests sent over WS that are waiting for response
+type pendingSpans struct {
+	mu    sync.Mutex
+	spans map[string]trace.Span
+}
+
+func (p *pendingSpans) add(spans map[string]trace.Span) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, span := range spans {
+		p.spans[id] = span
+	}
+}
+
+func (p *pendingSpans) end(response *jsonrpcMessage) {
+	p.mu.Lock()
+	span, ok := p.spans[string(response.ID)]
+	delete(p.spans, string(response.ID))
+	p.mu.Unlock()
+
+	if ok {
+		endSpan(span, response)
+	}
+}
+
+func (p *pendingSpans) failAll(err error) {
+	p.mu.Lock()
+	spans := p.spans
+	p.spans = make(map[string]trace.Span)
+	p.mu.Unlock()
+
+	failSpans(spans, err)
+}
+
+// ShutdownTelemetry flushes all RPC spans that were not exported yet. It should be called before the process exits if
+// OpenTelemetry instrumentation is enabled and Seth uses its own exporter.
+func (m *Client) ShutdownTelemetry() error {
+	ctx, cancel := context.WithTimeout(context.Background(), telemetryShutdownTimeout)
+	defer cancel()
+
+	return m.telemetry.shutdown(ctx)
+}
@@ -0,0 +1,215 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// same as defaults used by go-ethereum's RPC client
+	wsTelemetryReadBuffer  = 1024
+	wsTelemetryWriteBuffer = 1024
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+)
+
+// websocketDialer returns a dialer, which records JSON-RPC calls sent over the connection as OpenTelemetry spans. go-ethereum doesn't
+// expose any hooks for WS messages, so we look at the frames passing through the underlying connection. For "wss" TLS is
+// terminated inside the dialer (so that we see plain frames), which means that HTTP proxy env vars are respected only for "ws".
+func (t *rpcTelemetry) websocketDialer(rpcURL string) websocket.Dialer {
+	netDialer := &net.Dialer{}
+	dialer := websocket.Dialer{
+		ReadBufferSize:  wsTelemetryReadBuffer,
+		WriteBufferSize: wsTelemetryWriteBuffer,
+		NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := netDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return t.wrapConn(conn), nil
+		},
+		NetDialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			tlsDialer := &tls.Dialer{NetDialer: netDialer, Config: &tls.Config{}}
+			conn, err := tlsDialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			return t.wrapConn(conn), nil
+		},
+	}
+	if !strings.HasPrefix(strings.ToLower(rpcURL), "wss") {
+		dialer.Proxy = http.ProxyFromEnvironment
+	}
+
+	return dialer
+}
+
+func (t *rpcTelemetry) wrapConn(conn net.Conn) net.Conn {
+	c := &telemetryConn{
+		Conn:      conn,
+		telemetry: t,
+		pending:   &pendingSpans{spans: make(map[string]trace.Span)},
+	}
+	c.out.onMessage = c.onRequest
+	c.in.onMessage = c.onResponse
+
+	return c
+}
+
+// telemetryConn starts a span for each JSON-RPC request written to the connection and ends it when response with the same ID is read
+type telemetryConn struct {
+	net.Conn
+	telemetry *rpcTelemetry
+	pending   *pendingSpans
+	outMu     sync.Mutex
+	out       wsFrameParser
+	in        wsFrameParser
+}
+
+// Write parses outgoing frames before writing them, so that the span is always registered before the response can be read
+func (c *telemetryConn) Write(p []byte) (int, error) {
+	c.outMu.Lock()
+	c.out.feed(p)
+	c.outMu.Unlock()
+
+	return c.Conn.Write(p)
+}
+
+func (c *telemetryConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.in.feed(p[:n])
+	}
+
+	return n, err
+}
+
+func (c *telemetryConn) Close() error {
+	c.pending.failAll(errors.New("websocket connection closed"))
+
+	return c.Conn.Close()
+}
+
+func (c *telemetryConn) onRequest(data []byte) {
+	msgs, batch := parseJSONRPCMessages(data)
+	c.pending.add(c.telemetry.startSpans(context.Background(), "websocket", msgs, batch))
+}
+
+func (c *telemetryConn) onResponse(data []byte) {
+	msgs, _ := parseJSONRPCMessages(data)
+	for i := range msgs {
+		// subscription notifications have no ID
+		if len(msgs[i].ID) == 0 {
+			continue
+		}
+		c.pending.end(&msgs[i])
+	}
+}
+
+// wsFrameParser reassembles WebSocket data messages from a raw byte stream. HTTP messages that precede the frames (proxy CONNECT
+// and upgrade handshake) are skipped. Compression is not supported, but go-ethereum doesn't enable it.
+type wsFrameParser struct {
+	buf       []byte
+	message   []byte
+	onMessage func([]byte)
+}
+
+func (p *wsFrameParser) feed(data []byte) {
+	p.buf = append(p.buf, data...)
+
+	for {
+		consumed, ok := p.next()
+		if !ok {
+			return
+		}
+		p.buf = append(p.buf[:0], p.buf[consumed:]...)
+	}
+}
+
+// next processes a single HTTP header block or frame from the buffer and returns number of consumed bytes. It returns false if
+// the buffer doesn't contain enough data yet.
+func (p *wsFrameParser) next() (int, bool) {
+	if len(p.buf) < 2 {
+		return 0, false
+	}
+
+	// first byte of a frame is never an ASCII letter, while HTTP request or response always starts with one
+	if isASCIILetter(p.buf[0]) {
+		idx := bytes.Index(p.buf, []byte("\r\n\r\n"))
+		if idx == -1 {
+			return 0, false
+		}
+		return idx + 4, true
+	}
+
+	fin := p.buf[0]&0x80 != 0
+	opcode := p.buf[0] & 0x0f
+	masked := p.buf[1]&0x80 != 0
+	headerLen := 2
+	payloadLen := uint64(p.buf[1] & 0x7f)
+
+	switch payloadLen {
+	case 126:
+		if len(p.buf) < headerLen+2 {
+			return 0, false
+		}
+		payloadLen = uint64(binary.BigEndian.Uint16(p.buf[headerLen:]))
+		headerLen += 2
+	case 127:
+		if len(p.buf) < headerLen+8 {
+			return 0, false
+		}
+		payloadLen = binary.BigEndian.Uint64(p.buf[headerLen:])
+		headerLen += 8
+	}
+
+	var maskKey []byte
+	if masked {
+		if len(p.buf) < headerLen+4 {
+			return 0, false
+		}
+		maskKey = p.buf[headerLen : headerLen+4]
+		headerLen += 4
+	}
+
+	if uint64(len(p.buf)-headerLen) < payloadLen {
+		return 0, false
+	}
+	frameLen := headerLen + int(payloadLen)
+
+	switch opcode {
+	case wsOpText, wsOpBinary:
+		p.message = p.message[:0]
+		fallthrough
+	case wsOpContinuation:
+		start := len(p.message)
+		p.message = append(p.message, p.buf[headerLen:frameLen]...)
+		if masked {
+			for i := start; i < len(p.message); i++ {
+				p.message[i] ^= maskKey[(i-start)%4]
+			}
+		}
+		if fin {
+			p.onMessage(p.message)
+			p.message = nil
+		}
+	}
+
+	return frameLen, true
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z')
+}
@@ -0,0 +1,332 @@
+package seth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrNoDecodedCalls     = "no decoded calls found for transaction"
+	ErrTenderlyConfig     = "tenderly account, project and access key are required"
+	ErrTenderlySimulation = "failed to create tenderly simulation"
+
+	DefaultTenderlyAPIURL       = "https://api.tenderly.co"
+	DefaultTenderlyDashboardURL = "https://dashboard.tenderly.co"
+
+	TENDERLY_ACCOUNT_ENV_VAR    = "TENDERLY_ACCOUNT"
+	TENDERLY_PROJECT_ENV_VAR    = "TENDERLY_PROJECT"
+	TENDERLY_ACCESS_KEY_ENV_VAR = "TENDERLY_ACCESS_KEY"
+)
+
+// TenderlyTrace is a decoded call tree in the structure used by Tenderly's transaction and simulation views
+type TenderlyTrace struct {
+	NetworkID string        `json:"network_id"`
+	Hash      string        `json:"hash"`
+	CallTrace *TenderlyCall `json:"call_trace"`
+}
+
+// TenderlyCall is a single call in TenderlyTrace
+type TenderlyCall struct {
+	CallType         string          `json:"call_type"`
+	From             string          `json:"from"`
+	To               string          `json:"to"`
+	ContractName     string          `json:"contract_name,omitempty"`
+	FunctionName     string          `json:"function_name,omitempty"`
+	FunctionSelector string          `json:"function_selector,omitempty"`
+	Value            string          `json:"value"`
+	Gas              uint64          `json:"gas"`
+	GasUsed          uint64          `json:"gas_used"`
+	Error            string          `json:"error,omitempty"`
+	DecodedInput     []TenderlyArg   `json:"decoded_input"`
+	DecodedOutput    []TenderlyArg   `json:"decoded_output"`
+	Logs             []TenderlyLog   `json:"logs"`
+	Calls            []*TenderlyCall `json:"calls"`
+}
+
+// TenderlyArg is a decoded argument, Type is empty if ABI of the call (or event) isn't known
+type TenderlyArg struct {
+	Soltype TenderlySoltype `json:"soltype"`
+	Value   interface{}     `json:"value"`
+}
+
+type TenderlySoltype struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TenderlyLog is a decoded event emitted by a call
+type TenderlyLog struct {
+	Name    string        `json:"name"`
+	Address string        `json:"address"`
+	Inputs  []TenderlyArg `json:"inputs"`
+}
+
+// ExportTenderlyTrace converts decoded calls of given transaction into Tenderly's call trace structure
+func (t *Tracer) ExportTenderlyTrace(txHash string) (*TenderlyTrace, error) {
+	calls := t.GetDecodedCalls(txHash)
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("%s: %s", ErrNoDecodedCalls, txHash)
+	}
+
+	// decoded calls are a flat list in call order, nesting level tells which call is the parent
+	var root *TenderlyCall
+	stack := make([]*TenderlyCall, 0)
+	for _, call := range calls {
+		tc := t.tenderlyCall(call)
+		for len(stack) > 0 && len(stack) > call.NestingLevel {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			if root != nil {
+				// shouldn't happen, but let's not lose any calls
+				root.Calls = append(root.Calls, tc)
+				continue
+			}
+			root = tc
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Calls = append(parent.Calls, tc)
+		}
+		stack = append(stack, tc)
+	}
+
+	return &TenderlyTrace{
+		NetworkID: t.Cfg.Network.ChainID,
+		Hash:      txHash,
+		CallTrace: root,
+	}, nil
+}
+
+// SaveTenderlyTrace saves Tenderly's call trace of given transaction as JSON file in given directory and returns its path
+func (t *Tracer) SaveTenderlyTrace(txHash, dirname string) (string, error) {
+	trace, err := t.ExportTenderlyTrace(txHash)
+	if err != nil {
+		return "", err
+	}
+
+	return saveAsJson(trace, dirname, "tenderly_"+txHash)
+}
+
+func (t *Tracer) tenderlyCall(call *DecodedCall) *TenderlyCall {
+	tc := &TenderlyCall{
+		CallType:     call.CallType,
+		From:         call.FromAddress,
+		To:           call.ToAddress,
+		FunctionName: call.Method,
+		Value:        fmt.Sprintf("%d", call.Value),
+		Gas:          call.GasLimit,
+		GasUsed:      call.GasUsed,
+		Error:        call.Error,
+		Logs:         make([]TenderlyLog, 0, len(call.Events)),
+		Calls:        make([]*TenderlyCall, 0),
+	}
+	if call.Method == UNKNOWN {
+		tc.FunctionName = ""
+	}
+	if call.Signature != UNKNOWN {
+		tc.FunctionSelector = "0x" + call.Signature
+	}
+
+	var method *abi.Method
+	if name := t.ContractAddressToNameMap.GetContractName(call.ToAddress); name != "" {
+		tc.ContractName = name
+		if t.ContractStore != nil && call.Signature != UNKNOWN {
+			if contractABI, ok := t.ContractStore.GetABI(name); ok {
+				method, _ = contractABI.MethodById(common.FromHex(call.Signature))
+			}
+		}
+	}
+	if method != nil {
+		tc.DecodedInput = tenderlyArgs(call.Input, method.Inputs)
+		tc.DecodedOutput = tenderlyArgs(call.Output, method.Outputs)
+	} else {
+		tc.DecodedInput = tenderlyArgs(call.Input, nil)
+		tc.DecodedOutput = tenderlyArgs(call.Output, nil)
+	}
+
+	for _, event := range call.Events {
+		tc.Logs = append(tc.Logs, TenderlyLog{
+			Name:    nameFromSignature(event.Signature),
+			Address: event.Address.Hex(),
+			Inputs:  tenderlyArgs(event.EventData, t.eventArguments(event)),
+		})
+	}
+
+	return tc
+}
+
+func (t *Tracer) eventArguments(event DecodedCommonLog) abi.Arguments {
+	if event.ABIName == "" || t.ContractStore == nil {
+		return nil
+	}
+	contractABI, ok := t.ContractStore.GetABI(event.ABIName)
+	if !ok {
+		return nil
+	}
+	for _, e := range contractABI.Events {
+		if e.Sig == event.Signature {
+			return e.Inputs
+		}
+	}
+
+	return nil
+}
+
+// tenderlyArgs returns arguments in ABI order, if ABI is known, otherwise sorted by name and without types
+func tenderlyArgs(values map[string]interface{}, arguments abi.Arguments) []TenderlyArg {
+	args := make([]TenderlyArg, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, argument := range arguments {
+		value, ok := values[argument.Name]
+		if !ok {
+			continue
+		}
+		seen[argument.Name] = true
+		args = append(args, TenderlyArg{Soltype: TenderlySoltype{Name: argument.Name, Type: argument.Type.String()}, Value: value})
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, TenderlyArg{Soltype: TenderlySoltype{Name: name}, Value: values[name]})
+	}
+
+	return args
+}
+
+// TenderlyConfig holds credentials used to create shared simulations. APIURL and DashboardURL default to Tenderly's ones.
+type TenderlyConfig struct {
+	Account      string
+	Project      string
+	AccessKey    string
+	APIURL       string
+	DashboardURL string
+}
+
+// TenderlyConfigFromEnv returns Tenderly config read from TENDERLY_ACCOUNT, TENDERLY_PROJECT and TENDERLY_ACCESS_KEY env vars
+func TenderlyConfigFromEnv() TenderlyConfig {
+	return TenderlyConfig{
+		Account:   os.Getenv(TENDERLY_ACCOUNT_ENV_VAR),
+		Project:   os.Getenv(TENDERLY_PROJECT_ENV_VAR),
+		AccessKey: os.Getenv(TENDERLY_ACCESS_KEY_ENV_VAR),
+	}
+}
+
+// ShareTenderlySimulation simulates decoded transaction with Tenderly at the block in which it was mined (or latest block,
+// if it wasn't mined), shares the simulation and returns its public URL, which can be opened without Tenderly account.
+func (m *Client) ShareTenderlySimulation(ctx context.Context, cfg TenderlyConfig, decoded *DecodedTransaction) (string, error) {
+	if cfg.Account == "" || cfg.Project == "" || cfg.AccessKey == "" {
+		return "", errors.New(ErrTenderlyConfig)
+	}
+	if decoded == nil || decoded.Transaction == nil {
+		return "", errors.Wrap(errors.New("decoded transaction has no transaction"), ErrTenderlySimulation)
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = DefaultTenderlyAPIURL
+	}
+	if cfg.DashboardURL == "" {
+		cfg.DashboardURL = DefaultTenderlyDashboardURL
+	}
+
+	tx := decoded.Transaction
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "", errors.Wrap(err, ErrTenderlySimulation)
+	}
+	request := map[string]interface{}{
+		"network_id":      tx.ChainId().String(),
+		"from":            from.Hex(),
+		"input":           "0x" + common.Bytes2Hex(tx.Data()),
+		"gas":             tx.Gas(),
+		"gas_price":       tx.GasPrice().String(),
+		"value":           tx.Value().String(),
+		"save":            true,
+		"save_if_fails":   true,
+		"simulation_type": "full",
+	}
+	if tx.To() != nil {
+		request["to"] = tx.To().Hex()
+	}
+	if decoded.Receipt != nil && decoded.Receipt.BlockNumber != nil {
+		request["block_number"] = decoded.Receipt.BlockNumber.Uint64()
+		request["transaction_index"] = decoded.Receipt.TransactionIndex
+	}
+
+	projectURL := fmt.Sprintf("%s/api/v1/account/%s/project/%s", strings.TrimSuffix(cfg.APIURL, "/"), cfg.Account, cfg.Project)
+	var response struct {
+		Simulation struct {
+			ID string `json:"id"`
+		} `json:"simulation"`
+	}
+	if err := tenderlyRequest(ctx, cfg, projectURL+"/simulate", request, &response); err != nil {
+		return "", errors.Wrap(err, ErrTenderlySimulation)
+	}
+	if response.Simulation.ID == "" {
+		return "", errors.Wrap(errors.New("response has no simulation id"), ErrTenderlySimulation)
+	}
+	if err := tenderlyRequest(ctx, cfg, projectURL+"/simulations/"+response.Simulation.ID+"/share", nil, nil); err != nil {
+		return "", errors.Wrap(err, "failed to share tenderly simulation")
+	}
+
+	url := fmt.Sprintf("%s/shared/simulation/%s", strings.TrimSuffix(cfg.DashboardURL, "/"), response.Simulation.ID)
+	L.Info().
+		Str("Transaction", decoded.Hash).
+		Str("URL", url).
+		Msg("Shared Tenderly simulation")
+
+	return url, nil
+}
+
+func tenderlyRequest(ctx context.Context, cfg TenderlyConfig, url string, body, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Access-Key", cfg.AccessKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("tenderly API returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, result)
+}
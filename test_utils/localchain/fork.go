@@ -0,0 +1,143 @@
+package localchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+// ForkOpts configures fork started by StartFork
+type ForkOpts struct {
+	// BlockNumber is the block to fork from, defaults to the latest one
+	BlockNumber uint64
+	// KeyFunding is the minimum balance each key from the config will have on the fork (it's set with anvil_setBalance),
+	// defaults to DefaultKeyFunding
+	KeyFunding *big.Int
+	// UseDocker, BinaryPath, Image and StartupTimeout work in the same way as in Opts. Remember that if Anvil runs in Docker,
+	// forked network's URL has to be reachable from the container.
+	UseDocker      bool
+	BinaryPath     string
+	Image          string
+	StartupTimeout time.Duration
+}
+
+// StartFork starts Anvil forking the network from given config (using its first URL) and returns a Chain with a copy of the
+// config pointing at the fork and a client connected to it. Chain ID of the fork is the same as of the forked network.
+// Impersonation of any account is enabled, so that transactions can be sent on behalf of accounts whose keys we don't have
+// (see Chain.SendImpersonatedTransaction). Network name in returned config is changed to seth.ANVIL, so that contracts
+// deployed on the fork are not saved to the contract map of the live network. Everything is torn down when the test ends.
+func StartFork(t *testing.T, cfg *seth.Config, opts ForkOpts) *Chain {
+	t.Helper()
+
+	require.NotNil(t, cfg, "config is required")
+	require.NotNil(t, cfg.Network, "network config is required")
+	require.NotEmpty(t, cfg.Network.URLs, "network has no URLs to fork")
+
+	if opts.KeyFunding == nil {
+		opts.KeyFunding = DefaultKeyFunding
+	}
+	if opts.StartupTimeout == 0 {
+		opts.StartupTimeout = DefaultStartupTimeout
+	}
+
+	args := []string{"--fork-url", cfg.FirstNetworkURL(), "--auto-impersonate"}
+	if opts.BlockNumber != 0 {
+		args = append(args, "--fork-block-number", fmt.Sprint(opts.BlockNumber))
+	}
+
+	url := startExternalChain(t, Opts{
+		Backend:        Backend_Anvil,
+		UseDocker:      opts.UseDocker,
+		BinaryPath:     opts.BinaryPath,
+		Image:          opts.Image,
+		StartupTimeout: opts.StartupTimeout,
+	}, args)
+
+	forkCfg, err := test_utils.CopyConfig(cfg)
+	require.NoError(t, err, "failed to copy config")
+	forkCfg.Network.Name = seth.ANVIL
+	forkCfg.Network.URLs = []string{url}
+
+	privateKeys := forkCfg.Network.PrivateKeys
+	if len(privateKeys) == 0 {
+		_, pk, err := seth.NewAddress()
+		require.NoError(t, err, "failed to generate root key")
+		privateKeys = []string{pk}
+		forkCfg.Network.PrivateKeys = privateKeys
+	}
+	topUpKeys(t, url, privateKeys, opts.KeyFunding)
+
+	c, err := seth.NewClientWithConfig(forkCfg)
+	require.NoError(t, err, "failed to create Seth client")
+	t.Cleanup(c.Close)
+
+	return &Chain{
+		URL:         url,
+		Config:      forkCfg,
+		Client:      c,
+		PrivateKeys: privateKeys,
+	}
+}
+
+// topUpKeys sets balance of every key that has less than minBalance on the fork to minBalance
+func topUpKeys(t *testing.T, url string, privateKeys []string, minBalance *big.Int) {
+	client, err := rpc.Dial(url)
+	require.NoError(t, err, "failed to connect to fork")
+	defer client.Close()
+
+	for _, pk := range privateKeys {
+		key, err := crypto.HexToECDSA(pk)
+		require.NoError(t, err, "failed to parse private key")
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+
+		var balance hexutil.Big
+		require.NoError(t, client.Call(&balance, "eth_getBalance", addr, "latest"), "failed to get balance of %s", addr.Hex())
+		if balance.ToInt().Cmp(minBalance) >= 0 {
+			continue
+		}
+		require.NoError(t, client.Call(nil, "anvil_setBalance", addr, (*hexutil.Big)(minBalance)), "failed to set balance of %s", addr.Hex())
+	}
+}
+
+// SendImpersonatedTransaction sends a transaction on behalf of any account (e.g. a whale or a contract owner on forked network)
+// and waits for its receipt. It works only with chains started with StartFork, because they have impersonation enabled.
+func (c *Chain) SendImpersonatedTransaction(t *testing.T, from, to common.Address, value *big.Int, data []byte) *types.Receipt {
+	t.Helper()
+
+	client, err := rpc.Dial(c.URL)
+	require.NoError(t, err, "failed to connect to fork")
+	defer client.Close()
+
+	tx := map[string]interface{}{
+		"from": from,
+		"to":   to,
+		"data": hexutil.Bytes(data),
+	}
+	if value != nil {
+		tx["value"] = (*hexutil.Big)(value)
+	}
+
+	var txHash common.Hash
+	require.NoError(t, client.Call(&txHash, "eth_sendTransaction", tx), "failed to send impersonated transaction")
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Config.Network.TxnTimeout.Duration())
+	defer cancel()
+	var receipt *types.Receipt
+	require.Eventually(t, func() bool {
+		return client.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash) == nil && receipt != nil
+	}, c.Config.Network.TxnTimeout.Duration(), 100*time.Millisecond, "impersonated transaction was not mined")
+
+	return receipt
+}
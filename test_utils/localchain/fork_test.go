@@ -0,0 +1,49 @@
+package localchain_test
+
+import (
+	"context"
+	"math/big"
+	"os/exec"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/test_utils/localchain"
+)
+
+func TestLocalChainForkAtBlock(t *testing.T) {
+	// simulated chain listens on localhost, so Anvil can't run in Docker
+	if _, err := exec.LookPath(localchain.Backend_Anvil); err != nil {
+		t.Skip("forking requires anvil binary in PATH")
+	}
+
+	source := localchain.Start(t, localchain.Opts{})
+	recipient := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	require.NoError(t, source.Client.TransferETHFromKey(context.Background(), 0, recipient.Hex(), big.NewInt(1_000), nil), "failed to transfer funds")
+	forkBlock, err := source.Client.Client.BlockNumber(context.Background())
+	require.NoError(t, err, "failed to get block number")
+	require.NoError(t, source.Client.TransferETHFromKey(context.Background(), 0, recipient.Hex(), big.NewInt(2_000), nil), "failed to transfer funds")
+
+	fork := localchain.StartFork(t, source.Config, localchain.ForkOpts{BlockNumber: forkBlock})
+	require.Equal(t, seth.ANVIL, fork.Config.Network.Name, "fork should be treated as Anvil")
+	require.Equal(t, source.Config.Network.ChainID, fork.Config.Network.ChainID, "fork should have chain ID of the forked network")
+	require.Equal(t, source.Client.Addresses, fork.Client.Addresses, "fork should use keys of the forked network")
+
+	balance, err := fork.Client.BalanceOf(recipient, seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance on fork")
+	require.Equal(t, big.NewInt(1_000), balance, "fork should read state of the forked network at fork block")
+
+	rootBalance, err := fork.Client.BalanceOf(fork.Client.Addresses[0], seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance on fork")
+	require.GreaterOrEqual(t, rootBalance.Cmp(localchain.DefaultKeyFunding), 0, "root key should have been topped up")
+
+	require.NoError(t, fork.Client.TransferETHFromKey(context.Background(), 0, recipient.Hex(), big.NewInt(5_000), nil), "failed to transfer funds on fork")
+	balance, err = fork.Client.BalanceOf(recipient, seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance on fork")
+	require.Equal(t, big.NewInt(6_000), balance, "transfer should have been mined on fork")
+	balance, err = source.Client.BalanceOf(recipient, seth.BlockTag_Latest)
+	require.NoError(t, err, "failed to get balance on forked network")
+	require.Equal(t, big.NewInt(3_000), balance, "transactions sent to fork shouldn't reach forked network")
+}
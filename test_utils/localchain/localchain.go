@@ -0,0 +1,299 @@
+// Package localchain starts local chains (in-process simulated backend, Anvil or Geth) for tests and connects Seth to them.
+// It lives in a separate package, because the in-process backend pulls in go-ethereum's blockchain implementation.
+package localchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/test_utils"
+)
+
+const (
+	Backend_Simulated = "simulated"
+	Backend_Anvil     = "anvil"
+	Backend_Geth      = "geth"
+
+	DefaultAnvilImage = "ghcr.io/foundry-rs/foundry:latest"
+	DefaultGethImage  = "ethereum/client-go:v1.13.8"
+
+	DefaultStartupTimeout = 1 * time.Minute
+	DefaultGasLimit       = 30_000_000
+
+	containerRPCPort = "8545/tcp"
+)
+
+var (
+	// DefaultKeyFunding is the amount each additional key is funded with (100 ETH)
+	DefaultKeyFunding = big.NewInt(0).Mul(big.NewInt(100), big.NewInt(1_000_000_000_000_000_000))
+	// rootKeyFunding is the amount the root key is funded with on Anvil and Geth, which have their own pre-funded accounts (10000 ETH)
+	rootKeyFunding = big.NewInt(0).Mul(big.NewInt(10_000), big.NewInt(1_000_000_000_000_000_000))
+)
+
+// Opts configures local chain started by Start. Zero value starts an in-process simulated chain with only the root key.
+type Opts struct {
+	// Backend is one of Backend_Simulated (default), Backend_Anvil or Backend_Geth
+	Backend string
+	// Keys is the number of additional keys (besides the root key) that will be generated and funded
+	Keys int
+	// KeyFunding is the amount each additional key is funded with, defaults to DefaultKeyFunding
+	KeyFunding *big.Int
+	// Config is an optional base config. Its network URL, name and private keys are overwritten.
+	Config *seth.Config
+	// UseDocker forces running Anvil or Geth in a Docker container. If it's false, local binary is used if it's found in PATH
+	// (or at BinaryPath) and Docker otherwise.
+	UseDocker bool
+	// BinaryPath is a path to Anvil or Geth executable, defaults to looking it up in PATH
+	BinaryPath string
+	// Image is a Docker image to use, defaults to DefaultAnvilImage or DefaultGethImage
+	Image string
+	// StartupTimeout is how long to wait for the RPC to become available, defaults to DefaultStartupTimeout
+	StartupTimeout time.Duration
+}
+
+// Chain is a running local chain together with a ready to use Seth client connected to it
+type Chain struct {
+	URL         string
+	Config      *seth.Config
+	Client      *seth.Client
+	PrivateKeys []string
+}
+
+// Start launches a local chain (in-process simulated backend, Anvil or Geth), generates a Config with a funded root key
+// and opts.Keys additional funded keys, and returns a Client connected to it. Everything is torn down when the test ends.
+func Start(t *testing.T, opts Opts) *Chain {
+	t.Helper()
+
+	if opts.Backend == "" {
+		opts.Backend = Backend_Simulated
+	}
+	if opts.KeyFunding == nil {
+		opts.KeyFunding = DefaultKeyFunding
+	}
+	if opts.StartupTimeout == 0 {
+		opts.StartupTimeout = DefaultStartupTimeout
+	}
+
+	rootAddr, rootPk, err := seth.NewAddress()
+	require.NoError(t, err, "failed to generate root key")
+
+	var url, networkName string
+	switch opts.Backend {
+	case Backend_Simulated:
+		url = startSimulatedChain(t, common.HexToAddress(rootAddr))
+		networkName = seth.GETH
+	case Backend_Anvil, Backend_Geth:
+		url = startExternalChain(t, opts, nil)
+		fundFromUnlockedAccount(t, url, common.HexToAddress(rootAddr), rootKeyFunding)
+		networkName = seth.ANVIL
+		if opts.Backend == Backend_Geth {
+			networkName = seth.GETH
+		}
+	default:
+		require.FailNowf(t, "unknown local chain backend", "backend: %s", opts.Backend)
+	}
+
+	privateKeys := []string{rootPk}
+	for i := 0; i < opts.Keys; i++ {
+		_, pk, err := seth.NewAddress()
+		require.NoError(t, err, "failed to generate new address")
+		privateKeys = append(privateKeys, pk)
+	}
+
+	var cfg *seth.Config
+	if opts.Config != nil {
+		cfg, err = test_utils.CopyConfig(opts.Config)
+		require.NoError(t, err, "failed to copy config")
+	} else {
+		builder := seth.NewClientBuilder().
+			WithGasPriceEstimations(false, 0, seth.Priority_Standard).
+			WithProtections(false, false).
+			WithArtifactsFolder(t.TempDir())
+		cfg = builder.Config()
+	}
+	cfg.Network.Name = networkName
+	cfg.Network.URLs = []string{url}
+	cfg.Network.PrivateKeys = privateKeys
+
+	c, err := seth.NewClientWithConfig(cfg)
+	require.NoError(t, err, "failed to create Seth client")
+	t.Cleanup(c.Close)
+
+	if opts.Keys > 0 {
+		report, err := seth.NewFundingWorkflow(c, nil).SplitFunds(context.Background(), c.Addresses[1:], opts.KeyFunding)
+		require.NoError(t, err, "failed to fund keys")
+		require.Equal(t, 0, report.Failed, "some keys were not funded")
+	}
+
+	return &Chain{
+		URL:         url,
+		Config:      cfg,
+		Client:      c,
+		PrivateKeys: privateKeys,
+	}
+}
+
+// startExternalChain starts Anvil or Geth either as a local process or a Docker container and returns its HTTP RPC URL.
+// Extra arguments are passed to Anvil only.
+func startExternalChain(t *testing.T, opts Opts, extraAnvilArgs []string) string {
+	binary := opts.BinaryPath
+	if binary == "" && !opts.UseDocker {
+		binary, _ = exec.LookPath(opts.Backend)
+	}
+
+	if binary != "" && !opts.UseDocker {
+		port := freePort(t)
+		url := fmt.Sprintf("http://127.0.0.1:%d", port)
+		var args []string
+		if opts.Backend == Backend_Anvil {
+			args = append([]string{"--host", "127.0.0.1", "--port", fmt.Sprint(port), "--gas-limit", fmt.Sprint(DefaultGasLimit)}, extraAnvilArgs...)
+		} else {
+			args = append(gethDevArgs("127.0.0.1", port), "--datadir", t.TempDir(), "--authrpc.port", fmt.Sprint(freePort(t)))
+		}
+		cmd := exec.Command(binary, args...)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		require.NoError(t, cmd.Start(), "failed to start %s", binary)
+		t.Cleanup(func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		})
+		waitForRPC(t, url, opts.StartupTimeout, func() string { return output.String() })
+
+		return url
+	}
+
+	return startContainer(t, opts, extraAnvilArgs)
+}
+
+// startContainer starts Anvil or Geth in a Docker container managed by testcontainers (so that it's reaped even if the test
+// process crashes) and returns its HTTP RPC URL
+func startContainer(t *testing.T, opts Opts, extraAnvilArgs []string) string {
+	req := testcontainers.ContainerRequest{
+		Image:        opts.Image,
+		ExposedPorts: []string{containerRPCPort},
+		WaitingFor:   wait.ForListeningPort(containerRPCPort).WithStartupTimeout(opts.StartupTimeout),
+	}
+	if opts.Backend == Backend_Anvil {
+		if req.Image == "" {
+			req.Image = DefaultAnvilImage
+		}
+		req.Entrypoint = []string{"anvil"}
+		req.Cmd = append([]string{"--host", "0.0.0.0", "--port", "8545", "--gas-limit", fmt.Sprint(DefaultGasLimit)}, extraAnvilArgs...)
+	} else {
+		if req.Image == "" {
+			req.Image = DefaultGethImage
+		}
+		req.Cmd = gethDevArgs("0.0.0.0", 8545)
+	}
+
+	ctx := context.Background()
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if container != nil {
+		t.Cleanup(func() {
+			_ = container.Terminate(context.Background())
+		})
+	}
+	require.NoError(t, err, "failed to start %s container", opts.Backend)
+
+	host, err := container.Host(ctx)
+	require.NoError(t, err, "failed to get %s container host", opts.Backend)
+	port, err := container.MappedPort(ctx, containerRPCPort)
+	require.NoError(t, err, "failed to get %s container RPC port", opts.Backend)
+	url := fmt.Sprintf("http://%s:%s", host, port.Port())
+
+	// port is open before node starts serving requests
+	waitForRPC(t, url, opts.StartupTimeout, func() string {
+		logs, err := container.Logs(ctx)
+		if err != nil {
+			return err.Error()
+		}
+		defer logs.Close()
+		out, _ := io.ReadAll(logs)
+		return string(out)
+	})
+
+	return url
+}
+
+func gethDevArgs(host string, port int) []string {
+	return []string{
+		"--dev",
+		"--http", "--http.addr", host, "--http.port", fmt.Sprint(port),
+		"--http.api", "eth,net,web3,debug,txpool",
+		"--http.vhosts", "*",
+		"--nodiscover", "--maxpeers", "0", "--port", "0",
+	}
+}
+
+// waitForRPC waits until RPC node responds to eth_chainId or fails the test with node's output
+func waitForRPC(t *testing.T, url string, timeout time.Duration, outputFn func() string) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		client, err := rpc.DialContext(ctx, url)
+		if err == nil {
+			var chainID hexutil.Big
+			err = client.CallContext(ctx, &chainID, "eth_chainId")
+			client.Close()
+		}
+		cancel()
+		if err == nil {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	require.FailNowf(t, "local chain did not start in time", "url: %s, output:\n%s", url, outputFn())
+}
+
+// fundFromUnlockedAccount sends funds to given address from the first account unlocked on the node (Anvil and Geth in dev mode have them)
+func fundFromUnlockedAccount(t *testing.T, url string, to common.Address, amount *big.Int) {
+	client, err := rpc.Dial(url)
+	require.NoError(t, err, "failed to connect to local chain")
+	defer client.Close()
+
+	var accounts []common.Address
+	require.NoError(t, client.Call(&accounts, "eth_accounts"), "failed to get unlocked accounts")
+	require.NotEmpty(t, accounts, "local chain has no unlocked accounts")
+
+	var txHash common.Hash
+	err = client.Call(&txHash, "eth_sendTransaction", map[string]interface{}{
+		"from":  accounts[0],
+		"to":    to,
+		"value": (*hexutil.Big)(amount),
+	})
+	require.NoError(t, err, "failed to fund root key")
+
+	require.Eventually(t, func() bool {
+		var receipt map[string]interface{}
+		return client.Call(&receipt, "eth_getTransactionReceipt", txHash) == nil && receipt != nil
+	}, DefaultStartupTimeout, 100*time.Millisecond, "root key funding transaction was not mined")
+}
+
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "failed to find a free port")
+	defer func() { _ = l.Close() }()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
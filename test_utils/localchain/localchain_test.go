@@ -0,0 +1,55 @@
+package localchain_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+	"github.com/smartcontractkit/seth/test_utils/localchain"
+)
+
+func TestLocalChainSimulatedStartStop(t *testing.T) {
+	var url string
+	t.Run("start", func(t *testing.T) {
+		chain := localchain.Start(t, localchain.Opts{Keys: 2})
+		url = chain.URL
+
+		require.Len(t, chain.PrivateKeys, 3, "root key and 2 additional keys should have been generated")
+		require.Len(t, chain.Client.Addresses, 3, "client should use all generated keys")
+		require.Equal(t, seth.GETH, chain.Config.Network.Name, "simulated chain should be treated as Geth")
+		require.Equal(t, []string{chain.URL}, chain.Config.Network.URLs, "config should point at the local chain")
+
+		for _, addr := range chain.Client.Addresses[1:] {
+			balance, err := chain.Client.BalanceOf(addr, seth.BlockTag_Latest)
+			require.NoError(t, err, "failed to get balance")
+			require.Equal(t, localchain.DefaultKeyFunding, balance, "additional key should have been funded")
+		}
+
+		recipient := common.HexToAddress("0x1000000000000000000000000000000000000001")
+		value := big.NewInt(1_000)
+		err := chain.Client.TransferETHFromKey(context.Background(), 1, recipient.Hex(), value, nil)
+		require.NoError(t, err, "failed to transfer funds")
+		balance, err := chain.Client.BalanceOf(recipient, seth.BlockTag_Latest)
+		require.NoError(t, err, "failed to get balance")
+		require.Equal(t, value, balance, "transfer should have been mined")
+
+		block, err := chain.Client.Client.BlockByNumber(context.Background(), nil)
+		require.NoError(t, err, "failed to get latest block")
+		require.Len(t, block.Transactions(), 1, "every transaction should be mined in its own block")
+		tx, pending, err := chain.Client.Client.TransactionByHash(context.Background(), block.Transactions()[0].Hash())
+		require.NoError(t, err, "failed to get transaction")
+		require.False(t, pending, "transaction should have been mined")
+		require.Equal(t, &recipient, tx.To(), "incorrect transaction recipient")
+	})
+
+	client, err := rpc.Dial(url)
+	require.NoError(t, err, "failed to create RPC client")
+	defer client.Close()
+	var chainID string
+	require.Error(t, client.Call(&chainID, "eth_chainId"), "local chain should have been stopped when the test ended")
+}
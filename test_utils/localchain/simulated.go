@@ -0,0 +1,359 @@
+package localchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// simulatedRootFunding is the amount the root key is funded with in genesis of the simulated chain (1000000 ETH)
+	simulatedRootFunding = big.NewInt(0).Mul(big.NewInt(1_000_000), big.NewInt(1_000_000_000_000_000_000))
+)
+
+// startSimulatedChain starts go-ethereum's simulated backend with funded root key, serves it over HTTP JSON-RPC and returns
+// its URL. A block is mined for every transaction. Simulated backend doesn't pull in the whole go-ethereum node, but it
+// also doesn't support debug API, so tracing is disabled by Seth when it probes node's capabilities.
+func startSimulatedChain(t *testing.T, rootAddr common.Address) string {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{rootAddr: {Balance: simulatedRootFunding}}, DefaultGasLimit)
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", &simulatedChainService{backend: backend}), "failed to register simulated chain service")
+	require.NoError(t, server.RegisterName("net", &simulatedNetService{backend: backend}), "failed to register simulated net service")
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+
+	return httpServer.URL
+}
+
+// simulatedChainService exposes simulated backend as "eth" namespace of JSON-RPC API, only methods used by Seth are
+// implemented
+type simulatedChainService struct {
+	// makes sending a transaction and mining it atomic
+	mu      sync.Mutex
+	backend *backends.SimulatedBackend
+	// transactions with nonce higher than sender's next nonce, by sender and nonce
+	queued map[common.Address]map[uint64]*types.Transaction
+}
+
+// simulatedNetService exposes "net" namespace of JSON-RPC API for simulated backend
+type simulatedNetService struct {
+	backend *backends.SimulatedBackend
+}
+
+func (s *simulatedNetService) Version() string {
+	return s.backend.Blockchain().Config().ChainID.String()
+}
+
+// callArgs are arguments of eth_call and eth_estimateGas
+type callArgs struct {
+	From                 *common.Address   `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  *hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big      `json:"value"`
+	Data                 *hexutil.Bytes    `json:"data"`
+	Input                *hexutil.Bytes    `json:"input"`
+	AccessList           *types.AccessList `json:"accessList"`
+}
+
+func (a callArgs) toCallMsg() ethereum.CallMsg {
+	msg := ethereum.CallMsg{To: a.To}
+	if a.From != nil {
+		msg.From = *a.From
+	}
+	if a.Gas != nil {
+		msg.Gas = uint64(*a.Gas)
+	}
+	if a.GasPrice != nil {
+		msg.GasPrice = a.GasPrice.ToInt()
+	}
+	if a.MaxFeePerGas != nil {
+		msg.GasFeeCap = a.MaxFeePerGas.ToInt()
+	}
+	if a.MaxPriorityFeePerGas != nil {
+		msg.GasTipCap = a.MaxPriorityFeePerGas.ToInt()
+	}
+	if a.Value != nil {
+		msg.Value = a.Value.ToInt()
+	}
+	if a.Input != nil {
+		msg.Data = *a.Input
+	} else if a.Data != nil {
+		msg.Data = *a.Data
+	}
+	if a.AccessList != nil {
+		msg.AccessList = *a.AccessList
+	}
+
+	return msg
+}
+
+// blockNumber converts block argument to block number accepted by simulated backend, nil means the latest block (which is
+// also the pending one, because every transaction is mined right away)
+func (s *simulatedChainService) blockNumber(ctx context.Context, block *rpc.BlockNumberOrHash) (*big.Int, error) {
+	if block == nil {
+		return nil, nil
+	}
+	if hash, ok := block.Hash(); ok {
+		header, err := s.backend.HeaderByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		return header.Number, nil
+	}
+	if number, ok := block.Number(); ok && number >= 0 {
+		return big.NewInt(number.Int64()), nil
+	}
+
+	return nil, nil
+}
+
+func (s *simulatedChainService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.backend.Blockchain().Config().ChainID)
+}
+
+func (s *simulatedChainService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.backend.Blockchain().CurrentBlock().Number.Uint64())
+}
+
+func (s *simulatedChainService) GasPrice(ctx context.Context) (*hexutil.Big, error) {
+	price, err := s.backend.SuggestGasPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+func (s *simulatedChainService) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tip, err := s.backend.SuggestGasTipCap(ctx)
+	return (*hexutil.Big)(tip), err
+}
+
+func (s *simulatedChainService) GetBalance(ctx context.Context, addr common.Address, block *rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	number, err := s.blockNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.backend.BalanceAt(ctx, addr, number)
+	return (*hexutil.Big)(balance), err
+}
+
+func (s *simulatedChainService) GetTransactionCount(ctx context.Context, addr common.Address, block *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	number, err := s.blockNumber(ctx, block)
+	if err != nil {
+		return 0, err
+	}
+	nonce, err := s.backend.NonceAt(ctx, addr, number)
+	return hexutil.Uint64(nonce), err
+}
+
+func (s *simulatedChainService) GetCode(ctx context.Context, addr common.Address, block *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	number, err := s.blockNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.CodeAt(ctx, addr, number)
+}
+
+func (s *simulatedChainService) GetStorageAt(ctx context.Context, addr common.Address, key common.Hash, block *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	number, err := s.blockNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.StorageAt(ctx, addr, key, number)
+}
+
+func (s *simulatedChainService) Call(ctx context.Context, args callArgs, block *rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	number, err := s.blockNumber(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	return s.backend.CallContract(ctx, args.toCallMsg(), number)
+}
+
+func (s *simulatedChainService) EstimateGas(ctx context.Context, args callArgs, _ *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+	gas, err := s.backend.EstimateGas(ctx, args.toCallMsg())
+	return hexutil.Uint64(gas), err
+}
+
+func (s *simulatedChainService) SendRawTransaction(ctx context.Context, raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(s.backend.Blockchain().Config().ChainID), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next, err := s.backend.PendingNonceAt(ctx, from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	// transactions sent concurrently from the same key can arrive out of order, so like a real node's pool we keep
+	// the ones with nonce gap until the missing ones arrive
+	if tx.Nonce() > next {
+		if s.queued == nil {
+			s.queued = make(map[common.Address]map[uint64]*types.Transaction)
+		}
+		if s.queued[from] == nil {
+			s.queued[from] = make(map[uint64]*types.Transaction)
+		}
+		s.queued[from][tx.Nonce()] = tx
+		return tx.Hash(), nil
+	}
+	if err := s.backend.SendTransaction(ctx, tx); err != nil {
+		return common.Hash{}, err
+	}
+	s.backend.Commit()
+
+	for nonce := tx.Nonce() + 1; s.queued[from][nonce] != nil; nonce++ {
+		queuedTx := s.queued[from][nonce]
+		delete(s.queued[from], nonce)
+		if err := s.backend.SendTransaction(ctx, queuedTx); err != nil {
+			break
+		}
+		s.backend.Commit()
+	}
+
+	return tx.Hash(), nil
+}
+
+func (s *simulatedChainService) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := s.backend.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	return receipt, err
+}
+
+func (s *simulatedChainService) GetTransactionByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, _, err := s.backend.TransactionByHash(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := s.backend.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.marshalTx(tx, receipt.BlockHash, receipt.BlockNumber, receipt.TransactionIndex)
+}
+
+func (s *simulatedChainService) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	var blockNumber *big.Int
+	if number >= 0 {
+		blockNumber = big.NewInt(number.Int64())
+	}
+	block, err := s.backend.BlockByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, nil
+	}
+	return s.marshalBlock(block, fullTx)
+}
+
+func (s *simulatedChainService) GetBlockByHash(ctx context.Context, hash common.Hash, fullTx bool) (map[string]interface{}, error) {
+	block, err := s.backend.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, nil
+	}
+	return s.marshalBlock(block, fullTx)
+}
+
+func (s *simulatedChainService) GetLogs(ctx context.Context, criteria filters.FilterCriteria) ([]types.Log, error) {
+	logs, err := s.backend.FilterLogs(ctx, ethereum.FilterQuery(criteria))
+	if logs == nil {
+		logs = []types.Log{}
+	}
+	return logs, err
+}
+
+// marshalTx returns JSON fields of mined transaction in the same format as eth_getTransactionByHash
+func (s *simulatedChainService) marshalTx(tx *types.Transaction, blockHash common.Hash, blockNumber *big.Int, index uint) (map[string]interface{}, error) {
+	fields, err := toFields(tx)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(s.backend.Blockchain().Config().ChainID), tx)
+	if err != nil {
+		return nil, err
+	}
+	fields["from"] = from
+	fields["blockHash"] = blockHash
+	fields["blockNumber"] = (*hexutil.Big)(blockNumber)
+	fields["transactionIndex"] = hexutil.Uint64(index)
+
+	return fields, nil
+}
+
+// marshalBlock returns JSON fields of block in the same format as eth_getBlockByNumber
+func (s *simulatedChainService) marshalBlock(block *types.Block, fullTx bool) (map[string]interface{}, error) {
+	fields, err := toFields(block.Header())
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]interface{}, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !fullTx {
+			txs = append(txs, tx.Hash())
+			continue
+		}
+		txFields, err := s.marshalTx(tx, block.Hash(), block.Number(), uint(i))
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, txFields)
+	}
+	uncles := make([]common.Hash, 0, len(block.Uncles()))
+	for _, uncle := range block.Uncles() {
+		uncles = append(uncles, uncle.Hash())
+	}
+
+	fields["size"] = hexutil.Uint64(block.Size())
+	fields["transactions"] = txs
+	fields["uncles"] = uncles
+	if block.Withdrawals() != nil {
+		fields["withdrawals"] = block.Withdrawals()
+	}
+
+	return fields, nil
+}
+
+// toFields converts value to a map of its JSON fields, so that fields only returned by RPC can be added to it
+func toFields(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+
+	return fields, err
+}
@@ -0,0 +1,62 @@
+package test_utils
+
+import (
+	"crypto/ecdsa"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/smartcontractkit/seth"
+)
+
+// NewMockRPCServer creates JSON-RPC server with given services registered under their namespaces (e.g. "eth" or "debug"),
+// so that tests can mock only RPC methods they need. Server is stopped when the test ends.
+func NewMockRPCServer(t *testing.T, services map[string]interface{}) *rpc.Server {
+	t.Helper()
+
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+	for namespace, service := range services {
+		require.NoError(t, server.RegisterName(namespace, service), "failed to register %s service", namespace)
+	}
+
+	return server
+}
+
+// NewMockRPC serves JSON-RPC server with given services over HTTP and returns its URL. Server is closed when the test ends.
+func NewMockRPC(t *testing.T, services map[string]interface{}) string {
+	t.Helper()
+
+	httpServer := httptest.NewServer(NewMockRPCServer(t, services))
+	t.Cleanup(httpServer.Close)
+
+	return httpServer.URL
+}
+
+// NewMockRPCClientBuilder returns client builder for mock RPC at given URL with features that call RPC methods mocks usually
+// don't implement (tracing, protections, gas price estimation and EIP-1559 fees) disabled
+func NewMockRPCClientBuilder(url string) *seth.ClientBuilder {
+	return seth.NewClientBuilder().
+		WithRpcUrl(url).
+		WithTracing(seth.TracingLevel_None, nil).
+		WithProtections(false, false).
+		WithEIP1559DynamicFees(false).
+		WithGasPriceEstimations(false, 0, "")
+}
+
+// NewMockRPCClient creates client with given config and a newly generated root key. Client is closed when the test ends.
+func NewMockRPCClient(t *testing.T, cfg *seth.Config, opts ...seth.ClientOpt) *seth.Client {
+	t.Helper()
+
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	c, err := seth.NewClientRaw(cfg, []common.Address{crypto.PubkeyToAddress(pk.PublicKey)}, []*ecdsa.PrivateKey{pk}, opts...)
+	require.NoError(t, err, "failed to create client")
+	t.Cleanup(c.Close)
+
+	return c
+}
@@ -0,0 +1,233 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	ErrTokenFunding = "ERC-20 token funding failed"
+
+	erc20FundingABI = `[
+{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"view"},
+{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable"},
+{"type":"function","name":"decimals","inputs":[],"outputs":[{"name":"","type":"uint8"}],"stateMutability":"view"},
+{"type":"function","name":"symbol","inputs":[],"outputs":[{"name":"","type":"string"}],"stateMutability":"view"}
+]`
+)
+
+// erc20ABI is the subset of ERC-20 ABI used by token funding
+var erc20ABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(erc20FundingABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+func (f *FundingWorkflow) tokenContract(token common.Address) *bind.BoundContract {
+	return bind.NewBoundContract(token, erc20ABI, f.Client.Client, f.Client.Client, f.Client.Client)
+}
+
+// tokenBalanceOf returns ERC-20 token balance of given address
+func (f *FundingWorkflow) tokenBalanceOf(ctx context.Context, token, addr common.Address) (*big.Int, error) {
+	var out []interface{}
+	if err := f.tokenContract(token).Call(&bind.CallOpts{Context: ctx}, &out, "balanceOf", addr); err != nil {
+		return nil, errors.Wrapf(err, "failed to get token balance of %s", addr.Hex())
+	}
+
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+}
+
+// tokenCurrency returns symbol and decimals of ERC-20 token, used to format amounts in funding reports. Both are optional
+// in ERC-20, so token address and 18 decimals are used if token doesn't implement them.
+func (f *FundingWorkflow) tokenCurrency(ctx context.Context, token common.Address) NativeCurrency {
+	currency := NativeCurrency{Symbol: token.Hex(), Decimals: 18, BaseUnit: "base units"}
+	contract := f.tokenContract(token)
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "symbol"); err == nil && len(out) == 1 {
+		if symbol, ok := out[0].(string); ok && symbol != "" {
+			currency.Symbol = symbol
+		}
+	}
+	out = nil
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "decimals"); err == nil && len(out) == 1 {
+		if decimals, ok := out[0].(uint8); ok {
+			currency.Decimals = decimals
+		}
+	}
+
+	return currency
+}
+
+// transferToken sends ERC-20 tokens from key with given number and waits for the transfer to be mined
+func (f *FundingWorkflow) transferToken(token common.Address, fromKeyNum int, to common.Address, amount *big.Int) error {
+	c := f.Client
+	_, err := c.Decode(f.tokenContract(token).Transact(c.NewTXKeyOpts(fromKeyNum), "transfer", to, amount))
+	return err
+}
+
+// SplitTokenFunds sends ERC-20 tokens from the root key to each of the addresses, in the same way as SplitFunds does with
+// native funds. If amount is nil it will be calculated by dividing root key's token balance by the number of addresses that
+// still need funding. Transfers are paid for with root key's native funds. Amounts in the report are token amounts.
+func (f *FundingWorkflow) SplitTokenFunds(ctx context.Context, token common.Address, addresses []common.Address, amount *big.Int) (*FundingReport, error) {
+	if len(addresses) == 0 {
+		return nil, errors.New(ErrNoAddressesToFund)
+	}
+
+	c := f.Client
+	rootAddr := c.MustGetRootKeyAddress()
+	rootBalanceBefore, err := f.tokenBalanceOf(ctx, token, rootAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrTokenFunding)
+	}
+
+	report := &FundingReport{
+		RootAddress:       rootAddr,
+		RootBalanceBefore: rootBalanceBefore,
+		TotalTransferred:  big.NewInt(0),
+		Currency:          f.tokenCurrency(ctx, token),
+		Token:             &token,
+	}
+
+	balances := make([]*big.Int, len(addresses))
+	pending := make([]int, 0)
+	for i, addr := range addresses {
+		balance, err := f.tokenBalanceOf(ctx, token, addr)
+		if err != nil {
+			return nil, errors.Wrap(err, ErrTokenFunding)
+		}
+		balances[i] = balance
+		if (amount == nil && balance.Sign() == 0) || (amount != nil && balance.Cmp(amount) < 0) {
+			pending = append(pending, i)
+		}
+	}
+
+	if len(pending) < len(addresses) {
+		L.Info().
+			Int("Already funded", len(addresses)-len(pending)).
+			Int("To fund", len(pending)).
+			Msg("Resuming token funds split, some addresses were already funded")
+	}
+
+	if amount == nil && len(pending) > 0 {
+		amount = new(big.Int).Div(rootBalanceBefore, big.NewInt(int64(len(pending))))
+		if amount.Sign() == 0 {
+			return nil, errors.Errorf("%s: root key's token balance %s is too low to split it between %d addresses", ErrTokenFunding, report.currency().Format(rootBalanceBefore), len(pending))
+		}
+	}
+
+	transfers := make([]FundingTransfer, len(addresses))
+	for i, addr := range addresses {
+		transfers[i] = FundingTransfer{
+			From:          rootAddr,
+			To:            addr,
+			Amount:        big.NewInt(0),
+			BalanceBefore: balances[i],
+			Skipped:       true,
+		}
+	}
+
+	// we don't cancel remaining transfers when one of them fails, so that report says exactly which transfers failed
+	f.resetProgress()
+	var eg errgroup.Group
+	for _, idx := range pending {
+		idx := idx
+		eg.Go(func() error {
+			err := f.transferToken(token, 0, addresses[idx], amount)
+			transfers[idx].Skipped = false
+			transfers[idx].Err = err
+			if err == nil {
+				transfers[idx].Amount = amount
+			}
+			f.reportProgress(len(pending), addresses[idx], amount, err)
+			return err
+		})
+	}
+	splitErr := eg.Wait()
+
+	f.reconcile(ctx, report, transfers)
+
+	if splitErr != nil {
+		return report, &FundingError{Report: report}
+	}
+
+	return report, nil
+}
+
+// ReturnTokenFunds returns whole ERC-20 token balance of all non-root keys to toAddr (or to root key if it's empty). Keys
+// without tokens are skipped, so it's safe to run it again after an interrupted run. Transfers are paid for with native
+// funds of each key, so tokens have to be returned before native funds. Failed return doesn't cancel returns from other keys.
+func (f *FundingWorkflow) ReturnTokenFunds(ctx context.Context, token common.Address, toAddr string) (*FundingReport, error) {
+	c := f.Client
+	if toAddr == "" {
+		toAddr = c.Addresses[0].Hex()
+	}
+
+	if len(c.Addresses) == 1 {
+		return nil, errors.New(ErrNoAddressesToReturnFrom)
+	}
+
+	to := common.HexToAddress(toAddr)
+	toBalanceBefore, err := f.tokenBalanceOf(ctx, token, to)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrTokenFunding)
+	}
+
+	report := &FundingReport{
+		RootAddress:       to,
+		RootBalanceBefore: toBalanceBefore,
+		TotalTransferred:  big.NewInt(0),
+		Currency:          f.tokenCurrency(ctx, token),
+		Token:             &token,
+	}
+
+	addrs, _ := c.keys()
+	total := len(addrs) - 1
+	transfers := make([]FundingTransfer, total)
+
+	f.resetProgress()
+	var eg errgroup.Group
+	for i := 1; i < len(addrs); i++ {
+		idx := i
+		// tokens were already returned from the first occurrence of the key and node accounts' tokens belong to the node operator
+		if c.Cfg.IsKeyAlias(idx) || c.IsNodeAccount(idx) {
+			transfers[idx-1] = FundingTransfer{From: addrs[idx], To: to, Skipped: true}
+			continue
+		}
+		eg.Go(func() error {
+			transfer := FundingTransfer{From: addrs[idx], To: to, Amount: big.NewInt(0)}
+			balance, err := f.tokenBalanceOf(ctx, token, addrs[idx])
+			if err == nil {
+				transfer.BalanceBefore = balance
+				if balance.Sign() == 0 {
+					transfer.Skipped = true
+				} else {
+					L.Info().
+						Str("Key", addrs[idx].Hex()).
+						Str("Amount", report.currency().Format(balance)).
+						Msg("Returning token funds from address")
+					if err = f.transferToken(token, idx, to, balance); err == nil {
+						transfer.Amount = balance
+					}
+				}
+			}
+			transfer.Err = err
+			transfers[idx-1] = transfer
+			f.reportProgress(total, addrs[idx], transfer.Amount, err)
+			return err
+		})
+	}
+	returnErr := eg.Wait()
+
+	f.reconcile(ctx, report, transfers)
+
+	return report, returnErr
+}
@@ -0,0 +1,288 @@
+package seth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TraceBackend_Geth traces transactions with debug_traceTransaction (Geth, Anvil, Erigon, Nethermind with debug API)
+	TraceBackend_Geth = "geth"
+	// TraceBackend_Parity traces transactions with trace_transaction (OpenEthereum/Parity trace API, exposed by Erigon,
+	// Nethermind and many RPC providers)
+	TraceBackend_Parity = "parity"
+
+	ErrParityTrace = "failed to convert trace_transaction output"
+)
+
+// TraceBackend fetches raw trace of a transaction, which is then decoded by the Tracer. Custom backends can be set in
+// Tracer.Backend, e.g. to read traces from an indexer instead of the RPC node.
+type TraceBackend interface {
+	// TraceTransaction returns raw trace of a transaction and its size, which is used to enforce trace retention limits.
+	// Call trace is required, 4byte and opcodes traces are optional.
+	TraceTransaction(txHash string) (*Trace, TraceSize, error)
+}
+
+// newTraceBackend returns trace backend selected in config
+func newTraceBackend(cfg *Config, rpcClient *rpc.Client) TraceBackend {
+	if cfg.TraceBackend == TraceBackend_Parity {
+		return &parityTraceBackend{rpcClient: rpcClient}
+	}
+
+	return &gethTraceBackend{rpcClient: rpcClient, cfg: cfg}
+}
+
+// traceCapability returns capability of the RPC node required by trace backend selected in config
+func (c *Config) traceCapability() string {
+	if c.TraceBackend == TraceBackend_Parity {
+		return Capability_TraceTransaction
+	}
+
+	return Capability_DebugTrace
+}
+
+// gethTraceBackend traces transactions with debug_traceTransaction using callTracer, 4byteTracer and the default opcodes tracer.
+// Opcodes trace is only needed to trace storage access, so it's fetched only if storage tracing is enabled.
+type gethTraceBackend struct {
+	rpcClient *rpc.Client
+	cfg       *Config
+}
+
+func (g *gethTraceBackend) TraceTransaction(txHash string) (*Trace, TraceSize, error) {
+	var size TraceSize
+	fourByte, fourByteSize, err := g.trace4Byte(txHash)
+	if err != nil {
+		L.Debug().Err(err).Msg("Failed to trace 4byte signatures. Some tracing data might be missing")
+	}
+	var opCodesTrace map[string]interface{}
+	var opCodesSize int64
+	if g.cfg == nil || g.cfg.TraceStorage {
+		opCodesTrace, opCodesSize, err = g.traceOpCodesTracer(txHash)
+		if err != nil {
+			L.Debug().Err(err).Msg("Failed to trace opcodes. Some tracing data will be missing")
+		}
+	}
+
+	callTrace, callTraceSize, err := g.traceCallTracer(txHash)
+	if err != nil {
+		return nil, size, err
+	}
+	size.OpCodes = opCodesSize
+	size.Total = fourByteSize + opCodesSize + callTraceSize
+
+	return &Trace{
+		TxHash:       txHash,
+		FourByte:     fourByte,
+		CallTrace:    callTrace,
+		OpCodesTrace: opCodesTrace,
+	}, size, nil
+}
+
+func (g *gethTraceBackend) trace4Byte(txHash string) (map[string]*TXFourByteMetadataOutput, int64, error) {
+	var trace map[string]int
+	size, err := g.callTraceRaw(&trace, txHash, map[string]interface{}{"tracer": "4byteTracer"})
+	if err != nil {
+		return nil, 0, err
+	}
+	out := make(map[string]*TXFourByteMetadataOutput)
+	for k, v := range trace {
+		d := strings.Split(k, "-")
+		callParamsSize, err := strconv.Atoi(d[1])
+		if err != nil {
+			return nil, 0, err
+		}
+		out[d[0]] = &TXFourByteMetadataOutput{Times: v, CallSize: callParamsSize}
+	}
+	return out, size, nil
+}
+
+func (g *gethTraceBackend) traceCallTracer(txHash string) (*TXCallTraceOutput, int64, error) {
+	var trace *TXCallTraceOutput
+	size, err := g.callTraceRaw(
+		&trace,
+		txHash,
+		map[string]interface{}{
+			"tracer": "callTracer",
+			"tracerConfig": map[string]interface{}{
+				"withLog": true,
+			},
+		})
+	if err != nil {
+		return nil, 0, err
+	}
+	return trace, size, nil
+}
+
+func (g *gethTraceBackend) traceOpCodesTracer(txHash string) (map[string]interface{}, int64, error) {
+	var trace map[string]interface{}
+	size, err := g.callTraceRaw(&trace, txHash)
+	if err != nil {
+		return nil, 0, err
+	}
+	return trace, size, nil
+}
+
+// callTraceRaw calls debug_traceTransaction and returns size of the response, which is used to account memory used by traces
+func (g *gethTraceBackend) callTraceRaw(result interface{}, args ...interface{}) (int64, error) {
+	var raw json.RawMessage
+	if err := g.rpcClient.Call(&raw, "debug_traceTransaction", args...); err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return 0, err
+	}
+	return int64(len(raw)), nil
+}
+
+// parityTraceBackend traces transactions with trace_transaction, which returns a flat list of calls. Parity traces have
+// no logs, so decoded calls have no events, and no opcodes, so storage access can't be traced.
+type parityTraceBackend struct {
+	rpcClient *rpc.Client
+}
+
+// ParityTraceAction is the action of a single trace_transaction entry, fields depend on its type (call, create, suicide)
+type ParityTraceAction struct {
+	CallType       string `json:"callType"`
+	CreationMethod string `json:"creationMethod"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Gas            string `json:"gas"`
+	Input          string `json:"input"`
+	Init           string `json:"init"`
+	Value          string `json:"value"`
+	// Address, RefundAddress and Balance are only set for suicide (selfdestruct) actions
+	Address       string `json:"address"`
+	RefundAddress string `json:"refundAddress"`
+	Balance       string `json:"balance"`
+}
+
+// ParityTraceResult is the result of a single trace_transaction entry, it's nil if the call failed
+type ParityTraceResult struct {
+	GasUsed string `json:"gasUsed"`
+	Output  string `json:"output"`
+	// Address is the address of created contract, only set for create actions
+	Address string `json:"address"`
+}
+
+// ParityTrace is a single entry of trace_transaction output, TraceAddress is the path to the call in the call tree
+type ParityTrace struct {
+	Type         string             `json:"type"`
+	Action       ParityTraceAction  `json:"action"`
+	Result       *ParityTraceResult `json:"result"`
+	Error        string             `json:"error"`
+	Subtraces    int                `json:"subtraces"`
+	TraceAddress []int              `json:"traceAddress"`
+}
+
+func (p *parityTraceBackend) TraceTransaction(txHash string) (*Trace, TraceSize, error) {
+	var raw json.RawMessage
+	if err := p.rpcClient.Call(&raw, "trace_transaction", txHash); err != nil {
+		return nil, TraceSize{}, err
+	}
+	var traces []ParityTrace
+	if err := json.Unmarshal(raw, &traces); err != nil {
+		return nil, TraceSize{}, errors.Wrap(err, ErrParityTrace)
+	}
+	callTrace, err := ParityToCallTrace(traces)
+	if err != nil {
+		return nil, TraceSize{}, err
+	}
+
+	return &Trace{TxHash: txHash, CallTrace: callTrace}, TraceSize{Total: int64(len(raw))}, nil
+}
+
+// ParityToCallTrace converts flat trace_transaction output into the nested call trace returned by Geth's callTracer, so that
+// it can be decoded with Tracer.DecodeTrace. Block rewards are skipped.
+func ParityToCallTrace(traces []ParityTrace) (*TXCallTraceOutput, error) {
+	type node struct {
+		call     Call
+		children []*node
+	}
+
+	var root *node
+	nodes := map[string]*node{}
+	pathKey := func(path []int) string {
+		return fmt.Sprint(path)
+	}
+
+	for _, trace := range traces {
+		if trace.Type == "reward" {
+			continue
+		}
+		n := &node{call: parityToCall(trace)}
+		if len(trace.TraceAddress) == 0 {
+			if root != nil {
+				return nil, fmt.Errorf("%s: more than one top-level call", ErrParityTrace)
+			}
+			root = n
+		} else {
+			parent, ok := nodes[pathKey(trace.TraceAddress[:len(trace.TraceAddress)-1])]
+			if !ok {
+				return nil, fmt.Errorf("%s: parent of call %v not found", ErrParityTrace, trace.TraceAddress)
+			}
+			parent.children = append(parent.children, n)
+		}
+		nodes[pathKey(trace.TraceAddress)] = n
+	}
+	if root == nil {
+		return nil, errors.New(ErrNoTrace)
+	}
+
+	var toCall func(n *node) Call
+	toCall = func(n *node) Call {
+		call := n.call
+		for _, child := range n.children {
+			call.Calls = append(call.Calls, toCall(child))
+		}
+		return call
+	}
+	call := toCall(root)
+	// like in callTracer output, sub calls of the top-level call are only kept in TXCallTraceOutput.Calls
+	calls := call.Calls
+	call.Calls = nil
+
+	return &TXCallTraceOutput{Call: call, Calls: calls}, nil
+}
+
+// parityToCall converts single trace_transaction entry into call in the format of Geth's callTracer, without sub calls
+func parityToCall(trace ParityTrace) Call {
+	action := trace.Action
+	call := Call{
+		From:  action.From,
+		To:    action.To,
+		Gas:   action.Gas,
+		Input: action.Input,
+		Value: action.Value,
+		Error: trace.Error,
+	}
+	if trace.Result != nil {
+		call.GasUsed = trace.Result.GasUsed
+		call.Output = trace.Result.Output
+	}
+
+	switch trace.Type {
+	case "create":
+		call.Type = strings.ToUpper(action.CreationMethod)
+		if call.Type == "" {
+			call.Type = "CREATE"
+		}
+		call.Input = action.Init
+		if trace.Result != nil {
+			call.To = trace.Result.Address
+		}
+	case "suicide":
+		call.Type = "SELFDESTRUCT"
+		call.From = action.Address
+		call.To = action.RefundAddress
+		call.Value = action.Balance
+	default:
+		call.Type = strings.ToUpper(action.CallType)
+	}
+
+	return call
+}
@@ -0,0 +1,203 @@
+package seth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrReadTraceFile  = "failed to read trace file"
+	ErrParseTraceFile = "failed to parse trace file"
+)
+
+// MarshalCanonicalJSON serializes v as indented JSON, in which keys of all objects (including struct fields) are sorted and all
+// numbers are written as decimal strings. Output doesn't depend on field declaration order or float formatting, so it's stable
+// between runs and can be used for golden-file comparisons.
+func MarshalCanonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	generic, err := unmarshalGenericJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// maps are always marshalled with sorted keys
+	return json.MarshalIndent(canonicalizeJSONValue(generic), "", "   ")
+}
+
+func unmarshalGenericJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// canonicalizeJSONValue replaces all numbers with their canonical string representation
+func canonicalizeJSONValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = canonicalizeJSONValue(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = canonicalizeJSONValue(item)
+		}
+		return value
+	case json.Number:
+		return canonicalNumber(value)
+	default:
+		return value
+	}
+}
+
+// canonicalNumber returns the same string for all representations of the same number (e.g. "1001", "1001.0" and "1.001e3")
+func canonicalNumber(n json.Number) string {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return n.String()
+	}
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(r.FloatString(18), "0"), ".")
+}
+
+// saveTraceAsJson saves trace (or decoded transaction) as JSON file, using canonical format if it's enabled in the config
+func saveTraceAsJson(cfg *Config, v any, dirName, name string) (string, error) {
+	if !cfg.TraceCanonicalJSON {
+		return saveAsJson(v, dirName, name)
+	}
+
+	data, err := MarshalCanonicalJSON(v)
+	if err != nil {
+		return "", err
+	}
+
+	return writeJsonFile(data, dirName, name)
+}
+
+// DiffTraceFiles compares two trace files semantically and returns human-readable list of differences (empty if traces are
+// equivalent). Order of keys and number formatting are ignored, so files saved with and without canonical formatting can be
+// compared. Values of keys listed in ignoredKeys (e.g. "gas_used") are not compared at any nesting level.
+func DiffTraceFiles(expectedPath, actualPath string, ignoredKeys ...string) ([]string, error) {
+	expected, err := os.ReadFile(expectedPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", ErrReadTraceFile, expectedPath)
+	}
+	actual, err := os.ReadFile(actualPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s %s", ErrReadTraceFile, actualPath)
+	}
+
+	return DiffTraces(expected, actual, ignoredKeys...)
+}
+
+// DiffTraces compares two JSON-serialized traces semantically. See DiffTraceFiles for details.
+func DiffTraces(expected, actual []byte, ignoredKeys ...string) ([]string, error) {
+	expectedValue, err := unmarshalGenericJSON(expected)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrParseTraceFile)
+	}
+	actualValue, err := unmarshalGenericJSON(actual)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrParseTraceFile)
+	}
+
+	ignored := make(map[string]bool, len(ignoredKeys))
+	for _, k := range ignoredKeys {
+		ignored[k] = true
+	}
+
+	diffs := make([]string, 0)
+	diffJSONValues("$", canonicalizeJSONValue(expectedValue), canonicalizeJSONValue(actualValue), ignored, &diffs)
+
+	return diffs, nil
+}
+
+func diffJSONValues(path string, expected, actual interface{}, ignored map[string]bool, diffs *[]string) {
+	switch expectedValue := expected.(type) {
+	case map[string]interface{}:
+		actualValue, ok := actual.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected object, got %s", path, formatJSONValue(actual)))
+			return
+		}
+
+		keys := make([]string, 0, len(expectedValue)+len(actualValue))
+		for k := range expectedValue {
+			keys = append(keys, k)
+		}
+		for k := range actualValue {
+			if _, ok := expectedValue[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if ignored[k] {
+				continue
+			}
+			keyPath := fmt.Sprintf("%s.%s", path, k)
+			e, inExpected := expectedValue[k]
+			a, inActual := actualValue[k]
+			switch {
+			case !inActual:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, expected %s", keyPath, formatJSONValue(e)))
+			case !inExpected:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", keyPath, formatJSONValue(a)))
+			default:
+				diffJSONValues(keyPath, e, a, ignored, diffs)
+			}
+		}
+	case []interface{}:
+		actualValue, ok := actual.([]interface{})
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected array, got %s", path, formatJSONValue(actual)))
+			return
+		}
+		if len(expectedValue) != len(actualValue) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %d elements, got %d", path, len(expectedValue), len(actualValue)))
+		}
+		for i := 0; i < len(expectedValue) && i < len(actualValue); i++ {
+			diffJSONValues(fmt.Sprintf("%s[%d]", path, i), expectedValue[i], actualValue[i], ignored, diffs)
+		}
+	default:
+		if expected != actual {
+			*diffs = append(*diffs, fmt.Sprintf("%s: expected %s, got %s", path, formatJSONValue(expected), formatJSONValue(actual)))
+		}
+	}
+}
+
+func formatJSONValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,129 @@
+package seth
+
+// TraceRetentionConfig limits memory used by raw traces kept by the Tracer. By default all traces are kept for the whole
+// lifetime of the client. Zero means no limit.
+type TraceRetentionConfig struct {
+	// MaxTransactions is the maximum number of transactions whose raw traces are kept, oldest ones are released first
+	MaxTransactions int `toml:"max_transactions"`
+	// MaxBytes is the maximum size of raw traces (as received from the node) that are kept, oldest ones are released first
+	MaxBytes int64 `toml:"max_bytes"`
+	// DropOpCodeTraces releases opcode traces (which are the largest ones) as soon as transaction is decoded
+	DropOpCodeTraces bool `toml:"drop_opcode_traces"`
+}
+
+// TraceMemoryStats describes raw traces currently kept by the Tracer
+type TraceMemoryStats struct {
+	Transactions int
+	// Bytes is the size of kept traces as received from the node
+	Bytes int64
+	// Released is the number of traces released so far because of retention limits
+	Released int
+}
+
+// TraceSize is the size of raw trace as received from the node, it's used to enforce trace retention limits
+type TraceSize struct {
+	Total int64
+	// OpCodes is the part of Total taken by opcodes trace, which is released first
+	OpCodes int64
+}
+
+// addTrace stores raw trace and releases oldest ones, if retention limits are exceeded. Trace that was just added is never
+// released, because it still has to be decoded.
+func (t *Tracer) addTrace(txHash string, trace *Trace, size TraceSize) {
+	t.tracesMutex.Lock()
+	defer t.tracesMutex.Unlock()
+
+	if _, ok := t.traces[txHash]; ok {
+		t.removeTraceLocked(txHash)
+	}
+
+	t.traces[txHash] = trace
+	t.traceSizes[txHash] = size
+	t.traceOrder = append(t.traceOrder, txHash)
+	t.tracesBytes += size.Total
+
+	retention := t.Cfg.TraceRetention
+	if retention == nil {
+		return
+	}
+
+	for len(t.traceOrder) > 1 &&
+		((retention.MaxTransactions > 0 && len(t.traceOrder) > retention.MaxTransactions) ||
+			(retention.MaxBytes > 0 && t.tracesBytes > retention.MaxBytes)) {
+		oldest := t.traceOrder[0]
+		t.removeTraceLocked(oldest)
+		t.releasedTraces++
+		L.Trace().
+			Str("Transaction", oldest).
+			Msg("Released raw trace, because trace retention limit was exceeded")
+	}
+}
+
+// dropOpCodesTraceIfEnabled releases opcode trace of given transaction, if it's enabled in the config
+func (t *Tracer) dropOpCodesTraceIfEnabled(txHash string) {
+	if t.Cfg.TraceRetention == nil || !t.Cfg.TraceRetention.DropOpCodeTraces {
+		return
+	}
+
+	t.tracesMutex.Lock()
+	defer t.tracesMutex.Unlock()
+
+	trace, ok := t.traces[txHash]
+	if !ok || trace.OpCodesTrace == nil {
+		return
+	}
+
+	// trace might still be used by someone else, so we replace it instead of modifying it
+	withoutOpCodes := *trace
+	withoutOpCodes.OpCodesTrace = nil
+	t.traces[txHash] = &withoutOpCodes
+
+	size := t.traceSizes[txHash]
+	t.tracesBytes -= size.OpCodes
+	size.Total -= size.OpCodes
+	size.OpCodes = 0
+	t.traceSizes[txHash] = size
+}
+
+// removeTraceLocked removes raw trace of given transaction, caller must hold tracesMutex
+func (t *Tracer) removeTraceLocked(txHash string) {
+	delete(t.traces, txHash)
+	t.tracesBytes -= t.traceSizes[txHash].Total
+	delete(t.traceSizes, txHash)
+	for i, hash := range t.traceOrder {
+		if hash == txHash {
+			t.traceOrder = append(t.traceOrder[:i], t.traceOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// Release removes raw trace and decoded calls of given transaction from memory. Use it in long-running tests once you
+// no longer need the trace (e.g. after asserting on decoded calls).
+func (t *Tracer) Release(txHash string) {
+	t.tracesMutex.Lock()
+	t.removeTraceLocked(txHash)
+	t.tracesMutex.Unlock()
+
+	t.decodedMutex.Lock()
+	delete(t.decodedCalls, txHash)
+	for i, hash := range t.decodedOrder {
+		if hash == txHash {
+			t.decodedOrder = append(t.decodedOrder[:i], t.decodedOrder[i+1:]...)
+			break
+		}
+	}
+	t.decodedMutex.Unlock()
+}
+
+// TraceMemoryStats returns number and size of raw traces that are currently kept in memory
+func (t *Tracer) TraceMemoryStats() TraceMemoryStats {
+	t.tracesMutex.Lock()
+	defer t.tracesMutex.Unlock()
+
+	return TraceMemoryStats{
+		Transactions: len(t.traces),
+		Bytes:        t.tracesBytes,
+		Released:     t.releasedTraces,
+	}
+}
@@ -0,0 +1,91 @@
+package seth
+
+import (
+	"strings"
+)
+
+// DecodedCalls returns decoded calls of all transactions traced in this session, in the order in which transactions were
+// decoded (and calls were made within each transaction)
+func (t *Tracer) DecodedCalls() []*DecodedCall {
+	t.decodedMutex.Lock()
+	defer t.decodedMutex.Unlock()
+
+	calls := make([]*DecodedCall, 0)
+	for _, txHash := range t.decodedOrder {
+		calls = append(calls, t.decodedCalls[txHash]...)
+	}
+
+	return calls
+}
+
+// CallsTo returns all decoded calls made to contract with given name (or label) across all traced transactions
+func (t *Tracer) CallsTo(contractName string) []*DecodedCall {
+	contractName = strings.TrimSuffix(contractName, ".abi")
+
+	return t.filterCalls(func(call *DecodedCall) bool {
+		return t.ContractAddressToNameMap.GetContractName(call.ToAddress) == contractName ||
+			t.ContractAddressToNameMap.GetContractLabel(call.ToAddress) == contractName
+	})
+}
+
+// CallsByMethod returns all decoded calls of given method across all traced transactions. Method can be given as selector
+// ("0x8bda3ad6" or "8bda3ad6"), signature ("traceOneInt(int256)") or name ("traceOneInt").
+func (t *Tracer) CallsByMethod(method string) []*DecodedCall {
+	return t.filterCalls(func(call *DecodedCall) bool {
+		return callMatchesMethod(call, method)
+	})
+}
+
+// CallsToMethod returns all decoded calls of given method (see CallsByMethod) made to contract with given name (or label)
+func (t *Tracer) CallsToMethod(contractName, method string) []*DecodedCall {
+	calls := make([]*DecodedCall, 0)
+	for _, call := range t.CallsTo(contractName) {
+		if callMatchesMethod(call, method) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// EventsByName returns all events with given name ("Transfer") or signature ("Transfer(address,address,uint256)") emitted
+// by decoded calls across all traced transactions
+func (t *Tracer) EventsByName(name string) []DecodedCommonLog {
+	events := make([]DecodedCommonLog, 0)
+	for _, call := range t.DecodedCalls() {
+		for _, event := range call.Events {
+			if event.Signature == name || nameFromSignature(event.Signature) == name {
+				events = append(events, event)
+			}
+		}
+	}
+
+	return events
+}
+
+func (t *Tracer) filterCalls(matches func(call *DecodedCall) bool) []*DecodedCall {
+	calls := make([]*DecodedCall, 0)
+	for _, call := range t.DecodedCalls() {
+		if matches(call) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func callMatchesMethod(call *DecodedCall, method string) bool {
+	if call.Method == method || nameFromSignature(call.Method) == method {
+		return true
+	}
+	selector := strings.ToLower(strings.TrimPrefix(method, "0x"))
+	return len(selector) == 8 && strings.ToLower(call.Signature) == selector
+}
+
+// nameFromSignature returns the name part of a method or event signature, e.g. "Transfer" for "Transfer(address,uint256)"
+func nameFromSignature(signature string) string {
+	if i := strings.Index(signature, "("); i != -1 {
+		return signature[:i]
+	}
+	return signature
+}
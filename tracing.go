@@ -3,6 +3,7 @@ package seth
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
@@ -28,6 +29,8 @@ const (
 	NO_DATA          = "no data"
 
 	CommentMissingABI = "Call not decoded due to missing ABI instance"
+
+	ErrUndecodedCalls = "strict tracing is enabled and some calls could not be decoded"
 )
 
 type Tracer struct {
@@ -38,21 +41,39 @@ type Tracer struct {
 	ContractStore            *ContractStore
 	ContractAddressToNameMap ContractMap
 	decodedCalls             map[string][]*DecodedCall
-	ABIFinder                *ABIFinder
-	tracesMutex              *sync.RWMutex
-	decodedMutex             *sync.RWMutex
+	// hashes of transactions in the order in which they were decoded
+	decodedOrder []string
+	ABIFinder    *ABIFinder
+	tracesMutex  *sync.RWMutex
+	decodedMutex *sync.RWMutex
+	// guards Addresses, which are updated when keys are rotated
+	addressesMutex *sync.RWMutex
+	// used to enforce trace retention limits
+	traceSizes     map[string]TraceSize
+	traceOrder     []string
+	tracesBytes    int64
+	releasedTraces int
+	// addresses of actors other than Seth's keys, whose transactions are traced (e.g. Chainlink nodes)
+	externalActors externalActors
+	// masks sensitive calldata in decoded calls
+	Redactor *Redactor
+	// Backend fetches raw traces, it's selected with trace_backend config option
+	Backend TraceBackend
 }
 
-func (t *Tracer) getTrace(txHash string) *Trace {
-	t.tracesMutex.Lock()
-	defer t.tracesMutex.Unlock()
-	return t.traces[txHash]
+// replaceAddress replaces address of given key, so that transactions sent from rotated key are recognised as client's own
+func (t *Tracer) replaceAddress(keyNum int, addr common.Address) {
+	t.addressesMutex.Lock()
+	defer t.addressesMutex.Unlock()
+	if keyNum < len(t.Addresses) {
+		t.Addresses[keyNum] = addr
+	}
 }
 
-func (t *Tracer) addTrace(txHash string, trace *Trace) {
+func (t *Tracer) getTrace(txHash string) *Trace {
 	t.tracesMutex.Lock()
 	defer t.tracesMutex.Unlock()
-	t.traces[txHash] = trace
+	return t.traces[txHash]
 }
 
 func (t *Tracer) GetDecodedCalls(txHash string) []*DecodedCall {
@@ -70,6 +91,9 @@ func (t *Tracer) GetAllDecodedCalls() map[string][]*DecodedCall {
 func (t *Tracer) AddDecodedCalls(txHash string, calls []*DecodedCall) {
 	t.decodedMutex.Lock()
 	defer t.decodedMutex.Unlock()
+	if _, ok := t.decodedCalls[txHash]; !ok {
+		t.decodedOrder = append(t.decodedOrder, txHash)
+	}
 	t.decodedCalls[txHash] = calls
 }
 
@@ -129,47 +153,63 @@ type Call struct {
 func NewTracer(cs *ContractStore, abiFinder *ABIFinder, cfg *Config, contractAddressToNameMap ContractMap, addresses []common.Address) (*Tracer, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Network.DialTimeout.Duration())
 	defer cancel()
-	c, err := rpc.DialOptions(ctx, cfg.FirstNetworkURL(), rpc.WithHeaders(cfg.RPCHeaders))
+	authOpts, err := cfg.rpcAuthDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := append([]rpc.ClientOption{rpc.WithHeaders(cfg.RPCHeaders)}, authOpts...)
+	dialOpts = append(dialOpts, cfg.stickySessionDialOptions()...)
+	_, jar := cfg.stickySession()
+	if pool := cfg.rpcFailoverPool(); jar != nil || pool != nil {
+		var transport http.RoundTripper
+		if pool != nil {
+			// tracer shares endpoints' health with the client
+			transport = &FailoverTransport{pool: pool}
+		}
+		dialOpts = append(dialOpts, rpc.WithHTTPClient(&http.Client{Transport: transport, Jar: jar}))
+	}
+	c, err := rpc.DialOptions(ctx, cfg.FirstNetworkURL(), dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to '%s' due to: %w", cfg.FirstNetworkURL(), err)
 	}
-	return &Tracer{
+	t := &Tracer{
 		Cfg:                      cfg,
 		rpcClient:                c,
 		traces:                   make(map[string]*Trace),
-		Addresses:                addresses,
+		traceSizes:               make(map[string]TraceSize),
+		Addresses:                append([]common.Address{}, addresses...),
 		ContractStore:            cs,
 		ContractAddressToNameMap: contractAddressToNameMap,
 		decodedCalls:             make(map[string][]*DecodedCall),
 		ABIFinder:                abiFinder,
 		tracesMutex:              &sync.RWMutex{},
 		decodedMutex:             &sync.RWMutex{},
-	}, nil
+		addressesMutex:           &sync.RWMutex{},
+		Redactor:                 NewRedactor(cfg.Redaction),
+		Backend:                  newTraceBackend(cfg, c),
+	}
+	t.registerConfiguredExternalActors(cfg.Network)
+
+	return t, nil
 }
 
+// TraceGethTX traces transaction with configured trace backend (debug_traceTransaction by default, despite the name it
+// works with any backend), decodes all its calls and prints them depending on tracing level
 func (t *Tracer) TraceGethTX(txHash string, revertErr error) error {
-	fourByte, err := t.trace4Byte(txHash)
-	if err != nil {
-		L.Debug().Err(err).Msg("Failed to trace 4byte signatures. Some tracing data might be missing")
-	}
-	opCodesTrace, err := t.traceOpCodesTracer(txHash)
-	if err != nil {
-		L.Debug().Err(err).Msg("Failed to trace opcodes. Some tracing data will be missing")
-	}
+	return t.traceTX(txHash, nil, revertErr)
+}
 
-	callTrace, err := t.traceCallTracer(txHash)
+// traceTX traces transaction and decodes its trace. If transaction was already decoded, logs decoded from its receipt are
+// reused for the main call instead of being decoded again.
+func (t *Tracer) traceTX(txHash string, decoded *DecodedTransaction, revertErr error) error {
+	trace, size, err := t.Backend.TraceTransaction(txHash)
 	if err != nil {
 		return err
 	}
+	t.addTrace(txHash, trace, size)
 
-	t.addTrace(txHash, &Trace{
-		TxHash:       txHash,
-		FourByte:     fourByte,
-		CallTrace:    callTrace,
-		OpCodesTrace: opCodesTrace,
-	})
-
-	decodedCalls, err := t.DecodeTrace(L, *t.getTrace(txHash))
+	decodedCalls, err := t.decodeTrace(L, *trace, receiptDecodedLogs(decoded))
+	t.dropOpCodesTraceIfEnabled(txHash)
 	if err != nil {
 		return err
 	}
@@ -197,51 +237,14 @@ func (t *Tracer) PrintTXTrace(txHash string) error {
 	return nil
 }
 
-func (t *Tracer) trace4Byte(txHash string) (map[string]*TXFourByteMetadataOutput, error) {
-	var trace map[string]int
-	if err := t.rpcClient.Call(&trace, "debug_traceTransaction", txHash, map[string]interface{}{"tracer": "4byteTracer"}); err != nil {
-		return nil, err
-	}
-	out := make(map[string]*TXFourByteMetadataOutput)
-	for k, v := range trace {
-		d := strings.Split(k, "-")
-		callParamsSize, err := strconv.Atoi(d[1])
-		if err != nil {
-			return nil, err
-		}
-		out[d[0]] = &TXFourByteMetadataOutput{Times: v, CallSize: callParamsSize}
-	}
-	return out, nil
-}
-
-func (t *Tracer) traceCallTracer(txHash string) (*TXCallTraceOutput, error) {
-	var trace *TXCallTraceOutput
-	if err := t.rpcClient.Call(
-		&trace,
-		"debug_traceTransaction",
-		txHash,
-		map[string]interface{}{
-			"tracer": "callTracer",
-			"tracerConfig": map[string]interface{}{
-				"withLog": true,
-			},
-		}); err != nil {
-		return nil, err
-	}
-	return trace, nil
-}
-
-func (t *Tracer) traceOpCodesTracer(txHash string) (map[string]interface{}, error) {
-	var trace map[string]interface{}
-	if err := t.rpcClient.Call(&trace, "debug_traceTransaction", txHash); err != nil {
-		return nil, err
-	}
-	return trace, nil
-}
-
 // DecodeTrace decodes the trace of a transaction including all subcalls. It returns a list of decoded calls.
 // Depending on the config it also saves the decoded calls as JSON files.
 func (t *Tracer) DecodeTrace(l zerolog.Logger, trace Trace) ([]*DecodedCall, error) {
+	return t.decodeTrace(l, trace, nil)
+}
+
+// decodeTrace decodes the trace of a transaction, reusing given logs already decoded from its receipt for the main call
+func (t *Tracer) decodeTrace(l zerolog.Logger, trace Trace, receiptLogs decodedLogs) ([]*DecodedCall, error) {
 	var decodedCalls []*DecodedCall
 
 	if t.ContractStore == nil {
@@ -298,7 +301,7 @@ func (t *Tracer) DecodeTrace(l zerolog.Logger, trace Trace) ([]*DecodedCall, err
 		return nil, err
 	}
 
-	decodedMainCall, err := t.decodeCall(common.Hex2Bytes(methods[0]), trace.CallTrace.AsCall())
+	decodedMainCall, err := t.decodeCall(common.Hex2Bytes(methods[0]), trace.CallTrace.AsCall(), receiptLogs)
 	if err != nil {
 		l.Debug().
 			Err(err).
@@ -310,43 +313,51 @@ func (t *Tracer) DecodeTrace(l zerolog.Logger, trace Trace) ([]*DecodedCall, err
 	}
 
 	decodedCalls = append(decodedCalls, decodedMainCall)
+	// decoded calls by their position in the call trace, used to attach storage accesses
+	decodedByIndex := map[int]*DecodedCall{0: decodedMainCall}
+	callIndex := 0
 
 	methodCounter := 0
 	nestingLevel := 1
-	var processCallsFn func(calls []Call, parentSignature string) error
-	processCallsFn = func(calls []Call, parentSignature string) error {
+	var processCallsFn func(calls []Call, parentSignature string, parentIndex int) error
+	processCallsFn = func(calls []Call, parentSignature string, parentIndex int) error {
 		for _, call := range calls {
 			methodCounter++
+			callIndex++
 			if methodCounter >= len(methods) {
 				return errors.New("method counter exceeds the number of methods. This indicates there's a logical error in tracing. Please reach out to Test Tooling team")
 			}
 
 			methodHex := methods[methodCounter]
 			methodByte := common.Hex2Bytes(methodHex)
-			decodedSubCall, err := t.decodeCall(methodByte, call)
+			decodedSubCall, err := t.decodeCall(methodByte, call, nil)
 			if err != nil {
 				l.Debug().
 					Err(err).
 					Str("From", call.From).
 					Str("To", call.To).
 					Msg("Failed to decode sub call")
-				decodedCalls = append(decodedCalls, &DecodedCall{
+				// keep the failed call in the tree, so that its sub calls can still be decoded
+				decodedSubCall = &DecodedCall{
 					CommonData: CommonData{Method: FAILED_TO_DECODE,
 						Input:  map[string]interface{}{"error": FAILED_TO_DECODE},
 						Output: map[string]interface{}{"error": FAILED_TO_DECODE},
 					},
 					FromAddress: call.From,
 					ToAddress:   call.To,
-				})
-				continue
+				}
 			}
 			decodedSubCall.NestingLevel = nestingLevel
 			decodedSubCall.ParentSignature = parentSignature
+			// each call gets its own copy, so that changing parent of one call doesn't change it for its siblings
+			parent := parentIndex
+			decodedSubCall.ParentIndex = &parent
 			decodedCalls = append(decodedCalls, decodedSubCall)
+			decodedByIndex[callIndex] = decodedSubCall
 
 			if len(call.Calls) > 0 {
 				nestingLevel++
-				if err := processCallsFn(call.Calls, methodHex); err != nil {
+				if err := processCallsFn(call.Calls, methodHex, len(decodedCalls)-1); err != nil {
 					return err
 				}
 				nestingLevel--
@@ -355,11 +366,20 @@ func (t *Tracer) DecodeTrace(l zerolog.Logger, trace Trace) ([]*DecodedCall, err
 		return nil
 	}
 
-	err = processCallsFn(trace.CallTrace.Calls, mainSig)
+	err = processCallsFn(trace.CallTrace.Calls, mainSig, 0)
 	if err != nil {
 		return nil, err
 	}
 
+	if t.Cfg.TraceStorage {
+		if err := t.attachStorageAccesses(trace, decodedByIndex); err != nil {
+			l.Warn().
+				Err(err).
+				Str("Transaction", trace.TxHash).
+				Msg("Failed to trace storage access. Decoded calls will have no storage reads and writes")
+		}
+	}
+
 	missingCalls := t.checkForMissingCalls(trace)
 	decodedCalls = append(decodedCalls, missingCalls...)
 
@@ -367,13 +387,16 @@ func (t *Tracer) DecodeTrace(l zerolog.Logger, trace Trace) ([]*DecodedCall, err
 	return decodedCalls, nil
 }
 
-func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call) (*DecodedCall, error) {
+func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call, receiptLogs decodedLogs) (*DecodedCall, error) {
 	var txInput map[string]interface{}
 	var txOutput map[string]interface{}
 	var txEvents []DecodedCommonLog
 
 	var generateDuplicatesComment = func(abiResult ABIFinderResult) string {
 		var comment string
+		if abiResult.InterfaceName() != "" {
+			return fmt.Sprintf("decoded with %s interface - contract ABI is unknown", abiResult.InterfaceName())
+		}
 		if abiResult.DuplicateCount > 0 {
 			comment = fmt.Sprintf("potentially inaccurate - method present in %d other contracts", abiResult.DuplicateCount)
 		}
@@ -383,6 +406,8 @@ func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call) (*DecodedCall, e
 
 	defaultCall := getDefaultDecodedCall()
 
+	verifyContractMapEntry(t.rpcClient, t.Cfg.Network.TxnTimeout.Duration(), t.ContractAddressToNameMap, rawCall.To)
+	resolveClone(t.rpcClient, t.Cfg.Network.TxnTimeout.Duration(), t.ContractAddressToNameMap, rawCall.To)
 	abiResult, err := t.ABIFinder.FindABIByMethod(rawCall.To, byteSignature)
 
 	defaultCall.CommonData.Signature = common.Bytes2Hex(byteSignature)
@@ -395,6 +420,14 @@ func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call) (*DecodedCall, e
 	defaultCall.CallType = rawCall.Type
 	defaultCall.Error = rawCall.Error
 
+	if rawCall.Error != "" && rawCall.Output != "" {
+		if output, err := hexutil.Decode(rawCall.Output); err == nil {
+			if reason, isBuiltIn := DecodeRevertReason(output); isBuiltIn {
+				defaultCall.Error = fmt.Sprintf("%s: %s", rawCall.Error, reason)
+			}
+		}
+	}
+
 	if rawCall.Value != "" && rawCall.Value != "0x0" {
 		decimalValue, err := strconv.ParseInt(strings.TrimPrefix(rawCall.Value, "0x"), 16, 64)
 		if err != nil {
@@ -443,11 +476,24 @@ func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call) (*DecodedCall, e
 			Str("Contract", rawCall.To).
 			Msg("Method not found in any ABI instance. Unable to provide full tracing information")
 
+		// events can still be decoded, if any of known ABIs has them
+		txEvents, err = t.decodeContractLogs(L, rawCall.Logs, abi.ABI{}, "", nil)
+		if err != nil {
+			L.Debug().Err(err).Msg("Failed to decode logs")
+		} else {
+			defaultCall.Events = txEvents
+		}
+
+		if t.Cfg.TraceFallbackDecoding {
+			fallbackDecodeCall(defaultCall, common.FromHex(rawCall.Input))
+		}
+
 		// let's not return the error, as we can still provide some information
 		return defaultCall, nil
 	}
 
 	defaultCall.Method = abiResult.Method.Sig
+	defaultCall.Interface = abiResult.InterfaceName()
 	defaultCall.Signature = common.Bytes2Hex(abiResult.Method.ID)
 
 	txInput, err = decodeTxInputs(L, common.Hex2Bytes(strings.TrimPrefix(rawCall.Input, "0x")), abiResult.Method)
@@ -471,24 +517,29 @@ func (t *Tracer) decodeCall(byteSignature []byte, rawCall Call) (*DecodedCall, e
 
 	}
 
-	txEvents, err = t.decodeContractLogs(L, rawCall.Logs, abiResult.ABI)
+	txEvents, err = t.decodeContractLogs(L, rawCall.Logs, abiResult.ABI, abiResult.ContractName(), receiptLogs)
 	if err != nil {
 		L.Debug().Err(err).Msg("Failed to decode logs")
 	} else {
 		defaultCall.Events = txEvents
 	}
 
+	t.Redactor.redactCommonData(abiResult.ContractName(), &defaultCall.CommonData)
+
 	return defaultCall, nil
 }
 
-func (t *Tracer) isOwnAddress(addr string) bool {
-	for _, a := range t.Addresses {
+// ownKeyNum returns number of client's key with given address or -1 if it's not client's key
+func (t *Tracer) ownKeyNum(addr string) int {
+	t.addressesMutex.RLock()
+	defer t.addressesMutex.RUnlock()
+	for keyNum, a := range t.Addresses {
 		if strings.ToLower(a.Hex()) == addr {
-			return true
+			return keyNum
 		}
 	}
 
-	return false
+	return -1
 }
 
 func (t *Tracer) checkForMissingCalls(trace Trace) []*DecodedCall {
@@ -516,18 +567,6 @@ func (t *Tracer) checkForMissingCalls(trace Trace) []*DecodedCall {
 			Int("4byte signatures", len(trace.FourByte)).
 			Msgf("Number of calls and signatures does not match. There were %d more call that were't debugged", diff)
 
-		unknownCall := &DecodedCall{
-			CommonData: CommonData{Method: NO_DATA,
-				Input:  map[string]interface{}{"warning": NO_DATA},
-				Output: map[string]interface{}{"warning": NO_DATA},
-			},
-			FromAddress: UNKNOWN,
-			ToAddress:   UNKNOWN,
-			Events: []DecodedCommonLog{
-				{Signature: NO_DATA, EventData: map[string]interface{}{"warning": NO_DATA}},
-			},
-		}
-
 		var missingSignatures []string
 		var findSignatureFn func(fourByteSign string, calls []Call) bool
 		findSignatureFn = func(fourByteSign string, calls []Call) bool {
@@ -567,7 +606,20 @@ func (t *Tracer) checkForMissingCalls(trace Trace) []*DecodedCall {
 					Str("Signature", humanName).
 					Msg("Method not found in any ABI instance. Unable to provide any more tracing information")
 
-				missedCalls = append(missedCalls, unknownCall)
+				missedCalls = append(missedCalls, &DecodedCall{
+					CommonData: CommonData{
+						Signature: humanName,
+						Method:    NO_DATA,
+						Input:     map[string]interface{}{"warning": NO_DATA},
+						Output:    map[string]interface{}{"warning": NO_DATA},
+					},
+					FromAddress: UNKNOWN,
+					ToAddress:   UNKNOWN,
+					Comment:     CommentMissingABI,
+					Events: []DecodedCommonLog{
+						{Signature: NO_DATA, EventData: map[string]interface{}{"warning": NO_DATA}},
+					},
+				})
 				continue
 			}
 
@@ -601,9 +653,44 @@ func (t *Tracer) checkForMissingCalls(trace Trace) []*DecodedCall {
 	return []*DecodedCall{}
 }
 
+// UndecodedCalls returns calls that could not be fully decoded: calls to unknown methods, calls for which no ABI was found
+// and calls that failed to decode
+func UndecodedCalls(calls []*DecodedCall) []*DecodedCall {
+	undecoded := make([]*DecodedCall, 0)
+	for _, call := range calls {
+		if call == nil {
+			continue
+		}
+		if call.Method == UNKNOWN || call.Method == FAILED_TO_DECODE || strings.Contains(call.Comment, CommentMissingABI) {
+			undecoded = append(undecoded, call)
+		}
+	}
+
+	return undecoded
+}
+
+// undecodedCallsErr returns an error listing selectors and addresses of all calls that could not be decoded or nil if all were decoded
+func undecodedCallsErr(calls []*DecodedCall) error {
+	undecoded := UndecodedCalls(calls)
+	if len(undecoded) == 0 {
+		return nil
+	}
+
+	details := make([]string, 0, len(undecoded))
+	for _, call := range undecoded {
+		reason := call.Method
+		if strings.Contains(call.Comment, CommentMissingABI) {
+			reason = "missing ABI"
+		}
+		details = append(details, fmt.Sprintf("selector 0x%s to %s (%s)", strings.TrimPrefix(call.Signature, "0x"), call.ToAddress, reason))
+	}
+
+	return fmt.Errorf("%s: %s", ErrUndecodedCalls, strings.Join(details, ", "))
+}
+
 func (t *Tracer) SaveDecodedCallsAsJson(dirname string) error {
 	for txHash, calls := range t.GetAllDecodedCalls() {
-		_, err := saveAsJson(calls, dirname, txHash)
+		_, err := saveTraceAsJson(t.Cfg, calls, dirname, txHash)
 		if err != nil {
 			return err
 		}
@@ -612,34 +699,96 @@ func (t *Tracer) SaveDecodedCallsAsJson(dirname string) error {
 	return nil
 }
 
-func (t *Tracer) decodeContractLogs(l zerolog.Logger, logs []TraceLog, a abi.ABI) ([]DecodedCommonLog, error) {
+// decodeContractLogs decodes and redacts logs of a call. Logs found in receiptLogs were already decoded (and redacted) from
+// transaction receipt with the same ABI, so they are reused.
+func (t *Tracer) decodeContractLogs(l zerolog.Logger, logs []TraceLog, a abi.ABI, abiName string, receiptLogs decodedLogs) ([]DecodedCommonLog, error) {
 	l.Trace().Msg("Decoding events")
 	var eventsParsed []DecodedCommonLog
 	for _, lo := range logs {
+		// anonymous events have no topics, there's no way to tell which event it is
+		if len(lo.Topics) == 0 {
+			continue
+		}
+
+		if decodedLog, ok := receiptLogs.get(lo, abiName); ok {
+			eventsParsed = append(eventsParsed, *decodedLog)
+			l.Trace().Interface("Log", decodedLog).Msg("Transaction log decoded from receipt")
+			continue
+		}
+
+		var decodeErr error
+		var decodedLog *DecodedCommonLog
 		for _, evSpec := range a.Events {
 			if evSpec.ID.Hex() == lo.Topics[0] {
-				l.Trace().Str("Name", evSpec.RawName).Str("Signature", evSpec.Sig).Msg("Unpacking event")
-				eventsMap, topicsMap, err := decodeEventFromLog(l, a, evSpec, lo)
-				if err != nil {
-					return nil, errors.Wrap(err, ErrDecodeLog)
-				}
-				parsedEvent := decodedLogFromMaps(&DecodedCommonLog{}, eventsMap, topicsMap)
-				if decodedLog, ok := parsedEvent.(*DecodedCommonLog); ok {
-					decodedLog.Signature = evSpec.Sig
-					t.mergeLogMeta(decodedLog, lo)
-					eventsParsed = append(eventsParsed, *decodedLog)
-					l.Trace().Interface("Log", parsedEvent).Msg("Transaction log")
-				} else {
-					l.Trace().
-						Str("Actual type", fmt.Sprintf("%T", decodedLog)).
-						Msg("Failed to cast decoded event to DecodedCommonLog")
-				}
+				decodedLog, decodeErr = t.decodeLog(l, a, abiName, evSpec, lo)
+				break
+			}
+		}
+
+		// event might have been emitted by another contract (e.g. implementation called via delegatecall) or the ABI of called
+		// contract is unknown, in that case we look for the event in all known ABIs
+		if decodedLog == nil {
+			decodedLog = t.decodeLogWithAnyABI(l, lo)
+		}
+
+		if decodedLog == nil {
+			if decodeErr != nil {
+				return nil, decodeErr
 			}
+			l.Debug().
+				Str("Topic", lo.Topics[0]).
+				Str("Address", lo.Address).
+				Msg("Event not found in any ABI. Skipping it")
+			continue
 		}
+
+		t.Redactor.redactEvent(decodedLog)
+		eventsParsed = append(eventsParsed, *decodedLog)
+		l.Trace().Interface("Log", decodedLog).Msg("Transaction log")
 	}
 	return eventsParsed, nil
 }
 
+// decodeLogWithAnyABI decodes log using the first ABI from ContractStore, which has event with matching topic and can decode it
+func (t *Tracer) decodeLogWithAnyABI(l zerolog.Logger, lo TraceLog) *DecodedCommonLog {
+	if t.ContractStore == nil {
+		return nil
+	}
+
+	for _, match := range t.ContractStore.FindEventsByTopic(common.HexToHash(lo.Topics[0])) {
+		decodedLog, err := t.decodeLog(l, match.ABI, match.ABIName, match.Event, lo)
+		if err != nil {
+			l.Trace().
+				Err(err).
+				Str("ABI", match.ABIName).
+				Str("Signature", match.Event.Sig).
+				Msg("Failed to decode event with ABI, trying next one")
+			continue
+		}
+		return decodedLog
+	}
+
+	return nil
+}
+
+func (t *Tracer) decodeLog(l zerolog.Logger, a abi.ABI, abiName string, evSpec abi.Event, lo TraceLog) (*DecodedCommonLog, error) {
+	l.Trace().Str("Name", evSpec.RawName).Str("Signature", evSpec.Sig).Str("ABI", abiName).Msg("Unpacking event")
+	eventsMap, topicsMap, err := decodeEventFromLog(l, a, evSpec, lo)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrDecodeLog)
+	}
+	parsedEvent := decodedLogFromMaps(&DecodedCommonLog{}, eventsMap, topicsMap)
+	decodedLog, ok := parsedEvent.(*DecodedCommonLog)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast decoded event to DecodedCommonLog, actual type: %T", parsedEvent)
+	}
+	decodedLog.Signature = evSpec.Sig
+	decodedLog.ABIName = abiName
+	t.mergeLogMeta(decodedLog, lo)
+
+	return decodedLog, nil
+}
+
 // mergeLogMeta add metadata from log
 func (t *Tracer) mergeLogMeta(pe *DecodedCommonLog, l TraceLog) {
 	pe.Address = common.HexToAddress(l.Address)
@@ -648,9 +797,14 @@ func (t *Tracer) mergeLogMeta(pe *DecodedCommonLog, l TraceLog) {
 
 func (t *Tracer) getHumanReadableAddressName(address string) string {
 	if t.ContractAddressToNameMap.IsKnownAddress(address) {
-		address = t.ContractAddressToNameMap.GetContractName(address)
-	} else if t.isOwnAddress(address) {
+		address = t.ContractAddressToNameMap.GetContractLabel(address)
+	} else if keyNum := t.ownKeyNum(address); keyNum != -1 {
 		address = "you"
+		if label := t.Cfg.Network.KeyLabel(keyNum); label != "" {
+			address = fmt.Sprintf("you (%s)", label)
+		}
+	} else if label := t.externalActors.label(address); label != "" {
+		address = label
 	} else {
 		address = "unknown"
 	}
@@ -697,8 +851,23 @@ func (t *Tracer) printDecodedCallData(l zerolog.Logger, calls []*DecodedCall, re
 		for _, e := range dc.Events {
 			l.Debug().
 				Str("Signature", e.Signature).
+				Str("ABI", e.ABIName).
 				Interface(fmt.Sprintf("%s- Log", indentation), e.EventData).Send()
 		}
+		for _, r := range dc.StorageReads {
+			l.Debug().
+				Str("Address", r.Address).
+				Str("Label", r.Label).
+				Str("Value", r.Value).
+				Str(fmt.Sprintf("%s- Storage read", indentation), r.Slot).Send()
+		}
+		for _, w := range dc.StorageWrites {
+			l.Debug().
+				Str("Address", w.Address).
+				Str("Label", w.Label).
+				Str("Value", w.Value).
+				Str(fmt.Sprintf("%s- Storage write", indentation), w.Slot).Send()
+		}
 
 		if revertErr != nil && dc.Error != "" {
 			l.Error().Str(fmt.Sprintf("%s- Revert", indentation), revertErr.Error()).Send()
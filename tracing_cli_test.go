@@ -30,3 +30,18 @@ func TestCLITracing(t *testing.T) {
 	err = sethcmd.RunCLI([]string{"seth", "-n", "Geth", "trace", "-f", file.Name()})
 	require.NoError(t, err, "should have traced transactions")
 }
+
+func TestCLITracingWithOptions(t *testing.T) {
+	c := newClientWithContractMapFromEnv(t)
+	SkipAnvil(t, c)
+
+	tx, txErr := TestEnv.DebugContract.AlwaysRevertsCustomError(c.NewTXOpts())
+	require.NoError(t, txErr, "transaction should have reverted")
+
+	_, err := c.WaitMined(context.Background(), seth.L, c.Client, tx)
+	require.NoError(t, err, "should have waited for transaction to be mined")
+
+	// no env vars are needed when calling command implementation directly
+	err = sethcmd.Trace(seth.ConfigOptions{Path: "seth.toml", NetworkName: "Geth"}, []string{tx.Hash().Hex()})
+	require.NoError(t, err, "should have traced transaction")
+}
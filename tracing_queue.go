@@ -0,0 +1,81 @@
+package seth
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultTracingQueueSizePerWorker = 100
+)
+
+type tracingJob struct {
+	l         zerolog.Logger
+	decoded   *DecodedTransaction
+	revertErr error
+}
+
+// TracingQueue traces decoded transactions asynchronously using a pool of workers, so that tracing doesn't add
+// latency to Decode. Traces land in Tracer's decoded calls (and configured outputs) shortly after Decode returns.
+type TracingQueue struct {
+	client  *Client
+	jobs    chan tracingJob
+	pending *sync.WaitGroup
+	workers *sync.WaitGroup
+	once    *sync.Once
+}
+
+// NewTracingQueue creates a new tracing queue and starts given number of workers
+func NewTracingQueue(client *Client, workers int) *TracingQueue {
+	q := &TracingQueue{
+		client:  client,
+		jobs:    make(chan tracingJob, workers*DefaultTracingQueueSizePerWorker),
+		pending: &sync.WaitGroup{},
+		workers: &sync.WaitGroup{},
+		once:    &sync.Once{},
+	}
+
+	for i := 0; i < workers; i++ {
+		q.workers.Add(1)
+		go q.work()
+	}
+
+	L.Debug().Int("Workers", workers).Msg("Started asynchronous tracing queue")
+
+	return q
+}
+
+func (q *TracingQueue) work() {
+	defer q.workers.Done()
+	for job := range q.jobs {
+		if traceErr := q.client.traceDecodedTransaction(job.l, job.decoded, job.revertErr); traceErr != nil {
+			if err := q.client.handleTracingFailure(traceErr, nil); err != nil {
+				job.l.Error().
+					Err(err).
+					Msg("Asynchronous tracing failed")
+			}
+		}
+		q.pending.Done()
+	}
+}
+
+// Enqueue adds decoded transaction to the tracing queue. It blocks only if the queue is full.
+func (q *TracingQueue) Enqueue(l zerolog.Logger, decoded *DecodedTransaction, revertErr error) {
+	q.pending.Add(1)
+	q.jobs <- tracingJob{l: l, decoded: decoded, revertErr: revertErr}
+}
+
+// Flush blocks until all transactions that were enqueued so far have been traced
+func (q *TracingQueue) Flush() {
+	q.pending.Wait()
+}
+
+// Close flushes the queue and stops all workers. Queue cannot be used after it was closed.
+func (q *TracingQueue) Close() {
+	q.once.Do(func() {
+		q.Flush()
+		close(q.jobs)
+		q.workers.Wait()
+	})
+}
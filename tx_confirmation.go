@@ -0,0 +1,92 @@
+package seth
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// TxConfirmationStrategy_Polling checks transaction receipt every receipt poll interval
+	TxConfirmationStrategy_Polling = "polling"
+	// TxConfirmationStrategy_Subscription checks transaction receipt only when a new block arrives via eth_subscribe("newHeads"),
+	// if RPC doesn't support subscriptions (e.g. HTTP transport) it falls back to polling
+	TxConfirmationStrategy_Subscription = "subscription"
+
+	// MaxSubscriptionReceiptCheckInterval is the longest time WaitMined waits for a new block with subscription strategy
+	// before checking receipt anyway, in case a notification was missed
+	MaxSubscriptionReceiptCheckInterval = 30 * time.Second
+	// newHeadsBufferSize is the number of block headers buffered while receipt is being checked
+	newHeadsBufferSize = 16
+)
+
+// headWaiter waits for new blocks using eth_subscribe("newHeads")
+type headWaiter struct {
+	heads   chan *types.Header
+	sub     ethereum.Subscription
+	maxWait time.Duration
+}
+
+// newHeadWaiter subscribes to new blocks, if subscription confirmation strategy is enabled. It returns nil if it's disabled
+// or subscription fails, in which case WaitMined polls for the receipt.
+func (m *Client) newHeadWaiter(ctx context.Context, l zerolog.Logger) *headWaiter {
+	if m.Cfg.Network.TxConfirmationStrategy != TxConfirmationStrategy_Subscription {
+		return nil
+	}
+
+	heads := make(chan *types.Header, newHeadsBufferSize)
+	sub, err := m.Client.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		l.Debug().
+			Err(err).
+			Msg("Failed to subscribe to new blocks. Polling for transaction receipt instead")
+		return nil
+	}
+
+	maxWait := MaxSubscriptionReceiptCheckInterval
+	if miner := m.Cfg.Network.AutoMineAfter; miner != nil && miner.Duration() > 0 && miner.Duration() < maxWait {
+		// automatic mining is meant for networks without new blocks, so receipt can't wait for them
+		maxWait = miner.Duration()
+	}
+
+	return &headWaiter{heads: heads, sub: sub, maxWait: maxWait}
+}
+
+// wait blocks until a new block arrives, maximum wait time passes or context is done. It returns false if subscription
+// failed, in which case it should no longer be used.
+func (w *headWaiter) wait(ctx context.Context, l zerolog.Logger) bool {
+	timer := time.NewTimer(w.maxWait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	case header := <-w.heads:
+		// drain notifications that arrived while receipt was checked, one receipt check covers all of them
+		for drained := false; !drained; {
+			select {
+			case header = <-w.heads:
+			default:
+				drained = true
+			}
+		}
+		l.Trace().
+			Uint64("Block", header.Number.Uint64()).
+			Msg("New block arrived. Checking transaction receipt")
+	case err := <-w.sub.Err():
+		l.Warn().
+			Err(err).
+			Msg("New blocks subscription failed. Polling for transaction receipt instead")
+		return false
+	}
+
+	return true
+}
+
+func (w *headWaiter) close() {
+	if w != nil {
+		w.sub.Unsubscribe()
+	}
+}
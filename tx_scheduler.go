@@ -0,0 +1,262 @@
+package seth
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	TxClass_Critical   = "critical"
+	TxClass_Bulk       = "bulk"
+	TxClass_Background = "background"
+
+	ErrTxSchedulerClosed = "transaction scheduler is closed"
+	ErrUnknownTxClass    = "unknown transaction class"
+)
+
+// txClassOrder is the order in which classes are dispatched, when more than one of them has a transaction ready
+var txClassOrder = []string{TxClass_Critical, TxClass_Bulk, TxClass_Background}
+
+// TxClassPolicy defines fees and pacing of a transaction class
+type TxClassPolicy struct {
+	// Priority is gas price estimation priority (e.g. fast or slow) used to calculate fees of transactions of the class
+	Priority string
+	// Interval is the minimum time between dispatching two transactions of the class, 0 means they are dispatched immediately
+	Interval time.Duration
+}
+
+// DefaultTxClassPolicies are policies used by NewTxScheduler: critical transactions get fast fees and are dispatched
+// immediately, bulk and background ones use slow fees and are paced
+var DefaultTxClassPolicies = map[string]TxClassPolicy{
+	TxClass_Critical:   {Priority: Priority_Fast},
+	TxClass_Bulk:       {Priority: Priority_Slow, Interval: 100 * time.Millisecond},
+	TxClass_Background: {Priority: Priority_Slow, Interval: time.Second},
+}
+
+// ScheduledTxFn sends a transaction using given transaction options, e.g. a call to contract wrapper
+type ScheduledTxFn func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// ScheduledTxResult is the outcome of a scheduled transaction
+type ScheduledTxResult struct {
+	Class   string
+	Decoded *DecodedTransaction
+	Err     error
+}
+
+type scheduledTx struct {
+	class  string
+	fn     ScheduledTxFn
+	result chan *ScheduledTxResult
+}
+
+// TxScheduler dispatches transactions of a single key by class: critical ones are sent before anything else that is
+// waiting, bulk and background ones are sent no more often than their policy allows. Transactions are sent one at a time,
+// so that all classes share the key's nonce without collisions, but mining is awaited concurrently.
+type TxScheduler struct {
+	client   *Client
+	keyNum   int
+	policies map[string]TxClassPolicy
+
+	mu           *sync.Mutex
+	queues       map[string][]*scheduledTx
+	lastDispatch map[string]time.Time
+	closed       bool
+	wake         chan struct{}
+	done         chan struct{}
+	pending      *sync.WaitGroup
+}
+
+// NewTxScheduler creates a scheduler for transactions of given key with DefaultTxClassPolicies and starts dispatching
+func NewTxScheduler(client *Client, keyNum int) *TxScheduler {
+	return NewTxSchedulerWithPolicies(client, keyNum, DefaultTxClassPolicies)
+}
+
+// NewTxSchedulerWithPolicies creates a scheduler for transactions of given key with custom class policies. Classes missing
+// from policies can't be enqueued.
+func NewTxSchedulerWithPolicies(client *Client, keyNum int, policies map[string]TxClassPolicy) *TxScheduler {
+	s := &TxScheduler{
+		client:       client,
+		keyNum:       keyNum,
+		policies:     policies,
+		mu:           &sync.Mutex{},
+		queues:       make(map[string][]*scheduledTx),
+		lastDispatch: make(map[string]time.Time),
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		pending:      &sync.WaitGroup{},
+	}
+	go s.dispatch()
+
+	L.Debug().
+		Int("KeyNum", keyNum).
+		Interface("Policies", policies).
+		Msg("Started transaction scheduler")
+
+	return s
+}
+
+// Enqueue adds transaction of given class to the queue and returns a channel, which receives the result once transaction
+// is mined and decoded (or fails)
+func (s *TxScheduler) Enqueue(class string, fn ScheduledTxFn) (<-chan *ScheduledTxResult, error) {
+	if _, ok := s.policies[class]; !ok {
+		return nil, fmt.Errorf("%s: %s", ErrUnknownTxClass, class)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, errors.New(ErrTxSchedulerClosed)
+	}
+	tx := &scheduledTx{class: class, fn: fn, result: make(chan *ScheduledTxResult, 1)}
+	s.queues[class] = append(s.queues[class], tx)
+	s.pending.Add(1)
+	s.notify()
+
+	return tx.result, nil
+}
+
+// Send enqueues transaction and blocks until it's mined and decoded
+func (s *TxScheduler) Send(class string, fn ScheduledTxFn) (*DecodedTransaction, error) {
+	result, err := s.Enqueue(class, fn)
+	if err != nil {
+		return nil, err
+	}
+	r := <-result
+
+	return r.Decoded, r.Err
+}
+
+// Pending returns number of transactions of given class waiting to be dispatched
+func (s *TxScheduler) Pending(class string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues[class])
+}
+
+// Close waits until all enqueued transactions are mined and decoded and stops the scheduler. Scheduler cannot be used
+// after it was closed.
+func (s *TxScheduler) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.pending.Wait()
+	close(s.done)
+}
+
+func (s *TxScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *TxScheduler) dispatch() {
+	for {
+		tx, wait := s.next()
+		if tx != nil {
+			s.send(tx)
+			continue
+		}
+
+		var timer <-chan time.Time
+		if wait > 0 {
+			timer = time.After(wait)
+		}
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// next returns transaction that should be dispatched now or, if there's none, how long to wait for the next one
+// (0 means until a new transaction is enqueued). Custom classes are dispatched after built-in ones, in alphabetical order.
+func (s *TxScheduler) next() (*scheduledTx, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	classes := append([]string{}, txClassOrder...)
+	custom := make([]string, 0)
+	for class := range s.queues {
+		if !isBuiltInTxClass(class) {
+			custom = append(custom, class)
+		}
+	}
+	sort.Strings(custom)
+
+	var wait time.Duration
+	now := time.Now()
+	for _, class := range append(classes, custom...) {
+		queue := s.queues[class]
+		if len(queue) == 0 {
+			continue
+		}
+		readyAt := s.lastDispatch[class].Add(s.policies[class].Interval)
+		if !now.Before(readyAt) {
+			s.queues[class] = queue[1:]
+			s.lastDispatch[class] = now
+			return queue[0], 0
+		}
+		if untilReady := readyAt.Sub(now); wait == 0 || untilReady < wait {
+			wait = untilReady
+		}
+	}
+
+	return nil, wait
+}
+
+// send sends transaction with fees of its class and waits for it in the background, so that next transaction can be
+// dispatched right after this one was accepted by the node
+func (s *TxScheduler) send(tx *scheduledTx) {
+	policy := s.policies[tx.class]
+	estimations := s.client.CalculateGasEstimations(s.client.NewGasEstimationRequest(policy.Priority))
+	opts := s.client.NewTXKeyOpts(s.keyNum, feesOpt(estimations))
+
+	L.Debug().
+		Str("Class", tx.class).
+		Str("Priority", policy.Priority).
+		Int("KeyNum", s.keyNum).
+		Interface("Nonce", opts.Nonce).
+		Msg("Dispatching scheduled transaction")
+
+	sent, sendErr := tx.fn(opts)
+	go func() {
+		defer s.pending.Done()
+		decoded, err := s.client.Decode(sent, sendErr)
+		tx.result <- &ScheduledTxResult{Class: tx.class, Decoded: decoded, Err: err}
+	}()
+}
+
+// feesOpt sets fees from estimations, the same way as they are set for new transaction options
+func feesOpt(estimations GasEstimations) TransactOpt {
+	return func(o *bind.TransactOpts) {
+		if o.GasPrice != nil {
+			o.GasPrice = estimations.GasPrice
+			return
+		}
+		o.GasFeeCap = estimations.GasFeeCap
+		o.GasTipCap = estimations.GasTipCap
+	}
+}
+
+func isBuiltInTxClass(class string) bool {
+	for _, c := range txClassOrder {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package seth
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	TxType_Legacy     = "legacy"
+	TxType_DynamicFee = "dynamic_fee"
+
+	ErrDetectTxType = "failed to detect supported transaction type"
+)
+
+// ChainTxTypes is a registry of transaction types that should be used on known chains (by chain ID), because they either
+// reject the other type or misprice it (e.g. zero base fee). It takes precedence over probing the RPC node, when automatic
+// transaction type detection is enabled. You can add or override entries before creating the client.
+var ChainTxTypes = map[int64]string{
+	56:   TxType_Legacy, // BNB Smart Chain
+	97:   TxType_Legacy, // BNB Smart Chain Testnet
+	2021: TxType_Legacy, // Ronin
+}
+
+// DetectTxType returns transaction type that should be used on the network: the one from ChainTxTypes registry if the chain
+// is known, otherwise dynamic fee transactions if latest block has a base fee and eth_feeHistory is supported, and legacy
+// ones if it doesn't.
+func (m *Client) DetectTxType(ctx context.Context) (string, error) {
+	if txType, ok := ChainTxTypes[m.ChainID]; ok {
+		return txType, nil
+	}
+
+	header, err := m.Client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return "", errors.Wrap(err, ErrDetectTxType)
+	}
+	if header.BaseFee == nil || !m.Supports(Capability_FeeHistory) {
+		return TxType_Legacy, nil
+	}
+
+	return TxType_DynamicFee, nil
+}
+
+// selectTxType enables or disables EIP-1559 dynamic fees based on detected transaction type, if automatic detection
+// is enabled. If detection fails, configured value is used.
+func (m *Client) selectTxType() {
+	if !m.Cfg.Network.AutoDetectTxType {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+	txType, err := m.DetectTxType(ctx)
+	if err != nil {
+		L.Warn().
+			Err(err).
+			Bool("EIP1559DynamicFees", m.Cfg.Network.EIP1559DynamicFees).
+			Msg("Failed to detect transaction type supported by the network. Using configured one")
+		return
+	}
+
+	dynamicFees := txType == TxType_DynamicFee
+	if dynamicFees != m.Cfg.Network.EIP1559DynamicFees {
+		L.Info().
+			Str("Transaction type", txType).
+			Int64("ChainID", m.ChainID).
+			Msg("Overriding configured transaction type with the one supported by the network")
+	}
+	m.Cfg.Network.EIP1559DynamicFees = dynamicFees
+}
@@ -0,0 +1,133 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// TxExpiredError is returned when transaction wasn't mined within configured number of blocks (tx_validity_blocks) and was
+// replaced with a cancellation transaction (0 value transfer to self with the same nonce), which was mined instead.
+type TxExpiredError struct {
+	Tx           *types.Transaction
+	Cancellation *types.Transaction
+	// StartBlock is the latest block number when waiting started
+	StartBlock     uint64
+	ValidityBlocks uint64
+}
+
+func (e *TxExpiredError) Error() string {
+	return fmt.Sprintf("transaction %s wasn't mined within %d blocks since block %d and was cancelled with transaction %s",
+		e.Tx.Hash().Hex(), e.ValidityBlocks, e.StartBlock, e.Cancellation.Hash().Hex())
+}
+
+// txValidity tracks the deadline of a transaction and its cancellation
+type txValidity struct {
+	startBlock    uint64
+	deadlineBlock uint64
+	cancellation  *types.Transaction
+}
+
+// newTxValidity returns nil if transactions have no deadline or latest block number couldn't be fetched
+func (m *Client) newTxValidity(ctx context.Context, l zerolog.Logger, timing *InclusionTiming) *txValidity {
+	validityBlocks := m.Cfg.Network.TxValidityBlocks
+	if validityBlocks == 0 {
+		return nil
+	}
+
+	startBlock := timing.StartBlock
+	if startBlock == 0 {
+		var err error
+		startBlock, err = m.Client.BlockNumber(ctx)
+		if err != nil {
+			l.Warn().
+				Err(err).
+				Msg("Failed to get latest block number. Transaction won't be cancelled if it isn't mined in time")
+			return nil
+		}
+	}
+
+	return &txValidity{startBlock: startBlock, deadlineBlock: startBlock + validityBlocks}
+}
+
+// cancelIfExpired sends cancellation transaction if deadline block was reached and transaction wasn't cancelled yet
+func (m *Client) cancelIfExpired(ctx context.Context, l zerolog.Logger, tx *types.Transaction, validity *txValidity) {
+	if validity == nil || validity.cancellation != nil {
+		return
+	}
+
+	current, err := m.Client.BlockNumber(ctx)
+	if err != nil {
+		l.Debug().
+			Err(err).
+			Msg("Failed to get latest block number. Will check transaction deadline again on next poll")
+		return
+	}
+	if current < validity.deadlineBlock {
+		return
+	}
+
+	l.Warn().
+		Uint64("Start block", validity.startBlock).
+		Uint64("Current block", current).
+		Uint64("Validity blocks", m.Cfg.Network.TxValidityBlocks).
+		Msg("Transaction wasn't mined in time. Cancelling it")
+
+	cancellation, err := m.cancelTransaction(ctx, tx)
+	if err != nil {
+		l.Warn().
+			Err(err).
+			Msg("Failed to cancel transaction. Will keep waiting for it to be mined")
+		// don't retry on every poll
+		validity.deadlineBlock = current + 1
+		return
+	}
+	validity.cancellation = cancellation
+}
+
+// cancelTransaction replaces pending transaction with 0 value transfer to self, paying at least twice the original fees.
+// It returns nil, if transaction's nonce was already used (meaning it was mined in the meantime).
+func (m *Client) cancelTransaction(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	if tx.Type() == types.BlobTxType {
+		return nil, errors.New("blob transactions can only be replaced with blob transactions and can't be cancelled")
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	keyNum := -1
+	for i, addr := range m.Addresses {
+		if addr == sender {
+			keyNum = i
+			break
+		}
+	}
+	if keyNum == -1 {
+		return nil, fmt.Errorf("sender address '%s' not found in loaded private keys", sender)
+	}
+
+	gasPrice, gasTipCap, err := m.noOpTxFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice = maxBig(gasPrice, new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(2)))
+	if gasTipCap != nil {
+		gasTipCap = maxBig(gasTipCap, new(big.Int).Mul(tx.GasTipCap(), big.NewInt(2)))
+		gasPrice = maxBig(gasPrice, gasTipCap)
+	}
+
+	cancellation, _, _, err := m.sendNoOpTx(ctx, keyNum, tx.Nonce(), gasPrice, gasTipCap)
+	if err != nil {
+		return nil, err
+	}
+	if cancellation == nil {
+		return nil, errors.New("transaction nonce was already used, transaction was probably mined")
+	}
+
+	return cancellation, nil
+}
@@ -0,0 +1,186 @@
+package seth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrSubscribeTyped   = "failed to subscribe to typed events"
+	ErrTypedEventTarget = "typed event must be a struct"
+
+	// TypedEventsBufferSize is the number of decoded events that can wait in the channel before reading logs is paused
+	TypedEventsBufferSize = 1000
+)
+
+var logType = reflect.TypeOf(types.Log{})
+
+// SubscribeTyped decodes logs of given event emitted by contract at given address directly into T, which is usually an event
+// struct generated by abigen (e.g. link_token.LinkTokenTransfer), so that they can be consumed by code that already uses
+// geth bindings. If T has a 'Raw types.Log' field, it's set to the original log. ABI is taken from Contract Store.
+//
+// Logs subscription is used if node supports it (requires WS connection), otherwise logs are polled with eth_getLogs. Only
+// events emitted after the call are delivered, logs removed due to reorgs are skipped. Call returned function to stop
+// receiving events, it closes the channel. Logs that can't be decoded into T are skipped with a warning.
+func SubscribeTyped[T any](client *Client, address common.Address, abiName, eventName string) (<-chan T, func(), error) {
+	if eventType := reflect.TypeOf((*T)(nil)).Elem(); eventType.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%s: %s, got %s", ErrSubscribeTyped, ErrTypedEventTarget, eventType)
+	}
+	contractAbi, ok := client.ContractStore.GetABI(abiName)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: %s: %s", ErrSubscribeTyped, ErrNoAbiFound, abiName)
+	}
+	query, err := client.ContractStore.EventFilter(abiName, eventName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, ErrSubscribeTyped)
+	}
+	query.Addresses = []common.Address{address}
+
+	contract := bind.NewBoundContract(address, *contractAbi, nil, nil, nil)
+	events := make(chan T, TypedEventsBufferSize)
+	decode := func(ctx context.Context, log types.Log) bool {
+		if log.Removed {
+			return true
+		}
+		var event T
+		if err := contract.UnpackLog(&event, eventName, log); err != nil {
+			L.Warn().
+				Err(err).
+				Str("Event", eventName).
+				Str("Transaction", log.TxHash.Hex()).
+				Msgf("Failed to decode log into %T. Skipping it", event)
+			return true
+		}
+		setRawLog(&event, log)
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+
+	logs := make(chan types.Log, TypedEventsBufferSize)
+	sub, subErr := client.Client.SubscribeFilterLogs(ctx, query, logs)
+	if subErr == nil {
+		L.Debug().
+			Str("Event", eventName).
+			Str("Address", address.Hex()).
+			Msg("Watching typed events using logs subscription")
+		go func() {
+			defer close(done)
+			defer close(events)
+			defer sub.Unsubscribe()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-sub.Err():
+					if err != nil {
+						L.Warn().Err(err).Str("Event", eventName).Msg("Logs subscription failed. Typed events are no longer delivered")
+					}
+					return
+				case log := <-logs:
+					if !decode(ctx, log) {
+						return
+					}
+				}
+			}
+		}()
+		return events, stop, nil
+	}
+
+	// polling starts with the next block, so that only new events are delivered just like with subscription
+	headCtx, headCancel := context.WithTimeout(ctx, client.Cfg.Network.TxnTimeout.Duration())
+	head, err := client.Client.BlockNumber(headCtx)
+	headCancel()
+	if err != nil {
+		cancel()
+		return nil, nil, errors.Wrapf(err, "%s (subscription error: %s)", ErrSubscribeTyped, subErr.Error())
+	}
+
+	L.Debug().
+		Err(subErr).
+		Str("Event", eventName).
+		Str("Address", address.Hex()).
+		Msg("Logs subscription failed. Watching typed events by polling eth_getLogs")
+	go func() {
+		defer close(done)
+		defer close(events)
+		next := head + 1
+		ticker := time.NewTicker(client.ReceiptPollInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				polled, polledUntil, err := client.pollLogs(ctx, query, next)
+				if err != nil {
+					if ctx.Err() == nil {
+						L.Debug().Err(err).Str("Event", eventName).Msg("Failed to poll logs")
+					}
+					continue
+				}
+				for _, log := range polled {
+					if !decode(ctx, log) {
+						return
+					}
+				}
+				next = polledUntil + 1
+			}
+		}
+	}()
+
+	return events, stop, nil
+}
+
+// pollLogs returns logs matching the query from blocks between 'from' and the latest one, together with the last polled block
+func (m *Client) pollLogs(ctx context.Context, query ethereum.FilterQuery, from uint64) ([]types.Log, uint64, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, m.Cfg.Network.TxnTimeout.Duration())
+	defer cancel()
+
+	head, err := m.Client.BlockNumber(pollCtx)
+	if err != nil {
+		return nil, from - 1, err
+	}
+	if head < from {
+		return nil, from - 1, nil
+	}
+	query.FromBlock = new(big.Int).SetUint64(from)
+	query.ToBlock = new(big.Int).SetUint64(head)
+	logs, err := m.Client.FilterLogs(pollCtx, query)
+	if err != nil {
+		return nil, from - 1, err
+	}
+
+	return logs, head, nil
+}
+
+// setRawLog sets 'Raw' field of event structs generated by abigen, UnpackLog leaves it empty
+func setRawLog(target interface{}, log types.Log) {
+	raw := reflect.ValueOf(target).Elem().FieldByName("Raw")
+	if raw.IsValid() && raw.CanSet() && raw.Type() == logType {
+		raw.Set(reflect.ValueOf(log))
+	}
+}
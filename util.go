@@ -50,7 +50,7 @@ func NewEphemeralKeys(addrs int64) ([]string, error) {
 
 // CalculateSubKeyFunding calculates all required params to split funds from the root key to N test keys
 func (m *Client) CalculateSubKeyFunding(addrs, gasPrice, rooKeyBuffer int64) (*FundingDetails, error) {
-	balance, err := m.Client.BalanceAt(context.Background(), m.Addresses[0], nil)
+	balance, err := m.balanceOf(context.Background(), m.Addresses[0], BlockTag_Latest)
 	if err != nil {
 		return nil, err
 	}
@@ -66,14 +66,16 @@ func (m *Client) CalculateSubKeyFunding(addrs, gasPrice, rooKeyBuffer int64) (*F
 
 	networkTransferFee := gasPrice * gasLimit
 	totalFee := new(big.Int).Mul(big.NewInt(networkTransferFee), big.NewInt(addrs))
-	rootKeyBuffer := new(big.Int).Mul(big.NewInt(rooKeyBuffer), big.NewInt(1_000_000_000_000_000_000))
+	// root key buffer is expressed in whole units of native currency
+	oneUnit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(m.NativeCurrency().Decimals)), nil)
+	rootKeyBuffer := new(big.Int).Mul(big.NewInt(rooKeyBuffer), oneUnit)
 	freeBalance := new(big.Int).Sub(balance, big.NewInt(0).Add(totalFee, rootKeyBuffer))
 
 	L.Info().
-		Str("Balance (wei/ether)", fmt.Sprintf("%s/%s", balance.String(), WeiToEther(balance).Text('f', -1))).
-		Str("Total fee (wei/ether)", fmt.Sprintf("%s/%s", totalFee.String(), WeiToEther(totalFee).Text('f', -1))).
-		Str("Free Balance (wei/ether)", fmt.Sprintf("%s/%s", freeBalance.String(), WeiToEther(freeBalance).Text('f', -1))).
-		Str("Buffer (wei/ether)", fmt.Sprintf("%s/%s", rootKeyBuffer.String(), WeiToEther(rootKeyBuffer).Text('f', -1))).
+		Str("Balance", m.FormatNativeAmount(balance)).
+		Str("Total fee", m.FormatNativeAmount(totalFee)).
+		Str("Free Balance", m.FormatNativeAmount(freeBalance)).
+		Str("Buffer", m.FormatNativeAmount(rootKeyBuffer)).
 		Msg("Root key balance")
 
 	if freeBalance.Cmp(big.NewInt(0)) < 0 {
@@ -84,9 +86,9 @@ func (m *Client) CalculateSubKeyFunding(addrs, gasPrice, rooKeyBuffer int64) (*F
 	requiredBalance := big.NewInt(0).Mul(addrFunding, big.NewInt(addrs))
 
 	L.Debug().
-		Str("Funding per ephemeral key (wei/ether)", fmt.Sprintf("%s/%s", addrFunding.String(), WeiToEther(addrFunding).Text('f', -1))).
-		Str("Available balance (wei/ether)", fmt.Sprintf("%s/%s", freeBalance.String(), WeiToEther(freeBalance).Text('f', -1))).
-		Interface("Required balance (wei/ether)", fmt.Sprintf("%s/%s", requiredBalance.String(), WeiToEther(requiredBalance).Text('f', -1))).
+		Str("Funding per ephemeral key", m.FormatNativeAmount(addrFunding)).
+		Str("Available balance", m.FormatNativeAmount(freeBalance)).
+		Str("Required balance", m.FormatNativeAmount(requiredBalance)).
 		Msg("Using hardcoded ephemeral funding")
 
 	if freeBalance.Cmp(requiredBalance) < 0 {
@@ -259,11 +261,19 @@ func (d *Duration) UnmarshalText(input []byte) error {
 }
 
 func saveAsJson(v any, dirName, name string) (string, error) {
-	pwd, err := os.Getwd()
-	if err != nil {
-		return "", err
+	f, _ := json.MarshalIndent(v, "", "   ")
+	return writeJsonFile(f, dirName, name)
+}
+
+func writeJsonFile(data []byte, dirName, name string) (string, error) {
+	dir := dirName
+	if !filepath.IsAbs(dir) {
+		pwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(pwd, dirName)
 	}
-	dir := filepath.Join(pwd, dirName)
 	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
 		err := os.MkdirAll(dir, os.ModePerm)
 		if err != nil {
@@ -271,10 +281,8 @@ func saveAsJson(v any, dirName, name string) (string, error) {
 		}
 	}
 	confPath := filepath.Join(dir, fmt.Sprintf("%s.json", name))
-	f, _ := json.MarshalIndent(v, "", "   ")
-	err = os.WriteFile(confPath, f, 0600)
 
-	return confPath, err
+	return confPath, os.WriteFile(confPath, data, 0600)
 }
 
 func OpenJsonFileAsStruct(path string, v any) error {
@@ -367,6 +375,9 @@ func (p Pragma) String() string {
 // DecodePragmaVersion extracts the pragma version from the bytecode or returns an error if it's not found or can't be decoded.
 // Based on https://www.rareskills.io/post/solidity-metadata
 func DecodePragmaVersion(bytecode string) (Pragma, error) {
+	if len(bytecode) < 4 {
+		return Pragma{}, errors.New(MetadataNotFoundErr)
+	}
 	metadataEndIndex := len(bytecode) - 4
 	metadataLengthHex := bytecode[metadataEndIndex:]
 	metadataLengthByte, err := hex.DecodeString(metadataLengthHex)
@@ -384,7 +395,8 @@ func DecodePragmaVersion(bytecode string) (Pragma, error) {
 	metadataLengthInt := int(metadataByteLengthUint) * 2
 
 	// if we get nonsensical metadata length, it means that metadata section is not present and last 2 bytes do not represent metadata length
-	if metadataLengthInt > len(bytecode) {
+	// metadata has to be preceded by the marker byte
+	if metadataLengthInt+2 > metadataEndIndex {
 		return Pragma{}, errors.New(MetadataNotFoundErr)
 	}
 
@@ -450,5 +462,5 @@ This error could be caused by several issues. Please try these steps to resolve
 5. Conversely, if a gas limit was set manually, try increasing it to a higher value. This adjustment is especially crucial for some Layer 2 solutions that have variable gas limits.
 
 Original error:`
-	return fmt.Errorf("%s\n%s", message, err.Error())
+	return fmt.Errorf("%s\n%w", message, err)
 }
@@ -1,14 +1,23 @@
 package seth_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"errors"
 	network_sub_contract "github.com/smartcontractkit/seth/contracts/bind/sub"
 	"math/big"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/smartcontractkit/seth"
 	"github.com/stretchr/testify/require"
 )
@@ -227,3 +236,319 @@ func TestUtilPendingNonce_InvalidKey(t *testing.T) {
 		require.Error(t, err, "No error, when passing invalid key num")
 	}
 }
+
+func TestUtilCheckCodeSize(t *testing.T) {
+	require.NoError(t, seth.CheckContractCodeSize(make([]byte, seth.MaxContractCodeSize), seth.MaxContractCodeSize))
+	require.NoError(t, seth.CheckInitCodeSize(make([]byte, seth.MaxInitCodeSize), seth.MaxInitCodeSize))
+	require.NoError(t, seth.CheckContractCodeSize(make([]byte, seth.MaxContractCodeSize+1), -1), "negative limit should disable the check")
+
+	err := seth.CheckContractCodeSize(make([]byte, seth.MaxContractCodeSize+10), seth.MaxContractCodeSize)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), seth.ErrContractCodeSizeExceeded)
+	require.Contains(t, err.Error(), "10 bytes over the limit of 24576 bytes")
+
+	err = seth.CheckInitCodeSize(make([]byte, seth.MaxInitCodeSize+100), seth.MaxInitCodeSize)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), seth.ErrInitCodeSizeExceeded)
+	require.Contains(t, err.Error(), "100 bytes over the limit of 49152 bytes")
+}
+
+func TestUtilNativeCurrencyFormat(t *testing.T) {
+	require.Equal(t, "1500000000000000000 wei / 1.5 ETH", seth.DefaultNativeCurrency.Format(big.NewInt(1_500_000_000_000_000_000)))
+	require.Equal(t, "AVAX", seth.NativeCurrencyForChain(43113).Symbol)
+	require.Equal(t, seth.DefaultNativeCurrency, seth.NativeCurrencyForChain(123456789), "unknown chain should use default currency")
+
+	eightDecimals := seth.NativeCurrency{Symbol: "MYT", Decimals: 8, BaseUnit: "base units"}
+	require.Equal(t, "250000000 base units / 2.5 MYT", eightDecimals.Format(big.NewInt(250_000_000)))
+}
+
+func TestUtilSafeTransactionSignatures(t *testing.T) {
+	stx := seth.NewSafeTransaction(common.HexToAddress("0x1"), nil, nil, seth.SafeOperation_Call, big.NewInt(0))
+	stx.Hash = crypto.Keccak256Hash([]byte("safe transaction"))
+
+	keys := make([]*ecdsa.PrivateKey, 0)
+	for i := 0; i < 3; i++ {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err, "failed to generate key")
+		require.NoError(t, stx.Sign(key), "failed to sign safe transaction")
+		keys = append(keys, key)
+	}
+
+	signers := stx.Signers()
+	require.Len(t, signers, len(keys), "incorrect number of signers")
+	encoded := stx.EncodedSignatures()
+	require.Len(t, encoded, len(keys)*crypto.SignatureLength, "incorrect length of encoded signatures")
+
+	for i, signer := range signers {
+		if i > 0 {
+			require.Equal(t, -1, bytes.Compare(signers[i-1].Bytes(), signer.Bytes()), "signers should be sorted in ascending order")
+		}
+		sig := common.CopyBytes(encoded[i*crypto.SignatureLength : (i+1)*crypto.SignatureLength])
+		require.Contains(t, []byte{27, 28}, sig[crypto.RecoveryIDOffset], "v should be 27 or 28")
+		sig[crypto.RecoveryIDOffset] -= 27
+		pub, err := crypto.SigToPub(stx.Hash.Bytes(), sig)
+		require.NoError(t, err, "failed to recover signer")
+		require.Equal(t, signer, crypto.PubkeyToAddress(*pub), "signature should belong to signer at the same position")
+	}
+}
+
+func TestUtilParseBlockTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected *big.Int
+		err      bool
+	}{
+		{tag: "", expected: nil},
+		{tag: "latest", expected: nil},
+		{tag: "pending", expected: big.NewInt(int64(rpc.PendingBlockNumber))},
+		{tag: "Finalized", expected: big.NewInt(int64(rpc.FinalizedBlockNumber))},
+		{tag: "safe", expected: big.NewInt(int64(rpc.SafeBlockNumber))},
+		{tag: "earliest", expected: big.NewInt(0)},
+		{tag: "123", expected: big.NewInt(123)},
+		{tag: "0x10", expected: big.NewInt(16)},
+		{tag: "-1", err: true},
+		{tag: "newest", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.tag, func(t *testing.T) {
+			block, err := seth.ParseBlockTag(tc.tag)
+			if tc.err {
+				require.Error(t, err, "expected error")
+				require.Contains(t, err.Error(), seth.ErrInvalidBlockTag, "incorrect error")
+				return
+			}
+			require.NoError(t, err, "failed to parse block tag")
+			require.Equal(t, tc.expected, block, "incorrect block")
+		})
+	}
+}
+
+func TestUtilValidateCalldata(t *testing.T) {
+	parsedABI, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}]`))
+	require.NoError(t, err, "failed to parse ABI")
+
+	store, err := seth.NewContractStore("", "")
+	require.NoError(t, err, "failed to create contract store")
+	store.AddABI("Token", parsedABI)
+
+	token := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	client := &seth.Client{
+		Cfg:                      &seth.Config{CalldataValidation: &seth.CalldataValidationConfig{}},
+		ContractStore:            store,
+		ContractAddressToNameMap: seth.NewContractMap(map[string]string{strings.ToLower(token.Hex()): "Token"}),
+	}
+
+	pack := func(args ...interface{}) []byte {
+		data, err := parsedABI.Pack("transfer", args...)
+		require.NoError(t, err, "failed to pack calldata")
+		return data
+	}
+
+	recipient := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	require.NoError(t, client.ValidateCalldata(token, pack(recipient, big.NewInt(100))), "valid calldata should pass")
+	require.NoError(t, client.ValidateCalldata(recipient, pack(common.Address{}, big.NewInt(100))), "calldata to unknown contract should not be validated")
+
+	err = client.ValidateCalldata(token, pack(common.Address{}, big.NewInt(100)))
+	require.ErrorContains(t, err, "argument 'to' is a zero address", "zero address should be reported")
+
+	client.Cfg.CalldataValidation.ZeroAddressAllowedFor = []string{"to"}
+	require.NoError(t, client.ValidateCalldata(token, pack(common.Address{}, big.NewInt(100))), "zero address should be allowed by argument name")
+
+	swapped := pack(common.BigToAddress(big.NewInt(1_000_000)), new(big.Int).SetBytes(recipient.Bytes()))
+	err = client.ValidateCalldata(token, swapped)
+	require.ErrorContains(t, err, "arguments 'to' and 'amount' were probably swapped", "swapped arguments should be reported")
+
+	err = client.ValidateCalldata(token, append(pack(recipient, big.NewInt(100)), 0x01))
+	require.ErrorContains(t, err, "not canonically encoded", "trailing bytes should be reported")
+
+	err = client.ValidateCalldata(token, []byte{0xde, 0xad, 0xbe, 0xef})
+	require.ErrorContains(t, err, "selector 0xdeadbeef not found in contract ABI", "unknown selector should be reported")
+}
+
+func TestUtilFundingErrorListsFailedTransfers(t *testing.T) {
+	root := common.HexToAddress("0x1")
+	report := &seth.FundingReport{
+		RootAddress: root,
+		Transfers: []seth.FundingTransfer{
+			{From: root, To: common.HexToAddress("0x2"), Amount: big.NewInt(10)},
+			{From: root, To: common.HexToAddress("0x3"), Amount: big.NewInt(0), Err: errors.New("nonce too low")},
+			{From: root, To: common.HexToAddress("0x4"), Amount: big.NewInt(0), Skipped: true},
+		},
+	}
+	require.Len(t, report.FailedTransfers(), 1, "incorrect number of failed transfers")
+
+	fundingErr := &seth.FundingError{Report: report}
+	require.Contains(t, fundingErr.Error(), "1 of 3 funding transfers failed", "error should contain number of failed transfers")
+	require.Contains(t, fundingErr.Error(), common.HexToAddress("0x3").Hex()+": nonce too low", "error should list failed transfer")
+	require.NotContains(t, fundingErr.Error(), common.HexToAddress("0x2").Hex(), "error shouldn't list successful transfer")
+
+	fundingErr.RollbackErr = errors.New("insufficient funds")
+	require.Contains(t, fundingErr.Error(), seth.ErrFundingRollbackFailed, "error should mention failed rollback")
+}
+
+func TestUtilTxJournalSurvivesRestart(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	require.NoError(t, err, "failed to generate key")
+	from := crypto.PubkeyToAddress(pk.PublicKey)
+	signer := types.LatestSignerForChainID(big.NewInt(1337))
+
+	newEntry := func(nonce uint64, gasPrice int64, purpose string) seth.TxJournalEntry {
+		tx, err := types.SignNewTx(pk, signer, &types.LegacyTx{Nonce: nonce, Gas: 21_000, GasPrice: big.NewInt(gasPrice)})
+		require.NoError(t, err, "failed to sign transaction")
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err, "failed to encode transaction")
+		return seth.TxJournalEntry{Hash: tx.Hash(), From: from, Nonce: nonce, Purpose: purpose, RawTx: raw}
+	}
+
+	path := filepath.Join(t.TempDir(), "journal", "txs.jsonl")
+	journal, err := seth.NewTxJournal(path)
+	require.NoError(t, err, "failed to create journal")
+	require.Empty(t, journal.Orphaned(), "new journal shouldn't have orphaned transactions")
+
+	require.NoError(t, journal.Add(newEntry(0, 1, "first")))
+	require.NoError(t, journal.Add(newEntry(1, 1, "second")))
+	require.NoError(t, journal.Add(newEntry(1, 2, "bump of second")))
+	require.NoError(t, journal.Add(newEntry(2, 1, "third")))
+	require.NoError(t, journal.Done(from, 0))
+	require.Len(t, journal.Pending(), 3, "incorrect number of pending transactions")
+	// simulate crash, journal is not closed
+
+	restarted, err := seth.NewTxJournal(path)
+	require.NoError(t, err, "failed to reopen journal")
+	orphaned := restarted.Orphaned()
+	require.Len(t, orphaned, 3, "incorrect number of orphaned transactions")
+	require.Equal(t, uint64(1), orphaned[0].Nonce, "orphaned transactions should be sorted by nonce")
+	require.Equal(t, uint64(2), orphaned[2].Nonce, "orphaned transactions should be sorted by nonce")
+
+	tx, err := orphaned[0].Transaction()
+	require.NoError(t, err, "failed to decode journaled transaction")
+	require.Equal(t, orphaned[0].Hash, tx.Hash(), "decoded transaction should have the same hash")
+
+	// mining any transaction with nonce 1 resolves both the original and the bumped one
+	require.NoError(t, restarted.Done(from, 1))
+	require.Len(t, restarted.Pending(), 1, "only transaction with nonce 2 should be pending")
+	require.NoError(t, restarted.Close())
+}
+
+func TestUtilDecodePanic(t *testing.T) {
+	selector := crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+	panicData := func(code int64) []byte {
+		return append(append([]byte{}, selector...), common.LeftPadBytes(big.NewInt(code).Bytes(), 32)...)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		isPanic  bool
+		expected string
+	}{
+		{name: "assert", data: panicData(0x01), isPanic: true, expected: "panic: assertion failed (code: 0x1)"},
+		{name: "overflow", data: panicData(0x11), isPanic: true, expected: "panic: arithmetic overflow or underflow (code: 0x11)"},
+		{name: "division by zero", data: panicData(0x12), isPanic: true, expected: "panic: division or modulo by zero (code: 0x12)"},
+		{name: "index out of bounds", data: panicData(0x32), isPanic: true, expected: "panic: array index out of bounds (code: 0x32)"},
+		{name: "unknown code", data: panicData(0x99), isPanic: true, expected: "panic: unknown panic code (code: 0x99)"},
+		{name: "Error(string)", data: append(crypto.Keccak256([]byte("Error(string)"))[:4], make([]byte, 32)...), isPanic: false},
+		{name: "too short", data: selector, isPanic: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, isPanic := seth.DecodePanic(tc.data)
+			require.Equal(t, tc.isPanic, isPanic, "incorrect panic detection")
+			require.Equal(t, tc.expected, reason, "incorrect panic reason")
+		})
+	}
+}
+
+func TestUtilDecodeRevertReason(t *testing.T) {
+	stringType, err := abi.NewType("string", "", nil)
+	require.NoError(t, err, "failed to create string type")
+	errorData := func(message string) []byte {
+		packed, err := abi.Arguments{{Type: stringType}}.Pack(message)
+		require.NoError(t, err, "failed to pack revert message")
+		return append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...)
+	}
+	panicData := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], common.LeftPadBytes([]byte{0x12}, 32)...)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		isBuiltIn bool
+		expected  string
+	}{
+		{name: "require message", data: errorData("Ownable: caller is not the owner"), isBuiltIn: true, expected: "revert: Ownable: caller is not the owner"},
+		{name: "empty message", data: errorData(""), isBuiltIn: true, expected: "revert: "},
+		{name: "panic", data: panicData, isBuiltIn: true, expected: "panic: division or modulo by zero (code: 0x12)"},
+		{name: "truncated message", data: errorData("too short")[:40], isBuiltIn: false},
+		{name: "custom error", data: crypto.Keccak256([]byte("CustomErr(uint256)"))[:4], isBuiltIn: false},
+		{name: "no data", data: nil, isBuiltIn: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reason, isBuiltIn := seth.DecodeRevertReason(tc.data)
+			require.Equal(t, tc.isBuiltIn, isBuiltIn, "incorrect built-in error detection")
+			require.Equal(t, tc.expected, reason, "incorrect revert reason")
+		})
+	}
+}
+
+func TestUtilCanonicalTraceJSON(t *testing.T) {
+	calls := []*seth.DecodedCall{
+		{
+			CommonData: seth.CommonData{
+				Method: "emitInts(int256,int128,uint256)",
+				Input:  map[string]interface{}{"first": big.NewInt(1001), "second": 2.5},
+			},
+			GasUsed: 21_000,
+		},
+	}
+
+	first, err := seth.MarshalCanonicalJSON(calls)
+	require.NoError(t, err, "failed to marshal trace")
+	second, err := seth.MarshalCanonicalJSON(calls)
+	require.NoError(t, err, "failed to marshal trace")
+	require.Equal(t, first, second, "canonical JSON should be stable")
+	require.Contains(t, string(first), `"first": "1001"`, "numbers should be stringified")
+	require.Contains(t, string(first), `"second": "2.5"`, "floats should be stringified")
+	require.Less(t, strings.Index(string(first), `"gas_used"`), strings.Index(string(first), `"method"`), "keys should be sorted")
+
+	// same trace with different key order and number formatting
+	reformatted := `[{"method": "emitInts(int256,int128,uint256)", "input": {"second": 2.50, "first": 1.001e3}, "gas_used": 21000, "signature": ""}]`
+	diffs, err := seth.DiffTraces(first, []byte(reformatted))
+	require.NoError(t, err, "failed to diff traces")
+	require.Empty(t, diffs, "traces should be equivalent")
+
+	changed := `[{"method": "emitInts(int256,int128,uint256)", "input": {"first": 1002}, "gas_used": 30000, "signature": ""}]`
+	diffs, err = seth.DiffTraces(first, []byte(changed))
+	require.NoError(t, err, "failed to diff traces")
+	require.Equal(t, []string{
+		`$[0].gas_used: expected "21000", got "30000"`,
+		`$[0].input.first: expected "1001", got "1002"`,
+		`$[0].input.second: missing, expected "2.5"`,
+	}, diffs, "incorrect differences")
+
+	diffs, err = seth.DiffTraces(first, []byte(changed), "gas_used", "input")
+	require.NoError(t, err, "failed to diff traces")
+	require.Empty(t, diffs, "ignored keys should not be compared")
+}
+
+func TestUtilExpectedEventsMissing(t *testing.T) {
+	expected := seth.NewExpectedEvents(&seth.ExpectedEventsConfig{
+		Policy: "fail",
+		Methods: map[string][]string{
+			"transfer":                  {"Transfer"},
+			"LinkToken.transferAndCall": {"Transfer(address,address,uint256)", "Transfer(address,address,uint256,bytes)"},
+		},
+	})
+	expected.Expect("approve(address,uint256)", "Approval")
+	require.Equal(t, seth.ExpectedEventsPolicy_Fail, expected.Policy(), "policy should be normalised")
+
+	emitted := map[string]bool{"Transfer": true, "Transfer(address,address,uint256)": true}
+	require.Empty(t, expected.Missing("LinkToken", "transfer(address,uint256)", emitted), "Transfer was emitted")
+	require.Equal(t, []string{"Transfer"}, expected.Missing("OtherToken", "transfer(address,uint256)", map[string]bool{}), "Transfer should be missing for any contract")
+	require.Equal(t, []string{"Transfer(address,address,uint256,bytes)"}, expected.Missing("LinkToken", "transferAndCall(address,uint256,bytes)", emitted), "ERC677 Transfer should be missing")
+	require.Empty(t, expected.Missing("OtherToken", "transferAndCall(address,uint256,bytes)", map[string]bool{}), "expectation is only for LinkToken")
+	require.Equal(t, []string{"Approval"}, expected.Missing("", "approve(address,uint256)", emitted), "Approval should be missing")
+}
@@ -0,0 +1,86 @@
+package seth
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/pkg/errors"
+)
+
+const (
+	ErrConditionNotMet = "condition wasn't met before context was done"
+	ErrConditionCheck  = "failed to check condition"
+)
+
+// ConditionFn returns true once awaited condition is met. Returning an error stops waiting.
+type ConditionFn func() (bool, error)
+
+// WaitForCondition calls condition every pollInterval (or receipt poll interval, if it's 0) until it returns true, an error
+// or context is done. It's meant for awaiting asynchronous on-chain effects, like oracle rounds or upkeep execution.
+func (m *Client) WaitForCondition(ctx context.Context, pollInterval time.Duration, condition ConditionFn) error {
+	if pollInterval <= 0 {
+		pollInterval = m.ReceiptPollInterval()
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for attempt := 1; ; attempt++ {
+		met, err := condition()
+		if err != nil {
+			return errors.Wrap(err, ErrConditionCheck)
+		}
+		if met {
+			L.Debug().
+				Int("Attempts", attempt).
+				Msg("Condition met")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "%s (checked %d times)", ErrConditionNotMet, attempt)
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForValue calls contract getter every receipt poll interval until it returns expected value or timeout elapses.
+// Getter receives default call options (see NewCallOpts), so it can wrap a method of generated contract wrapper, e.g.:
+//
+//	err := seth.WaitForValue(client, func(opts *bind.CallOpts) (*big.Int, error) {
+//		return consumer.LatestRound(opts)
+//	}, big.NewInt(5), time.Minute)
+//
+// Big integers are compared by value, all other types with reflect.DeepEqual. Errors returned by getter are not retried.
+func WaitForValue[T any](client *Client, getter func(opts *bind.CallOpts) (T, error), expected T, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var last T
+	err := client.WaitForCondition(ctx, 0, func() (bool, error) {
+		value, err := getter(client.NewCallOpts())
+		if err != nil {
+			return false, err
+		}
+		last = value
+		return valuesEqual(value, expected), nil
+	})
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		return errors.Wrapf(err, "last value was %v, but %v was expected", last, expected)
+	}
+
+	return err
+}
+
+func valuesEqual(a, b interface{}) bool {
+	if aInt, ok := a.(*big.Int); ok {
+		if bInt, ok := b.(*big.Int); ok && aInt != nil && bInt != nil {
+			return aInt.Cmp(bInt) == 0
+		}
+	}
+
+	return reflect.DeepEqual(a, b)
+}